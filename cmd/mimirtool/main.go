@@ -26,6 +26,7 @@ var (
 	configCommand         commands.ConfigCommand
 	loadgenCommand        commands.LoadgenCommand
 	logConfig             commands.LoggerConfig
+	parquetExportCommand  commands.ParquetExportCommand
 	promQLCommand         commands.PromQLCommand
 	pushGateway           commands.PushGatewayConfig
 	remoteReadCommand     commands.RemoteReadCommand
@@ -47,6 +48,7 @@ func main() {
 	configCommand.Register(app, envVars)
 	loadgenCommand.Register(app, envVars, prometheus.DefaultRegisterer)
 	logConfig.Register(app, envVars)
+	parquetExportCommand.Register(app, envVars)
 	promQLCommand.Register(app, envVars)
 	pushGateway.Register(app, envVars)
 	remoteReadCommand.Register(app, envVars)
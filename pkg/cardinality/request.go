@@ -8,6 +8,7 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/prometheus/common/model"
@@ -178,6 +179,53 @@ func DecodeLabelValuesRequestFromValues(values url.Values) (*LabelValuesRequest,
 	return parsed, nil
 }
 
+type UnusedMetricsRequest struct {
+	Matchers    []*labels.Matcher
+	CountMethod CountMethod
+	UnusedFor   time.Duration
+	Limit       int
+}
+
+// DecodeUnusedMetricsRequest decodes the input http.Request into an UnusedMetricsRequest.
+// The input http.Request can either be a GET or POST with URL-encoded parameters.
+func DecodeUnusedMetricsRequest(r *http.Request) (*UnusedMetricsRequest, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+
+	return DecodeUnusedMetricsRequestFromValues(r.Form)
+}
+
+// DecodeUnusedMetricsRequestFromValues is like DecodeUnusedMetricsRequest but takes url.Values in input.
+func DecodeUnusedMetricsRequestFromValues(values url.Values) (*UnusedMetricsRequest, error) {
+	var (
+		parsed = &UnusedMetricsRequest{}
+		err    error
+	)
+
+	parsed.Matchers, err = extractSelector(values)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed.UnusedFor, err = extractUnusedFor(values)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed.Limit, err = extractLimit(values)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed.CountMethod, err = extractCountMethod(values)
+	if err != nil {
+		return nil, err
+	}
+
+	return parsed, nil
+}
+
 // extractSelector parses and gets selector query parameter containing a single matcher
 func extractSelector(values url.Values) (matchers []*labels.Matcher, err error) {
 	selectorParams := values["selector"]
@@ -237,6 +285,22 @@ func extractLimit(values url.Values) (limit int, err error) {
 	return limit, nil
 }
 
+// extractUnusedFor parses and validates request param `unused_for` if it's defined, otherwise returns 0.
+func extractUnusedFor(values url.Values) (time.Duration, error) {
+	unusedForParams := values["unused_for"]
+	if len(unusedForParams) == 0 {
+		return 0, nil
+	}
+	if len(unusedForParams) > 1 {
+		return 0, fmt.Errorf("multiple 'unused_for' params are not allowed")
+	}
+	unusedFor, err := model.ParseDuration(unusedForParams[0])
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to parse 'unused_for' param")
+	}
+	return time.Duration(unusedFor), nil
+}
+
 // extractLabelNames parses and gets label_names query parameter containing an array of label values
 func extractLabelNames(values url.Values) ([]model.LabelName, error) {
 	labelNamesParams := values["label_names[]"]
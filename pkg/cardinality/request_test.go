@@ -7,6 +7,7 @@ import (
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
@@ -123,6 +124,48 @@ func TestDecodeLabelValuesRequest(t *testing.T) {
 	})
 }
 
+func TestDecodeUnusedMetricsRequest(t *testing.T) {
+	var (
+		params = url.Values{
+			"selector":     []string{`{job="test"}`},
+			"unused_for":   []string{"30d"},
+			"count_method": []string{"active"},
+			"limit":        []string{"100"},
+		}
+
+		expected = &UnusedMetricsRequest{
+			Matchers: []*labels.Matcher{
+				labels.MustNewMatcher(labels.MatchEqual, "job", "test"),
+			},
+			CountMethod: ActiveMethod,
+			UnusedFor:   30 * 24 * time.Hour,
+			Limit:       100,
+		}
+	)
+
+	t.Run("DecodeUnusedMetricsRequest() GET request", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "http://localhost?"+params.Encode(), nil)
+		require.NoError(t, err)
+
+		actual, err := DecodeUnusedMetricsRequest(req)
+		require.NoError(t, err)
+
+		assert.Equal(t, expected, actual)
+	})
+
+	t.Run("DecodeUnusedMetricsRequestFromValues() with defaults", func(t *testing.T) {
+		actual, err := DecodeUnusedMetricsRequestFromValues(url.Values{})
+		require.NoError(t, err)
+
+		assert.Equal(t, &UnusedMetricsRequest{CountMethod: InMemoryMethod, Limit: defaultLimit}, actual)
+	})
+
+	t.Run("DecodeUnusedMetricsRequestFromValues() with invalid unused_for", func(t *testing.T) {
+		_, err := DecodeUnusedMetricsRequestFromValues(url.Values{"unused_for": []string{"foo"}})
+		require.Error(t, err)
+	})
+}
+
 func TestLabelValuesRequest_String(t *testing.T) {
 	req := &LabelValuesRequest{
 		LabelNames: []model.LabelName{"foo", "bar"},
@@ -0,0 +1,102 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"context"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/runutil"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/thanos-io/objstore"
+
+	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
+	"github.com/grafana/mimir/pkg/storegateway/indexheader"
+)
+
+// newBlockStatsFunc returns the function computing the series statistics of a block recorded in the bucket index,
+// with the stats of up to maxLabelNames label names. The stats are computed from the block index-header, because it
+// holds the label names and values of the block, so there's no need to download the whole index.
+func newBlockStatsFunc(maxLabelNames int, logger log.Logger) bucketindex.BlockStatsFunc {
+	metrics := indexheader.NewStreamBinaryReaderMetrics(nil)
+
+	return func(ctx context.Context, userBkt objstore.InstrumentedBucket, id ulid.ULID) (*bucketindex.BlockStats, error) {
+		meta, err := block.DownloadMeta(ctx, logger, userBkt, id)
+		if err != nil {
+			return nil, err
+		}
+
+		tmpDir, err := os.MkdirTemp("", "bucket-index-block-stats-")
+		if err != nil {
+			return nil, errors.Wrap(err, "create temporary directory for index-header")
+		}
+		defer func() {
+			if err := os.RemoveAll(tmpDir); err != nil {
+				level.Warn(logger).Log("msg", "failed to remove temporary directory for index-header", "dir", tmpDir, "err", err)
+			}
+		}()
+
+		r, err := indexheader.NewStreamBinaryReader(ctx, logger, userBkt, tmpDir, id, mimir_tsdb.DefaultPostingOffsetInMemorySampling, metrics, indexheader.Config{MaxIdleFileHandles: 1})
+		if err != nil {
+			return nil, errors.Wrap(err, "read index-header")
+		}
+		defer runutil.CloseWithLogOnErr(logger, r, "close index-header reader")
+
+		return computeBlockStats(ctx, r, meta.Stats.NumSeries, maxLabelNames)
+	}
+}
+
+// computeBlockStats computes the stats of a block from its index-header.
+func computeBlockStats(ctx context.Context, r indexheader.Reader, numSeries uint64, maxLabelNames int) (*bucketindex.BlockStats, error) {
+	names, err := r.LabelNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &bucketindex.BlockStats{NumSeries: numSeries}
+
+	// Count the distinct values of each label name to find the top label names.
+	labelStats := make([]bucketindex.LabelStats, 0, len(names))
+	for _, name := range names {
+		values, err := r.LabelValuesOffsets(ctx, name, "", nil)
+		if err != nil {
+			return nil, err
+		}
+
+		if name == labels.MetricName {
+			stats.NumMetricNames = uint64(len(values))
+			stats.MetricNames = bucketindex.NewBloomFilter(len(values))
+			for _, v := range values {
+				stats.MetricNames.Add(v.LabelValue)
+			}
+		}
+
+		labelStats = append(labelStats, bucketindex.LabelStats{Name: name, NumValues: uint64(len(values))})
+	}
+
+	slices.SortFunc(labelStats, func(a, b bucketindex.LabelStats) int {
+		if a.NumValues != b.NumValues {
+			if a.NumValues > b.NumValues {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	if len(labelStats) > maxLabelNames {
+		labelStats = labelStats[:maxLabelNames]
+	}
+
+	if len(labelStats) > 0 {
+		stats.Labels = labelStats
+	}
+	return stats, nil
+}
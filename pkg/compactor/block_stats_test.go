@@ -0,0 +1,55 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"context"
+	"path"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storage/tsdb/testutil"
+)
+
+func TestBlockStatsFunc(t *testing.T) {
+	const userID = "user-1"
+
+	bkt, _ := testutil.PrepareFilesystemBucket(t)
+
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+	userBkt := bucket.NewUserBucketClient(userID, bkt, nil)
+
+	blockDir := t.TempDir()
+	blockID, err := block.CreateBlock(ctx, blockDir, []labels.Labels{
+		labels.FromStrings(labels.MetricName, "series_1", "pod", "pod-1"),
+		labels.FromStrings(labels.MetricName, "series_1", "pod", "pod-2"),
+		labels.FromStrings(labels.MetricName, "series_2", "pod", "pod-1"),
+		labels.FromStrings(labels.MetricName, "series_2", "pod", "pod-3"),
+	}, 10, 0, 100, labels.EmptyLabels())
+	require.NoError(t, err)
+	meta, err := block.ReadMetaFromDir(path.Join(blockDir, blockID.String()))
+	require.NoError(t, err)
+	require.NoError(t, block.Upload(ctx, logger, userBkt, path.Join(blockDir, blockID.String()), meta))
+
+	stats, err := newBlockStatsFunc(1, logger)(ctx, userBkt, blockID)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4), stats.NumSeries)
+	assert.Equal(t, uint64(2), stats.NumMetricNames)
+	assert.True(t, stats.MetricNames.MayContain("series_1"))
+	assert.True(t, stats.MetricNames.MayContain("series_2"))
+	require.Len(t, stats.Labels, 1)
+	assert.Equal(t, "pod", stats.Labels[0].Name)
+	assert.Equal(t, uint64(3), stats.Labels[0].NumValues)
+
+	// The stats of a block which doesn't exist can't be computed.
+	_, err = newBlockStatsFunc(1, logger)(ctx, userBkt, ulid.MustNew(1, nil))
+	require.Error(t, err)
+}
@@ -237,7 +237,7 @@ func (c *MultitenantCompactor) createBlockUpload(ctx context.Context, meta *bloc
 	blockMaxTime := timestamp.Time(meta.MaxTime)
 
 	// validate data is within the retention period
-	retention := c.cfgProvider.CompactorBlocksRetentionPeriod(tenantID)
+	retention := c.cfgProvider.CompactorSeriesRetentionPolicies(tenantID).MaxPeriod()
 	if retention > 0 {
 		threshold := time.Now().Add(-retention)
 		if blockMaxTime.Before(threshold) {
@@ -249,7 +249,8 @@ func (c *MultitenantCompactor) createBlockUpload(ctx context.Context, meta *bloc
 	}
 
 	blockDuration := blockMaxTime.Sub(blockMinTime)
-	maxRange := c.compactorCfg.BlockRanges[len(c.compactorCfg.BlockRanges)-1]
+	ranges := tenantBlockRanges(c.compactorCfg.BlockRanges, c.cfgProvider, tenantID)
+	maxRange := ranges[len(ranges)-1]
 	if blockDuration > maxRange {
 		return httpError{
 			message:    fmt.Sprintf("block duration (%v) is larger than max configured compactor time range (%v)", model.Duration(blockDuration), model.Duration(maxRange)),
@@ -583,7 +584,24 @@ func (c *MultitenantCompactor) validateBlock(ctx context.Context, logger log.Log
 	defer c.removeTemporaryBlockDirectory(blockDir)
 
 	// check that all files listed in the metadata are present and the correct size
-	for _, f := range blockMetadata.Thanos.Files {
+	if err := verifyBlockFiles(blockDir, blockMetadata.Thanos.Files); err != nil {
+		return err
+	}
+
+	// validate block
+	checkChunks := c.cfgProvider.CompactorBlockUploadVerifyChunks(userID)
+	err = block.VerifyBlock(ctx, c.logger, blockDir, blockMetadata.MinTime, blockMetadata.MaxTime, checkChunks)
+	if err != nil {
+		return errors.Wrap(err, "error validating block")
+	}
+
+	return nil
+}
+
+// verifyBlockFiles checks that all the files listed in the block metadata are present in the
+// block directory and have the expected size.
+func verifyBlockFiles(blockDir string, files []block.File) error {
+	for _, f := range files {
 		fi, err := os.Stat(filepath.Join(blockDir, filepath.FromSlash(f.RelPath)))
 		if err != nil {
 			return errors.Wrapf(err, "failed to stat %s", f.RelPath)
@@ -597,14 +615,6 @@ func (c *MultitenantCompactor) validateBlock(ctx context.Context, logger log.Log
 			return errors.Errorf("file size mismatch for %s", f.RelPath)
 		}
 	}
-
-	// validate block
-	checkChunks := c.cfgProvider.CompactorBlockUploadVerifyChunks(userID)
-	err = block.VerifyBlock(ctx, c.logger, blockDir, blockMetadata.MinTime, blockMetadata.MaxTime, checkChunks)
-	if err != nil {
-		return errors.Wrap(err, "error validating block")
-	}
-
 	return nil
 }
 
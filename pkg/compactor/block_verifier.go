@@ -0,0 +1,453 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/runutil"
+	"github.com/grafana/dskit/services"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/prometheus/prometheus/tsdb/index"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
+	"github.com/grafana/mimir/pkg/storage/tsdb/downsample"
+	util_log "github.com/grafana/mimir/pkg/util/log"
+)
+
+// quarantineMarkFilter is a block.MetadataFilter which removes the blocks which failed the integrity
+// verification. Quarantined blocks are never compacted, downsampled or rewritten.
+type quarantineMarkFilter struct {
+	bkt objstore.BucketReader
+}
+
+func newQuarantineMarkFilter(bkt objstore.BucketReader) *quarantineMarkFilter {
+	return &quarantineMarkFilter{bkt: bkt}
+}
+
+func (f *quarantineMarkFilter) Filter(ctx context.Context, metas map[ulid.ULID]*block.Meta, _ block.GaugeVec) error {
+	marks, err := block.ListBlockQuarantineMarks(ctx, f.bkt)
+	if err != nil {
+		return errors.Wrap(err, "list quarantine marks")
+	}
+	for id := range marks {
+		delete(metas, id)
+	}
+	return nil
+}
+
+// countQuarantinedBlocks returns the number of quarantined blocks in the index.
+func countQuarantinedBlocks(idx *bucketindex.Index) int {
+	count := 0
+	for _, b := range idx.Blocks {
+		if b.Quarantined {
+			count++
+		}
+	}
+	return count
+}
+
+type BlockVerifierConfig struct {
+	VerificationInterval time.Duration
+	BlocksPerTenant      int
+	DataDir              string // Directory where the blocks are downloaded to be verified.
+}
+
+// BlockVerifier continuously verifies the integrity of a sample of the blocks of each owned tenant,
+// and quarantines the blocks which fail the verification. Quarantined blocks are excluded from
+// queries, compaction and any other rewrite, until the quarantine mark is removed by an operator.
+type BlockVerifier struct {
+	services.Service
+
+	cfg          BlockVerifierConfig
+	cfgProvider  ConfigProvider
+	logger       log.Logger
+	bucketClient objstore.Bucket
+	usersScanner *mimir_tsdb.UsersScanner
+	ownUser      func(userID string) (bool, error)
+
+	// Keep track of when each block has been verified the last time, by tenant. It's only accessed
+	// by the timer service goroutine, so it doesn't need to be guarded by a lock.
+	lastVerified map[string]map[ulid.ULID]time.Time
+
+	// Metrics.
+	runsStarted                prometheus.Counter
+	runsCompleted              prometheus.Counter
+	runsFailed                 prometheus.Counter
+	blocksVerified             prometheus.Counter
+	blocksVerificationFailures prometheus.Counter
+	blocksQuarantined          prometheus.Counter
+}
+
+func NewBlockVerifier(cfg BlockVerifierConfig, bucketClient objstore.Bucket, ownUser func(userID string) (bool, error), cfgProvider ConfigProvider, logger log.Logger, reg prometheus.Registerer) *BlockVerifier {
+	v := &BlockVerifier{
+		cfg:          cfg,
+		bucketClient: bucketClient,
+		usersScanner: mimir_tsdb.NewUsersScanner(bucketClient, ownUser, logger),
+		ownUser:      ownUser,
+		cfgProvider:  cfgProvider,
+		logger:       log.With(logger, "component", "block-verifier"),
+		lastVerified: map[string]map[ulid.ULID]time.Time{},
+		runsStarted: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_block_verification_started_total",
+			Help: "Total number of blocks verification runs started.",
+		}),
+		runsCompleted: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_block_verification_completed_total",
+			Help: "Total number of blocks verification runs successfully completed.",
+		}),
+		runsFailed: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_block_verification_failed_total",
+			Help: "Total number of blocks verification runs failed.",
+		}),
+		blocksVerified: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_blocks_verified_total",
+			Help: "Total number of blocks whose integrity has been verified.",
+		}),
+		blocksVerificationFailures: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_block_verification_failures_total",
+			Help: "Total number of blocks whose integrity couldn't be verified because of an error not related to the block integrity.",
+		}),
+		blocksQuarantined: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_blocks_quarantined_total",
+			Help: "Total number of blocks quarantined because they failed the integrity verification.",
+		}),
+	}
+
+	v.Service = services.NewTimerService(cfg.VerificationInterval, nil, v.ticker, nil)
+
+	return v
+}
+
+func (v *BlockVerifier) ticker(ctx context.Context) error {
+	v.runVerification(ctx)
+
+	return nil
+}
+
+func (v *BlockVerifier) runVerification(ctx context.Context) {
+	logger := log.With(v.logger,
+		"run_id", strconv.FormatInt(time.Now().Unix(), 10),
+		"task", "verify_blocks",
+	)
+
+	level.Info(logger).Log("msg", "started blocks verification")
+	v.runsStarted.Inc()
+
+	err := v.verifyUsers(ctx, logger)
+	switch {
+	case err == nil:
+		level.Info(logger).Log("msg", "successfully completed blocks verification")
+		v.runsCompleted.Inc()
+	case errors.Is(err, context.Canceled):
+		level.Info(logger).Log("msg", "canceled blocks verification", "err", err)
+	default:
+		level.Error(logger).Log("msg", "failed to run blocks verification", "err", err.Error())
+		v.runsFailed.Inc()
+	}
+}
+
+func (v *BlockVerifier) verifyUsers(ctx context.Context, logger log.Logger) error {
+	users, _, err := v.usersScanner.ScanUsers(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to discover users from bucket")
+	}
+
+	// Forget the blocks of the tenants not owned anymore.
+	owned := make(map[string]struct{}, len(users))
+	for _, userID := range users {
+		owned[userID] = struct{}{}
+	}
+	for userID := range v.lastVerified {
+		if _, ok := owned[userID]; !ok {
+			delete(v.lastVerified, userID)
+		}
+	}
+
+	// The blocks are verified one at a time, because downloading and verifying a block is expensive.
+	var lastErr error
+	for _, userID := range users {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if err := v.verifyUser(ctx, userID, util_log.WithUserID(userID, logger)); err != nil {
+			lastErr = errors.Wrapf(err, "failed to verify blocks for user: %s", userID)
+			level.Error(logger).Log("msg", "failed to verify blocks", "user", userID, "err", err)
+		}
+	}
+	return lastErr
+}
+
+func (v *BlockVerifier) verifyUser(ctx context.Context, userID string, userLogger log.Logger) error {
+	idx, err := bucketindex.ReadIndex(ctx, v.bucketClient, userID, v.cfgProvider, userLogger)
+	if errors.Is(err, bucketindex.ErrIndexNotFound) {
+		// The bucket index is created by the blocks cleaner, the tenant's blocks are verified once it exists.
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "read bucket index")
+	}
+
+	lastVerified := v.lastVerified[userID]
+	if lastVerified == nil {
+		lastVerified = map[ulid.ULID]time.Time{}
+		v.lastVerified[userID] = lastVerified
+	}
+
+	candidates := blocksToVerify(idx, lastVerified, v.cfg.BlocksPerTenant)
+	userBucket := bucket.NewUserBucketClient(userID, v.bucketClient, v.cfgProvider)
+
+	for _, id := range candidates {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		integrityErr, err := v.verifyBlock(ctx, userBucket, id, userLogger)
+		if err != nil {
+			// The block is verified again at the next run.
+			v.blocksVerificationFailures.Inc()
+			level.Warn(userLogger).Log("msg", "failed to verify block integrity", "block", id, "err", err)
+			continue
+		}
+
+		v.blocksVerified.Inc()
+		lastVerified[id] = time.Now()
+
+		if integrityErr == nil {
+			level.Debug(userLogger).Log("msg", "verified block integrity", "block", id)
+			continue
+		}
+
+		level.Warn(userLogger).Log("msg", "block failed the integrity verification, quarantining it", "block", id, "err", integrityErr)
+		if err := block.MarkForQuarantine(ctx, userLogger, userBucket, id, integrityErr.Error(), v.blocksQuarantined); err != nil {
+			level.Error(userLogger).Log("msg", "failed to quarantine block", "block", id, "err", err)
+		}
+	}
+
+	return nil
+}
+
+// blocksToVerify returns up to limit blocks of the index to verify, the least recently verified first. The
+// blocks no longer in the index are removed from lastVerified. Blocks marked for deletion, quarantined or in
+// the cold storage are never verified.
+func blocksToVerify(idx *bucketindex.Index, lastVerified map[ulid.ULID]time.Time, limit int) []ulid.ULID {
+	deleted := make(map[ulid.ULID]struct{}, len(idx.BlockDeletionMarks))
+	for _, mark := range idx.BlockDeletionMarks {
+		deleted[mark.ID] = struct{}{}
+	}
+
+	inIndex := make(map[ulid.ULID]struct{}, len(idx.Blocks))
+	candidates := make([]ulid.ULID, 0, len(idx.Blocks))
+	for _, b := range idx.Blocks {
+		inIndex[b.ID] = struct{}{}
+
+		if _, ok := deleted[b.ID]; ok || b.Quarantined || b.Tier != "" {
+			continue
+		}
+		candidates = append(candidates, b.ID)
+	}
+
+	for id := range lastVerified {
+		if _, ok := inIndex[id]; !ok {
+			delete(lastVerified, id)
+		}
+	}
+
+	// Blocks never verified have a zero last verification time, so they're picked first. Ties are
+	// broken by the block ID, so that the oldest blocks are verified first.
+	sort.Slice(candidates, func(i, j int) bool {
+		ti, tj := lastVerified[candidates[i]], lastVerified[candidates[j]]
+		if !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		return candidates[i].Compare(candidates[j]) < 0
+	})
+
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	return candidates
+}
+
+// verifyBlock downloads the block and verifies its integrity. The returned integrityErr is not nil if the
+// block is corrupted, while err is not nil if the block integrity couldn't be verified.
+func (v *BlockVerifier) verifyBlock(ctx context.Context, userBucket objstore.Bucket, id ulid.ULID, logger log.Logger) (integrityErr error, err error) {
+	if err := os.MkdirAll(v.cfg.DataDir, 0750); err != nil {
+		return nil, errors.Wrap(err, "create data directory")
+	}
+
+	tmpDir, err := os.MkdirTemp(v.cfg.DataDir, "block")
+	if err != nil {
+		return nil, errors.Wrap(err, "create temporary block directory")
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			level.Warn(logger).Log("msg", "failed to remove temporary block directory", "path", tmpDir, "err", err)
+		}
+	}()
+
+	blockDir := filepath.Join(tmpDir, id.String())
+	if err := block.Download(ctx, logger, userBucket, id, blockDir); err != nil {
+		return nil, errors.Wrap(err, "download block")
+	}
+
+	meta, err := block.ReadMetaFromDir(blockDir)
+	if err != nil {
+		return errors.Wrap(err, "read block meta"), nil
+	}
+
+	// Check that all files listed in the metadata are present and the correct size.
+	if err := verifyBlockFiles(blockDir, meta.Thanos.Files); err != nil {
+		return err, nil
+	}
+
+	// Check the index consistency, the chunks order and their CRCs. The chunks of the downsampled blocks can't be
+	// iterated like the raw ones, so they're verified separately.
+	downsampled := meta.Thanos.Downsample.Resolution > 0
+	stats, err := block.GatherBlockHealthStats(ctx, logger, blockDir, meta.MinTime, meta.MaxTime, !downsampled)
+	if err == nil && downsampled {
+		err = verifyDownsampledChunks(ctx, blockDir)
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		// The block files have been downloaded and checked above, so failing to open or read them
+		// is a local failure, not a corruption of the block.
+		if isIOError(err) {
+			return nil, errors.Wrap(err, "gather block health stats")
+		}
+		return err, nil
+	}
+
+	// The blocks with issues the compactor handles on its own aren't quarantined: out-of-order chunks are
+	// skipped from compaction and the chunks outside the block time range because of the issue 347 are repaired.
+	if err := stats.CriticalErr(); err != nil {
+		return err, nil
+	}
+	if err := stats.OutOfOrderLabelsErr(); err != nil {
+		return err, nil
+	}
+	if err := stats.OutOfOrderChunksErr(); err != nil {
+		level.Warn(logger).Log("msg", "block has out-of-order chunks, not quarantining it", "block", id, "err", err)
+	}
+	if err := stats.Issue347OutsideChunksErr(); err != nil {
+		level.Warn(logger).Log("msg", "block has repairable chunks outside its time range, not quarantining it", "block", id, "err", err)
+	}
+
+	// Check the stats in the metadata against the actual ones, if tracked.
+	if meta.Stats.NumSeries > 0 && meta.Stats.NumSeries != uint64(stats.TotalSeries) {
+		return fmt.Errorf("number of series in the block meta (%d) doesn't match the index (%d)", meta.Stats.NumSeries, stats.TotalSeries), nil
+	}
+	if meta.Stats.NumChunks > 0 && meta.Stats.NumChunks != uint64(stats.TotalChunks) {
+		return fmt.Errorf("number of chunks in the block meta (%d) doesn't match the index (%d)", meta.Stats.NumChunks, stats.TotalChunks), nil
+	}
+
+	return nil, nil
+}
+
+// verifyDownsampledChunks reads all the chunks of the downsampled block in blockDir, checking their CRCs, and
+// verifies that the aggregates of each chunk can be decoded.
+func verifyDownsampledChunks(ctx context.Context, blockDir string) (returnErr error) {
+	indexr, err := index.NewFileReader(filepath.Join(blockDir, block.IndexFilename))
+	if err != nil {
+		return errors.Wrap(err, "open index file")
+	}
+	defer runutil.CloseWithErrCapture(&returnErr, indexr, "close index reader")
+
+	chunkr, err := chunks.NewDirReader(filepath.Join(blockDir, block.ChunksDirname), downsample.NewPool())
+	if err != nil {
+		return errors.Wrap(err, "open chunks dir")
+	}
+	defer runutil.CloseWithErrCapture(&returnErr, chunkr, "close chunks reader")
+
+	k, v := index.AllPostingsKey()
+	postings, err := indexr.Postings(ctx, k, v)
+	if err != nil {
+		return errors.Wrap(err, "get all postings")
+	}
+
+	var (
+		builder labels.ScratchBuilder
+		chks    []chunks.Meta
+	)
+	for postings.Next() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		id := postings.At()
+		if err := indexr.Series(id, &builder, &chks); err != nil {
+			return errors.Wrapf(err, "read series %d", id)
+		}
+		for _, meta := range chks {
+			if err := verifyAggrChunk(chunkr, meta); err != nil {
+				return errors.Wrapf(err, "verify chunk %d of series %d", meta.Ref, id)
+			}
+		}
+	}
+	return errors.Wrap(postings.Err(), "walk postings")
+}
+
+// verifyAggrChunk checks that the count aggregate of the chunk exists, and that all its aggregates can be iterated.
+func verifyAggrChunk(chunkr *chunks.Reader, meta chunks.Meta) error {
+	chk, _, err := chunkr.ChunkOrIterable(meta)
+	if err != nil {
+		return err
+	}
+	aggr, ok := chk.(*downsample.AggrChunk)
+	if !ok {
+		return errors.Errorf("unexpected chunk encoding %s in a downsampled block", chk.Encoding())
+	}
+
+	for typ := downsample.AggrCount; typ <= downsample.AggrCounter; typ++ {
+		sub, err := aggr.Get(typ)
+		if errors.Is(err, downsample.ErrAggrNotExist) && typ != downsample.AggrCount {
+			continue
+		}
+		if err != nil {
+			return errors.Wrapf(err, "read %s aggregate", typ)
+		}
+
+		samples := 0
+		it := sub.Iterator(nil)
+		for it.Next() != chunkenc.ValNone {
+			samples++
+		}
+		if err := it.Err(); err != nil {
+			return errors.Wrapf(err, "iterate %s aggregate", typ)
+		}
+		if samples == 0 {
+			return errors.Errorf("no samples in %s aggregate", typ)
+		}
+	}
+	return nil
+}
+
+// isIOError returns whether err has been caused by a filesystem or system call failure.
+func isIOError(err error) bool {
+	var pathErr *fs.PathError
+	var errno syscall.Errno
+	return errors.As(err, &pathErr) || errors.As(err, &errno)
+}
@@ -0,0 +1,225 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	"github.com/grafana/mimir/pkg/storage/tsdb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
+	"github.com/grafana/mimir/pkg/storage/tsdb/downsample"
+	mimir_testutil "github.com/grafana/mimir/pkg/storage/tsdb/testutil"
+	"github.com/grafana/mimir/pkg/util/test"
+)
+
+func TestBlockVerifier_ShouldQuarantineCorruptedBlocks(t *testing.T) {
+	const userID = "user-1"
+
+	bucketClient, _ := mimir_testutil.PrepareFilesystemBucket(t)
+	bucketClient = block.BucketWithGlobalMarkers(bucketClient)
+
+	ctx := context.Background()
+	logger := test.NewTestingLogger(t)
+
+	block1 := createTSDBBlock(t, bucketClient, userID, 10, 20, 2, nil)
+	block2 := createTSDBBlock(t, bucketClient, userID, 20, 30, 2, nil)
+	block3 := createTSDBBlock(t, bucketClient, userID, 30, 40, 2, nil)
+	createDeletionMark(t, bucketClient, userID, block3, time.Now())
+
+	// Corrupt a chunk of block2, preserving the size of the segment file.
+	segmentPath := path.Join(userID, block2.String(), block.ChunksDirname, "000001")
+	reader, err := bucketClient.Get(ctx, segmentPath)
+	require.NoError(t, err)
+	segment, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.NoError(t, reader.Close())
+	segment[len(segment)-1] ^= 0xff
+	require.NoError(t, bucketClient.Upload(ctx, segmentPath, bytes.NewReader(segment)))
+
+	cfgProvider := newMockConfigProvider()
+	writeBucketIndex := func() {
+		idx, _, err := bucketindex.NewUpdater(bucketClient, userID, nil, 1, logger).UpdateIndex(ctx, nil)
+		require.NoError(t, err)
+		require.NoError(t, bucketindex.WriteIndex(ctx, bucketClient, userID, nil, idx))
+	}
+	writeBucketIndex()
+
+	reg := prometheus.NewPedanticRegistry()
+	verifier := NewBlockVerifier(BlockVerifierConfig{
+		VerificationInterval: time.Minute,
+		BlocksPerTenant:      10,
+		DataDir:              t.TempDir(),
+	}, bucketClient, tsdb.AllUsers, cfgProvider, logger, reg)
+
+	verifier.runVerification(ctx)
+
+	userBucket := bucket.NewUserBucketClient(userID, bucketClient, nil)
+	quarantined, err := block.ListBlockQuarantineMarks(ctx, userBucket)
+	require.NoError(t, err)
+	assert.Equal(t, map[ulid.ULID]struct{}{block2: {}}, quarantined)
+
+	mark := block.QuarantineMark{}
+	require.NoError(t, block.ReadMarker(ctx, logger, userBucket, block2.String(), &mark))
+	assert.Equal(t, block.QuarantineMarkVersion1, mark.Version)
+	assert.NotEmpty(t, mark.Details)
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_compactor_blocks_verified_total Total number of blocks whose integrity has been verified.
+		# TYPE cortex_compactor_blocks_verified_total counter
+		cortex_compactor_blocks_verified_total 2
+
+		# HELP cortex_compactor_blocks_quarantined_total Total number of blocks quarantined because they failed the integrity verification.
+		# TYPE cortex_compactor_blocks_quarantined_total counter
+		cortex_compactor_blocks_quarantined_total 1
+
+		# HELP cortex_compactor_block_verification_failures_total Total number of blocks whose integrity couldn't be verified because of an error not related to the block integrity.
+		# TYPE cortex_compactor_block_verification_failures_total counter
+		cortex_compactor_block_verification_failures_total 0
+	`),
+		"cortex_compactor_blocks_verified_total",
+		"cortex_compactor_blocks_quarantined_total",
+		"cortex_compactor_block_verification_failures_total",
+	))
+
+	// The quarantined block is excluded from the bucket index consumers and not verified anymore.
+	writeBucketIndex()
+	idx, err := bucketindex.ReadIndex(ctx, bucketClient, userID, nil, logger)
+	require.NoError(t, err)
+	for _, b := range idx.Blocks {
+		assert.Equal(t, b.ID == block2, b.Quarantined)
+	}
+	assert.NotContains(t, ConvertBucketIndexToMetasForCompactionJobPlanning(idx), block2)
+	assert.Equal(t, []ulid.ULID{block1}, blocksToVerify(idx, verifier.lastVerified[userID], 10))
+}
+
+func TestBlocksToVerify(t *testing.T) {
+	block1 := ulid.MustNew(1, nil)
+	block2 := ulid.MustNew(2, nil)
+	block3 := ulid.MustNew(3, nil)
+	block4 := ulid.MustNew(4, nil)
+	block5 := ulid.MustNew(5, nil)
+	block6 := ulid.MustNew(6, nil)
+
+	idx := &bucketindex.Index{
+		Blocks: bucketindex.Blocks{
+			{ID: block1},
+			{ID: block2},
+			{ID: block3},
+			{ID: block4, Quarantined: true},
+			{ID: block5, Tier: block.ColdStorageTier},
+			{ID: block6},
+		},
+		BlockDeletionMarks: bucketindex.BlockDeletionMarks{{ID: block6}},
+	}
+
+	now := time.Now()
+	removed := ulid.MustNew(7, nil)
+	lastVerified := map[ulid.ULID]time.Time{
+		block1:  now.Add(-time.Minute),
+		block2:  now.Add(-time.Hour),
+		removed: now.Add(-time.Hour),
+	}
+
+	assert.Equal(t, []ulid.ULID{block3, block2, block1}, blocksToVerify(idx, lastVerified, 10))
+	assert.Equal(t, []ulid.ULID{block3, block2}, blocksToVerify(idx, lastVerified, 2))
+	assert.NotContains(t, lastVerified, removed)
+}
+
+func TestIsIOError(t *testing.T) {
+	_, err := os.Open(path.Join(t.TempDir(), "missing"))
+	require.Error(t, err)
+
+	assert.True(t, isIOError(errors.Wrap(err, "open index file")))
+	assert.True(t, isIOError(errors.Wrap(syscall.ENOMEM, "mmap")))
+	assert.False(t, isIOError(errors.New("invalid magic number")))
+	assert.False(t, isIOError(nil))
+}
+
+func TestBlockVerifier_ShouldVerifyDownsampledBlocks(t *testing.T) {
+	const userID = "user-1"
+
+	bucketClient, _ := mimir_testutil.PrepareFilesystemBucket(t)
+	bucketClient = block.BucketWithGlobalMarkers(bucketClient)
+
+	ctx := context.Background()
+	logger := test.NewTestingLogger(t)
+
+	createTSDBBlock(t, bucketClient, userID, 0, 2*time.Hour.Milliseconds(), 10, nil)
+
+	cfgProvider := newMockConfigProvider()
+	cfgProvider.downsampling5mAfter[userID] = 24 * time.Hour
+	cfgProvider.downsampling1hAfter[userID] = 48 * time.Hour
+
+	c, _, _, _, _ := prepareWithConfigProvider(t, prepareConfig(t), bucketClient, cfgProvider)
+	userBucket := bucket.NewUserBucketClient(userID, bucketClient, cfgProvider)
+	require.NoError(t, c.downsampleUserBlocks(ctx, userID, userBucket, logger))
+
+	fetcher, err := block.NewMetaFetcher(logger, 1, userBucket, "", nil, nil, nil)
+	require.NoError(t, err)
+	metas, _, err := fetcher.Fetch(ctx)
+	require.NoError(t, err)
+	require.Len(t, metas, 3)
+
+	// Corrupt a chunk of the 1h block, preserving the size of the segment file.
+	var corrupted ulid.ULID
+	for id, meta := range metas {
+		if meta.Thanos.Downsample.Resolution == downsample.ResLevel2 {
+			corrupted = id
+		}
+	}
+	segmentPath := path.Join(corrupted.String(), block.ChunksDirname, "000001")
+	reader, err := userBucket.Get(ctx, segmentPath)
+	require.NoError(t, err)
+	segment, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.NoError(t, reader.Close())
+	segment[len(segment)-1] ^= 0xff
+	require.NoError(t, userBucket.Upload(ctx, segmentPath, bytes.NewReader(segment)))
+
+	idx, _, err := bucketindex.NewUpdater(bucketClient, userID, nil, 1, logger).UpdateIndex(ctx, nil)
+	require.NoError(t, err)
+	require.NoError(t, bucketindex.WriteIndex(ctx, bucketClient, userID, nil, idx))
+
+	reg := prometheus.NewPedanticRegistry()
+	verifier := NewBlockVerifier(BlockVerifierConfig{
+		VerificationInterval: time.Minute,
+		BlocksPerTenant:      10,
+		DataDir:              t.TempDir(),
+	}, bucketClient, tsdb.AllUsers, cfgProvider, logger, reg)
+	verifier.runVerification(ctx)
+
+	// The raw and 5m blocks pass the verification, while the corrupted 1h block is quarantined.
+	quarantined, err := block.ListBlockQuarantineMarks(ctx, userBucket)
+	require.NoError(t, err)
+	assert.Equal(t, map[ulid.ULID]struct{}{corrupted: {}}, quarantined)
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_compactor_blocks_verified_total Total number of blocks whose integrity has been verified.
+		# TYPE cortex_compactor_blocks_verified_total counter
+		cortex_compactor_blocks_verified_total 3
+
+		# HELP cortex_compactor_block_verification_failures_total Total number of blocks whose integrity couldn't be verified because of an error not related to the block integrity.
+		# TYPE cortex_compactor_block_verification_failures_total counter
+		cortex_compactor_block_verification_failures_total 0
+	`),
+		"cortex_compactor_blocks_verified_total",
+		"cortex_compactor_block_verification_failures_total",
+	))
+}
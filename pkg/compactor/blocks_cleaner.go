@@ -8,6 +8,7 @@ package compactor
 import (
 	"context"
 	"fmt"
+	"path"
 	"strconv"
 	"strings"
 	"sync"
@@ -23,10 +24,14 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/thanos-io/objstore"
 
+	"github.com/grafana/mimir/pkg/deadletter"
 	"github.com/grafana/mimir/pkg/storage/bucket"
 	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
 	"github.com/grafana/mimir/pkg/storage/tsdb/block"
 	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
+	"github.com/grafana/mimir/pkg/storage/tsdb/downsample"
+	"github.com/grafana/mimir/pkg/storage/tsdb/metadataindex"
+	"github.com/grafana/mimir/pkg/storage/tsdb/metricsusage"
 	"github.com/grafana/mimir/pkg/util"
 	util_log "github.com/grafana/mimir/pkg/util/log"
 	"github.com/grafana/mimir/pkg/util/validation"
@@ -35,6 +40,7 @@ import (
 const (
 	defaultDeleteBlocksConcurrency       = 16
 	defaultGetDeletionMarkersConcurrency = 16
+	defaultColdStorageMoveConcurrency    = 4
 )
 
 type BlocksCleanerConfig struct {
@@ -46,6 +52,11 @@ type BlocksCleanerConfig struct {
 	GetDeletionMarkersConcurrency int
 	NoBlocksFileCleanupEnabled    bool
 	CompactionBlockRanges         mimir_tsdb.DurationList // Used for estimating compaction jobs.
+	MetadataIndexEnabled          bool                    // Maintain the per-tenant metric metadata index.
+	ColdStorageRewriteInPlace     bool                    // Rewrite the blocks in place instead of moving them to the cold storage bucket.
+	ColdStorageMoveConcurrency    int
+	BlockStatsMaxLabelNames       int // Record the series statistics of the blocks in the bucket index, 0 to disable.
+	BlockStatsMaxTotalBytes       int // Maximum total size of the series statistics in the bucket index, 0 for no limit.
 }
 
 type BlocksCleaner struct {
@@ -59,6 +70,12 @@ type BlocksCleaner struct {
 	ownUser      func(userID string) (bool, error)
 	singleFlight *concurrency.LimitedConcurrencySingleFlight
 
+	// Client used to move blocks to the cold storage, nil if the cold storage is disabled.
+	coldBucketClient objstore.Bucket
+
+	// Computes the series statistics of the blocks in the bucket index, nil if the block stats are disabled.
+	blockStats bucketindex.BlockStatsFunc
+
 	// Keep track of the last owned users.
 	lastOwnedUsers []string
 
@@ -71,8 +88,11 @@ type BlocksCleaner struct {
 	blocksFailedTotal                   prometheus.Counter
 	blocksMarkedForDeletion             prometheus.Counter
 	partialBlocksMarkedForDeletion      prometheus.Counter
+	blocksMovedToColdStorage            prometheus.Counter
+	blocksMovedToColdStorageFailed      prometheus.Counter
 	tenantBlocks                        *prometheus.GaugeVec
 	tenantMarkedBlocks                  *prometheus.GaugeVec
+	tenantQuarantinedBlocks             *prometheus.GaugeVec
 	tenantPartialBlocks                 *prometheus.GaugeVec
 	tenantBucketIndexLastUpdate         *prometheus.GaugeVec
 	bucketIndexCompactionJobs           *prometheus.GaugeVec
@@ -122,6 +142,14 @@ func NewBlocksCleaner(cfg BlocksCleanerConfig, bucketClient objstore.Bucket, own
 			Help:        blocksMarkedForDeletionHelp,
 			ConstLabels: prometheus.Labels{"reason": "partial"},
 		}),
+		blocksMovedToColdStorage: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_blocks_moved_to_cold_storage_total",
+			Help: "Total number of blocks moved to the cold storage.",
+		}),
+		blocksMovedToColdStorageFailed: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_blocks_moved_to_cold_storage_failed_total",
+			Help: "Total number of blocks failed to be moved to the cold storage.",
+		}),
 
 		// The following metrics don't have the "cortex_compactor" prefix because not strictly related to
 		// the compactor. They're just tracked by the compactor because it's the most logical place where these
@@ -134,6 +162,10 @@ func NewBlocksCleaner(cfg BlocksCleanerConfig, bucketClient objstore.Bucket, own
 			Name: "cortex_bucket_blocks_marked_for_deletion_count",
 			Help: "Total number of blocks marked for deletion in the bucket.",
 		}, []string{"user"}),
+		tenantQuarantinedBlocks: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_bucket_blocks_quarantined_count",
+			Help: "Total number of blocks quarantined in the bucket because they failed the integrity verification.",
+		}, []string{"user"}),
 		tenantPartialBlocks: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
 			Name: "cortex_bucket_blocks_partials_count",
 			Help: "Total number of partial blocks.",
@@ -153,6 +185,10 @@ func NewBlocksCleaner(cfg BlocksCleanerConfig, bucketClient objstore.Bucket, own
 		}),
 	}
 
+	if cfg.BlockStatsMaxLabelNames > 0 {
+		c.blockStats = newBlockStatsFunc(cfg.BlockStatsMaxLabelNames, c.logger)
+	}
+
 	c.Service = services.NewTimerService(cfg.CleanupInterval, c.starting, c.ticker, c.stopping)
 
 	return c
@@ -243,6 +279,7 @@ func (c *BlocksCleaner) refreshOwnedUsers(ctx context.Context) ([]string, map[st
 		if !isActive[userID] && !isDeleted[userID] {
 			c.tenantBlocks.DeleteLabelValues(userID)
 			c.tenantMarkedBlocks.DeleteLabelValues(userID)
+			c.tenantQuarantinedBlocks.DeleteLabelValues(userID)
 			c.tenantPartialBlocks.DeleteLabelValues(userID)
 			c.tenantBucketIndexLastUpdate.DeleteLabelValues(userID)
 			c.bucketIndexCompactionJobs.DeleteLabelValues(userID, string(stageSplit))
@@ -309,6 +346,22 @@ func (c *BlocksCleaner) deleteUserMarkedForDeletion(ctx context.Context, userID
 	}
 	c.tenantBucketIndexLastUpdate.DeleteLabelValues(userID)
 
+	// The metric metadata of the tenant is deleted together with its blocks.
+	if err := metadataindex.DeleteIndex(ctx, c.bucketClient, userID, c.cfgProvider); err != nil {
+		return err
+	}
+
+	// The blocks in the cold storage are deleted before the blocks storage ones, so that they're not left
+	// behind if the deletion is interrupted.
+	if c.coldBucketClient != nil && !c.cfg.ColdStorageRewriteInPlace {
+		coldUserBucket := bucket.NewUserBucketClient(userID, c.coldBucketClient, c.cfgProvider)
+		if deleted, err := bucket.DeletePrefix(ctx, coldUserBucket, "", userLogger); err != nil {
+			return errors.Wrap(err, "failed to delete cold storage blocks")
+		} else if deleted > 0 {
+			level.Info(userLogger).Log("msg", "deleted cold storage files for tenant marked for deletion", "count", deleted)
+		}
+	}
+
 	var deletedBlocks, failed int
 	err := userBucket.Iter(ctx, "", func(name string) error {
 		if err := ctx.Err(); err != nil {
@@ -351,6 +404,7 @@ func (c *BlocksCleaner) deleteUserMarkedForDeletion(ctx context.Context, userID
 	// Given all blocks have been deleted, we can also remove the metrics.
 	c.tenantBlocks.DeleteLabelValues(userID)
 	c.tenantMarkedBlocks.DeleteLabelValues(userID)
+	c.tenantQuarantinedBlocks.DeleteLabelValues(userID)
 	c.tenantPartialBlocks.DeleteLabelValues(userID)
 	c.bucketIndexCompactionJobs.DeleteLabelValues(userID, string(stageSplit))
 	c.bucketIndexCompactionJobs.DeleteLabelValues(userID, string(stageMerge))
@@ -389,6 +443,35 @@ func (c *BlocksCleaner) deleteUserMarkedForDeletion(ctx context.Context, userID
 		level.Info(userLogger).Log("msg", "deleted files under "+block.DebugMetas+" for tenant marked for deletion", "count", deleted)
 	}
 
+	if deleted, err := bucket.DeletePrefix(ctx, userBucket, metricsusage.UsagePrefix, userLogger); err != nil {
+		return errors.Wrap(err, "failed to delete metrics usage files")
+	} else if deleted > 0 {
+		level.Info(userLogger).Log("msg", "deleted metrics usage files for tenant marked for deletion", "count", deleted)
+	}
+
+	if deleted, err := bucket.DeletePrefix(ctx, userBucket, deadletter.BucketPrefix, userLogger); err != nil {
+		return errors.Wrap(err, "failed to delete dead letter files")
+	} else if deleted > 0 {
+		level.Info(userLogger).Log("msg", "deleted dead letter files for tenant marked for deletion", "count", deleted)
+	}
+
+	if deleted, err := bucket.DeletePrefix(ctx, userBucket, mimir_tsdb.HeadSnapshotsPrefix, userLogger); err != nil {
+		return errors.Wrap(err, "failed to delete TSDB head snapshots")
+	} else if deleted > 0 {
+		level.Info(userLogger).Log("msg", "deleted TSDB head snapshots for tenant marked for deletion", "count", deleted)
+	}
+
+	// Each ingester keeps track of the tenants it has uploaded a head snapshot for.
+	err = c.bucketClient.Iter(ctx, path.Join(bucket.MimirInternalsPrefix, mimir_tsdb.HeadSnapshotsPrefix)+objstore.DirDelim, func(instancePrefix string) error {
+		if err := c.bucketClient.Delete(ctx, instancePrefix+userID); err != nil && !c.bucketClient.IsObjNotFoundErr(err) {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to delete TSDB head snapshot tenant markers")
+	}
+
 	// Tenant deletion mark file is inside Markers as well.
 	if deleted, err := bucket.DeletePrefix(ctx, userBucket, block.MarkersPathname, userLogger); err != nil {
 		return errors.Wrap(err, "failed to delete marker files")
@@ -429,18 +512,27 @@ func (c *BlocksCleaner) cleanUser(ctx context.Context, userID string, userLogger
 	if idx != nil {
 		// We do not want to stop the remaining work in the cleaner if an
 		// error occurs here. Errors are logged in the function.
-		retention := c.cfgProvider.CompactorBlocksRetentionPeriod(userID)
-		c.applyUserRetentionPeriod(ctx, idx, retention, userBucket, userLogger)
+		c.applyUserRetentionPeriod(ctx, idx, downsample.ResLevel0, c.cfgProvider.CompactorSeriesRetentionPolicies(userID).MaxPeriod(), userBucket, userLogger)
+		c.applyUserRetentionPeriod(ctx, idx, downsample.ResLevel1, c.cfgProvider.CompactorBlocksRetentionPeriod5m(userID), userBucket, userLogger)
+		c.applyUserRetentionPeriod(ctx, idx, downsample.ResLevel2, c.cfgProvider.CompactorBlocksRetentionPeriod1h(userID), userBucket, userLogger)
 	}
 
 	// Generate an updated in-memory version of the bucket index.
-	w := bucketindex.NewUpdater(c.bucketClient, userID, c.cfgProvider, c.cfg.GetDeletionMarkersConcurrency, userLogger)
+	w := bucketindex.NewUpdater(c.bucketClient, userID, c.cfgProvider, c.cfg.GetDeletionMarkersConcurrency, userLogger).WithBlockStats(c.blockStats, c.cfg.BlockStatsMaxTotalBytes)
 	idx, partials, err := w.UpdateIndex(ctx, idx)
 	if err != nil {
 		return err
 	}
 
-	c.deleteBlocksMarkedForDeletion(ctx, idx, userBucket, userLogger)
+	// Merge the metric metadata of the blocks before they get deleted. The metric metadata is best effort,
+	// so we don't fail the cleanup if the metadata index update fails.
+	if c.cfg.MetadataIndexEnabled {
+		if err := c.updateUserMetadataIndex(ctx, userID, idx, userLogger); err != nil {
+			level.Warn(userLogger).Log("msg", "failed to update metadata index", "err", err)
+		}
+	}
+
+	c.deleteBlocksMarkedForDeletion(ctx, userID, idx, userBucket, userLogger)
 
 	// Partial blocks with a deletion mark can be cleaned up. This is a best effort, so we don't return
 	// error if the cleanup of partial blocks fail.
@@ -457,6 +549,12 @@ func (c *BlocksCleaner) cleanUser(ctx context.Context, userID string, userLogger
 		level.Info(userLogger).Log("msg", "cleaned up partial blocks", "partials", len(partials))
 	}
 
+	// Moving the blocks to the cold storage is a best effort too: the blocks which failed to be moved are
+	// moved again at the next run. The index is updated accordingly.
+	if c.coldBucketClient != nil {
+		c.moveBlocksToColdStorage(ctx, userID, idx, userBucket, userLogger)
+	}
+
 	// If there are no more blocks, clean up any remaining files
 	// Otherwise upload the updated index to the storage.
 	if c.cfg.NoBlocksFileCleanupEnabled && len(idx.Blocks) == 0 {
@@ -471,11 +569,12 @@ func (c *BlocksCleaner) cleanUser(ctx context.Context, userID string, userLogger
 
 	c.tenantBlocks.WithLabelValues(userID).Set(float64(len(idx.Blocks)))
 	c.tenantMarkedBlocks.WithLabelValues(userID).Set(float64(len(idx.BlockDeletionMarks)))
+	c.tenantQuarantinedBlocks.WithLabelValues(userID).Set(float64(countQuarantinedBlocks(idx)))
 	c.tenantPartialBlocks.WithLabelValues(userID).Set(float64(len(partials)))
 	c.tenantBucketIndexLastUpdate.WithLabelValues(userID).SetToCurrentTime()
 
 	// Compute pending compaction jobs based on current index.
-	jobs, err := estimateCompactionJobsFromBucketIndex(ctx, userID, userBucket, idx, c.cfg.CompactionBlockRanges, c.cfgProvider.CompactorSplitAndMergeShards(userID), c.cfgProvider.CompactorSplitGroups(userID))
+	jobs, err := estimateCompactionJobsFromBucketIndex(ctx, userID, userBucket, idx, tenantBlockRanges(c.cfg.CompactionBlockRanges, c.cfgProvider, userID), c.cfgProvider.CompactorSplitAndMergeShards(userID), c.cfgProvider.CompactorSplitGroups(userID))
 	if err != nil {
 		// When compactor is shutting down, we get context cancellation. There's no reason to report that as error.
 		if !errors.Is(err, context.Canceled) {
@@ -494,6 +593,26 @@ func (c *BlocksCleaner) cleanUser(ctx context.Context, userID string, userLogger
 	return nil
 }
 
+// updateUserMetadataIndex merges the metric metadata of the blocks in the bucket index into the tenant's metadata index.
+func (c *BlocksCleaner) updateUserMetadataIndex(ctx context.Context, userID string, idx *bucketindex.Index, userLogger log.Logger) error {
+	old, err := metadataindex.ReadIndex(ctx, c.bucketClient, userID, c.cfgProvider, userLogger)
+	if errors.Is(err, metadataindex.ErrIndexCorrupted) {
+		level.Warn(userLogger).Log("msg", "found a corrupted metadata index, recreating it")
+	} else if err != nil && !errors.Is(err, metadataindex.ErrIndexNotFound) {
+		return err
+	}
+
+	// The metric metadata is retained as long as the blocks where it has been seen.
+	w := metadataindex.NewUpdater(c.bucketClient, userID, c.cfgProvider, c.cfg.GetDeletionMarkersConcurrency, userLogger).
+		WithRetentionPeriod(c.cfgProvider.CompactorBlocksRetentionPeriod(userID))
+	updated, err := w.UpdateIndex(ctx, old, idx.Blocks)
+	if err != nil {
+		return err
+	}
+
+	return metadataindex.WriteIndex(ctx, c.bucketClient, userID, c.cfgProvider, updated)
+}
+
 func computeSplitAndMergeJobs(jobs []*Job) (splitJobs int, mergeJobs int) {
 	for _, j := range jobs {
 		if j.UseSplitting() {
@@ -506,8 +625,9 @@ func computeSplitAndMergeJobs(jobs []*Job) (splitJobs int, mergeJobs int) {
 }
 
 // Concurrently deletes blocks marked for deletion, and removes blocks from index.
-func (c *BlocksCleaner) deleteBlocksMarkedForDeletion(ctx context.Context, idx *bucketindex.Index, userBucket objstore.Bucket, userLogger log.Logger) {
+func (c *BlocksCleaner) deleteBlocksMarkedForDeletion(ctx context.Context, userID string, idx *bucketindex.Index, userBucket objstore.Bucket, userLogger log.Logger) {
 	blocksToDelete := make([]ulid.ULID, 0, len(idx.BlockDeletionMarks))
+	coldBlocks := coldStorageBlocks(idx)
 
 	// Collect blocks marked for deletion into buffered channel.
 	for _, mark := range idx.BlockDeletionMarks {
@@ -523,6 +643,16 @@ func (c *BlocksCleaner) deleteBlocksMarkedForDeletion(ctx context.Context, idx *
 	_ = concurrency.ForEachJob(ctx, len(blocksToDelete), c.cfg.DeleteBlocksConcurrency, func(ctx context.Context, jobIdx int) error {
 		blockID := blocksToDelete[jobIdx]
 
+		// The data of the blocks in the cold storage is deleted first, so that it's not left behind
+		// if the deletion of the block from the blocks storage succeeds.
+		if _, cold := coldBlocks[blockID]; cold {
+			if err := c.deleteColdStorageBlock(ctx, userID, blockID, userLogger); err != nil {
+				c.blocksFailedTotal.Inc()
+				level.Warn(userLogger).Log("msg", "failed to delete block marked for deletion from the cold storage", "block", blockID, "err", err)
+				return nil
+			}
+		}
+
 		if err := block.Delete(ctx, userLogger, userBucket, blockID); err != nil {
 			c.blocksFailedTotal.Inc()
 			level.Warn(userLogger).Log("msg", "failed to delete block marked for deletion", "block", blockID, "err", err)
@@ -611,14 +741,14 @@ func (c *BlocksCleaner) cleanUserPartialBlocks(ctx context.Context, partials map
 	}
 }
 
-// applyUserRetentionPeriod marks blocks for deletion which have aged past the retention period.
-func (c *BlocksCleaner) applyUserRetentionPeriod(ctx context.Context, idx *bucketindex.Index, retention time.Duration, userBucket objstore.Bucket, userLogger log.Logger) {
+// applyUserRetentionPeriod marks blocks of the given resolution for deletion which have aged past the retention period.
+func (c *BlocksCleaner) applyUserRetentionPeriod(ctx context.Context, idx *bucketindex.Index, resolution int64, retention time.Duration, userBucket objstore.Bucket, userLogger log.Logger) {
 	// The retention period of zero is a special value indicating to never delete.
 	if retention <= 0 {
 		return
 	}
 
-	blocks := listBlocksOutsideRetentionPeriod(idx, time.Now().Add(-retention))
+	blocks := listBlocksOutsideRetentionPeriod(idx, resolution, time.Now().Add(-retention))
 
 	// Attempt to mark all blocks. It is not critical if a marking fails, as
 	// the cleaner will retry applying the retention in its next cycle.
@@ -628,12 +758,12 @@ func (c *BlocksCleaner) applyUserRetentionPeriod(ctx context.Context, idx *bucke
 			level.Warn(userLogger).Log("msg", "failed to mark block for deletion", "block", b.ID, "err", err)
 		}
 	}
-	level.Info(userLogger).Log("msg", "marked blocks for deletion", "num_blocks", len(blocks), "retention", retention.String())
+	level.Info(userLogger).Log("msg", "marked blocks for deletion", "num_blocks", len(blocks), "retention", retention.String(), "resolution", resolutionLabel(resolution))
 }
 
-// listBlocksOutsideRetentionPeriod determines the blocks which have aged past
+// listBlocksOutsideRetentionPeriod determines the blocks of the given resolution which have aged past
 // the specified retention period, and are not already marked for deletion.
-func listBlocksOutsideRetentionPeriod(idx *bucketindex.Index, threshold time.Time) (result bucketindex.Blocks) {
+func listBlocksOutsideRetentionPeriod(idx *bucketindex.Index, resolution int64, threshold time.Time) (result bucketindex.Blocks) {
 	// Whilst re-marking a block is not harmful, it is wasteful and generates
 	// a warning log message. Use the block deletion marks already in-memory
 	// to prevent marking blocks already marked for deletion.
@@ -643,6 +773,9 @@ func listBlocksOutsideRetentionPeriod(idx *bucketindex.Index, threshold time.Tim
 	}
 
 	for _, b := range idx.Blocks {
+		if b.Resolution != resolution {
+			continue
+		}
 		maxTime := time.Unix(b.MaxTime/1000, 0)
 		if maxTime.Before(threshold) {
 			if _, isMarked := marked[b.ID]; !isMarked {
@@ -706,7 +839,7 @@ func estimateCompactionJobsFromBucketIndex(ctx context.Context, userID string, u
 	return jobs, err
 }
 
-// Convert index into map of block Metas, but ignore blocks marked for deletion.
+// Convert index into map of block Metas, but ignore blocks marked for deletion and blocks moved to the cold storage.
 func ConvertBucketIndexToMetasForCompactionJobPlanning(idx *bucketindex.Index) map[ulid.ULID]*block.Meta {
 	deletedULIDs := idx.BlockDeletionMarks.GetULIDs()
 	deleted := make(map[ulid.ULID]struct{}, len(deletedULIDs))
@@ -719,6 +852,9 @@ func ConvertBucketIndexToMetasForCompactionJobPlanning(idx *bucketindex.Index) m
 		if _, del := deleted[b.ID]; del {
 			continue
 		}
+		if b.Tier == block.ColdStorageTier || b.Quarantined {
+			continue
+		}
 		metas[b.ID] = b.ThanosMeta()
 		if metas[b.ID].Thanos.Labels == nil {
 			metas[b.ID].Thanos.Labels = map[string]string{}
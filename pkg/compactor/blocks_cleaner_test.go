@@ -27,18 +27,24 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/relabel"
 	prom_tsdb "github.com/prometheus/prometheus/tsdb"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/thanos-io/objstore"
 
+	"github.com/grafana/mimir/pkg/deadletter"
 	"github.com/grafana/mimir/pkg/storage/bucket"
 	"github.com/grafana/mimir/pkg/storage/tsdb"
 	"github.com/grafana/mimir/pkg/storage/tsdb/block"
 	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
+	"github.com/grafana/mimir/pkg/storage/tsdb/downsample"
+	"github.com/grafana/mimir/pkg/storage/tsdb/metadataindex"
+	"github.com/grafana/mimir/pkg/storage/tsdb/metricsusage"
 	mimir_testutil "github.com/grafana/mimir/pkg/storage/tsdb/testutil"
 	"github.com/grafana/mimir/pkg/util"
 	"github.com/grafana/mimir/pkg/util/test"
+	"github.com/grafana/mimir/pkg/util/validation"
 )
 
 type testBlocksCleanerOptions struct {
@@ -93,6 +99,7 @@ func testBlocksCleanerWithOptions(t *testing.T, options testBlocksCleanerOptions
 	require.NoError(t, tsdb.WriteTenantDeletionMark(context.Background(), bucketClient, "user-3", nil, tsdb.NewTenantDeletionMark(time.Now())))
 	block9 := createTSDBBlock(t, bucketClient, "user-3", 10, 30, 2, nil)
 	block10 := createTSDBBlock(t, bucketClient, "user-3", 30, 50, 2, nil)
+	require.NoError(t, metadataindex.WriteIndex(context.Background(), bucketClient, "user-3", nil, &metadataindex.Index{Version: metadataindex.IndexVersion1}))
 
 	// User-4 with no more blocks, but couple of mark and debug files. Should be fully deleted.
 	user4Mark := tsdb.NewTenantDeletionMark(time.Now())
@@ -100,6 +107,14 @@ func testBlocksCleanerWithOptions(t *testing.T, options testBlocksCleanerOptions
 	require.NoError(t, tsdb.WriteTenantDeletionMark(context.Background(), bucketClient, "user-4", nil, user4Mark))
 	user4DebugMetaFile := path.Join("user-4", block.DebugMetas, "meta.json")
 	require.NoError(t, bucketClient.Upload(context.Background(), user4DebugMetaFile, strings.NewReader("some random content here")))
+	user4MetricsUsageFile := path.Join("user-4", metricsusage.UsagePrefix, "ingester-ingester-1.json.gz")
+	require.NoError(t, bucketClient.Upload(context.Background(), user4MetricsUsageFile, strings.NewReader("some random content here")))
+	user4DeadLetterFile := path.Join("user-4", deadletter.BucketPrefix, "00000000000000000001-0000000001-ingester-ingester-1.json")
+	require.NoError(t, bucketClient.Upload(context.Background(), user4DeadLetterFile, strings.NewReader("some random content here")))
+	user4HeadSnapshotFile := path.Join("user-4", tsdb.HeadSnapshotsPrefix, "ingester-1", "meta.json")
+	require.NoError(t, bucketClient.Upload(context.Background(), user4HeadSnapshotFile, strings.NewReader("some random content here")))
+	user4HeadSnapshotMarker := path.Join(bucket.MimirInternalsPrefix, tsdb.HeadSnapshotsPrefix, "ingester-1", "user-4")
+	require.NoError(t, bucketClient.Upload(context.Background(), user4HeadSnapshotMarker, strings.NewReader("some random content here")))
 
 	cfg := BlocksCleanerConfig{
 		DeletionDelay:                 deletionDelay,
@@ -144,11 +159,17 @@ func testBlocksCleanerWithOptions(t *testing.T, options testBlocksCleanerOptions
 		{path: path.Join("user-3", block9.String(), "index"), expectedExists: false},
 		{path: path.Join("user-3", block10.String(), block.MetaFilename), expectedExists: false},
 		{path: path.Join("user-3", block10.String(), "index"), expectedExists: false},
+		// Should delete the metadata index of user-3, together with its blocks.
+		{path: path.Join("user-3", metadataindex.IndexCompressedFilename), expectedExists: false},
 		// Tenant deletion mark is not removed.
 		{path: path.Join("user-3", tsdb.TenantDeletionMarkPath), expectedExists: true},
 		// User-4 is removed fully.
 		{path: path.Join("user-4", tsdb.TenantDeletionMarkPath), expectedExists: options.user4FilesExist},
 		{path: path.Join("user-4", block.DebugMetas, "meta.json"), expectedExists: options.user4FilesExist},
+		{path: user4MetricsUsageFile, expectedExists: options.user4FilesExist},
+		{path: user4DeadLetterFile, expectedExists: options.user4FilesExist},
+		{path: user4HeadSnapshotFile, expectedExists: options.user4FilesExist},
+		{path: user4HeadSnapshotMarker, expectedExists: options.user4FilesExist},
 	} {
 		exists, err := bucketClient.Exists(ctx, tc.path)
 		require.NoError(t, err)
@@ -347,6 +368,63 @@ func TestBlocksCleaner_ShouldRebuildBucketIndexOnCorruptedOne(t *testing.T) {
 	assert.ElementsMatch(t, []ulid.ULID{block3}, idx.BlockDeletionMarks.GetULIDs())
 }
 
+func TestBlocksCleaner_ShouldUpdateMetadataIndex(t *testing.T) {
+	const userID = "user-1"
+
+	bucketClient, _ := mimir_testutil.PrepareFilesystemBucket(t)
+	bucketClient = block.BucketWithGlobalMarkers(bucketClient)
+
+	ctx := context.Background()
+	deletionDelay := 12 * time.Hour
+	block1 := createTSDBBlock(t, bucketClient, userID, 10, 20, 2, nil)
+	block2 := createTSDBBlock(t, bucketClient, userID, 20, 30, 2, nil)
+	createMetricsMetadata(t, bucketClient, userID, block1, block.MetricMetadata{Metric: "metric_1", Type: "gauge", Help: "First metric."})
+	createMetricsMetadata(t, bucketClient, userID, block2, block.MetricMetadata{Metric: "metric_1", Type: "counter", Help: "First metric."})
+	createDeletionMark(t, bucketClient, userID, block1, time.Now().Add(-deletionDelay).Add(-time.Hour))
+
+	cfg := BlocksCleanerConfig{
+		DeletionDelay:                 deletionDelay,
+		CleanupInterval:               time.Minute,
+		CleanupConcurrency:            1,
+		DeleteBlocksConcurrency:       1,
+		GetDeletionMarkersConcurrency: 1,
+		MetadataIndexEnabled:          true,
+	}
+
+	logger := log.NewNopLogger()
+	cleaner := NewBlocksCleaner(cfg, bucketClient, tsdb.AllUsers, newMockConfigProvider(), logger, nil)
+	require.NoError(t, cleaner.runCleanupWithErr(ctx))
+
+	// Block 1 has been deleted, but its metric metadata has been merged into the metadata index before.
+	idx, err := bucketindex.ReadIndex(ctx, bucketClient, userID, nil, logger)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []ulid.ULID{block2}, idx.Blocks.GetULIDs())
+
+	metadataIdx, err := metadataindex.ReadIndex(ctx, bucketClient, userID, nil, logger)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []ulid.ULID{block1, block2}, metadataIdx.Blocks)
+	assert.Equal(t, map[string][]metadataindex.Entry{
+		"metric_1": {
+			{Type: "counter", Help: "First metric.", MinTime: 20, MaxTime: 30},
+			{Type: "gauge", Help: "First metric.", MinTime: 10, MaxTime: 20},
+		},
+	}, metadataIdx.Metrics)
+
+	// At the next run, the deleted block is removed from the merged blocks, but its metric metadata is retained.
+	require.NoError(t, cleaner.runCleanupWithErr(ctx))
+
+	metadataIdx, err = metadataindex.ReadIndex(ctx, bucketClient, userID, nil, logger)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []ulid.ULID{block2}, metadataIdx.Blocks)
+	assert.Len(t, metadataIdx.Metrics["metric_1"], 2)
+}
+
+func createMetricsMetadata(t *testing.T, bkt objstore.Bucket, userID string, blockID ulid.ULID, metadata ...block.MetricMetadata) {
+	dir := t.TempDir()
+	require.NoError(t, block.WriteMetricsMetadataFile(dir, &block.MetricsMetadata{Metadata: metadata}))
+	require.NoError(t, objstore.UploadFile(context.Background(), log.NewNopLogger(), bkt, filepath.Join(dir, block.MetricsMetadataFilename), path.Join(userID, blockID.String(), block.MetricsMetadataFilename)))
+}
+
 func TestBlocksCleaner_ShouldRemoveMetricsForTenantsNotBelongingAnymoreToTheShard(t *testing.T) {
 	bucketClient, _ := mimir_testutil.PrepareFilesystemBucket(t)
 	bucketClient = block.BucketWithGlobalMarkers(bucketClient)
@@ -497,20 +575,20 @@ func TestBlocksCleaner_ListBlocksOutsideRetentionPeriod(t *testing.T) {
 	assert.ElementsMatch(t, []ulid.ULID{id1, id2, id3}, idx.Blocks.GetULIDs())
 
 	// Excessive retention period (wrapping epoch)
-	result := listBlocksOutsideRetentionPeriod(idx, time.Unix(10, 0).Add(-time.Hour))
+	result := listBlocksOutsideRetentionPeriod(idx, downsample.ResLevel0, time.Unix(10, 0).Add(-time.Hour))
 	assert.ElementsMatch(t, []ulid.ULID{}, result.GetULIDs())
 
 	// Normal operation - varying retention period.
-	result = listBlocksOutsideRetentionPeriod(idx, time.Unix(6, 0))
+	result = listBlocksOutsideRetentionPeriod(idx, downsample.ResLevel0, time.Unix(6, 0))
 	assert.ElementsMatch(t, []ulid.ULID{}, result.GetULIDs())
 
-	result = listBlocksOutsideRetentionPeriod(idx, time.Unix(7, 0))
+	result = listBlocksOutsideRetentionPeriod(idx, downsample.ResLevel0, time.Unix(7, 0))
 	assert.ElementsMatch(t, []ulid.ULID{id1}, result.GetULIDs())
 
-	result = listBlocksOutsideRetentionPeriod(idx, time.Unix(8, 0))
+	result = listBlocksOutsideRetentionPeriod(idx, downsample.ResLevel0, time.Unix(8, 0))
 	assert.ElementsMatch(t, []ulid.ULID{id1, id2}, result.GetULIDs())
 
-	result = listBlocksOutsideRetentionPeriod(idx, time.Unix(9, 0))
+	result = listBlocksOutsideRetentionPeriod(idx, downsample.ResLevel0, time.Unix(9, 0))
 	assert.ElementsMatch(t, []ulid.ULID{id1, id2, id3}, result.GetULIDs())
 
 	// Avoiding redundant marking - blocks already marked for deletion.
@@ -520,21 +598,21 @@ func TestBlocksCleaner_ListBlocksOutsideRetentionPeriod(t *testing.T) {
 
 	idx.BlockDeletionMarks = bucketindex.BlockDeletionMarks{mark1}
 
-	result = listBlocksOutsideRetentionPeriod(idx, time.Unix(7, 0))
+	result = listBlocksOutsideRetentionPeriod(idx, downsample.ResLevel0, time.Unix(7, 0))
 	assert.ElementsMatch(t, []ulid.ULID{}, result.GetULIDs())
 
-	result = listBlocksOutsideRetentionPeriod(idx, time.Unix(8, 0))
+	result = listBlocksOutsideRetentionPeriod(idx, downsample.ResLevel0, time.Unix(8, 0))
 	assert.ElementsMatch(t, []ulid.ULID{id2}, result.GetULIDs())
 
 	idx.BlockDeletionMarks = bucketindex.BlockDeletionMarks{mark1, mark2}
 
-	result = listBlocksOutsideRetentionPeriod(idx, time.Unix(7, 0))
+	result = listBlocksOutsideRetentionPeriod(idx, downsample.ResLevel0, time.Unix(7, 0))
 	assert.ElementsMatch(t, []ulid.ULID{}, result.GetULIDs())
 
-	result = listBlocksOutsideRetentionPeriod(idx, time.Unix(8, 0))
+	result = listBlocksOutsideRetentionPeriod(idx, downsample.ResLevel0, time.Unix(8, 0))
 	assert.ElementsMatch(t, []ulid.ULID{}, result.GetULIDs())
 
-	result = listBlocksOutsideRetentionPeriod(idx, time.Unix(9, 0))
+	result = listBlocksOutsideRetentionPeriod(idx, downsample.ResLevel0, time.Unix(9, 0))
 	assert.ElementsMatch(t, []ulid.ULID{id3}, result.GetULIDs())
 }
 
@@ -1510,6 +1588,19 @@ type mockConfigProvider struct {
 	userPartialBlockDelayInvalid map[string]bool
 	verifyChunks                 map[string]bool
 	perTenantInMemoryCache       map[string]int
+	exemplarsRetentionPeriods    map[string]time.Duration
+	blockRanges                  map[string]tsdb.DurationList
+	ingesterBlockRanges          map[string]time.Duration
+	outOfOrderTimeWindows        map[string]time.Duration
+	downsampling5mAfter          map[string]time.Duration
+	downsampling1hAfter          map[string]time.Duration
+	retentionPeriods5m           map[string]time.Duration
+	retentionPeriods1h           map[string]time.Duration
+	seriesRetentionPolicies      map[string][]validation.SeriesRetentionPolicy
+	relabelConfigs               map[string][]*relabel.Config
+	coldStorageAfter             map[string]time.Duration
+	maxThroughputBytesPerSecond  map[string]int64
+	schedulingWeights            map[string]float64
 }
 
 func newMockConfigProvider() *mockConfigProvider {
@@ -1524,6 +1615,19 @@ func newMockConfigProvider() *mockConfigProvider {
 		userPartialBlockDelayInvalid: make(map[string]bool),
 		verifyChunks:                 make(map[string]bool),
 		perTenantInMemoryCache:       make(map[string]int),
+		exemplarsRetentionPeriods:    make(map[string]time.Duration),
+		blockRanges:                  make(map[string]tsdb.DurationList),
+		ingesterBlockRanges:          make(map[string]time.Duration),
+		outOfOrderTimeWindows:        make(map[string]time.Duration),
+		downsampling5mAfter:          make(map[string]time.Duration),
+		downsampling1hAfter:          make(map[string]time.Duration),
+		retentionPeriods5m:           make(map[string]time.Duration),
+		retentionPeriods1h:           make(map[string]time.Duration),
+		seriesRetentionPolicies:      make(map[string][]validation.SeriesRetentionPolicy),
+		relabelConfigs:               make(map[string][]*relabel.Config),
+		coldStorageAfter:             make(map[string]time.Duration),
+		maxThroughputBytesPerSecond:  make(map[string]int64),
+		schedulingWeights:            make(map[string]float64),
 	}
 }
 
@@ -1579,6 +1683,61 @@ func (m *mockConfigProvider) CompactorInMemoryTenantMetaCacheSize(userID string)
 	return m.perTenantInMemoryCache[userID]
 }
 
+func (m *mockConfigProvider) CompactorExemplarsRetentionPeriod(userID string) time.Duration {
+	return m.exemplarsRetentionPeriods[userID]
+}
+
+func (m *mockConfigProvider) CompactorBlockRanges(userID string) []time.Duration {
+	return m.blockRanges[userID]
+}
+
+func (m *mockConfigProvider) IngesterTSDBBlockRangePeriod(userID string) time.Duration {
+	return m.ingesterBlockRanges[userID]
+}
+
+func (m *mockConfigProvider) OutOfOrderTimeWindow(userID string) time.Duration {
+	return m.outOfOrderTimeWindows[userID]
+}
+
+func (m *mockConfigProvider) CompactorDownsampling5mAfter(userID string) time.Duration {
+	return m.downsampling5mAfter[userID]
+}
+
+func (m *mockConfigProvider) CompactorDownsampling1hAfter(userID string) time.Duration {
+	return m.downsampling1hAfter[userID]
+}
+
+func (m *mockConfigProvider) CompactorBlocksRetentionPeriod5m(userID string) time.Duration {
+	return m.retentionPeriods5m[userID]
+}
+
+func (m *mockConfigProvider) CompactorBlocksRetentionPeriod1h(userID string) time.Duration {
+	return m.retentionPeriods1h[userID]
+}
+
+func (m *mockConfigProvider) CompactorSeriesRetentionPolicies(userID string) validation.SeriesRetentionPolicies {
+	return validation.SeriesRetentionPolicies{Policies: m.seriesRetentionPolicies[userID], DefaultPeriod: m.CompactorBlocksRetentionPeriod(userID)}
+}
+
+func (m *mockConfigProvider) CompactorRelabelConfigs(userID string) []*relabel.Config {
+	return m.relabelConfigs[userID]
+}
+
+func (m *mockConfigProvider) CompactorColdStorageAfter(userID string) time.Duration {
+	return m.coldStorageAfter[userID]
+}
+
+func (m *mockConfigProvider) CompactorMaxThroughputBytesPerSecond(userID string) int64 {
+	return m.maxThroughputBytesPerSecond[userID]
+}
+
+func (m *mockConfigProvider) CompactorSchedulingWeight(userID string) float64 {
+	if result, ok := m.schedulingWeights[userID]; ok {
+		return result
+	}
+	return 1
+}
+
 func (m *mockConfigProvider) S3SSEType(string) string {
 	return ""
 }
@@ -8,6 +8,7 @@ package compactor
 import (
 	"context"
 	"fmt"
+	"maps"
 	"os"
 	"path"
 	"path/filepath"
@@ -27,6 +28,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
 	"github.com/prometheus/prometheus/tsdb"
 	"github.com/thanos-io/objstore"
 	"go.uber.org/atomic"
@@ -34,6 +36,7 @@ import (
 	"github.com/grafana/mimir/pkg/storage/sharding"
 	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
 	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/util/validation"
 )
 
 var errCompactionIterationCancelled = cancellation.NewErrorf("compaction iteration cancelled")
@@ -265,6 +268,9 @@ func (c *BucketCompactor) runCompactionJob(ctx context.Context, job *Job) (shoul
 		if rerr == nil {
 			c.metrics.compactionJobDuration.WithLabelValues(jobType).Observe(elapsed.Seconds())
 			c.metrics.compactionJobBlocks.WithLabelValues(jobType).Observe(float64(blockCount))
+			if c.jobsThroughput != nil {
+				c.jobsThroughput.observe(job.UserID(), job.EstimatedCost(), elapsed)
+			}
 
 			level.Info(jobLogger).Log("msg", "compaction job succeeded", "duration", elapsed, "duration_ms", elapsed.Milliseconds(), "block_count", blockCount)
 		} else {
@@ -309,6 +315,9 @@ func (c *BucketCompactor) runCompactionJob(ctx context.Context, job *Job) (shoul
 	// Once we have a plan we need to download the actual data.
 	downloadBegin := time.Now()
 
+	// The compacted blocks keep the rewrites applied to all the source blocks.
+	sourceRewrites := make([][]block.Rewrite, len(toCompact))
+
 	err = concurrency.ForEachJob(ctx, len(toCompact), c.blockSyncConcurrency, func(ctx context.Context, idx int) error {
 		meta := toCompact[idx]
 
@@ -343,6 +352,24 @@ func (c *BucketCompactor) runCompactionJob(ctx context.Context, job *Job) (shoul
 		if err := stats.OutOfOrderLabelsErr(); err != nil {
 			return errors.Wrapf(err, "block id %s", meta.ULID)
 		}
+
+		if reqs := c.seriesDeletionRequests.Overlapping(meta.MinTime, meta.MaxTime-1); len(reqs) > 0 {
+			if err := applySeriesDeletionRequestsToBlockDir(ctx, jobLogger, bdir, reqs); err != nil {
+				return errors.Wrapf(err, "block id %s", meta.ULID)
+			}
+		}
+
+		if now := time.Now(); seriesRetentionAppliesToBlock(meta, c.seriesRetentionPolicies, now) {
+			if _, err := applySeriesRetentionPoliciesToBlockDir(ctx, jobLogger, bdir, c.seriesRetentionPolicies, now); err != nil {
+				return errors.Wrapf(err, "block id %s", meta.ULID)
+			}
+		}
+
+		// Relabeling removes the samples deleted by the tombstones written above.
+		sourceRewrites[idx], err = relabelBlockDir(ctx, jobLogger, bdir, meta, c.relabelConfigs)
+		if err != nil {
+			return errors.Wrapf(err, "block id %s", meta.ULID)
+		}
 		return nil
 	})
 	if err != nil {
@@ -382,6 +409,14 @@ func (c *BucketCompactor) runCompactionJob(ctx context.Context, job *Job) (shoul
 		return true, nil, nil
 	}
 
+	var exemplars *block.Exemplars
+	if c.exemplarsEnabled {
+		exemplars, err = readSourceBlocksExemplars(blocksToCompactDirs)
+		if err != nil {
+			return false, nil, err
+		}
+	}
+
 	elapsed = time.Since(compactionBegin)
 	level.Info(jobLogger).Log("msg", "compacted blocks", "new_block_count", len(compIDs), "new_blocks", fmt.Sprintf("%v", compIDs), "block_count", blockCount, "blocks", toCompactStr, "duration", elapsed, "duration_ms", elapsed.Milliseconds())
 
@@ -412,6 +447,7 @@ func (c *BucketCompactor) runCompactionJob(ctx context.Context, job *Job) (shoul
 			Downsample:   block.ThanosDownsample{Resolution: job.Resolution()},
 			Source:       block.CompactorSource,
 			SegmentFiles: block.GetSegmentFiles(bdir),
+			Rewrites:     commonRewrites(sourceRewrites),
 		}, nil)
 		if err != nil {
 			return errors.Wrapf(err, "failed to finalize the block %s", bdir)
@@ -426,6 +462,12 @@ func (c *BucketCompactor) runCompactionJob(ctx context.Context, job *Job) (shoul
 			return errors.Wrapf(err, "invalid result block %s", bdir)
 		}
 
+		if exemplars != nil {
+			if err := writeCompactedBlockExemplars(ctx, bdir, newMeta, exemplars, c.exemplarsRetentionPeriod, c.seriesDeletionRequests); err != nil {
+				return errors.Wrapf(err, "failed to write exemplars of the block %s", bdir)
+			}
+		}
+
 		begin := time.Now()
 		if err := block.Upload(ctx, jobLogger, c.bkt, bdir, nil); err != nil {
 			return errors.Wrapf(err, "upload of %s failed", blockToUpload.ulid)
@@ -761,6 +803,35 @@ type BucketCompactor struct {
 	waitPeriod           time.Duration
 	blockSyncConcurrency int
 	metrics              *BucketCompactorMetrics
+
+	// seriesDeletionRequests are applied to the source blocks of the compaction jobs.
+	seriesDeletionRequests mimir_tsdb.SeriesDeletionRequests
+
+	// seriesRetentionPolicies are applied to the source blocks of the compaction jobs.
+	seriesRetentionPolicies validation.SeriesRetentionPolicies
+
+	// relabelConfigs are applied to the series of the source blocks of the compaction jobs.
+	relabelConfigs []*relabel.Config
+
+	// exemplarsEnabled enables the merge of the exemplars of the source blocks into the compacted blocks.
+	// Exemplars older than exemplarsRetentionPeriod are dropped, if it's greater than zero.
+	exemplarsEnabled         bool
+	exemplarsRetentionPeriod time.Duration
+
+	// jobsThroughput, if set, tracks the throughput of the successful compaction jobs, and catchUpSeconds is set
+	// to the estimated time to run the planned jobs.
+	jobsThroughput *jobsThroughputEstimator
+	catchUpSeconds prometheus.Gauge
+
+	// maxJobsBytes, if greater than 0, is the maximum estimated size of the jobs started by Compact, which always
+	// starts at least one job. hasPendingJobs is set when Compact returns before starting all the jobs because
+	// of it.
+	maxJobsBytes   int64
+	hasPendingJobs bool
+
+	// startedJobs, if set, holds the keys of the jobs started by the previous Compact calls, which aren't started
+	// again, and the keys of the jobs started by Compact are added to it.
+	startedJobs map[string]struct{}
 }
 
 // NewBucketCompactor creates a new bucket compactor.
@@ -821,6 +892,11 @@ func (c *BucketCompactor) Compact(ctx context.Context, maxCompactionTime time.Du
 		maxCompactionTimeChan = time.After(maxCompactionTime)
 	}
 
+	// Estimated size of the jobs started so far, checked against maxJobsBytes.
+	var startedJobsBytes int64
+	c.hasPendingJobs = false
+	previousJobs := maps.Clone(c.startedJobs)
+
 	// Loop over bucket and compact until there's no work left.
 	for {
 		var (
@@ -872,53 +948,13 @@ func (c *BucketCompactor) Compact(ctx context.Context, maxCompactionTime time.Du
 					// At this point the compaction has failed.
 					c.metrics.groupCompactionRunsFailed.Inc()
 
-					if ok, issue347Err := isIssue347Error(err); ok {
-						if err := repairIssue347(workCtx, c.logger, c.bkt, c.sy.metrics.blocksMarkedForDeletion, issue347Err); err == nil {
-							mtx.Lock()
-							finishedAllJobs = false
-							mtx.Unlock()
-							continue
-						}
-					}
-					// If the block has an out of order chunk and we have been configured to skip it,
-					// then we can mark the block for no compaction so that the next compaction run
-					// will skip it.
-					if ok, outOfOrderChunksErr := IsOutOfOrderChunkError(err); ok && c.skipUnhealthyBlocks {
-						err := block.MarkForNoCompact(
-							ctx,
-							c.logger,
-							c.bkt,
-							outOfOrderChunksErr.id,
-							block.OutOfOrderChunksNoCompactReason,
-							"OutofOrderChunk: marking block with out-of-order series/chunks as no compact to unblock compaction",
-							c.metrics.blocksMarkedForNoCompact.WithLabelValues(block.OutOfOrderChunksNoCompactReason),
-						)
-						if err == nil {
-							mtx.Lock()
-							finishedAllJobs = false
-							mtx.Unlock()
-							continue
-						}
-					}
-
-					// In case an unhealthy block is found, we mark it for no compaction
-					// to unblock future compaction run.
-					if ok, criticalErr := IsCriticalError(err); ok && c.skipUnhealthyBlocks {
-						err := block.MarkForNoCompact(
-							ctx,
-							c.logger,
-							c.bkt,
-							criticalErr.id,
-							block.CriticalNoCompactReason,
-							"UnhealthyBlock: marking unhealthy block as no compact to unblock compaction",
-							c.metrics.blocksMarkedForNoCompact.WithLabelValues(block.CriticalNoCompactReason),
-						)
-						if err == nil {
-							mtx.Lock()
-							finishedAllJobs = false
-							mtx.Unlock()
-							continue
-						}
+					// If the job has failed because of a broken block, we can repair it or mark it for no
+					// compaction, so that the next compaction run will succeed.
+					if c.recoverJobError(ctx, err) {
+						mtx.Lock()
+						finishedAllJobs = false
+						mtx.Unlock()
+						continue
 					}
 
 					errChan <- errors.Wrapf(err, "group %s", g.Key())
@@ -965,6 +1001,8 @@ func (c *BucketCompactor) Compact(ctx context.Context, maxCompactionTime time.Du
 		// Sort jobs based on the configured ordering algorithm.
 		jobs = c.sortJobs(jobs)
 
+		c.updateCatchUpEstimate(jobs)
+
 		ignoreDirs := []string{}
 		for _, gr := range jobs {
 			for _, grID := range gr.IDs() {
@@ -983,11 +1021,26 @@ func (c *BucketCompactor) Compact(ctx context.Context, maxCompactionTime time.Du
 		var jobErrs multierror.MultiError
 	jobLoop:
 		for _, g := range jobs {
+			if _, ok := previousJobs[g.Key()]; ok {
+				continue
+			}
+
+			jobBytes := g.EstimatedCost().Bytes
+			if c.maxJobsBytes > 0 && startedJobsBytes > 0 && startedJobsBytes+jobBytes > c.maxJobsBytes {
+				c.hasPendingJobs = true
+				level.Info(c.logger).Log("msg", "max size of the compaction jobs reached, no more compactions will be started", "started_jobs_bytes", startedJobsBytes)
+				break jobLoop
+			}
+
 			select {
 			case jobErr := <-errChan:
 				jobErrs.Add(jobErr)
 				break jobLoop
 			case jobChan <- g:
+				startedJobsBytes += jobBytes
+				if c.startedJobs != nil {
+					c.startedJobs[g.Key()] = struct{}{}
+				}
 			case <-maxCompactionTimeChan:
 				maxCompactionTimeReached = true
 				level.Info(c.logger).Log("msg", "max compaction time reached, no more compactions will be started")
@@ -1012,11 +1065,80 @@ func (c *BucketCompactor) Compact(ctx context.Context, maxCompactionTime time.Du
 		if maxCompactionTimeReached || finishedAllJobs {
 			break
 		}
+		if c.maxJobsBytes > 0 && startedJobsBytes >= c.maxJobsBytes {
+			c.hasPendingJobs = true
+			break
+		}
 	}
 	level.Info(c.logger).Log("msg", "compaction iterations done")
 	return nil
 }
 
+// updateCatchUpEstimate sets the estimated time to run the given jobs, running up to the configured concurrency
+// jobs at the same time. The estimate isn't updated if the throughput of the jobs hasn't been observed yet.
+func (c *BucketCompactor) updateCatchUpEstimate(jobs []*Job) {
+	if c.jobsThroughput == nil || c.catchUpSeconds == nil {
+		return
+	}
+	if len(jobs) == 0 {
+		c.catchUpSeconds.Set(0)
+		return
+	}
+
+	var pending JobCost
+	for _, job := range jobs {
+		pending = pending.add(job.EstimatedCost())
+	}
+	if seconds, ok := c.jobsThroughput.catchUpSeconds(jobs[0].UserID(), pending, float64(c.concurrency)); ok {
+		c.catchUpSeconds.Set(seconds)
+	}
+}
+
+// recoverJobError recovers from the error of a failed compaction job, by repairing the broken block or marking
+// it for no compaction, and returns whether it has been recovered.
+func (c *BucketCompactor) recoverJobError(ctx context.Context, err error) bool {
+	if ok, issue347Err := isIssue347Error(err); ok {
+		if err := repairIssue347(ctx, c.logger, c.bkt, c.metrics.blocksMarkedForDeletion, issue347Err); err == nil {
+			return true
+		}
+	}
+	// If the block has an out of order chunk and we have been configured to skip it,
+	// then we can mark the block for no compaction so that the next compaction run
+	// will skip it.
+	if ok, outOfOrderChunksErr := IsOutOfOrderChunkError(err); ok && c.skipUnhealthyBlocks {
+		err := block.MarkForNoCompact(
+			ctx,
+			c.logger,
+			c.bkt,
+			outOfOrderChunksErr.id,
+			block.OutOfOrderChunksNoCompactReason,
+			"OutofOrderChunk: marking block with out-of-order series/chunks as no compact to unblock compaction",
+			c.metrics.blocksMarkedForNoCompact.WithLabelValues(block.OutOfOrderChunksNoCompactReason),
+		)
+		if err == nil {
+			return true
+		}
+	}
+
+	// In case an unhealthy block is found, we mark it for no compaction
+	// to unblock future compaction run.
+	if ok, criticalErr := IsCriticalError(err); ok && c.skipUnhealthyBlocks {
+		err := block.MarkForNoCompact(
+			ctx,
+			c.logger,
+			c.bkt,
+			criticalErr.id,
+			block.CriticalNoCompactReason,
+			"UnhealthyBlock: marking unhealthy block as no compact to unblock compaction",
+			c.metrics.blocksMarkedForNoCompact.WithLabelValues(block.CriticalNoCompactReason),
+		)
+		if err == nil {
+			return true
+		}
+	}
+	return false
+}
+
 // blockMaxTimeDeltas returns a slice of the difference between now and the MaxTime of each
 // block that will be compacted as part of the provided jobs, in seconds.
 func (c *BucketCompactor) blockMaxTimeDeltas(now time.Time, jobs []*Job) []float64 {
@@ -0,0 +1,168 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"context"
+	"path"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/concurrency"
+	"github.com/grafana/dskit/runutil"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
+)
+
+// coldStorageMarkFilter is a block.MetadataFilter which removes the blocks moved to the cold storage. Blocks in the
+// cold storage are never compacted, downsampled or rewritten.
+type coldStorageMarkFilter struct {
+	bkt objstore.BucketReader
+}
+
+func newColdStorageMarkFilter(bkt objstore.BucketReader) *coldStorageMarkFilter {
+	return &coldStorageMarkFilter{bkt: bkt}
+}
+
+func (f *coldStorageMarkFilter) Filter(ctx context.Context, metas map[ulid.ULID]*block.Meta, _ block.GaugeVec) error {
+	marks, err := block.ListBlockColdStorageMarks(ctx, f.bkt)
+	if err != nil {
+		return errors.Wrap(err, "list cold storage marks")
+	}
+	for id := range marks {
+		delete(metas, id)
+	}
+	return nil
+}
+
+// coldStorageBlocks returns the IDs of the blocks in the cold storage tier.
+func coldStorageBlocks(idx *bucketindex.Index) map[ulid.ULID]struct{} {
+	blocks := map[ulid.ULID]struct{}{}
+	for _, b := range idx.Blocks {
+		if b.Tier == block.ColdStorageTier {
+			blocks[b.ID] = struct{}{}
+		}
+	}
+	return blocks
+}
+
+// moveBlocksToColdStorage moves the tenant's blocks older than the cold storage age to the cold storage, and deletes
+// the data of the blocks moved to the cold storage from the blocks storage once the deletion delay has elapsed. The
+// index is updated accordingly. Errors are logged and the affected blocks are retried at the next run.
+func (c *BlocksCleaner) moveBlocksToColdStorage(ctx context.Context, userID string, idx *bucketindex.Index, userBucket objstore.InstrumentedBucket, userLogger log.Logger) {
+	coldUserBucket := bucket.NewUserBucketClient(userID, c.coldBucketClient, c.cfgProvider)
+	after := c.cfgProvider.CompactorColdStorageAfter(userID)
+	threshold := time.Now().Add(-after).UnixMilli()
+
+	deleted := make(map[ulid.ULID]struct{}, len(idx.BlockDeletionMarks))
+	for _, mark := range idx.BlockDeletionMarks {
+		deleted[mark.ID] = struct{}{}
+	}
+
+	var toMove, toDeleteHotData []*bucketindex.Block
+	for _, b := range idx.Blocks {
+		if _, ok := deleted[b.ID]; ok {
+			continue
+		}
+
+		switch {
+		case b.Tier == "" && !b.Quarantined && after > 0 && b.MaxTime < threshold:
+			toMove = append(toMove, b)
+
+		// The data is deleted from the blocks storage only after the deletion delay, to give store-gateways
+		// and queriers the time to discover the new tier of the block.
+		case b.Tier == block.ColdStorageTier && !b.HotDataDeleted && !c.cfg.ColdStorageRewriteInPlace && time.Since(b.GetColdStorageTime()) > c.cfg.DeletionDelay:
+			toDeleteHotData = append(toDeleteHotData, b)
+		}
+	}
+
+	// We don't want to return errors from our function, as that would stop ForEach loop early.
+	_ = concurrency.ForEachJob(ctx, len(toMove), c.cfg.ColdStorageMoveConcurrency, func(ctx context.Context, jobIdx int) error {
+		b := toMove[jobIdx]
+
+		if err := c.moveBlockToColdStorage(ctx, b.ID, userBucket, coldUserBucket, userLogger); err != nil {
+			c.blocksMovedToColdStorageFailed.Inc()
+			level.Warn(userLogger).Log("msg", "failed to move block to the cold storage", "block", b.ID, "err", err)
+			return nil
+		}
+
+		b.Tier = block.ColdStorageTier
+		b.ColdStorageTime = time.Now().Unix()
+
+		level.Info(userLogger).Log("msg", "moved block to the cold storage", "block", b.ID)
+		return nil
+	})
+
+	_ = concurrency.ForEachJob(ctx, len(toDeleteHotData), c.cfg.DeleteBlocksConcurrency, func(ctx context.Context, jobIdx int) error {
+		b := toDeleteHotData[jobIdx]
+
+		if err := block.DeleteData(ctx, userLogger, userBucket, b.ID); err != nil {
+			level.Warn(userLogger).Log("msg", "failed to delete the data of a block moved to the cold storage", "block", b.ID, "err", err)
+			return nil
+		}
+
+		b.HotDataDeleted = true
+
+		level.Info(userLogger).Log("msg", "deleted the data of a block moved to the cold storage", "block", b.ID)
+		return nil
+	})
+}
+
+// moveBlockToColdStorage copies the block to the cold storage and marks it for cold storage. The meta.json is
+// copied last, so that the block is complete in the cold storage only once all its objects have been copied.
+// When the blocks are rewritten in place, the meta.json is not rewritten, because its storage class doesn't matter.
+func (c *BlocksCleaner) moveBlockToColdStorage(ctx context.Context, id ulid.ULID, userBucket, coldUserBucket objstore.Bucket, userLogger log.Logger) error {
+	var names []string
+	err := userBucket.Iter(ctx, id.String(), func(name string) error {
+		if !block.IsMetadataFile(name) {
+			names = append(names, name)
+		}
+		return nil
+	}, objstore.WithRecursiveIter())
+	if err != nil {
+		return errors.Wrap(err, "list block objects")
+	}
+
+	if !c.cfg.ColdStorageRewriteInPlace {
+		names = append(names, path.Join(id.String(), block.MetaFilename))
+	}
+
+	for _, name := range names {
+		if err := copyObject(ctx, userBucket, coldUserBucket, name, userLogger); err != nil {
+			return err
+		}
+	}
+
+	return block.MarkForColdStorage(ctx, userLogger, userBucket, id, "", c.blocksMovedToColdStorage)
+}
+
+// deleteColdStorageBlock deletes a block from the cold storage bucket.
+func (c *BlocksCleaner) deleteColdStorageBlock(ctx context.Context, userID string, id ulid.ULID, userLogger log.Logger) error {
+	// When the blocks are rewritten in place, the block is deleted from the blocks storage.
+	if c.cfg.ColdStorageRewriteInPlace {
+		return nil
+	}
+	if c.coldBucketClient == nil {
+		level.Warn(userLogger).Log("msg", "the block is in the cold storage but the cold storage is disabled, its data is not deleted from the cold storage", "block", id)
+		return nil
+	}
+
+	coldUserBucket := bucket.NewUserBucketClient(userID, c.coldBucketClient, c.cfgProvider)
+	return block.Delete(ctx, userLogger, coldUserBucket, id)
+}
+
+func copyObject(ctx context.Context, src objstore.BucketReader, dst objstore.Bucket, name string, logger log.Logger) error {
+	r, err := src.Get(ctx, name)
+	if err != nil {
+		return errors.Wrapf(err, "get %s", name)
+	}
+	defer runutil.CloseWithLogOnErr(logger, r, "close %s", name)
+
+	return errors.Wrapf(dst.Upload(ctx, name, r), "upload %s", name)
+}
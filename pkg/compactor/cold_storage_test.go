@@ -0,0 +1,125 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"context"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	"github.com/grafana/mimir/pkg/storage/tsdb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
+	mimir_testutil "github.com/grafana/mimir/pkg/storage/tsdb/testutil"
+	"github.com/grafana/mimir/pkg/util/test"
+)
+
+func TestBlocksCleaner_ShouldMoveBlocksToColdStorage(t *testing.T) {
+	const userID = "user-1"
+
+	bucketClient, _ := mimir_testutil.PrepareFilesystemBucket(t)
+	bucketClient = block.BucketWithGlobalMarkers(bucketClient)
+	coldBucketClient, _ := mimir_testutil.PrepareFilesystemBucket(t)
+
+	ts := func(hours int) int64 {
+		return time.Now().Add(time.Duration(hours)*time.Hour).Unix() * 1000
+	}
+
+	block1 := createTSDBBlock(t, bucketClient, userID, ts(-10), ts(-8), 2, nil)
+	block2 := createTSDBBlock(t, bucketClient, userID, ts(-2), ts(-1), 2, nil)
+
+	cfg := BlocksCleanerConfig{
+		DeletionDelay:              time.Hour,
+		CleanupInterval:            time.Minute,
+		CleanupConcurrency:         1,
+		DeleteBlocksConcurrency:    1,
+		ColdStorageMoveConcurrency: 1,
+	}
+
+	ctx := context.Background()
+	logger := test.NewTestingLogger(t)
+	reg := prometheus.NewPedanticRegistry()
+	cfgProvider := newMockConfigProvider()
+	cfgProvider.coldStorageAfter[userID] = 5 * time.Hour
+
+	cleaner := NewBlocksCleaner(cfg, bucketClient, tsdb.AllUsers, cfgProvider, logger, reg)
+	cleaner.coldBucketClient = coldBucketClient
+
+	assertObjectExists := func(bkt objstore.Bucket, blockID ulid.ULID, name string, expectExists bool) {
+		exists, err := bkt.Exists(ctx, path.Join(userID, blockID.String(), name))
+		require.NoError(t, err)
+		assert.Equal(t, expectExists, exists, "block: %s object: %s", blockID, name)
+	}
+
+	assertBlocksTier := func(expected map[ulid.ULID]bucketindex.Block) {
+		idx, err := bucketindex.ReadIndex(ctx, bucketClient, userID, nil, logger)
+		require.NoError(t, err)
+
+		actual := map[ulid.ULID]bucketindex.Block{}
+		for _, b := range idx.Blocks {
+			actual[b.ID] = bucketindex.Block{Tier: b.Tier, HotDataDeleted: b.HotDataDeleted}
+		}
+		assert.Equal(t, expected, actual)
+	}
+
+	// The block older than the cold storage age is copied to the cold storage, but its data is kept
+	// in the blocks storage until the deletion delay has elapsed.
+	require.NoError(t, cleaner.runCleanupWithErr(ctx))
+	assertObjectExists(coldBucketClient, block1, block.MetaFilename, true)
+	assertObjectExists(coldBucketClient, block1, block.IndexFilename, true)
+	assertObjectExists(coldBucketClient, block2, block.MetaFilename, false)
+	assertObjectExists(bucketClient, block1, block.IndexFilename, true)
+	assertObjectExists(bucketClient, block1, block.ColdStorageMarkFilename, true)
+	assertBlocksTier(map[ulid.ULID]bucketindex.Block{
+		block1: {Tier: block.ColdStorageTier},
+		block2: {},
+	})
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_compactor_blocks_moved_to_cold_storage_total Total number of blocks moved to the cold storage.
+		# TYPE cortex_compactor_blocks_moved_to_cold_storage_total counter
+		cortex_compactor_blocks_moved_to_cold_storage_total 1
+		# HELP cortex_compactor_blocks_moved_to_cold_storage_failed_total Total number of blocks failed to be moved to the cold storage.
+		# TYPE cortex_compactor_blocks_moved_to_cold_storage_failed_total counter
+		cortex_compactor_blocks_moved_to_cold_storage_failed_total 0
+		`),
+		"cortex_compactor_blocks_moved_to_cold_storage_total",
+		"cortex_compactor_blocks_moved_to_cold_storage_failed_total",
+	))
+
+	// Once the deletion delay has elapsed, the data of the block is deleted from the blocks storage,
+	// but its metadata is kept.
+	cleaner.cfg.DeletionDelay = 0
+
+	require.NoError(t, cleaner.runCleanupWithErr(ctx))
+	assertObjectExists(bucketClient, block1, block.IndexFilename, false)
+	assertObjectExists(bucketClient, block1, block.MetaFilename, true)
+	assertObjectExists(bucketClient, block1, block.ColdStorageMarkFilename, true)
+	assertObjectExists(coldBucketClient, block1, block.IndexFilename, true)
+	assertBlocksTier(map[ulid.ULID]bucketindex.Block{
+		block1: {Tier: block.ColdStorageTier, HotDataDeleted: true},
+		block2: {},
+	})
+
+	// A block marked for deletion is deleted from the cold storage too.
+	require.NoError(t, block.MarkForDeletion(ctx, logger, bucket.NewUserBucketClient(userID, bucketClient, nil), block1, "", promauto.With(nil).NewCounter(prometheus.CounterOpts{})))
+
+	require.NoError(t, cleaner.runCleanupWithErr(ctx))
+	assertObjectExists(bucketClient, block1, block.MetaFilename, false)
+	assertObjectExists(coldBucketClient, block1, block.MetaFilename, false)
+	assertObjectExists(coldBucketClient, block1, block.IndexFilename, false)
+	assertBlocksTier(map[ulid.ULID]bucketindex.Block{
+		block2: {},
+	})
+}
@@ -29,14 +29,18 @@ import (
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/model/relabel"
 	"github.com/thanos-io/objstore"
 	"go.uber.org/atomic"
 
+	"github.com/grafana/mimir/pkg/alertmanager/alertstore"
+	"github.com/grafana/mimir/pkg/ruler/rulestore"
 	"github.com/grafana/mimir/pkg/storage/bucket"
 	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
 	"github.com/grafana/mimir/pkg/storage/tsdb/block"
 	"github.com/grafana/mimir/pkg/util"
 	util_log "github.com/grafana/mimir/pkg/util/log"
+	"github.com/grafana/mimir/pkg/util/validation"
 )
 
 const (
@@ -60,6 +64,8 @@ var (
 	errInvalidMaxClosingBlocksConcurrency         = fmt.Errorf("invalid max-closing-blocks-concurrency value, must be positive")
 	errInvalidSymbolFlushersConcurrency           = fmt.Errorf("invalid symbols-flushers-concurrency value, must be positive")
 	errInvalidMaxBlockUploadValidationConcurrency = fmt.Errorf("invalid max-block-upload-validation-concurrency value, can't be negative")
+	errInvalidBlockVerificationBlocksPerTenant    = fmt.Errorf("invalid block-verification-blocks-per-tenant value, must be positive")
+	errInvalidCompactionRoundMaxBytes             = fmt.Errorf("invalid compaction-round-max-bytes value, can't be negative")
 	RingOp                                        = ring.NewOp([]ring.InstanceState{ring.ACTIVE}, nil)
 
 	// compactionIgnoredLabels defines the external labels that compactor will
@@ -104,8 +110,16 @@ type Config struct {
 	DeletionDelay              time.Duration           `yaml:"deletion_delay" category:"advanced"`
 	TenantCleanupDelay         time.Duration           `yaml:"tenant_cleanup_delay" category:"advanced"`
 	MaxCompactionTime          time.Duration           `yaml:"max_compaction_time" category:"advanced"`
+	CompactionRoundMaxBytes    int64                   `yaml:"compaction_round_max_bytes" category:"experimental"`
 	NoBlocksFileCleanupEnabled bool                    `yaml:"no_blocks_file_cleanup_enabled" category:"experimental"`
 
+	// Blocks integrity verification options.
+	BlockVerificationInterval        time.Duration `yaml:"block_verification_interval" category:"experimental"`
+	BlockVerificationBlocksPerTenant int           `yaml:"block_verification_blocks_per_tenant" category:"experimental"`
+
+	// Tenant operations options.
+	TenantOperationsEnabled bool `yaml:"tenant_operations_enabled" category:"experimental"`
+
 	// Compactor concurrency options
 	MaxOpeningBlocksConcurrency         int `yaml:"max_opening_blocks_concurrency" category:"advanced"`          // Number of goroutines opening blocks before compaction.
 	MaxClosingBlocksConcurrency         int `yaml:"max_closing_blocks_concurrency" category:"advanced"`          // Max number of blocks that can be closed concurrently during split compaction. Note that closing of newly compacted block uses a lot of memory for writing index.
@@ -120,6 +134,9 @@ type Config struct {
 
 	CompactionJobsOrder string `yaml:"compaction_jobs_order" category:"advanced"`
 
+	// Compaction jobs scheduling.
+	Scheduler SchedulerConfig `yaml:"scheduler"`
+
 	// No need to add options to customize the retry backoff,
 	// given the defaults should be fine, but allow to override
 	// it in tests.
@@ -134,6 +151,7 @@ type Config struct {
 // RegisterFlags registers the MultitenantCompactor flags.
 func (cfg *Config) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 	cfg.ShardingRing.RegisterFlags(f, logger)
+	cfg.Scheduler.RegisterFlags(f)
 
 	cfg.BlockRanges = mimir_tsdb.DurationList{2 * time.Hour, 12 * time.Hour, 24 * time.Hour}
 	cfg.retryMinBackoff = 10 * time.Second
@@ -145,6 +163,7 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 	f.StringVar(&cfg.DataDir, "compactor.data-dir", "./data-compactor/", "Directory to temporarily store blocks during compaction. This directory is not required to be persisted between restarts.")
 	f.DurationVar(&cfg.CompactionInterval, "compactor.compaction-interval", time.Hour, "The frequency at which the compaction runs")
 	f.DurationVar(&cfg.MaxCompactionTime, "compactor.max-compaction-time", time.Hour, "Max time for starting compactions for a single tenant. After this time no new compactions for the tenant are started before next compaction cycle. This can help in multi-tenant environments to avoid single tenant using all compaction time, but also in single-tenant environments to force new discovery of blocks more often. 0 = disabled.")
+	f.Int64Var(&cfg.CompactionRoundMaxBytes, "compactor.compaction-round-max-bytes", 0, "When greater than 0, the compactor compacts the tenants in rounds, starting in each round the compaction jobs of a tenant up to this estimated size of the source blocks multiplied by the tenant's -compactor.scheduling-weight, and at least one job. Rounds are repeated until the tenants have no pending jobs, so that a tenant with many pending jobs doesn't prevent the compaction of the other tenants. 0 to run all the jobs of a tenant before compacting the next one. Doesn't apply when the compactor runs the jobs leased from the compactor-scheduler.")
 	f.IntVar(&cfg.CompactionRetries, "compactor.compaction-retries", 3, "How many times to retry a failed compaction within a single compaction run.")
 	f.IntVar(&cfg.CompactionConcurrency, "compactor.compaction-concurrency", 1, "Max number of concurrent compactions running.")
 	f.DurationVar(&cfg.CompactionWaitPeriod, "compactor.first-level-compaction-wait-period", 25*time.Minute, "How long the compactor waits before compacting first-level blocks that are uploaded by the ingesters. This configuration option allows for the reduction of cases where the compactor begins to compact blocks before all ingesters have uploaded their blocks to the storage.")
@@ -156,6 +175,9 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 		"If 0, blocks will be deleted straight away. Note that deleting blocks immediately can cause query failures.")
 	f.DurationVar(&cfg.TenantCleanupDelay, "compactor.tenant-cleanup-delay", 6*time.Hour, "For tenants marked for deletion, this is the time between deletion of the last block, and doing final cleanup (marker files, debug files) of the tenant.")
 	f.BoolVar(&cfg.NoBlocksFileCleanupEnabled, "compactor.no-blocks-file-cleanup-enabled", false, "If enabled, will delete the bucket-index, markers and debug files in the tenant bucket when there are no blocks left in the index.")
+	f.DurationVar(&cfg.BlockVerificationInterval, "compactor.block-verification-interval", 0, "How frequently the compactor verifies the integrity of a sample of blocks of each tenant. Blocks failing the verification are quarantined, and excluded from queries and compaction until the quarantine mark is removed. 0 to disable.")
+	f.IntVar(&cfg.BlockVerificationBlocksPerTenant, "compactor.block-verification-blocks-per-tenant", 1, "Max number of blocks verified for each tenant at each block verification run. The least recently verified blocks are verified first.")
+	f.BoolVar(&cfg.TenantOperationsEnabled, "compactor.tenant-operations-enabled", false, "If enabled, the compactor exposes an API to copy, rename or merge tenants, and runs the tenant operations created with it. Operations copy the blocks, the rule groups and the alertmanager configuration of the source tenant to the destination tenant.")
 	// compactor concurrency options
 	f.IntVar(&cfg.MaxOpeningBlocksConcurrency, "compactor.max-opening-blocks-concurrency", 1, "Number of goroutines opening blocks before compaction.")
 	f.IntVar(&cfg.MaxClosingBlocksConcurrency, "compactor.max-closing-blocks-concurrency", 1, "Max number of blocks that can be closed concurrently during split compaction. Note that closing a newly compacted block uses a lot of memory for writing the index.")
@@ -195,9 +217,18 @@ func (cfg *Config) Validate(logger log.Logger) error {
 	if cfg.MaxBlockUploadValidationConcurrency < 0 {
 		return errInvalidMaxBlockUploadValidationConcurrency
 	}
+	if cfg.BlockVerificationInterval > 0 && cfg.BlockVerificationBlocksPerTenant < 1 {
+		return errInvalidBlockVerificationBlocksPerTenant
+	}
+	if cfg.CompactionRoundMaxBytes < 0 {
+		return errInvalidCompactionRoundMaxBytes
+	}
 	if !util.StringsContain(CompactionOrders, cfg.CompactionJobsOrder) {
 		return errInvalidCompactionOrder
 	}
+	if err := cfg.Scheduler.Validate(); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -238,6 +269,49 @@ type ConfigProvider interface {
 
 	// CompactorInMemoryTenantMetaCacheSize returns number of parsed *Meta objects that we can keep in memory for the user between compactions.
 	CompactorInMemoryTenantMetaCacheSize(userID string) int
+
+	// CompactorExemplarsRetentionPeriod returns the retention period of the exemplars stored in the blocks for a given user.
+	CompactorExemplarsRetentionPeriod(userID string) time.Duration
+
+	// CompactorBlockRanges returns the compaction time ranges for a given user. Empty if not overridden.
+	CompactorBlockRanges(userID string) []time.Duration
+
+	// IngesterTSDBBlockRangePeriod returns the range of the blocks cut by the ingesters for a given user. 0 if not overridden.
+	IngesterTSDBBlockRangePeriod(userID string) time.Duration
+
+	// OutOfOrderTimeWindow returns the out-of-order time window of the ingesters for a given user.
+	OutOfOrderTimeWindow(userID string) time.Duration
+
+	// CompactorDownsampling5mAfter returns the age after which the raw blocks are downsampled to 5m resolution for a given user.
+	// 0 if downsampling is disabled.
+	CompactorDownsampling5mAfter(userID string) time.Duration
+
+	// CompactorDownsampling1hAfter returns the age after which the 5m blocks are downsampled to 1h resolution for a given user.
+	// 0 if disabled.
+	CompactorDownsampling1hAfter(userID string) time.Duration
+
+	// CompactorBlocksRetentionPeriod5m returns the retention period of the 5m downsampled blocks for a given user.
+	CompactorBlocksRetentionPeriod5m(userID string) time.Duration
+
+	// CompactorBlocksRetentionPeriod1h returns the retention period of the 1h downsampled blocks for a given user.
+	CompactorBlocksRetentionPeriod1h(userID string) time.Duration
+
+	// CompactorSeriesRetentionPolicies returns the series retention policies for a given user.
+	CompactorSeriesRetentionPolicies(userID string) validation.SeriesRetentionPolicies
+
+	// CompactorRelabelConfigs returns the relabel configs applied to the series of a given user.
+	CompactorRelabelConfigs(userID string) []*relabel.Config
+
+	// CompactorColdStorageAfter returns the age after which the blocks of a given user are moved to the cold storage.
+	CompactorColdStorageAfter(userID string) time.Duration
+
+	// CompactorMaxThroughputBytesPerSecond returns the maximum bytes per second downloaded and uploaded by the compaction
+	// jobs of a given user, split between the compactors running them. 0 if not limited.
+	CompactorMaxThroughputBytesPerSecond(userID string) int64
+
+	// CompactorSchedulingWeight returns the weight of a given user when the compactor-scheduler shares the compactors
+	// between the tenants, or when the compactor compacts the tenants in rounds.
+	CompactorSchedulingWeight(userID string) float64
 }
 
 // MultitenantCompactor is a multi-tenant TSDB block compactor based on Thanos.
@@ -257,9 +331,15 @@ type MultitenantCompactor struct {
 	blocksGrouperFactory   BlocksGrouperFactory
 	blocksCompactorFactory BlocksCompactorFactory
 
+	// Function that creates the cold storage bucket client, nil if the cold storage is disabled.
+	coldBucketClientFactory func(ctx context.Context) (objstore.Bucket, error)
+
 	// Blocks cleaner is responsible for hard deletion of blocks marked for deletion.
 	blocksCleaner *BlocksCleaner
 
+	// Block verifier is responsible for verifying the blocks integrity, nil if disabled.
+	blockVerifier *BlockVerifier
+
 	// Underlying compactor and planner for compacting TSDB blocks.
 	blocksCompactor Compactor
 	blocksPlanner   Planner
@@ -276,6 +356,19 @@ type MultitenantCompactor struct {
 	shardingStrategy shardingStrategy
 	jobsOrder        JobsOrderFunc
 
+	// Client used to lease the compaction jobs from the compactor-scheduler, if configured.
+	schedulerClient *schedulerClient
+
+	// Stores used by the tenant operations to copy the rule groups and the alertmanager configuration, nil if not set.
+	ruleStore  rulestore.RuleStore
+	alertStore alertstore.AlertStore
+
+	// Limiters of the bytes per second downloaded and uploaded by the compaction jobs of each tenant.
+	throughputLimiters *tenantThroughputLimiters
+
+	// Throughput of the compaction jobs of each tenant, used to estimate the time to run the pending jobs.
+	jobsThroughput *jobsThroughputEstimator
+
 	// Metrics.
 	compactionRunsStarted          prometheus.Counter
 	compactionRunsCompleted        prometheus.Counter
@@ -289,6 +382,34 @@ type MultitenantCompactor struct {
 	compactionRunInterval          prometheus.Gauge
 	blocksMarkedForDeletion        prometheus.Counter
 
+	// Metrics tracking the blocks rewritten to permanently delete series.
+	seriesDeletionBlocksRewritten         prometheus.Counter
+	seriesDeletionBlocksMarkedForDeletion prometheus.Counter
+	seriesDeletionRequestsProcessed       prometheus.Counter
+	seriesDeletionRequestsDeleted         prometheus.Counter
+	seriesDeletionBlocksRewriteFailures   prometheus.Counter
+
+	// Metrics tracking the blocks rewritten to remove the expired series.
+	seriesRetentionBlocksRewritten         prometheus.Counter
+	seriesRetentionBlocksMarkedForDeletion prometheus.Counter
+	seriesRetentionBlocksRewriteFailures   prometheus.Counter
+
+	// Metrics tracking the blocks rewritten to relabel their series.
+	seriesRelabelBlocksRewritten         prometheus.Counter
+	seriesRelabelBlocksMarkedForDeletion prometheus.Counter
+	seriesRelabelBlocksRewriteFailures   prometheus.Counter
+
+	// Metrics tracking the downsampling of the blocks.
+	blocksDownsampled        *prometheus.CounterVec
+	blocksDownsampleFailures *prometheus.CounterVec
+
+	// Metrics tracking the tenant operations.
+	tenantOperationBlocksCopied prometheus.Counter
+	tenantOperationsCompleted   prometheus.Counter
+
+	// Estimated time to run the compaction jobs of each tenant owned by the compactor.
+	tenantCatchUpSeconds *prometheus.GaugeVec
+
 	// outOfSpace is a separate metric for out-of-space errors because this is a common issue which often requires an operator to investigate,
 	// so alerts need to be able to treat it with higher priority than other compaction errors.
 	outOfSpace prometheus.Counter
@@ -328,6 +449,12 @@ func NewMultitenantCompactor(compactorCfg Config, storageCfg mimir_tsdb.BlocksSt
 		return nil, errors.Wrap(err, "failed to create blocks compactor")
 	}
 
+	if storageCfg.ColdStorage.Enabled {
+		mimirCompactor.coldBucketClientFactory = func(ctx context.Context) (objstore.Bucket, error) {
+			return bucket.NewClient(ctx, storageCfg.ColdStorage.Bucket, "compactor-cold-storage", logger, registerer)
+		}
+	}
+
 	return mimirCompactor, nil
 }
 
@@ -353,6 +480,7 @@ func newMultitenantCompactor(
 		blocksGrouperFactory:   blocksGrouperFactory,
 		blocksCompactorFactory: blocksCompactorFactory,
 		metaCaches:             map[string]*block.MetaCache{},
+		jobsThroughput:         newJobsThroughputEstimator(),
 
 		compactionRunsStarted: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
 			Name: "cortex_compactor_runs_started_total",
@@ -405,6 +533,73 @@ func newMultitenantCompactor(
 			Help:        blocksMarkedForDeletionHelp,
 			ConstLabels: prometheus.Labels{"reason": "compaction"},
 		}),
+		seriesDeletionBlocksRewritten: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_series_deletion_blocks_rewritten_total",
+			Help: "Total number of blocks rewritten by the compactor to permanently delete series.",
+		}),
+		seriesDeletionBlocksMarkedForDeletion: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name:        blocksMarkedForDeletionName,
+			Help:        blocksMarkedForDeletionHelp,
+			ConstLabels: prometheus.Labels{"reason": "series-deletion"},
+		}),
+		seriesDeletionRequestsProcessed: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_series_deletion_requests_processed_total",
+			Help: "Total number of series deletion requests for which the compactor has finished rewriting the blocks.",
+		}),
+		seriesDeletionRequestsDeleted: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_series_deletion_requests_deleted_total",
+			Help: "Total number of processed series deletion requests deleted by the compactor because they're older than the TTL.",
+		}),
+		seriesDeletionBlocksRewriteFailures: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_series_deletion_blocks_rewrite_failures_total",
+			Help: "Total number of blocks the compactor failed to rewrite to permanently delete series.",
+		}),
+		seriesRetentionBlocksRewritten: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_series_retention_blocks_rewritten_total",
+			Help: "Total number of blocks rewritten by the compactor to remove the series expired by the series retention policies.",
+		}),
+		seriesRetentionBlocksMarkedForDeletion: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name:        blocksMarkedForDeletionName,
+			Help:        blocksMarkedForDeletionHelp,
+			ConstLabels: prometheus.Labels{"reason": "series-retention"},
+		}),
+		seriesRetentionBlocksRewriteFailures: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_series_retention_blocks_rewrite_failures_total",
+			Help: "Total number of blocks the compactor failed to rewrite to remove the series expired by the series retention policies.",
+		}),
+		seriesRelabelBlocksRewritten: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_series_relabel_blocks_rewritten_total",
+			Help: "Total number of blocks rewritten by the compactor to apply the relabel configs to their series.",
+		}),
+		seriesRelabelBlocksMarkedForDeletion: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name:        blocksMarkedForDeletionName,
+			Help:        blocksMarkedForDeletionHelp,
+			ConstLabels: prometheus.Labels{"reason": "series-relabel"},
+		}),
+		seriesRelabelBlocksRewriteFailures: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_series_relabel_blocks_rewrite_failures_total",
+			Help: "Total number of blocks the compactor failed to rewrite to apply the relabel configs to their series.",
+		}),
+		blocksDownsampled: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_compactor_blocks_downsampled_total",
+			Help: "Total number of downsampled blocks created by the compactor.",
+		}, []string{"resolution"}),
+		blocksDownsampleFailures: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_compactor_block_downsample_failures_total",
+			Help: "Total number of failures downsampling a block.",
+		}, []string{"resolution"}),
+		tenantOperationBlocksCopied: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_tenant_operation_blocks_copied_total",
+			Help: "Total number of blocks copied by the compactor to the destination tenant of tenant operations.",
+		}),
+		tenantOperationsCompleted: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_tenant_operations_completed_total",
+			Help: "Total number of tenant operations completed by the compactor.",
+		}),
+		tenantCatchUpSeconds: promauto.With(registerer).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_compactor_tenant_estimated_catch_up_seconds",
+			Help: "Estimated time to run the pending compaction jobs of the tenant owned by the compactor, based on the estimated cost of the jobs and the observed compaction throughput, as of the last compaction run of the tenant.",
+		}, []string{"user"}),
 		blockUploadBlocks: promauto.With(registerer).NewGaugeVec(prometheus.GaugeOpts{
 			Name: "cortex_block_upload_api_blocks_total",
 			Help: "Total number of blocks successfully uploaded and validated using the block upload API.",
@@ -435,11 +630,21 @@ func newMultitenantCompactor(
 		level.Info(c.logger).Log("msg", "compactor using disabled users", "disabled", compactorCfg.DisabledTenants)
 	}
 
+	c.throughputLimiters = newTenantThroughputLimiters(cfgProvider, c.tenantCompactorsCount)
+
 	c.jobsOrder = GetJobsOrderFunction(compactorCfg.CompactionJobsOrder)
 	if c.jobsOrder == nil {
 		return nil, errInvalidCompactionOrder
 	}
 
+	if compactorCfg.Scheduler.Address != "" {
+		var err error
+		if c.schedulerClient, err = newSchedulerClient(compactorCfg.Scheduler, registerer); err != nil {
+			return nil, errors.Wrap(err, "failed to create compactor-scheduler client")
+		}
+		level.Info(c.logger).Log("msg", "compactor running the compaction jobs leased from the compactor-scheduler", "address", compactorCfg.Scheduler.Address)
+	}
+
 	c.Service = services.NewBasicService(c.starting, c.running, c.stopping)
 
 	// The last successful compaction run metric is exposed as seconds since epoch, so we need to use seconds for this metric.
@@ -519,6 +724,13 @@ func (c *MultitenantCompactor) starting(ctx context.Context) error {
 	allowedTenants := util.NewAllowedTenants(c.compactorCfg.EnabledTenants, c.compactorCfg.DisabledTenants)
 	c.shardingStrategy = newSplitAndMergeShardingStrategy(allowedTenants, c.ring, c.ringLifecycler, c.cfgProvider)
 
+	blockStatsMaxLabelNames := 0
+	blockStatsMaxTotalBytes := 0
+	if bucketIndexCfg := c.storageCfg.BucketStore.BucketIndex; bucketIndexCfg.BlockStatsEnabled {
+		blockStatsMaxLabelNames = bucketIndexCfg.BlockStatsMaxLabelNames
+		blockStatsMaxTotalBytes = bucketIndexCfg.BlockStatsMaxTotalSizeBytes
+	}
+
 	// Create the blocks cleaner (service).
 	c.blocksCleaner = NewBlocksCleaner(BlocksCleanerConfig{
 		DeletionDelay:                 c.compactorCfg.DeletionDelay,
@@ -529,14 +741,42 @@ func (c *MultitenantCompactor) starting(ctx context.Context) error {
 		GetDeletionMarkersConcurrency: defaultGetDeletionMarkersConcurrency,
 		NoBlocksFileCleanupEnabled:    c.compactorCfg.NoBlocksFileCleanupEnabled,
 		CompactionBlockRanges:         c.compactorCfg.BlockRanges,
+		MetadataIndexEnabled:          c.storageCfg.DurableMetricsMetadataEnabled,
+		ColdStorageRewriteInPlace:     c.storageCfg.ColdStorage.RewriteInPlace,
+		ColdStorageMoveConcurrency:    defaultColdStorageMoveConcurrency,
+		BlockStatsMaxLabelNames:       blockStatsMaxLabelNames,
+		BlockStatsMaxTotalBytes:       blockStatsMaxTotalBytes,
 	}, c.bucketClient, c.shardingStrategy.blocksCleanerOwnsUser, c.cfgProvider, c.parentLogger, c.registerer)
 
+	if c.coldBucketClientFactory != nil {
+		coldBucketClient, err := c.coldBucketClientFactory(ctx)
+		if err != nil {
+			c.ringSubservices.StopAsync()
+			return errors.Wrap(err, "failed to create cold storage bucket client")
+		}
+		c.blocksCleaner.coldBucketClient = coldBucketClient
+	}
+
 	// Start blocks cleaner asynchronously, don't wait until initial cleanup is finished.
 	if err := c.blocksCleaner.StartAsync(ctx); err != nil {
 		c.ringSubservices.StopAsync()
 		return errors.Wrap(err, "failed to start the blocks cleaner")
 	}
 
+	// Create and start the block verifier (service), if enabled.
+	if c.compactorCfg.BlockVerificationInterval > 0 {
+		c.blockVerifier = NewBlockVerifier(BlockVerifierConfig{
+			VerificationInterval: util.DurationWithJitter(c.compactorCfg.BlockVerificationInterval, 0.1),
+			BlocksPerTenant:      c.compactorCfg.BlockVerificationBlocksPerTenant,
+			DataDir:              filepath.Join(c.compactorCfg.DataDir, "verify"),
+		}, c.bucketClient, c.shardingStrategy.blocksCleanerOwnsUser, c.cfgProvider, c.parentLogger, c.registerer)
+
+		if err := c.blockVerifier.StartAsync(ctx); err != nil {
+			c.ringSubservices.StopAsync()
+			return errors.Wrap(err, "failed to start the block verifier")
+		}
+	}
+
 	return nil
 }
 
@@ -574,6 +814,12 @@ func (c *MultitenantCompactor) stopping(_ error) error {
 	ctx := context.Background()
 
 	services.StopAndAwaitTerminated(ctx, c.blocksCleaner) //nolint:errcheck
+	if c.blockVerifier != nil {
+		services.StopAndAwaitTerminated(ctx, c.blockVerifier) //nolint:errcheck
+	}
+	if c.schedulerClient != nil {
+		c.schedulerClient.close() //nolint:errcheck
+	}
 	if c.ringSubservices != nil {
 		return services.StopManagerAndAwaitStopped(ctx, c.ringSubservices)
 	}
@@ -581,6 +827,19 @@ func (c *MultitenantCompactor) stopping(_ error) error {
 }
 
 func (c *MultitenantCompactor) running(ctx context.Context) error {
+	if c.schedulerClient != nil {
+		workersCtx, cancelWorkers := context.WithCancel(ctx)
+		workersDone := make(chan struct{})
+		go func() {
+			defer close(workersDone)
+			c.runSchedulerWorkers(workersCtx)
+		}()
+		defer func() {
+			cancelWorkers()
+			<-workersDone
+		}()
+	}
+
 	// Run an initial compaction before starting the interval.
 	c.compactUsers(ctx)
 
@@ -642,8 +901,41 @@ func (c *MultitenantCompactor) compactUsers(ctx context.Context) {
 		users[i], users[j] = users[j], users[i]
 	})
 
+	// compactUser runs a compaction round of the user, and returns whether the user has pending
+	// compaction jobs, and whether the compaction has been interrupted by a shutdown.
+	compactUser := func(userID string, round *compactionRound) (pending, interrupted bool) {
+		pending, err := c.compactUserWithRetries(ctx, userID, round)
+		if err != nil {
+			switch {
+			case errors.Is(err, context.Canceled):
+				// We don't want to count shutdowns as failed compactions because we will pick up with the rest of the compaction after the restart.
+				level.Info(c.logger).Log("msg", "compaction for user was interrupted by a shutdown", "user", userID)
+				return false, true
+			case errors.Is(err, syscall.ENOSPC):
+				c.outOfSpace.Inc()
+				fallthrough
+			default:
+				c.compactionRunFailedTenants.Inc()
+				compactionErrorCount++
+				level.Error(c.logger).Log("msg", "failed to compact user blocks", "user", userID, "err", err)
+			}
+			return false, false
+		}
+
+		if pending {
+			level.Info(c.logger).Log("msg", "compacted user blocks, pending compaction jobs will run in the next round", "user", userID, "round", round.index)
+			return true, false
+		}
+		c.compactionRunSucceededTenants.Inc()
+		level.Info(c.logger).Log("msg", "successfully compacted user blocks", "user", userID)
+		return false, false
+	}
+
 	// Keep track of users owned by this shard, so that we can delete the local files for all other users.
 	ownedUsers := map[string]struct{}{}
+	// Users having pending compaction jobs after the first round, and their rounds.
+	var pendingUsers []string
+	rounds := map[string]*compactionRound{}
 	for _, userID := range users {
 		// Ensure the context has not been canceled (ie. compactor shutdown has been triggered).
 		if ctx.Err() != nil {
@@ -658,6 +950,7 @@ func (c *MultitenantCompactor) compactUsers(ctx context.Context) {
 			continue
 		} else if !owned {
 			c.compactionRunSkippedTenants.Inc()
+			c.removeTenantCompactionEstimates(userID)
 			level.Debug(c.logger).Log("msg", "skipping user because it is not owned by this shard", "user", userID)
 			continue
 		}
@@ -670,31 +963,45 @@ func (c *MultitenantCompactor) compactUsers(ctx context.Context) {
 			continue
 		} else if markedForDeletion {
 			c.compactionRunSkippedTenants.Inc()
+			c.removeTenantCompactionEstimates(userID)
 			level.Debug(c.logger).Log("msg", "skipping user because it is marked for deletion", "user", userID)
 			continue
 		}
 
 		level.Info(c.logger).Log("msg", "starting compaction of user blocks", "user", userID)
 
-		if err = c.compactUserWithRetries(ctx, userID); err != nil {
-			switch {
-			case errors.Is(err, context.Canceled):
-				// We don't want to count shutdowns as failed compactions because we will pick up with the rest of the compaction after the restart.
-				level.Info(c.logger).Log("msg", "compaction for user was interrupted by a shutdown", "user", userID)
+		round := &compactionRound{startedJobs: map[string]struct{}{}}
+		pending, interrupted := compactUser(userID, round)
+		if interrupted {
+			return
+		} else if pending {
+			pendingUsers = append(pendingUsers, userID)
+			rounds[userID] = round
+		}
+	}
+
+	// Compact the tenants having pending jobs in rounds, so that a tenant with many pending jobs
+	// doesn't prevent the compaction of the other tenants.
+	for len(pendingUsers) > 0 {
+		var nextPendingUsers []string
+		for _, userID := range pendingUsers {
+			if ctx.Err() != nil {
+				level.Info(c.logger).Log("msg", "interrupting compaction of user blocks", "err", ctx.Err())
 				return
-			case errors.Is(err, syscall.ENOSPC):
-				c.outOfSpace.Inc()
-				fallthrough
-			default:
-				c.compactionRunFailedTenants.Inc()
-				compactionErrorCount++
-				level.Error(c.logger).Log("msg", "failed to compact user blocks", "user", userID, "err", err)
 			}
-			continue
-		}
 
-		c.compactionRunSucceededTenants.Inc()
-		level.Info(c.logger).Log("msg", "successfully compacted user blocks", "user", userID)
+			round := rounds[userID]
+			round.index++
+			level.Info(c.logger).Log("msg", "continuing compaction of user blocks", "user", userID, "round", round.index)
+
+			pending, interrupted := compactUser(userID, round)
+			if interrupted {
+				return
+			} else if pending {
+				nextPendingUsers = append(nextPendingUsers, userID)
+			}
+		}
+		pendingUsers = nextPendingUsers
 	}
 
 	// Delete local files for unowned tenants, if there are any. This cleans up
@@ -727,7 +1034,36 @@ func (c *MultitenantCompactor) compactUsers(ctx context.Context) {
 	succeeded = true
 }
 
-func (c *MultitenantCompactor) compactUserWithRetries(ctx context.Context, userID string) error {
+// tenantCompactorsCount returns the number of compactors which can run the compaction jobs of a tenant.
+func (c *MultitenantCompactor) tenantCompactorsCount(userID string) int {
+	if c.ring == nil {
+		return 1
+	}
+	// The compactor-scheduler leases the jobs of any tenant to any compactor.
+	if c.schedulerClient != nil {
+		return c.ring.InstancesCount()
+	}
+	return c.ring.ShuffleShard(userID, c.cfgProvider.CompactorTenantShardSize(userID)).InstancesCount()
+}
+
+// removeTenantCompactionEstimates removes the compaction estimates of a tenant which isn't compacted by this compactor anymore.
+func (c *MultitenantCompactor) removeTenantCompactionEstimates(userID string) {
+	c.tenantCatchUpSeconds.DeleteLabelValues(userID)
+	c.jobsThroughput.remove(userID)
+}
+
+// compactionRound is a round of the compaction of a tenant, in a compaction run.
+type compactionRound struct {
+	// index of the round in the compaction run, starting from 0.
+	index int
+	// startedJobs holds the keys of the compaction jobs started in the previous rounds, which aren't started again
+	// in the same compaction run.
+	startedJobs map[string]struct{}
+}
+
+// compactUserWithRetries runs a compaction round of the user, retrying on failure, and returns whether
+// the user has pending compaction jobs for the next round.
+func (c *MultitenantCompactor) compactUserWithRetries(ctx context.Context, userID string, round *compactionRound) (bool, error) {
 	var lastErr error
 
 	retries := backoff.New(ctx, backoff.Config{
@@ -737,21 +1073,34 @@ func (c *MultitenantCompactor) compactUserWithRetries(ctx context.Context, userI
 	})
 
 	for retries.Ongoing() {
-		lastErr = c.compactUser(ctx, userID)
+		var pending bool
+		pending, lastErr = c.compactUser(ctx, userID, round)
 		if lastErr == nil {
-			return nil
+			return pending, nil
 		}
 
 		retries.Wait()
 	}
 
-	return lastErr
+	return false, lastErr
 }
 
-func (c *MultitenantCompactor) compactUser(ctx context.Context, userID string) error {
+// compactUser runs a compaction round of the user, and returns whether the user has pending compaction jobs for
+// the next round. The user blocks are rewritten and downsampled only in the first round.
+func (c *MultitenantCompactor) compactUser(ctx context.Context, userID string, round *compactionRound) (bool, error) {
 	userBucket := bucket.NewUserBucketClient(userID, c.bucketClient, c.cfgProvider)
 	userLogger := util_log.WithUserID(userID, c.logger)
 
+	// The tenant blocks aren't compacted while tenant operations are copying blocks to the tenant.
+	if c.compactorCfg.TenantOperationsEnabled {
+		if running, err := c.runTenantOperations(ctx, userID, userLogger); err != nil {
+			return false, errors.Wrap(err, "tenant operations")
+		} else if running {
+			level.Info(userLogger).Log("msg", "skipping compaction of user blocks because tenant operations are running")
+			return false, nil
+		}
+	}
+
 	reg := prometheus.NewRegistry()
 	defer c.syncerMetrics.gatherThanosSyncerMetrics(reg, userLogger)
 
@@ -765,6 +1114,12 @@ func (c *MultitenantCompactor) compactUser(ctx context.Context, userID string) e
 		deduplicateBlocksFilter,
 		// removes blocks that should not be compacted due to being marked so.
 		NewNoCompactionMarkFilter(userBucket),
+		// removes blocks moved to the cold storage, which are never compacted.
+		newColdStorageMarkFilter(userBucket),
+		// removes blocks which failed the integrity verification.
+		newQuarantineMarkFilter(userBucket),
+		// removes downsampled blocks, which are never compacted.
+		excludeDownsampledBlocksFilter{},
 	}
 
 	var metaCache *block.MetaCache
@@ -793,7 +1148,7 @@ func (c *MultitenantCompactor) compactUser(ctx context.Context, userID string) e
 		metaCache,
 	)
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	syncer, err := newMetaSyncer(
@@ -805,17 +1160,17 @@ func (c *MultitenantCompactor) compactUser(ctx context.Context, userID string) e
 		c.blocksMarkedForDeletion,
 	)
 	if err != nil {
-		return errors.Wrap(err, "failed to create syncer")
+		return false, errors.Wrap(err, "failed to create syncer")
 	}
 
 	compactor, err := NewBucketCompactor(
 		userLogger,
 		syncer,
 		c.blocksGrouperFactory(ctx, c.compactorCfg, c.cfgProvider, userID, userLogger, reg),
-		c.blocksPlanner,
+		c.tenantPlanner(userID),
 		c.blocksCompactor,
 		path.Join(c.compactorCfg.DataDir, "compact"),
-		userBucket,
+		c.throughputLimiters.bucket(userID, userBucket),
 		c.compactorCfg.CompactionConcurrency,
 		true, // Skip unhealthy blocks, and mark them for no-compaction.
 		c.shardingStrategy.ownJob,
@@ -825,17 +1180,124 @@ func (c *MultitenantCompactor) compactUser(ctx context.Context, userID string) e
 		c.bucketCompactorMetrics,
 	)
 	if err != nil {
-		return errors.Wrap(err, "failed to create bucket compactor")
+		return false, errors.Wrap(err, "failed to create bucket compactor")
+	}
+
+	if err := c.applyTenantJobsSettings(ctx, compactor, userID, userLogger); err != nil {
+		return false, err
+	}
+	compactor.jobsThroughput = c.jobsThroughput
+	compactor.catchUpSeconds = c.tenantCatchUpSeconds.WithLabelValues(userID)
+
+	// Only one compactor rewrites the tenant blocks for series deletion.
+	if c.storageCfg.SeriesDeletionEnabled && round.index == 0 {
+		if owned, err := c.shardingStrategy.blocksCleanerOwnsUser(userID); err != nil {
+			return false, errors.Wrap(err, "failed to check if user is owned for series deletion")
+		} else if owned {
+			// Failing to rewrite the blocks for series deletion doesn't prevent the compaction: the deleted samples
+			// are filtered out at query time, and the rewrite is retried in the next run.
+			if err := c.processSeriesDeletionRequests(ctx, userID, userBucket, compactor.seriesDeletionRequests, userLogger); err != nil {
+				if ctx.Err() != nil {
+					return false, ctx.Err()
+				}
+				level.Warn(userLogger).Log("msg", "failed to process series deletion requests", "err", err)
+			}
+		}
+	}
+
+	// Only one compactor rewrites the tenant blocks for series retention.
+	if policies := compactor.seriesRetentionPolicies; policies.Enabled() && round.index == 0 {
+		if owned, err := c.shardingStrategy.blocksCleanerOwnsUser(userID); err != nil {
+			return false, errors.Wrap(err, "failed to check if user is owned for series retention")
+		} else if owned {
+			// Failing to remove the expired series doesn't prevent the compaction: they're filtered out at query
+			// time, and removed in the next run.
+			if err := c.processSeriesRetentionPolicies(ctx, userBucket, policies, userLogger); err != nil {
+				if ctx.Err() != nil {
+					return false, ctx.Err()
+				}
+				level.Warn(userLogger).Log("msg", "failed to process series retention policies", "err", err)
+			}
+		}
+	}
+
+	// Only one compactor rewrites the tenant blocks which are not compacted anymore for series relabeling.
+	if cfgs := compactor.relabelConfigs; len(cfgs) > 0 && round.index == 0 {
+		if owned, err := c.shardingStrategy.blocksCleanerOwnsUser(userID); err != nil {
+			return false, errors.Wrap(err, "failed to check if user is owned for series relabeling")
+		} else if owned {
+			// Failing to relabel the blocks doesn't prevent the compaction: they're relabeled in the next run.
+			if err := c.processSeriesRelabeling(ctx, userID, userBucket, cfgs, userLogger); err != nil {
+				if ctx.Err() != nil {
+					return false, ctx.Err()
+				}
+				level.Warn(userLogger).Log("msg", "failed to process series relabeling", "err", err)
+			}
+		}
+	}
+
+	// When the compactor-scheduler is configured, it plans the compaction jobs and leases them to the compactors.
+	if c.schedulerClient == nil {
+		if maxBytes := c.compactorCfg.CompactionRoundMaxBytes; maxBytes > 0 {
+			compactor.maxJobsBytes = max(1, int64(float64(maxBytes)*c.cfgProvider.CompactorSchedulingWeight(userID)))
+			compactor.startedJobs = round.startedJobs
+		}
+		if err := compactor.Compact(ctx, c.compactorCfg.MaxCompactionTime); err != nil {
+			return false, errors.Wrap(err, "compaction")
+		}
 	}
 
-	if err := compactor.Compact(ctx, c.compactorCfg.MaxCompactionTime); err != nil {
-		return errors.Wrap(err, "compaction")
+	// Only one compactor downsamples the tenant blocks, once their compaction is done.
+	if c.cfgProvider.CompactorDownsampling5mAfter(userID) > 0 && !compactor.hasPendingJobs {
+		if owned, err := c.shardingStrategy.blocksCleanerOwnsUser(userID); err != nil {
+			return false, errors.Wrap(err, "failed to check if user is owned for downsampling")
+		} else if owned {
+			if err := c.downsampleUserBlocks(ctx, userID, userBucket, userLogger); err != nil {
+				return false, errors.Wrap(err, "downsampling")
+			}
+		}
 	}
 
 	if metaCache != nil {
 		items, size, hits, misses := metaCache.Stats()
 		level.Info(userLogger).Log("msg", "per-user meta cache stats after compacting user", "items", items, "bytes_size", size, "hits", hits, "misses", misses)
 	}
+	return compactor.hasPendingJobs, nil
+}
+
+// tenantPlanner returns the planner of the compaction jobs of the tenant.
+func (c *MultitenantCompactor) tenantPlanner(userID string) Planner {
+	// The planner checks the blocks against the largest range, so it must match the tenant's ranges.
+	if ranges := tenantBlockRanges(c.compactorCfg.BlockRanges, c.cfgProvider, userID); !slices.Equal(ranges, c.compactorCfg.BlockRanges) {
+		return NewSplitAndMergePlanner(ranges.ToMilliseconds())
+	}
+	return c.blocksPlanner
+}
+
+// applyTenantJobsSettings configures the bucket compactor with the tenant settings applied by the compaction jobs.
+func (c *MultitenantCompactor) applyTenantJobsSettings(ctx context.Context, compactor *BucketCompactor, userID string, userLogger log.Logger) error {
+	if c.storageCfg.LongTermExemplarsEnabled {
+		compactor.exemplarsEnabled = true
+		compactor.exemplarsRetentionPeriod = c.cfgProvider.CompactorExemplarsRetentionPeriod(userID)
+	}
+
+	if c.storageCfg.SeriesDeletionEnabled {
+		reqs, err := mimir_tsdb.ReadSeriesDeletionRequests(ctx, c.bucketClient, userID, userLogger)
+		if err != nil {
+			return errors.Wrap(err, "failed to read series deletion requests")
+		}
+
+		// Compaction jobs apply the series deletion requests to the source blocks, so that compacted
+		// blocks don't contain deleted samples.
+		compactor.seriesDeletionRequests = reqs
+	}
+
+	// Compaction jobs remove the expired series from the source blocks, so that compacted blocks
+	// don't contain them.
+	compactor.seriesRetentionPolicies = c.cfgProvider.CompactorSeriesRetentionPolicies(userID)
+
+	// Compaction jobs relabel the series of the source blocks, merging the series with the same labels.
+	compactor.relabelConfigs = c.cfgProvider.CompactorRelabelConfigs(userID)
 	return nil
 }
 
@@ -128,6 +128,10 @@ func TestConfig_Validate(t *testing.T) {
 			setup:    func(cfg *Config) { cfg.SymbolsFlushersConcurrency = 0 },
 			expected: errInvalidSymbolFlushersConcurrency.Error(),
 		},
+		"should fail on negative value of compaction-round-max-bytes": {
+			setup:    func(cfg *Config) { cfg.CompactionRoundMaxBytes = -1 },
+			expected: errInvalidCompactionRoundMaxBytes.Error(),
+		},
 	}
 
 	for testName, testData := range tests {
@@ -246,6 +250,9 @@ func TestMultitenantCompactor_ShouldDoNothingOnNoUserBlocks(t *testing.T) {
 		cortex_compactor_blocks_marked_for_deletion_total{reason="compaction"} 0
 		cortex_compactor_blocks_marked_for_deletion_total{reason="partial"} 0
 		cortex_compactor_blocks_marked_for_deletion_total{reason="retention"} 0
+		cortex_compactor_blocks_marked_for_deletion_total{reason="series-deletion"} 0
+		cortex_compactor_blocks_marked_for_deletion_total{reason="series-relabel"} 0
+		cortex_compactor_blocks_marked_for_deletion_total{reason="series-retention"} 0
 
 		# TYPE cortex_compactor_block_cleanup_started_total counter
 		# HELP cortex_compactor_block_cleanup_started_total Total number of blocks cleanup runs started.
@@ -385,6 +392,9 @@ func TestMultitenantCompactor_ShouldRetryCompactionOnFailureWhileDiscoveringUser
 		cortex_compactor_blocks_marked_for_deletion_total{reason="compaction"} 0
 		cortex_compactor_blocks_marked_for_deletion_total{reason="partial"} 0
 		cortex_compactor_blocks_marked_for_deletion_total{reason="retention"} 0
+		cortex_compactor_blocks_marked_for_deletion_total{reason="series-deletion"} 0
+		cortex_compactor_blocks_marked_for_deletion_total{reason="series-relabel"} 0
+		cortex_compactor_blocks_marked_for_deletion_total{reason="series-retention"} 0
 
 		# TYPE cortex_compactor_block_cleanup_started_total counter
 		# HELP cortex_compactor_block_cleanup_started_total Total number of blocks cleanup runs started.
@@ -650,6 +660,9 @@ func TestMultitenantCompactor_ShouldIterateOverUsersAndRunCompaction(t *testing.
 		cortex_compactor_blocks_marked_for_deletion_total{reason="compaction"} 0
 		cortex_compactor_blocks_marked_for_deletion_total{reason="partial"} 0
 		cortex_compactor_blocks_marked_for_deletion_total{reason="retention"} 0
+		cortex_compactor_blocks_marked_for_deletion_total{reason="series-deletion"} 0
+		cortex_compactor_blocks_marked_for_deletion_total{reason="series-relabel"} 0
+		cortex_compactor_blocks_marked_for_deletion_total{reason="series-retention"} 0
 
 		# TYPE cortex_compactor_block_cleanup_started_total counter
 		# HELP cortex_compactor_block_cleanup_started_total Total number of blocks cleanup runs started.
@@ -733,6 +746,85 @@ func TestMultitenantCompactor_ShouldStopCompactingTenantOnReachingMaxCompactionT
 	}, removeIgnoredLogs(strings.Split(strings.TrimSpace(logs.String()), "\n")))
 }
 
+func TestMultitenantCompactor_ShouldCompactTenantInRoundsOnReachingCompactionRoundMaxBytes(t *testing.T) {
+	t.Parallel()
+
+	// By using blocks with different labels, we get two compaction jobs. Each round starts only one of them.
+	blocks := map[string]map[string]string{
+		"01DTVP434PA9VFXSW2JKB3392D": {"A": "B"},
+		"01FS51A7GQ1RQWV35DBVYQM4KF": {"A": "B"},
+		"01FN3VCQV5X342W2ZKMQQXAZRX": {"C": "D"},
+		"01FRQGQB7RWQ2TS0VWA82QTPXE": {"C": "D"},
+	}
+
+	bucketClient := &bucket.ClientMock{}
+	bucketClient.MockIter("", []string{"user-1"}, nil)
+	bucketClient.MockExists(path.Join("user-1", mimir_tsdb.TenantDeletionMarkPath), false, nil)
+	bucketClient.MockIter("user-1/", []string{"user-1/01DTVP434PA9VFXSW2JKB3392D", "user-1/01FN3VCQV5X342W2ZKMQQXAZRX", "user-1/01FS51A7GQ1RQWV35DBVYQM4KF", "user-1/01FRQGQB7RWQ2TS0VWA82QTPXE"}, nil)
+	for id, lbls := range blocks {
+		meta := blockMeta(id, 1574776800000, 1574784000000, lbls)
+		meta.Stats.NumSamples = 1000
+		content, err := json.Marshal(meta)
+		require.NoError(t, err)
+
+		bucketClient.MockGet("user-1/"+id+"/meta.json", string(content), nil)
+		bucketClient.MockGet("user-1/"+id+"/deletion-mark.json", "", nil)
+		bucketClient.MockGet("user-1/"+id+"/no-compact-mark.json", "", nil)
+	}
+	bucketClient.MockGet("user-1/bucket-index.json.gz", "", nil)
+	bucketClient.MockIter("user-1/markers/", nil, nil)
+	bucketClient.MockUpload("user-1/bucket-index.json.gz", nil)
+
+	cfg := prepareConfig(t)
+	cfg.CompactionRoundMaxBytes = 1
+	cfg.CompactionConcurrency = 1
+
+	c, _, tsdbPlanner, logs, _ := prepare(t, cfg, bucketClient)
+
+	// The planner returns no work, so the jobs started in the first round must not be started again.
+	tsdbPlanner.On("Plan", mock.Anything, mock.Anything).Return([]*block.Meta{}, nil)
+
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), c))
+
+	// Compactor doesn't wait for blocks cleaner to finish, but our test checks for cleaner metrics.
+	require.NoError(t, c.blocksCleaner.AwaitRunning(context.Background()))
+
+	test.Poll(t, 5*time.Second, 1.0, func() interface{} {
+		return prom_testutil.ToFloat64(c.compactionRunsCompleted)
+	})
+
+	require.NoError(t, services.StopAndAwaitTerminated(context.Background(), c))
+
+	// Each job has been started once.
+	tsdbPlanner.AssertNumberOfCalls(t, "Plan", 2)
+
+	assert.Equal(t, []string{
+		`level=info component=compactor msg="waiting until compactor is ACTIVE in the ring"`,
+		`level=info component=compactor msg="compactor is ACTIVE in the ring"`,
+		`level=info component=compactor msg="discovering users from bucket"`,
+		`level=info component=compactor msg="discovered users from bucket" users=1`,
+		`level=info component=compactor msg="starting compaction of user blocks" user=user-1`,
+		`level=info component=compactor user=user-1 msg="start sync of metas"`,
+		`level=info component=compactor user=user-1 msg="start of GC"`,
+		`level=debug component=compactor user=user-1 msg="grouper found a compactable blocks group" groupKey=0@12695595599644216241-merge--1574776800000-1574784000000 job="stage: merge, range start: 1574776800000, range end: 1574784000000, shard: , blocks: 01FN3VCQV5X342W2ZKMQQXAZRX (min time: 2019-11-26 14:00:00 +0000 UTC, max time: 2019-11-26 16:00:00 +0000 UTC),01FRQGQB7RWQ2TS0VWA82QTPXE (min time: 2019-11-26 14:00:00 +0000 UTC, max time: 2019-11-26 16:00:00 +0000 UTC)"`,
+		`level=debug component=compactor user=user-1 msg="grouper found a compactable blocks group" groupKey=0@414047632870839233-merge--1574776800000-1574784000000 job="stage: merge, range start: 1574776800000, range end: 1574784000000, shard: , blocks: 01DTVP434PA9VFXSW2JKB3392D (min time: 2019-11-26 14:00:00 +0000 UTC, max time: 2019-11-26 16:00:00 +0000 UTC),01FS51A7GQ1RQWV35DBVYQM4KF (min time: 2019-11-26 14:00:00 +0000 UTC, max time: 2019-11-26 16:00:00 +0000 UTC)"`,
+		`level=info component=compactor user=user-1 msg="start of compactions"`,
+		`level=info component=compactor user=user-1 msg="max size of the compaction jobs reached, no more compactions will be started" started_jobs_bytes=4000`,
+		`level=info component=compactor user=user-1 groupKey=0@12695595599644216241-merge--1574776800000-1574784000000 job_type=merge msg="compaction job succeeded" block_count=2`,
+		`level=info component=compactor user=user-1 msg="compaction iterations done"`,
+		`level=info component=compactor msg="compacted user blocks, pending compaction jobs will run in the next round" user=user-1 round=0`,
+		`level=info component=compactor msg="continuing compaction of user blocks" user=user-1 round=1`,
+		`level=info component=compactor user=user-1 msg="start sync of metas"`,
+		`level=info component=compactor user=user-1 msg="start of GC"`,
+		`level=debug component=compactor user=user-1 msg="grouper found a compactable blocks group" groupKey=0@12695595599644216241-merge--1574776800000-1574784000000 job="stage: merge, range start: 1574776800000, range end: 1574784000000, shard: , blocks: 01FN3VCQV5X342W2ZKMQQXAZRX (min time: 2019-11-26 14:00:00 +0000 UTC, max time: 2019-11-26 16:00:00 +0000 UTC),01FRQGQB7RWQ2TS0VWA82QTPXE (min time: 2019-11-26 14:00:00 +0000 UTC, max time: 2019-11-26 16:00:00 +0000 UTC)"`,
+		`level=debug component=compactor user=user-1 msg="grouper found a compactable blocks group" groupKey=0@414047632870839233-merge--1574776800000-1574784000000 job="stage: merge, range start: 1574776800000, range end: 1574784000000, shard: , blocks: 01DTVP434PA9VFXSW2JKB3392D (min time: 2019-11-26 14:00:00 +0000 UTC, max time: 2019-11-26 16:00:00 +0000 UTC),01FS51A7GQ1RQWV35DBVYQM4KF (min time: 2019-11-26 14:00:00 +0000 UTC, max time: 2019-11-26 16:00:00 +0000 UTC)"`,
+		`level=info component=compactor user=user-1 msg="start of compactions"`,
+		`level=info component=compactor user=user-1 groupKey=0@414047632870839233-merge--1574776800000-1574784000000 job_type=merge msg="compaction job succeeded" block_count=2`,
+		`level=info component=compactor user=user-1 msg="compaction iterations done"`,
+		`level=info component=compactor msg="successfully compacted user blocks" user=user-1`,
+	}, removeIgnoredLogs(strings.Split(strings.TrimSpace(logs.String()), "\n")))
+}
+
 func TestMultitenantCompactor_ShouldNotCompactBlocksMarkedForDeletion(t *testing.T) {
 	t.Parallel()
 
@@ -836,6 +928,9 @@ func TestMultitenantCompactor_ShouldNotCompactBlocksMarkedForDeletion(t *testing
 		cortex_compactor_blocks_marked_for_deletion_total{reason="compaction"} 0
 		cortex_compactor_blocks_marked_for_deletion_total{reason="partial"} 0
 		cortex_compactor_blocks_marked_for_deletion_total{reason="retention"} 0
+		cortex_compactor_blocks_marked_for_deletion_total{reason="series-deletion"} 0
+		cortex_compactor_blocks_marked_for_deletion_total{reason="series-relabel"} 0
+		cortex_compactor_blocks_marked_for_deletion_total{reason="series-retention"} 0
 
 		# TYPE cortex_compactor_block_cleanup_started_total counter
 		# HELP cortex_compactor_block_cleanup_started_total Total number of blocks cleanup runs started.
@@ -927,6 +1022,7 @@ func TestMultitenantCompactor_ShouldNotCompactBlocksForUsersMarkedForDeletion(t
 	bucketClient.MockDelete("user-1/01DTVP434PA9VFXSW2JKB3392D/meta.json", nil)
 	bucketClient.MockDelete("user-1/01DTVP434PA9VFXSW2JKB3392D/index", nil)
 	bucketClient.MockDelete("user-1/bucket-index.json.gz", nil)
+	bucketClient.MockDelete("user-1/metadata-index.json.gz", nil)
 
 	c, _, tsdbPlanner, logs, registry := prepare(t, cfg, bucketClient)
 
@@ -994,6 +1090,9 @@ func TestMultitenantCompactor_ShouldNotCompactBlocksForUsersMarkedForDeletion(t
 		cortex_compactor_blocks_marked_for_deletion_total{reason="compaction"} 0
 		cortex_compactor_blocks_marked_for_deletion_total{reason="partial"} 0
 		cortex_compactor_blocks_marked_for_deletion_total{reason="retention"} 0
+		cortex_compactor_blocks_marked_for_deletion_total{reason="series-deletion"} 0
+		cortex_compactor_blocks_marked_for_deletion_total{reason="series-relabel"} 0
+		cortex_compactor_blocks_marked_for_deletion_total{reason="series-retention"} 0
 
 		# TYPE cortex_compactor_block_cleanup_started_total counter
 		# HELP cortex_compactor_block_cleanup_started_total Total number of blocks cleanup runs started.
@@ -1126,6 +1225,9 @@ func TestMultitenantCompactor_ShouldCompactAllUsersOnShardingEnabledButOnlyOneIn
 		cortex_compactor_blocks_marked_for_deletion_total{reason="compaction"} 0
 		cortex_compactor_blocks_marked_for_deletion_total{reason="partial"} 0
 		cortex_compactor_blocks_marked_for_deletion_total{reason="retention"} 0
+		cortex_compactor_blocks_marked_for_deletion_total{reason="series-deletion"} 0
+		cortex_compactor_blocks_marked_for_deletion_total{reason="series-relabel"} 0
+		cortex_compactor_blocks_marked_for_deletion_total{reason="series-retention"} 0
 	`),
 		"cortex_compactor_runs_started_total",
 		"cortex_compactor_runs_completed_total",
@@ -1434,6 +1536,9 @@ func TestMultitenantCompactor_ShouldSkipCompactionForJobsNoMoreOwnedAfterPlannin
 		cortex_compactor_blocks_marked_for_deletion_total{reason="compaction"} 0
 		cortex_compactor_blocks_marked_for_deletion_total{reason="partial"} 0
 		cortex_compactor_blocks_marked_for_deletion_total{reason="retention"} 0
+		cortex_compactor_blocks_marked_for_deletion_total{reason="series-deletion"} 0
+		cortex_compactor_blocks_marked_for_deletion_total{reason="series-relabel"} 0
+		cortex_compactor_blocks_marked_for_deletion_total{reason="series-retention"} 0
 	`),
 		"cortex_compactor_runs_started_total",
 		"cortex_compactor_runs_completed_total",
@@ -1544,6 +1649,9 @@ func TestMultitenantCompactor_ShouldSkipCompactionForJobsWithFirstLevelCompactio
 		cortex_compactor_blocks_marked_for_deletion_total{reason="compaction"} 0
 		cortex_compactor_blocks_marked_for_deletion_total{reason="partial"} 0
 		cortex_compactor_blocks_marked_for_deletion_total{reason="retention"} 0
+		cortex_compactor_blocks_marked_for_deletion_total{reason="series-deletion"} 0
+		cortex_compactor_blocks_marked_for_deletion_total{reason="series-relabel"} 0
+		cortex_compactor_blocks_marked_for_deletion_total{reason="series-retention"} 0
 	`),
 		"cortex_compactor_runs_started_total",
 		"cortex_compactor_runs_completed_total",
@@ -0,0 +1,3376 @@
+// Code generated by protoc-gen-gogo. DO NOT EDIT.
+// source: scheduler.proto
+
+package compactorschedulerpb
+
+import (
+	context "context"
+	fmt "fmt"
+	_ "github.com/gogo/protobuf/gogoproto"
+	proto "github.com/gogo/protobuf/proto"
+	github_com_gogo_protobuf_sortkeys "github.com/gogo/protobuf/sortkeys"
+	github_com_gogo_protobuf_types "github.com/gogo/protobuf/types"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	_ "google.golang.org/protobuf/types/known/durationpb"
+	_ "google.golang.org/protobuf/types/known/timestamppb"
+	io "io"
+	math "math"
+	math_bits "math/bits"
+	reflect "reflect"
+	strconv "strconv"
+	strings "strings"
+	time "time"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+var _ = time.Kitchen
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.GoGoProtoPackageIsVersion3 // please upgrade the proto package
+
+type JobStatus int32
+
+const (
+	IN_PROGRESS JobStatus = 0
+	COMPLETE    JobStatus = 1
+	FAILED      JobStatus = 2
+)
+
+var JobStatus_name = map[int32]string{
+	0: "IN_PROGRESS",
+	1: "COMPLETE",
+	2: "FAILED",
+}
+
+var JobStatus_value = map[string]int32{
+	"IN_PROGRESS": 0,
+	"COMPLETE":    1,
+	"FAILED":      2,
+}
+
+func (JobStatus) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_2b3fc28395a6d9c5, []int{0}
+}
+
+type AssignJobRequest struct {
+	WorkerId string `protobuf:"bytes,1,opt,name=worker_id,json=workerId,proto3" json:"worker_id,omitempty"`
+}
+
+func (m *AssignJobRequest) Reset()      { *m = AssignJobRequest{} }
+func (*AssignJobRequest) ProtoMessage() {}
+func (*AssignJobRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2b3fc28395a6d9c5, []int{0}
+}
+func (m *AssignJobRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *AssignJobRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_AssignJobRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *AssignJobRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AssignJobRequest.Merge(m, src)
+}
+func (m *AssignJobRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *AssignJobRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_AssignJobRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AssignJobRequest proto.InternalMessageInfo
+
+func (m *AssignJobRequest) GetWorkerId() string {
+	if m != nil {
+		return m.WorkerId
+	}
+	return ""
+}
+
+type AssignJobResponse struct {
+	JobId         string        `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	Spec          JobSpec       `protobuf:"bytes,2,opt,name=spec,proto3" json:"spec"`
+	LeaseDuration time.Duration `protobuf:"bytes,3,opt,name=lease_duration,json=leaseDuration,proto3,stdduration" json:"lease_duration"`
+}
+
+func (m *AssignJobResponse) Reset()      { *m = AssignJobResponse{} }
+func (*AssignJobResponse) ProtoMessage() {}
+func (*AssignJobResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2b3fc28395a6d9c5, []int{1}
+}
+func (m *AssignJobResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *AssignJobResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_AssignJobResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *AssignJobResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AssignJobResponse.Merge(m, src)
+}
+func (m *AssignJobResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *AssignJobResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_AssignJobResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AssignJobResponse proto.InternalMessageInfo
+
+func (m *AssignJobResponse) GetJobId() string {
+	if m != nil {
+		return m.JobId
+	}
+	return ""
+}
+
+func (m *AssignJobResponse) GetSpec() JobSpec {
+	if m != nil {
+		return m.Spec
+	}
+	return JobSpec{}
+}
+
+func (m *AssignJobResponse) GetLeaseDuration() time.Duration {
+	if m != nil {
+		return m.LeaseDuration
+	}
+	return 0
+}
+
+type UpdateJobRequest struct {
+	JobId    string    `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	WorkerId string    `protobuf:"bytes,2,opt,name=worker_id,json=workerId,proto3" json:"worker_id,omitempty"`
+	Status   JobStatus `protobuf:"varint,3,opt,name=status,proto3,enum=compactorschedulerpb.JobStatus" json:"status,omitempty"`
+	// The spec of the job, which allows the compactor-scheduler to recover the lease of the job
+	// after a restart.
+	Spec JobSpec `protobuf:"bytes,4,opt,name=spec,proto3" json:"spec"`
+}
+
+func (m *UpdateJobRequest) Reset()      { *m = UpdateJobRequest{} }
+func (*UpdateJobRequest) ProtoMessage() {}
+func (*UpdateJobRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2b3fc28395a6d9c5, []int{2}
+}
+func (m *UpdateJobRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *UpdateJobRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_UpdateJobRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *UpdateJobRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UpdateJobRequest.Merge(m, src)
+}
+func (m *UpdateJobRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *UpdateJobRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_UpdateJobRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UpdateJobRequest proto.InternalMessageInfo
+
+func (m *UpdateJobRequest) GetJobId() string {
+	if m != nil {
+		return m.JobId
+	}
+	return ""
+}
+
+func (m *UpdateJobRequest) GetWorkerId() string {
+	if m != nil {
+		return m.WorkerId
+	}
+	return ""
+}
+
+func (m *UpdateJobRequest) GetStatus() JobStatus {
+	if m != nil {
+		return m.Status
+	}
+	return IN_PROGRESS
+}
+
+func (m *UpdateJobRequest) GetSpec() JobSpec {
+	if m != nil {
+		return m.Spec
+	}
+	return JobSpec{}
+}
+
+type UpdateJobResponse struct {
+}
+
+func (m *UpdateJobResponse) Reset()      { *m = UpdateJobResponse{} }
+func (*UpdateJobResponse) ProtoMessage() {}
+func (*UpdateJobResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2b3fc28395a6d9c5, []int{3}
+}
+func (m *UpdateJobResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *UpdateJobResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_UpdateJobResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *UpdateJobResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UpdateJobResponse.Merge(m, src)
+}
+func (m *UpdateJobResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *UpdateJobResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_UpdateJobResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UpdateJobResponse proto.InternalMessageInfo
+
+type ListJobsRequest struct {
+	// The tenant of the jobs to list, or empty to list the jobs of all tenants.
+	Tenant string `protobuf:"bytes,1,opt,name=tenant,proto3" json:"tenant,omitempty"`
+}
+
+func (m *ListJobsRequest) Reset()      { *m = ListJobsRequest{} }
+func (*ListJobsRequest) ProtoMessage() {}
+func (*ListJobsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2b3fc28395a6d9c5, []int{4}
+}
+func (m *ListJobsRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ListJobsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ListJobsRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ListJobsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListJobsRequest.Merge(m, src)
+}
+func (m *ListJobsRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *ListJobsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListJobsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListJobsRequest proto.InternalMessageInfo
+
+func (m *ListJobsRequest) GetTenant() string {
+	if m != nil {
+		return m.Tenant
+	}
+	return ""
+}
+
+type ListJobsResponse struct {
+	Jobs []JobState `protobuf:"bytes,1,rep,name=jobs,proto3" json:"jobs"`
+}
+
+func (m *ListJobsResponse) Reset()      { *m = ListJobsResponse{} }
+func (*ListJobsResponse) ProtoMessage() {}
+func (*ListJobsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2b3fc28395a6d9c5, []int{5}
+}
+func (m *ListJobsResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ListJobsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ListJobsResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ListJobsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListJobsResponse.Merge(m, src)
+}
+func (m *ListJobsResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *ListJobsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListJobsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListJobsResponse proto.InternalMessageInfo
+
+func (m *ListJobsResponse) GetJobs() []JobState {
+	if m != nil {
+		return m.Jobs
+	}
+	return nil
+}
+
+// JobSpec holds everything a compactor needs to run a compaction job without planning the tenant's compaction.
+type JobSpec struct {
+	Tenant         string            `protobuf:"bytes,1,opt,name=tenant,proto3" json:"tenant,omitempty"`
+	Key            string            `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Labels         map[string]string `protobuf:"bytes,3,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Resolution     int64             `protobuf:"varint,4,opt,name=resolution,proto3" json:"resolution,omitempty"`
+	UseSplitting   bool              `protobuf:"varint,5,opt,name=use_splitting,json=useSplitting,proto3" json:"use_splitting,omitempty"`
+	SplitNumShards uint32            `protobuf:"varint,6,opt,name=split_num_shards,json=splitNumShards,proto3" json:"split_num_shards,omitempty"`
+	ShardingKey    string            `protobuf:"bytes,7,opt,name=sharding_key,json=shardingKey,proto3" json:"sharding_key,omitempty"`
+	Blocks         []string          `protobuf:"bytes,8,rep,name=blocks,proto3" json:"blocks,omitempty"`
+	MinTime        int64             `protobuf:"varint,9,opt,name=min_time,json=minTime,proto3" json:"min_time,omitempty"`
+	MaxTime        int64             `protobuf:"varint,10,opt,name=max_time,json=maxTime,proto3" json:"max_time,omitempty"`
+	CostSeries     uint64            `protobuf:"varint,11,opt,name=cost_series,json=costSeries,proto3" json:"cost_series,omitempty"`
+	CostBytes      int64             `protobuf:"varint,12,opt,name=cost_bytes,json=costBytes,proto3" json:"cost_bytes,omitempty"`
+	// Order of the job among the jobs of the tenant, as sorted by the configured jobs order.
+	Order int64 `protobuf:"varint,13,opt,name=order,proto3" json:"order,omitempty"`
+}
+
+func (m *JobSpec) Reset()      { *m = JobSpec{} }
+func (*JobSpec) ProtoMessage() {}
+func (*JobSpec) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2b3fc28395a6d9c5, []int{6}
+}
+func (m *JobSpec) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *JobSpec) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_JobSpec.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *JobSpec) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_JobSpec.Merge(m, src)
+}
+func (m *JobSpec) XXX_Size() int {
+	return m.Size()
+}
+func (m *JobSpec) XXX_DiscardUnknown() {
+	xxx_messageInfo_JobSpec.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_JobSpec proto.InternalMessageInfo
+
+func (m *JobSpec) GetTenant() string {
+	if m != nil {
+		return m.Tenant
+	}
+	return ""
+}
+
+func (m *JobSpec) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *JobSpec) GetLabels() map[string]string {
+	if m != nil {
+		return m.Labels
+	}
+	return nil
+}
+
+func (m *JobSpec) GetResolution() int64 {
+	if m != nil {
+		return m.Resolution
+	}
+	return 0
+}
+
+func (m *JobSpec) GetUseSplitting() bool {
+	if m != nil {
+		return m.UseSplitting
+	}
+	return false
+}
+
+func (m *JobSpec) GetSplitNumShards() uint32 {
+	if m != nil {
+		return m.SplitNumShards
+	}
+	return 0
+}
+
+func (m *JobSpec) GetShardingKey() string {
+	if m != nil {
+		return m.ShardingKey
+	}
+	return ""
+}
+
+func (m *JobSpec) GetBlocks() []string {
+	if m != nil {
+		return m.Blocks
+	}
+	return nil
+}
+
+func (m *JobSpec) GetMinTime() int64 {
+	if m != nil {
+		return m.MinTime
+	}
+	return 0
+}
+
+func (m *JobSpec) GetMaxTime() int64 {
+	if m != nil {
+		return m.MaxTime
+	}
+	return 0
+}
+
+func (m *JobSpec) GetCostSeries() uint64 {
+	if m != nil {
+		return m.CostSeries
+	}
+	return 0
+}
+
+func (m *JobSpec) GetCostBytes() int64 {
+	if m != nil {
+		return m.CostBytes
+	}
+	return 0
+}
+
+func (m *JobSpec) GetOrder() int64 {
+	if m != nil {
+		return m.Order
+	}
+	return 0
+}
+
+type JobState struct {
+	JobId       string    `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	Spec        JobSpec   `protobuf:"bytes,2,opt,name=spec,proto3" json:"spec"`
+	State       string    `protobuf:"bytes,3,opt,name=state,proto3" json:"state,omitempty"`
+	Assignee    string    `protobuf:"bytes,4,opt,name=assignee,proto3" json:"assignee,omitempty"`
+	LeaseExpiry time.Time `protobuf:"bytes,5,opt,name=lease_expiry,json=leaseExpiry,proto3,stdtime" json:"lease_expiry"`
+	FailCount   int64     `protobuf:"varint,6,opt,name=fail_count,json=failCount,proto3" json:"fail_count,omitempty"`
+}
+
+func (m *JobState) Reset()      { *m = JobState{} }
+func (*JobState) ProtoMessage() {}
+func (*JobState) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2b3fc28395a6d9c5, []int{7}
+}
+func (m *JobState) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *JobState) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_JobState.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *JobState) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_JobState.Merge(m, src)
+}
+func (m *JobState) XXX_Size() int {
+	return m.Size()
+}
+func (m *JobState) XXX_DiscardUnknown() {
+	xxx_messageInfo_JobState.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_JobState proto.InternalMessageInfo
+
+func (m *JobState) GetJobId() string {
+	if m != nil {
+		return m.JobId
+	}
+	return ""
+}
+
+func (m *JobState) GetSpec() JobSpec {
+	if m != nil {
+		return m.Spec
+	}
+	return JobSpec{}
+}
+
+func (m *JobState) GetState() string {
+	if m != nil {
+		return m.State
+	}
+	return ""
+}
+
+func (m *JobState) GetAssignee() string {
+	if m != nil {
+		return m.Assignee
+	}
+	return ""
+}
+
+func (m *JobState) GetLeaseExpiry() time.Time {
+	if m != nil {
+		return m.LeaseExpiry
+	}
+	return time.Time{}
+}
+
+func (m *JobState) GetFailCount() int64 {
+	if m != nil {
+		return m.FailCount
+	}
+	return 0
+}
+
+func init() {
+	proto.RegisterEnum("compactorschedulerpb.JobStatus", JobStatus_name, JobStatus_value)
+	proto.RegisterType((*AssignJobRequest)(nil), "compactorschedulerpb.AssignJobRequest")
+	proto.RegisterType((*AssignJobResponse)(nil), "compactorschedulerpb.AssignJobResponse")
+	proto.RegisterType((*UpdateJobRequest)(nil), "compactorschedulerpb.UpdateJobRequest")
+	proto.RegisterType((*UpdateJobResponse)(nil), "compactorschedulerpb.UpdateJobResponse")
+	proto.RegisterType((*ListJobsRequest)(nil), "compactorschedulerpb.ListJobsRequest")
+	proto.RegisterType((*ListJobsResponse)(nil), "compactorschedulerpb.ListJobsResponse")
+	proto.RegisterType((*JobSpec)(nil), "compactorschedulerpb.JobSpec")
+	proto.RegisterMapType((map[string]string)(nil), "compactorschedulerpb.JobSpec.LabelsEntry")
+	proto.RegisterType((*JobState)(nil), "compactorschedulerpb.JobState")
+}
+
+func init() { proto.RegisterFile("scheduler.proto", fileDescriptor_2b3fc28395a6d9c5) }
+
+var fileDescriptor_2b3fc28395a6d9c5 = []byte{
+	// 878 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x55, 0x4f, 0x73, 0xdb, 0x44,
+	0x14, 0xd7, 0xfa, 0x5f, 0xa4, 0x27, 0x27, 0x71, 0x17, 0xc3, 0xa8, 0x66, 0xba, 0x36, 0x66, 0x68,
+	0x5d, 0x0e, 0xce, 0x8c, 0x99, 0xa1, 0x85, 0x5b, 0x92, 0x9a, 0x4e, 0x8c, 0x69, 0x3b, 0x72, 0xb8,
+	0xc0, 0x0c, 0x1a, 0xc9, 0xde, 0xba, 0x4a, 0x64, 0xad, 0xd0, 0xae, 0x20, 0xbe, 0xf1, 0x11, 0x7a,
+	0xe4, 0x23, 0x70, 0x63, 0xb8, 0xf1, 0x11, 0x7a, 0xcc, 0xb1, 0x27, 0x20, 0xce, 0x01, 0x8e, 0x3d,
+	0xf0, 0x01, 0x98, 0x5d, 0x49, 0x8e, 0x1b, 0xe2, 0x66, 0x98, 0xe1, 0xe6, 0xf7, 0x7b, 0xbf, 0xb7,
+	0x7a, 0xbf, 0xf7, 0x76, 0x7f, 0x86, 0x6d, 0x3e, 0x7e, 0x46, 0x27, 0x49, 0x40, 0xe3, 0x6e, 0x14,
+	0x33, 0xc1, 0x70, 0x7d, 0xcc, 0x66, 0x91, 0x3b, 0x16, 0x2c, 0x5e, 0x66, 0x22, 0xaf, 0x51, 0x9f,
+	0xb2, 0x29, 0x53, 0x84, 0x1d, 0xf9, 0x2b, 0xe5, 0x36, 0xc8, 0x94, 0xb1, 0x69, 0x40, 0x77, 0x54,
+	0xe4, 0x25, 0x4f, 0x77, 0x26, 0x49, 0xec, 0x0a, 0x9f, 0x85, 0x59, 0xbe, 0x79, 0x39, 0x2f, 0xfc,
+	0x19, 0xe5, 0xc2, 0x9d, 0x45, 0x29, 0xa1, 0xbd, 0x03, 0xb5, 0x5d, 0xce, 0xfd, 0x69, 0x38, 0x60,
+	0x9e, 0x4d, 0xbf, 0x4d, 0x28, 0x17, 0xf8, 0x5d, 0x30, 0xbe, 0x67, 0xf1, 0x31, 0x8d, 0x1d, 0x7f,
+	0x62, 0xa1, 0x16, 0xea, 0x18, 0xb6, 0x9e, 0x02, 0x07, 0x93, 0xf6, 0xcf, 0x08, 0x6e, 0xac, 0x54,
+	0xf0, 0x88, 0x85, 0x9c, 0xe2, 0xb7, 0xa1, 0x72, 0xc4, 0xbc, 0x0b, 0x7e, 0xf9, 0x88, 0x79, 0x07,
+	0x13, 0x7c, 0x0f, 0x4a, 0x3c, 0xa2, 0x63, 0xab, 0xd0, 0x42, 0x1d, 0xb3, 0x77, 0xab, 0x7b, 0x95,
+	0xb2, 0xee, 0x80, 0x79, 0xa3, 0x88, 0x8e, 0xf7, 0x4a, 0x2f, 0x7e, 0x6b, 0x6a, 0xb6, 0x2a, 0xc0,
+	0x03, 0xd8, 0x0a, 0xa8, 0xcb, 0xa9, 0x93, 0xeb, 0xb1, 0x8a, 0xea, 0x88, 0x9b, 0xdd, 0x54, 0x50,
+	0x37, 0x17, 0xd4, 0x7d, 0x90, 0x11, 0xf6, 0x74, 0x59, 0xfe, 0xe3, 0xef, 0x4d, 0x64, 0x6f, 0xaa,
+	0xd2, 0x3c, 0xd1, 0xfe, 0x15, 0x41, 0xed, 0xcb, 0x68, 0xe2, 0x0a, 0xba, 0xa2, 0x71, 0x4d, 0xc3,
+	0xaf, 0x49, 0x2f, 0xbc, 0x2e, 0x1d, 0xdf, 0x83, 0x0a, 0x17, 0xae, 0x48, 0xb8, 0x6a, 0x66, 0xab,
+	0xd7, 0x5c, 0xaf, 0x47, 0xd1, 0xec, 0x8c, 0xbe, 0x1c, 0x43, 0xe9, 0x3f, 0x8e, 0xa1, 0xfd, 0x16,
+	0xdc, 0x58, 0xe9, 0x3c, 0x9d, 0x75, 0xfb, 0x2e, 0x6c, 0x0f, 0x7d, 0x2e, 0x06, 0xcc, 0xe3, 0xb9,
+	0x9a, 0x77, 0xa0, 0x22, 0x68, 0xe8, 0x86, 0x22, 0x53, 0x93, 0x45, 0xed, 0x21, 0xd4, 0x2e, 0xa8,
+	0xd9, 0xaa, 0xee, 0x43, 0xe9, 0x88, 0x79, 0xdc, 0x42, 0xad, 0x62, 0xc7, 0xec, 0x91, 0x37, 0x6a,
+	0xa0, 0x79, 0x37, 0xb2, 0xa2, 0xfd, 0x67, 0x11, 0x36, 0xb2, 0x2e, 0xd7, 0x7d, 0x11, 0xd7, 0xa0,
+	0x78, 0x4c, 0xe7, 0xd9, 0xe8, 0xe4, 0x4f, 0xbc, 0x0b, 0x95, 0xc0, 0xf5, 0x68, 0x20, 0xa7, 0x26,
+	0xbf, 0x78, 0xf7, 0x8d, 0xf2, 0xbb, 0x43, 0xc5, 0xed, 0x87, 0x22, 0x9e, 0xdb, 0x59, 0x21, 0x26,
+	0x00, 0x31, 0xe5, 0x2c, 0x48, 0xd4, 0x4d, 0x90, 0x53, 0x2c, 0xda, 0x2b, 0x08, 0x7e, 0x1f, 0x36,
+	0x13, 0x4e, 0x1d, 0x1e, 0x05, 0xbe, 0x10, 0x7e, 0x38, 0xb5, 0xca, 0x2d, 0xd4, 0xd1, 0xed, 0x6a,
+	0xc2, 0xe9, 0x28, 0xc7, 0x70, 0x07, 0x6a, 0x8a, 0xe0, 0x84, 0xc9, 0xcc, 0xe1, 0xcf, 0xdc, 0x78,
+	0xc2, 0xad, 0x4a, 0x0b, 0x75, 0x36, 0xed, 0x2d, 0x85, 0x3f, 0x4a, 0x66, 0x23, 0x85, 0xe2, 0xf7,
+	0xa0, 0xaa, 0xf2, 0x7e, 0x38, 0x75, 0xa4, 0x98, 0x0d, 0x25, 0xc6, 0xcc, 0xb1, 0xcf, 0xe9, 0x5c,
+	0xca, 0xf7, 0x02, 0x36, 0x3e, 0xe6, 0x96, 0xde, 0x2a, 0x4a, 0xf9, 0x69, 0x84, 0x6f, 0x82, 0x3e,
+	0xf3, 0x43, 0x47, 0xbe, 0x32, 0xcb, 0x50, 0x7d, 0x6e, 0xcc, 0xfc, 0xf0, 0xd0, 0x9f, 0x51, 0x95,
+	0x72, 0x4f, 0xd2, 0x14, 0x64, 0x29, 0xf7, 0x44, 0xa5, 0x9a, 0x60, 0x8e, 0x19, 0x17, 0x0e, 0xa7,
+	0xb1, 0x4f, 0xb9, 0x65, 0xb6, 0x50, 0xa7, 0x64, 0x83, 0x84, 0x46, 0x0a, 0xc1, 0xb7, 0x40, 0x45,
+	0x8e, 0x37, 0x17, 0x94, 0x5b, 0x55, 0x55, 0x6d, 0x48, 0x64, 0x4f, 0x02, 0xb8, 0x0e, 0x65, 0x16,
+	0x4f, 0x68, 0x6c, 0x6d, 0xaa, 0x4c, 0x1a, 0x34, 0x3e, 0x01, 0x73, 0x65, 0x98, 0xf9, 0x66, 0xd0,
+	0xc5, 0x66, 0xea, 0x50, 0xfe, 0xce, 0x0d, 0x12, 0x9a, 0x6d, 0x2b, 0x0d, 0x3e, 0x2d, 0xdc, 0x47,
+	0xed, 0xbf, 0x11, 0xe8, 0xf9, 0x15, 0xf8, 0xdf, 0xdf, 0x76, 0x1d, 0xca, 0xf2, 0x5d, 0x50, 0xf5,
+	0x8a, 0x0c, 0x3b, 0x0d, 0x70, 0x03, 0x74, 0x57, 0xd9, 0x0a, 0xa5, 0x6a, 0xc3, 0x86, 0xbd, 0x8c,
+	0xf1, 0x43, 0xa8, 0xa6, 0x6e, 0x40, 0x4f, 0x22, 0x3f, 0x9e, 0xab, 0xf5, 0x9a, 0xbd, 0xc6, 0xbf,
+	0xbc, 0xe0, 0x30, 0x37, 0xb7, 0xd4, 0x0c, 0x9e, 0x4b, 0x33, 0x30, 0x55, 0x65, 0x5f, 0x15, 0xca,
+	0x39, 0x3e, 0x75, 0xfd, 0xc0, 0x19, 0xb3, 0x24, 0x14, 0x6a, 0xfb, 0x45, 0xdb, 0x90, 0xc8, 0xbe,
+	0x04, 0x3e, 0xfc, 0x18, 0x8c, 0xe5, 0xe3, 0xc5, 0xdb, 0x60, 0x1e, 0x3c, 0x72, 0x9e, 0xd8, 0x8f,
+	0x1f, 0xda, 0xfd, 0xd1, 0xa8, 0xa6, 0xe1, 0x2a, 0xe8, 0xfb, 0x8f, 0xbf, 0x78, 0x32, 0xec, 0x1f,
+	0xf6, 0x6b, 0x08, 0x03, 0x54, 0x3e, 0xdb, 0x3d, 0x18, 0xf6, 0x1f, 0xd4, 0x0a, 0xbd, 0x5f, 0x0a,
+	0x80, 0xf7, 0x73, 0xf9, 0xa3, 0x5c, 0x3e, 0xfe, 0x06, 0x8c, 0xa5, 0x53, 0xe2, 0xdb, 0x57, 0x0f,
+	0xe8, 0xb2, 0xf9, 0x36, 0xee, 0x5c, 0xcb, 0xcb, 0x6c, 0x40, 0x93, 0xe7, 0x2f, 0xdd, 0x61, 0xdd,
+	0xf9, 0x97, 0x8d, 0xaf, 0x71, 0xe7, 0x5a, 0xde, 0xf2, 0xfc, 0xaf, 0x41, 0xcf, 0xdd, 0x03, 0x7f,
+	0x70, 0x75, 0xd9, 0x25, 0x23, 0x6a, 0xdc, 0xbe, 0x8e, 0x96, 0x1f, 0xbe, 0x37, 0x38, 0x3d, 0x23,
+	0xda, 0xcb, 0x33, 0xa2, 0xbd, 0x3a, 0x23, 0xe8, 0x87, 0x05, 0x41, 0x3f, 0x2d, 0x08, 0x7a, 0xb1,
+	0x20, 0xe8, 0x74, 0x41, 0xd0, 0x1f, 0x0b, 0x82, 0xfe, 0x5a, 0x10, 0xed, 0xd5, 0x82, 0xa0, 0xe7,
+	0xe7, 0x44, 0x3b, 0x3d, 0x27, 0xda, 0xcb, 0x73, 0xa2, 0x7d, 0x75, 0xe5, 0x7f, 0xa3, 0x57, 0x51,
+	0x37, 0xe0, 0xa3, 0x7f, 0x06, 0x00, 0xac, 0x88, 0x01, 0x68, 0x4b, 0x07, 0x00, 0x00,
+}
+
+func (x JobStatus) String() string {
+	s, ok := JobStatus_name[int32(x)]
+	if ok {
+		return s
+	}
+	return strconv.Itoa(int(x))
+}
+func (this *AssignJobRequest) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*AssignJobRequest)
+	if !ok {
+		that2, ok := that.(AssignJobRequest)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.WorkerId != that1.WorkerId {
+		return false
+	}
+	return true
+}
+func (this *AssignJobResponse) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*AssignJobResponse)
+	if !ok {
+		that2, ok := that.(AssignJobResponse)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.JobId != that1.JobId {
+		return false
+	}
+	if !this.Spec.Equal(&that1.Spec) {
+		return false
+	}
+	if this.LeaseDuration != that1.LeaseDuration {
+		return false
+	}
+	return true
+}
+func (this *UpdateJobRequest) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*UpdateJobRequest)
+	if !ok {
+		that2, ok := that.(UpdateJobRequest)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.JobId != that1.JobId {
+		return false
+	}
+	if this.WorkerId != that1.WorkerId {
+		return false
+	}
+	if this.Status != that1.Status {
+		return false
+	}
+	if !this.Spec.Equal(&that1.Spec) {
+		return false
+	}
+	return true
+}
+func (this *UpdateJobResponse) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*UpdateJobResponse)
+	if !ok {
+		that2, ok := that.(UpdateJobResponse)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	return true
+}
+func (this *ListJobsRequest) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*ListJobsRequest)
+	if !ok {
+		that2, ok := that.(ListJobsRequest)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.Tenant != that1.Tenant {
+		return false
+	}
+	return true
+}
+func (this *ListJobsResponse) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*ListJobsResponse)
+	if !ok {
+		that2, ok := that.(ListJobsResponse)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if len(this.Jobs) != len(that1.Jobs) {
+		return false
+	}
+	for i := range this.Jobs {
+		if !this.Jobs[i].Equal(&that1.Jobs[i]) {
+			return false
+		}
+	}
+	return true
+}
+func (this *JobSpec) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*JobSpec)
+	if !ok {
+		that2, ok := that.(JobSpec)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.Tenant != that1.Tenant {
+		return false
+	}
+	if this.Key != that1.Key {
+		return false
+	}
+	if len(this.Labels) != len(that1.Labels) {
+		return false
+	}
+	for i := range this.Labels {
+		if this.Labels[i] != that1.Labels[i] {
+			return false
+		}
+	}
+	if this.Resolution != that1.Resolution {
+		return false
+	}
+	if this.UseSplitting != that1.UseSplitting {
+		return false
+	}
+	if this.SplitNumShards != that1.SplitNumShards {
+		return false
+	}
+	if this.ShardingKey != that1.ShardingKey {
+		return false
+	}
+	if len(this.Blocks) != len(that1.Blocks) {
+		return false
+	}
+	for i := range this.Blocks {
+		if this.Blocks[i] != that1.Blocks[i] {
+			return false
+		}
+	}
+	if this.MinTime != that1.MinTime {
+		return false
+	}
+	if this.MaxTime != that1.MaxTime {
+		return false
+	}
+	if this.CostSeries != that1.CostSeries {
+		return false
+	}
+	if this.CostBytes != that1.CostBytes {
+		return false
+	}
+	if this.Order != that1.Order {
+		return false
+	}
+	return true
+}
+func (this *JobState) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*JobState)
+	if !ok {
+		that2, ok := that.(JobState)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.JobId != that1.JobId {
+		return false
+	}
+	if !this.Spec.Equal(&that1.Spec) {
+		return false
+	}
+	if this.State != that1.State {
+		return false
+	}
+	if this.Assignee != that1.Assignee {
+		return false
+	}
+	if !this.LeaseExpiry.Equal(that1.LeaseExpiry) {
+		return false
+	}
+	if this.FailCount != that1.FailCount {
+		return false
+	}
+	return true
+}
+func (this *AssignJobRequest) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 5)
+	s = append(s, "&compactorschedulerpb.AssignJobRequest{")
+	s = append(s, "WorkerId: "+fmt.Sprintf("%#v", this.WorkerId)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *AssignJobResponse) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 7)
+	s = append(s, "&compactorschedulerpb.AssignJobResponse{")
+	s = append(s, "JobId: "+fmt.Sprintf("%#v", this.JobId)+",\n")
+	s = append(s, "Spec: "+strings.Replace(this.Spec.GoString(), `&`, ``, 1)+",\n")
+	s = append(s, "LeaseDuration: "+fmt.Sprintf("%#v", this.LeaseDuration)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *UpdateJobRequest) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 8)
+	s = append(s, "&compactorschedulerpb.UpdateJobRequest{")
+	s = append(s, "JobId: "+fmt.Sprintf("%#v", this.JobId)+",\n")
+	s = append(s, "WorkerId: "+fmt.Sprintf("%#v", this.WorkerId)+",\n")
+	s = append(s, "Status: "+fmt.Sprintf("%#v", this.Status)+",\n")
+	s = append(s, "Spec: "+strings.Replace(this.Spec.GoString(), `&`, ``, 1)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *UpdateJobResponse) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 4)
+	s = append(s, "&compactorschedulerpb.UpdateJobResponse{")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *ListJobsRequest) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 5)
+	s = append(s, "&compactorschedulerpb.ListJobsRequest{")
+	s = append(s, "Tenant: "+fmt.Sprintf("%#v", this.Tenant)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *ListJobsResponse) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 5)
+	s = append(s, "&compactorschedulerpb.ListJobsResponse{")
+	if this.Jobs != nil {
+		vs := make([]*JobState, len(this.Jobs))
+		for i := range vs {
+			vs[i] = &this.Jobs[i]
+		}
+		s = append(s, "Jobs: "+fmt.Sprintf("%#v", vs)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *JobSpec) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 17)
+	s = append(s, "&compactorschedulerpb.JobSpec{")
+	s = append(s, "Tenant: "+fmt.Sprintf("%#v", this.Tenant)+",\n")
+	s = append(s, "Key: "+fmt.Sprintf("%#v", this.Key)+",\n")
+	keysForLabels := make([]string, 0, len(this.Labels))
+	for k, _ := range this.Labels {
+		keysForLabels = append(keysForLabels, k)
+	}
+	github_com_gogo_protobuf_sortkeys.Strings(keysForLabels)
+	mapStringForLabels := "map[string]string{"
+	for _, k := range keysForLabels {
+		mapStringForLabels += fmt.Sprintf("%#v: %#v,", k, this.Labels[k])
+	}
+	mapStringForLabels += "}"
+	if this.Labels != nil {
+		s = append(s, "Labels: "+mapStringForLabels+",\n")
+	}
+	s = append(s, "Resolution: "+fmt.Sprintf("%#v", this.Resolution)+",\n")
+	s = append(s, "UseSplitting: "+fmt.Sprintf("%#v", this.UseSplitting)+",\n")
+	s = append(s, "SplitNumShards: "+fmt.Sprintf("%#v", this.SplitNumShards)+",\n")
+	s = append(s, "ShardingKey: "+fmt.Sprintf("%#v", this.ShardingKey)+",\n")
+	s = append(s, "Blocks: "+fmt.Sprintf("%#v", this.Blocks)+",\n")
+	s = append(s, "MinTime: "+fmt.Sprintf("%#v", this.MinTime)+",\n")
+	s = append(s, "MaxTime: "+fmt.Sprintf("%#v", this.MaxTime)+",\n")
+	s = append(s, "CostSeries: "+fmt.Sprintf("%#v", this.CostSeries)+",\n")
+	s = append(s, "CostBytes: "+fmt.Sprintf("%#v", this.CostBytes)+",\n")
+	s = append(s, "Order: "+fmt.Sprintf("%#v", this.Order)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *JobState) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 10)
+	s = append(s, "&compactorschedulerpb.JobState{")
+	s = append(s, "JobId: "+fmt.Sprintf("%#v", this.JobId)+",\n")
+	s = append(s, "Spec: "+strings.Replace(this.Spec.GoString(), `&`, ``, 1)+",\n")
+	s = append(s, "State: "+fmt.Sprintf("%#v", this.State)+",\n")
+	s = append(s, "Assignee: "+fmt.Sprintf("%#v", this.Assignee)+",\n")
+	s = append(s, "LeaseExpiry: "+fmt.Sprintf("%#v", this.LeaseExpiry)+",\n")
+	s = append(s, "FailCount: "+fmt.Sprintf("%#v", this.FailCount)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func valueToGoStringScheduler(v interface{}, typ string) string {
+	rv := reflect.ValueOf(v)
+	if rv.IsNil() {
+		return "nil"
+	}
+	pv := reflect.Indirect(rv).Interface()
+	return fmt.Sprintf("func(v %v) *%v { return &v } ( %#v )", typ, typ, pv)
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// CompactorSchedulerClient is the client API for CompactorScheduler service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type CompactorSchedulerClient interface {
+	// AssignJob leases the next compaction job to the worker. It fails with the NotFound status code
+	// if there's no job available.
+	AssignJob(ctx context.Context, in *AssignJobRequest, opts ...grpc.CallOption) (*AssignJobResponse, error)
+	// UpdateJob renews the lease of the job assigned to the worker, or completes it. It fails with the
+	// NotFound or FailedPrecondition status codes if the job isn't leased to the worker anymore.
+	UpdateJob(ctx context.Context, in *UpdateJobRequest, opts ...grpc.CallOption) (*UpdateJobResponse, error)
+	// ListJobs returns the compaction jobs in the queue.
+	ListJobs(ctx context.Context, in *ListJobsRequest, opts ...grpc.CallOption) (*ListJobsResponse, error)
+}
+
+type compactorSchedulerClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewCompactorSchedulerClient(cc *grpc.ClientConn) CompactorSchedulerClient {
+	return &compactorSchedulerClient{cc}
+}
+
+func (c *compactorSchedulerClient) AssignJob(ctx context.Context, in *AssignJobRequest, opts ...grpc.CallOption) (*AssignJobResponse, error) {
+	out := new(AssignJobResponse)
+	err := c.cc.Invoke(ctx, "/compactorschedulerpb.CompactorScheduler/AssignJob", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *compactorSchedulerClient) UpdateJob(ctx context.Context, in *UpdateJobRequest, opts ...grpc.CallOption) (*UpdateJobResponse, error) {
+	out := new(UpdateJobResponse)
+	err := c.cc.Invoke(ctx, "/compactorschedulerpb.CompactorScheduler/UpdateJob", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *compactorSchedulerClient) ListJobs(ctx context.Context, in *ListJobsRequest, opts ...grpc.CallOption) (*ListJobsResponse, error) {
+	out := new(ListJobsResponse)
+	err := c.cc.Invoke(ctx, "/compactorschedulerpb.CompactorScheduler/ListJobs", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CompactorSchedulerServer is the server API for CompactorScheduler service.
+type CompactorSchedulerServer interface {
+	// AssignJob leases the next compaction job to the worker. It fails with the NotFound status code
+	// if there's no job available.
+	AssignJob(context.Context, *AssignJobRequest) (*AssignJobResponse, error)
+	// UpdateJob renews the lease of the job assigned to the worker, or completes it. It fails with the
+	// NotFound or FailedPrecondition status codes if the job isn't leased to the worker anymore.
+	UpdateJob(context.Context, *UpdateJobRequest) (*UpdateJobResponse, error)
+	// ListJobs returns the compaction jobs in the queue.
+	ListJobs(context.Context, *ListJobsRequest) (*ListJobsResponse, error)
+}
+
+// UnimplementedCompactorSchedulerServer can be embedded to have forward compatible implementations.
+type UnimplementedCompactorSchedulerServer struct {
+}
+
+func (*UnimplementedCompactorSchedulerServer) AssignJob(ctx context.Context, req *AssignJobRequest) (*AssignJobResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AssignJob not implemented")
+}
+func (*UnimplementedCompactorSchedulerServer) UpdateJob(ctx context.Context, req *UpdateJobRequest) (*UpdateJobResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateJob not implemented")
+}
+func (*UnimplementedCompactorSchedulerServer) ListJobs(ctx context.Context, req *ListJobsRequest) (*ListJobsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListJobs not implemented")
+}
+
+func RegisterCompactorSchedulerServer(s *grpc.Server, srv CompactorSchedulerServer) {
+	s.RegisterService(&_CompactorScheduler_serviceDesc, srv)
+}
+
+func _CompactorScheduler_AssignJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AssignJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CompactorSchedulerServer).AssignJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/compactorschedulerpb.CompactorScheduler/AssignJob",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CompactorSchedulerServer).AssignJob(ctx, req.(*AssignJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CompactorScheduler_UpdateJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CompactorSchedulerServer).UpdateJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/compactorschedulerpb.CompactorScheduler/UpdateJob",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CompactorSchedulerServer).UpdateJob(ctx, req.(*UpdateJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CompactorScheduler_ListJobs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListJobsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CompactorSchedulerServer).ListJobs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/compactorschedulerpb.CompactorScheduler/ListJobs",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CompactorSchedulerServer).ListJobs(ctx, req.(*ListJobsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _CompactorScheduler_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "compactorschedulerpb.CompactorScheduler",
+	HandlerType: (*CompactorSchedulerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "AssignJob",
+			Handler:    _CompactorScheduler_AssignJob_Handler,
+		},
+		{
+			MethodName: "UpdateJob",
+			Handler:    _CompactorScheduler_UpdateJob_Handler,
+		},
+		{
+			MethodName: "ListJobs",
+			Handler:    _CompactorScheduler_ListJobs_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "scheduler.proto",
+}
+
+func (m *AssignJobRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *AssignJobRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *AssignJobRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.WorkerId) > 0 {
+		i -= len(m.WorkerId)
+		copy(dAtA[i:], m.WorkerId)
+		i = encodeVarintScheduler(dAtA, i, uint64(len(m.WorkerId)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *AssignJobResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *AssignJobResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *AssignJobResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	n1, err1 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.LeaseDuration, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.LeaseDuration):])
+	if err1 != nil {
+		return 0, err1
+	}
+	i -= n1
+	i = encodeVarintScheduler(dAtA, i, uint64(n1))
+	i--
+	dAtA[i] = 0x1a
+	{
+		size, err := m.Spec.MarshalToSizedBuffer(dAtA[:i])
+		if err != nil {
+			return 0, err
+		}
+		i -= size
+		i = encodeVarintScheduler(dAtA, i, uint64(size))
+	}
+	i--
+	dAtA[i] = 0x12
+	if len(m.JobId) > 0 {
+		i -= len(m.JobId)
+		copy(dAtA[i:], m.JobId)
+		i = encodeVarintScheduler(dAtA, i, uint64(len(m.JobId)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *UpdateJobRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *UpdateJobRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *UpdateJobRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	{
+		size, err := m.Spec.MarshalToSizedBuffer(dAtA[:i])
+		if err != nil {
+			return 0, err
+		}
+		i -= size
+		i = encodeVarintScheduler(dAtA, i, uint64(size))
+	}
+	i--
+	dAtA[i] = 0x22
+	if m.Status != 0 {
+		i = encodeVarintScheduler(dAtA, i, uint64(m.Status))
+		i--
+		dAtA[i] = 0x18
+	}
+	if len(m.WorkerId) > 0 {
+		i -= len(m.WorkerId)
+		copy(dAtA[i:], m.WorkerId)
+		i = encodeVarintScheduler(dAtA, i, uint64(len(m.WorkerId)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.JobId) > 0 {
+		i -= len(m.JobId)
+		copy(dAtA[i:], m.JobId)
+		i = encodeVarintScheduler(dAtA, i, uint64(len(m.JobId)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *UpdateJobResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *UpdateJobResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *UpdateJobResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	return len(dAtA) - i, nil
+}
+
+func (m *ListJobsRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ListJobsRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ListJobsRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Tenant) > 0 {
+		i -= len(m.Tenant)
+		copy(dAtA[i:], m.Tenant)
+		i = encodeVarintScheduler(dAtA, i, uint64(len(m.Tenant)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ListJobsResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ListJobsResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ListJobsResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Jobs) > 0 {
+		for iNdEx := len(m.Jobs) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Jobs[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintScheduler(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *JobSpec) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *JobSpec) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *JobSpec) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Order != 0 {
+		i = encodeVarintScheduler(dAtA, i, uint64(m.Order))
+		i--
+		dAtA[i] = 0x68
+	}
+	if m.CostBytes != 0 {
+		i = encodeVarintScheduler(dAtA, i, uint64(m.CostBytes))
+		i--
+		dAtA[i] = 0x60
+	}
+	if m.CostSeries != 0 {
+		i = encodeVarintScheduler(dAtA, i, uint64(m.CostSeries))
+		i--
+		dAtA[i] = 0x58
+	}
+	if m.MaxTime != 0 {
+		i = encodeVarintScheduler(dAtA, i, uint64(m.MaxTime))
+		i--
+		dAtA[i] = 0x50
+	}
+	if m.MinTime != 0 {
+		i = encodeVarintScheduler(dAtA, i, uint64(m.MinTime))
+		i--
+		dAtA[i] = 0x48
+	}
+	if len(m.Blocks) > 0 {
+		for iNdEx := len(m.Blocks) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Blocks[iNdEx])
+			copy(dAtA[i:], m.Blocks[iNdEx])
+			i = encodeVarintScheduler(dAtA, i, uint64(len(m.Blocks[iNdEx])))
+			i--
+			dAtA[i] = 0x42
+		}
+	}
+	if len(m.ShardingKey) > 0 {
+		i -= len(m.ShardingKey)
+		copy(dAtA[i:], m.ShardingKey)
+		i = encodeVarintScheduler(dAtA, i, uint64(len(m.ShardingKey)))
+		i--
+		dAtA[i] = 0x3a
+	}
+	if m.SplitNumShards != 0 {
+		i = encodeVarintScheduler(dAtA, i, uint64(m.SplitNumShards))
+		i--
+		dAtA[i] = 0x30
+	}
+	if m.UseSplitting {
+		i--
+		if m.UseSplitting {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x28
+	}
+	if m.Resolution != 0 {
+		i = encodeVarintScheduler(dAtA, i, uint64(m.Resolution))
+		i--
+		dAtA[i] = 0x20
+	}
+	if len(m.Labels) > 0 {
+		for k := range m.Labels {
+			v := m.Labels[k]
+			baseI := i
+			i -= len(v)
+			copy(dAtA[i:], v)
+			i = encodeVarintScheduler(dAtA, i, uint64(len(v)))
+			i--
+			dAtA[i] = 0x12
+			i -= len(k)
+			copy(dAtA[i:], k)
+			i = encodeVarintScheduler(dAtA, i, uint64(len(k)))
+			i--
+			dAtA[i] = 0xa
+			i = encodeVarintScheduler(dAtA, i, uint64(baseI-i))
+			i--
+			dAtA[i] = 0x1a
+		}
+	}
+	if len(m.Key) > 0 {
+		i -= len(m.Key)
+		copy(dAtA[i:], m.Key)
+		i = encodeVarintScheduler(dAtA, i, uint64(len(m.Key)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.Tenant) > 0 {
+		i -= len(m.Tenant)
+		copy(dAtA[i:], m.Tenant)
+		i = encodeVarintScheduler(dAtA, i, uint64(len(m.Tenant)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *JobState) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *JobState) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *JobState) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.FailCount != 0 {
+		i = encodeVarintScheduler(dAtA, i, uint64(m.FailCount))
+		i--
+		dAtA[i] = 0x30
+	}
+	n4, err4 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.LeaseExpiry, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.LeaseExpiry):])
+	if err4 != nil {
+		return 0, err4
+	}
+	i -= n4
+	i = encodeVarintScheduler(dAtA, i, uint64(n4))
+	i--
+	dAtA[i] = 0x2a
+	if len(m.Assignee) > 0 {
+		i -= len(m.Assignee)
+		copy(dAtA[i:], m.Assignee)
+		i = encodeVarintScheduler(dAtA, i, uint64(len(m.Assignee)))
+		i--
+		dAtA[i] = 0x22
+	}
+	if len(m.State) > 0 {
+		i -= len(m.State)
+		copy(dAtA[i:], m.State)
+		i = encodeVarintScheduler(dAtA, i, uint64(len(m.State)))
+		i--
+		dAtA[i] = 0x1a
+	}
+	{
+		size, err := m.Spec.MarshalToSizedBuffer(dAtA[:i])
+		if err != nil {
+			return 0, err
+		}
+		i -= size
+		i = encodeVarintScheduler(dAtA, i, uint64(size))
+	}
+	i--
+	dAtA[i] = 0x12
+	if len(m.JobId) > 0 {
+		i -= len(m.JobId)
+		copy(dAtA[i:], m.JobId)
+		i = encodeVarintScheduler(dAtA, i, uint64(len(m.JobId)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func encodeVarintScheduler(dAtA []byte, offset int, v uint64) int {
+	offset -= sovScheduler(v)
+	base := offset
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return base
+}
+func (m *AssignJobRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.WorkerId)
+	if l > 0 {
+		n += 1 + l + sovScheduler(uint64(l))
+	}
+	return n
+}
+
+func (m *AssignJobResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.JobId)
+	if l > 0 {
+		n += 1 + l + sovScheduler(uint64(l))
+	}
+	l = m.Spec.Size()
+	n += 1 + l + sovScheduler(uint64(l))
+	l = github_com_gogo_protobuf_types.SizeOfStdDuration(m.LeaseDuration)
+	n += 1 + l + sovScheduler(uint64(l))
+	return n
+}
+
+func (m *UpdateJobRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.JobId)
+	if l > 0 {
+		n += 1 + l + sovScheduler(uint64(l))
+	}
+	l = len(m.WorkerId)
+	if l > 0 {
+		n += 1 + l + sovScheduler(uint64(l))
+	}
+	if m.Status != 0 {
+		n += 1 + sovScheduler(uint64(m.Status))
+	}
+	l = m.Spec.Size()
+	n += 1 + l + sovScheduler(uint64(l))
+	return n
+}
+
+func (m *UpdateJobResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	return n
+}
+
+func (m *ListJobsRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Tenant)
+	if l > 0 {
+		n += 1 + l + sovScheduler(uint64(l))
+	}
+	return n
+}
+
+func (m *ListJobsResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Jobs) > 0 {
+		for _, e := range m.Jobs {
+			l = e.Size()
+			n += 1 + l + sovScheduler(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *JobSpec) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Tenant)
+	if l > 0 {
+		n += 1 + l + sovScheduler(uint64(l))
+	}
+	l = len(m.Key)
+	if l > 0 {
+		n += 1 + l + sovScheduler(uint64(l))
+	}
+	if len(m.Labels) > 0 {
+		for k, v := range m.Labels {
+			_ = k
+			_ = v
+			mapEntrySize := 1 + len(k) + sovScheduler(uint64(len(k))) + 1 + len(v) + sovScheduler(uint64(len(v)))
+			n += mapEntrySize + 1 + sovScheduler(uint64(mapEntrySize))
+		}
+	}
+	if m.Resolution != 0 {
+		n += 1 + sovScheduler(uint64(m.Resolution))
+	}
+	if m.UseSplitting {
+		n += 2
+	}
+	if m.SplitNumShards != 0 {
+		n += 1 + sovScheduler(uint64(m.SplitNumShards))
+	}
+	l = len(m.ShardingKey)
+	if l > 0 {
+		n += 1 + l + sovScheduler(uint64(l))
+	}
+	if len(m.Blocks) > 0 {
+		for _, s := range m.Blocks {
+			l = len(s)
+			n += 1 + l + sovScheduler(uint64(l))
+		}
+	}
+	if m.MinTime != 0 {
+		n += 1 + sovScheduler(uint64(m.MinTime))
+	}
+	if m.MaxTime != 0 {
+		n += 1 + sovScheduler(uint64(m.MaxTime))
+	}
+	if m.CostSeries != 0 {
+		n += 1 + sovScheduler(uint64(m.CostSeries))
+	}
+	if m.CostBytes != 0 {
+		n += 1 + sovScheduler(uint64(m.CostBytes))
+	}
+	if m.Order != 0 {
+		n += 1 + sovScheduler(uint64(m.Order))
+	}
+	return n
+}
+
+func (m *JobState) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.JobId)
+	if l > 0 {
+		n += 1 + l + sovScheduler(uint64(l))
+	}
+	l = m.Spec.Size()
+	n += 1 + l + sovScheduler(uint64(l))
+	l = len(m.State)
+	if l > 0 {
+		n += 1 + l + sovScheduler(uint64(l))
+	}
+	l = len(m.Assignee)
+	if l > 0 {
+		n += 1 + l + sovScheduler(uint64(l))
+	}
+	l = github_com_gogo_protobuf_types.SizeOfStdTime(m.LeaseExpiry)
+	n += 1 + l + sovScheduler(uint64(l))
+	if m.FailCount != 0 {
+		n += 1 + sovScheduler(uint64(m.FailCount))
+	}
+	return n
+}
+
+func sovScheduler(x uint64) (n int) {
+	return (math_bits.Len64(x|1) + 6) / 7
+}
+func sozScheduler(x uint64) (n int) {
+	return sovScheduler(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (this *AssignJobRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&AssignJobRequest{`,
+		`WorkerId:` + fmt.Sprintf("%v", this.WorkerId) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *AssignJobResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&AssignJobResponse{`,
+		`JobId:` + fmt.Sprintf("%v", this.JobId) + `,`,
+		`Spec:` + strings.Replace(strings.Replace(this.Spec.String(), "JobSpec", "JobSpec", 1), `&`, ``, 1) + `,`,
+		`LeaseDuration:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.LeaseDuration), "Duration", "durationpb.Duration", 1), `&`, ``, 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *UpdateJobRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&UpdateJobRequest{`,
+		`JobId:` + fmt.Sprintf("%v", this.JobId) + `,`,
+		`WorkerId:` + fmt.Sprintf("%v", this.WorkerId) + `,`,
+		`Status:` + fmt.Sprintf("%v", this.Status) + `,`,
+		`Spec:` + strings.Replace(strings.Replace(this.Spec.String(), "JobSpec", "JobSpec", 1), `&`, ``, 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *UpdateJobResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&UpdateJobResponse{`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *ListJobsRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&ListJobsRequest{`,
+		`Tenant:` + fmt.Sprintf("%v", this.Tenant) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *ListJobsResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForJobs := "[]JobState{"
+	for _, f := range this.Jobs {
+		repeatedStringForJobs += strings.Replace(strings.Replace(f.String(), "JobState", "JobState", 1), `&`, ``, 1) + ","
+	}
+	repeatedStringForJobs += "}"
+	s := strings.Join([]string{`&ListJobsResponse{`,
+		`Jobs:` + repeatedStringForJobs + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *JobSpec) String() string {
+	if this == nil {
+		return "nil"
+	}
+	keysForLabels := make([]string, 0, len(this.Labels))
+	for k, _ := range this.Labels {
+		keysForLabels = append(keysForLabels, k)
+	}
+	github_com_gogo_protobuf_sortkeys.Strings(keysForLabels)
+	mapStringForLabels := "map[string]string{"
+	for _, k := range keysForLabels {
+		mapStringForLabels += fmt.Sprintf("%v: %v,", k, this.Labels[k])
+	}
+	mapStringForLabels += "}"
+	s := strings.Join([]string{`&JobSpec{`,
+		`Tenant:` + fmt.Sprintf("%v", this.Tenant) + `,`,
+		`Key:` + fmt.Sprintf("%v", this.Key) + `,`,
+		`Labels:` + mapStringForLabels + `,`,
+		`Resolution:` + fmt.Sprintf("%v", this.Resolution) + `,`,
+		`UseSplitting:` + fmt.Sprintf("%v", this.UseSplitting) + `,`,
+		`SplitNumShards:` + fmt.Sprintf("%v", this.SplitNumShards) + `,`,
+		`ShardingKey:` + fmt.Sprintf("%v", this.ShardingKey) + `,`,
+		`Blocks:` + fmt.Sprintf("%v", this.Blocks) + `,`,
+		`MinTime:` + fmt.Sprintf("%v", this.MinTime) + `,`,
+		`MaxTime:` + fmt.Sprintf("%v", this.MaxTime) + `,`,
+		`CostSeries:` + fmt.Sprintf("%v", this.CostSeries) + `,`,
+		`CostBytes:` + fmt.Sprintf("%v", this.CostBytes) + `,`,
+		`Order:` + fmt.Sprintf("%v", this.Order) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *JobState) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&JobState{`,
+		`JobId:` + fmt.Sprintf("%v", this.JobId) + `,`,
+		`Spec:` + strings.Replace(strings.Replace(this.Spec.String(), "JobSpec", "JobSpec", 1), `&`, ``, 1) + `,`,
+		`State:` + fmt.Sprintf("%v", this.State) + `,`,
+		`Assignee:` + fmt.Sprintf("%v", this.Assignee) + `,`,
+		`LeaseExpiry:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.LeaseExpiry), "Timestamp", "timestamppb.Timestamp", 1), `&`, ``, 1) + `,`,
+		`FailCount:` + fmt.Sprintf("%v", this.FailCount) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func valueToStringScheduler(v interface{}) string {
+	rv := reflect.ValueOf(v)
+	if rv.IsNil() {
+		return "nil"
+	}
+	pv := reflect.Indirect(rv).Interface()
+	return fmt.Sprintf("*%v", pv)
+}
+func (m *AssignJobRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowScheduler
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: AssignJobRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: AssignJobRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field WorkerId", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.WorkerId = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipScheduler(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *AssignJobResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowScheduler
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: AssignJobResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: AssignJobResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field JobId", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.JobId = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Spec", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := m.Spec.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LeaseDuration", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := github_com_gogo_protobuf_types.StdDurationUnmarshal(&m.LeaseDuration, dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipScheduler(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *UpdateJobRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowScheduler
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: UpdateJobRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: UpdateJobRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field JobId", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.JobId = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field WorkerId", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.WorkerId = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Status", wireType)
+			}
+			m.Status = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Status |= JobStatus(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Spec", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := m.Spec.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipScheduler(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *UpdateJobResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowScheduler
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: UpdateJobResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: UpdateJobResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		default:
+			iNdEx = preIndex
+			skippy, err := skipScheduler(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ListJobsRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowScheduler
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ListJobsRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ListJobsRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Tenant", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Tenant = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipScheduler(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ListJobsResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowScheduler
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ListJobsResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ListJobsResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Jobs", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Jobs = append(m.Jobs, JobState{})
+			if err := m.Jobs[len(m.Jobs)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipScheduler(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *JobSpec) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowScheduler
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: JobSpec: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: JobSpec: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Tenant", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Tenant = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Key", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Key = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Labels", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Labels == nil {
+				m.Labels = make(map[string]string)
+			}
+			var mapkey string
+			var mapvalue string
+			for iNdEx < postIndex {
+				entryPreIndex := iNdEx
+				var wire uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowScheduler
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					wire |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				fieldNum := int32(wire >> 3)
+				if fieldNum == 1 {
+					var stringLenmapkey uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowScheduler
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapkey |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapkey := int(stringLenmapkey)
+					if intStringLenmapkey < 0 {
+						return ErrInvalidLengthScheduler
+					}
+					postStringIndexmapkey := iNdEx + intStringLenmapkey
+					if postStringIndexmapkey < 0 {
+						return ErrInvalidLengthScheduler
+					}
+					if postStringIndexmapkey > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
+					iNdEx = postStringIndexmapkey
+				} else if fieldNum == 2 {
+					var stringLenmapvalue uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowScheduler
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapvalue |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapvalue := int(stringLenmapvalue)
+					if intStringLenmapvalue < 0 {
+						return ErrInvalidLengthScheduler
+					}
+					postStringIndexmapvalue := iNdEx + intStringLenmapvalue
+					if postStringIndexmapvalue < 0 {
+						return ErrInvalidLengthScheduler
+					}
+					if postStringIndexmapvalue > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapvalue = string(dAtA[iNdEx:postStringIndexmapvalue])
+					iNdEx = postStringIndexmapvalue
+				} else {
+					iNdEx = entryPreIndex
+					skippy, err := skipScheduler(dAtA[iNdEx:])
+					if err != nil {
+						return err
+					}
+					if skippy < 0 {
+						return ErrInvalidLengthScheduler
+					}
+					if (iNdEx + skippy) > postIndex {
+						return io.ErrUnexpectedEOF
+					}
+					iNdEx += skippy
+				}
+			}
+			m.Labels[mapkey] = mapvalue
+			iNdEx = postIndex
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Resolution", wireType)
+			}
+			m.Resolution = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Resolution |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field UseSplitting", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.UseSplitting = bool(v != 0)
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SplitNumShards", wireType)
+			}
+			m.SplitNumShards = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.SplitNumShards |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ShardingKey", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ShardingKey = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 8:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Blocks", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Blocks = append(m.Blocks, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		case 9:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MinTime", wireType)
+			}
+			m.MinTime = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MinTime |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 10:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxTime", wireType)
+			}
+			m.MaxTime = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxTime |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 11:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CostSeries", wireType)
+			}
+			m.CostSeries = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.CostSeries |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 12:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CostBytes", wireType)
+			}
+			m.CostBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.CostBytes |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 13:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Order", wireType)
+			}
+			m.Order = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Order |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipScheduler(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *JobState) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowScheduler
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: JobState: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: JobState: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field JobId", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.JobId = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Spec", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := m.Spec.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field State", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.State = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Assignee", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Assignee = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LeaseExpiry", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := github_com_gogo_protobuf_types.StdTimeUnmarshal(&m.LeaseExpiry, dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FailCount", wireType)
+			}
+			m.FailCount = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.FailCount |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipScheduler(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func skipScheduler(dAtA []byte) (n int, err error) {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return 0, ErrIntOverflowScheduler
+			}
+			if iNdEx >= l {
+				return 0, io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		wireType := int(wire & 0x7)
+		switch wireType {
+		case 0:
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return 0, ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return 0, io.ErrUnexpectedEOF
+				}
+				iNdEx++
+				if dAtA[iNdEx-1] < 0x80 {
+					break
+				}
+			}
+			return iNdEx, nil
+		case 1:
+			iNdEx += 8
+			return iNdEx, nil
+		case 2:
+			var length int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return 0, ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return 0, io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				length |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if length < 0 {
+				return 0, ErrInvalidLengthScheduler
+			}
+			iNdEx += length
+			if iNdEx < 0 {
+				return 0, ErrInvalidLengthScheduler
+			}
+			return iNdEx, nil
+		case 3:
+			for {
+				var innerWire uint64
+				var start int = iNdEx
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return 0, ErrIntOverflowScheduler
+					}
+					if iNdEx >= l {
+						return 0, io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					innerWire |= (uint64(b) & 0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				innerWireType := int(innerWire & 0x7)
+				if innerWireType == 4 {
+					break
+				}
+				next, err := skipScheduler(dAtA[start:])
+				if err != nil {
+					return 0, err
+				}
+				iNdEx = start + next
+				if iNdEx < 0 {
+					return 0, ErrInvalidLengthScheduler
+				}
+			}
+			return iNdEx, nil
+		case 4:
+			return iNdEx, nil
+		case 5:
+			iNdEx += 4
+			return iNdEx, nil
+		default:
+			return 0, fmt.Errorf("proto: illegal wireType %d", wireType)
+		}
+	}
+	panic("unreachable")
+}
+
+var (
+	ErrInvalidLengthScheduler = fmt.Errorf("proto: negative length found during unmarshaling")
+	ErrIntOverflowScheduler   = fmt.Errorf("proto: integer overflow")
+)
@@ -0,0 +1,197 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storage/tsdb/downsample"
+)
+
+// excludeDownsampledBlocksFilter is a block.MetadataFilter which removes the downsampled blocks. Downsampled
+// blocks are never compacted: they're created from the compacted raw blocks instead.
+type excludeDownsampledBlocksFilter struct{}
+
+func (excludeDownsampledBlocksFilter) Filter(_ context.Context, metas map[ulid.ULID]*block.Meta, _ block.GaugeVec) error {
+	for id, meta := range metas {
+		if meta.Thanos.Downsample.Resolution > downsample.ResLevel0 {
+			delete(metas, id)
+		}
+	}
+	return nil
+}
+
+// downsampleUserBlocks downsamples the raw blocks of the tenant older than the 5m downsampling age to 5m resolution,
+// and the 5m blocks older than the 1h downsampling age to 1h resolution. A block is downsampled only once: it's
+// skipped if the downsampled blocks already cover all its sources.
+func (c *MultitenantCompactor) downsampleUserBlocks(ctx context.Context, userID string, userBucket objstore.InstrumentedBucket, logger log.Logger) error {
+	// Blocks marked for no-compaction are downsampled too, so we don't apply the compaction filters, but
+	// blocks moved to the cold storage are not.
+	fetcher, err := block.NewMetaFetcher(logger, c.compactorCfg.MetaSyncConcurrency, userBucket, "", nil, []block.MetadataFilter{newColdStorageMarkFilter(userBucket), newQuarantineMarkFilter(userBucket)}, nil)
+	if err != nil {
+		return err
+	}
+	metas, _, err := fetcher.FetchWithoutMarkedForDeletion(ctx)
+	if err != nil {
+		return errors.Wrap(err, "fetch blocks metadata")
+	}
+
+	steps := []struct {
+		from, to int64
+		after    time.Duration
+	}{
+		{from: downsample.ResLevel0, to: downsample.ResLevel1, after: c.cfgProvider.CompactorDownsampling5mAfter(userID)},
+		{from: downsample.ResLevel1, to: downsample.ResLevel2, after: c.cfgProvider.CompactorDownsampling1hAfter(userID)},
+	}
+
+	for _, step := range steps {
+		if step.after <= 0 {
+			continue
+		}
+
+		for _, meta := range blocksToDownsample(metas, step.from, step.to, time.Now().Add(-step.after)) {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			newMeta, err := c.downsampleBlock(ctx, userBucket, meta, step.to, logger)
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				// A failing block shouldn't prevent the other blocks from being downsampled.
+				c.blocksDownsampleFailures.WithLabelValues(resolutionLabel(step.to)).Inc()
+				level.Warn(logger).Log("msg", "failed to downsample block", "block", meta.ULID, "resolution", step.to, "err", err)
+				continue
+			}
+			// The new block may be downsampled further in the next step.
+			metas[newMeta.ULID] = newMeta
+		}
+	}
+	return nil
+}
+
+// blocksToDownsample returns the blocks with the resolution from, whose samples are all older than maxTime, and
+// whose sources are not already covered by the blocks with the resolution to. The returned blocks are sorted by
+// min time.
+func blocksToDownsample(metas map[ulid.ULID]*block.Meta, from, to int64, maxTime time.Time) []*block.Meta {
+	// The sources of the split blocks are the same for all the shards, so they're tracked by external labels.
+	type source struct {
+		labels string
+		id     ulid.ULID
+	}
+
+	covered := map[source]struct{}{}
+	for _, meta := range metas {
+		if meta.Thanos.Downsample.Resolution != to {
+			continue
+		}
+		lbls := labels.FromMap(meta.Thanos.Labels).String()
+		for _, id := range meta.Compaction.Sources {
+			covered[source{labels: lbls, id: id}] = struct{}{}
+		}
+	}
+
+	var result []*block.Meta
+	for _, meta := range metas {
+		if meta.Thanos.Downsample.Resolution != from || meta.MaxTime > maxTime.UnixMilli() {
+			continue
+		}
+
+		lbls := labels.FromMap(meta.Thanos.Labels).String()
+		missing := false
+		for _, id := range meta.Compaction.Sources {
+			if _, ok := covered[source{labels: lbls, id: id}]; !ok {
+				missing = true
+				break
+			}
+		}
+		if missing {
+			result = append(result, meta)
+		}
+	}
+
+	slices.SortFunc(result, func(a, b *block.Meta) int {
+		switch {
+		case a.MinTime < b.MinTime:
+			return -1
+		case a.MinTime > b.MinTime:
+			return 1
+		}
+		return a.ULID.Compare(b.ULID)
+	})
+	return result
+}
+
+// downsampleBlock downloads the block, downsamples it to the resolution and uploads the downsampled block.
+func (c *MultitenantCompactor) downsampleBlock(ctx context.Context, userBucket objstore.Bucket, meta *block.Meta, resolution int64, logger log.Logger) (*block.Meta, error) {
+	logger = log.With(logger, "block", meta.ULID, "resolution", resolutionLabel(resolution))
+	start := time.Now()
+
+	tmpDir, err := os.MkdirTemp(c.compactorCfg.DataDir, "downsample-")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			level.Warn(logger).Log("msg", "failed to remove downsampling temporary directory", "dir", tmpDir, "err", err)
+		}
+	}()
+
+	bdir := filepath.Join(tmpDir, meta.ULID.String())
+	if err := block.Download(ctx, logger, userBucket, meta.ULID, bdir); err != nil {
+		return nil, errors.Wrapf(err, "download block %s", meta.ULID)
+	}
+
+	b, err := tsdb.OpenBlock(logger, bdir, downsample.NewPool())
+	if err != nil {
+		return nil, errors.Wrapf(err, "open block %s", meta.ULID)
+	}
+
+	id, err := downsample.Downsample(ctx, logger, meta, b, tmpDir, resolution)
+	if closeErr := b.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resdir := filepath.Join(tmpDir, id.String())
+	newMeta, err := block.ReadMetaFromDir(resdir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read meta of downsampled block %s", id)
+	}
+	if err := block.Upload(ctx, logger, userBucket, resdir, newMeta); err != nil {
+		return nil, errors.Wrapf(err, "upload of %s failed", id)
+	}
+
+	c.blocksDownsampled.WithLabelValues(resolutionLabel(resolution)).Inc()
+	level.Info(logger).Log("msg", "uploaded downsampled block", "result_block", id, "series", newMeta.Stats.NumSeries, "duration", time.Since(start))
+	return newMeta, nil
+}
+
+func resolutionLabel(resolution int64) string {
+	switch resolution {
+	case downsample.ResLevel0:
+		return "raw"
+	case downsample.ResLevel1:
+		return "5m"
+	case downsample.ResLevel2:
+		return "1h"
+	}
+	return strconv.FormatInt(resolution, 10)
+}
@@ -0,0 +1,152 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"context"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storage/tsdb/downsample"
+)
+
+func TestBlocksToDownsample(t *testing.T) {
+	now := time.Now()
+	newMeta := func(id uint64, maxTime time.Time, resolution int64, lbls map[string]string, sources ...ulid.ULID) *block.Meta {
+		meta := &block.Meta{}
+		meta.ULID = ulid.MustNew(id, nil)
+		meta.MinTime = maxTime.Add(-2 * time.Hour).UnixMilli()
+		meta.MaxTime = maxTime.UnixMilli()
+		meta.Compaction.Sources = sources
+		meta.Thanos.Labels = lbls
+		meta.Thanos.Downsample.Resolution = resolution
+		return meta
+	}
+
+	source1, source2, source3 := ulid.MustNew(101, nil), ulid.MustNew(102, nil), ulid.MustNew(103, nil)
+	shard1 := map[string]string{"__compactor_shard_id__": "1_of_2"}
+	shard2 := map[string]string{"__compactor_shard_id__": "2_of_2"}
+
+	// Raw blocks.
+	old := newMeta(1, now.Add(-48*time.Hour), downsample.ResLevel0, nil, source1)
+	oldest := newMeta(2, now.Add(-72*time.Hour), downsample.ResLevel0, nil, source2)
+	recent := newMeta(3, now.Add(-time.Hour), downsample.ResLevel0, nil, source3)
+	oldShard1 := newMeta(4, now.Add(-96*time.Hour), downsample.ResLevel0, shard1, source1, source2)
+	oldShard2 := newMeta(5, now.Add(-96*time.Hour), downsample.ResLevel0, shard2, source1, source2)
+
+	// Downsampled blocks.
+	oldest5m := newMeta(6, now.Add(-72*time.Hour), downsample.ResLevel1, nil, source2)
+	oldShard15m := newMeta(7, now.Add(-96*time.Hour), downsample.ResLevel1, shard1, source1, source2)
+
+	metas := map[ulid.ULID]*block.Meta{}
+	for _, meta := range []*block.Meta{old, oldest, recent, oldShard1, oldShard2, oldest5m, oldShard15m} {
+		metas[meta.ULID] = meta
+	}
+
+	assert.Equal(t, []*block.Meta{oldShard2, old}, blocksToDownsample(metas, downsample.ResLevel0, downsample.ResLevel1, now.Add(-24*time.Hour)))
+	assert.Equal(t, []*block.Meta{oldShard2, old, recent}, blocksToDownsample(metas, downsample.ResLevel0, downsample.ResLevel1, now))
+	assert.Equal(t, []*block.Meta{oldShard15m, oldest5m}, blocksToDownsample(metas, downsample.ResLevel1, downsample.ResLevel2, now))
+	assert.Empty(t, blocksToDownsample(metas, downsample.ResLevel1, downsample.ResLevel2, now.Add(-100*time.Hour)))
+}
+
+func TestMultitenantCompactor_DownsampleUserBlocks(t *testing.T) {
+	const userID = "user-1"
+
+	ctx := context.Background()
+	bucketClient := objstore.NewInMemBucket()
+
+	// A raw block old enough to be downsampled to both resolutions, and a recent one.
+	oldID := createTSDBBlock(t, bucketClient, userID, 0, 2*time.Hour.Milliseconds(), 10, nil)
+	now := time.Now().UnixMilli()
+	recentID := createTSDBBlock(t, bucketClient, userID, now-time.Hour.Milliseconds(), now, 10, nil)
+
+	cfgProvider := newMockConfigProvider()
+	cfgProvider.downsampling5mAfter[userID] = 24 * time.Hour
+	cfgProvider.downsampling1hAfter[userID] = 48 * time.Hour
+
+	c, _, _, _, _ := prepareWithConfigProvider(t, prepareConfig(t), bucketClient, cfgProvider)
+	userBucket := bucket.NewUserBucketClient(userID, bucketClient, cfgProvider)
+
+	listResolutions := func() map[int64][]*block.Meta {
+		fetcher, err := block.NewMetaFetcher(log.NewNopLogger(), 1, userBucket, "", nil, nil, nil)
+		require.NoError(t, err)
+		metas, _, err := fetcher.Fetch(ctx)
+		require.NoError(t, err)
+
+		byResolution := map[int64][]*block.Meta{}
+		for _, meta := range metas {
+			byResolution[meta.Thanos.Downsample.Resolution] = append(byResolution[meta.Thanos.Downsample.Resolution], meta)
+		}
+		return byResolution
+	}
+
+	require.NoError(t, c.downsampleUserBlocks(ctx, userID, userBucket, log.NewNopLogger()))
+
+	byResolution := listResolutions()
+	require.Len(t, byResolution[downsample.ResLevel0], 2)
+	require.Len(t, byResolution[downsample.ResLevel1], 1)
+	require.Len(t, byResolution[downsample.ResLevel2], 1)
+
+	for _, meta := range []*block.Meta{byResolution[downsample.ResLevel1][0], byResolution[downsample.ResLevel2][0]} {
+		assert.Equal(t, []ulid.ULID{oldID}, meta.Compaction.Sources)
+		assert.NotEqual(t, recentID, meta.Compaction.Parents[0].ULID)
+		assert.Equal(t, int64(10), int64(meta.Stats.NumSeries))
+	}
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(c.blocksDownsampled.WithLabelValues("5m")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(c.blocksDownsampled.WithLabelValues("1h")))
+
+	// The blocks are not downsampled again.
+	require.NoError(t, c.downsampleUserBlocks(ctx, userID, userBucket, log.NewNopLogger()))
+	byResolution = listResolutions()
+	require.Len(t, byResolution[downsample.ResLevel1], 1)
+	require.Len(t, byResolution[downsample.ResLevel2], 1)
+	assert.Equal(t, 1.0, testutil.ToFloat64(c.blocksDownsampled.WithLabelValues("5m")))
+}
+
+func TestMultitenantCompactor_DownsampleUserBlocks_ShouldContinueOnBlockFailure(t *testing.T) {
+	const userID = "user-1"
+
+	ctx := context.Background()
+	bucketClient := objstore.NewInMemBucket()
+
+	// Two raw blocks old enough to be downsampled, one of which is missing its index.
+	corruptedID := createTSDBBlock(t, bucketClient, userID, 0, 2*time.Hour.Milliseconds(), 10, nil)
+	validID := createTSDBBlock(t, bucketClient, userID, 2*time.Hour.Milliseconds(), 4*time.Hour.Milliseconds(), 10, nil)
+	require.NoError(t, bucketClient.Delete(ctx, path.Join(userID, corruptedID.String(), block.IndexFilename)))
+
+	cfgProvider := newMockConfigProvider()
+	cfgProvider.downsampling5mAfter[userID] = 24 * time.Hour
+
+	c, _, _, _, _ := prepareWithConfigProvider(t, prepareConfig(t), bucketClient, cfgProvider)
+	userBucket := bucket.NewUserBucketClient(userID, bucketClient, cfgProvider)
+
+	require.NoError(t, c.downsampleUserBlocks(ctx, userID, userBucket, log.NewNopLogger()))
+
+	fetcher, err := block.NewMetaFetcher(log.NewNopLogger(), 1, userBucket, "", nil, nil, nil)
+	require.NoError(t, err)
+	metas, _, err := fetcher.Fetch(ctx)
+	require.NoError(t, err)
+
+	var downsampled []*block.Meta
+	for _, meta := range metas {
+		if meta.Thanos.Downsample.Resolution == downsample.ResLevel1 {
+			downsampled = append(downsampled, meta)
+		}
+	}
+	require.Len(t, downsampled, 1)
+	assert.Equal(t, []ulid.ULID{validID}, downsampled[0].Compaction.Sources)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(c.blocksDownsampled.WithLabelValues("5m")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(c.blocksDownsampleFailures.WithLabelValues("5m")))
+}
@@ -0,0 +1,141 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/grafana/dskit/runutil"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/tsdb/index"
+	"github.com/prometheus/prometheus/tsdb/tombstones"
+
+	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+)
+
+// readSourceBlocksExemplars reads and merges the exemplars of the source blocks of a compaction job.
+func readSourceBlocksExemplars(dirs []string) (*block.Exemplars, error) {
+	sets := make([]*block.Exemplars, 0, len(dirs))
+	for _, dir := range dirs {
+		e, err := block.ReadExemplarsFromDir(dir)
+		if err != nil {
+			return nil, errors.Wrapf(err, "read exemplars of block %s", dir)
+		}
+		sets = append(sets, e)
+	}
+	return block.MergeExemplars(sets...), nil
+}
+
+// writeCompactedBlockExemplars writes to the compacted block the exemplars of the source blocks whose series are
+// in the compacted block, within the block time range and the retention period, and not deleted by series deletion requests.
+func writeCompactedBlockExemplars(ctx context.Context, bdir string, meta *block.Meta, exemplars *block.Exemplars, retention time.Duration, deletionReqs mimir_tsdb.SeriesDeletionRequests) (err error) {
+	if exemplars.Len() == 0 {
+		return nil
+	}
+
+	r, err := index.NewFileReader(filepath.Join(bdir, block.IndexFilename))
+	if err != nil {
+		return errors.Wrap(err, "open index file")
+	}
+	defer runutil.CloseWithErrCapture(&err, r, "close index reader")
+
+	series, err := newBlockSeriesCursor(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	// The block max time is exclusive.
+	minT, maxT := meta.MinTime, meta.MaxTime-1
+	if retention > 0 {
+		minT = max(minT, time.Now().Add(-retention).UnixMilli())
+	}
+
+	// The exemplars series are sorted by labels, like the index series.
+	filtered := exemplars.Filter(minT, maxT, series.contains)
+	if err := series.Err(); err != nil {
+		return err
+	}
+
+	if reqs := deletionReqs.Overlapping(minT, maxT); len(reqs) > 0 {
+		kept := filtered.Series[:0]
+		for _, s := range filtered.Series {
+			intervals := reqs.DeletedIntervals(s.Labels)
+
+			exemplars := s.Exemplars[:0]
+			for _, e := range s.Exemplars {
+				if !slices.ContainsFunc(intervals, func(itv tombstones.Interval) bool { return itv.InBounds(e.Timestamp) }) {
+					exemplars = append(exemplars, e)
+				}
+			}
+			if len(exemplars) > 0 {
+				kept = append(kept, block.ExemplarSeries{Labels: s.Labels, Exemplars: exemplars})
+			}
+		}
+		filtered.Series = kept
+	}
+
+	if filtered.Len() == 0 {
+		return nil
+	}
+	return block.WriteExemplarsFile(bdir, filtered)
+}
+
+// blockSeriesCursor checks whether series are in a block index, iterating the index series in order instead of
+// loading all of them in memory. The series must be checked in increasing labels order, which is the order of
+// the series in the index.
+type blockSeriesCursor struct {
+	r       *index.Reader
+	p       index.Postings
+	builder labels.ScratchBuilder
+
+	// cur holds the labels of the current index series, valid if ok is true.
+	cur  labels.Labels
+	ok   bool
+	done bool
+	err  error
+}
+
+func newBlockSeriesCursor(ctx context.Context, r *index.Reader) (*blockSeriesCursor, error) {
+	n, v := index.AllPostingsKey()
+	p, err := r.Postings(ctx, n, v)
+	if err != nil {
+		return nil, errors.Wrap(err, "get all postings")
+	}
+	return &blockSeriesCursor{r: r, p: p}, nil
+}
+
+// contains returns whether the series is in the block. It returns false once an error occurred, see Err.
+func (c *blockSeriesCursor) contains(lset labels.Labels) bool {
+	for !c.done && c.err == nil {
+		if c.ok {
+			switch cmp := labels.Compare(c.cur, lset); {
+			case cmp == 0:
+				return true
+			case cmp > 0:
+				return false
+			}
+		}
+
+		if !c.p.Next() {
+			c.done = true
+			c.err = errors.Wrap(c.p.Err(), "iterate postings")
+			break
+		}
+		if err := c.r.Series(c.p.At(), &c.builder, nil); err != nil {
+			c.err = errors.Wrap(err, "read series")
+			break
+		}
+		c.cur, c.ok = c.builder.Labels(), true
+	}
+	return false
+}
+
+// Err returns the error occurred while iterating the index series, if any.
+func (c *blockSeriesCursor) Err() error {
+	return c.err
+}
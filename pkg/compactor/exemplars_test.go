@@ -0,0 +1,129 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+)
+
+func TestReadSourceBlocksExemplars(t *testing.T) {
+	dir1, dir2, dir3 := t.TempDir(), t.TempDir(), t.TempDir()
+	series := labels.FromStrings("series_id", "0")
+	trace1 := labels.FromStrings("trace_id", "1")
+	trace2 := labels.FromStrings("trace_id", "2")
+
+	require.NoError(t, block.WriteExemplarsFile(dir1, &block.Exemplars{Series: []block.ExemplarSeries{
+		{Labels: series, Exemplars: []block.Exemplar{{Labels: trace1, Value: 1, Timestamp: 10}}},
+	}}))
+	require.NoError(t, block.WriteExemplarsFile(dir2, &block.Exemplars{Series: []block.ExemplarSeries{
+		{Labels: series, Exemplars: []block.Exemplar{{Labels: trace1, Value: 1, Timestamp: 10}, {Labels: trace2, Value: 2, Timestamp: 20}}},
+	}}))
+
+	// The third block has no exemplars file.
+	e, err := readSourceBlocksExemplars([]string{dir1, dir2, dir3})
+	require.NoError(t, err)
+	assert.Equal(t, []block.ExemplarSeries{
+		{Labels: series, Exemplars: []block.Exemplar{{Labels: trace1, Value: 1, Timestamp: 10}, {Labels: trace2, Value: 2, Timestamp: 20}}},
+	}, e.Series)
+}
+
+func TestWriteCompactedBlockExemplars(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UnixMilli()
+	trace := labels.FromStrings("trace_id", "1")
+
+	tests := map[string]struct {
+		retention    time.Duration
+		deletionReqs []string
+		expected     []block.ExemplarSeries
+	}{
+		"no retention and no series deletion": {
+			expected: []block.ExemplarSeries{
+				{Labels: labels.FromStrings("series_id", "0"), Exemplars: []block.Exemplar{
+					{Labels: trace, Value: 1, Timestamp: now - 2*time.Hour.Milliseconds()},
+					{Labels: trace, Value: 2, Timestamp: now - time.Minute.Milliseconds()},
+				}},
+				{Labels: labels.FromStrings("series_id", "1"), Exemplars: []block.Exemplar{
+					{Labels: trace, Value: 3, Timestamp: now - time.Minute.Milliseconds()},
+				}},
+			},
+		},
+		"exemplars older than the retention period are dropped": {
+			retention: time.Hour,
+			expected: []block.ExemplarSeries{
+				{Labels: labels.FromStrings("series_id", "0"), Exemplars: []block.Exemplar{
+					{Labels: trace, Value: 2, Timestamp: now - time.Minute.Milliseconds()},
+				}},
+				{Labels: labels.FromStrings("series_id", "1"), Exemplars: []block.Exemplar{
+					{Labels: trace, Value: 3, Timestamp: now - time.Minute.Milliseconds()},
+				}},
+			},
+		},
+		"exemplars of deleted series are dropped": {
+			deletionReqs: []string{`{series_id="1"}`},
+			expected: []block.ExemplarSeries{
+				{Labels: labels.FromStrings("series_id", "0"), Exemplars: []block.Exemplar{
+					{Labels: trace, Value: 1, Timestamp: now - 2*time.Hour.Milliseconds()},
+					{Labels: trace, Value: 2, Timestamp: now - time.Minute.Milliseconds()},
+				}},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			bkt := objstore.NewInMemBucket()
+			userBkt := bucket.NewUserBucketClient("user", bkt, nil)
+			blockID := createTSDBBlock(t, bkt, "user", now-3*time.Hour.Milliseconds(), now, 2, nil)
+
+			dir := filepath.Join(t.TempDir(), blockID.String())
+			require.NoError(t, block.Download(ctx, log.NewNopLogger(), userBkt, blockID, dir))
+			meta, err := block.ReadMetaFromDir(dir)
+			require.NoError(t, err)
+
+			exemplars := &block.Exemplars{Series: []block.ExemplarSeries{
+				{Labels: labels.FromStrings("series_id", "0"), Exemplars: []block.Exemplar{
+					{Labels: trace, Value: 1, Timestamp: now - 2*time.Hour.Milliseconds()},
+					{Labels: trace, Value: 2, Timestamp: now - time.Minute.Milliseconds()},
+				}},
+				// Series not in the block, sorted between the block series.
+				{Labels: labels.FromStrings("series_id", "00"), Exemplars: []block.Exemplar{
+					{Labels: trace, Value: 5, Timestamp: now - time.Minute.Milliseconds()},
+				}},
+				{Labels: labels.FromStrings("series_id", "1"), Exemplars: []block.Exemplar{
+					{Labels: trace, Value: 3, Timestamp: now - time.Minute.Milliseconds()},
+				}},
+				// Series not in the block.
+				{Labels: labels.FromStrings("series_id", "100"), Exemplars: []block.Exemplar{
+					{Labels: trace, Value: 4, Timestamp: now - time.Minute.Milliseconds()},
+				}},
+			}}
+
+			var reqs mimir_tsdb.SeriesDeletionRequests
+			if len(tc.deletionReqs) > 0 {
+				req, err := mimir_tsdb.NewSeriesDeletionRequest(tc.deletionReqs, 0, now, time.Now())
+				require.NoError(t, err)
+				reqs = append(reqs, req)
+			}
+
+			require.NoError(t, writeCompactedBlockExemplars(ctx, dir, meta, exemplars, tc.retention, reqs))
+
+			actual, err := block.ReadExemplarsFromDir(dir)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, actual.Series)
+		})
+	}
+}
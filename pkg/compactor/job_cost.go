@@ -0,0 +1,120 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"sync"
+	"time"
+
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+)
+
+const (
+	// Estimated size of a sample, used for the blocks whose meta.json doesn't have the size of the files.
+	estimatedBytesPerSample = 2
+
+	// Weight of the last observed job in the moving average of the compaction jobs throughput.
+	jobsThroughputSmoothingFactor = 0.3
+)
+
+// JobCost is the estimated cost of a compaction job, computed from the metas of its source blocks.
+type JobCost struct {
+	// Series is the sum of the number of series of the source blocks.
+	Series uint64 `json:"series"`
+	// Bytes is the size of the source blocks, which the job downloads from the object storage. The compacted
+	// blocks uploaded by the job are usually about the same size.
+	Bytes int64 `json:"bytes"`
+}
+
+func (c JobCost) add(o JobCost) JobCost {
+	return JobCost{Series: c.Series + o.Series, Bytes: c.Bytes + o.Bytes}
+}
+
+// EstimatedCost returns the estimated cost of the job.
+func (job *Job) EstimatedCost() JobCost {
+	return estimateBlocksCost(job.metasByMinTime)
+}
+
+func estimateBlocksCost(metas []*block.Meta) JobCost {
+	var cost JobCost
+	for _, meta := range metas {
+		var size int64
+		for _, f := range meta.Thanos.Files {
+			size += f.SizeBytes
+		}
+		if size == 0 {
+			size = int64(meta.Stats.NumSamples) * estimatedBytesPerSample
+		}
+		cost = cost.add(JobCost{Series: meta.Stats.NumSeries, Bytes: size})
+	}
+	return cost
+}
+
+// jobsThroughputEstimator tracks the throughput of the compaction jobs of each tenant, in bytes per second, as the
+// exponentially weighted moving average of the throughput of the jobs.
+type jobsThroughputEstimator struct {
+	mu    sync.Mutex
+	rates map[string]float64
+}
+
+func newJobsThroughputEstimator() *jobsThroughputEstimator {
+	return &jobsThroughputEstimator{rates: map[string]float64{}}
+}
+
+// observe records a successful compaction job of the tenant.
+func (e *jobsThroughputEstimator) observe(userID string, cost JobCost, elapsed time.Duration) {
+	if cost.Bytes <= 0 || elapsed <= 0 {
+		return
+	}
+	rate := float64(cost.Bytes) / elapsed.Seconds()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if prev, ok := e.rates[userID]; ok {
+		rate = jobsThroughputSmoothingFactor*rate + (1-jobsThroughputSmoothingFactor)*prev
+	}
+	e.rates[userID] = rate
+}
+
+// rate returns the throughput of a compaction job of the tenant. The average throughput of the other tenants is
+// returned if no job of the tenant has been observed yet. It returns false if no job has been observed at all.
+func (e *jobsThroughputEstimator) rate(userID string) (float64, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if rate, ok := e.rates[userID]; ok {
+		return rate, true
+	}
+	if len(e.rates) == 0 {
+		return 0, false
+	}
+
+	sum := 0.0
+	for _, rate := range e.rates {
+		sum += rate
+	}
+	return sum / float64(len(e.rates)), true
+}
+
+// catchUpSeconds returns the estimated time to run compaction jobs of the tenant with the given total cost,
+// running the given number of jobs at the same time. It returns false if the time can't be estimated.
+func (e *jobsThroughputEstimator) catchUpSeconds(userID string, pending JobCost, parallelism float64) (float64, bool) {
+	if pending.Bytes <= 0 {
+		return 0, true
+	}
+
+	rate, ok := e.rate(userID)
+	if !ok || rate <= 0 || parallelism <= 0 {
+		return 0, false
+	}
+	return float64(pending.Bytes) / (rate * parallelism), true
+}
+
+// remove forgets the throughput of the tenant.
+func (e *jobsThroughputEstimator) remove(userID string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	delete(e.rates, userID)
+}
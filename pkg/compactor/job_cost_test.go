@@ -0,0 +1,79 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+)
+
+func TestJob_EstimatedCost(t *testing.T) {
+	job := newJob("user-1", "group-1", labels.EmptyLabels(), 0, false, 0, "")
+	assert.Equal(t, JobCost{}, job.EstimatedCost())
+
+	require.NoError(t, job.AppendMeta(&block.Meta{
+		BlockMeta: tsdb.BlockMeta{ULID: ulid.MustNew(1, nil), Stats: tsdb.BlockStats{NumSeries: 10, NumSamples: 1000}},
+		Thanos: block.ThanosMeta{Files: []block.File{
+			{RelPath: block.IndexFilename, SizeBytes: 300},
+			{RelPath: "chunks/000001", SizeBytes: 700},
+			{RelPath: block.MetaFilename},
+		}},
+	}))
+	assert.Equal(t, JobCost{Series: 10, Bytes: 1000}, job.EstimatedCost())
+
+	// The size of blocks without files is estimated from their number of samples.
+	require.NoError(t, job.AppendMeta(&block.Meta{
+		BlockMeta: tsdb.BlockMeta{ULID: ulid.MustNew(2, nil), Stats: tsdb.BlockStats{NumSeries: 5, NumSamples: 100}},
+	}))
+	assert.Equal(t, JobCost{Series: 15, Bytes: 1000 + 100*estimatedBytesPerSample}, job.EstimatedCost())
+}
+
+func TestJobsThroughputEstimator(t *testing.T) {
+	e := newJobsThroughputEstimator()
+
+	// Nothing can be estimated before observing jobs, unless there's nothing pending.
+	_, ok := e.catchUpSeconds("user-1", JobCost{Bytes: 100}, 1)
+	assert.False(t, ok)
+	seconds, ok := e.catchUpSeconds("user-1", JobCost{}, 1)
+	assert.True(t, ok)
+	assert.Equal(t, 0.0, seconds)
+
+	e.observe("user-1", JobCost{Bytes: 1000}, 10*time.Second)
+	rate, ok := e.rate("user-1")
+	require.True(t, ok)
+	assert.Equal(t, 100.0, rate)
+
+	// The throughput is the moving average of the jobs throughput.
+	e.observe("user-1", JobCost{Bytes: 2000}, 10*time.Second)
+	rate, ok = e.rate("user-1")
+	require.True(t, ok)
+	assert.InDelta(t, 0.3*200+0.7*100, rate, 0.001)
+
+	// Jobs with an unknown cost are ignored.
+	e.observe("user-1", JobCost{}, 10*time.Second)
+	rate, _ = e.rate("user-1")
+	assert.InDelta(t, 130, rate, 0.001)
+
+	seconds, ok = e.catchUpSeconds("user-1", JobCost{Bytes: 1300}, 2)
+	require.True(t, ok)
+	assert.InDelta(t, 5, seconds, 0.001)
+
+	// Tenants without observed jobs use the average throughput of the other tenants.
+	e.observe("user-2", JobCost{Bytes: 700}, 10*time.Second)
+	rate, ok = e.rate("user-3")
+	require.True(t, ok)
+	assert.InDelta(t, 100, rate, 0.001)
+
+	e.remove("user-1")
+	e.remove("user-2")
+	_, ok = e.rate("user-3")
+	assert.False(t, ok)
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"html/template"
 	"net/http"
+	"slices"
 	"strconv"
 	"time"
 
@@ -17,6 +18,7 @@ import (
 
 	"github.com/grafana/mimir/pkg/storage/bucket"
 	"github.com/grafana/mimir/pkg/storage/tsdb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
 	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
 	"github.com/grafana/mimir/pkg/util"
 )
@@ -28,6 +30,17 @@ var tenantsTemplate = template.Must(template.New("webpage").Parse(tenantsPageHTM
 type tenantsPageContents struct {
 	Now     time.Time `json:"now"`
 	Tenants []string  `json:"tenants,omitempty"`
+
+	// SeriesRetention is the progress of the series retention policies, for the tenants having them.
+	SeriesRetention map[string]*tenantSeriesRetention `json:"series_retention,omitempty"`
+}
+
+type tenantSeriesRetention struct {
+	Policies        int    `json:"policies"`
+	LastCheckTime   string `json:"last_check_time,omitempty"`
+	PendingBlocks   int    `json:"pending_blocks"`
+	RewrittenBlocks int    `json:"rewritten_blocks"`
+	Error           string `json:"error,omitempty"`
 }
 
 func (c *MultitenantCompactor) TenantsHandler(w http.ResponseWriter, req *http.Request) {
@@ -37,9 +50,31 @@ func (c *MultitenantCompactor) TenantsHandler(w http.ResponseWriter, req *http.R
 		return
 	}
 
+	seriesRetention := map[string]*tenantSeriesRetention{}
+	for _, tenantID := range tenants {
+		policies := c.cfgProvider.CompactorSeriesRetentionPolicies(tenantID)
+		if !policies.Enabled() {
+			continue
+		}
+
+		tsr := &tenantSeriesRetention{Policies: len(policies.Policies)}
+		status, err := ReadSeriesRetentionStatus(req.Context(), bucket.NewUserBucketClient(tenantID, c.bucketClient, c.cfgProvider))
+		if err != nil {
+			tsr.Error = err.Error()
+		} else {
+			tsr.PendingBlocks = status.PendingBlocks
+			tsr.RewrittenBlocks = status.RewrittenBlocks
+			if status.LastCheckTime > 0 {
+				tsr.LastCheckTime = formatTime(status.LastCheckTime.Time())
+			}
+		}
+		seriesRetention[tenantID] = tsr
+	}
+
 	util.RenderHTTPResponse(w, tenantsPageContents{
-		Now:     time.Now(),
-		Tenants: tenants,
+		Now:             time.Now(),
+		Tenants:         tenants,
+		SeriesRetention: seriesRetention,
 	}, tenantsTemplate, req)
 }
 
@@ -58,6 +93,10 @@ type plannerJobsContent struct {
 	ShowBlocks     bool `json:"-"`
 	ShowCompactors bool `json:"-"`
 
+	// ShowScheduler is true when the compaction jobs are leased to the compactors by the compactor-scheduler.
+	ShowScheduler  bool   `json:"-"`
+	SchedulerError string `json:"scheduler_error,omitempty"`
+
 	SplitJobsCount int `json:"split_jobs_count"`
 	MergeJobsCount int `json:"merge_jobs_count"`
 
@@ -73,6 +112,12 @@ type plannedCompactionJob struct {
 	MaxTime   string      `json:"max_time"`
 	Blocks    []ulid.ULID `json:"blocks"`
 	Compactor string      `json:"compactor,omitempty"`
+
+	// State of the job in the compactor-scheduler queue, if configured.
+	State       string `json:"state,omitempty"`
+	Assignee    string `json:"assignee,omitempty"`
+	LeaseExpiry string `json:"lease_expiry,omitempty"`
+	FailCount   int    `json:"fail_count,omitempty"`
 }
 
 func (c *MultitenantCompactor) PlannedJobsHandler(w http.ResponseWriter, req *http.Request) {
@@ -117,7 +162,7 @@ func (c *MultitenantCompactor) PlannedJobsHandler(w http.ResponseWriter, req *ht
 		return
 	}
 
-	jobs, err := estimateCompactionJobsFromBucketIndex(req.Context(), tenantID, bucket.NewUserBucketClient(tenantID, c.bucketClient, c.cfgProvider), idx, c.compactorCfg.BlockRanges, mergeShards, splitGroups)
+	jobs, err := estimateCompactionJobsFromBucketIndex(req.Context(), tenantID, bucket.NewUserBucketClient(tenantID, c.bucketClient, c.cfgProvider), idx, tenantBlockRanges(c.compactorCfg.BlockRanges, c.cfgProvider, tenantID), mergeShards, splitGroups)
 	if err != nil {
 		level.Error(c.logger).Log("msg", "failed to compute compaction jobs from bucket index for tenant while listing compaction jobs", "user", tenantID, "err", err)
 		util.WriteTextResponse(w, "Failed to compute compaction jobs from bucket index")
@@ -126,6 +171,22 @@ func (c *MultitenantCompactor) PlannedJobsHandler(w http.ResponseWriter, req *ht
 
 	jobs = c.jobsOrder(jobs)
 
+	var (
+		scheduledJobs  map[string]schedulerJobStatus
+		schedulerError string
+	)
+	if c.schedulerClient != nil {
+		scheduled, err := c.schedulerClient.listJobs(req.Context(), tenantID)
+		if err != nil {
+			schedulerError = err.Error()
+		}
+
+		scheduledJobs = make(map[string]schedulerJobStatus, len(scheduled))
+		for _, j := range scheduled {
+			scheduledJobs[j.Spec.Key] = j
+		}
+	}
+
 	plannedJobs := make([]plannedCompactionJob, 0, len(jobs))
 
 	splitJobs, mergeJobs := 0, 0
@@ -144,6 +205,19 @@ func (c *MultitenantCompactor) PlannedJobsHandler(w http.ResponseWriter, req *ht
 			mergeJobs++
 		}
 
+		if scheduledJobs != nil {
+			// Jobs not in the scheduler queue haven't been planned by the scheduler yet, or have been completed already.
+			pj.State = "not scheduled"
+			if sj, ok := scheduledJobs[j.Key()]; ok {
+				pj.State = sj.State
+				pj.Assignee = sj.Assignee
+				pj.FailCount = sj.FailCount
+				if !sj.LeaseExpiry.IsZero() {
+					pj.LeaseExpiry = formatTime(sj.LeaseExpiry)
+				}
+			}
+		}
+
 		if showCompactors {
 			inst, err := c.shardingStrategy.instanceOwningJob(j)
 			if err != nil {
@@ -164,6 +238,8 @@ func (c *MultitenantCompactor) PlannedJobsHandler(w http.ResponseWriter, req *ht
 
 		ShowBlocks:     showBlocks,
 		ShowCompactors: showCompactors,
+		ShowScheduler:  c.schedulerClient != nil,
+		SchedulerError: schedulerError,
 
 		TenantSplitGroups: tenantSplitGroups,
 		TenantMergeShards: tenantMergeShards,
@@ -175,6 +251,64 @@ func (c *MultitenantCompactor) PlannedJobsHandler(w http.ResponseWriter, req *ht
 	}, plannerJobsTemplate, req)
 }
 
+//go:embed quarantined_blocks.gohtml
+var quarantinedBlocksHTML string
+var quarantinedBlocksTemplate = template.Must(template.New("webpage").Parse(quarantinedBlocksHTML))
+
+type quarantinedBlocksContent struct {
+	Now    string             `json:"now"`
+	Tenant string             `json:"tenant"`
+	Blocks []quarantinedBlock `json:"blocks"`
+}
+
+type quarantinedBlock struct {
+	ID             ulid.ULID `json:"id"`
+	QuarantineTime string    `json:"quarantine_time,omitempty"`
+	Details        string    `json:"details,omitempty"`
+}
+
+func (c *MultitenantCompactor) QuarantinedBlocksHandler(w http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	tenantID := vars["tenant"]
+	if tenantID == "" {
+		util.WriteTextResponse(w, "Tenant ID can't be empty")
+		return
+	}
+
+	userBucket := bucket.NewUserBucketClient(tenantID, c.bucketClient, c.cfgProvider)
+	marks, err := block.ListBlockQuarantineMarks(req.Context(), userBucket)
+	if err != nil {
+		level.Error(c.logger).Log("msg", "failed to list quarantine marks for tenant", "user", tenantID, "err", err)
+		util.WriteTextResponse(w, "Failed to list quarantine marks for tenant")
+		return
+	}
+
+	blocks := make([]quarantinedBlock, 0, len(marks))
+	for id := range marks {
+		qb := quarantinedBlock{ID: id}
+
+		// The details are best effort: the block is listed even if its quarantine mark can't be read.
+		mark := block.QuarantineMark{}
+		if err := block.ReadMarker(req.Context(), c.logger, userBucket, id.String(), &mark); err != nil {
+			qb.Details = fmt.Sprintf("failed to read quarantine mark: %s", err)
+		} else {
+			qb.QuarantineTime = formatTime(time.Unix(mark.QuarantineTime, 0))
+			qb.Details = mark.Details
+		}
+
+		blocks = append(blocks, qb)
+	}
+	slices.SortFunc(blocks, func(a, b quarantinedBlock) int {
+		return a.ID.Compare(b.ID)
+	})
+
+	util.RenderHTTPResponse(w, quarantinedBlocksContent{
+		Now:    formatTime(time.Now()),
+		Tenant: tenantID,
+		Blocks: blocks,
+	}, quarantinedBlocksTemplate, req)
+}
+
 func formatTime(t time.Time) string {
 	return t.UTC().Format(time.RFC3339)
 }
@@ -62,6 +62,8 @@ func TestPlannedJobsHandler(t *testing.T) {
 	userBucket := bucket.NewUserBucketClient(user, bucketClient, nil)
 	// Mark block for no-compaction.
 	require.NoError(t, block.MarkForNoCompact(context.Background(), log.NewNopLogger(), userBucket, blockMarkedForNoCompact, block.CriticalNoCompactReason, "testing", promauto.With(nil).NewCounter(prometheus.CounterOpts{})))
+	// Quarantine a block.
+	require.NoError(t, block.MarkForQuarantine(context.Background(), log.NewNopLogger(), userBucket, blockMarkedForNoCompact, "corrupted chunk", promauto.With(nil).NewCounter(prometheus.CounterOpts{})))
 
 	headersWithJSONAccept := http.Header{}
 	headersWithJSONAccept.Set("Accept", "application/json")
@@ -115,4 +117,21 @@ func TestPlannedJobsHandler(t *testing.T) {
 		require.Contains(t, resp.Body.String(), `"key":"0@17241709254077376921-merge-1_of_3-86400000-172800000"`)
 		require.Contains(t, resp.Body.String(), `"key":"0@17241709254077376921-merge-2_of_3-86400000-172800000"`)
 	})
+	t.Run("quarantined blocks html", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		c.QuarantinedBlocksHandler(resp, mux.SetURLVars(&http.Request{}, map[string]string{"tenant": user}))
+
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.Contains(t, resp.Body.String(), "<td>"+blockMarkedForNoCompact.String()+"</td>")
+		require.Contains(t, resp.Body.String(), "<td>corrupted chunk</td>")
+	})
+
+	t.Run("quarantined blocks json", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		c.QuarantinedBlocksHandler(resp, mux.SetURLVars(&http.Request{Header: headersWithJSONAccept}, map[string]string{"tenant": user}))
+
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.Contains(t, resp.Body.String(), `"id":"`+blockMarkedForNoCompact.String()+`"`)
+		require.Contains(t, resp.Body.String(), `"details":"corrupted chunk"`)
+	})
 }
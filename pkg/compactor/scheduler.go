@@ -0,0 +1,402 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/concurrency"
+	"github.com/grafana/dskit/grpcclient"
+	"github.com/grafana/dskit/services"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/compactor/compactorschedulerpb"
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/util"
+	util_log "github.com/grafana/mimir/pkg/util/log"
+)
+
+var (
+	errInvalidSchedulerSchedulingInterval  = errors.New("invalid compactor scheduler scheduling interval, must be positive")
+	errInvalidSchedulerJobLeaseDuration    = errors.New("invalid compactor scheduler job lease duration, must be positive")
+	errInvalidSchedulerPlanningConcurrency = errors.New("invalid compactor scheduler planning concurrency, must be positive")
+)
+
+// SchedulerConfig configures the compactor scheduler, and the compactors running the jobs leased from it.
+type SchedulerConfig struct {
+	Address             string            `yaml:"address" category:"experimental"`
+	GRPCClientConfig    grpcclient.Config `yaml:"grpc_client_config" doc:"description=Configures the gRPC client used to communicate between the compactors and the compactor-scheduler." category:"experimental"`
+	SchedulingInterval  time.Duration     `yaml:"scheduling_interval" category:"experimental"`
+	JobLeaseDuration    time.Duration     `yaml:"job_lease_duration" category:"experimental"`
+	PlanningConcurrency int               `yaml:"planning_concurrency" category:"experimental"`
+
+	// How long the compactors wait before asking the scheduler for a job again, when none is available.
+	pollInterval time.Duration `yaml:"-"`
+}
+
+func (cfg *SchedulerConfig) RegisterFlags(f *flag.FlagSet) {
+	cfg.pollInterval = 10 * time.Second
+
+	f.StringVar(&cfg.Address, "compactor.scheduler.address", "", "gRPC address of the compactor-scheduler, in the host:port format. When set, the compaction jobs are planned by the compactor-scheduler and the compactors run the jobs leased from it, instead of planning and running the jobs of the tenants they own through the ring. Blocks cleanup and maintenance are still sharded through the ring.")
+	f.DurationVar(&cfg.SchedulingInterval, "compactor.scheduler.scheduling-interval", time.Minute, "How frequently the compactor-scheduler plans the compaction jobs of all tenants.")
+	f.DurationVar(&cfg.JobLeaseDuration, "compactor.scheduler.job-lease-duration", 5*time.Minute, "How long a compaction job is leased to a compactor. The compactor renews the lease while running the job. The job is reassigned to another compactor if the lease expires. After a restart, the compactor-scheduler waits for this period before leasing jobs, so that the compactors running jobs can recover their leases.")
+	f.IntVar(&cfg.PlanningConcurrency, "compactor.scheduler.planning-concurrency", 20, "Max number of tenants for which the compactor-scheduler plans the compaction jobs concurrently.")
+	cfg.GRPCClientConfig.RegisterFlagsWithPrefix("compactor.scheduler.grpc-client-config", f)
+}
+
+func (cfg *SchedulerConfig) Validate() error {
+	if cfg.SchedulingInterval <= 0 {
+		return errInvalidSchedulerSchedulingInterval
+	}
+	if cfg.JobLeaseDuration <= 0 {
+		return errInvalidSchedulerJobLeaseDuration
+	}
+	if cfg.PlanningConcurrency <= 0 {
+		return errInvalidSchedulerPlanningConcurrency
+	}
+	return cfg.GRPCClientConfig.Validate()
+}
+
+// CompactorScheduler plans the compaction jobs of all tenants and leases them to the compactors,
+// so that the jobs of a tenant are spread across all compactors.
+type CompactorScheduler struct {
+	services.Service
+
+	cfg          Config
+	cfgProvider  ConfigProvider
+	logger       log.Logger
+	bucketClient objstore.Bucket
+
+	bucketClientFactory  func(ctx context.Context) (objstore.Bucket, error)
+	blocksGrouperFactory BlocksGrouperFactory
+
+	allowedTenants *util.AllowedTenants
+	jobsOrder      JobsOrderFunc
+	jobs           *schedulerJobQueue
+
+	// No job is leased until this time after startup, so that the compactors running jobs leased
+	// before a restart can recover their leases first.
+	assignJobsAfter time.Time
+
+	// Metrics.
+	updateScheduleDuration  prometheus.Histogram
+	tenantPlanningFailures  prometheus.Counter
+	jobsCount               *prometheus.GaugeVec
+	tenantCatchUpSeconds    *prometheus.GaugeVec
+	blocksMarkedForDeletion prometheus.Counter
+}
+
+// NewCompactorScheduler makes a new CompactorScheduler.
+func NewCompactorScheduler(cfg Config, storageCfg mimir_tsdb.BlocksStorageConfig, cfgProvider ConfigProvider, logger log.Logger, registerer prometheus.Registerer) (*CompactorScheduler, error) {
+	bucketClientFactory := func(ctx context.Context) (objstore.Bucket, error) {
+		return bucket.NewClient(ctx, storageCfg.Bucket, "compactor-scheduler", logger, registerer)
+	}
+
+	// Configure the grouper factory only if it wasn't already set by a downstream project.
+	if cfg.BlocksGrouperFactory == nil || cfg.BlocksCompactorFactory == nil {
+		configureSplitAndMergeCompactor(&cfg)
+	}
+
+	return newCompactorScheduler(cfg, cfgProvider, logger, registerer, bucketClientFactory, cfg.BlocksGrouperFactory)
+}
+
+func newCompactorScheduler(
+	cfg Config,
+	cfgProvider ConfigProvider,
+	logger log.Logger,
+	registerer prometheus.Registerer,
+	bucketClientFactory func(ctx context.Context) (objstore.Bucket, error),
+	blocksGrouperFactory BlocksGrouperFactory,
+) (*CompactorScheduler, error) {
+	s := &CompactorScheduler{
+		cfg:                  cfg,
+		cfgProvider:          cfgProvider,
+		logger:               log.With(logger, "component", "compactor-scheduler"),
+		bucketClientFactory:  bucketClientFactory,
+		blocksGrouperFactory: blocksGrouperFactory,
+		allowedTenants:       util.NewAllowedTenants(cfg.EnabledTenants, cfg.DisabledTenants),
+		jobsOrder:            GetJobsOrderFunction(cfg.CompactionJobsOrder),
+		jobs:                 newSchedulerJobQueue(cfg.Scheduler.JobLeaseDuration, cfgProvider.CompactorSchedulingWeight),
+
+		updateScheduleDuration: promauto.With(registerer).NewHistogram(prometheus.HistogramOpts{
+			Name: "cortex_compactor_scheduler_schedule_update_seconds",
+			Help: "Time spent planning the compaction jobs of all tenants.",
+
+			NativeHistogramBucketFactor: 1.1,
+		}),
+		tenantPlanningFailures: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_scheduler_tenant_planning_failures_total",
+			Help: "Total number of failures planning the compaction jobs of a tenant.",
+		}),
+		jobsCount: promauto.With(registerer).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_compactor_scheduler_jobs",
+			Help: "Number of compaction jobs in the scheduler queue, as of the last schedule update.",
+		}, []string{"state"}),
+		tenantCatchUpSeconds: promauto.With(registerer).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_compactor_scheduler_tenant_estimated_catch_up_seconds",
+			Help: "Estimated time to run the compaction jobs of the tenant in the scheduler queue, based on the estimated cost of the jobs and the observed compaction throughput, as of the last schedule update.",
+		}, []string{"user"}),
+		blocksMarkedForDeletion: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name:        blocksMarkedForDeletionName,
+			Help:        blocksMarkedForDeletionHelp,
+			ConstLabels: prometheus.Labels{"reason": "compaction"},
+		}),
+	}
+
+	if s.jobsOrder == nil {
+		return nil, errInvalidCompactionOrder
+	}
+
+	s.Service = services.NewBasicService(s.starting, s.running, nil)
+	return s, nil
+}
+
+func (s *CompactorScheduler) starting(ctx context.Context) error {
+	bkt, err := s.bucketClientFactory(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to create bucket client")
+	}
+
+	// Wrap the bucket client to write block deletion marks in the global location too.
+	s.bucketClient = block.BucketWithGlobalMarkers(bkt)
+	s.assignJobsAfter = time.Now().Add(s.cfg.Scheduler.JobLeaseDuration)
+	return nil
+}
+
+func (s *CompactorScheduler) running(ctx context.Context) error {
+	s.updateSchedule(ctx)
+
+	updateTick := time.NewTicker(s.cfg.Scheduler.SchedulingInterval)
+	defer updateTick.Stop()
+	for {
+		select {
+		case <-updateTick.C:
+			s.jobs.clearExpiredLeases()
+			s.updateSchedule(ctx)
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// updateSchedule plans the compaction jobs of all tenants, adding the new jobs to the queue and removing
+// the pending jobs which aren't planned anymore.
+func (s *CompactorScheduler) updateSchedule(ctx context.Context) {
+	startTime := time.Now()
+	defer func() {
+		s.updateScheduleDuration.Observe(time.Since(startTime).Seconds())
+	}()
+
+	tenants, err := mimir_tsdb.ListUsers(ctx, s.bucketClient)
+	if err != nil {
+		level.Warn(s.logger).Log("msg", "failed to discover tenants from bucket", "err", err)
+		return
+	}
+
+	var (
+		plannedMx sync.Mutex
+		planned   = map[string]struct{}{}
+	)
+	_ = concurrency.ForEachUser(ctx, tenants, s.cfg.Scheduler.PlanningConcurrency, func(ctx context.Context, userID string) error {
+		if !s.allowedTenants.IsAllowed(userID) {
+			return nil
+		}
+
+		if markedForDeletion, err := mimir_tsdb.TenantDeletionMarkExists(ctx, s.bucketClient, userID); err != nil {
+			level.Warn(s.logger).Log("msg", "unable to check if tenant is marked for deletion", "user", userID, "err", err)
+			return nil
+		} else if markedForDeletion {
+			return nil
+		}
+
+		jobs, err := s.planTenantJobs(ctx, userID)
+		if err != nil {
+			// Keep the jobs of the tenant which have been planned previously.
+			s.tenantPlanningFailures.Inc()
+			level.Warn(s.logger).Log("msg", "failed to plan compaction jobs for tenant", "user", userID, "err", err)
+		} else {
+			plannedJobs := make(map[string]struct{}, len(jobs))
+			for order, job := range jobs {
+				spec := newSchedulerJobSpec(job, order)
+				s.jobs.addOrUpdate(spec)
+				plannedJobs[spec.id()] = struct{}{}
+			}
+			s.jobs.removeStale(userID, plannedJobs)
+		}
+
+		plannedMx.Lock()
+		planned[userID] = struct{}{}
+		plannedMx.Unlock()
+		return nil
+	})
+	if ctx.Err() != nil {
+		return
+	}
+
+	// Remove the pending jobs of the tenants which aren't compacted anymore.
+	for userID := range s.jobs.tenants() {
+		if _, ok := planned[userID]; !ok {
+			s.jobs.removeStale(userID, nil)
+		}
+	}
+
+	pending, assigned := 0, 0
+	for _, j := range s.jobs.list("") {
+		if j.State == schedulerJobStateAssigned {
+			assigned++
+		} else {
+			pending++
+		}
+	}
+	s.jobsCount.WithLabelValues(schedulerJobStatePending).Set(float64(pending))
+	s.jobsCount.WithLabelValues(schedulerJobStateAssigned).Set(float64(assigned))
+
+	s.tenantCatchUpSeconds.Reset()
+	for userID, seconds := range s.jobs.catchUpEstimates() {
+		s.tenantCatchUpSeconds.WithLabelValues(userID).Set(seconds)
+	}
+}
+
+// planTenantJobs returns the compaction jobs of the tenant which can run now, sorted by the configured jobs order.
+func (s *CompactorScheduler) planTenantJobs(ctx context.Context, userID string) ([]*Job, error) {
+	userBucket := bucket.NewUserBucketClient(userID, s.bucketClient, s.cfgProvider)
+	userLogger := util_log.WithUserID(userID, s.logger)
+	reg := prometheus.NewRegistry()
+
+	// The same filters as the compactors planning the jobs themselves.
+	deduplicateBlocksFilter := NewShardAwareDeduplicateFilter()
+	fetcherFilters := []block.MetadataFilter{
+		NewLabelRemoverFilter(compactionIgnoredLabels),
+		deduplicateBlocksFilter,
+		NewNoCompactionMarkFilter(userBucket),
+		newColdStorageMarkFilter(userBucket),
+		newQuarantineMarkFilter(userBucket),
+		excludeDownsampledBlocksFilter{},
+	}
+
+	fetcher, err := block.NewMetaFetcher(userLogger, s.cfg.MetaSyncConcurrency, userBucket, "", reg, fetcherFilters, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	syncer, err := newMetaSyncer(userLogger, reg, userBucket, fetcher, deduplicateBlocksFilter, s.blocksMarkedForDeletion)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create syncer")
+	}
+	if err := syncer.SyncMetas(ctx); err != nil {
+		return nil, errors.Wrap(err, "sync")
+	}
+
+	// The compactors don't garbage collect the blocks anymore, because they only run the jobs.
+	if err := syncer.GarbageCollect(ctx); err != nil {
+		return nil, errors.Wrap(err, "blocks garbage collect")
+	}
+
+	grouper := s.blocksGrouperFactory(ctx, s.cfg, s.cfgProvider, userID, userLogger, reg)
+	jobs, err := grouper.Groups(syncer.Metas())
+	if err != nil {
+		return nil, errors.Wrap(err, "build compaction jobs")
+	}
+
+	// Skip the jobs with nothing to compact, and the ones for which the wait period hasn't been honored yet.
+	ranges := tenantBlockRanges(s.cfg.BlockRanges, s.cfgProvider, userID)
+	planner := NewSplitAndMergePlanner(ranges.ToMilliseconds())
+	runnable := jobs[:0]
+	for _, job := range jobs {
+		if toCompact, err := planner.Plan(ctx, job.Metas()); err != nil {
+			return nil, errors.Wrapf(err, "plan compaction job %s", job.Key())
+		} else if len(toCompact) == 0 {
+			continue
+		}
+
+		if elapsed, _, err := jobWaitPeriodElapsed(ctx, job, s.cfg.CompactionWaitPeriod, userBucket); err != nil {
+			level.Warn(userLogger).Log("msg", "not enforcing compaction wait period because the check if compaction job contains recently uploaded blocks has failed", "groupKey", job.Key(), "err", err)
+		} else if !elapsed {
+			continue
+		}
+		runnable = append(runnable, job)
+	}
+
+	return s.jobsOrder(runnable), nil
+}
+
+// AssignJob implements compactorschedulerpb.CompactorSchedulerServer.
+func (s *CompactorScheduler) AssignJob(_ context.Context, req *compactorschedulerpb.AssignJobRequest) (*compactorschedulerpb.AssignJobResponse, error) {
+	if time.Now().Before(s.assignJobsAfter) {
+		return nil, toSchedulerError(errNoJobAvailable)
+	}
+
+	id, spec, err := s.jobs.assign(req.WorkerId)
+	if err != nil {
+		return nil, toSchedulerError(err)
+	}
+
+	level.Debug(s.logger).Log("msg", "assigned compaction job", "job", id, "worker", req.WorkerId)
+	return &compactorschedulerpb.AssignJobResponse{
+		JobId:         id,
+		Spec:          spec.toProto(),
+		LeaseDuration: s.cfg.Scheduler.JobLeaseDuration,
+	}, nil
+}
+
+// UpdateJob implements compactorschedulerpb.CompactorSchedulerServer.
+func (s *CompactorScheduler) UpdateJob(_ context.Context, req *compactorschedulerpb.UpdateJobRequest) (*compactorschedulerpb.UpdateJobResponse, error) {
+	var err error
+	switch req.Status {
+	case compactorschedulerpb.IN_PROGRESS:
+		var spec schedulerJobSpec
+		if spec, err = schedulerJobSpecFromProto(req.Spec); err == nil {
+			err = s.jobs.renewLease(req.JobId, req.WorkerId, spec)
+		}
+	case compactorschedulerpb.COMPLETE:
+		err = s.jobs.completeJob(req.JobId, req.WorkerId)
+		if errors.Is(err, errJobNotFound) {
+			// The job may have been planned before a restart, and not planned again because it's complete.
+			err = nil
+		}
+	case compactorschedulerpb.FAILED:
+		err = s.jobs.failJob(req.JobId, req.WorkerId)
+		level.Warn(s.logger).Log("msg", "compaction job failed", "job", req.JobId, "worker", req.WorkerId)
+	default:
+		err = fmt.Errorf("unknown job status: %s", req.Status)
+	}
+	if err != nil {
+		return nil, toSchedulerError(err)
+	}
+	return &compactorschedulerpb.UpdateJobResponse{}, nil
+}
+
+// ListJobs implements compactorschedulerpb.CompactorSchedulerServer.
+func (s *CompactorScheduler) ListJobs(_ context.Context, req *compactorschedulerpb.ListJobsRequest) (*compactorschedulerpb.ListJobsResponse, error) {
+	jobs := s.jobs.list(req.Tenant)
+
+	resp := &compactorschedulerpb.ListJobsResponse{Jobs: make([]compactorschedulerpb.JobState, 0, len(jobs))}
+	for _, j := range jobs {
+		resp.Jobs = append(resp.Jobs, compactorschedulerpb.JobState{
+			JobId:       j.ID,
+			Spec:        j.Spec.toProto(),
+			State:       j.State,
+			Assignee:    j.Assignee,
+			LeaseExpiry: j.LeaseExpiry,
+			FailCount:   int64(j.FailCount),
+		})
+	}
+	return resp, nil
+}
+
+// ListJobsHandler returns the compaction jobs in the queue, optionally filtered by the "tenant" form value.
+func (s *CompactorScheduler) ListJobsHandler(w http.ResponseWriter, req *http.Request) {
+	util.WriteJSONResponse(w, s.jobs.list(req.FormValue("tenant")))
+}
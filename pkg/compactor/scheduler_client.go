@@ -0,0 +1,139 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"context"
+
+	"github.com/grafana/dskit/grpcclient"
+	"github.com/grafana/dskit/user"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/grafana/mimir/pkg/compactor/compactorschedulerpb"
+)
+
+// schedulerClient is the client used by the compactors to lease the compaction jobs from the compactor-scheduler.
+type schedulerClient struct {
+	client compactorschedulerpb.CompactorSchedulerClient
+	conn   *grpc.ClientConn
+}
+
+func newSchedulerClient(cfg SchedulerConfig, reg prometheus.Registerer) (*schedulerClient, error) {
+	requestDuration := promauto.With(reg).NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "cortex_compactor_scheduler_client_request_duration_seconds",
+		Help:    "Time spent executing requests to the compactor-scheduler.",
+		Buckets: prometheus.ExponentialBuckets(0.008, 4, 7),
+	}, []string{"operation", "status_code"})
+
+	opts, err := cfg.GRPCClientConfig.DialOption(grpcclient.Instrument(requestDuration))
+	if err != nil {
+		return nil, err
+	}
+
+	// nolint:staticcheck // grpc.Dial() has been deprecated; we'll address it before upgrading to gRPC 2.
+	conn, err := grpc.Dial(cfg.Address, opts...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to dial compactor-scheduler %s", cfg.Address)
+	}
+
+	return &schedulerClient{
+		client: compactorschedulerpb.NewCompactorSchedulerClient(conn),
+		conn:   conn,
+	}, nil
+}
+
+// assignJob leases the next compaction job to the worker. It returns errNoJobAvailable if there's no job to run.
+func (c *schedulerClient) assignJob(ctx context.Context, workerID string) (*compactorschedulerpb.AssignJobResponse, error) {
+	job, err := c.client.AssignJob(withFakeTenant(ctx), &compactorschedulerpb.AssignJobRequest{WorkerId: workerID})
+	if err != nil {
+		return nil, fromSchedulerError(err)
+	}
+	return job, nil
+}
+
+// updateJob renews the lease of the job assigned to the worker, or completes it, depending on the status.
+// It returns errJobNotFound or errJobNotAssigned if the job isn't leased to the worker anymore.
+func (c *schedulerClient) updateJob(ctx context.Context, workerID string, job *compactorschedulerpb.AssignJobResponse, status compactorschedulerpb.JobStatus) error {
+	_, err := c.client.UpdateJob(withFakeTenant(ctx), &compactorschedulerpb.UpdateJobRequest{
+		JobId:    job.JobId,
+		WorkerId: workerID,
+		Status:   status,
+		Spec:     job.Spec,
+	})
+	return fromSchedulerError(err)
+}
+
+// listJobs returns the compaction jobs of the tenant in the scheduler queue.
+func (c *schedulerClient) listJobs(ctx context.Context, tenant string) ([]schedulerJobStatus, error) {
+	resp, err := c.client.ListJobs(withFakeTenant(ctx), &compactorschedulerpb.ListJobsRequest{Tenant: tenant})
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make([]schedulerJobStatus, 0, len(resp.Jobs))
+	for _, j := range resp.Jobs {
+		spec, err := schedulerJobSpecFromProto(j.Spec)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, schedulerJobStatus{
+			ID:          j.JobId,
+			Spec:        spec,
+			State:       j.State,
+			Assignee:    j.Assignee,
+			LeaseExpiry: j.LeaseExpiry,
+			FailCount:   int(j.FailCount),
+		})
+	}
+	return jobs, nil
+}
+
+func (c *schedulerClient) close() error {
+	return c.conn.Close()
+}
+
+// withFakeTenant injects a fake tenant, because the compactor-scheduler gRPC endpoints don't need it, but
+// the client-side gRPC instrumentation fails without it.
+func withFakeTenant(ctx context.Context) context.Context {
+	return user.InjectOrgID(ctx, "")
+}
+
+// toSchedulerError converts the errors of the job queue to the gRPC errors returned by the compactor-scheduler.
+func toSchedulerError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, errNoJobAvailable), errors.Is(err, errJobNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, errJobNotAssigned):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+}
+
+// fromSchedulerError converts the gRPC errors returned by the compactor-scheduler back to the errors of the job queue.
+func fromSchedulerError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	s, ok := status.FromError(err)
+	if !ok {
+		return errors.Wrap(err, "compactor-scheduler request failed")
+	}
+	switch {
+	case s.Code() == codes.NotFound && s.Message() == errNoJobAvailable.Error():
+		return errNoJobAvailable
+	case s.Code() == codes.NotFound:
+		return errJobNotFound
+	case s.Code() == codes.FailedPrecondition:
+		return errJobNotAssigned
+	}
+	return errors.Wrap(err, "compactor-scheduler request failed")
+}
@@ -0,0 +1,560 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/oklog/ulid"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/mimir/pkg/compactor/compactorschedulerpb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+)
+
+var (
+	errNoJobAvailable = errors.New("no job available")
+	errJobNotFound    = errors.New("job not found")
+	errJobNotAssigned = errors.New("job not assigned to worker")
+)
+
+const (
+	schedulerJobStatePending  = "pending"
+	schedulerJobStateAssigned = "assigned"
+)
+
+// schedulerJobSpec is the compaction job leased by the scheduler to the workers. It holds
+// everything a worker needs to run the job without planning the tenant's compaction.
+type schedulerJobSpec struct {
+	Tenant         string            `json:"tenant"`
+	Key            string            `json:"key"`
+	Labels         map[string]string `json:"labels,omitempty"`
+	Resolution     int64             `json:"resolution"`
+	UseSplitting   bool              `json:"use_splitting"`
+	SplitNumShards uint32            `json:"split_num_shards,omitempty"`
+	ShardingKey    string            `json:"sharding_key"`
+	Blocks         []ulid.ULID       `json:"blocks"`
+	MinTime        int64             `json:"min_time"`
+	MaxTime        int64             `json:"max_time"`
+	Cost           JobCost           `json:"cost"`
+
+	// Order of the job among the jobs of the tenant, as sorted by the configured jobs order.
+	Order int `json:"order"`
+}
+
+func newSchedulerJobSpec(job *Job, order int) schedulerJobSpec {
+	return schedulerJobSpec{
+		Tenant:         job.UserID(),
+		Key:            job.Key(),
+		Labels:         job.Labels().Map(),
+		Resolution:     job.Resolution(),
+		UseSplitting:   job.UseSplitting(),
+		SplitNumShards: job.SplittingShards(),
+		ShardingKey:    job.ShardingKey(),
+		Blocks:         job.IDs(),
+		MinTime:        job.MinTime(),
+		MaxTime:        job.MaxTime(),
+		Cost:           job.EstimatedCost(),
+		Order:          order,
+	}
+}
+
+func (s *schedulerJobSpec) toProto() compactorschedulerpb.JobSpec {
+	blocks := make([]string, 0, len(s.Blocks))
+	for _, id := range s.Blocks {
+		blocks = append(blocks, id.String())
+	}
+
+	return compactorschedulerpb.JobSpec{
+		Tenant:         s.Tenant,
+		Key:            s.Key,
+		Labels:         s.Labels,
+		Resolution:     s.Resolution,
+		UseSplitting:   s.UseSplitting,
+		SplitNumShards: s.SplitNumShards,
+		ShardingKey:    s.ShardingKey,
+		Blocks:         blocks,
+		MinTime:        s.MinTime,
+		MaxTime:        s.MaxTime,
+		CostSeries:     s.Cost.Series,
+		CostBytes:      s.Cost.Bytes,
+		Order:          int64(s.Order),
+	}
+}
+
+func schedulerJobSpecFromProto(pb compactorschedulerpb.JobSpec) (schedulerJobSpec, error) {
+	blocks := make([]ulid.ULID, 0, len(pb.Blocks))
+	for _, b := range pb.Blocks {
+		id, err := ulid.Parse(b)
+		if err != nil {
+			return schedulerJobSpec{}, fmt.Errorf("invalid block ID %q: %w", b, err)
+		}
+		blocks = append(blocks, id)
+	}
+
+	return schedulerJobSpec{
+		Tenant:         pb.Tenant,
+		Key:            pb.Key,
+		Labels:         pb.Labels,
+		Resolution:     pb.Resolution,
+		UseSplitting:   pb.UseSplitting,
+		SplitNumShards: pb.SplitNumShards,
+		ShardingKey:    pb.ShardingKey,
+		Blocks:         blocks,
+		MinTime:        pb.MinTime,
+		MaxTime:        pb.MaxTime,
+		Cost:           JobCost{Series: pb.CostSeries, Bytes: pb.CostBytes},
+		Order:          int(pb.Order),
+	}, nil
+}
+
+// id returns the identifier of the job, unique across tenants.
+func (s *schedulerJobSpec) id() string {
+	return s.Tenant + "/" + s.Key
+}
+
+// toJob returns the compaction job with the given block metas, which must be the ones of the job blocks.
+func (s *schedulerJobSpec) toJob(metas []*block.Meta) *Job {
+	job := newJob(s.Tenant, s.Key, labels.FromMap(s.Labels), s.Resolution, s.UseSplitting, s.SplitNumShards, s.ShardingKey)
+	job.metasByMinTime = append(job.metasByMinTime, metas...)
+	sort.Slice(job.metasByMinTime, func(i, j int) bool {
+		return job.metasByMinTime[i].MinTime < job.metasByMinTime[j].MinTime
+	})
+	return job
+}
+
+// less returns whether the job should be assigned before the other job of the same tenant.
+func (s *schedulerJobSpec) less(o *schedulerJobSpec) bool {
+	if s.Order != o.Order {
+		return s.Order < o.Order
+	}
+	return s.Key < o.Key
+}
+
+// cost returns the cost of the job used to share the compactors between tenants. Jobs with an unknown
+// cost are considered to have the minimum cost.
+func (s *schedulerJobSpec) cost() float64 {
+	return float64(max(s.Cost.Bytes, 1))
+}
+
+// schedulerJobQueue holds the compaction jobs planned by the scheduler. Jobs are leased to
+// the workers, which must renew the lease until the job is complete.
+//
+// The compactors are shared between tenants with weighted fair queuing: each tenant has a virtual time,
+// which is increased by the cost of each job assigned to the tenant divided by the tenant weight, and
+// the next job is assigned to the tenant with unassigned jobs having the lowest virtual time. This way
+// a tenant with many pending jobs doesn't delay the compaction of the other tenants.
+type schedulerJobQueue struct {
+	leaseDuration time.Duration
+	weight        func(tenant string) float64
+	throughput    *jobsThroughputEstimator
+
+	mu           sync.Mutex
+	jobs         map[string]*schedulerJob
+	unassigned   map[string]*schedulerJobHeap
+	virtualTimes map[string]float64
+}
+
+// newSchedulerJobQueue makes a new schedulerJobQueue. The weight function returns the weight of a tenant;
+// all tenants have the same weight if it's nil.
+func newSchedulerJobQueue(leaseDuration time.Duration, weight func(tenant string) float64) *schedulerJobQueue {
+	return &schedulerJobQueue{
+		leaseDuration: leaseDuration,
+		weight:        weight,
+		throughput:    newJobsThroughputEstimator(),
+		jobs:          make(map[string]*schedulerJob),
+		unassigned:    make(map[string]*schedulerJobHeap),
+		virtualTimes:  make(map[string]float64),
+	}
+}
+
+// assign assigns the highest-priority unassigned job of the tenant with the lowest virtual time to the given worker.
+func (q *schedulerJobQueue) assign(workerID string) (string, schedulerJobSpec, error) {
+	if workerID == "" {
+		return "", schedulerJobSpec{}, errors.New("workerID cannot be empty")
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	tenant, ok := q.nextTenant()
+	if !ok {
+		return "", schedulerJobSpec{}, errNoJobAvailable
+	}
+
+	h := q.unassigned[tenant]
+	j := heap.Pop(h).(*schedulerJob)
+	if h.Len() == 0 {
+		delete(q.unassigned, tenant)
+	}
+	q.virtualTimes[tenant] += j.spec.cost() / q.tenantWeight(tenant)
+
+	j.assignee = workerID
+	j.assignedAt = time.Now()
+	j.leaseExpiry = j.assignedAt.Add(q.leaseDuration)
+	return j.id, j.spec, nil
+}
+
+// nextTenant returns the tenant with unassigned jobs having the lowest virtual time.
+func (q *schedulerJobQueue) nextTenant() (string, bool) {
+	var (
+		next   string
+		nextVT float64
+		found  bool
+	)
+	for tenant := range q.unassigned {
+		vt := q.virtualTimes[tenant]
+		if !found || vt < nextVT || (vt == nextVT && tenant < next) {
+			next, nextVT, found = tenant, vt, true
+		}
+	}
+	return next, found
+}
+
+func (q *schedulerJobQueue) tenantWeight(tenant string) float64 {
+	if q.weight == nil {
+		return 1
+	}
+	if w := q.weight(tenant); w > 0 {
+		return w
+	}
+	return 1
+}
+
+// pushUnassigned adds the job to the unassigned jobs of its tenant.
+func (q *schedulerJobQueue) pushUnassigned(j *schedulerJob) {
+	tenant := j.spec.Tenant
+	h, ok := q.unassigned[tenant]
+	if !ok {
+		// The virtual time of a tenant which had no unassigned jobs is moved forward to the lowest virtual time
+		// of the other tenants, so that it doesn't get the compactors for itself after being idle.
+		if vt, ok := q.lowestVirtualTime(); ok {
+			q.virtualTimes[tenant] = max(q.virtualTimes[tenant], vt)
+		}
+
+		h = &schedulerJobHeap{}
+		q.unassigned[tenant] = h
+	}
+	heap.Push(h, j)
+}
+
+// removeUnassigned removes the job from the unassigned jobs of its tenant.
+func (q *schedulerJobQueue) removeUnassigned(j *schedulerJob) {
+	tenant := j.spec.Tenant
+	h := q.unassigned[tenant]
+	heap.Remove(h, j.index)
+	if h.Len() == 0 {
+		delete(q.unassigned, tenant)
+	}
+}
+
+// lowestVirtualTime returns the lowest virtual time of the tenants having unassigned jobs.
+func (q *schedulerJobQueue) lowestVirtualTime() (float64, bool) {
+	if tenant, ok := q.nextTenant(); ok {
+		return q.virtualTimes[tenant], true
+	}
+	return 0, false
+}
+
+// addOrUpdate adds a new job or updates an existing unassigned job with the given spec.
+func (q *schedulerJobQueue) addOrUpdate(spec schedulerJobSpec) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	id := spec.id()
+	if j, ok := q.jobs[id]; ok {
+		if j.assignee == "" {
+			// We can only update an unassigned job.
+			j.spec = spec
+			heap.Fix(q.unassigned[spec.Tenant], j.index)
+		}
+		return
+	}
+
+	j := &schedulerJob{id: id, spec: spec}
+	q.jobs[id] = j
+	q.pushUnassigned(j)
+}
+
+// removeStale removes the unassigned jobs of the tenant which aren't in the given planned jobs anymore.
+// Assigned jobs are kept until they're completed or their lease expires.
+func (q *schedulerJobQueue) removeStale(tenant string, planned map[string]struct{}) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	hasJobs := false
+	for id, j := range q.jobs {
+		if j.spec.Tenant != tenant {
+			continue
+		}
+		if _, ok := planned[id]; ok || j.assignee != "" {
+			hasJobs = true
+			continue
+		}
+		q.removeUnassigned(j)
+		delete(q.jobs, id)
+	}
+
+	// The virtual time of tenants without jobs isn't needed anymore, because it's moved forward when they get new jobs.
+	if !hasJobs {
+		delete(q.virtualTimes, tenant)
+	}
+}
+
+// tenants returns the tenants having jobs in the queue.
+func (q *schedulerJobQueue) tenants() map[string]struct{} {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	out := map[string]struct{}{}
+	for _, j := range q.jobs {
+		out[j.spec.Tenant] = struct{}{}
+	}
+	return out
+}
+
+// renewLease renews the lease of the job with the given ID for the given worker. The worker recovers the lease
+// of the job if it isn't assigned to any worker, which happens when the scheduler restarted or the lease expired
+// while the worker was still running the job. The job is added to the queue with the given spec if the scheduler
+// doesn't know it.
+func (q *schedulerJobQueue) renewLease(jobID, workerID string, spec schedulerJobSpec) error {
+	if err := validateJobUpdate(jobID, workerID); err != nil {
+		return err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	j, ok := q.jobs[jobID]
+	switch {
+	case !ok:
+		if spec.id() != jobID {
+			return errJobNotFound
+		}
+		j = &schedulerJob{id: jobID, spec: spec, index: -1}
+		q.jobs[jobID] = j
+	case j.assignee == "":
+		q.removeUnassigned(j)
+	case j.assignee != workerID:
+		return errJobNotAssigned
+	}
+
+	if j.assignee != workerID {
+		j.assignee = workerID
+		j.assignedAt = time.Now()
+	}
+	j.leaseExpiry = time.Now().Add(q.leaseDuration)
+	return nil
+}
+
+// completeJob completes the job with the given ID for the given worker, removing it from the queue. A job
+// which isn't assigned to any worker is completed too, because the worker may have recovered it after
+// the scheduler restarted or the lease expired.
+func (q *schedulerJobQueue) completeJob(jobID, workerID string) error {
+	if err := validateJobUpdate(jobID, workerID); err != nil {
+		return err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	j, ok := q.jobs[jobID]
+	switch {
+	case !ok:
+		return errJobNotFound
+	case j.assignee == "":
+		q.removeUnassigned(j)
+	case j.assignee != workerID:
+		return errJobNotAssigned
+	default:
+		q.throughput.observe(j.spec.Tenant, j.spec.Cost, time.Since(j.assignedAt))
+	}
+
+	delete(q.jobs, jobID)
+	return nil
+}
+
+// failJob unassigns the job with the given ID which has failed on the given worker, making it
+// eligible for reassignment.
+func (q *schedulerJobQueue) failJob(jobID, workerID string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	j, err := q.assignedJob(jobID, workerID)
+	if err != nil {
+		return err
+	}
+
+	q.unassign(j)
+	return nil
+}
+
+// clearExpiredLeases unassigns jobs whose leases have expired, making them eligible for reassignment.
+func (q *schedulerJobQueue) clearExpiredLeases() {
+	now := time.Now()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, j := range q.jobs {
+		if j.assignee != "" && now.After(j.leaseExpiry) {
+			q.unassign(j)
+		}
+	}
+}
+
+// list returns the jobs of the tenant, or all jobs if the tenant is empty, sorted by tenant and priority.
+func (q *schedulerJobQueue) list(tenant string) []schedulerJobStatus {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	out := make([]schedulerJobStatus, 0, len(q.jobs))
+	for _, j := range q.jobs {
+		if tenant != "" && j.spec.Tenant != tenant {
+			continue
+		}
+
+		status := schedulerJobStatus{
+			ID:        j.id,
+			Spec:      j.spec,
+			State:     schedulerJobStatePending,
+			FailCount: j.failCount,
+		}
+		if j.assignee != "" {
+			status.State = schedulerJobStateAssigned
+			status.Assignee = j.assignee
+			status.LeaseExpiry = j.leaseExpiry
+		}
+		out = append(out, status)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Spec.Tenant != out[j].Spec.Tenant {
+			return out[i].Spec.Tenant < out[j].Spec.Tenant
+		}
+		return out[i].Spec.less(&out[j].Spec)
+	})
+	return out
+}
+
+// catchUpEstimates returns the estimated time to run the jobs in the queue of each tenant, for the tenants
+// for which the time can be estimated. Each tenant is expected to get a share of the jobs currently running
+// proportional to its weight, with jobs running at the throughput observed for the tenant.
+func (q *schedulerJobQueue) catchUpEstimates() map[string]float64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	pending := map[string]JobCost{}
+	running := 0
+	for _, j := range q.jobs {
+		pending[j.spec.Tenant] = pending[j.spec.Tenant].add(j.spec.Cost)
+
+		if j.assignee != "" {
+			running++
+		}
+	}
+
+	totalWeight := 0.0
+	for tenant := range pending {
+		totalWeight += q.tenantWeight(tenant)
+	}
+
+	out := make(map[string]float64, len(pending))
+	for tenant, cost := range pending {
+		parallelism := float64(max(running, 1)) * q.tenantWeight(tenant) / totalWeight
+		if seconds, ok := q.throughput.catchUpSeconds(tenant, cost, parallelism); ok {
+			out[tenant] = seconds
+		}
+	}
+	return out
+}
+
+func validateJobUpdate(jobID, workerID string) error {
+	if jobID == "" {
+		return errors.New("jobID cannot be empty")
+	}
+	if workerID == "" {
+		return errors.New("workerID cannot be empty")
+	}
+	return nil
+}
+
+func (q *schedulerJobQueue) assignedJob(jobID, workerID string) (*schedulerJob, error) {
+	if err := validateJobUpdate(jobID, workerID); err != nil {
+		return nil, err
+	}
+
+	j, ok := q.jobs[jobID]
+	if !ok {
+		return nil, errJobNotFound
+	}
+	if j.assignee != workerID {
+		return nil, errJobNotAssigned
+	}
+	return j, nil
+}
+
+func (q *schedulerJobQueue) unassign(j *schedulerJob) {
+	j.assignee = ""
+	j.assignedAt = time.Time{}
+	j.leaseExpiry = time.Time{}
+	j.failCount++
+	q.pushUnassigned(j)
+}
+
+type schedulerJob struct {
+	id   string
+	spec schedulerJobSpec
+
+	assignee    string
+	assignedAt  time.Time
+	leaseExpiry time.Time
+	failCount   int
+
+	// Position in the unassigned jobs heap of the tenant.
+	index int
+}
+
+// schedulerJobStatus is the state of a job in the scheduler queue.
+type schedulerJobStatus struct {
+	ID          string           `json:"id"`
+	Spec        schedulerJobSpec `json:"spec"`
+	State       string           `json:"state"`
+	Assignee    string           `json:"assignee,omitempty"`
+	LeaseExpiry time.Time        `json:"lease_expiry,omitempty"`
+	FailCount   int              `json:"fail_count"`
+}
+
+type schedulerJobHeap []*schedulerJob
+
+// Implement the heap.Interface for schedulerJobHeap.
+func (h schedulerJobHeap) Len() int           { return len(h) }
+func (h schedulerJobHeap) Less(i, j int) bool { return h[i].spec.less(&h[j].spec) }
+func (h schedulerJobHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *schedulerJobHeap) Push(x interface{}) {
+	j := x.(*schedulerJob)
+	j.index = len(*h)
+	*h = append(*h, j)
+}
+
+func (h *schedulerJobHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	old[n-1] = nil
+	x.index = -1
+	*h = old[0 : n-1]
+	return x
+}
+
+var _ heap.Interface = (*schedulerJobHeap)(nil)
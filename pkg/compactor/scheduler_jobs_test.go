@@ -0,0 +1,253 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedulerJobQueue_Assign(t *testing.T) {
+	q := newSchedulerJobQueue(time.Hour, nil)
+
+	_, _, err := q.assign("w0")
+	require.ErrorIs(t, err, errNoJobAvailable)
+
+	_, _, err = q.assign("")
+	require.Error(t, err)
+
+	// Tenants with the same weight and jobs with the same cost get jobs in turn.
+	q.addOrUpdate(schedulerJobSpec{Tenant: "user-1", Key: "a", Order: 0})
+	q.addOrUpdate(schedulerJobSpec{Tenant: "user-1", Key: "b", Order: 1})
+	q.addOrUpdate(schedulerJobSpec{Tenant: "user-1", Key: "c", Order: 2})
+	q.addOrUpdate(schedulerJobSpec{Tenant: "user-2", Key: "a", Order: 0})
+	q.addOrUpdate(schedulerJobSpec{Tenant: "user-2", Key: "b", Order: 1})
+
+	var assigned []string
+	for {
+		id, spec, err := q.assign("w0")
+		if err != nil {
+			require.ErrorIs(t, err, errNoJobAvailable)
+			break
+		}
+		assert.Equal(t, spec.id(), id)
+		assert.Equal(t, "w0", q.jobs[id].assignee)
+		assigned = append(assigned, id)
+	}
+	assert.Equal(t, []string{"user-1/a", "user-2/a", "user-1/b", "user-2/b", "user-1/c"}, assigned)
+}
+
+func TestSchedulerJobQueue_AssignWeightedFair(t *testing.T) {
+	weights := map[string]float64{"user-1": 1, "user-2": 2}
+	q := newSchedulerJobQueue(time.Hour, func(tenant string) float64 { return weights[tenant] })
+
+	// Tenants get jobs proportionally to their weight and the cost of their jobs.
+	for i, key := range []string{"a", "b", "c", "d"} {
+		q.addOrUpdate(schedulerJobSpec{Tenant: "user-1", Key: key, Order: i, Cost: JobCost{Bytes: 100}})
+	}
+	for i, key := range []string{"a", "b", "c", "d", "e", "f"} {
+		q.addOrUpdate(schedulerJobSpec{Tenant: "user-2", Key: key, Order: i, Cost: JobCost{Bytes: 100}})
+	}
+	q.addOrUpdate(schedulerJobSpec{Tenant: "user-3", Key: "a", Cost: JobCost{Bytes: 400}})
+
+	var assigned []string
+	for i := 0; i < 7; i++ {
+		id, _, err := q.assign("w0")
+		require.NoError(t, err)
+		assigned = append(assigned, id)
+	}
+	assert.Equal(t, []string{"user-1/a", "user-2/a", "user-3/a", "user-2/b", "user-1/b", "user-2/c", "user-2/d"}, assigned)
+
+	// A tenant getting new jobs after having none doesn't get the compactors for itself.
+	q.addOrUpdate(schedulerJobSpec{Tenant: "user-4", Key: "a", Order: 0, Cost: JobCost{Bytes: 100}})
+	q.addOrUpdate(schedulerJobSpec{Tenant: "user-4", Key: "b", Order: 1, Cost: JobCost{Bytes: 100}})
+	assert.Equal(t, q.virtualTimes["user-2"], q.virtualTimes["user-4"])
+
+	assigned = nil
+	for {
+		id, _, err := q.assign("w0")
+		if err != nil {
+			require.ErrorIs(t, err, errNoJobAvailable)
+			break
+		}
+		assigned = append(assigned, id)
+	}
+	assert.Equal(t, []string{"user-1/c", "user-2/e", "user-4/a", "user-2/f", "user-1/d", "user-4/b"}, assigned)
+}
+
+func TestSchedulerJobQueue_CatchUpEstimates(t *testing.T) {
+	weights := map[string]float64{"user-1": 1, "user-2": 3}
+	q := newSchedulerJobQueue(time.Hour, func(tenant string) float64 { return weights[tenant] })
+
+	q.addOrUpdate(schedulerJobSpec{Tenant: "user-1", Key: "a", Cost: JobCost{Bytes: 1000}})
+	q.addOrUpdate(schedulerJobSpec{Tenant: "user-1", Key: "b", Cost: JobCost{Bytes: 1000}})
+
+	// Nothing can be estimated before the first job is complete.
+	assert.Empty(t, q.catchUpEstimates())
+
+	id, _, err := q.assign("w0")
+	require.NoError(t, err)
+	q.jobs[id].assignedAt = time.Now().Add(-10 * time.Second)
+	require.NoError(t, q.completeJob(id, "w0"))
+
+	estimates := q.catchUpEstimates()
+	require.Contains(t, estimates, "user-1")
+	assert.InDelta(t, 10, estimates["user-1"], 0.1)
+
+	// The compactors are shared between the tenants according to their weights.
+	q.addOrUpdate(schedulerJobSpec{Tenant: "user-2", Key: "a", Cost: JobCost{Bytes: 3000}})
+	_, _, err = q.assign("w0")
+	require.NoError(t, err)
+	_, _, err = q.assign("w1")
+	require.NoError(t, err)
+
+	estimates = q.catchUpEstimates()
+	assert.InDelta(t, 1000/(100*2*0.25), estimates["user-1"], 1)
+	assert.InDelta(t, 3000/(100*2*0.75), estimates["user-2"], 1)
+}
+
+func TestSchedulerJobQueue_AddOrUpdate(t *testing.T) {
+	q := newSchedulerJobQueue(time.Hour, nil)
+
+	q.addOrUpdate(schedulerJobSpec{Tenant: "user-1", Key: "a", Order: 1, MaxTime: 10})
+	q.addOrUpdate(schedulerJobSpec{Tenant: "user-1", Key: "b", Order: 0})
+
+	// Unassigned jobs are updated, including their priority.
+	q.addOrUpdate(schedulerJobSpec{Tenant: "user-1", Key: "a", Order: 0, MaxTime: 20})
+	id, spec, err := q.assign("w0")
+	require.NoError(t, err)
+	assert.Equal(t, "user-1/a", id)
+	assert.Equal(t, int64(20), spec.MaxTime)
+
+	// Assigned jobs aren't updated.
+	q.addOrUpdate(schedulerJobSpec{Tenant: "user-1", Key: "a", Order: 0, MaxTime: 30})
+	assert.Equal(t, int64(20), q.jobs[id].spec.MaxTime)
+
+	id, _, err = q.assign("w1")
+	require.NoError(t, err)
+	assert.Equal(t, "user-1/b", id)
+
+	_, _, err = q.assign("w1")
+	require.ErrorIs(t, err, errNoJobAvailable)
+}
+
+func TestSchedulerJobQueue_RemoveStale(t *testing.T) {
+	q := newSchedulerJobQueue(time.Hour, nil)
+
+	q.addOrUpdate(schedulerJobSpec{Tenant: "user-1", Key: "a", Order: 0})
+	q.addOrUpdate(schedulerJobSpec{Tenant: "user-1", Key: "b", Order: 1})
+	q.addOrUpdate(schedulerJobSpec{Tenant: "user-1", Key: "c", Order: 2})
+	q.addOrUpdate(schedulerJobSpec{Tenant: "user-2", Key: "a", Order: 0})
+
+	id, _, err := q.assign("w0")
+	require.NoError(t, err)
+	require.Equal(t, "user-1/a", id)
+
+	// The assigned job is kept, even if it's not planned anymore.
+	q.removeStale("user-1", map[string]struct{}{"user-1/c": {}})
+	assert.Equal(t, map[string]struct{}{"user-1": {}, "user-2": {}}, q.tenants())
+
+	var ids []string
+	for _, j := range q.list("user-1") {
+		ids = append(ids, j.ID)
+	}
+	assert.Equal(t, []string{"user-1/a", "user-1/c"}, ids)
+
+	q.removeStale("user-2", nil)
+	assert.Equal(t, map[string]struct{}{"user-1": {}}, q.tenants())
+
+	id, _, err = q.assign("w0")
+	require.NoError(t, err)
+	assert.Equal(t, "user-1/c", id)
+}
+
+func TestSchedulerJobQueue_UpdateJob(t *testing.T) {
+	q := newSchedulerJobQueue(time.Hour, nil)
+
+	require.ErrorIs(t, q.completeJob("user-1/a", "w0"), errJobNotFound)
+
+	q.addOrUpdate(schedulerJobSpec{Tenant: "user-1", Key: "a"})
+	id, _, err := q.assign("w0")
+	require.NoError(t, err)
+
+	require.ErrorIs(t, q.renewLease(id, "w1", schedulerJobSpec{}), errJobNotAssigned)
+	require.ErrorIs(t, q.completeJob(id, "w1"), errJobNotAssigned)
+	require.ErrorIs(t, q.failJob(id, "w1"), errJobNotAssigned)
+
+	before := q.jobs[id].leaseExpiry
+	require.NoError(t, q.renewLease(id, "w0", schedulerJobSpec{}))
+	assert.False(t, q.jobs[id].leaseExpiry.Before(before))
+
+	// A failed job is reassigned.
+	require.NoError(t, q.failJob(id, "w0"))
+	jobs := q.list("")
+	require.Len(t, jobs, 1)
+	assert.Equal(t, schedulerJobStatePending, jobs[0].State)
+	assert.Equal(t, 1, jobs[0].FailCount)
+
+	id, _, err = q.assign("w1")
+	require.NoError(t, err)
+	jobs = q.list("")
+	require.Len(t, jobs, 1)
+	assert.Equal(t, schedulerJobStateAssigned, jobs[0].State)
+	assert.Equal(t, "w1", jobs[0].Assignee)
+
+	require.NoError(t, q.completeJob(id, "w1"))
+	require.ErrorIs(t, q.completeJob(id, "w1"), errJobNotFound)
+	assert.Empty(t, q.list(""))
+}
+
+func TestSchedulerJobQueue_RecoverLease(t *testing.T) {
+	q := newSchedulerJobQueue(time.Hour, nil)
+
+	// A job unknown to the scheduler, like after a restart, is recovered from the spec sent by the worker.
+	a := schedulerJobSpec{Tenant: "user-1", Key: "a"}
+	require.ErrorIs(t, q.renewLease(a.id(), "w0", schedulerJobSpec{Tenant: "user-1", Key: "other"}), errJobNotFound)
+	require.NoError(t, q.renewLease(a.id(), "w0", a))
+
+	// Planning the recovered job again doesn't unassign it.
+	q.addOrUpdate(a)
+	_, _, err := q.assign("w1")
+	require.ErrorIs(t, err, errNoJobAvailable)
+
+	// An unassigned job is recovered by the worker running it.
+	b := schedulerJobSpec{Tenant: "user-1", Key: "b"}
+	q.addOrUpdate(b)
+	require.NoError(t, q.renewLease(b.id(), "w1", b))
+	_, _, err = q.assign("w2")
+	require.ErrorIs(t, err, errNoJobAvailable)
+
+	jobs := q.list("")
+	require.Len(t, jobs, 2)
+	assert.Equal(t, "w0", jobs[0].Assignee)
+	assert.Equal(t, "w1", jobs[1].Assignee)
+
+	// An unassigned job completed by a worker is removed.
+	c := schedulerJobSpec{Tenant: "user-1", Key: "c"}
+	q.addOrUpdate(c)
+	require.NoError(t, q.completeJob(c.id(), "w2"))
+	_, _, err = q.assign("w2")
+	require.ErrorIs(t, err, errNoJobAvailable)
+	assert.Len(t, q.list(""), 2)
+}
+
+func TestSchedulerJobQueue_ClearExpiredLeases(t *testing.T) {
+	q := newSchedulerJobQueue(-time.Second, nil)
+
+	q.addOrUpdate(schedulerJobSpec{Tenant: "user-1", Key: "a"})
+	id, _, err := q.assign("w0")
+	require.NoError(t, err)
+	_, _, err = q.assign("w1")
+	require.ErrorIs(t, err, errNoJobAvailable)
+
+	q.clearExpiredLeases()
+
+	reassigned, _, err := q.assign("w1")
+	require.NoError(t, err)
+	assert.Equal(t, id, reassigned)
+	assert.Equal(t, 1, q.jobs[id].failCount)
+	require.ErrorIs(t, q.completeJob(id, "w0"), errJobNotAssigned)
+}
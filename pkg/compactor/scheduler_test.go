@@ -0,0 +1,149 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"context"
+	"net"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/flagext"
+	"github.com/grafana/dskit/services"
+	"github.com/grafana/dskit/test"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+	"google.golang.org/grpc"
+
+	"github.com/grafana/mimir/pkg/compactor/compactorschedulerpb"
+	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestSchedulerConfig_Validate(t *testing.T) {
+	cfg := SchedulerConfig{}
+	flagext.DefaultValues(&cfg)
+	require.NoError(t, cfg.Validate())
+
+	cfg.SchedulingInterval = 0
+	require.ErrorIs(t, cfg.Validate(), errInvalidSchedulerSchedulingInterval)
+
+	cfg.SchedulingInterval = time.Minute
+	cfg.JobLeaseDuration = 0
+	require.ErrorIs(t, cfg.Validate(), errInvalidSchedulerJobLeaseDuration)
+
+	cfg.JobLeaseDuration = time.Minute
+	cfg.PlanningConcurrency = 0
+	require.ErrorIs(t, cfg.Validate(), errInvalidSchedulerPlanningConcurrency)
+}
+
+func TestCompactorScheduler_ShouldLeaseJobsToCompactors(t *testing.T) {
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+
+	// Each tenant has two first-level blocks to merge.
+	sourceBlocks := map[string][]ulid.ULID{}
+	for _, userID := range []string{"user-1", "user-2"} {
+		sourceBlocks[userID] = []ulid.ULID{
+			createTSDBBlock(t, bkt, userID, 10, 20, 2, nil),
+			createTSDBBlock(t, bkt, userID, 30, 40, 2, nil),
+		}
+	}
+
+	var limits validation.Limits
+	flagext.DefaultValues(&limits)
+	overrides, err := validation.NewOverrides(limits, nil)
+	require.NoError(t, err)
+
+	cfg := prepareConfig(t)
+	// The scheduler doesn't lease jobs for a lease duration after startup.
+	cfg.Scheduler.JobLeaseDuration = time.Second
+	bucketClientFactory := func(context.Context) (objstore.Bucket, error) {
+		return bkt, nil
+	}
+
+	scheduler, err := newCompactorScheduler(cfg, overrides, log.NewNopLogger(), prometheus.NewPedanticRegistry(), bucketClientFactory, splitAndMergeGrouperFactory)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(ctx, scheduler))
+	t.Cleanup(func() { require.NoError(t, services.StopAndAwaitTerminated(ctx, scheduler)) })
+
+	test.Poll(t, 5*time.Second, 2, func() interface{} {
+		return len(scheduler.jobs.list(""))
+	})
+	for _, j := range scheduler.jobs.list("") {
+		assert.Equal(t, schedulerJobStatePending, j.State)
+		assert.ElementsMatch(t, sourceBlocks[j.Spec.Tenant], j.Spec.Blocks)
+	}
+
+	srv := grpc.NewServer()
+	compactorschedulerpb.RegisterCompactorSchedulerServer(srv, scheduler)
+	listener, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	go func() { _ = srv.Serve(listener) }()
+	t.Cleanup(srv.Stop)
+
+	// The compactor runs the jobs leased from the scheduler.
+	cfg.Scheduler.Address = listener.Addr().String()
+	cfg.Scheduler.pollInterval = 100 * time.Millisecond
+	cfg.CompactionConcurrency = 2
+	cfg.CompactionInterval = time.Hour
+
+	storageCfg := mimir_tsdb.BlocksStorageConfig{}
+	flagext.DefaultValues(&storageCfg)
+	cfg.DataDir = t.TempDir()
+
+	c, err := newMultitenantCompactor(cfg, storageCfg, overrides, log.NewNopLogger(), prometheus.NewPedanticRegistry(), bucketClientFactory, splitAndMergeGrouperFactory, splitAndMergeCompactorFactory)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(ctx, c))
+	t.Cleanup(func() { require.NoError(t, services.StopAndAwaitTerminated(ctx, c)) })
+
+	// The source blocks are marked for deletion once compacted.
+	test.Poll(t, 10*time.Second, true, func() interface{} {
+		for userID, ids := range sourceBlocks {
+			for _, id := range ids {
+				if ok, err := bkt.Exists(ctx, path.Join(userID, id.String(), block.DeletionMarkFilename)); err != nil || !ok {
+					return false
+				}
+			}
+		}
+		return true
+	})
+
+	// The jobs have been completed.
+	test.Poll(t, 5*time.Second, 0, func() interface{} {
+		return len(scheduler.jobs.list(""))
+	})
+
+	jobs, err := c.schedulerClient.listJobs(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Empty(t, jobs)
+
+	// Each tenant has a compacted block.
+	for userID := range sourceBlocks {
+		var compacted []*block.Meta
+		require.NoError(t, bkt.Iter(ctx, userID+"/", func(name string) error {
+			id, ok := block.IsBlockDir(path.Base(name))
+			if !ok {
+				return nil
+			}
+			meta, err := block.DownloadMeta(ctx, log.NewNopLogger(), objstore.NewPrefixedBucket(bkt, userID), id)
+			if err != nil {
+				return err
+			}
+			if meta.Compaction.Level > 1 {
+				compacted = append(compacted, &meta)
+			}
+			return nil
+		}))
+
+		require.Len(t, compacted, 1, userID)
+		assert.ElementsMatch(t, sourceBlocks[userID], compacted[0].Compaction.Sources)
+		assert.Equal(t, block.CompactorSource, compacted[0].Thanos.Source)
+	}
+}
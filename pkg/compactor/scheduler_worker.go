@@ -0,0 +1,184 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+
+	"github.com/grafana/mimir/pkg/compactor/compactorschedulerpb"
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	util_log "github.com/grafana/mimir/pkg/util/log"
+)
+
+var errJobLeaseLost = errors.New("compaction job lease lost")
+
+// runSchedulerWorkers runs the compaction jobs leased from the compactor-scheduler, running up to
+// the configured compaction concurrency jobs at the same time, until the context is canceled.
+func (c *MultitenantCompactor) runSchedulerWorkers(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < c.compactorCfg.CompactionConcurrency; i++ {
+		// Each worker has its own ID, because the scheduler leases the jobs to the workers.
+		workerID := fmt.Sprintf("%s-%d", c.ringLifecycler.GetInstanceID(), i)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.runSchedulerWorker(ctx, workerID)
+		}()
+	}
+	wg.Wait()
+}
+
+func (c *MultitenantCompactor) runSchedulerWorker(ctx context.Context, workerID string) {
+	for ctx.Err() == nil {
+		job, err := c.schedulerClient.assignJob(ctx, workerID)
+		if err != nil {
+			if !errors.Is(err, errNoJobAvailable) && ctx.Err() == nil {
+				level.Warn(c.logger).Log("msg", "failed to lease compaction job from the compactor-scheduler", "err", err)
+			}
+
+			select {
+			case <-time.After(c.compactorCfg.Scheduler.pollInterval):
+			case <-ctx.Done():
+			}
+			continue
+		}
+
+		status := compactorschedulerpb.COMPLETE
+		if err := c.runScheduledJob(ctx, workerID, job); err != nil {
+			if ctx.Err() != nil {
+				// The job will be reassigned once the lease expires.
+				return
+			}
+			status = compactorschedulerpb.FAILED
+		}
+
+		if err := c.schedulerClient.updateJob(ctx, workerID, job, status); err != nil && ctx.Err() == nil {
+			level.Warn(c.logger).Log("msg", "failed to update compaction job on the compactor-scheduler", "job", job.JobId, "status", status, "err", err)
+		}
+	}
+}
+
+// runScheduledJob runs the compaction job leased from the compactor-scheduler, renewing the lease until it's done.
+func (c *MultitenantCompactor) runScheduledJob(ctx context.Context, workerID string, job *compactorschedulerpb.AssignJobResponse) error {
+	spec, err := schedulerJobSpecFromProto(job.Spec)
+	if err != nil {
+		return err
+	}
+	userID := spec.Tenant
+	userBucket := bucket.NewUserBucketClient(userID, c.bucketClient, c.cfgProvider)
+	userLogger := util_log.WithUserID(userID, c.logger)
+
+	jobCtx, cancelJob := context.WithCancelCause(ctx)
+	renewDone := make(chan struct{})
+	defer func() { <-renewDone }()
+	go func() {
+		defer close(renewDone)
+		c.renewJobLease(jobCtx, cancelJob, workerID, job, userLogger)
+	}()
+	defer cancelJob(nil)
+
+	metas := make([]*block.Meta, 0, len(spec.Blocks))
+	for _, id := range spec.Blocks {
+		// The job is stale if any of its blocks has been compacted already, for example by a previous
+		// lease of the job.
+		if marked, err := userBucket.Exists(jobCtx, path.Join(id.String(), block.DeletionMarkFilename)); err != nil {
+			return errors.Wrapf(err, "check deletion mark of block %s", id)
+		} else if marked {
+			level.Info(userLogger).Log("msg", "skipped compaction job because a block has been marked for deletion", "groupKey", spec.Key, "block", id)
+			return nil
+		}
+
+		meta, err := block.DownloadMeta(jobCtx, userLogger, userBucket, id)
+		if err != nil {
+			return errors.Wrapf(err, "download meta of block %s", id)
+		}
+
+		// Blocks are grouped by the scheduler ignoring these labels, like the ones fetched for compaction.
+		for _, l := range compactionIgnoredLabels {
+			delete(meta.Thanos.Labels, l)
+		}
+		metas = append(metas, &meta)
+	}
+
+	compactor, err := NewBucketCompactor(
+		userLogger,
+		nil,
+		nil,
+		c.tenantPlanner(userID),
+		c.blocksCompactor,
+		path.Join(c.compactorCfg.DataDir, "compact-jobs", userID),
+		c.throughputLimiters.bucket(userID, userBucket),
+		1,
+		true, // Skip unhealthy blocks, and mark them for no-compaction.
+		ownAllJobs,
+		nil,
+		c.compactorCfg.CompactionWaitPeriod,
+		c.compactorCfg.BlockSyncConcurrency,
+		c.bucketCompactorMetrics,
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create bucket compactor")
+	}
+	if err := c.applyTenantJobsSettings(jobCtx, compactor, userID, userLogger); err != nil {
+		return err
+	}
+
+	c.bucketCompactorMetrics.groupCompactionRunsStarted.Inc()
+
+	_, compactedBlockIDs, err := compactor.runCompactionJob(jobCtx, spec.toJob(metas))
+	if err == nil {
+		c.bucketCompactorMetrics.groupCompactionRunsCompleted.Inc()
+		if hasNonZeroULIDs(compactedBlockIDs) {
+			c.bucketCompactorMetrics.groupCompactions.Inc()
+		}
+		return nil
+	}
+
+	c.bucketCompactorMetrics.groupCompactionRunsFailed.Inc()
+	if cause := context.Cause(jobCtx); errors.Is(cause, errJobLeaseLost) {
+		err = cause
+	}
+
+	// The job is complete if the broken block has been repaired or marked for no compaction,
+	// because the scheduler will plan the next job without it.
+	if compactor.recoverJobError(ctx, err) {
+		return nil
+	}
+
+	level.Error(userLogger).Log("msg", "failed to run compaction job leased from the compactor-scheduler", "groupKey", spec.Key, "err", err)
+	return err
+}
+
+// renewJobLease periodically renews the lease of the job until the context is canceled. It cancels
+// the job if the lease has been lost, because the job may have been assigned to another compactor.
+func (c *MultitenantCompactor) renewJobLease(ctx context.Context, cancelJob context.CancelCauseFunc, workerID string, job *compactorschedulerpb.AssignJobResponse, logger log.Logger) {
+	ticker := time.NewTicker(job.LeaseDuration / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			err := c.schedulerClient.updateJob(ctx, workerID, job, compactorschedulerpb.IN_PROGRESS)
+			if errors.Is(err, errJobNotFound) || errors.Is(err, errJobNotAssigned) {
+				level.Warn(logger).Log("msg", "canceling compaction job because its lease has been lost", "job", job.JobId, "err", err)
+				cancelJob(errJobLeaseLost)
+				return
+			}
+			if err != nil && ctx.Err() == nil {
+				level.Warn(logger).Log("msg", "failed to renew compaction job lease", "job", job.JobId, "err", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,515 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"context"
+	"crypto/rand"
+	"maps"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/tenant"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/thanos-io/objstore"
+
+	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/util"
+)
+
+const (
+	SeriesDeletionRequestStatePending    = "pending"
+	SeriesDeletionRequestStateProcessing = "processing"
+	SeriesDeletionRequestStateProcessed  = "processed"
+)
+
+// DeleteSeries creates a series deletion request for the tenant. The series to delete are selected with
+// the match[] parameters, and the samples to delete with the start and end parameters.
+func (c *MultitenantCompactor) DeleteSeries(w http.ResponseWriter, r *http.Request) {
+	if !c.storageCfg.SeriesDeletionEnabled {
+		http.Error(w, "series deletion is disabled", http.StatusNotFound)
+		return
+	}
+
+	userID, err := tenant.TenantID(r.Context())
+	if err != nil {
+		// When Mimir is running, it uses Auth Middleware for checking X-Scope-OrgID and injecting tenant into context.
+		// Auth Middleware sends http.StatusUnauthorized if X-Scope-OrgID is missing, so we do too here, for consistency.
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	start, err := util.ParseTimeParam(r, "start", math.MinInt64)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	end, err := util.ParseTimeParam(r, "end", math.MaxInt64)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	req, err := mimir_tsdb.NewSeriesDeletionRequest(r.Form["match[]"], start, end, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := mimir_tsdb.WriteSeriesDeletionRequest(r.Context(), c.bucketClient, userID, c.cfgProvider, req); err != nil {
+		level.Error(c.logger).Log("msg", "failed to write series deletion request", "user", userID, "err", err)
+
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	level.Info(c.logger).Log("msg", "series deletion request created", "user", userID, "request_id", req.ID, "selectors", len(req.Selectors), "min_time", req.MinTime, "max_time", req.MaxTime)
+
+	util.WriteJSONResponse(w, newSeriesDeletionRequestStatus(req))
+}
+
+type DeleteSeriesStatusResponse struct {
+	TenantID string                        `json:"tenant_id"`
+	Requests []SeriesDeletionRequestStatus `json:"requests"`
+}
+
+type SeriesDeletionRequestStatus struct {
+	*mimir_tsdb.SeriesDeletionRequest
+
+	State string `json:"state"`
+}
+
+func newSeriesDeletionRequestStatus(req *mimir_tsdb.SeriesDeletionRequest) SeriesDeletionRequestStatus {
+	state := SeriesDeletionRequestStatePending
+	if req.Processed() {
+		state = SeriesDeletionRequestStateProcessed
+	} else if req.LastCheckTime > 0 {
+		state = SeriesDeletionRequestStateProcessing
+	}
+
+	return SeriesDeletionRequestStatus{SeriesDeletionRequest: req, State: state}
+}
+
+// DeleteSeriesStatus shows the progress of the series deletion requests of the tenant.
+func (c *MultitenantCompactor) DeleteSeriesStatus(w http.ResponseWriter, r *http.Request) {
+	userID, err := tenant.TenantID(r.Context())
+	if err != nil {
+		// Respond with http.StatusUnauthorized like DeleteSeries, for consistency.
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	reqs, err := mimir_tsdb.ReadSeriesDeletionRequests(r.Context(), c.bucketClient, userID, c.logger)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	result := DeleteSeriesStatusResponse{TenantID: userID, Requests: make([]SeriesDeletionRequestStatus, 0, len(reqs))}
+	for _, req := range reqs {
+		result.Requests = append(result.Requests, newSeriesDeletionRequestStatus(req))
+	}
+
+	util.WriteJSONResponse(w, result)
+}
+
+// seriesDeletionSettleDelay returns how long the compactor waits after a series deletion request has been
+// created before rewriting the blocks. After this delay, ingesters have applied the request, so blocks created
+// afterwards don't contain the deleted samples, and only the blocks created before need to be rewritten.
+func (c *MultitenantCompactor) seriesDeletionSettleDelay() time.Duration {
+	return 2 * c.storageCfg.SeriesDeletionSyncInterval
+}
+
+// seriesDeletionIngestionWindow returns for how long after the end of the time range of a series deletion request
+// new blocks may still contain samples in the range. Ingesters accept samples up to the larger of the out-of-order
+// time window and half of the block range late, and ship them once the block range is over.
+func (c *MultitenantCompactor) seriesDeletionIngestionWindow(userID string) time.Duration {
+	blockRange := c.cfgProvider.IngesterTSDBBlockRangePeriod(userID)
+	if blockRange <= 0 && len(c.storageCfg.TSDB.BlockRanges) > 0 {
+		blockRange = c.storageCfg.TSDB.BlockRanges[0]
+	}
+	return max(c.cfgProvider.OutOfOrderTimeWindow(userID), blockRange/2) + blockRange
+}
+
+// processSeriesDeletionRequests rewrites the tenant blocks containing samples deleted by the series deletion
+// requests, and updates the progress of the requests in the bucket. A request is processed once its time range
+// is older than the ingestion window: until then, the blocks created since the previous check are checked too,
+// because they may contain late samples, or out-of-order ones, ingested after the request has been applied.
+// A block which fails to be rewritten doesn't stop the processing of the other blocks, and is retried in the
+// next run. Processed requests are deleted once they're older than the configured TTL.
+func (c *MultitenantCompactor) processSeriesDeletionRequests(ctx context.Context, userID string, userBucket objstore.InstrumentedBucket, reqs mimir_tsdb.SeriesDeletionRequests, logger log.Logger) error {
+	now := time.Now()
+	settleDelay := c.seriesDeletionSettleDelay()
+	ingestionWindow := c.seriesDeletionIngestionWindow(userID)
+	ttl := c.storageCfg.SeriesDeletionProcessedRequestsTTL
+
+	var ready mimir_tsdb.SeriesDeletionRequests
+	for _, req := range reqs {
+		if req.Processed() {
+			if ttl > 0 && now.Sub(req.ProcessedTime.Time()) >= ttl {
+				if err := mimir_tsdb.DeleteSeriesDeletionRequest(ctx, c.bucketClient, userID, c.cfgProvider, req.ID); err != nil {
+					return err
+				}
+				c.seriesDeletionRequestsDeleted.Inc()
+				level.Info(logger).Log("msg", "deleted processed series deletion request older than the TTL", "request_id", req.ID, "processed_time", req.ProcessedTime.Time())
+			}
+			continue
+		}
+		if now.Sub(req.CreationTime.Time()) >= settleDelay {
+			ready = append(ready, req)
+		}
+	}
+	if len(ready) == 0 {
+		return nil
+	}
+
+	metas, err := c.fetchBlocksToRewrite(ctx, userBucket, logger)
+	if err != nil {
+		return err
+	}
+
+	// Find out the blocks to rewrite for each request, and track the progress.
+	pending := map[ulid.ULID]mimir_tsdb.SeriesDeletionRequests{}
+	prevCheckTimes := make(map[*mimir_tsdb.SeriesDeletionRequest]util.UnixSeconds, len(ready))
+	for _, req := range ready {
+		prevCheckTimes[req] = req.LastCheckTime
+		req.PendingBlocks = 0
+		for _, meta := range metas {
+			if seriesDeletionPendingForBlock(req, meta, settleDelay, req.LastCheckTime) {
+				pending[meta.ULID] = append(pending[meta.ULID], req)
+				req.PendingBlocks++
+			}
+		}
+		req.LastCheckTime = util.UnixSecondsFromTime(time.Now())
+
+		if err := mimir_tsdb.WriteSeriesDeletionRequest(ctx, c.bucketClient, userID, c.cfgProvider, req); err != nil {
+			return err
+		}
+	}
+
+	pendingMetas := make([]*block.Meta, 0, len(pending))
+	for id := range pending {
+		pendingMetas = append(pendingMetas, metas[id])
+	}
+	failedBlocks, err := c.rewriteBlocks(ctx, pendingMetas, "series deletion", c.seriesDeletionBlocksRewriteFailures, logger, func(meta *block.Meta) error {
+		toApply := pending[meta.ULID]
+		rewritten, err := c.rewriteBlockForSeriesDeletion(ctx, userBucket, meta, toApply, logger)
+		if err != nil {
+			return err
+		}
+
+		for _, req := range toApply {
+			req.PendingBlocks--
+			if rewritten {
+				req.RewrittenBlocks++
+			}
+			if err := mimir_tsdb.WriteSeriesDeletionRequest(ctx, c.bucketClient, userID, c.cfgProvider, req); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// The blocks which failed to be rewritten are still pending: restore the previous check time of their requests,
+	// so that the blocks are checked again even if they've been created since then, and the requests are not
+	// processed yet.
+	failed := map[*mimir_tsdb.SeriesDeletionRequest]struct{}{}
+	for _, id := range failedBlocks {
+		for _, req := range pending[id] {
+			if _, ok := failed[req]; ok {
+				continue
+			}
+			failed[req] = struct{}{}
+			req.LastCheckTime = prevCheckTimes[req]
+			if err := mimir_tsdb.WriteSeriesDeletionRequest(ctx, c.bucketClient, userID, c.cfgProvider, req); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Once the time range of a request is older than the ingestion window, and the blocks with the samples ingested
+	// late have been uploaded, new blocks don't contain the deleted samples, so the request is processed.
+	for _, req := range ready {
+		if _, ok := failed[req]; ok || req.MaxTime >= now.Add(-ingestionWindow-settleDelay).UnixMilli() {
+			continue
+		}
+
+		req.LastCheckTime = util.UnixSecondsFromTime(time.Now())
+		req.ProcessedTime = req.LastCheckTime
+
+		if err := mimir_tsdb.WriteSeriesDeletionRequest(ctx, c.bucketClient, userID, c.cfgProvider, req); err != nil {
+			return err
+		}
+
+		c.seriesDeletionRequestsProcessed.Inc()
+		level.Info(logger).Log("msg", "series deletion request processed", "request_id", req.ID, "rewritten_blocks", req.RewrittenBlocks)
+	}
+
+	return nil
+}
+
+// seriesDeletionPendingForBlock returns whether the block may contain samples deleted by the request, because it
+// overlaps the request time range and it has been created either before the request has been applied by ingesters,
+// or since the previous check of the blocks, in which case it may contain samples ingested late. Blocks are uploaded
+// some time after they've been created, so the blocks created up to the settle delay before the previous check are
+// checked again.
+func seriesDeletionPendingForBlock(req *mimir_tsdb.SeriesDeletionRequest, meta *block.Meta, settleDelay time.Duration, lastCheckTime util.UnixSeconds) bool {
+	// Block max time is exclusive.
+	if !req.Overlaps(meta.MinTime, meta.MaxTime-1) {
+		return false
+	}
+
+	createdAt := time.UnixMilli(int64(meta.ULID.Time()))
+	if createdAt.Before(req.CreationTime.Time().Add(settleDelay)) {
+		return true
+	}
+	return lastCheckTime == 0 || !createdAt.Before(lastCheckTime.Time().Add(-settleDelay))
+}
+
+// rewriteBlockForSeriesDeletion downloads the block, deletes the series of the requests and, if any sample has been
+// deleted, uploads the rewritten block and marks the original one for deletion. The rewritten block keeps the
+// compaction level and sources of the original block, so that it replaces it in the next compactions.
+func (c *MultitenantCompactor) rewriteBlockForSeriesDeletion(ctx context.Context, userBucket objstore.Bucket, meta *block.Meta, reqs mimir_tsdb.SeriesDeletionRequests, logger log.Logger) (bool, error) {
+	logger = log.With(logger, "block", meta.ULID)
+
+	rewritten, err := c.rewriteBlock(ctx, userBucket, meta, "series deletion", c.seriesDeletionBlocksMarkedForDeletion, logger, func(bdir, dest string) ([]ulid.ULID, error) {
+		if err := applySeriesDeletionRequestsToBlockDir(ctx, logger, bdir, reqs); err != nil {
+			return nil, err
+		}
+		return c.writeBlockWithoutDeletedSamples(ctx, logger, bdir, dest, meta)
+	})
+	if rewritten {
+		c.seriesDeletionBlocksRewritten.Inc()
+	}
+	return rewritten, err
+}
+
+// fetchBlocksToRewrite returns the metadata of the tenant blocks which the compactor may rewrite. Blocks marked for
+// no-compaction are rewritten too, so the compaction filters are not applied, but the blocks moved to the cold
+// storage and the quarantined blocks are not.
+func (c *MultitenantCompactor) fetchBlocksToRewrite(ctx context.Context, userBucket objstore.InstrumentedBucket, logger log.Logger) (map[ulid.ULID]*block.Meta, error) {
+	fetcher, err := block.NewMetaFetcher(logger, c.compactorCfg.MetaSyncConcurrency, userBucket, "", nil, []block.MetadataFilter{newColdStorageMarkFilter(userBucket), newQuarantineMarkFilter(userBucket)}, nil)
+	if err != nil {
+		return nil, err
+	}
+	metas, _, err := fetcher.FetchWithoutMarkedForDeletion(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetch blocks metadata")
+	}
+	return metas, nil
+}
+
+// rewriteBlocks calls rewrite for each block, in order of ID. A block which fails to be rewritten doesn't stop the
+// rewrite of the other blocks: the failure is logged and counted, and the block is retried in the next run. It
+// returns the IDs of the blocks which failed to be rewritten, and an error only if the context is canceled.
+func (c *MultitenantCompactor) rewriteBlocks(ctx context.Context, metas []*block.Meta, reason string, failures prometheus.Counter, logger log.Logger, rewrite func(meta *block.Meta) error) ([]ulid.ULID, error) {
+	slices.SortFunc(metas, func(a, b *block.Meta) int {
+		return a.ULID.Compare(b.ULID)
+	})
+
+	var failed []ulid.ULID
+	for _, meta := range metas {
+		if ctx.Err() != nil {
+			return failed, ctx.Err()
+		}
+		if err := rewrite(meta); err != nil {
+			if ctx.Err() != nil {
+				return failed, ctx.Err()
+			}
+			failures.Inc()
+			level.Warn(logger).Log("msg", "failed to rewrite block, it will be retried in the next run", "reason", reason, "block", meta.ULID, "err", err)
+			failed = append(failed, meta.ULID)
+		}
+	}
+	return failed, nil
+}
+
+// rewriteBlock downloads the block and rewrites it with the write function, which returns the IDs of the blocks
+// written to dest, or nil if the block doesn't need to be rewritten. The rewritten blocks are uploaded, and the
+// original block is marked for deletion. It returns whether the block has been rewritten.
+func (c *MultitenantCompactor) rewriteBlock(ctx context.Context, userBucket objstore.Bucket, meta *block.Meta, reason string, blocksMarkedForDeletion prometheus.Counter, logger log.Logger, write func(bdir, dest string) ([]ulid.ULID, error)) (bool, error) {
+	tmpDir, err := os.MkdirTemp(c.compactorCfg.DataDir, "rewrite-")
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			level.Warn(logger).Log("msg", "failed to remove block rewrite temporary directory", "dir", tmpDir, "err", err)
+		}
+	}()
+
+	bdir := filepath.Join(tmpDir, meta.ULID.String())
+	if err := block.Download(ctx, logger, userBucket, meta.ULID, bdir); err != nil {
+		return false, errors.Wrapf(err, "download block %s", meta.ULID)
+	}
+
+	newIDs, err := write(bdir, tmpDir)
+	if err != nil {
+		return false, err
+	}
+	if newIDs == nil {
+		level.Debug(logger).Log("msg", "block doesn't need to be rewritten", "reason", reason)
+		return false, nil
+	}
+
+	for _, id := range newIDs {
+		if err := finalizeRewrittenBlock(ctx, logger, filepath.Join(tmpDir, id.String()), meta); err != nil {
+			return false, err
+		}
+		if err := block.Upload(ctx, logger, userBucket, filepath.Join(tmpDir, id.String()), nil); err != nil {
+			return false, errors.Wrapf(err, "upload of %s failed", id)
+		}
+		level.Info(logger).Log("msg", "uploaded rewritten block", "reason", reason, "result_block", id)
+	}
+
+	// Spawn a new context so we always mark a block for deletion in full on shutdown.
+	delCtx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	if err := block.MarkForDeletion(delCtx, logger, userBucket, meta.ULID, "source of block rewritten for "+reason, blocksMarkedForDeletion); err != nil {
+		return false, errors.Wrapf(err, "mark block %s for deletion", meta.ULID)
+	}
+	return true, nil
+}
+
+// writeBlockWithoutDeletedSamples writes the block in bdir to dest without the samples deleted by its tombstones.
+// It returns nil if the block doesn't have tombstones, and an empty slice if all the samples of the block have been
+// deleted. The compactor can't read the aggregated chunks of the downsampled blocks, which are rewritten series by
+// series instead.
+func (c *MultitenantCompactor) writeBlockWithoutDeletedSamples(ctx context.Context, logger log.Logger, bdir, dest string, meta *block.Meta) ([]ulid.ULID, error) {
+	if meta.Thanos.Downsample.Resolution > 0 {
+		return writeDownsampledBlockWithoutDeletedSamples(ctx, logger, bdir, dest, meta)
+	}
+
+	b, err := tsdb.OpenBlock(logger, bdir, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "open block %s", meta.ULID)
+	}
+	defer func() {
+		if err := b.Close(); err != nil {
+			level.Warn(logger).Log("msg", "failed to close block", "block", meta.ULID, "err", err)
+		}
+	}()
+	if b.Meta().Stats.NumTombstones == 0 {
+		return nil, nil
+	}
+
+	ids, err := c.blocksCompactor.Write(dest, b, b.MinTime(), b.MaxTime(), &meta.BlockMeta)
+	if err != nil {
+		return nil, errors.Wrapf(err, "write block %s without deleted samples", meta.ULID)
+	}
+	if ids == nil {
+		ids = []ulid.ULID{}
+	}
+	return ids, nil
+}
+
+// writeDownsampledBlockWithoutDeletedSamples writes the downsampled block in bdir to dest without the aggregated
+// windows overlapping its tombstones, with the same semantics as writeBlockWithoutDeletedSamples.
+func writeDownsampledBlockWithoutDeletedSamples(ctx context.Context, logger log.Logger, bdir, dest string, meta *block.Meta) ([]ulid.ULID, error) {
+	// Writing the tombstones updates the stats of the block meta in bdir, without the Thanos metadata.
+	current, err := block.ReadMetaFromDir(bdir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read meta of block %s", meta.ULID)
+	}
+	if current.Stats.NumTombstones == 0 {
+		return nil, nil
+	}
+
+	newMeta := *meta
+	newMeta.ULID = ulid.MustNew(ulid.Now(), rand.Reader)
+	if err := writeBlockWithRewrittenSeries(ctx, logger, bdir, dest, &newMeta, func(lset labels.Labels) (labels.Labels, error) {
+		return lset, nil
+	}); err != nil {
+		return nil, errors.Wrapf(err, "write block %s without deleted samples", meta.ULID)
+	}
+
+	written, err := block.ReadMetaFromDir(filepath.Join(dest, newMeta.ULID.String()))
+	if err != nil {
+		return nil, errors.Wrapf(err, "read meta of block %s", newMeta.ULID)
+	}
+	if written.Stats.NumSeries == 0 {
+		return []ulid.ULID{}, os.RemoveAll(filepath.Join(dest, newMeta.ULID.String()))
+	}
+	return []ulid.ULID{newMeta.ULID}, nil
+}
+
+// applySeriesDeletionRequests writes the tombstones for the series deleted by the requests to the block.
+func applySeriesDeletionRequests(ctx context.Context, b *tsdb.Block, reqs mimir_tsdb.SeriesDeletionRequests) error {
+	for _, req := range reqs.Overlapping(b.MinTime(), b.MaxTime()-1) {
+		for _, matchers := range req.Matchers() {
+			if err := b.Delete(ctx, req.MinTime, req.MaxTime, matchers...); err != nil {
+				return errors.Wrapf(err, "apply series deletion request %s", req.ID)
+			}
+		}
+	}
+	return nil
+}
+
+// applySeriesDeletionRequestsToBlockDir writes the tombstones for the series deleted by the requests to the block
+// in the directory, so that they're removed when the block gets compacted.
+func applySeriesDeletionRequestsToBlockDir(ctx context.Context, logger log.Logger, bdir string, reqs mimir_tsdb.SeriesDeletionRequests) error {
+	b, err := tsdb.OpenBlock(logger, bdir, nil)
+	if err != nil {
+		return errors.Wrapf(err, "open block %s", bdir)
+	}
+
+	err = applySeriesDeletionRequests(ctx, b, reqs)
+	if closeErr := b.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// finalizeRewrittenBlock sets the metadata of the original block on the rewritten one, and validates it.
+func finalizeRewrittenBlock(ctx context.Context, logger log.Logger, bdir string, original *block.Meta) error {
+	// The series of the rewritten block may have been split by compactor shard again.
+	lbls := original.Thanos.Labels
+	if written, err := block.ReadMetaFromDir(bdir); err == nil {
+		if shardID, ok := written.Thanos.Labels[mimir_tsdb.CompactorShardIDExternalLabel]; ok {
+			lbls = maps.Clone(lbls)
+			lbls[mimir_tsdb.CompactorShardIDExternalLabel] = shardID
+		}
+	}
+
+	newMeta, err := block.InjectThanosMeta(logger, bdir, block.ThanosMeta{
+		Labels:       lbls,
+		Downsample:   original.Thanos.Downsample,
+		Source:       block.CompactorSource,
+		SegmentFiles: block.GetSegmentFiles(bdir),
+		Rewrites:     original.Thanos.Rewrites,
+	}, nil)
+	if err != nil {
+		return errors.Wrapf(err, "failed to finalize the block %s", bdir)
+	}
+
+	newMeta.Compaction.Level = original.Compaction.Level
+	newMeta.Compaction.Sources = original.Compaction.Sources
+	if err := newMeta.WriteToDir(logger, bdir); err != nil {
+		return errors.Wrapf(err, "write meta of block %s", bdir)
+	}
+
+	if err := os.Remove(filepath.Join(bdir, "tombstones")); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "remove tombstones")
+	}
+
+	return errors.Wrapf(block.VerifyBlock(ctx, logger, bdir, newMeta.MinTime, newMeta.MaxTime, false), "invalid rewritten block %s", bdir)
+}
@@ -0,0 +1,414 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/user"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storage/tsdb/downsample"
+	"github.com/grafana/mimir/pkg/util"
+)
+
+func TestDeleteSeries(t *testing.T) {
+	bkt := objstore.NewInMemBucket()
+	c, _, _, _, _ := prepare(t, prepareConfig(t), bkt)
+	c.bucketClient = bkt
+
+	deleteSeries := func(ctx context.Context, params url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodDelete, "/prometheus/api/v1/series?"+params.Encode(), nil)
+		resp := httptest.NewRecorder()
+		c.DeleteSeries(resp, req.WithContext(ctx))
+		return resp
+	}
+
+	ctx := user.InjectOrgID(context.Background(), "user")
+	params := url.Values{"match[]": []string{`{__name__="metric"}`}, "start": []string{"10"}, "end": []string{"20"}}
+
+	t.Run("series deletion disabled", func(t *testing.T) {
+		require.Equal(t, http.StatusNotFound, deleteSeries(ctx, params).Code)
+	})
+
+	c.storageCfg.SeriesDeletionEnabled = true
+
+	t.Run("missing tenant", func(t *testing.T) {
+		require.Equal(t, http.StatusUnauthorized, deleteSeries(context.Background(), params).Code)
+	})
+
+	t.Run("missing selectors", func(t *testing.T) {
+		require.Equal(t, http.StatusBadRequest, deleteSeries(ctx, url.Values{"start": []string{"10"}}).Code)
+	})
+
+	t.Run("invalid selector", func(t *testing.T) {
+		require.Equal(t, http.StatusBadRequest, deleteSeries(ctx, url.Values{"match[]": []string{"{"}}).Code)
+	})
+
+	t.Run("start after end", func(t *testing.T) {
+		require.Equal(t, http.StatusBadRequest, deleteSeries(ctx, url.Values{"match[]": []string{"metric"}, "start": []string{"20"}, "end": []string{"10"}}).Code)
+	})
+
+	t.Run("request created", func(t *testing.T) {
+		resp := deleteSeries(ctx, params)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		reqs, err := mimir_tsdb.ReadSeriesDeletionRequests(context.Background(), bkt, "user", log.NewNopLogger())
+		require.NoError(t, err)
+		require.Len(t, reqs, 1)
+		assert.Equal(t, []string{`{__name__="metric"}`}, reqs[0].Selectors)
+		assert.Equal(t, int64(10_000), reqs[0].MinTime)
+		assert.Equal(t, int64(20_000), reqs[0].MaxTime)
+
+		statusResp := httptest.NewRecorder()
+		c.DeleteSeriesStatus(statusResp, httptest.NewRequest(http.MethodGet, "/compactor/delete_series_status", nil).WithContext(ctx))
+		require.Equal(t, http.StatusOK, statusResp.Code)
+
+		var status struct {
+			TenantID string `json:"tenant_id"`
+			Requests []struct {
+				ID    string `json:"id"`
+				State string `json:"state"`
+			} `json:"requests"`
+		}
+		require.NoError(t, json.Unmarshal(statusResp.Body.Bytes(), &status))
+		assert.Equal(t, "user", status.TenantID)
+		require.Len(t, status.Requests, 1)
+		assert.Equal(t, reqs[0].ID, status.Requests[0].ID)
+		assert.Equal(t, SeriesDeletionRequestStatePending, status.Requests[0].State)
+	})
+}
+
+func TestSeriesDeletionPendingForBlock(t *testing.T) {
+	now := time.Now()
+	req, err := mimir_tsdb.NewSeriesDeletionRequest([]string{"metric"}, 100, 200, now)
+	require.NoError(t, err)
+
+	newMeta := func(minT, maxT int64, createdAt time.Time) *block.Meta {
+		return &block.Meta{BlockMeta: tsdb.BlockMeta{ULID: ulid.MustNew(ulid.Timestamp(createdAt), nil), MinTime: minT, MaxTime: maxT}}
+	}
+
+	lastCheck := util.UnixSecondsFromTime(now.Add(10 * time.Minute))
+
+	assert.True(t, seriesDeletionPendingForBlock(req, newMeta(0, 101, now.Add(-time.Hour)), time.Minute, lastCheck))
+	assert.True(t, seriesDeletionPendingForBlock(req, newMeta(200, 300, now.Add(30*time.Second)), time.Minute, lastCheck))
+	// The block max time is exclusive.
+	assert.False(t, seriesDeletionPendingForBlock(req, newMeta(0, 100, now.Add(-time.Hour)), time.Minute, lastCheck))
+	assert.False(t, seriesDeletionPendingForBlock(req, newMeta(201, 300, now.Add(-time.Hour)), time.Minute, lastCheck))
+	// Blocks created after the request has been applied by ingesters and checked by the previous run don't contain the deleted samples.
+	assert.False(t, seriesDeletionPendingForBlock(req, newMeta(0, 300, now.Add(2*time.Minute)), time.Minute, lastCheck))
+	// Blocks created after the request has been applied by ingesters, but not checked yet, may contain samples ingested late.
+	assert.True(t, seriesDeletionPendingForBlock(req, newMeta(0, 300, now.Add(2*time.Minute)), time.Minute, 0))
+	assert.True(t, seriesDeletionPendingForBlock(req, newMeta(0, 300, now.Add(9*time.Minute+30*time.Second)), time.Minute, lastCheck))
+	assert.True(t, seriesDeletionPendingForBlock(req, newMeta(0, 300, now.Add(20*time.Minute)), time.Minute, lastCheck))
+}
+
+func TestMultitenantCompactor_processSeriesDeletionRequests(t *testing.T) {
+	const userID = "user"
+
+	bkt := objstore.NewInMemBucket()
+	cfgProvider := newMockConfigProvider()
+	c, _, _, _, _ := prepareWithConfigProvider(t, prepareConfig(t), bkt, cfgProvider)
+	c.bucketClient = bkt
+	c.storageCfg.SeriesDeletionSyncInterval = time.Minute
+	c.storageCfg.TSDB.BlockRanges = mimir_tsdb.DurationList{2 * time.Hour}
+	userBucket := bucket.NewUserBucketClient(userID, bkt, c.cfgProvider)
+
+	now := time.Now()
+	createdAt := now.Add(-time.Hour)
+	oldReq, err := mimir_tsdb.NewSeriesDeletionRequest([]string{"metric"}, 0, now.Add(-24*time.Hour).UnixMilli(), createdAt)
+	require.NoError(t, err)
+	recentReq, err := mimir_tsdb.NewSeriesDeletionRequest([]string{"metric"}, 0, now.Add(-time.Hour).UnixMilli(), createdAt)
+	require.NoError(t, err)
+
+	reqs := mimir_tsdb.SeriesDeletionRequests{oldReq, recentReq}
+	require.NoError(t, c.processSeriesDeletionRequests(context.Background(), userID, userBucket, reqs, log.NewNopLogger()))
+
+	// The request which time range is older than the ingestion window is processed, while the other one is kept
+	// pending, because new blocks may still contain samples in its time range.
+	assert.True(t, oldReq.Processed())
+	assert.False(t, recentReq.Processed())
+	assert.NotZero(t, recentReq.LastCheckTime)
+
+	cfgProvider.outOfOrderTimeWindows[userID] = 48 * time.Hour
+	oldReq.ProcessedTime = 0
+	require.NoError(t, c.processSeriesDeletionRequests(context.Background(), userID, userBucket, reqs, log.NewNopLogger()))
+	assert.False(t, oldReq.Processed())
+}
+
+func TestMultitenantCompactor_processSeriesDeletionRequests_BlockRewriteFailure(t *testing.T) {
+	const userID = "user"
+
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+	c, _, _, _, _ := prepareWithConfigProvider(t, prepareConfig(t), bkt, newMockConfigProvider())
+	c.bucketClient = bkt
+	c.storageCfg.SeriesDeletionSyncInterval = time.Minute
+	c.storageCfg.TSDB.BlockRanges = mimir_tsdb.DurationList{2 * time.Hour}
+	userBucket := bucket.NewUserBucketClient(userID, bkt, c.cfgProvider)
+
+	// Remove the index of the block, so that it can't be rewritten.
+	blockID := createTSDBBlock(t, bkt, userID, 0, 2*time.Hour.Milliseconds(), 4, nil)
+	require.NoError(t, userBucket.Delete(ctx, filepath.Join(blockID.String(), block.IndexFilename)))
+
+	now := time.Now()
+	req, err := mimir_tsdb.NewSeriesDeletionRequest([]string{"series_id"}, 0, now.Add(-24*time.Hour).UnixMilli(), now.Add(-time.Hour))
+	require.NoError(t, err)
+
+	reqs := mimir_tsdb.SeriesDeletionRequests{req}
+	require.NoError(t, c.processSeriesDeletionRequests(ctx, userID, userBucket, reqs, log.NewNopLogger()))
+	assert.Equal(t, float64(1), testutil.ToFloat64(c.seriesDeletionBlocksRewriteFailures))
+
+	// The request is not processed, and the block is checked again in the next run.
+	assert.False(t, req.Processed())
+	assert.Equal(t, 1, req.PendingBlocks)
+	assert.Zero(t, req.LastCheckTime)
+
+	require.NoError(t, c.processSeriesDeletionRequests(ctx, userID, userBucket, reqs, log.NewNopLogger()))
+	assert.Equal(t, float64(2), testutil.ToFloat64(c.seriesDeletionBlocksRewriteFailures))
+	assert.False(t, req.Processed())
+}
+
+func TestMultitenantCompactor_processSeriesDeletionRequests_ProcessedRequestsTTL(t *testing.T) {
+	const userID = "user"
+
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+	c, _, _, _, _ := prepareWithConfigProvider(t, prepareConfig(t), bkt, newMockConfigProvider())
+	c.bucketClient = bkt
+	c.storageCfg.SeriesDeletionSyncInterval = time.Minute
+	c.storageCfg.SeriesDeletionProcessedRequestsTTL = 24 * time.Hour
+	c.storageCfg.TSDB.BlockRanges = mimir_tsdb.DurationList{2 * time.Hour}
+	userBucket := bucket.NewUserBucketClient(userID, bkt, c.cfgProvider)
+
+	now := time.Now()
+	expiredReq, err := mimir_tsdb.NewSeriesDeletionRequest([]string{"metric"}, 0, 10, now.Add(-72*time.Hour))
+	require.NoError(t, err)
+	expiredReq.ProcessedTime = util.UnixSecondsFromTime(now.Add(-25 * time.Hour))
+	recentReq, err := mimir_tsdb.NewSeriesDeletionRequest([]string{"metric"}, 0, 10, now.Add(-72*time.Hour))
+	require.NoError(t, err)
+	recentReq.ProcessedTime = util.UnixSecondsFromTime(now.Add(-time.Hour))
+
+	reqs := mimir_tsdb.SeriesDeletionRequests{expiredReq, recentReq}
+	for _, req := range reqs {
+		require.NoError(t, mimir_tsdb.WriteSeriesDeletionRequest(ctx, bkt, userID, c.cfgProvider, req))
+	}
+
+	require.NoError(t, c.processSeriesDeletionRequests(ctx, userID, userBucket, reqs, log.NewNopLogger()))
+	assert.Equal(t, float64(1), testutil.ToFloat64(c.seriesDeletionRequestsDeleted))
+
+	stored, err := mimir_tsdb.ReadSeriesDeletionRequests(ctx, bkt, userID, log.NewNopLogger())
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	assert.Equal(t, recentReq.ID, stored[0].ID)
+}
+
+func TestMultitenantCompactor_rewriteBlockForSeriesDeletion(t *testing.T) {
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+	userBkt := bucket.NewUserBucketClient("user", bkt, nil)
+
+	cfg := prepareConfig(t)
+	c, _, _, _, _ := prepare(t, cfg, bkt)
+	var err error
+	c.blocksCompactor, _, err = splitAndMergeCompactorFactory(ctx, cfg, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+
+	// Creates series with series_id from 0 to 4.
+	blockID := createTSDBBlock(t, bkt, "user", 0, 100, 4, map[string]string{"a": "1"})
+	meta, err := block.DownloadMeta(ctx, log.NewNopLogger(), userBkt, blockID)
+	require.NoError(t, err)
+
+	t.Run("block without series to delete", func(t *testing.T) {
+		req, err := mimir_tsdb.NewSeriesDeletionRequest([]string{`{series_id="10"}`}, 0, 100, time.Now())
+		require.NoError(t, err)
+
+		rewritten, err := c.rewriteBlockForSeriesDeletion(ctx, userBkt, &meta, mimir_tsdb.SeriesDeletionRequests{req}, log.NewNopLogger())
+		require.NoError(t, err)
+		assert.False(t, rewritten)
+	})
+
+	t.Run("block with series to delete", func(t *testing.T) {
+		req, err := mimir_tsdb.NewSeriesDeletionRequest([]string{`{series_id="1"}`, `{series_id="3"}`}, 0, 100, time.Now())
+		require.NoError(t, err)
+
+		rewritten, err := c.rewriteBlockForSeriesDeletion(ctx, userBkt, &meta, mimir_tsdb.SeriesDeletionRequests{req}, log.NewNopLogger())
+		require.NoError(t, err)
+		assert.True(t, rewritten)
+		assert.Equal(t, float64(1), testutil.ToFloat64(c.seriesDeletionBlocksRewritten))
+
+		// The original block has been marked for deletion.
+		exists, err := userBkt.Exists(ctx, filepath.Join(blockID.String(), block.DeletionMarkFilename))
+		require.NoError(t, err)
+		assert.True(t, exists)
+
+		// Find the rewritten block.
+		var newID ulid.ULID
+		require.NoError(t, userBkt.Iter(ctx, "", func(name string) error {
+			if id, ok := block.IsBlockDir(strings.TrimSuffix(name, "/")); ok && id != blockID {
+				newID = id
+			}
+			return nil
+		}))
+		require.NotEqual(t, ulid.ULID{}, newID)
+
+		newMeta, err := block.DownloadMeta(ctx, log.NewNopLogger(), userBkt, newID)
+		require.NoError(t, err)
+		assert.Equal(t, meta.Thanos.Labels, newMeta.Thanos.Labels)
+		assert.Equal(t, meta.Compaction.Level, newMeta.Compaction.Level)
+		assert.Equal(t, meta.Compaction.Sources, newMeta.Compaction.Sources)
+		assert.Equal(t, uint64(3), newMeta.Stats.NumSeries)
+
+		dir := t.TempDir()
+		require.NoError(t, block.Download(ctx, log.NewNopLogger(), userBkt, newID, filepath.Join(dir, newID.String())))
+		b, err := tsdb.OpenBlock(log.NewNopLogger(), filepath.Join(dir, newID.String()), nil)
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, b.Close()) })
+
+		q, err := tsdb.NewBlockQuerier(b, 0, 100)
+		require.NoError(t, err)
+		values, _, err := q.LabelValues(ctx, "series_id", nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"0", "2", "4"}, values)
+		require.NoError(t, q.Close())
+	})
+}
+
+func TestMultitenantCompactor_rewriteBlockForSeriesDeletion_DownsampledBlock(t *testing.T) {
+	const userID = "user"
+
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+
+	cfgProvider := newMockConfigProvider()
+	cfgProvider.downsampling5mAfter[userID] = 24 * time.Hour
+	c, _, _, _, _ := prepareWithConfigProvider(t, prepareConfig(t), bkt, cfgProvider)
+	userBkt := bucket.NewUserBucketClient(userID, bkt, cfgProvider)
+
+	// Creates series with series_id from 0 to 3, and downsamples them to 5m.
+	rawID := createTSDBBlock(t, bkt, userID, 0, 2*time.Hour.Milliseconds(), 4, nil)
+	require.NoError(t, c.downsampleUserBlocks(ctx, userID, userBkt, log.NewNopLogger()))
+
+	var meta *block.Meta
+	require.NoError(t, userBkt.Iter(ctx, "", func(name string) error {
+		id, ok := block.IsBlockDir(strings.TrimSuffix(name, "/"))
+		if !ok || id == rawID {
+			return nil
+		}
+		m, err := block.DownloadMeta(ctx, log.NewNopLogger(), userBkt, id)
+		meta = &m
+		return err
+	}))
+	require.NotNil(t, meta)
+	require.Equal(t, downsample.ResLevel1, meta.Thanos.Downsample.Resolution)
+
+	req, err := mimir_tsdb.NewSeriesDeletionRequest([]string{`{series_id="1"}`}, 0, 2*time.Hour.Milliseconds(), time.Now())
+	require.NoError(t, err)
+
+	rewritten, err := c.rewriteBlockForSeriesDeletion(ctx, userBkt, meta, mimir_tsdb.SeriesDeletionRequests{req}, log.NewNopLogger())
+	require.NoError(t, err)
+	assert.True(t, rewritten)
+
+	// The downsampled block is replaced by a rewritten one, without the deleted series.
+	exists, err := userBkt.Exists(ctx, filepath.Join(meta.ULID.String(), block.DeletionMarkFilename))
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	var newMeta *block.Meta
+	require.NoError(t, userBkt.Iter(ctx, "", func(name string) error {
+		id, ok := block.IsBlockDir(strings.TrimSuffix(name, "/"))
+		if !ok || id == rawID || id == meta.ULID {
+			return nil
+		}
+		m, err := block.DownloadMeta(ctx, log.NewNopLogger(), userBkt, id)
+		newMeta = &m
+		return err
+	}))
+	require.NotNil(t, newMeta)
+	assert.Equal(t, downsample.ResLevel1, newMeta.Thanos.Downsample.Resolution)
+	assert.Equal(t, meta.Compaction.Sources, newMeta.Compaction.Sources)
+	assert.Equal(t, uint64(3), newMeta.Stats.NumSeries)
+	assert.Equal(t, meta.Stats.NumChunks*3/4, newMeta.Stats.NumChunks)
+
+	dir := filepath.Join(t.TempDir(), newMeta.ULID.String())
+	require.NoError(t, block.Download(ctx, log.NewNopLogger(), userBkt, newMeta.ULID, dir))
+	b, err := tsdb.OpenBlock(log.NewNopLogger(), dir, downsample.NewPool())
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, b.Close()) })
+
+	indexr, err := b.Index()
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, indexr.Close()) })
+	chunkr, err := b.Chunks()
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, chunkr.Close()) })
+
+	values, err := indexr.SortedLabelValues(ctx, "series_id")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0", "2", "3"}, values)
+
+	p, err := indexr.Postings(ctx, "series_id", "0")
+	require.NoError(t, err)
+	require.True(t, p.Next())
+	var (
+		builder labels.ScratchBuilder
+		chks    []chunks.Meta
+	)
+	require.NoError(t, indexr.Series(p.At(), &builder, &chks))
+	require.NotEmpty(t, chks)
+	for _, chk := range chks {
+		c, _, err := chunkr.ChunkOrIterable(chk)
+		require.NoError(t, err)
+		assert.IsType(t, &downsample.AggrChunk{}, c)
+	}
+}
+
+func TestApplySeriesDeletionRequestsToBlockDir(t *testing.T) {
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+	userBkt := bucket.NewUserBucketClient("user", bkt, nil)
+	blockID := createTSDBBlock(t, bkt, "user", 0, 100, 2, nil)
+
+	dir := filepath.Join(t.TempDir(), blockID.String())
+	require.NoError(t, block.Download(ctx, log.NewNopLogger(), userBkt, blockID, dir))
+
+	req, err := mimir_tsdb.NewSeriesDeletionRequest([]string{`{series_id="0"}`}, 0, 10, time.Now())
+	require.NoError(t, err)
+	require.NoError(t, applySeriesDeletionRequestsToBlockDir(ctx, log.NewNopLogger(), dir, mimir_tsdb.SeriesDeletionRequests{req}))
+
+	b, err := tsdb.OpenBlock(log.NewNopLogger(), dir, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, b.Close()) })
+	assert.Equal(t, uint64(1), b.Meta().Stats.NumTombstones)
+
+	q, err := tsdb.NewBlockQuerier(b, 0, 100)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, q.Close()) })
+
+	set := q.Select(ctx, false, nil, labels.MustNewMatcher(labels.MatchRegexp, "series_id", ".+"))
+	var series []string
+	for set.Next() {
+		series = append(series, set.At().Labels().Get("series_id"))
+	}
+	require.NoError(t, set.Err())
+	assert.Equal(t, []string{"1"}, series)
+}
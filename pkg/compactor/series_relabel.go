@@ -0,0 +1,555 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"context"
+	"crypto/rand"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/prometheus/prometheus/tsdb/index"
+	"github.com/prometheus/prometheus/tsdb/tombstones"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/storage/sharding"
+	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storage/tsdb/downsample"
+)
+
+// processSeriesRelabeling rewrites the blocks of the tenant which are not compacted anymore, because they're
+// downsampled or span the largest compaction range, and to which the relabel configs haven't been applied yet.
+// The other blocks are relabeled by the compaction jobs.
+func (c *MultitenantCompactor) processSeriesRelabeling(ctx context.Context, userID string, userBucket objstore.InstrumentedBucket, cfgs []*relabel.Config, logger log.Logger) error {
+	ranges := tenantBlockRanges(c.compactorCfg.BlockRanges, c.cfgProvider, userID)
+	if len(ranges) == 0 {
+		return nil
+	}
+	largestRange := ranges[len(ranges)-1].Milliseconds()
+
+	metas, err := c.fetchBlocksToRewrite(ctx, userBucket, logger)
+	if err != nil {
+		return err
+	}
+
+	applied := block.NewRelabelConfigs(cfgs)
+	var pending []*block.Meta
+	for _, meta := range metas {
+		notCompacted := meta.Thanos.Downsample.Resolution > 0 || meta.MaxTime-meta.MinTime >= largestRange
+		if notCompacted && !meta.Thanos.RelabelsApplied(applied) {
+			pending = append(pending, meta)
+		}
+	}
+
+	// The blocks which failed to be rewritten are still not relabeled, so they're rewritten in the next run.
+	failed, err := c.rewriteBlocks(ctx, pending, "series relabeling", c.seriesRelabelBlocksRewriteFailures, logger, func(meta *block.Meta) error {
+		return c.rewriteBlockForSeriesRelabeling(ctx, userBucket, meta, cfgs, logger)
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(pending) > 0 {
+		level.Info(logger).Log("msg", "applied relabel configs to the blocks", "rewritten_blocks", len(pending)-len(failed), "failed_blocks", len(failed))
+	}
+	return nil
+}
+
+// rewriteBlockForSeriesRelabeling downloads the block, uploads it with a new ID and the relabel configs applied to
+// its series, and marks the original block for deletion. Relabeling changes the compactor shard of the series, so
+// the blocks of a compactor shard are split again into a block per shard, which get merged by the next compactions.
+func (c *MultitenantCompactor) rewriteBlockForSeriesRelabeling(ctx context.Context, userBucket objstore.Bucket, meta *block.Meta, cfgs []*relabel.Config, logger log.Logger) error {
+	logger = log.With(logger, "block", meta.ULID)
+
+	// The rewritten block gets the metadata of the original one, with the applied relabel configs.
+	relabeledMeta := *meta
+	relabeledMeta.Thanos.Rewrites = appendRelabelRewrite(meta.Thanos.Rewrites, cfgs)
+
+	shardCount := uint64(1)
+	if shardID, ok := meta.Thanos.Labels[mimir_tsdb.CompactorShardIDExternalLabel]; ok {
+		var err error
+		if _, shardCount, err = sharding.ParseShardIDLabelValue(shardID); err != nil {
+			return errors.Wrapf(err, "parse compactor shard ID of block %s", meta.ULID)
+		}
+	}
+
+	rewritten, err := c.rewriteBlock(ctx, userBucket, &relabeledMeta, "series relabeling", c.seriesRelabelBlocksMarkedForDeletion, logger, func(bdir, dest string) ([]ulid.ULID, error) {
+		if shardCount <= 1 {
+			newMeta := relabeledMeta
+			newMeta.ULID = ulid.MustNew(ulid.Now(), rand.Reader)
+			if err := writeBlockWithRewrittenSeries(ctx, logger, bdir, dest, &newMeta, relabelFunc(cfgs)); err != nil {
+				return nil, err
+			}
+			return []ulid.ULID{newMeta.ULID}, nil
+		}
+		return writeShardedBlocksWithRewrittenSeries(ctx, logger, bdir, dest, &relabeledMeta, shardCount, relabelFunc(cfgs))
+	})
+	if rewritten {
+		c.seriesRelabelBlocksRewritten.Inc()
+	}
+	return err
+}
+
+// writeShardedBlocksWithRewrittenSeries writes the block in bdir to a block per compactor shard in dest, like
+// writeBlockWithRewrittenSeries, with the series of each shard according to their rewritten labels. It returns the
+// IDs of the written blocks, skipping the shards without series.
+func writeShardedBlocksWithRewrittenSeries(ctx context.Context, logger log.Logger, bdir, dest string, meta *block.Meta, shardCount uint64, rewrite func(labels.Labels) (labels.Labels, error)) ([]ulid.ULID, error) {
+	ids := []ulid.ULID{}
+	for shardIndex := uint64(0); shardIndex < shardCount; shardIndex++ {
+		newMeta := *meta
+		newMeta.ULID = ulid.MustNew(ulid.Now(), rand.Reader)
+		newMeta.Thanos.Labels = maps.Clone(meta.Thanos.Labels)
+		newMeta.Thanos.Labels[mimir_tsdb.CompactorShardIDExternalLabel] = sharding.FormatShardIDLabelValue(shardIndex, shardCount)
+
+		// The series are sharded like the split compaction does.
+		if err := writeBlockWithRewrittenSeries(ctx, logger, bdir, dest, &newMeta, func(lset labels.Labels) (labels.Labels, error) {
+			lset, err := rewrite(lset)
+			if err != nil || lset.IsEmpty() || labels.StableHash(lset)%shardCount == shardIndex {
+				return lset, err
+			}
+			return labels.EmptyLabels(), nil
+		}); err != nil {
+			return nil, err
+		}
+
+		blockDir := filepath.Join(dest, newMeta.ULID.String())
+		written, err := block.ReadMetaFromDir(blockDir)
+		if err != nil {
+			return nil, errors.Wrapf(err, "read meta of block %s", newMeta.ULID)
+		}
+		if written.Stats.NumSeries == 0 {
+			if err := os.RemoveAll(blockDir); err != nil {
+				return nil, errors.Wrapf(err, "remove empty block %s", newMeta.ULID)
+			}
+			continue
+		}
+		ids = append(ids, newMeta.ULID)
+	}
+	return ids, nil
+}
+
+// relabelBlockDir rewrites the block in bdir with the relabel configs applied to its series, unless they've already
+// been applied. It returns the rewrites applied to the block. Relabeling can change the compactor shard of the series,
+// so the blocks of a compactor shard, which are the sources of the merge jobs, are not relabeled: their series are
+// relabeled and split again by shard once the blocks are not compacted anymore.
+func relabelBlockDir(ctx context.Context, logger log.Logger, bdir string, meta *block.Meta, cfgs []*relabel.Config) ([]block.Rewrite, error) {
+	if len(cfgs) == 0 || meta.Thanos.RelabelsApplied(block.NewRelabelConfigs(cfgs)) {
+		return meta.Thanos.Rewrites, nil
+	}
+	if _, sharded := meta.Thanos.Labels[mimir_tsdb.CompactorShardIDExternalLabel]; sharded {
+		return meta.Thanos.Rewrites, nil
+	}
+
+	newMeta := *meta
+	newMeta.Thanos.Rewrites = appendRelabelRewrite(meta.Thanos.Rewrites, cfgs)
+
+	dest := bdir + ".relabeled"
+	defer func() {
+		if err := os.RemoveAll(dest); err != nil {
+			level.Warn(logger).Log("msg", "failed to remove relabeled block directory", "dir", dest, "err", err)
+		}
+	}()
+
+	if err := writeBlockWithRewrittenSeries(ctx, logger, bdir, dest, &newMeta, relabelFunc(cfgs)); err != nil {
+		return nil, errors.Wrapf(err, "relabel block %s", meta.ULID)
+	}
+
+	// The relabeled block replaces the original one, with the same ID.
+	if err := os.RemoveAll(bdir); err != nil {
+		return nil, errors.Wrapf(err, "remove block %s", meta.ULID)
+	}
+	if err := os.Rename(filepath.Join(dest, meta.ULID.String()), bdir); err != nil {
+		return nil, errors.Wrapf(err, "move relabeled block %s", meta.ULID)
+	}
+
+	level.Info(logger).Log("msg", "relabeled series of block", "block", meta.ULID, "series", newMeta.Stats.NumSeries)
+	return newMeta.Thanos.Rewrites, nil
+}
+
+func appendRelabelRewrite(rewrites []block.Rewrite, cfgs []*relabel.Config) []block.Rewrite {
+	return append(slices.Clone(rewrites), block.Rewrite{RelabelsApplied: block.NewRelabelConfigs(cfgs)})
+}
+
+// relabelFunc returns the function applying the relabel configs to the series labels. The series are dropped if
+// the relabel configs drop them, or all their labels.
+func relabelFunc(cfgs []*relabel.Config) func(labels.Labels) (labels.Labels, error) {
+	return func(lset labels.Labels) (labels.Labels, error) {
+		lset, keep := relabel.Process(lset, cfgs...)
+		if !keep {
+			return labels.EmptyLabels(), nil
+		}
+		return lset, nil
+	}
+}
+
+// commonRewrites returns the rewrites applied to all the source blocks, in the order of the first block.
+func commonRewrites(sourceRewrites [][]block.Rewrite) []block.Rewrite {
+	if len(sourceRewrites) == 0 {
+		return nil
+	}
+
+	var result []block.Rewrite
+	for _, r := range sourceRewrites[0] {
+		common := true
+		for _, rewrites := range sourceRewrites[1:] {
+			if !slices.ContainsFunc(rewrites, r.Equal) {
+				common = false
+				break
+			}
+		}
+		if common {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+type rewrittenSeries struct {
+	lset labels.Labels
+	chks []chunks.Meta
+
+	// intervals are the deleted intervals of the original series.
+	intervals tombstones.Intervals
+}
+
+// writeBlockWithRewrittenSeries writes the block in bdir to dest, with the meta and the series labels rewritten by
+// the rewrite function. The series for which it returns empty labels are dropped, and the series which have the
+// same labels after the rewrite are merged. The deleted samples of the block are removed.
+func writeBlockWithRewrittenSeries(ctx context.Context, logger log.Logger, bdir, dest string, meta *block.Meta, rewrite func(labels.Labels) (labels.Labels, error)) (returnErr error) {
+	// The pool supports the chunks of the downsampled blocks too.
+	b, err := tsdb.OpenBlock(logger, bdir, downsample.NewPool())
+	if err != nil {
+		return errors.Wrapf(err, "open block %s", bdir)
+	}
+	defer func() {
+		if err := b.Close(); err != nil && returnErr == nil {
+			returnErr = errors.Wrap(err, "close block")
+		}
+	}()
+
+	indexr, err := b.Index()
+	if err != nil {
+		return errors.Wrap(err, "open index reader")
+	}
+	defer func() {
+		if err := indexr.Close(); err != nil && returnErr == nil {
+			returnErr = errors.Wrap(err, "close index reader")
+		}
+	}()
+
+	chunkr, err := b.Chunks()
+	if err != nil {
+		return errors.Wrap(err, "open chunk reader")
+	}
+	defer func() {
+		if err := chunkr.Close(); err != nil && returnErr == nil {
+			returnErr = errors.Wrap(err, "close chunk reader")
+		}
+	}()
+
+	tombsr, err := b.Tombstones()
+	if err != nil {
+		return errors.Wrap(err, "open tombstones reader")
+	}
+	defer func() {
+		if err := tombsr.Close(); err != nil && returnErr == nil {
+			returnErr = errors.Wrap(err, "close tombstones reader")
+		}
+	}()
+
+	// Rewriting the labels can change the order of the series, so all the series are sorted before writing them. Only
+	// the chunk references are sorted, the chunk data is read when writing the series.
+	blockDir := filepath.Join(dest, meta.ULID.String())
+	sortDir := blockDir + ".sort"
+	if err := os.MkdirAll(sortDir, 0o750); err != nil {
+		return errors.Wrap(err, "create series sort directory")
+	}
+	defer func() {
+		if err := os.RemoveAll(sortDir); err != nil {
+			level.Warn(logger).Log("msg", "failed to remove series sort directory", "dir", sortDir, "err", err)
+		}
+	}()
+
+	sorter := newRewrittenSeriesSorter(sortDir, rewrittenSeriesSortBatchSize)
+	symbols, err := readRewrittenSeries(ctx, indexr, tombsr, rewrite, sorter)
+	if err != nil {
+		return err
+	}
+	series, err := sorter.iterator()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := series.Close(); err != nil && returnErr == nil {
+			returnErr = errors.Wrap(err, "close sorted series")
+		}
+	}()
+
+	chunkw, err := chunks.NewWriter(filepath.Join(blockDir, block.ChunksDirname))
+	if err != nil {
+		return errors.Wrap(err, "create chunk writer")
+	}
+	indexw, err := index.NewWriter(ctx, filepath.Join(blockDir, block.IndexFilename))
+	if err != nil {
+		_ = chunkw.Close()
+		return errors.Wrap(err, "create index writer")
+	}
+
+	stats, err := writeRewrittenSeries(ctx, chunkr, chunkw, indexw, series, symbols, meta.Thanos.Downsample.Resolution)
+	if closeErr := chunkw.Close(); closeErr != nil && err == nil {
+		err = errors.Wrap(closeErr, "close chunk writer")
+	}
+	if closeErr := indexw.Close(); closeErr != nil && err == nil {
+		err = errors.Wrap(closeErr, "close index writer")
+	}
+	if err != nil {
+		return err
+	}
+
+	exemplars, err := block.ReadExemplarsFromDir(bdir)
+	if err != nil {
+		return err
+	}
+	if exemplars.Len() > 0 {
+		rewritten := &block.Exemplars{}
+		for _, s := range exemplars.Series {
+			if s.Labels, err = rewrite(s.Labels); err != nil {
+				return err
+			}
+			if !s.Labels.IsEmpty() {
+				rewritten.Series = append(rewritten.Series, s)
+			}
+		}
+		if err := block.WriteExemplarsFile(blockDir, block.MergeExemplars(rewritten)); err != nil {
+			return err
+		}
+	}
+
+	metadata, err := os.ReadFile(filepath.Join(bdir, block.MetricsMetadataFilename))
+	if err == nil {
+		err = os.WriteFile(filepath.Join(blockDir, block.MetricsMetadataFilename), metadata, 0o644)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "copy metric metadata")
+	}
+
+	newMeta := *meta
+	newMeta.Stats.NumSeries = stats.NumSeries
+	newMeta.Stats.NumChunks = stats.NumChunks
+	newMeta.Stats.NumSamples = stats.NumSamples
+	newMeta.Stats.NumTombstones = 0
+	newMeta.Thanos.Files = nil
+	newMeta.Thanos.SegmentFiles = block.GetSegmentFiles(blockDir)
+	if err := newMeta.WriteToDir(logger, blockDir); err != nil {
+		return errors.Wrap(err, "write meta")
+	}
+
+	return errors.Wrapf(block.VerifyBlock(ctx, logger, blockDir, newMeta.MinTime, newMeta.MaxTime, false), "invalid rewritten block %s", newMeta.ULID)
+}
+
+// readRewrittenSeries adds all the series of the index with the labels rewritten and their deleted intervals to the
+// sorter, and returns the sorted symbols of the rewritten series.
+func readRewrittenSeries(ctx context.Context, indexr tsdb.IndexReader, tombsr tombstones.Reader, rewrite func(labels.Labels) (labels.Labels, error), sorter *rewrittenSeriesSorter) ([]string, error) {
+	k, v := index.AllPostingsKey()
+	postings, err := indexr.Postings(ctx, k, v)
+	if err != nil {
+		return nil, errors.Wrap(err, "get all postings")
+	}
+
+	var (
+		symbols = map[string]struct{}{}
+		builder labels.ScratchBuilder
+	)
+	for postings.Next() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		ref := postings.At()
+		var chks []chunks.Meta
+		if err := indexr.Series(ref, &builder, &chks); err != nil {
+			return nil, errors.Wrapf(err, "get series %d", ref)
+		}
+		lset, err := rewrite(builder.Labels())
+		if err != nil {
+			return nil, err
+		}
+		if lset.IsEmpty() || len(chks) == 0 {
+			continue
+		}
+
+		intervals, err := tombsr.Get(ref)
+		if err != nil {
+			return nil, errors.Wrapf(err, "get tombstones of series %d", ref)
+		}
+
+		lset.Range(func(l labels.Label) {
+			symbols[l.Name] = struct{}{}
+			symbols[l.Value] = struct{}{}
+		})
+		if err := sorter.add(rewrittenSeries{lset: lset, chks: chks, intervals: intervals}); err != nil {
+			return nil, err
+		}
+	}
+	if err := postings.Err(); err != nil {
+		return nil, errors.Wrap(err, "iterate postings")
+	}
+
+	sortedSymbols := make([]string, 0, len(symbols))
+	for s := range symbols {
+		sortedSymbols = append(sortedSymbols, s)
+	}
+	slices.Sort(sortedSymbols)
+
+	return sortedSymbols, nil
+}
+
+// writeRewrittenSeries writes the sorted series, merging the adjacent series with the same labels.
+func writeRewrittenSeries(ctx context.Context, chunkr tsdb.ChunkReader, chunkw *chunks.Writer, indexw *index.Writer, series *rewrittenSeriesIterator, symbols []string, resolution int64) (tsdb.BlockStats, error) {
+	var stats tsdb.BlockStats
+
+	for _, s := range symbols {
+		if err := indexw.AddSymbol(s); err != nil {
+			return stats, errors.Wrap(err, "add symbol")
+		}
+	}
+
+	var (
+		ref   storage.SeriesRef
+		group []rewrittenSeries
+	)
+	writeGroup := func() error {
+		lset := group[0].lset
+		chks, err := readRewrittenSeriesChunks(chunkr, group, resolution)
+		if err != nil {
+			return errors.Wrapf(err, "read chunks of series %s", lset)
+		}
+
+		// The series whose samples have all been deleted are dropped.
+		if len(chks) == 0 {
+			return nil
+		}
+
+		// The chunks are written again, because the index requires the chunk references to increase with the series.
+		if err := chunkw.WriteChunks(chks...); err != nil {
+			return errors.Wrap(err, "write chunks")
+		}
+		if err := indexw.AddSeries(ref, lset, chks...); err != nil {
+			return errors.Wrap(err, "add series")
+		}
+		ref++
+
+		stats.NumSeries++
+		stats.NumChunks += uint64(len(chks))
+		for _, chk := range chks {
+			stats.NumSamples += uint64(chk.Chunk.NumSamples())
+		}
+		return nil
+	}
+
+	for series.Next() {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+
+		s := series.At()
+		if len(group) > 0 && !labels.Equal(group[0].lset, s.lset) {
+			if err := writeGroup(); err != nil {
+				return stats, err
+			}
+			group = group[:0]
+		}
+		group = append(group, s)
+	}
+	if err := series.Err(); err != nil {
+		return stats, err
+	}
+	if len(group) > 0 {
+		if err := writeGroup(); err != nil {
+			return stats, err
+		}
+	}
+	return stats, nil
+}
+
+// readRewrittenSeriesChunks reads the chunks of the series with the same rewritten labels. The deleted samples are
+// removed, and the overlapping chunks of the merged series are compacted. The aggregated chunks of the series of
+// downsampled blocks, whose resolution is greater than zero, are merged by windows.
+func readRewrittenSeriesChunks(chunkr tsdb.ChunkReader, series []rewrittenSeries, resolution int64) ([]chunks.Meta, error) {
+	var (
+		toMerge     = make([]storage.ChunkSeries, 0, len(series))
+		aggrChunks  = make([][]chunks.Meta, 0, len(series))
+		aggrDeleted = make([]tombstones.Intervals, 0, len(series))
+	)
+	for _, s := range series {
+		chks := make([]chunks.Meta, 0, len(s.chks))
+		for _, meta := range s.chks {
+			chk, iterable, err := chunkr.ChunkOrIterable(meta)
+			if err != nil {
+				return nil, err
+			}
+			if iterable != nil {
+				return nil, errors.New("unexpected iterable chunk")
+			}
+			chks = append(chks, chunks.Meta{Chunk: chk, MinTime: meta.MinTime, MaxTime: meta.MaxTime})
+		}
+
+		// Nothing to merge nor to delete: the chunks are written as they are.
+		if len(series) == 1 && len(s.intervals) == 0 {
+			return chks, nil
+		}
+		if resolution > 0 {
+			aggrChunks = append(aggrChunks, chks)
+			aggrDeleted = append(aggrDeleted, s.intervals)
+			continue
+		}
+		toMerge = append(toMerge, rewrittenChunkSeries(s.lset, chks, s.intervals))
+	}
+
+	if resolution > 0 {
+		return downsample.MergeAggrChunks(aggrChunks, aggrDeleted, resolution)
+	}
+
+	var chks []chunks.Meta
+	it := storage.NewCompactingChunkSeriesMerger(storage.ChainedSeriesMerge)(toMerge...).Iterator(nil)
+	for it.Next() {
+		chks = append(chks, it.At())
+	}
+	return chks, it.Err()
+}
+
+// rewrittenChunkSeries returns the series with the chunks, re-encoded without the deleted samples if there's any.
+func rewrittenChunkSeries(lset labels.Labels, chks []chunks.Meta, intervals tombstones.Intervals) storage.ChunkSeries {
+	if len(intervals) == 0 {
+		return &storage.ChunkSeriesEntry{
+			Lset: lset,
+			ChunkIteratorFn: func(chunks.Iterator) chunks.Iterator {
+				return storage.NewListChunkSeriesIterator(chks...)
+			},
+		}
+	}
+
+	return storage.NewSeriesToChunkEncoder(&storage.SeriesEntry{
+		Lset: lset,
+		SampleIteratorFn: func(it chunkenc.Iterator) chunkenc.Iterator {
+			iterators := make([]chunkenc.Iterator, 0, len(chks))
+			for _, chk := range chks {
+				iterators = append(iterators, &tsdb.DeletedIterator{Iter: chk.Chunk.Iterator(nil), Intervals: intervals})
+			}
+			return storage.ChainSampleIteratorFromIterators(it, iterators)
+		},
+	})
+}
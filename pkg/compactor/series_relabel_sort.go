@@ -0,0 +1,286 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/prometheus/prometheus/tsdb/encoding"
+	"github.com/prometheus/prometheus/tsdb/tombstones"
+)
+
+// rewrittenSeriesSortBatchSize is the number of rewritten series sorted in memory before being spilled to disk.
+const rewrittenSeriesSortBatchSize = 100_000
+
+// rewrittenSeriesSorter sorts the rewritten series by labels, keeping the series with the same labels in the order
+// they've been added. The series are sorted in memory by batches, which are spilled to files in dir once full, and
+// the sorted batches are merged when iterating the series, so that the memory doesn't grow with the block size.
+type rewrittenSeriesSorter struct {
+	dir       string
+	batchSize int
+
+	batch []rewrittenSeries
+	files []string
+}
+
+func newRewrittenSeriesSorter(dir string, batchSize int) *rewrittenSeriesSorter {
+	return &rewrittenSeriesSorter{dir: dir, batchSize: batchSize}
+}
+
+func (s *rewrittenSeriesSorter) add(series rewrittenSeries) error {
+	s.batch = append(s.batch, series)
+	if len(s.batch) < s.batchSize {
+		return nil
+	}
+	return s.spill()
+}
+
+// spill sorts the current batch and writes it to a new file.
+func (s *rewrittenSeriesSorter) spill() (returnErr error) {
+	sortRewrittenSeries(s.batch)
+
+	name := filepath.Join(s.dir, fmt.Sprintf("series-%06d", len(s.files)))
+	f, err := os.Create(name)
+	if err != nil {
+		return errors.Wrap(err, "create sorted series file")
+	}
+	defer func() {
+		if err := f.Close(); err != nil && returnErr == nil {
+			returnErr = errors.Wrap(err, "close sorted series file")
+		}
+	}()
+
+	var (
+		w   = bufio.NewWriter(f)
+		buf encoding.Encbuf
+	)
+	for _, series := range s.batch {
+		buf.Reset()
+		encodeRewrittenSeries(&buf, series)
+		if _, err := w.Write(binary.AppendUvarint(nil, uint64(buf.Len()))); err != nil {
+			return errors.Wrap(err, "write sorted series file")
+		}
+		if _, err := w.Write(buf.Get()); err != nil {
+			return errors.Wrap(err, "write sorted series file")
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return errors.Wrap(err, "write sorted series file")
+	}
+
+	s.files = append(s.files, name)
+	s.batch = s.batch[:0]
+	return nil
+}
+
+// iterator returns an iterator over all the series added, sorted by labels. The series can't be added afterwards.
+func (s *rewrittenSeriesSorter) iterator() (*rewrittenSeriesIterator, error) {
+	// All the series fit in a single batch: there's no need to spill them.
+	if len(s.files) == 0 {
+		sortRewrittenSeries(s.batch)
+		return &rewrittenSeriesIterator{batch: s.batch}, nil
+	}
+
+	if len(s.batch) > 0 {
+		if err := s.spill(); err != nil {
+			return nil, err
+		}
+	}
+
+	it := &rewrittenSeriesIterator{}
+	for idx, name := range s.files {
+		f, err := os.Open(name)
+		if err != nil {
+			_ = it.Close()
+			return nil, errors.Wrap(err, "open sorted series file")
+		}
+		r := &rewrittenSeriesFileReader{idx: idx, file: f, reader: bufio.NewReader(f)}
+		it.readers = append(it.readers, r)
+		if err := it.push(r); err != nil {
+			_ = it.Close()
+			return nil, err
+		}
+	}
+	return it, nil
+}
+
+// rewrittenSeriesIterator iterates the sorted series, either of a single batch in memory or by merging the sorted
+// series files.
+type rewrittenSeriesIterator struct {
+	batch []rewrittenSeries
+	idx   int
+
+	readers []*rewrittenSeriesFileReader
+	heap    rewrittenSeriesHeap
+	cur     rewrittenSeries
+	err     error
+}
+
+func (it *rewrittenSeriesIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if it.readers == nil {
+		if it.idx >= len(it.batch) {
+			return false
+		}
+		it.cur = it.batch[it.idx]
+		it.idx++
+		return true
+	}
+
+	if len(it.heap) == 0 {
+		return false
+	}
+	r := heap.Pop(&it.heap).(*rewrittenSeriesFileReader)
+	it.cur = r.cur
+	if err := it.push(r); err != nil {
+		it.err = err
+		return false
+	}
+	return true
+}
+
+func (it *rewrittenSeriesIterator) At() rewrittenSeries {
+	return it.cur
+}
+
+func (it *rewrittenSeriesIterator) Err() error {
+	return it.err
+}
+
+func (it *rewrittenSeriesIterator) Close() error {
+	var lastErr error
+	for _, r := range it.readers {
+		if err := r.file.Close(); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// push reads the next series of the file, and pushes the reader to the heap unless the file is exhausted.
+func (it *rewrittenSeriesIterator) push(r *rewrittenSeriesFileReader) error {
+	ok, err := r.next()
+	if err != nil {
+		return err
+	}
+	if ok {
+		heap.Push(&it.heap, r)
+	}
+	return nil
+}
+
+type rewrittenSeriesFileReader struct {
+	// idx is the index of the file, used to keep the order of the series with the same labels.
+	idx    int
+	file   *os.File
+	reader *bufio.Reader
+	buf    []byte
+	cur    rewrittenSeries
+}
+
+func (r *rewrittenSeriesFileReader) next() (bool, error) {
+	n, err := binary.ReadUvarint(r.reader)
+	if errors.Is(err, io.EOF) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrap(err, "read sorted series file")
+	}
+
+	r.buf = slices.Grow(r.buf[:0], int(n))[:n]
+	if _, err := io.ReadFull(r.reader, r.buf); err != nil {
+		return false, errors.Wrap(err, "read sorted series file")
+	}
+	r.cur, err = decodeRewrittenSeries(r.buf)
+	return err == nil, err
+}
+
+type rewrittenSeriesHeap []*rewrittenSeriesFileReader
+
+func (h rewrittenSeriesHeap) Len() int { return len(h) }
+
+func (h rewrittenSeriesHeap) Less(i, j int) bool {
+	if c := labels.Compare(h[i].cur.lset, h[j].cur.lset); c != 0 {
+		return c < 0
+	}
+	return h[i].idx < h[j].idx
+}
+
+func (h rewrittenSeriesHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *rewrittenSeriesHeap) Push(x any) { *h = append(*h, x.(*rewrittenSeriesFileReader)) }
+
+func (h *rewrittenSeriesHeap) Pop() any {
+	old := *h
+	r := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return r
+}
+
+func sortRewrittenSeries(series []rewrittenSeries) {
+	slices.SortStableFunc(series, func(a, b rewrittenSeries) int {
+		return labels.Compare(a.lset, b.lset)
+	})
+}
+
+func encodeRewrittenSeries(buf *encoding.Encbuf, s rewrittenSeries) {
+	buf.PutUvarint(s.lset.Len())
+	s.lset.Range(func(l labels.Label) {
+		buf.PutUvarintStr(l.Name)
+		buf.PutUvarintStr(l.Value)
+	})
+
+	buf.PutUvarint(len(s.chks))
+	for _, chk := range s.chks {
+		buf.PutUvarint64(uint64(chk.Ref))
+		buf.PutVarint64(chk.MinTime)
+		buf.PutVarint64(chk.MaxTime)
+	}
+
+	buf.PutUvarint(len(s.intervals))
+	for _, iv := range s.intervals {
+		buf.PutVarint64(iv.Mint)
+		buf.PutVarint64(iv.Maxt)
+	}
+}
+
+func decodeRewrittenSeries(b []byte) (rewrittenSeries, error) {
+	var (
+		d       = encoding.Decbuf{B: b}
+		builder labels.ScratchBuilder
+		s       rewrittenSeries
+	)
+
+	for n := d.Uvarint(); n > 0 && d.Err() == nil; n-- {
+		name := d.UvarintStr()
+		builder.Add(name, d.UvarintStr())
+	}
+	s.lset = builder.Labels()
+
+	if n := d.Uvarint(); n > 0 {
+		s.chks = make([]chunks.Meta, 0, n)
+		for ; n > 0 && d.Err() == nil; n-- {
+			s.chks = append(s.chks, chunks.Meta{Ref: chunks.ChunkRef(d.Uvarint64()), MinTime: d.Varint64(), MaxTime: d.Varint64()})
+		}
+	}
+
+	if n := d.Uvarint(); n > 0 {
+		s.intervals = make(tombstones.Intervals, 0, n)
+		for ; n > 0 && d.Err() == nil; n-- {
+			s.intervals = append(s.intervals, tombstones.Interval{Mint: d.Varint64(), Maxt: d.Varint64()})
+		}
+	}
+	return s, errors.Wrap(d.Err(), "decode sorted series")
+}
@@ -0,0 +1,49 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/prometheus/prometheus/tsdb/tombstones"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewrittenSeriesSorter(t *testing.T) {
+	series := []rewrittenSeries{
+		{lset: labels.FromStrings("job", "c"), chks: []chunks.Meta{{Ref: 1, MinTime: 0, MaxTime: 10}}},
+		{lset: labels.FromStrings("job", "a"), chks: []chunks.Meta{{Ref: 2, MinTime: 0, MaxTime: 10}}, intervals: tombstones.Intervals{{Mint: 0, Maxt: 5}}},
+		{lset: labels.FromStrings("job", "b"), chks: []chunks.Meta{{Ref: 3, MinTime: 0, MaxTime: 10}, {Ref: 4, MinTime: 11, MaxTime: 20}}},
+		{lset: labels.FromStrings("job", "a"), chks: []chunks.Meta{{Ref: 5, MinTime: -10, MaxTime: -1}}},
+		{lset: labels.FromStrings("job", "c"), chks: []chunks.Meta{{Ref: 6, MinTime: 0, MaxTime: 10}}},
+	}
+	// The series with the same labels keep the order in which they've been added.
+	expected := []rewrittenSeries{series[1], series[3], series[2], series[0], series[4]}
+
+	for _, batchSize := range []int{1, 2, 10} {
+		sorter := newRewrittenSeriesSorter(t.TempDir(), batchSize)
+		for _, s := range series {
+			require.NoError(t, sorter.add(s))
+		}
+
+		it, err := sorter.iterator()
+		require.NoError(t, err)
+
+		var actual []rewrittenSeries
+		for it.Next() {
+			actual = append(actual, it.At())
+		}
+		require.NoError(t, it.Err())
+		require.NoError(t, it.Close())
+
+		require.Len(t, actual, len(expected), "batch size: %d", batchSize)
+		for i := range expected {
+			assert.Equal(t, expected[i].lset, actual[i].lset, "batch size: %d", batchSize)
+			assert.Equal(t, expected[i].chks, actual[i].chks, "batch size: %d", batchSize)
+			assert.Equal(t, expected[i].intervals, actual[i].intervals, "batch size: %d", batchSize)
+		}
+	}
+}
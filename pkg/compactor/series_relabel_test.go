@@ -0,0 +1,412 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"context"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/flagext"
+	"github.com/grafana/dskit/services"
+	"github.com/grafana/dskit/test"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/prometheus/prometheus/tsdb/index"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	"github.com/grafana/mimir/pkg/storage/sharding"
+	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storage/tsdb/downsample"
+)
+
+var testRelabelConfigs = []*relabel.Config{
+	{
+		Action: relabel.LabelDrop,
+		Regex:  relabel.MustNewRegexp("request_id"),
+	},
+	{
+		SourceLabels: []model.LabelName{"job"},
+		Action:       relabel.Drop,
+		Regex:        relabel.MustNewRegexp("dropped"),
+	},
+}
+
+func TestRelabelBlockDir(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	logger := log.NewNopLogger()
+
+	meta, err := block.GenerateBlockFromSpec(dir, []*block.SeriesSpec{
+		{
+			Labels: labels.FromStrings("__name__", "up", "job", "a", "request_id", "1"),
+			Chunks: []chunks.Meta{must(chunks.ChunkFromSamples([]chunks.Sample{newSample(10, 1, nil, nil), newSample(20, 2, nil, nil), newSample(30, 3, nil, nil)}))},
+		},
+		{
+			Labels: labels.FromStrings("__name__", "up", "job", "a", "request_id", "2"),
+			Chunks: []chunks.Meta{must(chunks.ChunkFromSamples([]chunks.Sample{newSample(20, 2, nil, nil), newSample(40, 4, nil, nil)}))},
+		},
+		{
+			Labels: labels.FromStrings("__name__", "up", "job", "b", "request_id", "3"),
+			Chunks: []chunks.Meta{must(chunks.ChunkFromSamples([]chunks.Sample{newSample(10, 1, nil, nil), newSample(20, 2, nil, nil)}))},
+		},
+		{
+			Labels: labels.FromStrings("__name__", "up", "job", "dropped"),
+			Chunks: []chunks.Meta{must(chunks.ChunkFromSamples([]chunks.Sample{newSample(10, 1, nil, nil)}))},
+		},
+		{
+			Labels: labels.FromStrings("__name__", "up", "job", "deleted"),
+			Chunks: []chunks.Meta{must(chunks.ChunkFromSamples([]chunks.Sample{newSample(10, 1, nil, nil)}))},
+		},
+	})
+	require.NoError(t, err)
+	bdir := filepath.Join(dir, meta.ULID.String())
+
+	// The samples deleted by the tombstones are removed, and the series without samples left are dropped.
+	b, err := tsdb.OpenBlock(logger, bdir, nil)
+	require.NoError(t, err)
+	require.NoError(t, b.Delete(ctx, 20, 20, labels.MustNewMatcher(labels.MatchEqual, "job", "b")))
+	require.NoError(t, b.Delete(ctx, 0, 100, labels.MustNewMatcher(labels.MatchEqual, "job", "deleted")))
+	require.NoError(t, b.Close())
+
+	rewrites, err := relabelBlockDir(ctx, logger, bdir, meta, testRelabelConfigs)
+	require.NoError(t, err)
+	expectedRewrites := []block.Rewrite{{RelabelsApplied: block.NewRelabelConfigs(testRelabelConfigs)}}
+	assert.Equal(t, expectedRewrites, rewrites)
+
+	// The series with the same labels after relabeling are merged.
+	assert.Equal(t, map[string][]int64{
+		`{__name__="up", job="a"}`: {10, 20, 30, 40},
+		`{__name__="up", job="b"}`: {10},
+	}, readBlockSamplesTimestamps(t, bdir))
+
+	newMeta, err := block.ReadMetaFromDir(bdir)
+	require.NoError(t, err)
+	assert.Equal(t, meta.ULID, newMeta.ULID)
+	assert.Equal(t, expectedRewrites, newMeta.Thanos.Rewrites)
+	assert.Equal(t, uint64(2), newMeta.Stats.NumSeries)
+	assert.Equal(t, uint64(5), newMeta.Stats.NumSamples)
+
+	// The relabel configs are not applied twice.
+	rewrites, err = relabelBlockDir(ctx, logger, bdir, newMeta, testRelabelConfigs)
+	require.NoError(t, err)
+	assert.Equal(t, expectedRewrites, rewrites)
+
+	// Different relabel configs are applied.
+	otherConfigs := []*relabel.Config{{Action: relabel.LabelDrop, Regex: relabel.MustNewRegexp("job")}}
+	rewrites, err = relabelBlockDir(ctx, logger, bdir, newMeta, otherConfigs)
+	require.NoError(t, err)
+	assert.Equal(t, append(expectedRewrites, block.Rewrite{RelabelsApplied: block.NewRelabelConfigs(otherConfigs)}), rewrites)
+	assert.Equal(t, map[string][]int64{
+		`{__name__="up"}`: {10, 20, 30, 40},
+	}, readBlockSamplesTimestamps(t, bdir))
+}
+
+func TestCommonRewrites(t *testing.T) {
+	first := block.Rewrite{RelabelsApplied: block.NewRelabelConfigs(testRelabelConfigs[:1])}
+	second := block.Rewrite{RelabelsApplied: block.NewRelabelConfigs(testRelabelConfigs)}
+
+	assert.Nil(t, commonRewrites(nil))
+	assert.Nil(t, commonRewrites([][]block.Rewrite{nil, {second}}))
+	assert.Equal(t, []block.Rewrite{first, second}, commonRewrites([][]block.Rewrite{{first, second}, {first, second}}))
+	assert.Equal(t, []block.Rewrite{second}, commonRewrites([][]block.Rewrite{{first, second}, {second}}))
+}
+
+func TestMultitenantCompactor_processSeriesRelabeling(t *testing.T) {
+	ctx := context.Background()
+	bkt := block.BucketWithGlobalMarkers(objstore.NewInMemBucket())
+	userBkt := bucket.NewUserBucketClient("user", bkt, nil)
+	logger := log.NewNopLogger()
+
+	cfgProvider := newMockConfigProvider()
+	cfgProvider.blockRanges["user"] = mimir_tsdb.DurationList{2 * time.Hour}
+
+	cfg := prepareConfig(t)
+	c, _, _, _, _ := prepareWithConfigProvider(t, cfg, bkt, cfgProvider)
+
+	uploadBlock := func(specs []*block.SeriesSpec) ulid.ULID {
+		dir := t.TempDir()
+		meta, err := block.GenerateBlockFromSpec(dir, specs)
+		require.NoError(t, err)
+		require.NoError(t, block.Upload(ctx, logger, userBkt, filepath.Join(dir, meta.ULID.String()), meta))
+		return meta.ULID
+	}
+
+	// The blocks spanning the largest compaction range are not compacted anymore.
+	compacted := uploadBlock([]*block.SeriesSpec{
+		{
+			Labels: labels.FromStrings("__name__", "up", "request_id", "1"),
+			Chunks: []chunks.Meta{must(chunks.ChunkFromSamples([]chunks.Sample{newSample(0, 1, nil, nil), newSample(time.Hour.Milliseconds(), 2, nil, nil)}))},
+		},
+		{
+			Labels: labels.FromStrings("__name__", "up", "request_id", "2"),
+			Chunks: []chunks.Meta{must(chunks.ChunkFromSamples([]chunks.Sample{newSample(2*time.Hour.Milliseconds()-1, 3, nil, nil)}))},
+		},
+	})
+	// The other blocks are relabeled by the compaction jobs.
+	notCompacted := uploadBlock([]*block.SeriesSpec{
+		{
+			Labels: labels.FromStrings("__name__", "up", "request_id", "1"),
+			Chunks: []chunks.Meta{must(chunks.ChunkFromSamples([]chunks.Sample{newSample(0, 1, nil, nil)}))},
+		},
+	})
+
+	require.NoError(t, c.processSeriesRelabeling(ctx, "user", userBkt, testRelabelConfigs, logger))
+	assert.Equal(t, float64(1), testutil.ToFloat64(c.seriesRelabelBlocksRewritten))
+
+	// The original block has been marked for deletion.
+	exists, err := userBkt.Exists(ctx, filepath.Join(compacted.String(), block.DeletionMarkFilename))
+	require.NoError(t, err)
+	assert.True(t, exists)
+	exists, err = userBkt.Exists(ctx, filepath.Join(notCompacted.String(), block.DeletionMarkFilename))
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	// Find the rewritten block.
+	var newID ulid.ULID
+	require.NoError(t, userBkt.Iter(ctx, "", func(name string) error {
+		if id, ok := block.IsBlockDir(strings.TrimSuffix(name, "/")); ok && id != compacted && id != notCompacted {
+			newID = id
+		}
+		return nil
+	}))
+	require.NotEqual(t, ulid.ULID{}, newID)
+
+	dir := filepath.Join(t.TempDir(), newID.String())
+	require.NoError(t, block.Download(ctx, logger, userBkt, newID, dir))
+	assert.Equal(t, map[string][]int64{
+		`{__name__="up"}`: {0, time.Hour.Milliseconds(), 2*time.Hour.Milliseconds() - 1},
+	}, readBlockSamplesTimestamps(t, dir))
+
+	newMeta, err := block.ReadMetaFromDir(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []block.Rewrite{{RelabelsApplied: block.NewRelabelConfigs(testRelabelConfigs)}}, newMeta.Thanos.Rewrites)
+	assert.Equal(t, block.CompactorSource, newMeta.Thanos.Source)
+	assert.Equal(t, []ulid.ULID{compacted}, newMeta.Compaction.Sources)
+
+	// The rewritten block is not rewritten again.
+	require.NoError(t, c.processSeriesRelabeling(ctx, "user", userBkt, testRelabelConfigs, logger))
+	assert.Equal(t, float64(1), testutil.ToFloat64(c.seriesRelabelBlocksRewritten))
+}
+
+func TestMultitenantCompactor_processSeriesRelabeling_ShardedAndDownsampledBlocks(t *testing.T) {
+	ctx := context.Background()
+	bkt := block.BucketWithGlobalMarkers(objstore.NewInMemBucket())
+	userBkt := bucket.NewUserBucketClient("user", bkt, nil)
+	logger := log.NewNopLogger()
+
+	cfgProvider := newMockConfigProvider()
+	cfgProvider.blockRanges["user"] = mimir_tsdb.DurationList{2 * time.Hour}
+
+	cfg := prepareConfig(t)
+	c, _, _, _, _ := prepareWithConfigProvider(t, cfg, bkt, cfgProvider)
+
+	var specs []*block.SeriesSpec
+	for i := 0; i < 10; i++ {
+		specs = append(specs, &block.SeriesSpec{
+			Labels: labels.FromStrings("__name__", "up", "job", strconv.Itoa(i), "request_id", strconv.Itoa(i)),
+			Chunks: []chunks.Meta{must(chunks.ChunkFromSamples([]chunks.Sample{newSample(0, 1, nil, nil), newSample(2*time.Hour.Milliseconds()-1, 2, nil, nil)}))},
+		})
+	}
+
+	// A block of the second of two compactor shards, spanning the largest compaction range.
+	dir := t.TempDir()
+	shardedMeta, err := block.GenerateBlockFromSpec(dir, specs)
+	require.NoError(t, err)
+	shardedMeta.Thanos.Labels = map[string]string{mimir_tsdb.CompactorShardIDExternalLabel: sharding.FormatShardIDLabelValue(1, 2)}
+	require.NoError(t, block.Upload(ctx, logger, userBkt, filepath.Join(dir, shardedMeta.ULID.String()), shardedMeta))
+
+	// A downsampled block, smaller than the largest compaction range.
+	dir = t.TempDir()
+	rawMeta, err := block.GenerateBlockFromSpec(dir, specs[:2])
+	require.NoError(t, err)
+	rawBlock, err := tsdb.OpenBlock(logger, filepath.Join(dir, rawMeta.ULID.String()), nil)
+	require.NoError(t, err)
+	downsampledID, err := downsample.Downsample(ctx, logger, rawMeta, rawBlock, dir, downsample.ResLevel1)
+	require.NoError(t, err)
+	require.NoError(t, rawBlock.Close())
+	require.NoError(t, block.Upload(ctx, logger, userBkt, filepath.Join(dir, downsampledID.String()), nil))
+
+	// The blocks of a compactor shard are not relabeled by the compaction jobs.
+	rewrites, err := relabelBlockDir(ctx, logger, t.TempDir(), shardedMeta, testRelabelConfigs)
+	require.NoError(t, err)
+	assert.Empty(t, rewrites)
+
+	require.NoError(t, c.processSeriesRelabeling(ctx, "user", userBkt, testRelabelConfigs, logger))
+	assert.Equal(t, float64(2), testutil.ToFloat64(c.seriesRelabelBlocksRewritten))
+
+	metas := map[ulid.ULID]*block.Meta{}
+	require.NoError(t, userBkt.Iter(ctx, "", func(name string) error {
+		id, ok := block.IsBlockDir(strings.TrimSuffix(name, "/"))
+		if !ok || id == shardedMeta.ULID || id == downsampledID {
+			return nil
+		}
+		meta, err := block.DownloadMeta(ctx, logger, userBkt, id)
+		metas[id] = &meta
+		return err
+	}))
+
+	// The series of the sharded block are split again by shard, according to their relabeled labels.
+	expectedShards := map[string][]string{}
+	for i := 0; i < 10; i++ {
+		lset := labels.FromStrings("__name__", "up", "job", strconv.Itoa(i))
+		shardID := sharding.FormatShardIDLabelValue(labels.StableHash(lset)%2, 2)
+		expectedShards[shardID] = append(expectedShards[shardID], lset.String())
+	}
+	require.Len(t, expectedShards, 2)
+
+	actualShards := map[string][]string{}
+	for id, meta := range metas {
+		assert.Equal(t, []block.Rewrite{{RelabelsApplied: block.NewRelabelConfigs(testRelabelConfigs)}}, meta.Thanos.Rewrites)
+
+		bdir := filepath.Join(t.TempDir(), id.String())
+		require.NoError(t, block.Download(ctx, logger, userBkt, id, bdir))
+		if meta.Thanos.Downsample.Resolution > 0 {
+			assert.Equal(t, []ulid.ULID{rawMeta.ULID}, meta.Compaction.Sources)
+			assert.Equal(t, []string{`{__name__="up", job="0"}`, `{__name__="up", job="1"}`}, readBlockSeriesLabels(t, bdir))
+			continue
+		}
+
+		shardID := meta.Thanos.Labels[mimir_tsdb.CompactorShardIDExternalLabel]
+		actualShards[shardID] = readBlockSeriesLabels(t, bdir)
+	}
+	assert.Equal(t, expectedShards, actualShards)
+}
+
+// readBlockSeriesLabels returns the labels of the series of the block in the directory.
+func readBlockSeriesLabels(t *testing.T, dir string) []string {
+	b, err := tsdb.OpenBlock(log.NewNopLogger(), dir, downsample.NewPool())
+	require.NoError(t, err)
+	defer func() { require.NoError(t, b.Close()) }()
+
+	indexr, err := b.Index()
+	require.NoError(t, err)
+	defer func() { require.NoError(t, indexr.Close()) }()
+
+	k, v := index.AllPostingsKey()
+	p, err := indexr.Postings(context.Background(), k, v)
+	require.NoError(t, err)
+
+	var (
+		result  []string
+		builder labels.ScratchBuilder
+	)
+	for p.Next() {
+		require.NoError(t, indexr.Series(p.At(), &builder, nil))
+		result = append(result, builder.Labels().String())
+	}
+	require.NoError(t, p.Err())
+	return result
+}
+
+// readBlockSamplesTimestamps returns the timestamps of the samples of each series of the block in the directory.
+func readBlockSamplesTimestamps(t *testing.T, dir string) map[string][]int64 {
+	b, err := tsdb.OpenBlock(log.NewNopLogger(), dir, nil)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, b.Close()) }()
+
+	q, err := tsdb.NewBlockQuerier(b, b.MinTime(), b.MaxTime())
+	require.NoError(t, err)
+	defer func() { require.NoError(t, q.Close()) }()
+
+	result := map[string][]int64{}
+	set := q.Select(context.Background(), true, nil, labels.MustNewMatcher(labels.MatchEqual, "", ""))
+	for set.Next() {
+		it := set.At().Iterator(nil)
+		for it.Next() != chunkenc.ValNone {
+			result[set.At().Labels().String()] = append(result[set.At().Labels().String()], it.AtT())
+		}
+		require.NoError(t, it.Err())
+	}
+	require.NoError(t, set.Err())
+	return result
+}
+
+func TestMultitenantCompactor_ShouldRelabelSeriesWhileCompacting(t *testing.T) {
+	const userID = "user-1"
+
+	storageCfg := mimir_tsdb.BlocksStorageConfig{}
+	flagext.DefaultValues(&storageCfg)
+	storageCfg.Bucket.Backend = bucket.Filesystem
+	storageCfg.Bucket.Filesystem.Directory = t.TempDir()
+
+	compactorCfg := prepareConfig(t)
+	compactorCfg.DataDir = t.TempDir()
+	compactorCfg.BlockRanges = mimir_tsdb.DurationList{2 * time.Hour}
+
+	relabelConfigs := []*relabel.Config{
+		{
+			SourceLabels: []model.LabelName{"series_id"},
+			Regex:        relabel.MustNewRegexp("(.*)"),
+			TargetLabel:  "job",
+			Replacement:  "test",
+			Action:       relabel.Replace,
+		},
+		{
+			Regex:  relabel.MustNewRegexp("series_id"),
+			Action: relabel.LabelDrop,
+		},
+	}
+	cfgProvider := newMockConfigProvider()
+	cfgProvider.relabelConfigs[userID] = relabelConfigs
+
+	logger := log.NewNopLogger()
+	reg := prometheus.NewPedanticRegistry()
+	ctx := context.Background()
+
+	bucketClient, err := bucket.NewClient(ctx, storageCfg.Bucket, "test", logger, nil)
+	require.NoError(t, err)
+
+	// Create two blocks, each with 5 series with one sample.
+	block1 := createTSDBBlock(t, bucketClient, userID, 0, time.Hour.Milliseconds(), 5, nil)
+	block2 := createTSDBBlock(t, bucketClient, userID, time.Hour.Milliseconds(), 2*time.Hour.Milliseconds(), 5, nil)
+
+	c, err := NewMultitenantCompactor(compactorCfg, storageCfg, cfgProvider, logger, reg)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(ctx, c))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(ctx, c))
+	})
+
+	// Wait until the first compaction run completed.
+	test.Poll(t, 15*time.Second, nil, func() interface{} {
+		return testutil.GatherAndCompare(reg, strings.NewReader(`
+			# HELP cortex_compactor_runs_completed_total Total number of compaction runs successfully completed.
+			# TYPE cortex_compactor_runs_completed_total counter
+			cortex_compactor_runs_completed_total 1
+		`), "cortex_compactor_runs_completed_total")
+	})
+
+	userBucket := bucket.NewUserBucketClient(userID, bucketClient, nil)
+	fetcher, err := block.NewMetaFetcher(logger, 1, userBucket, t.TempDir(), nil, nil, nil)
+	require.NoError(t, err)
+	metas, _, err := fetcher.FetchWithoutMarkedForDeletion(ctx)
+	require.NoError(t, err)
+	require.Len(t, metas, 1)
+
+	// The series of the source blocks have been merged into a single series, and the relabel configs recorded.
+	for id, meta := range metas {
+		assert.ElementsMatch(t, []ulid.ULID{block1, block2}, meta.Compaction.Sources)
+		assert.Equal(t, []block.Rewrite{{RelabelsApplied: block.NewRelabelConfigs(relabelConfigs)}}, meta.Thanos.Rewrites)
+
+		samples := readBlockSamplesTimestamps(t, filepath.Join(storageCfg.Bucket.Filesystem.Directory, userID, id.String()))
+		require.Contains(t, samples, `{job="test"}`)
+		assert.Len(t, samples, 1)
+		assert.Len(t, samples[`{job="test"}`], 10)
+	}
+}
@@ -0,0 +1,256 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/index"
+	"github.com/prometheus/prometheus/tsdb/tombstones"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+// SeriesRetentionStatusPath is the location of the series retention status, relative to the tenant location.
+const SeriesRetentionStatusPath = "markers/series-retention-status.json"
+
+// SeriesRetentionStatus tracks the progress of the compactor removing the expired series from the tenant blocks.
+type SeriesRetentionStatus struct {
+	// Unix timestamp of the last compactor run which checked all the blocks. The blocks which have become older
+	// than a retention period since then are checked by the next run.
+	LastCheckTime util.UnixSeconds `json:"last_check_time"`
+	// Number of blocks still to check, as of the last compactor run.
+	PendingBlocks int `json:"pending_blocks"`
+	// Number of blocks rewritten so far because they contained expired series.
+	RewrittenBlocks int `json:"rewritten_blocks"`
+
+	// FailedBlocks are the blocks which failed to be rewritten by the last compactor run, and are checked again
+	// by the next one.
+	FailedBlocks []ulid.ULID `json:"failed_blocks,omitempty"`
+}
+
+// ReadSeriesRetentionStatus reads the series retention status from the tenant bucket. It returns an empty
+// status if it doesn't exist.
+func ReadSeriesRetentionStatus(ctx context.Context, userBucket objstore.BucketReader) (*SeriesRetentionStatus, error) {
+	status := &SeriesRetentionStatus{}
+
+	r, err := userBucket.Get(ctx, SeriesRetentionStatusPath)
+	if userBucket.IsObjNotFoundErr(err) {
+		return status, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "read series retention status")
+	}
+	defer r.Close()
+
+	if err := json.NewDecoder(r).Decode(status); err != nil {
+		return nil, errors.Wrap(err, "decode series retention status")
+	}
+	return status, nil
+}
+
+func writeSeriesRetentionStatus(ctx context.Context, userBucket objstore.Bucket, status *SeriesRetentionStatus) error {
+	data, err := json.Marshal(status)
+	if err != nil {
+		return errors.Wrap(err, "serialize series retention status")
+	}
+
+	return errors.Wrap(userBucket.Upload(ctx, SeriesRetentionStatusPath, bytes.NewReader(data)), "upload series retention status")
+}
+
+// processSeriesRetentionPolicies rewrites the tenant blocks which have become older than the retention period of some
+// of their series, to remove them, and updates the series retention status in the bucket. The status is updated once
+// the pending blocks are found, and once they've been rewritten.
+func (c *MultitenantCompactor) processSeriesRetentionPolicies(ctx context.Context, userBucket objstore.InstrumentedBucket, policies validation.SeriesRetentionPolicies, logger log.Logger) error {
+	periods := policies.ShorterPeriods()
+	if len(periods) == 0 {
+		return nil
+	}
+
+	status, err := ReadSeriesRetentionStatus(ctx, userBucket)
+	if err != nil {
+		return err
+	}
+
+	metas, err := c.fetchBlocksToRewrite(ctx, userBucket, logger)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var pending []*block.Meta
+	for _, meta := range metas {
+		if slices.Contains(status.FailedBlocks, meta.ULID) || seriesRetentionPendingForBlock(meta, periods, policies.MaxPeriod(), status.LastCheckTime.Time(), now) {
+			pending = append(pending, meta)
+		}
+	}
+
+	status.PendingBlocks = len(pending)
+	if err := writeSeriesRetentionStatus(ctx, userBucket, status); err != nil {
+		return err
+	}
+
+	// The last check time is only updated once all the pending blocks have been checked, so that the blocks which
+	// haven't been checked because the compactor has been stopped are checked by the next run.
+	failed, err := c.rewriteBlocks(ctx, pending, "series retention", c.seriesRetentionBlocksRewriteFailures, logger, func(meta *block.Meta) error {
+		rewritten, err := c.rewriteBlockForSeriesRetention(ctx, userBucket, meta, policies, now, logger)
+		if rewritten {
+			status.RewrittenBlocks++
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	status.LastCheckTime = util.UnixSecondsFromTime(now)
+	status.PendingBlocks = len(failed)
+	status.FailedBlocks = failed
+	if err := writeSeriesRetentionStatus(ctx, userBucket, status); err != nil {
+		return err
+	}
+
+	if len(pending) > 0 {
+		level.Info(logger).Log("msg", "applied series retention policies", "checked_blocks", len(pending), "failed_blocks", len(failed), "rewritten_blocks_total", status.RewrittenBlocks)
+	}
+	return nil
+}
+
+// seriesRetentionPendingForBlock returns whether the block may contain expired series, because it has become older
+// than one of the retention periods since it has been last checked, or created by the compactor, which removes the
+// expired series from the compacted blocks. Blocks older than the blocks retention period are deleted instead.
+func seriesRetentionPendingForBlock(meta *block.Meta, periods []time.Duration, maxPeriod time.Duration, checkedAt, now time.Time) bool {
+	maxTime := time.UnixMilli(meta.MaxTime)
+	if maxPeriod > 0 && !maxTime.Add(maxPeriod).After(now) {
+		return false
+	}
+
+	lastCheck := checkedAt
+	if createdAt := ulid.Time(meta.ULID.Time()); meta.Thanos.Source == block.CompactorSource && createdAt.After(lastCheck) {
+		lastCheck = createdAt
+	}
+
+	for _, period := range periods {
+		expiredAt := maxTime.Add(period)
+		if !expiredAt.After(now) && lastCheck.Before(expiredAt) {
+			return true
+		}
+	}
+	return false
+}
+
+// rewriteBlockForSeriesRetention downloads the block, removes the expired series and, if any, uploads the rewritten
+// block and marks the original one for deletion.
+func (c *MultitenantCompactor) rewriteBlockForSeriesRetention(ctx context.Context, userBucket objstore.Bucket, meta *block.Meta, policies validation.SeriesRetentionPolicies, now time.Time, logger log.Logger) (bool, error) {
+	logger = log.With(logger, "block", meta.ULID)
+
+	rewritten, err := c.rewriteBlock(ctx, userBucket, meta, "series retention", c.seriesRetentionBlocksMarkedForDeletion, logger, func(bdir, dest string) ([]ulid.ULID, error) {
+		expired, err := applySeriesRetentionPoliciesToBlockDir(ctx, logger, bdir, policies, now)
+		if err != nil || expired == 0 {
+			return nil, err
+		}
+		return c.writeBlockWithoutDeletedSamples(ctx, logger, bdir, dest, meta)
+	})
+	if rewritten {
+		c.seriesRetentionBlocksRewritten.Inc()
+	}
+	return rewritten, err
+}
+
+// applySeriesRetentionPoliciesToBlockDir writes the tombstones for the series of the block in the directory whose
+// samples have all expired, and updates the number of tombstones in the block meta, so that they're removed when
+// the block gets compacted. It returns the number of expired series.
+func applySeriesRetentionPoliciesToBlockDir(ctx context.Context, logger log.Logger, bdir string, policies validation.SeriesRetentionPolicies, now time.Time) (_ int, returnErr error) {
+	b, err := tsdb.OpenBlock(logger, bdir, nil)
+	if err != nil {
+		return 0, errors.Wrapf(err, "open block %s", bdir)
+	}
+	defer func() {
+		if err := b.Close(); returnErr == nil {
+			returnErr = err
+		}
+	}()
+
+	stones, expired, err := expiredSeriesTombstones(ctx, b, policies, now)
+	if err != nil || expired == 0 {
+		return 0, err
+	}
+
+	if _, err := tombstones.WriteFile(logger, bdir, stones); err != nil {
+		return 0, errors.Wrapf(err, "write tombstones of block %s", bdir)
+	}
+
+	meta, err := block.ReadMetaFromDir(bdir)
+	if err != nil {
+		return 0, errors.Wrapf(err, "read meta of block %s", bdir)
+	}
+	meta.Stats.NumTombstones = stones.Total()
+	if err := meta.WriteToDir(logger, bdir); err != nil {
+		return 0, errors.Wrapf(err, "write meta of block %s", bdir)
+	}
+	return expired, nil
+}
+
+// expiredSeriesTombstones returns the tombstones of the block, including the ones for the expired series,
+// and the number of expired series.
+func expiredSeriesTombstones(ctx context.Context, b *tsdb.Block, policies validation.SeriesRetentionPolicies, now time.Time) (_ tombstones.Reader, expired int, _ error) {
+	ir, err := b.Index()
+	if err != nil {
+		return nil, 0, err
+	}
+	defer ir.Close()
+
+	tr, err := b.Tombstones()
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tr.Close()
+
+	stones := tombstones.NewMemTombstones()
+	if err := tr.Iter(func(ref storage.SeriesRef, ivs tombstones.Intervals) error {
+		stones.AddInterval(ref, ivs...)
+		return nil
+	}); err != nil {
+		return nil, 0, err
+	}
+
+	name, value := index.AllPostingsKey()
+	p, err := ir.Postings(ctx, name, value)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var builder labels.ScratchBuilder
+	for p.Next() {
+		if err := ir.Series(p.At(), &builder, nil); err != nil {
+			return nil, 0, err
+		}
+		// Block max time is exclusive.
+		if policies.ExpiredBefore(builder.Labels(), now) >= b.MaxTime() {
+			stones.AddInterval(p.At(), tombstones.Interval{Mint: b.MinTime(), Maxt: b.MaxTime()})
+			expired++
+		}
+	}
+	return stones, expired, p.Err()
+}
+
+// seriesRetentionAppliesToBlock returns whether the block is older than any retention period shorter than the
+// blocks retention period, so that some of its series may have expired.
+func seriesRetentionAppliesToBlock(meta *block.Meta, policies validation.SeriesRetentionPolicies, now time.Time) bool {
+	periods := policies.ShorterPeriods()
+	return len(periods) > 0 && !time.UnixMilli(meta.MaxTime).Add(periods[0]).After(now)
+}
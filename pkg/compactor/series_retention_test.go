@@ -0,0 +1,244 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storage/tsdb/downsample"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestSeriesRetentionPendingForBlock(t *testing.T) {
+	now := time.Now()
+	periods := []time.Duration{time.Hour, 10 * time.Hour}
+
+	newMeta := func(maxT time.Time, createdAt time.Time) *block.Meta {
+		return &block.Meta{
+			BlockMeta: tsdb.BlockMeta{ULID: ulid.MustNew(ulid.Timestamp(createdAt), nil), MinTime: maxT.Add(-2 * time.Hour).UnixMilli(), MaxTime: maxT.UnixMilli()},
+			Thanos:    block.ThanosMeta{Source: block.CompactorSource},
+		}
+	}
+
+	// The block is younger than any period.
+	assert.False(t, seriesRetentionPendingForBlock(newMeta(now.Add(-30*time.Minute), now.Add(-20*time.Minute)), periods, 0, time.Time{}, now))
+	// The block has become older than a period since it has been created.
+	assert.True(t, seriesRetentionPendingForBlock(newMeta(now.Add(-2*time.Hour), now.Add(-90*time.Minute)), periods, 0, time.Time{}, now))
+	// The block has been created by the compactor after it has become older than a period.
+	assert.False(t, seriesRetentionPendingForBlock(newMeta(now.Add(-2*time.Hour), now.Add(-30*time.Minute)), periods, 0, time.Time{}, now))
+	// Blocks not created by the compactor may contain expired series.
+	uploaded := newMeta(now.Add(-2*time.Hour), now.Add(-30*time.Minute))
+	uploaded.Thanos.Source = block.ReceiveSource
+	assert.True(t, seriesRetentionPendingForBlock(uploaded, periods, 0, time.Time{}, now))
+	// The block has been checked after it has become older than a period.
+	assert.False(t, seriesRetentionPendingForBlock(newMeta(now.Add(-2*time.Hour), now.Add(-90*time.Minute)), periods, 0, now.Add(-time.Minute), now))
+	// The block has become older than another period since it has been checked.
+	assert.True(t, seriesRetentionPendingForBlock(newMeta(now.Add(-11*time.Hour), now.Add(-11*time.Hour)), periods, 0, now.Add(-2*time.Hour), now))
+	// The block is deleted by the blocks retention instead.
+	assert.False(t, seriesRetentionPendingForBlock(newMeta(now.Add(-25*time.Hour), now.Add(-25*time.Hour)), periods, 24*time.Hour, time.Time{}, now))
+
+	// Downsampled blocks are rewritten too.
+	downsampled := newMeta(now.Add(-2*time.Hour), now.Add(-90*time.Minute))
+	downsampled.Thanos.Downsample.Resolution = 300_000
+	assert.True(t, seriesRetentionPendingForBlock(downsampled, periods, 0, time.Time{}, now))
+}
+
+func TestMultitenantCompactor_processSeriesRetentionPolicies(t *testing.T) {
+	ctx := context.Background()
+	bkt := block.BucketWithGlobalMarkers(objstore.NewInMemBucket())
+	userBkt := bucket.NewUserBucketClient("user", bkt, nil)
+
+	cfg := prepareConfig(t)
+	c, _, _, _, _ := prepare(t, cfg, bkt)
+	var err error
+	c.blocksCompactor, _, err = splitAndMergeCompactorFactory(ctx, cfg, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+
+	// Creates series with series_id from 0 to 4.
+	blockID := createTSDBBlock(t, bkt, "user", 0, 100, 4, map[string]string{"a": "1"})
+
+	policies := validation.SeriesRetentionPolicies{
+		Policies: []validation.SeriesRetentionPolicy{
+			{Matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, "series_id", "1|3")}, Period: 24 * time.Hour},
+		},
+	}
+
+	t.Run("block without expired series", func(t *testing.T) {
+		unmatched := validation.SeriesRetentionPolicies{
+			Policies: []validation.SeriesRetentionPolicy{
+				{Matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "series_id", "10")}, Period: 24 * time.Hour},
+			},
+		}
+		require.NoError(t, c.processSeriesRetentionPolicies(ctx, userBkt, unmatched, log.NewNopLogger()))
+
+		status, err := ReadSeriesRetentionStatus(ctx, userBkt)
+		require.NoError(t, err)
+		assert.Equal(t, 0, status.PendingBlocks)
+		assert.Equal(t, 0, status.RewrittenBlocks)
+		assert.NotZero(t, status.LastCheckTime)
+		assert.Empty(t, status.FailedBlocks)
+		assert.Equal(t, float64(0), testutil.ToFloat64(c.seriesRetentionBlocksRewritten))
+	})
+
+	t.Run("block with expired series", func(t *testing.T) {
+		// The block has been checked already, but it's checked again as the policies have changed.
+		require.NoError(t, writeSeriesRetentionStatus(ctx, userBkt, &SeriesRetentionStatus{}))
+		require.NoError(t, c.processSeriesRetentionPolicies(ctx, userBkt, policies, log.NewNopLogger()))
+
+		status, err := ReadSeriesRetentionStatus(ctx, userBkt)
+		require.NoError(t, err)
+		assert.Equal(t, 0, status.PendingBlocks)
+		assert.Equal(t, 1, status.RewrittenBlocks)
+		assert.NotZero(t, status.LastCheckTime)
+		assert.Equal(t, float64(1), testutil.ToFloat64(c.seriesRetentionBlocksRewritten))
+
+		// The original block has been marked for deletion.
+		exists, err := userBkt.Exists(ctx, filepath.Join(blockID.String(), block.DeletionMarkFilename))
+		require.NoError(t, err)
+		assert.True(t, exists)
+
+		// Find the rewritten block.
+		var newID ulid.ULID
+		require.NoError(t, userBkt.Iter(ctx, "", func(name string) error {
+			if id, ok := block.IsBlockDir(strings.TrimSuffix(name, "/")); ok && id != blockID {
+				newID = id
+			}
+			return nil
+		}))
+		require.NotEqual(t, ulid.ULID{}, newID)
+
+		dir := t.TempDir()
+		require.NoError(t, block.Download(ctx, log.NewNopLogger(), userBkt, newID, filepath.Join(dir, newID.String())))
+		b, err := tsdb.OpenBlock(log.NewNopLogger(), filepath.Join(dir, newID.String()), nil)
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, b.Close()) })
+
+		q, err := tsdb.NewBlockQuerier(b, 0, 100)
+		require.NoError(t, err)
+		values, _, err := q.LabelValues(ctx, "series_id", nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"0", "2", "4"}, values)
+		require.NoError(t, q.Close())
+	})
+
+	t.Run("rewritten block is not rewritten again", func(t *testing.T) {
+		require.NoError(t, c.processSeriesRetentionPolicies(ctx, userBkt, policies, log.NewNopLogger()))
+
+		status, err := ReadSeriesRetentionStatus(ctx, userBkt)
+		require.NoError(t, err)
+		assert.Equal(t, 0, status.PendingBlocks)
+		assert.Equal(t, 1, status.RewrittenBlocks)
+		assert.Empty(t, status.FailedBlocks)
+		assert.Equal(t, float64(1), testutil.ToFloat64(c.seriesRetentionBlocksRewritten))
+	})
+}
+
+func TestMultitenantCompactor_processSeriesRetentionPolicies_BlockRewriteFailure(t *testing.T) {
+	ctx := context.Background()
+	bkt := block.BucketWithGlobalMarkers(objstore.NewInMemBucket())
+	userBkt := bucket.NewUserBucketClient("user", bkt, nil)
+	c, _, _, _, _ := prepare(t, prepareConfig(t), bkt)
+
+	// Remove the index of the block, so that it can't be rewritten.
+	blockID := createTSDBBlock(t, bkt, "user", 0, 100, 4, nil)
+	require.NoError(t, userBkt.Delete(ctx, filepath.Join(blockID.String(), block.IndexFilename)))
+
+	policies := validation.SeriesRetentionPolicies{
+		Policies: []validation.SeriesRetentionPolicy{
+			{Matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "series_id", "1")}, Period: 24 * time.Hour},
+		},
+	}
+	require.NoError(t, c.processSeriesRetentionPolicies(ctx, userBkt, policies, log.NewNopLogger()))
+	assert.Equal(t, float64(1), testutil.ToFloat64(c.seriesRetentionBlocksRewriteFailures))
+
+	status, err := ReadSeriesRetentionStatus(ctx, userBkt)
+	require.NoError(t, err)
+	assert.Equal(t, 1, status.PendingBlocks)
+	assert.Equal(t, []ulid.ULID{blockID}, status.FailedBlocks)
+	assert.NotZero(t, status.LastCheckTime)
+
+	// The failed block is checked again by the next run, although it has been checked since it has expired.
+	require.NoError(t, c.processSeriesRetentionPolicies(ctx, userBkt, policies, log.NewNopLogger()))
+	assert.Equal(t, float64(2), testutil.ToFloat64(c.seriesRetentionBlocksRewriteFailures))
+}
+
+func TestMultitenantCompactor_rewriteBlockForSeriesRetention_DownsampledBlock(t *testing.T) {
+	const userID = "user"
+
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+
+	cfgProvider := newMockConfigProvider()
+	cfgProvider.downsampling5mAfter[userID] = 24 * time.Hour
+	c, _, _, _, _ := prepareWithConfigProvider(t, prepareConfig(t), bkt, cfgProvider)
+	userBkt := bucket.NewUserBucketClient(userID, bkt, cfgProvider)
+
+	// Creates series with series_id from 0 to 3, and downsamples them to 5m.
+	rawID := createTSDBBlock(t, bkt, userID, 0, 2*time.Hour.Milliseconds(), 4, nil)
+	require.NoError(t, c.downsampleUserBlocks(ctx, userID, userBkt, log.NewNopLogger()))
+
+	findBlock := func(skip ...ulid.ULID) *block.Meta {
+		var meta *block.Meta
+		require.NoError(t, userBkt.Iter(ctx, "", func(name string) error {
+			id, ok := block.IsBlockDir(strings.TrimSuffix(name, "/"))
+			if !ok || slices.Contains(skip, id) {
+				return nil
+			}
+			m, err := block.DownloadMeta(ctx, log.NewNopLogger(), userBkt, id)
+			meta = &m
+			return err
+		}))
+		require.NotNil(t, meta)
+		return meta
+	}
+	meta := findBlock(rawID)
+	require.Equal(t, downsample.ResLevel1, meta.Thanos.Downsample.Resolution)
+
+	policies := validation.SeriesRetentionPolicies{
+		Policies: []validation.SeriesRetentionPolicy{
+			{Matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "series_id", "1")}, Period: 24 * time.Hour},
+		},
+	}
+	rewritten, err := c.rewriteBlockForSeriesRetention(ctx, userBkt, meta, policies, time.Now(), log.NewNopLogger())
+	require.NoError(t, err)
+	assert.True(t, rewritten)
+
+	// The downsampled block is replaced by a rewritten one, without the expired series.
+	exists, err := userBkt.Exists(ctx, filepath.Join(meta.ULID.String(), block.DeletionMarkFilename))
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	newMeta := findBlock(rawID, meta.ULID)
+	assert.Equal(t, downsample.ResLevel1, newMeta.Thanos.Downsample.Resolution)
+	assert.Equal(t, uint64(3), newMeta.Stats.NumSeries)
+
+	dir := filepath.Join(t.TempDir(), newMeta.ULID.String())
+	require.NoError(t, block.Download(ctx, log.NewNopLogger(), userBkt, newMeta.ULID, dir))
+	b, err := tsdb.OpenBlock(log.NewNopLogger(), dir, downsample.NewPool())
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, b.Close()) })
+
+	indexr, err := b.Index()
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, indexr.Close()) })
+
+	values, err := indexr.SortedLabelValues(ctx, "series_id")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0", "2", "3"}, values)
+}
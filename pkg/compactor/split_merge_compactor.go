@@ -8,12 +8,15 @@ import (
 	"github.com/go-kit/log"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/prometheus/tsdb"
+
+	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
 )
 
 func splitAndMergeGrouperFactory(_ context.Context, cfg Config, cfgProvider ConfigProvider, userID string, logger log.Logger, _ prometheus.Registerer) Grouper {
+	ranges := tenantBlockRanges(cfg.BlockRanges, cfgProvider, userID)
 	return NewSplitAndMergeGrouper(
 		userID,
-		cfg.BlockRanges.ToMilliseconds(),
+		ranges.ToMilliseconds(),
 		uint32(cfgProvider.CompactorSplitAndMergeShards(userID)),
 		uint32(cfgProvider.CompactorSplitGroups(userID)),
 		logger)
@@ -37,6 +40,28 @@ func splitAndMergeCompactorFactory(ctx context.Context, cfg Config, logger log.L
 	return compactor, planner, nil
 }
 
+// tenantBlockRanges returns the compaction time ranges of the tenant. If they're not overridden, but the
+// tenant's ingesters cut blocks with a custom range, the ranges are adapted to start from it.
+func tenantBlockRanges(ranges mimir_tsdb.DurationList, cfgProvider ConfigProvider, userID string) mimir_tsdb.DurationList {
+	if tenantRanges := cfgProvider.CompactorBlockRanges(userID); len(tenantRanges) > 0 {
+		return mimir_tsdb.DurationList(tenantRanges)
+	}
+
+	blockRange := cfgProvider.IngesterTSDBBlockRangePeriod(userID)
+	if blockRange <= 0 || len(ranges) == 0 || blockRange == ranges[0] {
+		return ranges
+	}
+
+	// Keep the configured ranges which blocks cut by the ingesters can be compacted into.
+	adapted := mimir_tsdb.DurationList{blockRange}
+	for _, r := range ranges {
+		if r > blockRange && r%blockRange == 0 {
+			adapted = append(adapted, r)
+		}
+	}
+	return adapted
+}
+
 // configureSplitAndMergeCompactor updates the provided configuration injecting the split-and-merge compactor.
 func configureSplitAndMergeCompactor(cfg *Config) {
 	cfg.BlocksGrouperFactory = splitAndMergeGrouperFactory
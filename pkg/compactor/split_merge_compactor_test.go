@@ -800,6 +800,51 @@ func TestMultitenantCompactor_ShouldGuaranteeSeriesShardingConsistencyOverTheTim
 	}
 }
 
+func TestTenantBlockRanges(t *testing.T) {
+	global := mimir_tsdb.DurationList{2 * time.Hour, 12 * time.Hour, 24 * time.Hour}
+
+	tests := map[string]struct {
+		tenantRanges       mimir_tsdb.DurationList
+		ingesterBlockRange time.Duration
+		expected           mimir_tsdb.DurationList
+	}{
+		"no overrides": {
+			expected: global,
+		},
+		"compactor block ranges overridden": {
+			tenantRanges:       mimir_tsdb.DurationList{time.Hour, 24 * time.Hour},
+			ingesterBlockRange: 30 * time.Minute,
+			expected:           mimir_tsdb.DurationList{time.Hour, 24 * time.Hour},
+		},
+		"ingester block range equal to the first range": {
+			ingesterBlockRange: 2 * time.Hour,
+			expected:           global,
+		},
+		"ingester block range shorter than the first range": {
+			ingesterBlockRange: time.Hour,
+			expected:           mimir_tsdb.DurationList{time.Hour, 2 * time.Hour, 12 * time.Hour, 24 * time.Hour},
+		},
+		"ingester block range longer than the first range": {
+			ingesterBlockRange: 6 * time.Hour,
+			expected:           mimir_tsdb.DurationList{6 * time.Hour, 12 * time.Hour, 24 * time.Hour},
+		},
+		"ingester block range not dividing some ranges": {
+			ingesterBlockRange: 8 * time.Hour,
+			expected:           mimir_tsdb.DurationList{8 * time.Hour, 24 * time.Hour},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			cfgProvider := newMockConfigProvider()
+			cfgProvider.blockRanges["user-1"] = testData.tenantRanges
+			cfgProvider.ingesterBlockRanges["user-1"] = testData.ingesterBlockRange
+
+			assert.Equal(t, testData.expected, tenantBlockRanges(global, cfgProvider, "user-1"))
+		})
+	}
+}
+
 func convertMetasMapToSlice(metas map[ulid.ULID]*block.Meta) []*block.Meta {
 	var out []*block.Meta
 	for _, m := range metas {
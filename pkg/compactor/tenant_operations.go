@@ -0,0 +1,697 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/tenant"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/prometheus/prometheus/tsdb/index"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/alertmanager/alertspb"
+	"github.com/grafana/mimir/pkg/alertmanager/alertstore"
+	"github.com/grafana/mimir/pkg/ruler/rulespb"
+	"github.com/grafana/mimir/pkg/ruler/rulestore"
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
+	"github.com/grafana/mimir/pkg/storage/tsdb/downsample"
+	"github.com/grafana/mimir/pkg/util"
+)
+
+// SetTenantOperationsStores sets the stores used by the tenant operations to copy the rule groups and the
+// alertmanager configuration of the tenants. If a store is nil, the related configuration is not copied.
+func (c *MultitenantCompactor) SetTenantOperationsStores(ruleStore rulestore.RuleStore, alertStore alertstore.AlertStore) {
+	c.ruleStore = ruleStore
+	c.alertStore = alertStore
+}
+
+// StartTenantOperation creates an operation copying, renaming or merging the source tenant into the destination
+// tenant. The operation is run asynchronously by the compactor owning the destination tenant.
+func (c *MultitenantCompactor) StartTenantOperation(w http.ResponseWriter, r *http.Request) {
+	if !c.compactorCfg.TenantOperationsEnabled {
+		http.Error(w, "tenant operations are disabled", http.StatusNotFound)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	source, destination := r.Form.Get("source"), r.Form.Get("destination")
+	for _, userID := range []string{source, destination} {
+		if userID == "" {
+			http.Error(w, "the source and destination tenants are required", http.StatusBadRequest)
+			return
+		}
+		if err := tenant.ValidTenantID(userID); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	injectLabels := map[string]string{}
+	for _, l := range r.Form["inject_label"] {
+		name, value, ok := strings.Cut(l, "=")
+		if !ok {
+			http.Error(w, "invalid injected label "+l+", the expected format is name=value", http.StatusBadRequest)
+			return
+		}
+		injectLabels[name] = value
+	}
+
+	op, err := mimir_tsdb.NewTenantOperation(r.Form.Get("type"), source, destination, injectLabels, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if op.RequiresEmptyDestination() {
+		empty, err := c.isTenantEmptyForOperation(r.Context(), destination)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !empty {
+			http.Error(w, "the destination tenant has blocks or running tenant operations", http.StatusConflict)
+			return
+		}
+	}
+
+	if err := mimir_tsdb.WriteTenantOperation(r.Context(), c.bucketClient, c.cfgProvider, op); err != nil {
+		level.Error(c.logger).Log("msg", "failed to write tenant operation", "source", source, "destination", destination, "err", err)
+
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	level.Info(c.logger).Log("msg", "tenant operation created", "operation_id", op.ID, "type", op.Type, "source", source, "destination", destination)
+
+	util.WriteJSONResponse(w, op)
+}
+
+// isTenantEmptyForOperation returns whether the tenant has no blocks and no running tenant operations.
+func (c *MultitenantCompactor) isTenantEmptyForOperation(ctx context.Context, userID string) (bool, error) {
+	empty, err := c.isBlocksForUserDeleted(ctx, userID)
+	if err != nil || !empty {
+		return false, err
+	}
+
+	ops, err := mimir_tsdb.ReadTenantOperations(ctx, c.bucketClient, userID, c.logger)
+	if err != nil {
+		return false, err
+	}
+	return !tenantOperationsRunning(ops), nil
+}
+
+type TenantOperationsStatusResponse struct {
+	Operations []*mimir_tsdb.TenantOperation `json:"operations"`
+}
+
+// TenantOperationsStatus shows the progress of the tenant operations having the tenant in the "destination"
+// parameter as destination, or of all the tenant operations if the parameter is empty.
+func (c *MultitenantCompactor) TenantOperationsStatus(w http.ResponseWriter, r *http.Request) {
+	if !c.compactorCfg.TenantOperationsEnabled {
+		http.Error(w, "tenant operations are disabled", http.StatusNotFound)
+		return
+	}
+
+	users := []string{r.FormValue("destination")}
+	if users[0] == "" {
+		var err error
+		if users, err = mimir_tsdb.ListUsers(r.Context(), c.bucketClient); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	result := TenantOperationsStatusResponse{Operations: []*mimir_tsdb.TenantOperation{}}
+	for _, userID := range users {
+		ops, err := mimir_tsdb.ReadTenantOperations(r.Context(), c.bucketClient, userID, c.logger)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		result.Operations = append(result.Operations, ops...)
+	}
+
+	util.WriteJSONResponse(w, result)
+}
+
+func tenantOperationsRunning(ops []*mimir_tsdb.TenantOperation) bool {
+	for _, op := range ops {
+		if !op.Completed() {
+			return true
+		}
+	}
+	return false
+}
+
+// runTenantOperations runs the tenant operations having the tenant as destination, if this compactor owns the
+// tenant. It returns whether some operations are still running, in which case the tenant blocks must not be
+// compacted, because the copied blocks are looked up in the destination tenant to resume the operations.
+func (c *MultitenantCompactor) runTenantOperations(ctx context.Context, userID string, logger log.Logger) (bool, error) {
+	ops, err := mimir_tsdb.ReadTenantOperations(ctx, c.bucketClient, userID, logger)
+	if err != nil {
+		return false, err
+	}
+	if !tenantOperationsRunning(ops) {
+		return false, nil
+	}
+
+	// Only one compactor runs the tenant operations.
+	if owned, err := c.shardingStrategy.blocksCleanerOwnsUser(userID); err != nil {
+		return true, errors.Wrap(err, "failed to check if user is owned for tenant operations")
+	} else if !owned {
+		return true, nil
+	}
+
+	for _, op := range ops {
+		if op.Completed() {
+			continue
+		}
+		if err := c.runTenantOperation(ctx, op, log.With(logger, "operation_id", op.ID, "source", op.SourceTenant)); err != nil {
+			return true, errors.Wrapf(err, "tenant operation %s", op.ID)
+		}
+	}
+	return false, nil
+}
+
+// runTenantOperation runs the tenant operation, tracking its progress in the bucket. If the operation fails,
+// the error is tracked too, and the operation is resumed at the next compactor run.
+func (c *MultitenantCompactor) runTenantOperation(ctx context.Context, op *mimir_tsdb.TenantOperation, logger log.Logger) error {
+	level.Info(logger).Log("msg", "running tenant operation", "type", op.Type)
+
+	op.State = mimir_tsdb.TenantOperationStateRunning
+	if err := c.updateTenantOperation(ctx, op); err != nil {
+		return err
+	}
+
+	err := c.copyTenantData(ctx, op, logger)
+	if err != nil {
+		op.Error = err.Error()
+		if updateErr := c.updateTenantOperation(ctx, op); updateErr != nil {
+			level.Warn(logger).Log("msg", "failed to track the tenant operation error", "err", updateErr)
+		}
+		return err
+	}
+
+	op.State = mimir_tsdb.TenantOperationStateCompleted
+	op.Error = ""
+	op.CompletedTime = util.UnixSecondsFromTime(time.Now())
+	if err := c.updateTenantOperation(ctx, op); err != nil {
+		return err
+	}
+
+	c.tenantOperationsCompleted.Inc()
+	level.Info(logger).Log("msg", "tenant operation completed", "type", op.Type, "copied_blocks", op.CopiedBlocks)
+	return nil
+}
+
+func (c *MultitenantCompactor) updateTenantOperation(ctx context.Context, op *mimir_tsdb.TenantOperation) error {
+	op.LastUpdateTime = util.UnixSecondsFromTime(time.Now())
+	return mimir_tsdb.WriteTenantOperation(ctx, c.bucketClient, c.cfgProvider, op)
+}
+
+// copyTenantData copies the blocks, rule groups and alertmanager configuration of the source tenant to the
+// destination tenant, and marks the source tenant for deletion if the operation deletes it. Each step is
+// idempotent, so that the operation can be resumed.
+func (c *MultitenantCompactor) copyTenantData(ctx context.Context, op *mimir_tsdb.TenantOperation, logger log.Logger) error {
+	if err := c.copyTenantBlocks(ctx, op, logger); err != nil {
+		return errors.Wrap(err, "copy blocks")
+	}
+
+	if err := c.copyTenantRuleGroups(ctx, op); err != nil {
+		return errors.Wrap(err, "copy rule groups")
+	}
+	if err := c.copyTenantAlertmanagerConfig(ctx, op); err != nil {
+		return errors.Wrap(err, "copy alertmanager configuration")
+	}
+	if err := c.updateTenantOperation(ctx, op); err != nil {
+		return err
+	}
+
+	if !op.DeletesSource() {
+		return nil
+	}
+
+	if err := mimir_tsdb.WriteTenantDeletionMark(ctx, c.bucketClient, op.SourceTenant, c.cfgProvider, mimir_tsdb.NewTenantDeletionMark(time.Now())); err != nil {
+		return errors.Wrap(err, "mark source tenant for deletion")
+	}
+	if c.ruleStore != nil {
+		if err := c.ruleStore.DeleteNamespace(ctx, op.SourceTenant, ""); err != nil && !errors.Is(err, rulestore.ErrGroupNamespaceNotFound) {
+			return errors.Wrap(err, "delete source tenant rule groups")
+		}
+	}
+	if c.alertStore != nil {
+		if err := c.alertStore.DeleteAlertConfig(ctx, op.SourceTenant); err != nil {
+			return errors.Wrap(err, "delete source tenant alertmanager configuration")
+		}
+	}
+	return nil
+}
+
+// copyTenantBlocks copies the blocks of the source tenant which aren't in the destination tenant yet, and rebuilds
+// the bucket index of the destination tenant. The blocks keep their ID, so the blocks already copied are skipped
+// when the operation is resumed.
+func (c *MultitenantCompactor) copyTenantBlocks(ctx context.Context, op *mimir_tsdb.TenantOperation, logger log.Logger) error {
+	srcBucket := bucket.NewUserBucketClient(op.SourceTenant, c.bucketClient, c.cfgProvider)
+	dstBucket := bucket.NewUserBucketClient(op.DestinationTenant, c.bucketClient, c.cfgProvider)
+
+	// The data of the blocks moved to the cold storage may not be in the blocks storage anymore.
+	coldBlocks, err := block.ListBlockColdStorageMarks(ctx, srcBucket)
+	if err != nil {
+		return errors.Wrap(err, "list cold storage marks")
+	}
+	if len(coldBlocks) > 0 {
+		return errors.Errorf("the source tenant has %d blocks in the cold storage, which can't be copied", len(coldBlocks))
+	}
+
+	fetcher, err := block.NewMetaFetcher(logger, c.compactorCfg.MetaSyncConcurrency, srcBucket, "", nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	metas, _, err := fetcher.FetchWithoutMarkedForDeletion(ctx)
+	if err != nil {
+		return errors.Wrap(err, "fetch blocks metadata")
+	}
+
+	var pending []*block.Meta
+	for _, meta := range metas {
+		exists, err := dstBucket.Exists(ctx, path.Join(meta.ULID.String(), block.MetaFilename))
+		if err != nil {
+			return errors.Wrapf(err, "check if block %s exists in the destination tenant", meta.ULID)
+		}
+		if !exists {
+			pending = append(pending, meta)
+		}
+	}
+	slices.SortFunc(pending, func(a, b *block.Meta) int {
+		return a.ULID.Compare(b.ULID)
+	})
+
+	op.PendingBlocks = len(pending)
+	if err := c.updateTenantOperation(ctx, op); err != nil {
+		return err
+	}
+
+	for _, meta := range pending {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if err := c.copyTenantBlock(ctx, srcBucket, dstBucket, meta, op.Labels(), logger); err != nil {
+			return errors.Wrapf(err, "copy block %s", meta.ULID)
+		}
+		c.tenantOperationBlocksCopied.Inc()
+		level.Info(logger).Log("msg", "copied block to the destination tenant", "block", meta.ULID)
+
+		op.CopiedBlocks++
+		op.PendingBlocks--
+		if err := c.updateTenantOperation(ctx, op); err != nil {
+			return err
+		}
+	}
+
+	// Rebuild the bucket index, so that the copied blocks are immediately queryable.
+	idx, err := bucketindex.ReadIndex(ctx, c.bucketClient, op.DestinationTenant, c.cfgProvider, logger)
+	if err != nil && !errors.Is(err, bucketindex.ErrIndexNotFound) && !errors.Is(err, bucketindex.ErrIndexCorrupted) {
+		return err
+	}
+	w := bucketindex.NewUpdater(c.bucketClient, op.DestinationTenant, c.cfgProvider, defaultGetDeletionMarkersConcurrency, logger)
+	idx, _, err = w.UpdateIndex(ctx, idx)
+	if err != nil {
+		return errors.Wrap(err, "update bucket index")
+	}
+	return errors.Wrap(bucketindex.WriteIndex(ctx, c.bucketClient, op.DestinationTenant, c.cfgProvider, idx), "write bucket index")
+}
+
+// copyTenantBlock copies the block to the destination bucket. If there are labels to inject, the block is rewritten
+// with the labels injected in all its series, unless the block is quarantined, because its data can't be read.
+// The no-compaction and quarantine marks are copied too, while the meta.json is copied last, so that the block is
+// complete in the destination bucket only once all its objects have been copied.
+func (c *MultitenantCompactor) copyTenantBlock(ctx context.Context, srcBucket, dstBucket objstore.Bucket, meta *block.Meta, inject labels.Labels, logger log.Logger) error {
+	var dataNames, markNames []string
+	err := srcBucket.Iter(ctx, meta.ULID.String(), func(name string) error {
+		switch path.Base(name) {
+		case block.NoCompactMarkFilename, block.QuarantineMarkFilename:
+			markNames = append(markNames, name)
+		case block.MetaFilename, block.DeletionMarkFilename, block.ColdStorageMarkFilename:
+		default:
+			dataNames = append(dataNames, name)
+		}
+		return nil
+	}, objstore.WithRecursiveIter())
+	if err != nil {
+		return errors.Wrap(err, "list block objects")
+	}
+
+	quarantined := slices.Contains(markNames, path.Join(meta.ULID.String(), block.QuarantineMarkFilename))
+	if inject.IsEmpty() || quarantined {
+		for _, name := range append(dataNames, markNames...) {
+			if err := copyObject(ctx, srcBucket, dstBucket, name, logger); err != nil {
+				return err
+			}
+		}
+		return copyObject(ctx, srcBucket, dstBucket, path.Join(meta.ULID.String(), block.MetaFilename), logger)
+	}
+
+	for _, name := range markNames {
+		if err := copyObject(ctx, srcBucket, dstBucket, name, logger); err != nil {
+			return err
+		}
+	}
+	return c.copyTenantBlockWithInjectedLabels(ctx, srcBucket, dstBucket, meta, inject, logger)
+}
+
+// copyTenantBlockWithInjectedLabels downloads the block, rewrites it with the labels injected in all its series,
+// and uploads it to the destination bucket with the same ID.
+func (c *MultitenantCompactor) copyTenantBlockWithInjectedLabels(ctx context.Context, srcBucket, dstBucket objstore.Bucket, meta *block.Meta, inject labels.Labels, logger log.Logger) error {
+	tmpDir, err := os.MkdirTemp(c.compactorCfg.DataDir, "tenant-operation-")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			level.Warn(logger).Log("msg", "failed to remove tenant operation temporary directory", "dir", tmpDir, "err", err)
+		}
+	}()
+
+	bdir := filepath.Join(tmpDir, "source", meta.ULID.String())
+	if err := block.Download(ctx, logger, srcBucket, meta.ULID, bdir); err != nil {
+		return errors.Wrapf(err, "download block %s", meta.ULID)
+	}
+
+	dest := filepath.Join(tmpDir, "destination")
+	if err := writeBlockWithInjectedLabels(ctx, logger, bdir, dest, meta, inject); err != nil {
+		return err
+	}
+	return errors.Wrapf(block.Upload(ctx, logger, dstBucket, filepath.Join(dest, meta.ULID.String()), nil), "upload of %s failed", meta.ULID)
+}
+
+type seriesWithInjectedLabels struct {
+	lset labels.Labels
+	chks []chunks.Meta
+}
+
+// writeBlockWithInjectedLabels writes the block in bdir to dest, with the same ID and the labels injected in all
+// its series and exemplars. It fails if any series already has one of the injected labels.
+func writeBlockWithInjectedLabels(ctx context.Context, logger log.Logger, bdir, dest string, meta *block.Meta, inject labels.Labels) (returnErr error) {
+	// The pool supports the chunks of the downsampled blocks too.
+	b, err := tsdb.OpenBlock(logger, bdir, downsample.NewPool())
+	if err != nil {
+		return errors.Wrapf(err, "open block %s", meta.ULID)
+	}
+	defer func() {
+		if err := b.Close(); err != nil && returnErr == nil {
+			returnErr = errors.Wrap(err, "close block")
+		}
+	}()
+
+	indexr, err := b.Index()
+	if err != nil {
+		return errors.Wrap(err, "open index reader")
+	}
+	defer func() {
+		if err := indexr.Close(); err != nil && returnErr == nil {
+			returnErr = errors.Wrap(err, "close index reader")
+		}
+	}()
+
+	chunkr, err := b.Chunks()
+	if err != nil {
+		return errors.Wrap(err, "open chunk reader")
+	}
+	defer func() {
+		if err := chunkr.Close(); err != nil && returnErr == nil {
+			returnErr = errors.Wrap(err, "close chunk reader")
+		}
+	}()
+
+	// Injecting the labels can change the order of the series, so all the series are read before writing them.
+	series, symbols, err := readSeriesWithInjectedLabels(ctx, indexr, inject)
+	if err != nil {
+		return err
+	}
+
+	blockDir := filepath.Join(dest, meta.ULID.String())
+	chunkw, err := chunks.NewWriter(filepath.Join(blockDir, block.ChunksDirname))
+	if err != nil {
+		return errors.Wrap(err, "create chunk writer")
+	}
+	indexw, err := index.NewWriter(ctx, filepath.Join(blockDir, block.IndexFilename))
+	if err != nil {
+		_ = chunkw.Close()
+		return errors.Wrap(err, "create index writer")
+	}
+
+	stats, err := writeSeriesWithInjectedLabels(ctx, chunkr, chunkw, indexw, series, symbols)
+	if closeErr := chunkw.Close(); closeErr != nil && err == nil {
+		err = errors.Wrap(closeErr, "close chunk writer")
+	}
+	if closeErr := indexw.Close(); closeErr != nil && err == nil {
+		err = errors.Wrap(closeErr, "close index writer")
+	}
+	if err != nil {
+		return err
+	}
+
+	exemplars, err := block.ReadExemplarsFromDir(bdir)
+	if err != nil {
+		return err
+	}
+	if exemplars.Len() > 0 {
+		for i := range exemplars.Series {
+			if exemplars.Series[i].Labels, err = injectLabels(exemplars.Series[i].Labels, inject); err != nil {
+				return err
+			}
+		}
+		if err := block.WriteExemplarsFile(blockDir, block.MergeExemplars(exemplars)); err != nil {
+			return err
+		}
+	}
+
+	metadata, err := os.ReadFile(filepath.Join(bdir, block.MetricsMetadataFilename))
+	if err == nil {
+		err = os.WriteFile(filepath.Join(blockDir, block.MetricsMetadataFilename), metadata, 0o644)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "copy metric metadata")
+	}
+
+	newMeta := *meta
+	newMeta.Stats.NumSeries = stats.NumSeries
+	newMeta.Stats.NumChunks = stats.NumChunks
+	newMeta.Thanos.Files = nil
+	newMeta.Thanos.SegmentFiles = block.GetSegmentFiles(blockDir)
+	if err := newMeta.WriteToDir(logger, blockDir); err != nil {
+		return errors.Wrap(err, "write meta")
+	}
+
+	return errors.Wrapf(block.VerifyBlock(ctx, logger, blockDir, newMeta.MinTime, newMeta.MaxTime, false), "invalid block %s with injected labels", meta.ULID)
+}
+
+// readSeriesWithInjectedLabels returns all the series of the index with the labels injected, sorted by labels,
+// and the sorted symbols of the injected series.
+func readSeriesWithInjectedLabels(ctx context.Context, indexr tsdb.IndexReader, inject labels.Labels) ([]seriesWithInjectedLabels, []string, error) {
+	symbols := map[string]struct{}{}
+	inject.Range(func(l labels.Label) {
+		symbols[l.Name] = struct{}{}
+		symbols[l.Value] = struct{}{}
+	})
+
+	k, v := index.AllPostingsKey()
+	postings, err := indexr.Postings(ctx, k, v)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "get all postings")
+	}
+
+	var (
+		series  []seriesWithInjectedLabels
+		builder labels.ScratchBuilder
+	)
+	for postings.Next() {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+
+		var chks []chunks.Meta
+		if err := indexr.Series(postings.At(), &builder, &chks); err != nil {
+			return nil, nil, errors.Wrapf(err, "get series %d", postings.At())
+		}
+		lset, err := injectLabels(builder.Labels(), inject)
+		if err != nil {
+			return nil, nil, err
+		}
+		lset.Range(func(l labels.Label) {
+			symbols[l.Name] = struct{}{}
+			symbols[l.Value] = struct{}{}
+		})
+		series = append(series, seriesWithInjectedLabels{lset: lset, chks: chks})
+	}
+	if err := postings.Err(); err != nil {
+		return nil, nil, errors.Wrap(err, "iterate postings")
+	}
+
+	slices.SortFunc(series, func(a, b seriesWithInjectedLabels) int {
+		return labels.Compare(a.lset, b.lset)
+	})
+
+	sortedSymbols := make([]string, 0, len(symbols))
+	for s := range symbols {
+		sortedSymbols = append(sortedSymbols, s)
+	}
+	slices.Sort(sortedSymbols)
+
+	return series, sortedSymbols, nil
+}
+
+func writeSeriesWithInjectedLabels(ctx context.Context, chunkr tsdb.ChunkReader, chunkw *chunks.Writer, indexw *index.Writer, series []seriesWithInjectedLabels, symbols []string) (tsdb.BlockStats, error) {
+	var stats tsdb.BlockStats
+
+	for _, s := range symbols {
+		if err := indexw.AddSymbol(s); err != nil {
+			return stats, errors.Wrap(err, "add symbol")
+		}
+	}
+
+	for i, s := range series {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+
+		for j := range s.chks {
+			chk, iterable, err := chunkr.ChunkOrIterable(s.chks[j])
+			if err != nil {
+				return stats, errors.Wrapf(err, "read chunk of series %s", s.lset)
+			}
+			if iterable != nil {
+				return stats, errors.Errorf("unexpected iterable chunk of series %s", s.lset)
+			}
+			s.chks[j].Chunk = chk
+		}
+
+		// The chunks are written again, because the index requires the chunk references to increase with the series.
+		if err := chunkw.WriteChunks(s.chks...); err != nil {
+			return stats, errors.Wrap(err, "write chunks")
+		}
+		if err := indexw.AddSeries(storage.SeriesRef(i), s.lset, s.chks...); err != nil {
+			return stats, errors.Wrap(err, "add series")
+		}
+
+		stats.NumSeries++
+		stats.NumChunks += uint64(len(s.chks))
+	}
+	return stats, nil
+}
+
+// injectLabels returns the series labels with the injected labels, failing if the series already has any of them.
+func injectLabels(lset, inject labels.Labels) (labels.Labels, error) {
+	builder := labels.NewBuilder(lset)
+	var err error
+	inject.Range(func(l labels.Label) {
+		if lset.Has(l.Name) && err == nil {
+			err = errors.Errorf("series %s already has the injected label %s", lset, l.Name)
+		}
+		builder.Set(l.Name, l.Value)
+	})
+	return builder.Labels(), err
+}
+
+// copyTenantRuleGroups copies the rule groups of the source tenant to the destination tenant. When merging the
+// tenants, the rule groups already in the destination tenant are kept.
+func (c *MultitenantCompactor) copyTenantRuleGroups(ctx context.Context, op *mimir_tsdb.TenantOperation) error {
+	if c.ruleStore == nil || op.RulesCopied {
+		return nil
+	}
+
+	groups, err := c.ruleStore.ListRuleGroupsForUserAndNamespace(ctx, op.SourceTenant, "", rulestore.WithCacheDisabled())
+	if err != nil {
+		return err
+	}
+	missing, err := c.ruleStore.LoadRuleGroups(ctx, map[string]rulespb.RuleGroupList{op.SourceTenant: groups})
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		return errors.Errorf("%d rule groups have been deleted while being copied", len(missing))
+	}
+
+	existing := map[string]struct{}{}
+	if op.Type == mimir_tsdb.TenantOperationMerge {
+		destGroups, err := c.ruleStore.ListRuleGroupsForUserAndNamespace(ctx, op.DestinationTenant, "", rulestore.WithCacheDisabled())
+		if err != nil {
+			return err
+		}
+		for _, g := range destGroups {
+			existing[g.Namespace+"/"+g.Name] = struct{}{}
+		}
+	}
+
+	for _, g := range groups {
+		if _, ok := existing[g.Namespace+"/"+g.Name]; ok {
+			continue
+		}
+
+		g.User = op.DestinationTenant
+		if err := c.ruleStore.SetRuleGroup(ctx, op.DestinationTenant, g.Namespace, g); err != nil {
+			return errors.Wrapf(err, "set rule group %s/%s", g.Namespace, g.Name)
+		}
+	}
+
+	op.RulesCopied = true
+	return nil
+}
+
+// copyTenantAlertmanagerConfig copies the alertmanager configuration of the source tenant to the destination tenant.
+// When merging the tenants, the configuration is copied only if the destination tenant has none.
+func (c *MultitenantCompactor) copyTenantAlertmanagerConfig(ctx context.Context, op *mimir_tsdb.TenantOperation) error {
+	if c.alertStore == nil || op.AlertmanagerConfigCopied {
+		return nil
+	}
+
+	cfg, err := c.alertStore.GetAlertConfig(ctx, op.SourceTenant)
+	if errors.Is(err, alertspb.ErrNotFound) {
+		op.AlertmanagerConfigCopied = true
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if op.Type == mimir_tsdb.TenantOperationMerge {
+		_, err := c.alertStore.GetAlertConfig(ctx, op.DestinationTenant)
+		if err == nil {
+			op.AlertmanagerConfigCopied = true
+			return nil
+		}
+		if !errors.Is(err, alertspb.ErrNotFound) {
+			return err
+		}
+	}
+
+	cfg.User = op.DestinationTenant
+	if err := c.alertStore.SetAlertConfig(ctx, cfg); err != nil {
+		return err
+	}
+
+	op.AlertmanagerConfigCopied = true
+	return nil
+}
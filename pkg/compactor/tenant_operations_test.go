@@ -0,0 +1,315 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/alertmanager/alertspb"
+	alertbucketclient "github.com/grafana/mimir/pkg/alertmanager/alertstore/bucketclient"
+	"github.com/grafana/mimir/pkg/ruler/rulespb"
+	rulebucketclient "github.com/grafana/mimir/pkg/ruler/rulestore/bucketclient"
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
+)
+
+func TestStartTenantOperation(t *testing.T) {
+	bkt := objstore.NewInMemBucket()
+	c, _, _, _, _ := prepare(t, prepareConfig(t), bkt)
+	c.bucketClient = bkt
+
+	startOperation := func(params url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/compactor/tenant_operation?"+params.Encode(), nil)
+		resp := httptest.NewRecorder()
+		c.StartTenantOperation(resp, req)
+		return resp
+	}
+
+	params := url.Values{"type": []string{"rename"}, "source": []string{"source"}, "destination": []string{"dest"}, "inject_label": []string{"cluster=a"}}
+
+	t.Run("tenant operations disabled", func(t *testing.T) {
+		require.Equal(t, http.StatusNotFound, startOperation(params).Code)
+	})
+
+	c.compactorCfg.TenantOperationsEnabled = true
+
+	t.Run("invalid tenant", func(t *testing.T) {
+		require.Equal(t, http.StatusBadRequest, startOperation(url.Values{"type": []string{"copy"}, "source": []string{"source"}}).Code)
+	})
+
+	t.Run("invalid type", func(t *testing.T) {
+		require.Equal(t, http.StatusBadRequest, startOperation(url.Values{"type": []string{"move"}, "source": []string{"source"}, "destination": []string{"dest"}}).Code)
+	})
+
+	t.Run("invalid injected label", func(t *testing.T) {
+		require.Equal(t, http.StatusBadRequest, startOperation(url.Values{"type": []string{"copy"}, "source": []string{"source"}, "destination": []string{"dest"}, "inject_label": []string{"cluster"}}).Code)
+	})
+
+	t.Run("operation created", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, startOperation(params).Code)
+
+		ops, err := mimir_tsdb.ReadTenantOperations(context.Background(), bkt, "dest", log.NewNopLogger())
+		require.NoError(t, err)
+		require.Len(t, ops, 1)
+		assert.Equal(t, mimir_tsdb.TenantOperationRename, ops[0].Type)
+		assert.Equal(t, "source", ops[0].SourceTenant)
+		assert.Equal(t, map[string]string{"cluster": "a"}, ops[0].InjectLabels)
+		assert.Equal(t, mimir_tsdb.TenantOperationStatePending, ops[0].State)
+
+		statusResp := httptest.NewRecorder()
+		c.TenantOperationsStatus(statusResp, httptest.NewRequest(http.MethodGet, "/compactor/tenant_operation_status", nil))
+		require.Equal(t, http.StatusOK, statusResp.Code)
+
+		var status TenantOperationsStatusResponse
+		require.NoError(t, json.Unmarshal(statusResp.Body.Bytes(), &status))
+		assert.Equal(t, ops, status.Operations)
+	})
+
+	t.Run("destination with a running operation", func(t *testing.T) {
+		require.Equal(t, http.StatusConflict, startOperation(params).Code)
+	})
+
+	t.Run("destination with blocks", func(t *testing.T) {
+		createTSDBBlock(t, bkt, "other", 0, 100, 1, nil)
+
+		params := url.Values{"type": []string{"copy"}, "source": []string{"source"}, "destination": []string{"other"}}
+		require.Equal(t, http.StatusConflict, startOperation(params).Code)
+
+		// Merging doesn't require an empty destination.
+		params.Set("type", "merge")
+		require.Equal(t, http.StatusOK, startOperation(params).Code)
+	})
+}
+
+func TestMultitenantCompactor_runTenantOperation(t *testing.T) {
+	for _, opType := range mimir_tsdb.TenantOperationTypes {
+		t.Run(opType, func(t *testing.T) {
+			ctx := context.Background()
+			bkt := objstore.NewInMemBucket()
+			storesBkt := objstore.NewInMemBucket()
+
+			c, _, _, _, _ := prepare(t, prepareConfig(t), bkt)
+			c.bucketClient = block.BucketWithGlobalMarkers(bkt)
+			ruleStore := rulebucketclient.NewBucketRuleStore(storesBkt, nil, log.NewNopLogger())
+			alertStore := alertbucketclient.NewBucketAlertStore(alertbucketclient.BucketAlertStoreConfig{}, storesBkt, nil, log.NewNopLogger())
+			c.SetTenantOperationsStores(ruleStore, alertStore)
+
+			block1 := createTSDBBlock(t, bkt, "source", 0, 100, 2, nil)
+			block2 := createTSDBBlock(t, bkt, "source", 100, 200, 2, nil)
+			deleted := createTSDBBlock(t, bkt, "source", 200, 300, 2, nil)
+			require.NoError(t, block.MarkForDeletion(ctx, log.NewNopLogger(), bucket.NewUserBucketClient("source", c.bucketClient, nil), deleted, "", prometheus.NewCounter(prometheus.CounterOpts{})))
+
+			require.NoError(t, ruleStore.SetRuleGroup(ctx, "source", "ns", &rulespb.RuleGroupDesc{Name: "group", Namespace: "ns", User: "source", Rules: []*rulespb.RuleDesc{{Record: "source_rule", Expr: "up"}}}))
+			require.NoError(t, alertStore.SetAlertConfig(ctx, alertspb.AlertConfigDesc{User: "source", RawConfig: "source-config"}))
+
+			// The destination of the merge has its own data, which is kept.
+			var destBlock = createTSDBBlock(t, bkt, "dest", 300, 400, 1, nil)
+			if opType == mimir_tsdb.TenantOperationMerge {
+				require.NoError(t, ruleStore.SetRuleGroup(ctx, "dest", "ns", &rulespb.RuleGroupDesc{Name: "group", Namespace: "ns", User: "dest", Rules: []*rulespb.RuleDesc{{Record: "dest_rule", Expr: "up"}}}))
+				require.NoError(t, alertStore.SetAlertConfig(ctx, alertspb.AlertConfigDesc{User: "dest", RawConfig: "dest-config"}))
+			} else {
+				require.NoError(t, block.Delete(ctx, log.NewNopLogger(), bucket.NewUserBucketClient("dest", bkt, nil), destBlock))
+			}
+
+			op, err := mimir_tsdb.NewTenantOperation(opType, "source", "dest", nil, time.Now())
+			require.NoError(t, err)
+			require.NoError(t, mimir_tsdb.WriteTenantOperation(ctx, bkt, nil, op))
+
+			// Simulate a previous run, interrupted after copying the first block.
+			srcBucket := bucket.NewUserBucketClient("source", bkt, nil)
+			dstBucket := bucket.NewUserBucketClient("dest", bkt, nil)
+			meta1, err := block.DownloadMeta(ctx, log.NewNopLogger(), srcBucket, block1)
+			require.NoError(t, err)
+			require.NoError(t, c.copyTenantBlock(ctx, srcBucket, dstBucket, &meta1, labels.EmptyLabels(), log.NewNopLogger()))
+
+			require.NoError(t, c.runTenantOperation(ctx, op, log.NewNopLogger()))
+			assert.Equal(t, float64(1), testutil.ToFloat64(c.tenantOperationBlocksCopied))
+			assert.Equal(t, float64(1), testutil.ToFloat64(c.tenantOperationsCompleted))
+
+			ops, err := mimir_tsdb.ReadTenantOperations(ctx, bkt, "dest", log.NewNopLogger())
+			require.NoError(t, err)
+			require.Len(t, ops, 1)
+			assert.True(t, ops[0].Completed())
+			assert.Equal(t, 1, ops[0].CopiedBlocks)
+			assert.Equal(t, 0, ops[0].PendingBlocks)
+			assert.True(t, ops[0].RulesCopied)
+			assert.True(t, ops[0].AlertmanagerConfigCopied)
+			assert.Empty(t, ops[0].Error)
+
+			// The bucket index of the destination tenant has been rebuilt.
+			idx, err := bucketindex.ReadIndex(ctx, bkt, "dest", nil, log.NewNopLogger())
+			require.NoError(t, err)
+			expectedBlocks := []ulid.ULID{block1, block2}
+			if opType == mimir_tsdb.TenantOperationMerge {
+				expectedBlocks = append(expectedBlocks, destBlock)
+			}
+			assert.ElementsMatch(t, expectedBlocks, idx.Blocks.GetULIDs())
+
+			groups, err := ruleStore.ListRuleGroupsForUserAndNamespace(ctx, "dest", "")
+			require.NoError(t, err)
+			_, err = ruleStore.LoadRuleGroups(ctx, map[string]rulespb.RuleGroupList{"dest": groups})
+			require.NoError(t, err)
+			require.Len(t, groups, 1)
+			assert.Equal(t, "dest", groups[0].User)
+
+			alertConfig, err := alertStore.GetAlertConfig(ctx, "dest")
+			require.NoError(t, err)
+
+			sourceMarked, err := mimir_tsdb.TenantDeletionMarkExists(ctx, bkt, "source")
+			require.NoError(t, err)
+			sourceGroups, err := ruleStore.ListRuleGroupsForUserAndNamespace(ctx, "source", "")
+			require.NoError(t, err)
+			_, sourceAlertConfigErr := alertStore.GetAlertConfig(ctx, "source")
+
+			switch opType {
+			case mimir_tsdb.TenantOperationCopy:
+				assert.Equal(t, "source_rule", groups[0].Rules[0].Record)
+				assert.Equal(t, "source-config", alertConfig.RawConfig)
+				assert.False(t, sourceMarked)
+				assert.Len(t, sourceGroups, 1)
+				assert.NoError(t, sourceAlertConfigErr)
+			case mimir_tsdb.TenantOperationRename:
+				assert.Equal(t, "source_rule", groups[0].Rules[0].Record)
+				assert.Equal(t, "source-config", alertConfig.RawConfig)
+				assert.True(t, sourceMarked)
+				assert.Empty(t, sourceGroups)
+				assert.ErrorIs(t, sourceAlertConfigErr, alertspb.ErrNotFound)
+			case mimir_tsdb.TenantOperationMerge:
+				assert.Equal(t, "dest_rule", groups[0].Rules[0].Record)
+				assert.Equal(t, "dest-config", alertConfig.RawConfig)
+				assert.True(t, sourceMarked)
+				assert.Empty(t, sourceGroups)
+				assert.ErrorIs(t, sourceAlertConfigErr, alertspb.ErrNotFound)
+			}
+		})
+	}
+}
+
+func TestMultitenantCompactor_runTenantOperation_ShouldFailWithColdStorageBlocks(t *testing.T) {
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+	c, _, _, _, _ := prepare(t, prepareConfig(t), bkt)
+	c.bucketClient = block.BucketWithGlobalMarkers(bkt)
+
+	blockID := createTSDBBlock(t, bkt, "source", 0, 100, 2, nil)
+	require.NoError(t, block.MarkForColdStorage(ctx, log.NewNopLogger(), bucket.NewUserBucketClient("source", c.bucketClient, nil), blockID, "", prometheus.NewCounter(prometheus.CounterOpts{})))
+
+	op, err := mimir_tsdb.NewTenantOperation(mimir_tsdb.TenantOperationCopy, "source", "dest", nil, time.Now())
+	require.NoError(t, err)
+	require.ErrorContains(t, c.runTenantOperation(ctx, op, log.NewNopLogger()), "cold storage")
+
+	ops, err := mimir_tsdb.ReadTenantOperations(ctx, bkt, "dest", log.NewNopLogger())
+	require.NoError(t, err)
+	require.Len(t, ops, 1)
+	assert.Equal(t, mimir_tsdb.TenantOperationStateRunning, ops[0].State)
+	assert.Contains(t, ops[0].Error, "cold storage")
+}
+
+func TestMultitenantCompactor_copyTenantBlock_WithInjectedLabels(t *testing.T) {
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+	c, _, _, _, _ := prepare(t, prepareConfig(t), bkt)
+
+	srcBucket := bucket.NewUserBucketClient("source", bkt, nil)
+	dstBucket := bucket.NewUserBucketClient("dest", bkt, nil)
+
+	// Creates series with series_id from 0 to 4.
+	blockID := createTSDBBlock(t, bkt, "source", 0, 100, 5, map[string]string{"a": "1"})
+
+	// Add the exemplars of a series.
+	exemplarsDir := t.TempDir()
+	require.NoError(t, block.WriteExemplarsFile(exemplarsDir, &block.Exemplars{Series: []block.ExemplarSeries{{
+		Labels:    labels.FromStrings("series_id", "1"),
+		Exemplars: []block.Exemplar{{Labels: labels.FromStrings("trace_id", "abc"), Value: 1, Timestamp: 10}},
+	}}}))
+	require.NoError(t, objstore.UploadFile(ctx, log.NewNopLogger(), srcBucket, filepath.Join(exemplarsDir, block.ExemplarsFilename), path.Join(blockID.String(), block.ExemplarsFilename)))
+
+	meta, err := block.DownloadMeta(ctx, log.NewNopLogger(), srcBucket, blockID)
+	require.NoError(t, err)
+
+	t.Run("series already having an injected label", func(t *testing.T) {
+		err := c.copyTenantBlock(ctx, srcBucket, dstBucket, &meta, labels.FromStrings("series_id", "x"), log.NewNopLogger())
+		require.ErrorContains(t, err, "already has the injected label series_id")
+
+		exists, err := dstBucket.Exists(ctx, path.Join(blockID.String(), block.MetaFilename))
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("labels injected", func(t *testing.T) {
+		require.NoError(t, c.copyTenantBlock(ctx, srcBucket, dstBucket, &meta, labels.FromStrings("cluster", "b", "zone", "z"), log.NewNopLogger()))
+
+		newMeta, err := block.DownloadMeta(ctx, log.NewNopLogger(), dstBucket, blockID)
+		require.NoError(t, err)
+		assert.Equal(t, meta.Thanos.Labels, newMeta.Thanos.Labels)
+		assert.Equal(t, meta.Stats.NumSeries, newMeta.Stats.NumSeries)
+		assert.Equal(t, meta.Stats.NumSamples, newMeta.Stats.NumSamples)
+
+		dir := filepath.Join(t.TempDir(), blockID.String())
+		require.NoError(t, block.Download(ctx, log.NewNopLogger(), dstBucket, blockID, dir))
+		b, err := tsdb.OpenBlock(log.NewNopLogger(), dir, nil)
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, b.Close()) })
+
+		q, err := tsdb.NewBlockQuerier(b, 0, 100)
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, q.Close()) })
+
+		set := q.Select(ctx, true, nil, labels.MustNewMatcher(labels.MatchEqual, "cluster", "b"))
+		var series []string
+		for set.Next() {
+			series = append(series, set.At().Labels().String())
+			samples := 0
+			it := set.At().Iterator(nil)
+			for it.Next() != 0 {
+				samples++
+			}
+			require.NoError(t, it.Err())
+			assert.Equal(t, 1, samples)
+		}
+		require.NoError(t, set.Err())
+		assert.Equal(t, []string{
+			`{cluster="b", series_id="0", zone="z"}`,
+			`{cluster="b", series_id="1", zone="z"}`,
+			`{cluster="b", series_id="2", zone="z"}`,
+			`{cluster="b", series_id="3", zone="z"}`,
+			`{cluster="b", series_id="4", zone="z"}`,
+		}, series)
+
+		exemplars, err := block.ReadExemplarsFromDir(dir)
+		require.NoError(t, err)
+		require.Len(t, exemplars.Series, 1)
+		assert.Equal(t, labels.FromStrings("cluster", "b", "series_id", "1", "zone", "z"), exemplars.Series[0].Labels)
+	})
+}
+
+func TestInjectLabels(t *testing.T) {
+	lset, err := injectLabels(labels.FromStrings("__name__", "up", "job", "a"), labels.FromStrings("cluster", "b"))
+	require.NoError(t, err)
+	assert.Equal(t, labels.FromStrings("__name__", "up", "cluster", "b", "job", "a"), lset)
+
+	_, err = injectLabels(labels.FromStrings("__name__", "up", "cluster", "a"), labels.FromStrings("cluster", "b"))
+	require.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), "already has the injected label cluster"))
+}
@@ -0,0 +1,136 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/thanos-io/objstore"
+	"golang.org/x/time/rate"
+)
+
+// tenantThroughputLimiters holds the limiters of the bytes per second downloaded and uploaded by the compaction
+// jobs of each tenant. The limiters are shared by all the jobs of a tenant running in the compactor, and the
+// tenant limit is evenly split between the compactors which can run the jobs of the tenant.
+type tenantThroughputLimiters struct {
+	cfgProvider ConfigProvider
+
+	// compactorsCount returns the number of compactors which can run the compaction jobs of a tenant.
+	compactorsCount func(userID string) int
+
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+func newTenantThroughputLimiters(cfgProvider ConfigProvider, compactorsCount func(userID string) int) *tenantThroughputLimiters {
+	return &tenantThroughputLimiters{
+		cfgProvider:     cfgProvider,
+		compactorsCount: compactorsCount,
+		limiters:        map[string]*rate.Limiter{},
+	}
+}
+
+// limiter returns the limiter of the tenant, or nil if its compaction throughput isn't limited.
+func (l *tenantThroughputLimiters) limiter(userID string) *rate.Limiter {
+	bytesPerSecond := l.cfgProvider.CompactorMaxThroughputBytesPerSecond(userID)
+	if compactors := l.compactorsCount(userID); bytesPerSecond > 0 && compactors > 1 {
+		// Each compactor gets at least 1 byte per second, so that the jobs can make progress.
+		bytesPerSecond = max(1, bytesPerSecond/int64(compactors))
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if bytesPerSecond <= 0 {
+		delete(l.limiters, userID)
+		return nil
+	}
+
+	// The burst allows to read or write up to 1 second worth of bytes at once.
+	limit, burst := rate.Limit(bytesPerSecond), int(bytesPerSecond)
+	lim, ok := l.limiters[userID]
+	if !ok {
+		lim = rate.NewLimiter(limit, burst)
+		l.limiters[userID] = lim
+	} else if lim.Limit() != limit {
+		lim.SetLimit(limit)
+		lim.SetBurst(burst)
+	}
+	return lim
+}
+
+// bucket returns the bucket used by the compaction jobs of the tenant, limiting their throughput if configured.
+func (l *tenantThroughputLimiters) bucket(userID string, bkt objstore.Bucket) objstore.Bucket {
+	if lim := l.limiter(userID); lim != nil {
+		return &throughputLimitedBucket{Bucket: bkt, limiter: lim}
+	}
+	return bkt
+}
+
+// throughputLimitedBucket limits the bytes per second read from the objects and uploaded to the bucket.
+type throughputLimitedBucket struct {
+	objstore.Bucket
+	limiter *rate.Limiter
+}
+
+func (b *throughputLimitedBucket) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	r, err := b.Bucket.Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return &throughputLimitedReadCloser{ReadCloser: r, reader: throughputLimitedReader{ctx: ctx, r: r, limiter: b.limiter}}, nil
+}
+
+func (b *throughputLimitedBucket) GetRange(ctx context.Context, name string, off, length int64) (io.ReadCloser, error) {
+	r, err := b.Bucket.GetRange(ctx, name, off, length)
+	if err != nil {
+		return nil, err
+	}
+	return &throughputLimitedReadCloser{ReadCloser: r, reader: throughputLimitedReader{ctx: ctx, r: r, limiter: b.limiter}}, nil
+}
+
+func (b *throughputLimitedBucket) Upload(ctx context.Context, name string, r io.Reader) error {
+	return b.Bucket.Upload(ctx, name, &throughputLimitedReader{ctx: ctx, r: r, limiter: b.limiter})
+}
+
+// throughputLimitedReader waits for the limiter after each read.
+type throughputLimitedReader struct {
+	ctx     context.Context
+	r       io.Reader
+	limiter *rate.Limiter
+}
+
+func (r *throughputLimitedReader) Read(p []byte) (int, error) {
+	// The limiter doesn't allow to wait for more bytes than its burst.
+	if burst := r.limiter.Burst(); len(p) > burst {
+		p = p[:burst]
+	}
+
+	n, err := r.r.Read(p)
+	if n > 0 {
+		if waitErr := r.limiter.WaitN(r.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
+
+// ObjectSize returns the size of the wrapped reader, which some bucket clients need to upload the object.
+func (r *throughputLimitedReader) ObjectSize() (int64, error) {
+	return objstore.TryToGetSize(r.r)
+}
+
+type throughputLimitedReadCloser struct {
+	io.ReadCloser
+	reader throughputLimitedReader
+}
+
+func (r *throughputLimitedReadCloser) Read(p []byte) (int, error) {
+	return r.reader.Read(p)
+}
+
+func (r *throughputLimitedReadCloser) ObjectSize() (int64, error) {
+	return r.reader.ObjectSize()
+}
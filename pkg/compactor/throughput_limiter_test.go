@@ -0,0 +1,86 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+)
+
+func TestTenantThroughputLimiters(t *testing.T) {
+	cfgProvider := newMockConfigProvider()
+	compactors := map[string]int{}
+	limiters := newTenantThroughputLimiters(cfgProvider, func(userID string) int { return compactors[userID] })
+	bkt := objstore.NewInMemBucket()
+
+	// The bucket isn't wrapped when the throughput isn't limited.
+	assert.Nil(t, limiters.limiter("user-1"))
+	assert.Same(t, bkt, limiters.bucket("user-1", bkt))
+
+	cfgProvider.maxThroughputBytesPerSecond["user-1"] = 1000
+	lim := limiters.limiter("user-1")
+	require.NotNil(t, lim)
+	assert.Equal(t, 1000, lim.Burst())
+
+	// The limiter is shared by the jobs of the tenant, and updated when the limit changes.
+	assert.Same(t, lim, limiters.limiter("user-1"))
+	cfgProvider.maxThroughputBytesPerSecond["user-1"] = 2000
+	assert.Same(t, lim, limiters.limiter("user-1"))
+	assert.Equal(t, 2000, lim.Burst())
+
+	// The limit is split between the compactors running the jobs of the tenant.
+	compactors["user-1"] = 4
+	assert.Same(t, lim, limiters.limiter("user-1"))
+	assert.Equal(t, 500, lim.Burst())
+
+	compactors["user-1"] = 3000
+	assert.Equal(t, 1, limiters.limiter("user-1").Burst())
+
+	cfgProvider.maxThroughputBytesPerSecond["user-1"] = 0
+	assert.Nil(t, limiters.limiter("user-1"))
+}
+
+func TestThroughputLimitedBucket(t *testing.T) {
+	ctx := context.Background()
+	cfgProvider := newMockConfigProvider()
+	cfgProvider.maxThroughputBytesPerSecond["user-1"] = 1000
+
+	inmem := objstore.NewInMemBucket()
+	bkt := newTenantThroughputLimiters(cfgProvider, func(string) int { return 1 }).bucket("user-1", inmem)
+
+	// The first second worth of bytes is uploaded immediately, then the upload is throttled.
+	data := bytes.Repeat([]byte("a"), 1500)
+	start := time.Now()
+	require.NoError(t, bkt.Upload(ctx, "object", bytes.NewReader(data)))
+	assert.GreaterOrEqual(t, time.Since(start), 400*time.Millisecond)
+	assert.Equal(t, data, inmem.Objects()["object"])
+
+	start = time.Now()
+	r, err := bkt.Get(ctx, "object")
+	require.NoError(t, err)
+	read, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	assert.Equal(t, data, read)
+	assert.GreaterOrEqual(t, time.Since(start), 1400*time.Millisecond)
+
+	// The size of the uploaded objects is preserved.
+	size, err := objstore.TryToGetSize(&throughputLimitedReader{r: bytes.NewReader(data)})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1500), size)
+
+	// Reads are interrupted when the context is canceled.
+	canceledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	r, err = bkt.GetRange(canceledCtx, "object", 0, 100)
+	require.NoError(t, err)
+	_, err = io.ReadAll(r)
+	require.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,307 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package deadletter captures a bounded sample of the series rejected on the write path, so that tenants
+// can inspect what has been discarded and why, instead of only seeing the discarded samples counters.
+package deadletter
+
+import (
+	"context"
+	"flag"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/services"
+	"github.com/grafana/dskit/tenant"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/storage/ingest"
+	"github.com/grafana/mimir/pkg/util"
+)
+
+const (
+	// maxRecentRecordsPerTenant is the number of recently flushed records kept in memory for each tenant,
+	// used to serve the API when the sink can't be read back.
+	maxRecentRecordsPerTenant = 100
+
+	defaultHandlerLimit = 100
+)
+
+var errMissingBucket = errors.New("the dead letter sink requires an object storage bucket when ingest storage is disabled")
+
+// Config holds the dead letter configuration of a component.
+type Config struct {
+	Enabled       bool          `yaml:"enabled" category:"experimental"`
+	FlushInterval time.Duration `yaml:"flush_interval" category:"experimental"`
+	Retention     time.Duration `yaml:"retention" category:"experimental"`
+	KafkaTopic    string        `yaml:"kafka_topic" category:"experimental"`
+}
+
+// RegisterFlagsWithPrefix registers the flags with the given prefix, like "distributor.".
+func (cfg *Config) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, prefix+"dead-letter.enabled", false, "True to capture a sample of the rejected series of the tenants which have enabled the dead letter, and write them to the object storage, or to a Kafka topic when ingest storage is enabled.")
+	f.DurationVar(&cfg.FlushInterval, prefix+"dead-letter.flush-interval", time.Minute, "How frequently the captured rejected series are written to the dead letter sink.")
+	f.DurationVar(&cfg.Retention, prefix+"dead-letter.retention", 24*time.Hour, "How long the rejected series written to the object storage are retained. Doesn't apply to the Kafka topic, whose retention is configured in Kafka.")
+	f.StringVar(&cfg.KafkaTopic, prefix+"dead-letter.kafka-topic", "mimir-dead-letter", "The Kafka topic the rejected series are written to when ingest storage is enabled.")
+}
+
+// Validate the config.
+func (cfg *Config) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.FlushInterval <= 0 {
+		return errors.New("the dead letter flush interval must be greater than 0")
+	}
+	if cfg.Retention <= 0 {
+		return errors.New("the dead letter retention must be greater than 0")
+	}
+	return nil
+}
+
+// Limits are the per-tenant limits used by the Recorder.
+type Limits interface {
+	DeadLetterEnabled(userID string) bool
+	DeadLetterMaxSeriesPerReason(userID string) int
+}
+
+// Record is a rejected series captured by the dead letter.
+type Record struct {
+	// Time is when the series has been rejected.
+	Time time.Time `json:"time"`
+	// Component and Instance which rejected the series.
+	Component string `json:"component"`
+	Instance  string `json:"instance"`
+	// Reason is the same reason used by the discarded samples metrics.
+	Reason string `json:"reason"`
+	Series string `json:"series"`
+	// TimestampMs is the timestamp of the rejected sample.
+	TimestampMs int64  `json:"timestamp_ms"`
+	Message     string `json:"message,omitempty"`
+}
+
+type tenantRecords struct {
+	records   []Record
+	perReason map[string]int
+}
+
+// Recorder captures the rejected series of the tenants which enabled the dead letter, and periodically
+// flushes them to the sink. For each tenant, at most DeadLetterMaxSeriesPerReason series are captured for
+// each reason between two flushes, the others are only counted.
+type Recorder struct {
+	services.Service
+
+	cfg        Config
+	component  string
+	instanceID string
+	limits     Limits
+	sink       Sink
+	logger     log.Logger
+
+	mtx     sync.Mutex
+	pending map[string]*tenantRecords
+	recent  map[string][]Record
+
+	capturedRecords *prometheus.CounterVec
+	skippedRecords  *prometheus.CounterVec
+	flushFailures   prometheus.Counter
+}
+
+// NewRecorder creates a Recorder which writes to a Kafka topic when ingest storage is enabled, or to the
+// input bucket otherwise.
+func NewRecorder(cfg Config, component, instanceID string, limits Limits, bkt objstore.Bucket, ingestCfg ingest.Config, logger log.Logger, reg prometheus.Registerer) (*Recorder, error) {
+	var sink Sink
+	if ingestCfg.Enabled {
+		kafkaSink, err := newKafkaSink(cfg, ingestCfg.KafkaConfig, logger, reg)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create the dead letter Kafka sink")
+		}
+		sink = kafkaSink
+	} else {
+		if bkt == nil {
+			return nil, errMissingBucket
+		}
+		sink = newBucketSink(bkt, cfg.Retention, component, instanceID, logger)
+	}
+
+	return newRecorder(cfg, component, instanceID, limits, sink, logger, reg), nil
+}
+
+func newRecorder(cfg Config, component, instanceID string, limits Limits, sink Sink, logger log.Logger, reg prometheus.Registerer) *Recorder {
+	r := &Recorder{
+		cfg:        cfg,
+		component:  component,
+		instanceID: instanceID,
+		limits:     limits,
+		sink:       sink,
+		logger:     log.With(logger, "component", "dead-letter"),
+		pending:    map[string]*tenantRecords{},
+		recent:     map[string][]Record{},
+
+		capturedRecords: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_dead_letter_records_captured_total",
+			Help: "The total number of rejected series captured by the dead letter.",
+		}, []string{"user", "reason"}),
+		skippedRecords: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_dead_letter_records_skipped_total",
+			Help: "The total number of rejected series not captured by the dead letter because the max series per reason has been reached.",
+		}, []string{"user", "reason"}),
+		flushFailures: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_dead_letter_flush_failures_total",
+			Help: "The total number of failures writing the captured rejected series to the dead letter sink.",
+		}),
+	}
+
+	r.Service = services.NewTimerService(cfg.FlushInterval, nil, r.iteration, r.stopping)
+	return r
+}
+
+// Record captures a rejected series, if the tenant enabled the dead letter. The labels are copied, so
+// they can be safely reused after this function returns. It's safe to call on a nil Recorder.
+func (r *Recorder) Record(userID, reason string, lbls []mimirpb.LabelAdapter, timestampMs int64, message string) {
+	if r == nil || !r.limits.DeadLetterEnabled(userID) {
+		return
+	}
+
+	maxPerReason := r.limits.DeadLetterMaxSeriesPerReason(userID)
+
+	r.mtx.Lock()
+	pending, ok := r.pending[userID]
+	if !ok {
+		pending = &tenantRecords{perReason: map[string]int{}}
+		r.pending[userID] = pending
+	}
+	if maxPerReason > 0 && pending.perReason[reason] >= maxPerReason {
+		r.mtx.Unlock()
+		r.skippedRecords.WithLabelValues(userID, reason).Inc()
+		return
+	}
+	pending.perReason[reason]++
+	pending.records = append(pending.records, Record{
+		Time:        time.Now(),
+		Component:   r.component,
+		Instance:    r.instanceID,
+		Reason:      reason,
+		Series:      mimirpb.FromLabelAdaptersToString(lbls),
+		TimestampMs: timestampMs,
+		Message:     message,
+	})
+	r.mtx.Unlock()
+
+	r.capturedRecords.WithLabelValues(userID, reason).Inc()
+}
+
+func (r *Recorder) iteration(ctx context.Context) error {
+	r.flush(ctx)
+
+	if expirer, ok := r.sink.(Expirer); ok {
+		if err := expirer.DeleteExpired(ctx); err != nil {
+			level.Warn(r.logger).Log("msg", "failed to delete the expired rejected series from the dead letter sink", "err", err)
+		}
+	}
+	return nil
+}
+
+func (r *Recorder) stopping(_ error) error {
+	// Flush the records captured since the last iteration, to not lose them on shutdown.
+	r.flush(context.Background())
+
+	if closer, ok := r.sink.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func (r *Recorder) flush(ctx context.Context) {
+	r.mtx.Lock()
+	pending := r.pending
+	r.pending = map[string]*tenantRecords{}
+	r.mtx.Unlock()
+
+	for userID, p := range pending {
+		if err := r.sink.Write(ctx, userID, p.records); err != nil {
+			r.flushFailures.Inc()
+			level.Warn(r.logger).Log("msg", "failed to write rejected series to the dead letter sink", "user", userID, "records", len(p.records), "err", err)
+		}
+
+		r.mtx.Lock()
+		recent := append(r.recent[userID], p.records...)
+		if len(recent) > maxRecentRecordsPerTenant {
+			recent = recent[len(recent)-maxRecentRecordsPerTenant:]
+		}
+		r.recent[userID] = recent
+		r.mtx.Unlock()
+	}
+}
+
+// RecentRecords returns up to limit of the most recent records of the tenant, newest first. Records are read
+// back from the sink when supported, so they include the records of all the instances, otherwise only the
+// records flushed by this instance are returned.
+func (r *Recorder) RecentRecords(ctx context.Context, userID string, limit int) ([]Record, error) {
+	if reader, ok := r.sink.(Reader); ok {
+		return reader.Read(ctx, userID, limit)
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	recent := r.recent[userID]
+	out := make([]Record, 0, min(limit, len(recent)))
+	for i := len(recent) - 1; i >= 0 && len(out) < limit; i-- {
+		out = append(out, recent[i])
+	}
+	return out, nil
+}
+
+// RemoveTenant removes the metrics and the in-memory records of the tenant.
+func (r *Recorder) RemoveTenant(userID string) {
+	if r == nil {
+		return
+	}
+
+	r.mtx.Lock()
+	delete(r.recent, userID)
+	r.mtx.Unlock()
+
+	filter := prometheus.Labels{"user": userID}
+	r.capturedRecords.DeletePartialMatch(filter)
+	r.skippedRecords.DeletePartialMatch(filter)
+}
+
+type recordsResponse struct {
+	Records []Record `json:"records"`
+}
+
+// Handler serves the recent rejected series of the tenant.
+func (r *Recorder) Handler(w http.ResponseWriter, req *http.Request) {
+	userID, err := tenant.TenantID(req.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	limit := defaultHandlerLimit
+	if v := req.FormValue("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+	}
+
+	records, err := r.RecentRecords(req.Context(), userID, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	util.WriteJSONResponse(w, recordsResponse{Records: records})
+}
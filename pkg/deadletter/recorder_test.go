@@ -0,0 +1,208 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package deadletter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/services"
+	"github.com/grafana/dskit/user"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/storage/ingest"
+)
+
+type mockLimits struct {
+	enabled      map[string]bool
+	maxPerReason int
+}
+
+func (m mockLimits) DeadLetterEnabled(userID string) bool {
+	return m.enabled[userID]
+}
+
+func (m mockLimits) DeadLetterMaxSeriesPerReason(string) int {
+	return m.maxPerReason
+}
+
+func testConfig() Config {
+	return Config{Enabled: true, FlushInterval: time.Hour, Retention: 24 * time.Hour, KafkaTopic: "dead-letter"}
+}
+
+func series(name string) []mimirpb.LabelAdapter {
+	return []mimirpb.LabelAdapter{{Name: "__name__", Value: name}, {Name: "job", Value: "test"}}
+}
+
+func TestRecorder_Record(t *testing.T) {
+	bkt := objstore.NewInMemBucket()
+	reg := prometheus.NewPedanticRegistry()
+	limits := mockLimits{enabled: map[string]bool{"user-1": true}, maxPerReason: 2}
+
+	r, err := NewRecorder(testConfig(), "distributor", "distributor-1", limits, bkt, ingest.Config{}, log.NewNopLogger(), reg)
+	require.NoError(t, err)
+
+	r.Record("user-1", "too_far_in_past", series("metric_1"), 1000, "sample too old")
+	r.Record("user-1", "too_far_in_past", series("metric_2"), 2000, "sample too old")
+	r.Record("user-1", "too_far_in_past", series("metric_3"), 3000, "sample too old")
+	r.Record("user-1", "label_value_too_long", series("metric_4"), 4000, "label value too long")
+	// The dead letter isn't enabled for this tenant.
+	r.Record("user-2", "too_far_in_past", series("metric_1"), 1000, "sample too old")
+
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_dead_letter_records_captured_total The total number of rejected series captured by the dead letter.
+		# TYPE cortex_dead_letter_records_captured_total counter
+		cortex_dead_letter_records_captured_total{reason="label_value_too_long",user="user-1"} 1
+		cortex_dead_letter_records_captured_total{reason="too_far_in_past",user="user-1"} 2
+
+		# HELP cortex_dead_letter_records_skipped_total The total number of rejected series not captured by the dead letter because the max series per reason has been reached.
+		# TYPE cortex_dead_letter_records_skipped_total counter
+		cortex_dead_letter_records_skipped_total{reason="too_far_in_past",user="user-1"} 1
+	`), "cortex_dead_letter_records_captured_total", "cortex_dead_letter_records_skipped_total"))
+
+	r.flush(context.Background())
+
+	records, err := r.RecentRecords(context.Background(), "user-1", 10)
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+
+	// Records are returned newest first.
+	assert.Equal(t, "label_value_too_long", records[0].Reason)
+	assert.Equal(t, `metric_4{job="test"}`, records[0].Series)
+	assert.Equal(t, int64(4000), records[0].TimestampMs)
+	assert.Equal(t, "distributor", records[0].Component)
+	assert.Equal(t, "distributor-1", records[0].Instance)
+	assert.Equal(t, `metric_2{job="test"}`, records[1].Series)
+	assert.Equal(t, `metric_1{job="test"}`, records[2].Series)
+
+	records, err = r.RecentRecords(context.Background(), "user-2", 10)
+	require.NoError(t, err)
+	assert.Empty(t, records)
+
+	// The per-reason limit is reset after each flush.
+	r.Record("user-1", "too_far_in_past", series("metric_5"), 5000, "sample too old")
+	r.flush(context.Background())
+
+	records, err = r.RecentRecords(context.Background(), "user-1", 2)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, `metric_5{job="test"}`, records[0].Series)
+	assert.Equal(t, `metric_4{job="test"}`, records[1].Series)
+}
+
+func TestRecorder_Record_NilRecorder(t *testing.T) {
+	var r *Recorder
+	r.Record("user-1", "too_far_in_past", series("metric_1"), 1000, "")
+	r.RemoveTenant("user-1")
+}
+
+func TestRecorder_FlushOnStop(t *testing.T) {
+	bkt := objstore.NewInMemBucket()
+	limits := mockLimits{enabled: map[string]bool{"user-1": true}}
+
+	r, err := NewRecorder(testConfig(), "ingester", "ingester-1", limits, bkt, ingest.Config{}, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), r))
+
+	r.Record("user-1", "sample-out-of-order", series("metric_1"), 1000, "")
+	require.NoError(t, services.StopAndAwaitTerminated(context.Background(), r))
+
+	// A new recorder reads the records back from the bucket.
+	r, err = NewRecorder(testConfig(), "distributor", "distributor-1", limits, bkt, ingest.Config{}, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+
+	records, err := r.RecentRecords(context.Background(), "user-1", 10)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "ingester", records[0].Component)
+	assert.Equal(t, "sample-out-of-order", records[0].Reason)
+}
+
+func TestRecorder_Handler(t *testing.T) {
+	bkt := objstore.NewInMemBucket()
+	limits := mockLimits{enabled: map[string]bool{"user-1": true}}
+
+	r, err := NewRecorder(testConfig(), "distributor", "distributor-1", limits, bkt, ingest.Config{}, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+
+	r.Record("user-1", "too_far_in_past", series("metric_1"), 1000, "sample too old")
+	r.Record("user-1", "too_far_in_past", series("metric_2"), 2000, "sample too old")
+	r.flush(context.Background())
+
+	t.Run("returns the recent records of the tenant", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/dead_letter?limit=1", nil)
+		req = req.WithContext(user.InjectOrgID(req.Context(), "user-1"))
+		rec := httptest.NewRecorder()
+		r.Handler(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		var resp recordsResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.Len(t, resp.Records, 1)
+		assert.Equal(t, `metric_2{job="test"}`, resp.Records[0].Series)
+		assert.Equal(t, "sample too old", resp.Records[0].Message)
+	})
+
+	t.Run("requires a tenant", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		r.Handler(rec, httptest.NewRequest(http.MethodGet, "/api/v1/dead_letter", nil))
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("rejects an invalid limit", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/dead_letter?limit=-1", nil)
+		req = req.WithContext(user.InjectOrgID(req.Context(), "user-1"))
+		rec := httptest.NewRecorder()
+		r.Handler(rec, req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
+
+func TestNewRecorder_MissingBucket(t *testing.T) {
+	_, err := NewRecorder(testConfig(), "distributor", "distributor-1", mockLimits{}, nil, ingest.Config{}, log.NewNopLogger(), nil)
+	require.ErrorIs(t, err, errMissingBucket)
+}
+
+type memorySink struct {
+	records map[string][]Record
+}
+
+func (s *memorySink) Write(_ context.Context, userID string, records []Record) error {
+	s.records[userID] = append(s.records[userID], records...)
+	return nil
+}
+
+func TestRecorder_RecentRecords_SinkWithoutReader(t *testing.T) {
+	sink := &memorySink{records: map[string][]Record{}}
+	limits := mockLimits{enabled: map[string]bool{"user-1": true}}
+	r := newRecorder(testConfig(), "distributor", "distributor-1", limits, sink, log.NewNopLogger(), nil)
+
+	for i := 0; i < maxRecentRecordsPerTenant+10; i++ {
+		r.Record("user-1", "too_far_in_past", series("metric"), int64(i), "")
+	}
+	r.flush(context.Background())
+
+	require.Len(t, sink.records["user-1"], maxRecentRecordsPerTenant+10)
+
+	// Only the most recent records are kept in memory.
+	records, err := r.RecentRecords(context.Background(), "user-1", 1000)
+	require.NoError(t, err)
+	require.Len(t, records, maxRecentRecordsPerTenant)
+	assert.Equal(t, int64(maxRecentRecordsPerTenant+9), records[0].TimestampMs)
+
+	r.RemoveTenant("user-1")
+	records, err = r.RecentRecords(context.Background(), "user-1", 1000)
+	require.NoError(t, err)
+	assert.Empty(t, records)
+}
@@ -0,0 +1,399 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package deadletter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/objstore"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"go.uber.org/atomic"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	"github.com/grafana/mimir/pkg/storage/ingest"
+	"github.com/grafana/mimir/pkg/storage/tsdb"
+)
+
+const (
+	// BucketPrefix is the prefix of the dead letter objects, inside the tenant prefix.
+	BucketPrefix = "dead-letter"
+
+	// bucketCleanupInterval is how frequently the expired objects of all the tenants are deleted.
+	bucketCleanupInterval = time.Hour
+
+	kafkaMaxInflightProduceRequests = 20
+
+	// kafkaPartitionsRefreshInterval is how frequently the number of partitions of the topic is refreshed.
+	kafkaPartitionsRefreshInterval = time.Minute
+
+	// kafkaReadMaxRecords is the maximum number of records read back from the tenant partition.
+	kafkaReadMaxRecords = 10_000
+	kafkaReadTimeout    = 10 * time.Second
+)
+
+// Sink is where the captured records are written.
+type Sink interface {
+	Write(ctx context.Context, userID string, records []Record) error
+}
+
+// Reader is implemented by the sinks which can read back the records.
+type Reader interface {
+	// Read returns up to limit of the most recent records of the tenant, newest first.
+	Read(ctx context.Context, userID string, limit int) ([]Record, error)
+}
+
+// Expirer is implemented by the sinks which delete the expired records themselves.
+type Expirer interface {
+	// DeleteExpired deletes the expired records of all the tenants.
+	DeleteExpired(ctx context.Context) error
+}
+
+// bucketSink writes the records of each flush to a JSON lines object in the tenant prefix. Object names
+// start with the zero-padded flush time in milliseconds, so that they're listed in chronological order,
+// followed by a sequence number to not overwrite objects written in the same millisecond.
+type bucketSink struct {
+	bkt        objstore.Bucket
+	retention  time.Duration
+	component  string
+	instanceID string
+	logger     log.Logger
+	seq        atomic.Uint64
+
+	lastCleanup time.Time
+}
+
+func newBucketSink(bkt objstore.Bucket, retention time.Duration, component, instanceID string, logger log.Logger) *bucketSink {
+	return &bucketSink{
+		bkt:        bkt,
+		retention:  retention,
+		component:  component,
+		instanceID: instanceID,
+		logger:     logger,
+	}
+}
+
+func (s *bucketSink) Write(ctx context.Context, userID string, records []Record) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	buf := bytes.Buffer{}
+	enc := json.NewEncoder(&buf)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+
+	userBkt := bucket.NewUserBucketClient(userID, s.bkt, nil)
+	now := time.Now()
+	name := path.Join(BucketPrefix, fmt.Sprintf("%020d-%010d-%s-%s.json", now.UnixMilli(), s.seq.Inc(), s.component, s.instanceID))
+	if err := userBkt.Upload(ctx, name, &buf); err != nil {
+		return errors.Wrapf(err, "failed to upload %s", name)
+	}
+	return nil
+}
+
+// DeleteExpired deletes the expired objects of all the tenants, at most once every bucketCleanupInterval, so that
+// the objects of the tenants whose series aren't rejected anymore are deleted too. It's not safe to call concurrently.
+func (s *bucketSink) DeleteExpired(ctx context.Context) error {
+	now := time.Now()
+	if now.Sub(s.lastCleanup) < bucketCleanupInterval {
+		return nil
+	}
+	s.lastCleanup = now
+
+	users, err := tsdb.ListUsers(ctx, s.bkt)
+	if err != nil {
+		return errors.Wrap(err, "failed to list tenants")
+	}
+
+	for _, userID := range users {
+		if err := s.deleteTenantExpired(ctx, bucket.NewUserBucketClient(userID, s.bkt, nil), now); err != nil {
+			level.Warn(s.logger).Log("msg", "failed to delete expired dead letter objects", "user", userID, "err", err)
+		}
+	}
+	return nil
+}
+
+func (s *bucketSink) deleteTenantExpired(ctx context.Context, userBkt objstore.Bucket, now time.Time) error {
+	names, err := listObjects(ctx, userBkt)
+	if err != nil {
+		return err
+	}
+
+	minTime := now.Add(-s.retention)
+	for _, name := range names {
+		// Objects are listed in chronological order.
+		if objectTime(name).After(minTime) {
+			break
+		}
+		if err := userBkt.Delete(ctx, name); err != nil && !userBkt.IsObjNotFoundErr(err) {
+			return errors.Wrapf(err, "failed to delete %s", name)
+		}
+	}
+	return nil
+}
+
+func (s *bucketSink) Read(ctx context.Context, userID string, limit int) ([]Record, error) {
+	userBkt := bucket.NewUserBucketClient(userID, s.bkt, nil)
+	names, err := listObjects(ctx, userBkt)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []Record
+	for i := len(names) - 1; i >= 0 && len(out) < limit; i-- {
+		records, err := readObject(ctx, userBkt, names[i])
+		if userBkt.IsObjNotFoundErr(err) {
+			// The object may have been deleted by the cleanup in the meanwhile.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for j := len(records) - 1; j >= 0 && len(out) < limit; j-- {
+			out = append(out, records[j])
+		}
+	}
+	return out, nil
+}
+
+func listObjects(ctx context.Context, userBkt objstore.Bucket) ([]string, error) {
+	var names []string
+	err := userBkt.Iter(ctx, BucketPrefix+"/", func(name string) error {
+		if strings.HasSuffix(name, ".json") {
+			names = append(names, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list dead letter objects")
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func readObject(ctx context.Context, userBkt objstore.Bucket, name string) ([]Record, error) {
+	reader, err := userBkt.Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	var records []Record
+	dec := json.NewDecoder(reader)
+	for dec.More() {
+		var r Record
+		if err := dec.Decode(&r); err != nil {
+			return nil, errors.Wrapf(err, "failed to decode %s", name)
+		}
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+// objectTime returns the flush time encoded in the object name, or the zero time if it can't be parsed.
+func objectTime(name string) time.Time {
+	base := path.Base(name)
+	idx := strings.IndexByte(base, '-')
+	if idx < 0 {
+		return time.Time{}
+	}
+	ms, err := strconv.ParseInt(base[:idx], 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
+
+// kafkaSink writes the records to the dead letter Kafka topic, keyed by tenant. The records of a tenant are
+// always written to the same partition, so that they can be read back from a single partition.
+type kafkaSink struct {
+	client      *kgo.Client
+	admin       *kadm.Client
+	kafkaCfg    ingest.KafkaConfig
+	readTimeout time.Duration
+	logger      log.Logger
+
+	partitionsMtx       sync.Mutex
+	partitions          int32
+	partitionsUpdatedAt time.Time
+}
+
+func newKafkaSink(cfg Config, kafkaCfg ingest.KafkaConfig, logger log.Logger, reg prometheus.Registerer) (*kafkaSink, error) {
+	kafkaCfg.Topic = cfg.KafkaTopic
+
+	client, err := ingest.NewKafkaWriterClient(kafkaCfg, kafkaMaxInflightProduceRequests, logger, prometheus.WrapRegistererWithPrefix("cortex_dead_letter_kafka_", reg))
+	if err != nil {
+		return nil, err
+	}
+	return &kafkaSink{
+		client:      client,
+		admin:       kadm.NewClient(client),
+		kafkaCfg:    kafkaCfg,
+		readTimeout: kafkaReadTimeout,
+		logger:      logger,
+	}, nil
+}
+
+func (s *kafkaSink) Write(ctx context.Context, userID string, records []Record) error {
+	partition, err := s.tenantPartition(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	kafkaRecords := make([]*kgo.Record, 0, len(records))
+	for _, r := range records {
+		value, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		kafkaRecords = append(kafkaRecords, &kgo.Record{Key: []byte(userID), Value: value, Partition: partition})
+	}
+
+	return s.client.ProduceSync(ctx, kafkaRecords...).FirstErr()
+}
+
+// tenantPartition returns the partition the records of the tenant are written to.
+func (s *kafkaSink) tenantPartition(ctx context.Context, userID string) (int32, error) {
+	partitions, err := s.numPartitions(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return int32(mimirpb.ShardByUser(userID) % uint32(partitions)), nil
+}
+
+// numPartitions returns the number of partitions of the topic, which is periodically refreshed.
+func (s *kafkaSink) numPartitions(ctx context.Context) (int32, error) {
+	s.partitionsMtx.Lock()
+	defer s.partitionsMtx.Unlock()
+
+	if s.partitions > 0 && time.Since(s.partitionsUpdatedAt) < kafkaPartitionsRefreshInterval {
+		return s.partitions, nil
+	}
+
+	topics, err := s.admin.ListTopics(ctx, s.kafkaCfg.Topic)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to list the dead letter topic partitions")
+	}
+	topic, ok := topics[s.kafkaCfg.Topic]
+	if !ok || topic.Err != nil || len(topic.Partitions) == 0 {
+		if s.partitions > 0 {
+			// Keep using the last known number of partitions.
+			return s.partitions, nil
+		}
+		if topic.Err != nil {
+			return 0, errors.Wrap(topic.Err, "failed to list the dead letter topic partitions")
+		}
+		return 0, errors.Errorf("the dead letter topic %s has no partitions", s.kafkaCfg.Topic)
+	}
+
+	s.partitions = int32(len(topic.Partitions))
+	s.partitionsUpdatedAt = time.Now()
+	return s.partitions, nil
+}
+
+// Read returns the most recent records of the tenant, written by all the distributors and ingesters. Only the
+// last kafkaReadMaxRecords records of the tenant partition are scanned, because it's shared with other tenants.
+// If the partition can't be scanned within the read timeout, the most recent records read so far are returned.
+func (s *kafkaSink) Read(ctx context.Context, userID string, limit int) ([]Record, error) {
+	partition, err := s.tenantPartition(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	startOffsets, err := s.admin.ListStartOffsets(ctx, s.kafkaCfg.Topic)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list the dead letter topic start offsets")
+	}
+	endOffsets, err := s.admin.ListEndOffsets(ctx, s.kafkaCfg.Topic)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list the dead letter topic end offsets")
+	}
+	start, ok := startOffsets.Lookup(s.kafkaCfg.Topic, partition)
+	if !ok || start.Err != nil {
+		return nil, errors.Errorf("failed to get the start offset of the dead letter partition %d", partition)
+	}
+	end, ok := endOffsets.Lookup(s.kafkaCfg.Topic, partition)
+	if !ok || end.Err != nil {
+		return nil, errors.Errorf("failed to get the end offset of the dead letter partition %d", partition)
+	}
+
+	from := max(start.Offset, end.Offset-kafkaReadMaxRecords)
+	if from >= end.Offset {
+		return nil, nil
+	}
+
+	client, err := ingest.NewKafkaReaderClient(s.kafkaCfg, nil, s.logger, kgo.ConsumePartitions(map[string]map[int32]kgo.Offset{
+		s.kafkaCfg.Topic: {partition: kgo.NewOffset().At(from)},
+	}))
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	readCtx, cancel := context.WithTimeout(ctx, s.readTimeout)
+	defer cancel()
+
+	// The records are read oldest first, so only the last limit records of the tenant are kept.
+	var records []Record
+	for next := from; next < end.Offset; {
+		fetches := client.PollFetches(readCtx)
+		timedOut := readCtx.Err() != nil && ctx.Err() == nil
+		if err := fetches.Err0(); err != nil && !timedOut {
+			return nil, errors.Wrap(err, "failed to read the dead letter partition")
+		}
+
+		var decodeErr error
+		fetches.EachRecord(func(kr *kgo.Record) {
+			next = kr.Offset + 1
+			if kr.Offset >= end.Offset || string(kr.Key) != userID || decodeErr != nil {
+				return
+			}
+
+			var r Record
+			if err := json.Unmarshal(kr.Value, &r); err != nil {
+				decodeErr = errors.Wrapf(err, "failed to decode the dead letter record at offset %d", kr.Offset)
+				return
+			}
+			records = append(records, r)
+			if len(records) > limit {
+				records = records[1:]
+			}
+		})
+		if decodeErr != nil {
+			return nil, decodeErr
+		}
+
+		if timedOut {
+			level.Warn(s.logger).Log("msg", "timed out reading the dead letter partition, returning the records read so far", "user", userID, "partition", partition, "read_until_offset", next, "end_offset", end.Offset)
+			break
+		}
+	}
+
+	slices.Reverse(records)
+	return records, nil
+}
+
+func (s *kafkaSink) Close() error {
+	s.client.Close()
+	return nil
+}
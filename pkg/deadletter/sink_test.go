@@ -0,0 +1,121 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package deadletter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/flagext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/storage/ingest"
+	"github.com/grafana/mimir/pkg/util/testkafka"
+)
+
+func TestBucketSink_DeleteExpired(t *testing.T) {
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+
+	now := time.Now()
+	expired := path.Join("user-1", BucketPrefix, fmt.Sprintf("%020d-ingester-ingester-1.json", now.Add(-25*time.Hour).UnixMilli()))
+	notExpired := path.Join("user-1", BucketPrefix, fmt.Sprintf("%020d-ingester-ingester-1.json", now.Add(-time.Hour).UnixMilli()))
+	// The objects of the tenants not written by the sink expire too.
+	otherTenantExpired := path.Join("user-2", BucketPrefix, fmt.Sprintf("%020d-ingester-ingester-1.json", now.Add(-25*time.Hour).UnixMilli()))
+	require.NoError(t, bkt.Upload(ctx, expired, bytes.NewReader(nil)))
+	require.NoError(t, bkt.Upload(ctx, notExpired, bytes.NewReader(nil)))
+	require.NoError(t, bkt.Upload(ctx, otherTenantExpired, bytes.NewReader(nil)))
+
+	sink := newBucketSink(bkt, 24*time.Hour, "distributor", "distributor-1", log.NewNopLogger())
+	require.NoError(t, sink.Write(ctx, "user-1", []Record{{Reason: "too_far_in_past", Series: `{__name__="metric"}`}}))
+	require.NoError(t, sink.DeleteExpired(ctx))
+
+	for name, expectedExists := range map[string]bool{expired: false, notExpired: true, otherTenantExpired: false} {
+		exists, err := bkt.Exists(ctx, name)
+		require.NoError(t, err)
+		assert.Equal(t, expectedExists, exists, name)
+	}
+
+	// The expired objects are deleted at most once every cleanup interval.
+	require.NoError(t, bkt.Upload(ctx, expired, bytes.NewReader(nil)))
+	require.NoError(t, sink.DeleteExpired(ctx))
+	exists, err := bkt.Exists(ctx, expired)
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	records, err := sink.Read(ctx, "user-1", 10)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "too_far_in_past", records[0].Reason)
+}
+
+func TestObjectTime(t *testing.T) {
+	ts := time.UnixMilli(1700000000000)
+	assert.Equal(t, ts, objectTime(path.Join(BucketPrefix, fmt.Sprintf("%020d-ingester-ingester-1.json", ts.UnixMilli()))))
+	assert.True(t, objectTime(path.Join(BucketPrefix, "invalid.json")).IsZero())
+}
+
+func TestKafkaSink_WriteAndRead(t *testing.T) {
+	const (
+		topic         = "dead-letter"
+		numPartitions = 4
+	)
+
+	ctx := context.Background()
+	_, clusterAddr := testkafka.CreateCluster(t, numPartitions, topic)
+
+	kafkaCfg := ingest.KafkaConfig{}
+	flagext.DefaultValues(&kafkaCfg)
+	kafkaCfg.Address = clusterAddr
+
+	cfg := testConfig()
+	cfg.KafkaTopic = topic
+
+	// Distributors and ingesters write to the same topic, and each of them can read the records of the others.
+	distributorSink, err := newKafkaSink(cfg, kafkaCfg, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = distributorSink.Close() })
+	ingesterSink, err := newKafkaSink(cfg, kafkaCfg, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ingesterSink.Close() })
+
+	require.NoError(t, distributorSink.Write(ctx, "user-1", []Record{{Component: "distributor", Reason: "reason-1"}, {Component: "distributor", Reason: "reason-2"}}))
+	require.NoError(t, ingesterSink.Write(ctx, "user-1", []Record{{Component: "ingester", Reason: "reason-3"}}))
+	require.NoError(t, ingesterSink.Write(ctx, "user-2", []Record{{Component: "ingester", Reason: "reason-4"}}))
+
+	records, err := distributorSink.Read(ctx, "user-1", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []Record{{Component: "ingester", Reason: "reason-3"}, {Component: "distributor", Reason: "reason-2"}, {Component: "distributor", Reason: "reason-1"}}, records)
+
+	records, err = ingesterSink.Read(ctx, "user-1", 2)
+	require.NoError(t, err)
+	assert.Equal(t, []Record{{Component: "ingester", Reason: "reason-3"}, {Component: "distributor", Reason: "reason-2"}}, records)
+
+	records, err = distributorSink.Read(ctx, "user-2", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []Record{{Component: "ingester", Reason: "reason-4"}}, records)
+
+	records, err = distributorSink.Read(ctx, "user-3", 10)
+	require.NoError(t, err)
+	assert.Empty(t, records)
+
+	// If the partition can't be read within the timeout, the records read so far are returned without error.
+	distributorSink.readTimeout = time.Nanosecond
+	records, err = distributorSink.Read(ctx, "user-1", 10)
+	require.NoError(t, err)
+	assert.Empty(t, records)
+	distributorSink.readTimeout = kafkaReadTimeout
+
+	// The records of a tenant are written to a partition chosen by the tenant ID.
+	partition, err := distributorSink.tenantPartition(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, int32(mimirpb.ShardByUser("user-1")%numPartitions), partition)
+}
@@ -43,14 +43,17 @@ import (
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/model/relabel"
 	"github.com/prometheus/prometheus/scrape"
+	"github.com/thanos-io/objstore"
 	"go.uber.org/atomic"
 	"golang.org/x/exp/slices"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/grafana/mimir/pkg/cardinality"
+	"github.com/grafana/mimir/pkg/deadletter"
 	ingester_client "github.com/grafana/mimir/pkg/ingester/client"
 	"github.com/grafana/mimir/pkg/mimirpb"
 	"github.com/grafana/mimir/pkg/querier/stats"
+	"github.com/grafana/mimir/pkg/storage/bucket"
 	"github.com/grafana/mimir/pkg/storage/ingest"
 	"github.com/grafana/mimir/pkg/util"
 	"github.com/grafana/mimir/pkg/util/globalerror"
@@ -109,6 +112,9 @@ type Distributor struct {
 	// For handling HA replicas.
 	HATracker *haTracker
 
+	// DeadLetter captures the rejected series. Nil if the dead letter is disabled.
+	DeadLetter *deadletter.Recorder
+
 	// Per-user rate limiters.
 	requestRateLimiter   *limiter.RateLimiter
 	ingestionRateLimiter *limiter.RateLimiter
@@ -136,6 +142,9 @@ type Distributor struct {
 	incomingMetadata                 *prometheus.CounterVec
 	nonHASamples                     *prometheus.CounterVec
 	dedupedSamples                   *prometheus.CounterVec
+	labelTransformationsApplied      *prometheus.CounterVec
+	nhcbConversions                  *prometheus.CounterVec
+	nhcbConversionsSkipped           *prometheus.CounterVec
 	labelsHistogram                  prometheus.Histogram
 	incomingSamplesPerRequest        *prometheus.HistogramVec
 	incomingExemplarsPerRequest      *prometheus.HistogramVec
@@ -175,6 +184,9 @@ type Distributor struct {
 	// ingestStorageWriter is the writer used when ingest storage is enabled.
 	ingestStorageWriter *ingest.Writer
 
+	// ingestionLag tracks the age of the received samples. Nil if the ingestion lag tracking is disabled.
+	ingestionLag *ingestionLagTracker
+
 	// partitionsRing is the hash ring holding ingester partitions. It's used when ingest storage is enabled.
 	partitionsRing *ring.PartitionInstanceRing
 }
@@ -187,6 +199,8 @@ type Config struct {
 	RetryConfig     RetryConfig     `yaml:"retry_after_header"`
 	HATrackerConfig HATrackerConfig `yaml:"ha_tracker"`
 
+	DeadLetterConfig deadletter.Config `yaml:"dead_letter"`
+
 	MaxRecvMsgSize           int           `yaml:"max_recv_msg_size" category:"advanced"`
 	MaxOTLPRequestSize       int           `yaml:"max_otlp_request_size" category:"experimental"`
 	MaxRequestPoolBufferSize int           `yaml:"max_request_pool_buffer_size" category:"experimental"`
@@ -216,6 +230,10 @@ type Config struct {
 	// IngestStorageConfig is dynamically injected because defined outside of distributor config.
 	IngestStorageConfig ingest.Config `yaml:"-"`
 
+	// BucketConfig is dynamically injected because defined outside of distributor config.
+	// It's used by the dead letter when ingest storage is disabled.
+	BucketConfig bucket.Config `yaml:"-"`
+
 	// Limits for distributor
 	DefaultLimits    InstanceLimits         `yaml:"instance_limits"`
 	InstanceLimitsFn func() *InstanceLimits `yaml:"-"`
@@ -227,6 +245,8 @@ type Config struct {
 
 	WriteRequestsBufferPoolingEnabled bool `yaml:"write_requests_buffer_pooling_enabled" category:"experimental"`
 	ReusableIngesterPushWorkers       int  `yaml:"reusable_ingester_push_workers" category:"advanced"`
+
+	IngestionLagTrackingEnabled bool `yaml:"ingestion_lag_tracking_enabled" category:"experimental"`
 }
 
 // PushWrapper wraps around a push. It is similar to middleware.Interface.
@@ -238,6 +258,7 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 	cfg.HATrackerConfig.RegisterFlags(f)
 	cfg.DistributorRing.RegisterFlags(f, logger)
 	cfg.RetryConfig.RegisterFlags(f)
+	cfg.DeadLetterConfig.RegisterFlagsWithPrefix("distributor.", f)
 
 	f.IntVar(&cfg.MaxRecvMsgSize, "distributor.max-recv-msg-size", 100<<20, "Max message size in bytes that the distributors will accept for incoming push requests to the remote write API. If exceeded, the request will be rejected.")
 	f.IntVar(&cfg.MaxOTLPRequestSize, maxOTLPRequestSizeFlag, 100<<20, "Maximum OTLP request size in bytes that the distributors accept. Requests exceeding this limit are rejected.")
@@ -245,6 +266,7 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 	f.DurationVar(&cfg.RemoteTimeout, "distributor.remote-timeout", 2*time.Second, "Timeout for downstream ingesters.")
 	f.BoolVar(&cfg.WriteRequestsBufferPoolingEnabled, "distributor.write-requests-buffer-pooling-enabled", true, "Enable pooling of buffers used for marshaling write requests.")
 	f.IntVar(&cfg.ReusableIngesterPushWorkers, "distributor.reusable-ingester-push-workers", 2000, "Number of pre-allocated workers used to forward push requests to the ingesters. If 0, no workers will be used and a new goroutine will be spawned for each ingester push request. If not enough workers available, new goroutine will be spawned. (Note: this is a performance optimization, not a limiting feature.)")
+	f.BoolVar(&cfg.IngestionLagTrackingEnabled, "distributor.ingestion-lag-tracking-enabled", false, "Track the age of the accepted and rejected samples for each tenant and HA cluster, exposed by the cortex_distributor_sample_age_seconds metric and the /distributor/tenant/{tenant}/ingestion_lag page.")
 
 	cfg.DefaultLimits.RegisterFlags(f)
 }
@@ -258,6 +280,9 @@ func (cfg *Config) Validate(limits validation.Limits) error {
 	if err := cfg.HATrackerConfig.Validate(); err != nil {
 		return err
 	}
+	if err := cfg.DeadLetterConfig.Validate(); err != nil {
+		return err
+	}
 	return cfg.RetryConfig.Validate()
 }
 
@@ -383,6 +408,18 @@ func New(cfg Config, clientConfig ingester_client.Config, limits *validation.Ove
 			Name: "cortex_distributor_deduped_samples_total",
 			Help: "The total number of deduplicated samples.",
 		}, []string{"user", "cluster"}),
+		labelTransformationsApplied: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_distributor_label_transformations_applied_total",
+			Help: "The total number of series whose labels have been changed by a label transformation.",
+		}, []string{"user", "transformation"}),
+		nhcbConversions: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_distributor_nhcb_conversions_total",
+			Help: "The total number of classic histogram samples converted to native histogram with custom buckets samples.",
+		}, []string{"user"}),
+		nhcbConversionsSkipped: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_distributor_nhcb_conversions_skipped_total",
+			Help: "The total number of classic histograms received which have not been converted to native histograms with custom buckets.",
+		}, []string{"user", "reason"}),
 		labelsHistogram: promauto.With(reg).NewHistogram(prometheus.HistogramOpts{
 			Name:    "cortex_labels_per_sample",
 			Help:    "Number of labels per sample.",
@@ -532,6 +569,26 @@ func New(cfg Config, clientConfig ingester_client.Config, limits *validation.Ove
 		subservices = append(subservices, d.ingestStorageWriter)
 	}
 
+	if cfg.IngestionLagTrackingEnabled {
+		d.ingestionLag = newIngestionLagTracker(reg)
+	}
+
+	if cfg.DeadLetterConfig.Enabled {
+		var bkt objstore.Bucket
+		if !cfg.IngestStorageConfig.Enabled {
+			bkt, err = bucket.NewClient(context.Background(), cfg.BucketConfig, "distributor-dead-letter", log, reg)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to create the dead letter bucket client")
+			}
+		}
+
+		d.DeadLetter, err = deadletter.NewRecorder(cfg.DeadLetterConfig, "distributor", cfg.DistributorRing.Common.InstanceID, limits, bkt, cfg.IngestStorageConfig, log, reg)
+		if err != nil {
+			return nil, err
+		}
+		subservices = append(subservices, d.DeadLetter)
+	}
+
 	// Register each metric only if the corresponding storage is enabled.
 	// Some queries in the mixin use the presence of these metrics as indication whether Mimir is running with ingest storage or not.
 	exportStorageModeMetrics(reg, cfg.IngestStorageConfig.Migration.DistributorSendToIngestersEnabled || !cfg.IngestStorageConfig.Enabled, cfg.IngestStorageConfig.Enabled, ingestersRing.ReplicationFactor())
@@ -655,6 +712,11 @@ func (d *Distributor) cleanupInactiveUser(userID string) {
 
 	filter := prometheus.Labels{"user": userID}
 	d.dedupedSamples.DeletePartialMatch(filter)
+	d.labelTransformationsApplied.DeletePartialMatch(filter)
+	d.nhcbConversions.DeleteLabelValues(userID)
+	d.nhcbConversionsSkipped.DeletePartialMatch(filter)
+	d.DeadLetter.RemoveTenant(userID)
+	d.ingestionLag.removeTenant(userID)
 	d.discardedSamplesTooManyHaClusters.DeletePartialMatch(filter)
 	d.discardedSamplesRateLimited.DeletePartialMatch(filter)
 	d.discardedRequestsRateLimited.DeleteLabelValues(userID)
@@ -681,7 +743,7 @@ func (d *Distributor) stopping(_ error) error {
 // Returns a boolean that indicates whether or not we want to remove the replica label going forward,
 // and an error that indicates whether we want to accept samples based on the cluster/replica found in ts.
 // nil for the error means accept the sample.
-func (d *Distributor) checkSample(ctx context.Context, userID, cluster, replica string) (removeReplicaLabel bool, _ error) {
+func (d *Distributor) checkSample(ctx context.Context, userID, cluster, replica string, numSamples int) (removeReplicaLabel bool, _ error) {
 	// If the sample doesn't have either HA label, accept it.
 	// At the moment we want to accept these samples by default.
 	if cluster == "" || replica == "" {
@@ -695,7 +757,10 @@ func (d *Distributor) checkSample(ctx context.Context, userID, cluster, replica
 
 	// At this point we know we have both HA labels, we should lookup
 	// the cluster/instance here to see if we want to accept this sample.
-	err := d.HATracker.checkReplica(ctx, userID, cluster, replica, time.Now())
+	now := time.Now()
+	err := d.HATracker.checkReplica(ctx, userID, cluster, replica, now)
+	// Track the samples of both the elected and non-elected replicas, used to compare their freshness.
+	d.HATracker.recordReplicaSamples(userID, cluster, replica, numSamples, now)
 	// checkReplica would have returned an error if there was a real error talking to Consul,
 	// or if the replica is not the currently elected replica.
 	if err != nil { // Don't accept the sample.
@@ -771,6 +836,28 @@ func (d *Distributor) validateSeries(nowt time.Time, ts *mimirpb.PreallocTimeser
 	}
 	return nil
 }
+
+// recordRejectedSeries captures the series rejected by the validation in the dead letter.
+func (d *Distributor) recordRejectedSeries(userID string, ts mimirpb.PreallocTimeseries, validationErr error) {
+	if d.DeadLetter == nil {
+		return
+	}
+
+	reason := "validation"
+	if id, ok := globalerror.IDFromMessage(validationErr.Error()); ok {
+		reason = id.LabelValue()
+	}
+
+	var timestampMs int64
+	if len(ts.Samples) > 0 {
+		timestampMs = ts.Samples[0].TimestampMs
+	} else if len(ts.Histograms) > 0 {
+		timestampMs = ts.Histograms[0].Timestamp
+	}
+
+	d.DeadLetter.Record(userID, reason, ts.Labels, timestampMs, validationErr.Error())
+}
+
 func (d *Distributor) labelValuesWithNewlines(labels []mimirpb.LabelAdapter) int {
 	count := 0
 	for _, l := range labels {
@@ -793,7 +880,9 @@ func (d *Distributor) wrapPushWithMiddlewares(next PushFunc) PushFunc {
 	middlewares = append(middlewares, d.metricsMiddleware)
 	middlewares = append(middlewares, d.prePushHaDedupeMiddleware)
 	middlewares = append(middlewares, d.prePushRelabelMiddleware)
+	middlewares = append(middlewares, d.prePushLabelTransformationMiddleware)
 	middlewares = append(middlewares, d.prePushSortAndFilterMiddleware)
+	middlewares = append(middlewares, d.prePushNHCBConversionMiddleware)
 	middlewares = append(middlewares, d.prePushValidationMiddleware)
 	middlewares = append(middlewares, d.cfg.PushWrappers...)
 
@@ -840,7 +929,7 @@ func (d *Distributor) prePushHaDedupeMiddleware(next PushFunc) PushFunc {
 			numSamples += len(ts.Samples) + len(ts.Histograms)
 		}
 
-		removeReplica, err := d.checkSample(ctx, userID, cluster, replica)
+		removeReplica, err := d.checkSample(ctx, userID, cluster, replica, numSamples)
 		if err != nil {
 			if errors.As(err, &replicasDidNotMatchError{}) {
 				// These samples have been deduped.
@@ -927,6 +1016,92 @@ func (d *Distributor) prePushRelabelMiddleware(next PushFunc) PushFunc {
 	}
 }
 
+// prePushLabelTransformationMiddleware applies the tenant's label transformations to the series labels.
+// It runs after relabeling, so that transformations see the final label set, and before sorting, because
+// transformations can rename labels.
+func (d *Distributor) prePushLabelTransformationMiddleware(next PushFunc) PushFunc {
+	return func(ctx context.Context, pushReq *Request) error {
+		next, maybeCleanup := NextOrCleanup(next, pushReq)
+		defer maybeCleanup()
+
+		userID, err := tenant.TenantID(ctx)
+		if err != nil {
+			return err
+		}
+
+		transformations := d.limits.LabelTransformations(userID)
+		if len(transformations) == 0 {
+			return next(ctx, pushReq)
+		}
+
+		req, err := pushReq.WriteRequest()
+		if err != nil {
+			return err
+		}
+
+		maxLabelValueLength := d.limits.MaxLabelValueLength(userID)
+
+		var removeTsIndexes []int
+		for tsIdx := 0; tsIdx < len(req.Timeseries); tsIdx++ {
+			changed := false
+			lbls := transformLabels(req.Timeseries[tsIdx].Labels, transformations, maxLabelValueLength, func(name string) {
+				d.labelTransformationsApplied.WithLabelValues(userID, name).Inc()
+				changed = true
+			})
+			if !changed {
+				continue
+			}
+
+			req.Timeseries[tsIdx].SetLabels(lbls)
+			if len(lbls) == 0 {
+				removeTsIndexes = append(removeTsIndexes, tsIdx)
+			}
+		}
+
+		if len(removeTsIndexes) > 0 {
+			for _, removeTsIndex := range removeTsIndexes {
+				mimirpb.ReusePreallocTimeseries(&req.Timeseries[removeTsIndex])
+			}
+			req.Timeseries = util.RemoveSliceIndexes(req.Timeseries, removeTsIndexes)
+		}
+
+		return next(ctx, pushReq)
+	}
+}
+
+// prePushNHCBConversionMiddleware converts the classic histograms in the request to native histograms with
+// custom buckets. It runs after sorting, because classic histogram series are grouped by their labels, and
+// before validation, so that the converted histograms are validated.
+func (d *Distributor) prePushNHCBConversionMiddleware(next PushFunc) PushFunc {
+	return func(ctx context.Context, pushReq *Request) error {
+		next, maybeCleanup := NextOrCleanup(next, pushReq)
+		defer maybeCleanup()
+
+		userID, err := tenant.TenantID(ctx)
+		if err != nil {
+			return err
+		}
+
+		if !d.limits.ConvertClassicHistogramsToNHCB(userID) || !d.limits.NativeHistogramsIngestionEnabled(userID) {
+			return next(ctx, pushReq)
+		}
+
+		req, err := pushReq.WriteRequest()
+		if err != nil {
+			return err
+		}
+
+		converted := convertClassicHistogramsToNHCB(req, d.limits.MaxNativeHistogramBuckets(userID), func(reason string) {
+			d.nhcbConversionsSkipped.WithLabelValues(userID, reason).Inc()
+		})
+		if converted > 0 {
+			d.nhcbConversions.WithLabelValues(userID).Add(float64(converted))
+		}
+
+		return next(ctx, pushReq)
+	}
+}
+
 // prePushSortAndFilterMiddleware is responsible for sorting labels and
 // filtering empty values. This is a protection mechanism for ingesters.
 func (d *Distributor) prePushSortAndFilterMiddleware(next PushFunc) PushFunc {
@@ -1034,6 +1209,13 @@ func (d *Distributor) prePushValidationMiddleware(next PushFunc) PushFunc {
 		var removeIndexes []int
 		totalSamples, totalExemplars := 0, 0
 
+		lag := d.ingestionLag.newRequest(userID, req.Timeseries, d.limits.HAClusterLabel(userID), d.limits.MaxHAClusters(userID), d.ingestersRing.ReplicationFactor(), now)
+		if lag != nil {
+			// The accepted samples are observed once all the ingesters pushes completed, to account the samples
+			// rejected by the ingesters as rejected. The cleanup runs before the request buffers are released.
+			pushReq.AddCleanup(lag.done)
+		}
+
 		labelValuesWithNewlines := 0
 		for tsIdx, ts := range req.Timeseries {
 			totalSamples += len(ts.Samples)
@@ -1058,6 +1240,8 @@ func (d *Distributor) prePushValidationMiddleware(next PushFunc) PushFunc {
 					// The series are never retained by validationErr. This is guaranteed by the way the latter is built.
 					firstPartialErr = newValidationError(validationErr)
 				}
+				d.recordRejectedSeries(userID, ts, validationErr)
+				lag.observeRejected(ts)
 				removeIndexes = append(removeIndexes, tsIdx)
 				continue
 			}
@@ -1118,7 +1302,8 @@ func (d *Distributor) prePushValidationMiddleware(next PushFunc) PushFunc {
 		// totalN included samples, exemplars and metadata. Ingester follows this pattern when computing its ingestion rate.
 		d.ingestionRate.Add(int64(totalN))
 
-		err = next(ctx, pushReq)
+		lag.push(req)
+		err = next(contextWithIngestionLagRequest(ctx, lag), pushReq)
 		if err != nil {
 			// Errors resulting from the pushing to the ingesters have priority over validation errors.
 			return err
@@ -1566,6 +1751,8 @@ func (d *Distributor) sendWriteRequestToBackends(ctx context.Context, tenantID s
 }
 
 func (d *Distributor) sendWriteRequestToIngesters(ctx context.Context, tenantRing ring.DoBatchRing, req *mimirpb.WriteRequest, keys []uint32, initialMetadataIndex int, remoteRequestContext func() context.Context, batchOptions ring.DoBatchOptions) error {
+	lag := ingestionLagRequestFromContext(ctx)
+
 	err := ring.DoBatchWithOptions(ctx, ring.WriteNoExtend, tenantRing, keys,
 		func(ingester ring.InstanceDesc, indexes []int) error {
 			req := req.ForIndexes(indexes, initialMetadataIndex)
@@ -1580,6 +1767,7 @@ func (d *Distributor) sendWriteRequestToIngesters(ctx context.Context, tenantRin
 			ctx = grpcutil.AppendMessageSizeToOutgoingContext(ctx, req) // Let ingester know the size of the message, without needing to read the message first.
 
 			_, err = c.Push(ctx, req)
+			lag.observeIngesterRejections(indexes, err)
 			err = wrapIngesterPushError(err, ingester.Id)
 			err = wrapDeadlineExceededPushError(err)
 
@@ -59,6 +59,7 @@ import (
 	"github.com/grafana/mimir/pkg/mimirpb"
 	"github.com/grafana/mimir/pkg/querier/api"
 	"github.com/grafana/mimir/pkg/querier/stats"
+	"github.com/grafana/mimir/pkg/storage/bucket"
 	"github.com/grafana/mimir/pkg/storage/chunk"
 	"github.com/grafana/mimir/pkg/storage/ingest"
 	"github.com/grafana/mimir/pkg/util/extract"
@@ -4786,6 +4787,155 @@ func TestRelabelMiddleware(t *testing.T) {
 	}
 }
 
+func TestLabelTransformationMiddleware(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "user")
+
+	var limits validation.Limits
+	flagext.DefaultValues(&limits)
+	limits.LabelTransformations = []*validation.LabelTransformation{
+		{Name: "lowercase_env", Action: validation.LabelTransformationLowercase, LabelNames: []string{"env"}},
+		{Name: "drop_uuids", Action: validation.LabelTransformationDropUUIDValues},
+	}
+
+	ds, _, regs, _ := prepare(t, prepConfig{
+		numDistributors: 1,
+		limits:          &limits,
+	})
+
+	var gotReq *mimirpb.WriteRequest
+	next := func(_ context.Context, pushReq *Request) error {
+		req, err := pushReq.WriteRequest()
+		require.NoError(t, err)
+		gotReq = req
+		pushReq.CleanUp()
+		return nil
+	}
+	middleware := ds[0].prePushLabelTransformationMiddleware(next)
+
+	req := &mimirpb.WriteRequest{
+		Timeseries: []mimirpb.PreallocTimeseries{
+			makeTimeseries([]string{model.MetricNameLabel, "metric1", "env", "PROD"}, makeSamples(123, 1), nil),
+			makeTimeseries([]string{model.MetricNameLabel, "metric2", "env", "prod", "id", "3f0c8a4e-1b2d-4c5e-8f9a-0b1c2d3e4f5a"}, makeSamples(123, 2), nil),
+			makeTimeseries([]string{model.MetricNameLabel, "metric3", "env", "prod"}, makeSamples(123, 3), nil),
+			makeTimeseries([]string{"id", "3f0c8a4e-1b2d-4c5e-8f9a-0b1c2d3e4f5a"}, makeSamples(123, 4), nil),
+		},
+	}
+	require.NoError(t, middleware(ctx, NewParsedRequest(req)))
+
+	assert.Equal(t, &mimirpb.WriteRequest{
+		Timeseries: []mimirpb.PreallocTimeseries{
+			makeTimeseries([]string{model.MetricNameLabel, "metric1", "env", "prod"}, makeSamples(123, 1), nil),
+			makeTimeseries([]string{model.MetricNameLabel, "metric2", "env", "prod"}, makeSamples(123, 2), nil),
+			makeTimeseries([]string{model.MetricNameLabel, "metric3", "env", "prod"}, makeSamples(123, 3), nil),
+		},
+	}, gotReq)
+
+	require.NoError(t, testutil.GatherAndCompare(regs[0], strings.NewReader(`
+		# HELP cortex_distributor_label_transformations_applied_total The total number of series whose labels have been changed by a label transformation.
+		# TYPE cortex_distributor_label_transformations_applied_total counter
+		cortex_distributor_label_transformations_applied_total{transformation="drop_uuids",user="user"} 2
+		cortex_distributor_label_transformations_applied_total{transformation="lowercase_env",user="user"} 1
+	`), "cortex_distributor_label_transformations_applied_total"))
+}
+
+func TestNHCBConversionMiddleware(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "user")
+
+	var limits validation.Limits
+	flagext.DefaultValues(&limits)
+	limits.NativeHistogramsIngestionEnabled = true
+	limits.ConvertClassicHistogramsToNHCB = true
+
+	ds, _, regs, _ := prepare(t, prepConfig{
+		numDistributors: 1,
+		limits:          &limits,
+	})
+
+	var gotReq *mimirpb.WriteRequest
+	next := func(_ context.Context, pushReq *Request) error {
+		req, err := pushReq.WriteRequest()
+		require.NoError(t, err)
+		gotReq = req
+		pushReq.CleanUp()
+		return nil
+	}
+	middleware := ds[0].prePushNHCBConversionMiddleware(next)
+
+	req := &mimirpb.WriteRequest{
+		Timeseries: []mimirpb.PreallocTimeseries{
+			makeTimeseries([]string{model.MetricNameLabel, "latency_bucket", "le", "1"}, makeSamples(123, 1), nil),
+			makeTimeseries([]string{model.MetricNameLabel, "latency_bucket", "le", "+Inf"}, makeSamples(123, 3), nil),
+			makeTimeseries([]string{model.MetricNameLabel, "latency_sum"}, makeSamples(123, 2.5), nil),
+			makeTimeseries([]string{model.MetricNameLabel, "latency_count"}, makeSamples(123, 3), nil),
+			makeTimeseries([]string{model.MetricNameLabel, "partial_bucket", "le", "+Inf"}, makeSamples(123, 1), nil),
+		},
+	}
+	require.NoError(t, middleware(ctx, NewParsedRequest(req)))
+
+	require.Len(t, gotReq.Timeseries, 2)
+	assert.Equal(t, []mimirpb.LabelAdapter{{Name: model.MetricNameLabel, Value: "latency"}}, gotReq.Timeseries[0].Labels)
+	assert.Equal(t, makeHistograms(123, &histogram.Histogram{
+		Schema:          histogram.CustomBucketsSchema,
+		Count:           3,
+		Sum:             2.5,
+		PositiveSpans:   []histogram.Span{{Offset: 0, Length: 2}},
+		PositiveBuckets: []int64{1, 1},
+		CustomValues:    []float64{1},
+	}), gotReq.Timeseries[0].Histograms)
+	assert.Equal(t, makeSamples(123, 1), gotReq.Timeseries[1].Samples)
+
+	require.NoError(t, testutil.GatherAndCompare(regs[0], strings.NewReader(`
+		# HELP cortex_distributor_nhcb_conversions_total The total number of classic histogram samples converted to native histogram with custom buckets samples.
+		# TYPE cortex_distributor_nhcb_conversions_total counter
+		cortex_distributor_nhcb_conversions_total{user="user"} 1
+		# HELP cortex_distributor_nhcb_conversions_skipped_total The total number of classic histograms received which have not been converted to native histograms with custom buckets.
+		# TYPE cortex_distributor_nhcb_conversions_skipped_total counter
+		cortex_distributor_nhcb_conversions_skipped_total{reason="incomplete",user="user"} 1
+	`), "cortex_distributor_nhcb_conversions_total", "cortex_distributor_nhcb_conversions_skipped_total"))
+}
+
+func TestDistributor_DeadLetter(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "user")
+
+	var limits validation.Limits
+	flagext.DefaultValues(&limits)
+	limits.DeadLetterEnabled = true
+	limits.MaxLabelNameLength = 10
+
+	ds, _, _, _ := prepare(t, prepConfig{
+		numIngesters:    3,
+		happyIngesters:  3,
+		numDistributors: 1,
+		limits:          &limits,
+		configure: func(cfg *Config) {
+			cfg.DeadLetterConfig.Enabled = true
+			cfg.BucketConfig.StorageBackendConfig.Backend = bucket.Filesystem
+			cfg.BucketConfig.Filesystem.Directory = t.TempDir()
+		},
+	})
+	require.NotNil(t, ds[0].DeadLetter)
+
+	req := &mimirpb.WriteRequest{
+		Timeseries: []mimirpb.PreallocTimeseries{
+			makeTimeseries([]string{model.MetricNameLabel, "metric1", "a_very_long_label_name", "value"}, makeSamples(123, 1), nil),
+			makeTimeseries([]string{model.MetricNameLabel, "metric2"}, makeSamples(123, 2), nil),
+		},
+	}
+	_, err := ds[0].Push(ctx, req)
+	require.Error(t, err)
+
+	// Records are written to the object storage on flush, when the distributor stops.
+	require.NoError(t, services.StopAndAwaitTerminated(context.Background(), ds[0]))
+
+	records, err := ds[0].DeadLetter.RecentRecords(context.Background(), "user", 10)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "distributor", records[0].Component)
+	assert.Equal(t, globalerror.SeriesLabelNameTooLong.LabelValue(), records[0].Reason)
+	assert.Equal(t, `metric1{a_very_long_label_name="value"}`, records[0].Series)
+	assert.Equal(t, int64(123), records[0].TimestampMs)
+}
+
 func TestSortAndFilterMiddleware(t *testing.T) {
 	ctxWithUser := user.InjectOrgID(context.Background(), "user")
 
@@ -315,6 +315,22 @@ func wrapIngesterPushError(err error, ingesterID string) error {
 	return newIngesterPushError(stat, ingesterID)
 }
 
+// wrapIngesterQueryError converts the error of a query rejected by an ingester because of a per-tenant limit
+// into a validation.LimitError, so that the query is neither retried on other ingesters nor by the client.
+func wrapIngesterQueryError(err error) error {
+	stat, ok := grpcutil.ErrorToStatus(err)
+	if !ok {
+		return err
+	}
+	details := stat.Details()
+	if len(details) == 1 {
+		if errorDetails, ok := details[0].(*mimirpb.ErrorDetails); ok && errorDetails.GetCause() == mimirpb.TENANT_LIMIT {
+			return validation.NewLimitError(stat.Message())
+		}
+	}
+	return err
+}
+
 func wrapPartitionPushError(err error, partitionID int32) error {
 	if err == nil {
 		return nil
@@ -20,6 +20,7 @@ import (
 
 	"github.com/grafana/mimir/pkg/mimirpb"
 	"github.com/grafana/mimir/pkg/storage/ingest"
+	"github.com/grafana/mimir/pkg/util/validation"
 )
 
 func TestNewReplicasNotMatchError(t *testing.T) {
@@ -452,6 +453,26 @@ func TestWrapIngesterPushError(t *testing.T) {
 	}
 }
 
+func TestWrapIngesterQueryError(t *testing.T) {
+	const testErrorMsg = "this is a test error message"
+
+	// A per-tenant limit error is not retried.
+	err := wrapIngesterQueryError(createStatusWithDetails(t, codes.FailedPrecondition, testErrorMsg, mimirpb.TENANT_LIMIT).Err())
+	require.True(t, validation.IsLimitError(err))
+	require.EqualError(t, err, testErrorMsg)
+
+	// Other errors are returned as is.
+	for _, inputErr := range []error{
+		createStatusWithDetails(t, codes.ResourceExhausted, testErrorMsg, mimirpb.TOO_BUSY).Err(),
+		status.Error(codes.Internal, testErrorMsg),
+		errors.New(testErrorMsg),
+	} {
+		err := wrapIngesterQueryError(inputErr)
+		require.False(t, validation.IsLimitError(err))
+		require.Equal(t, inputErr, err)
+	}
+}
+
 func TestWrapPartitionPushError(t *testing.T) {
 	tests := map[string]struct {
 		err           error
@@ -33,6 +33,13 @@ var (
 	errNegativeUpdateTimeoutJitterMax = errors.New("HA tracker max update timeout jitter shouldn't be negative")
 	errInvalidFailoverTimeout         = "HA Tracker failover timeout (%v) must be at least 1s greater than update timeout - max jitter (%v)"
 	errMemberlistUnsupported          = errors.New("memberlist is not supported by the HA tracker since gossip propagation is too slow for HA purposes")
+	errInvalidFreshnessWindow         = errors.New("HA tracker freshness failover window must be greater than 0")
+	errInvalidFreshnessMinRatio       = errors.New("HA tracker freshness failover min ratio must be greater than 0 and less than 1")
+)
+
+const (
+	// freshnessWindowBuckets is the number of buckets the freshness failover window is split into.
+	freshnessWindowBuckets = 6
 )
 
 type haTrackerLimits interface {
@@ -66,6 +73,12 @@ type HATrackerConfig struct {
 	// more than this duration
 	FailoverTimeout time.Duration `yaml:"ha_tracker_failover_timeout" category:"advanced"`
 
+	// Fail over when the elected replica keeps pushing, but with a sample volume much lower
+	// than the other replicas of the same cluster.
+	FreshnessFailoverEnabled  bool          `yaml:"ha_tracker_freshness_failover_enabled" category:"experimental"`
+	FreshnessFailoverWindow   time.Duration `yaml:"ha_tracker_freshness_failover_window" category:"experimental"`
+	FreshnessFailoverMinRatio float64       `yaml:"ha_tracker_freshness_failover_min_ratio" category:"experimental"`
+
 	KVStore kv.Config `yaml:"kvstore" doc:"description=Backend storage to use for the ring. Please be aware that memberlist is not supported by the HA tracker since gossip propagation is too slow for HA purposes."`
 }
 
@@ -75,6 +88,9 @@ func (cfg *HATrackerConfig) RegisterFlags(f *flag.FlagSet) {
 	f.DurationVar(&cfg.UpdateTimeout, "distributor.ha-tracker.update-timeout", 15*time.Second, "Update the timestamp in the KV store for a given cluster/replica only after this amount of time has passed since the current stored timestamp.")
 	f.DurationVar(&cfg.UpdateTimeoutJitterMax, "distributor.ha-tracker.update-timeout-jitter-max", 5*time.Second, "Maximum jitter applied to the update timeout, in order to spread the HA heartbeats over time.")
 	f.DurationVar(&cfg.FailoverTimeout, "distributor.ha-tracker.failover-timeout", 30*time.Second, "If we don't receive any samples from the accepted replica for a cluster in this amount of time we will failover to the next replica we receive a sample from. This value must be greater than the update timeout")
+	f.BoolVar(&cfg.FreshnessFailoverEnabled, "distributor.ha-tracker.freshness-failover-enabled", false, "Fail over to another replica of the cluster when the elected replica keeps sending samples, but the number of samples received from it over the freshness failover window drops below the configured ratio of the samples received from another replica.")
+	f.DurationVar(&cfg.FreshnessFailoverWindow, "distributor.ha-tracker.freshness-failover-window", time.Minute, "The sliding window over which the number of samples received from each replica is compared. A replica is never demoted before being elected for at least this amount of time.")
+	f.Float64Var(&cfg.FreshnessFailoverMinRatio, "distributor.ha-tracker.freshness-failover-min-ratio", 0.5, "Fail over when the number of samples received from the elected replica is lower than this ratio of the samples received from another replica. Must be greater than 0 and less than 1.")
 
 	// We want the ability to use different instances for the ring and
 	// for HA cluster tracking. We also customize the default keys prefix, in
@@ -98,6 +114,15 @@ func (cfg *HATrackerConfig) Validate() error {
 		return errMemberlistUnsupported
 	}
 
+	if cfg.FreshnessFailoverEnabled {
+		if cfg.FreshnessFailoverWindow <= 0 {
+			return errInvalidFreshnessWindow
+		}
+		if cfg.FreshnessFailoverMinRatio <= 0 || cfg.FreshnessFailoverMinRatio >= 1 {
+			return errInvalidFreshnessMinRatio
+		}
+	}
+
 	return nil
 }
 
@@ -125,6 +150,7 @@ type haTracker struct {
 	totalReelections              *prometheus.GaugeVec
 	electedReplicaPropagationTime prometheus.Histogram
 	kvCASCalls                    *prometheus.CounterVec
+	freshnessFailovers            *prometheus.CounterVec
 
 	cleanupRuns               prometheus.Counter
 	replicasMarkedForDeletion prometheus.Counter
@@ -138,6 +164,49 @@ type haClusterInfo struct {
 	electedLastSeenTimestamp    int64       // timestamp in milliseconds
 	nonElectedLastSeenReplica   string
 	nonElectedLastSeenTimestamp int64 // timestamp in milliseconds
+
+	// Number of samples received from each replica, only tracked if the freshness failover is enabled.
+	// It's updated on every push, so it's protected by its own lock rather than the electedLock.
+	replicaSamplesMtx sync.Mutex
+	replicaSamples    map[string]*replicaSampleVolume
+	// Replica demoted by the last freshness failover triggered by this distributor, and when.
+	freshnessFailoverFrom string
+	freshnessFailoverAt   int64 // timestamp in milliseconds
+}
+
+// replicaSampleVolume tracks the number of samples received from a replica over a sliding window,
+// split into freshnessWindowBuckets buckets.
+type replicaSampleVolume struct {
+	lastSeen     int64 // timestamp in milliseconds
+	buckets      [freshnessWindowBuckets]int64
+	bucketStarts [freshnessWindowBuckets]int64 // timestamp in milliseconds
+}
+
+func (v *replicaSampleVolume) add(samples int, now time.Time, window time.Duration) {
+	bucketMs := max(window.Milliseconds()/freshnessWindowBuckets, 1)
+	nowMs := timestamp.FromTime(now)
+	start := nowMs - nowMs%bucketMs
+	idx := (nowMs / bucketMs) % freshnessWindowBuckets
+
+	if v.bucketStarts[idx] != start {
+		v.bucketStarts[idx] = start
+		v.buckets[idx] = 0
+	}
+	v.buckets[idx] += int64(samples)
+	v.lastSeen = nowMs
+}
+
+// total returns the number of samples received within the window.
+func (v *replicaSampleVolume) total(now time.Time, window time.Duration) int64 {
+	minStart := timestamp.FromTime(now.Add(-window))
+
+	var total int64
+	for idx, start := range v.bucketStarts {
+		if start > minStart {
+			total += v.buckets[idx]
+		}
+	}
+	return total
 }
 
 // newHATracker returns a new HA cluster tracker using either Consul,
@@ -180,6 +249,10 @@ func newHATracker(cfg HATrackerConfig, limits haTrackerLimits, reg prometheus.Re
 			Name: "cortex_ha_tracker_kv_store_cas_total",
 			Help: "The total number of CAS calls to the KV store for a user ID/cluster.",
 		}, []string{"user", "cluster"}),
+		freshnessFailovers: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_ha_tracker_freshness_failovers_total",
+			Help: "The total number of times this distributor failed over to another replica because the elected replica sample volume was too low, for a user ID/cluster.",
+		}, []string{"user", "cluster"}),
 
 		cleanupRuns: promauto.With(reg).NewCounter(prometheus.CounterOpts{
 			Name: "cortex_ha_tracker_replicas_cleanup_started_total",
@@ -313,6 +386,7 @@ func (h *haTracker) cleanupDeletedReplica(user string, cluster string) {
 	h.electedReplicaTimestamp.DeleteLabelValues(user, cluster)
 	h.lastElectionTimestamp.DeleteLabelValues(user, cluster)
 	h.totalReelections.DeleteLabelValues(user, cluster)
+	h.freshnessFailovers.DeleteLabelValues(user, cluster)
 
 	h.electedLock.Lock()
 	defer h.electedLock.Unlock()
@@ -367,10 +441,18 @@ func (h *haTracker) updateKVStoreAll(ctx context.Context, now time.Time) {
 			}
 			var replica string
 			var receivedAt int64
+			var freshnessFailover bool
 			if h.withinUpdateTimeout(now, entry.electedLastSeenTimestamp) {
-				// We have seen the elected replica recently; carry on with that choice.
+				// We have seen the elected replica recently; carry on with that choice,
+				// unless another replica is sending many more samples.
 				replica = entry.elected.Replica
 				receivedAt = entry.electedLastSeenTimestamp
+
+				if candidate, candidateLastSeen, ok := h.freshnessFailoverCandidate(entry, now); ok {
+					replica = candidate
+					receivedAt = candidateLastSeen
+					freshnessFailover = true
+				}
 			} else if h.withinUpdateTimeout(now, entry.nonElectedLastSeenTimestamp) {
 				// Not seen elected but have seen another: attempt to fail over.
 				replica = entry.nonElectedLastSeenReplica
@@ -380,7 +462,7 @@ func (h *haTracker) updateKVStoreAll(ctx context.Context, now time.Time) {
 			}
 			// Release lock while we talk to KVStore, which could take a while.
 			h.electedLock.RUnlock()
-			err := h.updateKVStore(ctx, userID, cluster, replica, now, receivedAt)
+			err := h.updateKVStore(ctx, userID, cluster, replica, now, receivedAt, freshnessFailover)
 			h.electedLock.RLock()
 			if err != nil {
 				// Failed to store - log it but carry on
@@ -500,7 +582,7 @@ func (h *haTracker) checkReplica(ctx context.Context, userID, cluster, replica s
 		return newTooManyClustersError(limit)
 	}
 
-	err := h.updateKVStore(ctx, userID, cluster, replica, now, now.UnixMilli())
+	err := h.updateKVStore(ctx, userID, cluster, replica, now, now.UnixMilli(), false)
 	if err != nil {
 		level.Error(h.logger).Log("msg", "failed to update KVStore - rejecting sample", "err", err)
 		return err
@@ -509,6 +591,81 @@ func (h *haTracker) checkReplica(ctx context.Context, userID, cluster, replica s
 	return h.checkReplica(ctx, userID, cluster, replica, now)
 }
 
+// recordReplicaSamples tracks the number of samples received from the replica, used by the freshness
+// failover. It's a no-op if the freshness failover is disabled or the cluster isn't known yet.
+func (h *haTracker) recordReplicaSamples(userID, cluster, replica string, samples int, now time.Time) {
+	if !h.cfg.EnableHATracker || !h.cfg.FreshnessFailoverEnabled {
+		return
+	}
+
+	// The read lock is enough to look up the cluster, then only the cluster entry is locked,
+	// so that the pushes of different clusters don't serialize on the electedLock.
+	h.electedLock.RLock()
+	entry := h.clusters[userID][cluster]
+	h.electedLock.RUnlock()
+	if entry == nil {
+		return
+	}
+
+	entry.replicaSamplesMtx.Lock()
+	defer entry.replicaSamplesMtx.Unlock()
+
+	if entry.replicaSamples == nil {
+		entry.replicaSamples = map[string]*replicaSampleVolume{}
+	}
+	volume := entry.replicaSamples[replica]
+	if volume == nil {
+		// Remove the replicas which haven't sent samples within the window, before tracking a new one.
+		for r, v := range entry.replicaSamples {
+			if v.total(now, h.cfg.FreshnessFailoverWindow) == 0 {
+				delete(entry.replicaSamples, r)
+			}
+		}
+		volume = &replicaSampleVolume{}
+		entry.replicaSamples[replica] = volume
+	}
+	volume.add(samples, now, h.cfg.FreshnessFailoverWindow)
+}
+
+// freshnessFailoverCandidate returns the replica to fail over to, and when it has been last seen, if the
+// number of samples received from the elected replica over the freshness window is lower than the min
+// ratio of the samples received from the replica sending the most samples. The elected replica must
+// have been elected for at least the window, so that its volume is fully tracked.
+// Must be called with electedLock held.
+func (h *haTracker) freshnessFailoverCandidate(entry *haClusterInfo, now time.Time) (string, int64, bool) {
+	if !h.cfg.FreshnessFailoverEnabled || now.Sub(timestamp.Time(entry.elected.ElectedAt)) < h.cfg.FreshnessFailoverWindow {
+		return "", 0, false
+	}
+
+	electedSamples, candidate, candidateSamples, lastSeen := h.replicaSampleVolumes(entry, now)
+	if candidate == "" || float64(electedSamples) >= h.cfg.FreshnessFailoverMinRatio*float64(candidateSamples) {
+		return "", 0, false
+	}
+
+	if !h.withinUpdateTimeout(now, lastSeen) {
+		return "", 0, false
+	}
+	return candidate, lastSeen, true
+}
+
+// replicaSampleVolumes returns the number of samples received over the freshness window from the elected
+// replica, and from the non-elected replica sending the most samples along with when it has been last seen.
+// Must be called with electedLock held.
+func (h *haTracker) replicaSampleVolumes(entry *haClusterInfo, now time.Time) (electedSamples int64, candidate string, candidateSamples, candidateLastSeen int64) {
+	entry.replicaSamplesMtx.Lock()
+	defer entry.replicaSamplesMtx.Unlock()
+
+	for replica, volume := range entry.replicaSamples {
+		total := volume.total(now, h.cfg.FreshnessFailoverWindow)
+		if replica == entry.elected.Replica {
+			electedSamples = total
+		} else if total > candidateSamples {
+			candidate, candidateSamples, candidateLastSeen = replica, total, volume.lastSeen
+		}
+	}
+	return electedSamples, candidate, candidateSamples, candidateLastSeen
+}
+
 func (h *haTracker) withinUpdateTimeout(now time.Time, receivedAt int64) bool {
 	return now.Sub(timestamp.Time(receivedAt)) < h.cfg.UpdateTimeout+h.updateTimeoutJitter
 }
@@ -541,11 +698,14 @@ func (h *haTracker) updateCache(userID, cluster string, desc *ReplicaDesc) {
 
 // If we do set the value then err will be nil and desc will contain the value we set.
 // If there is already a valid value in the store, return nil, nil.
-func (h *haTracker) updateKVStore(ctx context.Context, userID, cluster, replica string, now time.Time, receivedAt int64) error {
+// If freshnessFailover is true, the elected replica is replaced without waiting for the failover timeout.
+func (h *haTracker) updateKVStore(ctx context.Context, userID, cluster, replica string, now time.Time, receivedAt int64, freshnessFailover bool) error {
 	key := fmt.Sprintf("%s/%s", userID, cluster)
 	var desc *ReplicaDesc
 	var electedAtTime, electedChanges int64
+	var failedOverFrom string
 	err := h.client.CAS(ctx, key, func(in interface{}) (out interface{}, retry bool, err error) {
+		failedOverFrom = ""
 		var ok bool
 		if desc, ok = in.(*ReplicaDesc); ok && desc.DeletedAt == 0 {
 			// If the entry in KVStore is up-to-date, just stop the loop.
@@ -556,11 +716,15 @@ func (h *haTracker) updateKVStore(ctx context.Context, userID, cluster, replica
 			electedChanges = desc.ElectedChanges
 			// If our replica is different, wait until the failover time
 			if desc.Replica != replica {
-				if now.Sub(timestamp.Time(desc.ReceivedAt)) < h.cfg.FailoverTimeout {
+				if freshnessFailover {
+					level.Info(h.logger).Log("msg", "elected replica sample volume is too low, attempting to fail over", "user", userID, "cluster", cluster, "replica", replica, "elected", desc.Replica)
+					failedOverFrom = desc.Replica
+				} else if now.Sub(timestamp.Time(desc.ReceivedAt)) < h.cfg.FailoverTimeout {
 					level.Info(h.logger).Log("msg", "replica differs, but it's too early to failover", "user", userID, "cluster", cluster, "replica", replica, "elected", desc.Replica, "received_at", timestamp.Time(desc.ReceivedAt))
 					return nil, false, nil
+				} else {
+					level.Info(h.logger).Log("msg", "replica differs, attempting to update kv", "user", userID, "cluster", cluster, "replica", replica, "elected", desc.Replica, "received_at", timestamp.Time(desc.ReceivedAt))
 				}
-				level.Info(h.logger).Log("msg", "replica differs, attempting to update kv", "user", userID, "cluster", cluster, "replica", replica, "elected", desc.Replica, "received_at", timestamp.Time(desc.ReceivedAt))
 				electedAtTime = timestamp.FromTime(now)
 				electedChanges = desc.ElectedChanges + 1
 			}
@@ -586,8 +750,17 @@ func (h *haTracker) updateKVStore(ctx context.Context, userID, cluster, replica
 		if h.clusters[userID][cluster] == nil {
 			h.updateCache(userID, cluster, desc)
 		}
+		if failedOverFrom != "" {
+			if entry := h.clusters[userID][cluster]; entry != nil {
+				entry.freshnessFailoverFrom = failedOverFrom
+				entry.freshnessFailoverAt = timestamp.FromTime(now)
+			}
+		}
 		h.electedLock.Unlock()
 	}
+	if err == nil && failedOverFrom != "" {
+		h.freshnessFailovers.WithLabelValues(userID, cluster).Inc()
+	}
 	return err
 }
 
@@ -615,4 +788,5 @@ func (h *haTracker) cleanupHATrackerMetricsForUser(userID string) {
 	h.lastElectionTimestamp.DeletePartialMatch(filter)
 	h.totalReelections.DeletePartialMatch(filter)
 	h.kvCASCalls.DeletePartialMatch(filter)
+	h.freshnessFailovers.DeletePartialMatch(filter)
 }
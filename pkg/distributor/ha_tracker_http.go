@@ -22,8 +22,9 @@ var haTrackerStatusPageHTML string
 var haTrackerStatusPageTemplate = template.Must(template.New("ha-tracker").Parse(haTrackerStatusPageHTML))
 
 type haTrackerStatusPageContents struct {
-	Elected []haTrackerReplica `json:"elected"`
-	Now     time.Time          `json:"now"`
+	Elected                  []haTrackerReplica `json:"elected"`
+	Now                      time.Time          `json:"now"`
+	FreshnessFailoverEnabled bool               `json:"freshnessFailoverEnabled"`
 }
 
 type haTrackerReplica struct {
@@ -34,16 +35,25 @@ type haTrackerReplica struct {
 	ElectedLastSeenTime time.Time     `json:"electedLastSeenTime"`
 	UpdateTime          time.Duration `json:"updateDuration"`
 	FailoverTime        time.Duration `json:"failoverDuration"`
+
+	// Freshness failover status, only set if the freshness failover is enabled.
+	ElectedSamples            int64     `json:"electedSamples,omitempty"`
+	CandidateReplica          string    `json:"candidateReplica,omitempty"`
+	CandidateSamples          int64     `json:"candidateSamples,omitempty"`
+	FreshnessDecision         string    `json:"freshnessDecision,omitempty"`
+	LastFreshnessFailoverFrom string    `json:"lastFreshnessFailoverFrom,omitempty"`
+	LastFreshnessFailoverTime time.Time `json:"lastFreshnessFailoverTime"`
 }
 
 func (h *haTracker) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	now := time.Now()
 	h.electedLock.RLock()
 
 	var electedReplicas []haTrackerReplica
 	for userID, clusters := range h.clusters {
 		for cluster, entry := range clusters {
 			desc := &entry.elected
-			electedReplicas = append(electedReplicas, haTrackerReplica{
+			replica := haTrackerReplica{
 				UserID:              userID,
 				Cluster:             cluster,
 				Replica:             desc.Replica,
@@ -51,7 +61,11 @@ func (h *haTracker) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 				ElectedLastSeenTime: timestamp.Time(desc.ReceivedAt),
 				UpdateTime:          time.Until(timestamp.Time(desc.ReceivedAt).Add(h.cfg.UpdateTimeout)),
 				FailoverTime:        time.Until(timestamp.Time(desc.ReceivedAt).Add(h.cfg.FailoverTimeout)),
-			})
+			}
+			if h.cfg.FreshnessFailoverEnabled {
+				h.setFreshnessStatus(&replica, entry, now)
+			}
+			electedReplicas = append(electedReplicas, replica)
 		}
 	}
 	h.electedLock.RUnlock()
@@ -67,7 +81,32 @@ func (h *haTracker) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	})
 
 	util.RenderHTTPResponse(w, haTrackerStatusPageContents{
-		Elected: electedReplicas,
-		Now:     time.Now(),
+		Elected:                  electedReplicas,
+		Now:                      now,
+		FreshnessFailoverEnabled: h.cfg.FreshnessFailoverEnabled,
 	}, haTrackerStatusPageTemplate, req)
 }
+
+// setFreshnessStatus sets the freshness failover status of the cluster on the replica.
+// Must be called with electedLock held.
+func (h *haTracker) setFreshnessStatus(replica *haTrackerReplica, entry *haClusterInfo, now time.Time) {
+	replica.ElectedSamples, replica.CandidateReplica, replica.CandidateSamples, _ = h.replicaSampleVolumes(entry, now)
+
+	switch {
+	case now.Sub(timestamp.Time(entry.elected.ElectedAt)) < h.cfg.FreshnessFailoverWindow:
+		replica.FreshnessDecision = "keep: elected less than the freshness window ago"
+	case replica.CandidateReplica == "":
+		replica.FreshnessDecision = "keep: no other replica sending samples"
+	default:
+		if candidate, _, ok := h.freshnessFailoverCandidate(entry, now); ok {
+			replica.FreshnessDecision = "fail over to " + candidate + " on next update"
+		} else {
+			replica.FreshnessDecision = "keep: elected replica sample volume is above the min ratio"
+		}
+	}
+
+	if entry.freshnessFailoverFrom != "" {
+		replica.LastFreshnessFailoverFrom = entry.freshnessFailoverFrom
+		replica.LastFreshnessFailoverTime = timestamp.Time(entry.freshnessFailoverAt)
+	}
+}
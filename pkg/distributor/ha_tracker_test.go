@@ -7,7 +7,10 @@ package distributor
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"strings"
 	"testing"
@@ -192,6 +195,38 @@ func TestHATrackerConfig_Validate(t *testing.T) {
 			}(),
 			expectedErr: errMemberlistUnsupported,
 		},
+		"should fail if freshness failover is enabled with a zero window": {
+			cfg: func() HATrackerConfig {
+				cfg := HATrackerConfig{}
+				flagext.DefaultValues(&cfg)
+				cfg.FreshnessFailoverEnabled = true
+				cfg.FreshnessFailoverWindow = 0
+
+				return cfg
+			}(),
+			expectedErr: errInvalidFreshnessWindow,
+		},
+		"should fail if freshness failover is enabled with a min ratio >= 1": {
+			cfg: func() HATrackerConfig {
+				cfg := HATrackerConfig{}
+				flagext.DefaultValues(&cfg)
+				cfg.FreshnessFailoverEnabled = true
+				cfg.FreshnessFailoverMinRatio = 1
+
+				return cfg
+			}(),
+			expectedErr: errInvalidFreshnessMinRatio,
+		},
+		"should pass if freshness failover is enabled with the default config": {
+			cfg: func() HATrackerConfig {
+				cfg := HATrackerConfig{}
+				flagext.DefaultValues(&cfg)
+				cfg.FreshnessFailoverEnabled = true
+
+				return cfg
+			}(),
+			expectedErr: nil,
+		},
 	}
 
 	for testName, testData := range tests {
@@ -1035,3 +1070,132 @@ func TestHATrackerChangeInElectedReplicaClearsLastSeenTimestamp(t *testing.T) {
 		require.Equal(t, firstReceivedAtT2.UnixMilli(), info.electedLastSeenTimestamp)
 	}
 }
+
+func TestReplicaSampleVolume(t *testing.T) {
+	const window = time.Minute
+	now := time.UnixMilli(1700000000000)
+
+	v := &replicaSampleVolume{}
+	v.add(10, now, window)
+	v.add(20, now.Add(15*time.Second), window)
+	v.add(30, now.Add(45*time.Second), window)
+
+	assert.Equal(t, int64(60), v.total(now.Add(45*time.Second), window))
+	assert.Equal(t, now.Add(45*time.Second).UnixMilli(), v.lastSeen)
+
+	// The first samples are out of the window.
+	assert.Equal(t, int64(50), v.total(now.Add(65*time.Second), window))
+
+	// A bucket is reset when reused for a later period.
+	v.add(5, now.Add(time.Minute), window)
+	assert.Equal(t, int64(55), v.total(now.Add(time.Minute), window))
+
+	assert.Equal(t, int64(0), v.total(now.Add(3*time.Minute), window))
+}
+
+func TestHATrackerFreshnessFailover(t *testing.T) {
+	const (
+		userID         = "user"
+		cluster        = "cluster"
+		electedReplica = "first"
+		otherReplica   = "second"
+		window         = 30 * time.Second
+		updateTimeout  = 5 * time.Second
+	)
+
+	tests := map[string]struct {
+		electedSamples   int
+		otherSamples     int
+		expectedFailover bool
+	}{
+		"should fail over when the elected replica sample volume is below the min ratio of the other replica": {
+			electedSamples:   10,
+			otherSamples:     100,
+			expectedFailover: true,
+		},
+		"should not fail over when the elected replica sample volume is above the min ratio of the other replica": {
+			electedSamples: 60,
+			otherSamples:   100,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			kvStore, closer := consul.NewInMemoryClient(GetReplicaDescCodec(), log.NewNopLogger(), nil)
+			t.Cleanup(func() { assert.NoError(t, closer.Close()) })
+
+			reg := prometheus.NewPedanticRegistry()
+			c, err := newHATracker(HATrackerConfig{
+				EnableHATracker:           true,
+				KVStore:                   kv.Config{Mock: kvStore},
+				UpdateTimeout:             updateTimeout,
+				UpdateTimeoutJitterMax:    0,
+				FailoverTimeout:           10 * time.Second,
+				FreshnessFailoverEnabled:  true,
+				FreshnessFailoverWindow:   window,
+				FreshnessFailoverMinRatio: 0.5,
+			}, trackerLimits{maxClusters: 100}, reg, log.NewNopLogger())
+			require.NoError(t, err)
+			require.NoError(t, services.StartAndAwaitRunning(context.Background(), c))
+			defer services.StopAndAwaitTerminated(context.Background(), c) //nolint:errcheck
+
+			start := time.Now()
+			require.NoError(t, c.checkReplica(context.Background(), userID, cluster, electedReplica, start))
+
+			// Both replicas keep pushing, but with a different sample volume.
+			now := start
+			for now.Sub(start) < window {
+				now = now.Add(updateTimeout)
+
+				require.NoError(t, c.checkReplica(context.Background(), userID, cluster, electedReplica, now))
+				c.recordReplicaSamples(userID, cluster, electedReplica, testData.electedSamples, now)
+				require.Error(t, c.checkReplica(context.Background(), userID, cluster, otherReplica, now))
+				c.recordReplicaSamples(userID, cluster, otherReplica, testData.otherSamples, now)
+
+				c.updateKVStoreAll(context.Background(), now)
+
+				if now.Sub(start) < window {
+					// The elected replica is never demoted before being elected for the whole window.
+					checkReplicaTimestamp(t, time.Second, c, userID, cluster, electedReplica, now, start)
+				}
+			}
+
+			if testData.expectedFailover {
+				checkReplicaTimestamp(t, time.Second, c, userID, cluster, otherReplica, now, now)
+			} else {
+				checkReplicaTimestamp(t, time.Second, c, userID, cluster, electedReplica, now, start)
+			}
+
+			expectedFailovers := ""
+			if testData.expectedFailover {
+				expectedFailovers = `
+					# HELP cortex_ha_tracker_freshness_failovers_total The total number of times this distributor failed over to another replica because the elected replica sample volume was too low, for a user ID/cluster.
+					# TYPE cortex_ha_tracker_freshness_failovers_total counter
+					cortex_ha_tracker_freshness_failovers_total{cluster="cluster",user="user"} 1
+				`
+			}
+			require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expectedFailovers), "cortex_ha_tracker_freshness_failovers_total"))
+
+			// The decision is shown in the status page.
+			req := httptest.NewRequest(http.MethodGet, "/distributor/ha_tracker", nil)
+			req.Header.Set("Accept", "application/json")
+			resp := httptest.NewRecorder()
+			c.ServeHTTP(resp, req)
+			require.Equal(t, http.StatusOK, resp.Code)
+
+			var contents haTrackerStatusPageContents
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &contents))
+			require.True(t, contents.FreshnessFailoverEnabled)
+			require.Len(t, contents.Elected, 1)
+
+			if testData.expectedFailover {
+				assert.Equal(t, otherReplica, contents.Elected[0].Replica)
+				assert.Equal(t, electedReplica, contents.Elected[0].LastFreshnessFailoverFrom)
+				assert.Equal(t, "keep: elected less than the freshness window ago", contents.Elected[0].FreshnessDecision)
+			} else {
+				assert.Equal(t, electedReplica, contents.Elected[0].Replica)
+				assert.Empty(t, contents.Elected[0].LastFreshnessFailoverFrom)
+			}
+		})
+	}
+}
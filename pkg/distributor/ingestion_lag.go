@@ -0,0 +1,440 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	_ "embed" // Used to embed html template
+	"html/template"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/grafana/dskit/grpcutil"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util"
+)
+
+const (
+	ingestionLagOutcomeAccepted = "accepted"
+	ingestionLagOutcomeRejected = "rejected"
+
+	// ingestionLagOtherCluster is the cluster the samples are accounted to once the tenant reached the max number
+	// of tracked clusters.
+	ingestionLagOtherCluster = "__other__"
+)
+
+// ingestionLagBuckets are the upper bounds, in seconds, of the sample age buckets.
+var ingestionLagBuckets = [...]float64{1, 5, 15, 30, 60, 120, 300, 600, 1800, 3600, 7200, 21600, 43200, 86400}
+
+//go:embed ingestion_lag.gohtml
+var ingestionLagPageHTML string
+var ingestionLagPageTemplate = template.Must(template.New("ingestion-lag").Parse(ingestionLagPageHTML))
+
+// ingestionLagTracker tracks, for each tenant and HA cluster, the age of the accepted and rejected samples,
+// computed as the difference between the time the sample is received and its timestamp. The rejected samples
+// include the ones rejected by the distributor validation and the ones reported as too old or out of order
+// by the ingesters.
+type ingestionLagTracker struct {
+	mtx     sync.Mutex
+	tenants map[string]map[string]*ingestionLagStats // First key = user, second key = cluster.
+
+	sampleAge *prometheus.HistogramVec
+}
+
+type ingestionLagStats struct {
+	accepted ingestionLagCounts
+	rejected ingestionLagCounts
+}
+
+// ingestionLagCounts is a histogram of sample ages.
+type ingestionLagCounts struct {
+	buckets   [len(ingestionLagBuckets) + 1]uint64 // The last bucket is +Inf.
+	count     uint64
+	maxAgeSec float64
+}
+
+func newIngestionLagTracker(reg prometheus.Registerer) *ingestionLagTracker {
+	return &ingestionLagTracker{
+		tenants: map[string]map[string]*ingestionLagStats{},
+		sampleAge: promauto.With(reg).NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "cortex_distributor_sample_age_seconds",
+			Help:    "The difference between the time a sample has been received and its timestamp. Samples with a timestamp in the future are observed as 0.",
+			Buckets: ingestionLagBuckets[:],
+		}, []string{"user", "cluster", "outcome"}),
+	}
+}
+
+func (c *ingestionLagCounts) observe(ageSec float64) {
+	idx, _ := slices.BinarySearch(ingestionLagBuckets[:], ageSec)
+	c.buckets[idx]++
+	c.count++
+	c.maxAgeSec = max(c.maxAgeSec, ageSec)
+}
+
+func (c *ingestionLagCounts) merge(other *ingestionLagCounts) {
+	for idx, count := range other.buckets {
+		c.buckets[idx] += count
+	}
+	c.count += other.count
+	c.maxAgeSec = max(c.maxAgeSec, other.maxAgeSec)
+}
+
+// quantile returns the upper bound of the bucket containing the quantile, or the max age if the quantile
+// falls into the +Inf bucket.
+func (c *ingestionLagCounts) quantile(q float64) float64 {
+	if c.count == 0 {
+		return 0
+	}
+
+	rank := uint64(math.Ceil(q * float64(c.count)))
+	var cumulative uint64
+	for idx, count := range c.buckets {
+		cumulative += count
+		if cumulative >= rank {
+			if idx < len(ingestionLagBuckets) {
+				return min(ingestionLagBuckets[idx], c.maxAgeSec)
+			}
+			break
+		}
+	}
+	return c.maxAgeSec
+}
+
+// ingestionLagRequestKey is the context key of the ingestionLagRequest of a write request.
+const ingestionLagRequestKey ctxKey = 2
+
+// ingestionLagRequest accumulates the sample ages of a single write request, so that the tracker lock is only
+// taken once per request.
+type ingestionLagRequest struct {
+	tracker *ingestionLagTracker
+	userID  string
+	cluster string
+	nowMs   int64
+	stats   ingestionLagStats
+
+	acceptedObserver prometheus.Observer
+	rejectedObserver prometheus.Observer
+
+	// minRejectingIngesters is the number of ingesters that must reject a sample to account it as rejected,
+	// because the sample can't be written to a quorum of ingesters anymore.
+	minRejectingIngesters int
+
+	// pushed is the write request pushed to the ingesters. Its samples are accounted as accepted once the
+	// request completes, except the ones rejected by the ingesters.
+	pushed *mimirpb.WriteRequest
+
+	// ingesterRejectionsMtx protects ingesterRejections, because the ingesters are pushed to concurrently.
+	ingesterRejectionsMtx sync.Mutex
+	// ingesterRejections is the number of ingesters that rejected each sample of the pushed request.
+	ingesterRejections map[ingestionLagSample]int
+}
+
+// ingestionLagSample identifies a sample of the pushed request.
+type ingestionLagSample struct {
+	seriesIndex int
+	timestampMs int64
+}
+
+// newRequest starts tracking the sample ages of a write request. All the series of the request are accounted to
+// the HA cluster of the first series, like the HA tracker does. If the tenant already tracks maxClusters clusters,
+// the samples of any other cluster are accounted to ingestionLagOtherCluster. It returns nil if the tracker is nil.
+func (t *ingestionLagTracker) newRequest(userID string, timeseries []mimirpb.PreallocTimeseries, clusterLabel string, maxClusters, replicationFactor int, now time.Time) *ingestionLagRequest {
+	if t == nil {
+		return nil
+	}
+
+	var cluster string
+	if len(timeseries) > 0 {
+		for _, l := range timeseries[0].Labels {
+			if l.Name == clusterLabel {
+				cluster = l.Value
+				break
+			}
+		}
+	}
+
+	t.mtx.Lock()
+	if _, ok := t.tenants[userID][cluster]; !ok && maxClusters > 0 && len(t.tenants[userID]) >= maxClusters {
+		cluster = ingestionLagOtherCluster
+	} else {
+		// Make a copy, since it's retained in the tracker and as a label of the metrics.
+		cluster = strings.Clone(cluster)
+	}
+	t.mtx.Unlock()
+
+	return &ingestionLagRequest{
+		tracker:          t,
+		userID:           userID,
+		cluster:          cluster,
+		nowMs:            now.UnixMilli(),
+		acceptedObserver: t.sampleAge.WithLabelValues(userID, cluster, ingestionLagOutcomeAccepted),
+		rejectedObserver: t.sampleAge.WithLabelValues(userID, cluster, ingestionLagOutcomeRejected),
+		// A write succeeds once a quorum of replicationFactor/2+1 ingesters succeeded.
+		minRejectingIngesters: max(replicationFactor-(replicationFactor/2+1)+1, 1),
+	}
+}
+
+// contextWithIngestionLagRequest returns a context carrying r, so that the ingesters rejections can be reported to it.
+func contextWithIngestionLagRequest(ctx context.Context, r *ingestionLagRequest) context.Context {
+	if r == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, ingestionLagRequestKey, r)
+}
+
+// ingestionLagRequestFromContext returns the ingestionLagRequest carried by ctx, or nil if there's none.
+func ingestionLagRequestFromContext(ctx context.Context) *ingestionLagRequest {
+	r, _ := ctx.Value(ingestionLagRequestKey).(*ingestionLagRequest)
+	return r
+}
+
+// observeRejected observes the age of the samples and histograms of a series rejected by the distributor.
+// It's safe to call on a nil ingestionLagRequest.
+func (r *ingestionLagRequest) observeRejected(ts mimirpb.PreallocTimeseries) {
+	if r == nil {
+		return
+	}
+
+	for _, s := range ts.Samples {
+		r.observeRejectedSample(s.TimestampMs)
+	}
+	for _, h := range ts.Histograms {
+		r.observeRejectedSample(h.Timestamp)
+	}
+}
+
+func (r *ingestionLagRequest) observeRejectedSample(timestampMs int64) {
+	ageSec := r.ageSeconds(timestampMs)
+	r.stats.rejected.observe(ageSec)
+	r.rejectedObserver.Observe(ageSec)
+}
+
+// push records the request pushed to the ingesters. Its samples are observed once the request completes, when done
+// is called. It's safe to call on a nil ingestionLagRequest.
+func (r *ingestionLagRequest) push(req *mimirpb.WriteRequest) {
+	if r == nil {
+		return
+	}
+	r.pushed = req
+}
+
+// observeIngesterRejections records the samples reported as too old or out of order in the error details of the
+// ingester push error. The series indexes of the error details refer to the request sent to the ingester, which
+// contains the series of the pushed request at indexes. It's safe to call on a nil ingestionLagRequest.
+func (r *ingestionLagRequest) observeIngesterRejections(indexes []int, err error) {
+	if r == nil || err == nil {
+		return
+	}
+
+	stat, ok := grpcutil.ErrorToStatus(err)
+	if !ok {
+		return
+	}
+	details := stat.Details()
+	if len(details) != 1 {
+		return
+	}
+	errorDetails, ok := details[0].(*mimirpb.ErrorDetails)
+	if !ok || len(errorDetails.GetRejectedSamples()) == 0 {
+		return
+	}
+
+	r.ingesterRejectionsMtx.Lock()
+	defer r.ingesterRejectionsMtx.Unlock()
+
+	if r.ingesterRejections == nil {
+		r.ingesterRejections = map[ingestionLagSample]int{}
+	}
+	for _, rejected := range errorDetails.GetRejectedSamples() {
+		if rejected.SeriesIndex < 0 || int(rejected.SeriesIndex) >= len(indexes) {
+			continue
+		}
+		seriesIndex := indexes[rejected.SeriesIndex]
+		for _, timestampMs := range rejected.TimestampsMs {
+			r.ingesterRejections[ingestionLagSample{seriesIndex: seriesIndex, timestampMs: timestampMs}]++
+		}
+	}
+}
+
+func (r *ingestionLagRequest) ageSeconds(timestampMs int64) float64 {
+	return max(float64(r.nowMs-timestampMs)/1000, 0)
+}
+
+// done observes the samples of the pushed request, accounting the ones rejected by enough ingesters as rejected and
+// the others as accepted, and merges the sample ages of the request into the tracker. It must be called once all
+// the ingesters pushes completed, while the pushed request is still valid. It's safe to call on a nil
+// ingestionLagRequest.
+func (r *ingestionLagRequest) done() {
+	if r == nil {
+		return
+	}
+
+	if r.pushed != nil {
+		for seriesIdx, ts := range r.pushed.Timeseries {
+			for _, s := range ts.Samples {
+				r.observePushedSample(seriesIdx, s.TimestampMs)
+			}
+			for _, h := range ts.Histograms {
+				r.observePushedSample(seriesIdx, h.Timestamp)
+			}
+		}
+		r.pushed = nil
+	}
+
+	if r.stats.accepted.count+r.stats.rejected.count == 0 {
+		return
+	}
+
+	t := r.tracker
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	clusters := t.tenants[r.userID]
+	if clusters == nil {
+		clusters = map[string]*ingestionLagStats{}
+		t.tenants[r.userID] = clusters
+	}
+	stats := clusters[r.cluster]
+	if stats == nil {
+		stats = &ingestionLagStats{}
+		clusters[r.cluster] = stats
+	}
+	stats.accepted.merge(&r.stats.accepted)
+	stats.rejected.merge(&r.stats.rejected)
+}
+
+func (r *ingestionLagRequest) observePushedSample(seriesIdx int, timestampMs int64) {
+	if r.ingesterRejections[ingestionLagSample{seriesIndex: seriesIdx, timestampMs: timestampMs}] >= r.minRejectingIngesters {
+		r.observeRejectedSample(timestampMs)
+		return
+	}
+
+	ageSec := r.ageSeconds(timestampMs)
+	r.stats.accepted.observe(ageSec)
+	r.acceptedObserver.Observe(ageSec)
+}
+
+// removeTenant removes the tracked sample ages and the metrics of the tenant.
+func (t *ingestionLagTracker) removeTenant(userID string) {
+	if t == nil {
+		return
+	}
+
+	t.mtx.Lock()
+	delete(t.tenants, userID)
+	t.mtx.Unlock()
+
+	t.sampleAge.DeletePartialMatch(prometheus.Labels{"user": userID})
+}
+
+type ingestionLagPageContents struct {
+	Now                  time.Time                     `json:"now"`
+	Tenant               string                        `json:"tenant"`
+	OutOfOrderTimeWindow model.Duration                `json:"outOfOrderTimeWindow"`
+	PastGracePeriod      model.Duration                `json:"pastGracePeriod"`
+	Clusters             []ingestionLagClusterContents `json:"clusters"`
+}
+
+type ingestionLagClusterContents struct {
+	Cluster  string                      `json:"cluster"`
+	Accepted ingestionLagOutcomeContents `json:"accepted"`
+	Rejected ingestionLagOutcomeContents `json:"rejected"`
+
+	// SuggestedOutOfOrderTimeWindow is the 99th percentile of the age of all the samples of the cluster.
+	SuggestedOutOfOrderTimeWindow model.Duration `json:"suggestedOutOfOrderTimeWindow"`
+}
+
+type ingestionLagOutcomeContents struct {
+	Samples uint64               `json:"samples"`
+	Buckets []ingestionLagBucket `json:"buckets"`
+	P50     model.Duration       `json:"p50"`
+	P90     model.Duration       `json:"p90"`
+	P99     model.Duration       `json:"p99"`
+	Max     model.Duration       `json:"max"`
+}
+
+type ingestionLagBucket struct {
+	LessThanOrEqual string `json:"le"`
+	Samples         uint64 `json:"samples"`
+}
+
+// IngestionLagHandler shows the age of the samples received by this distributor for the tenant in the path,
+// broken down by HA cluster.
+func (d *Distributor) IngestionLagHandler(w http.ResponseWriter, req *http.Request) {
+	userID := mux.Vars(req)["tenant"]
+	if userID == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		util.WriteTextResponse(w, "Tenant ID can't be empty")
+		return
+	}
+
+	if d.ingestionLag == nil {
+		w.WriteHeader(http.StatusNotFound)
+		util.WriteTextResponse(w, "Ingestion lag tracking is disabled")
+		return
+	}
+
+	util.RenderHTTPResponse(w, ingestionLagPageContents{
+		Now:                  time.Now(),
+		Tenant:               userID,
+		OutOfOrderTimeWindow: model.Duration(d.limits.OutOfOrderTimeWindow(userID)),
+		PastGracePeriod:      model.Duration(d.limits.PastGracePeriod(userID)),
+		Clusters:             d.ingestionLag.clusterContents(userID),
+	}, ingestionLagPageTemplate, req)
+}
+
+func (t *ingestionLagTracker) clusterContents(userID string) []ingestionLagClusterContents {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	out := make([]ingestionLagClusterContents, 0, len(t.tenants[userID]))
+	for cluster, stats := range t.tenants[userID] {
+		all := stats.accepted
+		all.merge(&stats.rejected)
+
+		out = append(out, ingestionLagClusterContents{
+			Cluster:                       cluster,
+			Accepted:                      stats.accepted.contents(),
+			Rejected:                      stats.rejected.contents(),
+			SuggestedOutOfOrderTimeWindow: secondsToDuration(all.quantile(0.99)),
+		})
+	}
+
+	slices.SortFunc(out, func(a, b ingestionLagClusterContents) int {
+		return strings.Compare(a.Cluster, b.Cluster)
+	})
+	return out
+}
+
+func (c *ingestionLagCounts) contents() ingestionLagOutcomeContents {
+	buckets := make([]ingestionLagBucket, 0, len(c.buckets))
+	for idx, count := range c.buckets {
+		le := "+Inf"
+		if idx < len(ingestionLagBuckets) {
+			le = secondsToDuration(ingestionLagBuckets[idx]).String()
+		}
+		buckets = append(buckets, ingestionLagBucket{LessThanOrEqual: le, Samples: count})
+	}
+
+	return ingestionLagOutcomeContents{
+		Samples: c.count,
+		Buckets: buckets,
+		P50:     secondsToDuration(c.quantile(0.5)),
+		P90:     secondsToDuration(c.quantile(0.9)),
+		P99:     secondsToDuration(c.quantile(0.99)),
+		Max:     secondsToDuration(c.maxAgeSec),
+	}
+}
+
+func secondsToDuration(sec float64) model.Duration {
+	return model.Duration(time.Duration(sec * float64(time.Second)).Round(time.Millisecond))
+}
@@ -0,0 +1,251 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gogo/status"
+	"github.com/gorilla/mux"
+	"github.com/grafana/dskit/flagext"
+	"github.com/grafana/dskit/user"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestIngestionLagCounts(t *testing.T) {
+	t.Run("empty", func(t *testing.T) {
+		var c ingestionLagCounts
+		assert.Equal(t, 0.0, c.quantile(0.99))
+	})
+
+	t.Run("quantiles are the upper bound of the bucket, capped to the max age", func(t *testing.T) {
+		var c ingestionLagCounts
+		for i := 0; i < 90; i++ {
+			c.observe(0.5)
+		}
+		for i := 0; i < 9; i++ {
+			c.observe(100)
+		}
+		c.observe(250)
+
+		assert.Equal(t, uint64(100), c.count)
+		assert.Equal(t, 1.0, c.quantile(0.5))
+		assert.Equal(t, 1.0, c.quantile(0.9))
+		assert.Equal(t, 120.0, c.quantile(0.99))
+		assert.Equal(t, 250.0, c.quantile(1))
+	})
+
+	t.Run("quantiles in the +Inf bucket are the max age", func(t *testing.T) {
+		var c ingestionLagCounts
+		c.observe(200000)
+		assert.Equal(t, 200000.0, c.quantile(0.5))
+		assert.Equal(t, uint64(1), c.buckets[len(ingestionLagBuckets)])
+	})
+
+	t.Run("merge", func(t *testing.T) {
+		var a, b ingestionLagCounts
+		a.observe(10)
+		b.observe(20)
+		b.observe(4000)
+		a.merge(&b)
+
+		assert.Equal(t, uint64(3), a.count)
+		assert.Equal(t, 4000.0, a.maxAgeSec)
+	})
+}
+
+func TestIngestionLagTracker_MaxClusters(t *testing.T) {
+	tracker := newIngestionLagTracker(prometheus.NewPedanticRegistry())
+	now := time.Now()
+
+	for _, cluster := range []string{"a", "b", "c"} {
+		timeseries := []mimirpb.PreallocTimeseries{makeTimeseries([]string{model.MetricNameLabel, "metric", "cluster", cluster}, makeSamples(now.UnixMilli(), 1), nil)}
+		req := tracker.newRequest("user", timeseries, "cluster", 2, 1, now)
+		req.push(&mimirpb.WriteRequest{Timeseries: timeseries})
+		req.done()
+	}
+
+	clusters := tracker.clusterContents("user")
+	require.Len(t, clusters, 3)
+	assert.Equal(t, ingestionLagOtherCluster, clusters[0].Cluster)
+	assert.Equal(t, "a", clusters[1].Cluster)
+	assert.Equal(t, "b", clusters[2].Cluster)
+}
+
+func TestIngestionLagRequest_ObserveIngesterRejections(t *testing.T) {
+	now := time.Now()
+	oldTimestamp := now.Add(-2 * time.Hour).UnixMilli()
+
+	ingesterErr := func(details *mimirpb.ErrorDetails) error {
+		stat, err := status.New(codes.InvalidArgument, "sample rejected").WithDetails(details)
+		require.NoError(t, err)
+		return stat.Err()
+	}
+	rejectedErr := ingesterErr(&mimirpb.ErrorDetails{
+		Cause: mimirpb.BAD_DATA,
+		// The series index is the index in the request sent to the ingester, which contains the series 1 and 2.
+		RejectedSamples: []mimirpb.RejectedSeriesSamples{{SeriesIndex: 1, TimestampsMs: []int64{oldTimestamp, oldTimestamp + 1}}},
+	})
+
+	tests := map[string]struct {
+		replicationFactor int
+		errs              []error
+		expectedRejected  uint64
+	}{
+		"no error": {
+			replicationFactor: 1,
+			errs:              []error{nil},
+		},
+		"error without rejected samples": {
+			replicationFactor: 1,
+			errs:              []error{ingesterErr(&mimirpb.ErrorDetails{Cause: mimirpb.BAD_DATA})},
+		},
+		"error which is not a gRPC status": {
+			replicationFactor: 1,
+			errs:              []error{errors.New("connection refused")},
+		},
+		"samples rejected by the only ingester": {
+			replicationFactor: 1,
+			errs:              []error{rejectedErr},
+			expectedRejected:  2,
+		},
+		"samples rejected by one ingester out of three": {
+			replicationFactor: 3,
+			errs:              []error{rejectedErr, nil, nil},
+		},
+		"samples rejected by two ingesters out of three": {
+			replicationFactor: 3,
+			errs:              []error{rejectedErr, nil, rejectedErr},
+			expectedRejected:  2,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			tracker := newIngestionLagTracker(prometheus.NewPedanticRegistry())
+			timeseries := []mimirpb.PreallocTimeseries{
+				makeTimeseries([]string{model.MetricNameLabel, "metric1"}, makeSamples(now.UnixMilli(), 1), nil),
+				makeTimeseries([]string{model.MetricNameLabel, "metric2"}, makeSamples(now.UnixMilli(), 1), nil),
+				makeTimeseries([]string{model.MetricNameLabel, "metric3"}, makeSamples(oldTimestamp, 1), nil),
+			}
+			timeseries[2].Samples = append(timeseries[2].Samples, mimirpb.Sample{TimestampMs: oldTimestamp + 1, Value: 1})
+
+			req := tracker.newRequest("user", timeseries, "cluster", 0, tc.replicationFactor, now)
+			req.push(&mimirpb.WriteRequest{Timeseries: timeseries})
+			for _, err := range tc.errs {
+				req.observeIngesterRejections([]int{1, 2}, err)
+			}
+			req.done()
+
+			clusters := tracker.clusterContents("user")
+			require.Len(t, clusters, 1)
+			assert.Equal(t, 4-tc.expectedRejected, clusters[0].Accepted.Samples)
+			assert.Equal(t, tc.expectedRejected, clusters[0].Rejected.Samples)
+			if tc.expectedRejected > 0 {
+				assert.InDelta(t, float64(2*time.Hour), float64(clusters[0].Rejected.Max), float64(time.Second))
+				assert.Equal(t, model.Duration(0), clusters[0].Accepted.Max)
+			}
+		})
+	}
+}
+
+func TestDistributor_IngestionLag(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "user")
+
+	var limits validation.Limits
+	flagext.DefaultValues(&limits)
+	limits.PastGracePeriod = model.Duration(time.Hour)
+	limits.OutOfOrderTimeWindow = model.Duration(10 * time.Minute)
+
+	ds, _, regs, _ := prepare(t, prepConfig{
+		numIngesters:    3,
+		happyIngesters:  3,
+		numDistributors: 1,
+		limits:          &limits,
+		configure: func(cfg *Config) {
+			cfg.IngestionLagTrackingEnabled = true
+		},
+	})
+
+	now := time.Now()
+	req := &mimirpb.WriteRequest{
+		Timeseries: []mimirpb.PreallocTimeseries{
+			makeTimeseries([]string{model.MetricNameLabel, "metric1", "cluster", "prom"}, makeSamples(now.Add(-20*time.Second).UnixMilli(), 1), nil),
+			makeTimeseries([]string{model.MetricNameLabel, "metric2", "cluster", "prom"}, makeSamples(now.Add(-3*time.Minute).UnixMilli(), 2), nil),
+			// Rejected because too far in the past.
+			makeTimeseries([]string{model.MetricNameLabel, "metric3", "cluster", "prom"}, makeSamples(now.Add(-2*time.Hour).UnixMilli(), 3), nil),
+		},
+	}
+	_, err := ds[0].Push(ctx, req)
+	require.Error(t, err)
+
+	sampleCounts := func() map[string]uint64 {
+		metrics, err := regs[0].Gather()
+		require.NoError(t, err)
+		counts := map[string]uint64{}
+		for _, mf := range metrics {
+			if mf.GetName() != "cortex_distributor_sample_age_seconds" {
+				continue
+			}
+			for _, m := range mf.GetMetric() {
+				for _, l := range m.GetLabel() {
+					if l.GetName() == "outcome" {
+						counts[l.GetValue()] = m.GetHistogram().GetSampleCount()
+					}
+				}
+			}
+		}
+		return counts
+	}
+	// The accepted samples are observed once all the ingesters pushes completed, which may be after Push() returns.
+	expectedSampleCounts := map[string]uint64{ingestionLagOutcomeAccepted: 2, ingestionLagOutcomeRejected: 1}
+	require.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual(expectedSampleCounts, sampleCounts())
+	}, time.Second, 10*time.Millisecond)
+
+	httpReq := httptest.NewRequest(http.MethodGet, "/distributor/tenant/user/ingestion_lag", nil)
+	httpReq = mux.SetURLVars(httpReq, map[string]string{"tenant": "user"})
+	httpReq.Header.Set("Accept", "application/json")
+	resp := httptest.NewRecorder()
+	ds[0].IngestionLagHandler(resp, httpReq)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var contents ingestionLagPageContents
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &contents))
+	assert.Equal(t, "user", contents.Tenant)
+	assert.Equal(t, model.Duration(10*time.Minute), contents.OutOfOrderTimeWindow)
+	require.Len(t, contents.Clusters, 1)
+
+	cluster := contents.Clusters[0]
+	assert.Equal(t, "prom", cluster.Cluster)
+	assert.Equal(t, uint64(2), cluster.Accepted.Samples)
+	assert.Equal(t, model.Duration(30*time.Second), cluster.Accepted.P50)
+	// The p99 is capped to the max age.
+	assert.InDelta(t, float64(3*time.Minute), float64(cluster.Accepted.P99), float64(time.Second))
+	assert.Equal(t, uint64(1), cluster.Rejected.Samples)
+	assert.InDelta(t, float64(2*time.Hour), float64(cluster.Rejected.Max), float64(time.Minute))
+	assert.InDelta(t, float64(2*time.Hour), float64(cluster.SuggestedOutOfOrderTimeWindow), float64(time.Minute))
+
+	t.Run("renders the HTML page", func(t *testing.T) {
+		httpReq := httptest.NewRequest(http.MethodGet, "/distributor/tenant/user/ingestion_lag", nil)
+		httpReq = mux.SetURLVars(httpReq, map[string]string{"tenant": "user"})
+		resp := httptest.NewRecorder()
+		ds[0].IngestionLagHandler(resp, httpReq)
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.True(t, strings.Contains(resp.Body.String(), "Cluster: prom"))
+	})
+}
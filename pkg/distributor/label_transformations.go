@@ -0,0 +1,143 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	prometheustranslator "github.com/prometheus/prometheus/storage/remote/otlptranslator/prometheus"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+var uuidRegexp = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// transformLabels applies the label transformations to the input labels, in order, modifying them in place.
+// onApplied is called with the name of each transformation which changed the labels. The returned labels
+// are not guaranteed to be sorted.
+func transformLabels(lbls []mimirpb.LabelAdapter, transformations []*validation.LabelTransformation, maxLabelValueLength int, onApplied func(name string)) []mimirpb.LabelAdapter {
+	for _, t := range transformations {
+		changed := false
+
+		switch t.Action {
+		case validation.LabelTransformationLowercase:
+			changed = transformLabelValues(lbls, t, strings.ToLower)
+		case validation.LabelTransformationTrim:
+			changed = transformLabelValues(lbls, t, strings.TrimSpace)
+		case validation.LabelTransformationTruncate:
+			maxLength := t.MaxLength
+			if maxLength == 0 {
+				maxLength = maxLabelValueLength
+			}
+			if maxLength > validation.LabelTransformationTruncateHashSuffixLength {
+				changed = transformLabelValues(lbls, t, func(v string) string {
+					return truncateLabelValue(v, maxLength)
+				})
+			}
+		case validation.LabelTransformationMapOTelNames:
+			changed = mapOTelNames(lbls)
+		case validation.LabelTransformationDropUUIDValues:
+			before := len(lbls)
+			lbls = dropUUIDLabelValues(lbls, t)
+			changed = len(lbls) != before
+		}
+
+		if changed {
+			onApplied(t.Name)
+		}
+	}
+	return lbls
+}
+
+// labelTransformationApplies returns whether a value transformation applies to the label with the given name.
+func labelTransformationApplies(t *validation.LabelTransformation, name string) bool {
+	if len(t.LabelNames) == 0 {
+		return name != labels.MetricName
+	}
+	for _, n := range t.LabelNames {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func transformLabelValues(lbls []mimirpb.LabelAdapter, t *validation.LabelTransformation, transform func(string) string) bool {
+	changed := false
+	for i := range lbls {
+		if !labelTransformationApplies(t, lbls[i].Name) {
+			continue
+		}
+		if v := transform(lbls[i].Value); v != lbls[i].Value {
+			lbls[i].Value = v
+			changed = true
+		}
+	}
+	return changed
+}
+
+// truncateLabelValue truncates the value to maxLength bytes, replacing its tail with a hash of the
+// whole value so that different values sharing the same prefix are kept distinct.
+func truncateLabelValue(value string, maxLength int) string {
+	if len(value) <= maxLength {
+		return value
+	}
+
+	// Don't cut a multi-byte rune in half, otherwise the value would be invalid UTF-8.
+	cut := maxLength - validation.LabelTransformationTruncateHashSuffixLength
+	for cut > 0 && !utf8.RuneStart(value[cut]) {
+		cut--
+	}
+	return fmt.Sprintf("%s~%016x", value[:cut], xxhash.Sum64String(value))
+}
+
+// mapOTelNames maps OpenTelemetry semantic convention metric and label names, like "http.server.duration",
+// to Prometheus compliant names.
+func mapOTelNames(lbls []mimirpb.LabelAdapter) bool {
+	changed := false
+	for i := range lbls {
+		if lbls[i].Name == labels.MetricName {
+			if model.IsValidMetricName(model.LabelValue(lbls[i].Value)) {
+				continue
+			}
+			lbls[i].Value = otelMetricNameToPrometheus(lbls[i].Value)
+			changed = true
+			continue
+		}
+
+		if model.LabelName(lbls[i].Name).IsValid() {
+			continue
+		}
+		lbls[i].Name = prometheustranslator.NormalizeLabel(lbls[i].Name)
+		changed = true
+	}
+	return changed
+}
+
+func otelMetricNameToPrometheus(name string) string {
+	name = prometheustranslator.RemovePromForbiddenRunes(name)
+	// Metric name cannot start with a digit.
+	if name != "" && unicode.IsDigit(rune(name[0])) {
+		name = "_" + name
+	}
+	return name
+}
+
+func dropUUIDLabelValues(lbls []mimirpb.LabelAdapter, t *validation.LabelTransformation) []mimirpb.LabelAdapter {
+	out := lbls[:0]
+	for _, l := range lbls {
+		if labelTransformationApplies(t, l.Name) && uuidRegexp.MatchString(l.Value) {
+			continue
+		}
+		out = append(out, l)
+	}
+	return out
+}
@@ -0,0 +1,132 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestTransformLabels(t *testing.T) {
+	const uuid = "3f0c8a4e-1b2d-4c5e-8f9a-0b1c2d3e4f5a"
+
+	tests := map[string]struct {
+		transformations     []*validation.LabelTransformation
+		maxLabelValueLength int
+		input               []mimirpb.LabelAdapter
+		expected            []mimirpb.LabelAdapter
+		expectedApplied     []string
+	}{
+		"no transformations": {
+			input:    []mimirpb.LabelAdapter{{Name: "__name__", Value: "Metric"}, {Name: "env", Value: "PROD"}},
+			expected: []mimirpb.LabelAdapter{{Name: "__name__", Value: "Metric"}, {Name: "env", Value: "PROD"}},
+		},
+		"lowercase all labels except the metric name": {
+			transformations: []*validation.LabelTransformation{{Name: "lc", Action: validation.LabelTransformationLowercase}},
+			input:           []mimirpb.LabelAdapter{{Name: "__name__", Value: "Metric"}, {Name: "env", Value: "PROD"}},
+			expected:        []mimirpb.LabelAdapter{{Name: "__name__", Value: "Metric"}, {Name: "env", Value: "prod"}},
+			expectedApplied: []string{"lc"},
+		},
+		"lowercase only the selected labels": {
+			transformations: []*validation.LabelTransformation{{Name: "lc", Action: validation.LabelTransformationLowercase, LabelNames: []string{"region"}}},
+			input:           []mimirpb.LabelAdapter{{Name: "__name__", Value: "metric"}, {Name: "env", Value: "PROD"}, {Name: "region", Value: "EU"}},
+			expected:        []mimirpb.LabelAdapter{{Name: "__name__", Value: "metric"}, {Name: "env", Value: "PROD"}, {Name: "region", Value: "eu"}},
+			expectedApplied: []string{"lc"},
+		},
+		"transformation not applied if nothing changes": {
+			transformations: []*validation.LabelTransformation{{Name: "trim", Action: validation.LabelTransformationTrim}},
+			input:           []mimirpb.LabelAdapter{{Name: "__name__", Value: "metric"}, {Name: "env", Value: "prod"}},
+			expected:        []mimirpb.LabelAdapter{{Name: "__name__", Value: "metric"}, {Name: "env", Value: "prod"}},
+		},
+		"trim": {
+			transformations: []*validation.LabelTransformation{{Name: "trim", Action: validation.LabelTransformationTrim}},
+			input:           []mimirpb.LabelAdapter{{Name: "__name__", Value: "metric"}, {Name: "env", Value: " prod\t"}},
+			expected:        []mimirpb.LabelAdapter{{Name: "__name__", Value: "metric"}, {Name: "env", Value: "prod"}},
+			expectedApplied: []string{"trim"},
+		},
+		"truncate with the tenant's max label value length": {
+			transformations:     []*validation.LabelTransformation{{Name: "truncate", Action: validation.LabelTransformationTruncate}},
+			maxLabelValueLength: 20,
+			input:               []mimirpb.LabelAdapter{{Name: "__name__", Value: "metric"}, {Name: "path", Value: "/a/very/long/path/to/somewhere"}, {Name: "short", Value: "value"}},
+			expected:            []mimirpb.LabelAdapter{{Name: "__name__", Value: "metric"}, {Name: "path", Value: truncateLabelValue("/a/very/long/path/to/somewhere", 20)}, {Name: "short", Value: "value"}},
+			expectedApplied:     []string{"truncate"},
+		},
+		"truncate with the transformation's max length": {
+			transformations:     []*validation.LabelTransformation{{Name: "truncate", Action: validation.LabelTransformationTruncate, MaxLength: 20}},
+			maxLabelValueLength: 2048,
+			input:               []mimirpb.LabelAdapter{{Name: "__name__", Value: "metric"}, {Name: "path", Value: "/a/very/long/path/to/somewhere"}},
+			expected:            []mimirpb.LabelAdapter{{Name: "__name__", Value: "metric"}, {Name: "path", Value: truncateLabelValue("/a/very/long/path/to/somewhere", 20)}},
+			expectedApplied:     []string{"truncate"},
+		},
+		"map OTel names": {
+			transformations: []*validation.LabelTransformation{{Name: "otel", Action: validation.LabelTransformationMapOTelNames}},
+			input:           []mimirpb.LabelAdapter{{Name: "__name__", Value: "http.server.duration"}, {Name: "http.method", Value: "GET"}, {Name: "env", Value: "prod"}},
+			expected:        []mimirpb.LabelAdapter{{Name: "__name__", Value: "http_server_duration"}, {Name: "http_method", Value: "GET"}, {Name: "env", Value: "prod"}},
+			expectedApplied: []string{"otel"},
+		},
+		"map OTel names prefixes metric names starting with a digit": {
+			transformations: []*validation.LabelTransformation{{Name: "otel", Action: validation.LabelTransformationMapOTelNames}},
+			input:           []mimirpb.LabelAdapter{{Name: "__name__", Value: "2xx.requests"}},
+			expected:        []mimirpb.LabelAdapter{{Name: "__name__", Value: "_2xx_requests"}},
+			expectedApplied: []string{"otel"},
+		},
+		"drop UUID values": {
+			transformations: []*validation.LabelTransformation{{Name: "uuid", Action: validation.LabelTransformationDropUUIDValues}},
+			input:           []mimirpb.LabelAdapter{{Name: "__name__", Value: "metric"}, {Name: "request_id", Value: uuid}, {Name: "env", Value: "prod-" + uuid}},
+			expected:        []mimirpb.LabelAdapter{{Name: "__name__", Value: "metric"}, {Name: "env", Value: "prod-" + uuid}},
+			expectedApplied: []string{"uuid"},
+		},
+		"transformations are applied in order": {
+			transformations: []*validation.LabelTransformation{
+				{Name: "trim", Action: validation.LabelTransformationTrim},
+				{Name: "uuid", Action: validation.LabelTransformationDropUUIDValues},
+				{Name: "lc", Action: validation.LabelTransformationLowercase},
+			},
+			input:           []mimirpb.LabelAdapter{{Name: "__name__", Value: "metric"}, {Name: "request_id", Value: " " + strings.ToUpper(uuid)}},
+			expected:        []mimirpb.LabelAdapter{{Name: "__name__", Value: "metric"}},
+			expectedApplied: []string{"trim", "uuid"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var applied []string
+			actual := transformLabels(tc.input, tc.transformations, tc.maxLabelValueLength, func(name string) {
+				applied = append(applied, name)
+			})
+			assert.Equal(t, tc.expected, actual)
+			assert.Equal(t, tc.expectedApplied, applied)
+		})
+	}
+}
+
+func TestTruncateLabelValue(t *testing.T) {
+	const maxLength = 30
+
+	t.Run("values within the max length are not changed", func(t *testing.T) {
+		assert.Equal(t, "value", truncateLabelValue("value", maxLength))
+	})
+
+	t.Run("long values are truncated to the max length", func(t *testing.T) {
+		a := truncateLabelValue(strings.Repeat("a", 40)+"1", maxLength)
+		b := truncateLabelValue(strings.Repeat("a", 40)+"2", maxLength)
+
+		assert.Len(t, a, maxLength)
+		assert.Len(t, b, maxLength)
+		assert.True(t, strings.HasPrefix(a, strings.Repeat("a", maxLength-validation.LabelTransformationTruncateHashSuffixLength)+"~"))
+		assert.NotEqual(t, a, b, "values sharing the same prefix must be kept distinct")
+	})
+
+	t.Run("multi-byte runes are not split", func(t *testing.T) {
+		actual := truncateLabelValue(strings.Repeat("é", 30), maxLength)
+		require.True(t, utf8.ValidString(actual))
+		assert.LessOrEqual(t, len(actual), maxLength)
+	})
+}
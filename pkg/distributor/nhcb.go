@@ -0,0 +1,353 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/value"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util"
+)
+
+const (
+	// nhcbSkipReasonIncomplete is used when the request doesn't contain all the series of a classic histogram,
+	// or the series don't have samples for the same timestamps.
+	nhcbSkipReasonIncomplete = "incomplete"
+
+	// nhcbSkipReasonInvalid is used when the series of a classic histogram can't be converted to a valid
+	// native histogram, for example because the bucket counts are not cumulative.
+	nhcbSkipReasonInvalid = "invalid"
+
+	// nhcbSkipReasonTooManyBuckets is used when the classic histogram has more buckets than the native histograms
+	// are allowed to have. The buckets of a native histogram with custom buckets can't be reduced, so the classic
+	// histogram is kept instead of having the native histogram rejected.
+	nhcbSkipReasonTooManyBuckets = "too_many_buckets"
+)
+
+// classicHistogram references the series of a classic histogram in a write request.
+type classicHistogram struct {
+	buckets  []classicHistogramBucket
+	sumIdx   int
+	countIdx int
+	invalid  bool
+}
+
+type classicHistogramBucket struct {
+	upperBound float64
+	idx        int
+}
+
+// convertClassicHistogramsToNHCB replaces the bucket, sum and count series of each classic histogram in the
+// request with a single native histogram with custom buckets (NHCB) series. The labels of the series must be
+// sorted. Classic histograms whose series are not all in the request, which can't be converted, or which have more
+// than maxBuckets buckets (if greater than 0) are left untouched and onSkipped is called with the reason. It returns
+// the number of converted histogram samples.
+func convertClassicHistogramsToNHCB(req *mimirpb.WriteRequest, maxBuckets int, onSkipped func(reason string)) int {
+	histograms := groupClassicHistograms(req.Timeseries)
+	if len(histograms) == 0 {
+		return 0
+	}
+
+	var (
+		converted       int
+		removeTsIndexes []int
+	)
+	for _, h := range histograms {
+		// Series with a sum and count but no bucket are not histograms, e.g. summaries.
+		if len(h.buckets) == 0 {
+			continue
+		}
+		if maxBuckets > 0 && len(h.buckets) > maxBuckets {
+			onSkipped(nhcbSkipReasonTooManyBuckets)
+			continue
+		}
+
+		samples, reason := h.toNativeHistograms(req.Timeseries)
+		if reason != "" {
+			onSkipped(reason)
+			continue
+		}
+
+		// Reuse the count series, which has the labels of the histogram except for the metric name suffix.
+		ts := &req.Timeseries[h.countIdx]
+		lbls := ts.Labels
+		for i := range lbls {
+			if lbls[i].Name == model.MetricNameLabel {
+				lbls[i].Value = strings.TrimSuffix(lbls[i].Value, "_count")
+			}
+		}
+		ts.SetLabels(lbls)
+		ts.Samples = ts.Samples[:0]
+		ts.Histograms = append(ts.Histograms[:0], samples...)
+
+		// The exemplars of the removed series are moved to the histogram. Their labels are copied because the
+		// memory backing them is released with the removed series.
+		for _, b := range h.buckets {
+			for _, e := range req.Timeseries[b.idx].Exemplars {
+				ts.Exemplars = append(ts.Exemplars, cloneExemplar(e))
+			}
+			removeTsIndexes = append(removeTsIndexes, b.idx)
+		}
+		for _, e := range req.Timeseries[h.sumIdx].Exemplars {
+			ts.Exemplars = append(ts.Exemplars, cloneExemplar(e))
+		}
+		removeTsIndexes = append(removeTsIndexes, h.sumIdx)
+
+		converted += len(samples)
+	}
+
+	if len(removeTsIndexes) > 0 {
+		slices.Sort(removeTsIndexes)
+		for _, removeTsIndex := range removeTsIndexes {
+			mimirpb.ReusePreallocTimeseries(&req.Timeseries[removeTsIndex])
+		}
+		req.Timeseries = util.RemoveSliceIndexes(req.Timeseries, removeTsIndexes)
+	}
+
+	return converted
+}
+
+// groupClassicHistograms groups the float series by classic histogram, identified by the metric name
+// without the _bucket, _sum or _count suffix and the labels other than le.
+func groupClassicHistograms(series []mimirpb.PreallocTimeseries) map[string]*classicHistogram {
+	var (
+		histograms map[string]*classicHistogram
+		key        strings.Builder
+	)
+
+	for idx, ts := range series {
+		if len(ts.Samples) == 0 || len(ts.Histograms) > 0 {
+			continue
+		}
+
+		var name, le string
+		hasLe := false
+		for _, l := range ts.Labels {
+			switch l.Name {
+			case model.MetricNameLabel:
+				name = l.Value
+			case model.BucketLabel:
+				le = l.Value
+				hasLe = true
+			}
+		}
+
+		var baseName, suffix string
+		switch {
+		case hasLe && strings.HasSuffix(name, "_bucket"):
+			baseName, suffix = strings.TrimSuffix(name, "_bucket"), "_bucket"
+		case !hasLe && strings.HasSuffix(name, "_sum"):
+			baseName, suffix = strings.TrimSuffix(name, "_sum"), "_sum"
+		case !hasLe && strings.HasSuffix(name, "_count"):
+			baseName, suffix = strings.TrimSuffix(name, "_count"), "_count"
+		default:
+			continue
+		}
+
+		key.Reset()
+		key.WriteString(baseName)
+		for _, l := range ts.Labels {
+			if l.Name == model.MetricNameLabel || l.Name == model.BucketLabel {
+				continue
+			}
+			key.WriteByte('\xff')
+			key.WriteString(l.Name)
+			key.WriteByte('\xff')
+			key.WriteString(l.Value)
+		}
+
+		if histograms == nil {
+			histograms = map[string]*classicHistogram{}
+		}
+		h := histograms[key.String()]
+		if h == nil {
+			h = &classicHistogram{sumIdx: -1, countIdx: -1}
+			histograms[key.String()] = h
+		}
+
+		switch suffix {
+		case "_bucket":
+			upperBound, err := strconv.ParseFloat(le, 64)
+			if err != nil {
+				h.invalid = true
+			}
+			h.buckets = append(h.buckets, classicHistogramBucket{upperBound: upperBound, idx: idx})
+		case "_sum":
+			h.invalid = h.invalid || h.sumIdx >= 0
+			h.sumIdx = idx
+		case "_count":
+			h.invalid = h.invalid || h.countIdx >= 0
+			h.countIdx = idx
+		}
+	}
+
+	return histograms
+}
+
+// toNativeHistograms returns a native histogram with custom buckets sample for each timestamp of the classic
+// histogram, or the reason why it can't be converted.
+func (h *classicHistogram) toNativeHistograms(series []mimirpb.PreallocTimeseries) ([]mimirpb.Histogram, string) {
+	if h.invalid {
+		return nil, nhcbSkipReasonInvalid
+	}
+	if h.sumIdx < 0 || h.countIdx < 0 {
+		return nil, nhcbSkipReasonIncomplete
+	}
+
+	slices.SortFunc(h.buckets, func(a, b classicHistogramBucket) int {
+		switch {
+		case a.upperBound < b.upperBound:
+			return -1
+		case a.upperBound > b.upperBound:
+			return 1
+		}
+		return 0
+	})
+	for i := 1; i < len(h.buckets); i++ {
+		if h.buckets[i].upperBound == h.buckets[i-1].upperBound {
+			return nil, nhcbSkipReasonInvalid
+		}
+	}
+	if !math.IsInf(h.buckets[len(h.buckets)-1].upperBound, 1) {
+		return nil, nhcbSkipReasonIncomplete
+	}
+
+	// All the series must have samples for the same timestamps.
+	timestamps := series[h.countIdx].Samples
+	sameTimestamps := func(idx int) bool {
+		return slices.EqualFunc(series[idx].Samples, timestamps, func(a, b mimirpb.Sample) bool {
+			return a.TimestampMs == b.TimestampMs
+		})
+	}
+	if !sameTimestamps(h.sumIdx) {
+		return nil, nhcbSkipReasonIncomplete
+	}
+	for _, b := range h.buckets {
+		if !sameTimestamps(b.idx) {
+			return nil, nhcbSkipReasonIncomplete
+		}
+	}
+
+	customValues := make([]float64, 0, len(h.buckets)-1)
+	for _, b := range h.buckets[:len(h.buckets)-1] {
+		customValues = append(customValues, b.upperBound)
+	}
+
+	// Integer histograms are used, unless some counts are not integers.
+	isFloat := false
+	for i := range timestamps {
+		for _, b := range h.buckets {
+			if v := series[b.idx].Samples[i].Value; !value.IsStaleNaN(v) && v != math.Trunc(v) {
+				isFloat = true
+			}
+		}
+	}
+
+	out := make([]mimirpb.Histogram, 0, len(timestamps))
+	counts := make([]float64, len(h.buckets))
+	for i, sample := range timestamps {
+		stale := 0
+		for j, b := range h.buckets {
+			counts[j] = series[b.idx].Samples[i].Value
+			if value.IsStaleNaN(counts[j]) {
+				stale++
+			}
+		}
+		sum := series[h.sumIdx].Samples[i].Value
+		if value.IsStaleNaN(sum) {
+			stale++
+		}
+		if value.IsStaleNaN(sample.Value) {
+			stale++
+		}
+
+		switch stale {
+		case 0:
+		case len(h.buckets) + 2:
+			// All the series are marked stale, so the histogram is marked stale too.
+			out = append(out, newStaleNHCB(sample.TimestampMs, customValues, isFloat))
+			continue
+		default:
+			return nil, nhcbSkipReasonInvalid
+		}
+
+		// Convert the cumulative bucket counts to the count of each bucket.
+		for j := len(counts) - 1; j > 0; j-- {
+			counts[j] -= counts[j-1]
+			if counts[j] < 0 {
+				return nil, nhcbSkipReasonInvalid
+			}
+		}
+		if counts[0] < 0 {
+			return nil, nhcbSkipReasonInvalid
+		}
+
+		out = append(out, newNHCB(sample.TimestampMs, sum, counts, customValues, isFloat))
+	}
+
+	return out, ""
+}
+
+func newNHCB(ts int64, sum float64, counts, customValues []float64, isFloat bool) mimirpb.Histogram {
+	var total float64
+	for _, c := range counts {
+		total += c
+	}
+
+	h := mimirpb.Histogram{
+		Sum:           sum,
+		Schema:        histogram.CustomBucketsSchema,
+		PositiveSpans: []mimirpb.BucketSpan{{Offset: 0, Length: uint32(len(counts))}},
+		Timestamp:     ts,
+		CustomValues:  customValues,
+	}
+
+	if isFloat {
+		h.Count = &mimirpb.Histogram_CountFloat{CountFloat: total}
+		h.ZeroCount = &mimirpb.Histogram_ZeroCountFloat{}
+		h.PositiveCounts = slices.Clone(counts)
+		return h
+	}
+
+	h.Count = &mimirpb.Histogram_CountInt{CountInt: uint64(total)}
+	h.ZeroCount = &mimirpb.Histogram_ZeroCountInt{}
+	h.PositiveDeltas = make([]int64, len(counts))
+	var prev int64
+	for i, c := range counts {
+		h.PositiveDeltas[i] = int64(c) - prev
+		prev = int64(c)
+	}
+	return h
+}
+
+func newStaleNHCB(ts int64, customValues []float64, isFloat bool) mimirpb.Histogram {
+	h := mimirpb.Histogram{
+		Sum:          math.Float64frombits(value.StaleNaN),
+		Schema:       histogram.CustomBucketsSchema,
+		Timestamp:    ts,
+		CustomValues: customValues,
+	}
+	if isFloat {
+		h.Count = &mimirpb.Histogram_CountFloat{}
+		h.ZeroCount = &mimirpb.Histogram_ZeroCountFloat{}
+	} else {
+		h.Count = &mimirpb.Histogram_CountInt{}
+		h.ZeroCount = &mimirpb.Histogram_ZeroCountInt{}
+	}
+	return h
+}
+
+func cloneExemplar(e mimirpb.Exemplar) mimirpb.Exemplar {
+	lbls := make([]mimirpb.LabelAdapter, 0, len(e.Labels))
+	for _, l := range e.Labels {
+		lbls = append(lbls, mimirpb.LabelAdapter{Name: strings.Clone(l.Name), Value: strings.Clone(l.Value)})
+	}
+	return mimirpb.Exemplar{Labels: lbls, Value: e.Value, TimestampMs: e.TimestampMs}
+}
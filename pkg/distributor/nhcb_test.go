@@ -0,0 +1,217 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"math"
+	"testing"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+func TestConvertClassicHistogramsToNHCB(t *testing.T) {
+	bucket := func(le string, v float64) mimirpb.PreallocTimeseries {
+		return makeTimeseries([]string{model.MetricNameLabel, "http_request_duration_seconds_bucket", "job", "api", "le", le}, makeSamples(10, v), nil)
+	}
+	sum := makeTimeseries([]string{model.MetricNameLabel, "http_request_duration_seconds_sum", "job", "api"}, makeSamples(10, 4.5), nil)
+	count := makeTimeseries([]string{model.MetricNameLabel, "http_request_duration_seconds_count", "job", "api"}, makeSamples(10, 6), nil)
+	other := makeTimeseries([]string{model.MetricNameLabel, "up", "job", "api"}, makeSamples(10, 1), nil)
+
+	histogramLabels := []string{model.MetricNameLabel, "http_request_duration_seconds", "job", "api"}
+
+	tests := map[string]struct {
+		series           []mimirpb.PreallocTimeseries
+		maxBuckets       int
+		expectedSeries   []mimirpb.PreallocTimeseries
+		expectedSkipped  []string
+		expectedConverts int
+	}{
+		"complete classic histogram with integer counts": {
+			series: []mimirpb.PreallocTimeseries{other, bucket("0.5", 1), bucket("1", 4), bucket("+Inf", 6), sum, count},
+			expectedSeries: []mimirpb.PreallocTimeseries{
+				other,
+				makeTimeseries(histogramLabels, nil, nil),
+			},
+			expectedConverts: 1,
+		},
+		"classic histogram with unsorted buckets": {
+			series: []mimirpb.PreallocTimeseries{count, bucket("+Inf", 6), bucket("1", 4), sum, bucket("0.5", 1)},
+			expectedSeries: []mimirpb.PreallocTimeseries{
+				makeTimeseries(histogramLabels, nil, nil),
+			},
+			expectedConverts: 1,
+		},
+		"classic histogram with as many buckets as the limit": {
+			series:     []mimirpb.PreallocTimeseries{bucket("0.5", 1), bucket("1", 4), bucket("+Inf", 6), sum, count},
+			maxBuckets: 3,
+			expectedSeries: []mimirpb.PreallocTimeseries{
+				makeTimeseries(histogramLabels, nil, nil),
+			},
+			expectedConverts: 1,
+		},
+		"classic histogram with more buckets than the limit": {
+			series:          []mimirpb.PreallocTimeseries{bucket("0.5", 1), bucket("1", 4), bucket("+Inf", 6), sum, count},
+			maxBuckets:      2,
+			expectedSeries:  []mimirpb.PreallocTimeseries{bucket("0.5", 1), bucket("1", 4), bucket("+Inf", 6), sum, count},
+			expectedSkipped: []string{nhcbSkipReasonTooManyBuckets},
+		},
+		"classic histogram without +Inf bucket": {
+			series:          []mimirpb.PreallocTimeseries{bucket("0.5", 1), bucket("1", 4), sum, count},
+			expectedSeries:  []mimirpb.PreallocTimeseries{bucket("0.5", 1), bucket("1", 4), sum, count},
+			expectedSkipped: []string{nhcbSkipReasonIncomplete},
+		},
+		"classic histogram without count": {
+			series:          []mimirpb.PreallocTimeseries{bucket("0.5", 1), bucket("+Inf", 6), sum},
+			expectedSeries:  []mimirpb.PreallocTimeseries{bucket("0.5", 1), bucket("+Inf", 6), sum},
+			expectedSkipped: []string{nhcbSkipReasonIncomplete},
+		},
+		"classic histogram with decreasing bucket counts": {
+			series:          []mimirpb.PreallocTimeseries{bucket("0.5", 5), bucket("+Inf", 4), sum, count},
+			expectedSeries:  []mimirpb.PreallocTimeseries{bucket("0.5", 5), bucket("+Inf", 4), sum, count},
+			expectedSkipped: []string{nhcbSkipReasonInvalid},
+		},
+		"classic histogram with an invalid le label": {
+			series:          []mimirpb.PreallocTimeseries{bucket("x", 1), bucket("+Inf", 6), sum, count},
+			expectedSeries:  []mimirpb.PreallocTimeseries{bucket("x", 1), bucket("+Inf", 6), sum, count},
+			expectedSkipped: []string{nhcbSkipReasonInvalid},
+		},
+		"classic histogram with misaligned timestamps": {
+			series: []mimirpb.PreallocTimeseries{
+				bucket("0.5", 1),
+				makeTimeseries([]string{model.MetricNameLabel, "http_request_duration_seconds_bucket", "job", "api", "le", "+Inf"}, makeSamples(20, 6), nil),
+				sum,
+				count,
+			},
+			expectedSeries: []mimirpb.PreallocTimeseries{
+				bucket("0.5", 1),
+				makeTimeseries([]string{model.MetricNameLabel, "http_request_duration_seconds_bucket", "job", "api", "le", "+Inf"}, makeSamples(20, 6), nil),
+				sum,
+				count,
+			},
+			expectedSkipped: []string{nhcbSkipReasonIncomplete},
+		},
+		"summary": {
+			series:         []mimirpb.PreallocTimeseries{sum, count},
+			expectedSeries: []mimirpb.PreallocTimeseries{sum, count},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := &mimirpb.WriteRequest{Timeseries: cloneClassicHistogramSeries(tc.series)}
+
+			var skipped []string
+			converted := convertClassicHistogramsToNHCB(req, tc.maxBuckets, func(reason string) {
+				skipped = append(skipped, reason)
+			})
+			assert.Equal(t, tc.expectedConverts, converted)
+			assert.Equal(t, tc.expectedSkipped, skipped)
+
+			require.Len(t, req.Timeseries, len(tc.expectedSeries))
+			for i, ts := range req.Timeseries {
+				assert.Equal(t, tc.expectedSeries[i].Labels, ts.Labels)
+				if len(ts.Histograms) == 0 {
+					assert.Equal(t, tc.expectedSeries[i].Samples, ts.Samples)
+					continue
+				}
+
+				assert.Empty(t, ts.Samples)
+				assert.Equal(t, makeHistograms(10, &histogram.Histogram{
+					Schema:          histogram.CustomBucketsSchema,
+					Count:           6,
+					Sum:             4.5,
+					PositiveSpans:   []histogram.Span{{Offset: 0, Length: 3}},
+					PositiveBuckets: []int64{1, 2, -1},
+					CustomValues:    []float64{0.5, 1},
+				}), ts.Histograms)
+			}
+		})
+	}
+}
+
+func TestConvertClassicHistogramsToNHCB_FloatCounts(t *testing.T) {
+	req := &mimirpb.WriteRequest{Timeseries: []mimirpb.PreallocTimeseries{
+		makeTimeseries([]string{model.MetricNameLabel, "latency_bucket", "le", "1"}, makeSamples(10, 1.5), nil),
+		makeTimeseries([]string{model.MetricNameLabel, "latency_bucket", "le", "+Inf"}, makeSamples(10, 2.5), nil),
+		makeTimeseries([]string{model.MetricNameLabel, "latency_sum"}, makeSamples(10, 3), nil),
+		makeTimeseries([]string{model.MetricNameLabel, "latency_count"}, makeSamples(10, 2.5), nil),
+	}}
+
+	require.Equal(t, 1, convertClassicHistogramsToNHCB(req, 0, func(string) { t.Fatal("unexpected skipped histogram") }))
+	require.Len(t, req.Timeseries, 1)
+	assert.Equal(t, makeFloatHistograms(10, &histogram.FloatHistogram{
+		Schema:          histogram.CustomBucketsSchema,
+		Count:           2.5,
+		Sum:             3,
+		PositiveSpans:   []histogram.Span{{Offset: 0, Length: 2}},
+		PositiveBuckets: []float64{1.5, 1},
+		CustomValues:    []float64{1},
+	}), req.Timeseries[0].Histograms)
+}
+
+func TestConvertClassicHistogramsToNHCB_StaleMarkers(t *testing.T) {
+	staleNaN := math.Float64frombits(value.StaleNaN)
+
+	makeRequest := func(bucketValue float64) *mimirpb.WriteRequest {
+		return &mimirpb.WriteRequest{Timeseries: []mimirpb.PreallocTimeseries{
+			makeTimeseries([]string{model.MetricNameLabel, "latency_bucket", "le", "1"}, []mimirpb.Sample{{TimestampMs: 10, Value: 1}, {TimestampMs: 20, Value: bucketValue}}, nil),
+			makeTimeseries([]string{model.MetricNameLabel, "latency_bucket", "le", "+Inf"}, []mimirpb.Sample{{TimestampMs: 10, Value: 2}, {TimestampMs: 20, Value: staleNaN}}, nil),
+			makeTimeseries([]string{model.MetricNameLabel, "latency_sum"}, []mimirpb.Sample{{TimestampMs: 10, Value: 3}, {TimestampMs: 20, Value: staleNaN}}, nil),
+			makeTimeseries([]string{model.MetricNameLabel, "latency_count"}, []mimirpb.Sample{{TimestampMs: 10, Value: 2}, {TimestampMs: 20, Value: staleNaN}}, nil),
+		}}
+	}
+
+	t.Run("all series marked stale", func(t *testing.T) {
+		req := makeRequest(staleNaN)
+		require.Equal(t, 2, convertClassicHistogramsToNHCB(req, 0, func(string) { t.Fatal("unexpected skipped histogram") }))
+		require.Len(t, req.Timeseries, 1)
+
+		histograms := req.Timeseries[0].Histograms
+		require.Len(t, histograms, 2)
+		assert.False(t, value.IsStaleNaN(histograms[0].Sum))
+		assert.True(t, value.IsStaleNaN(histograms[1].Sum))
+		assert.Equal(t, int64(20), histograms[1].Timestamp)
+		assert.Equal(t, []float64{1}, histograms[1].CustomValues)
+		assert.NoError(t, mimirpb.FromHistogramProtoToHistogram(&histograms[1]).Validate())
+	})
+
+	t.Run("some series marked stale", func(t *testing.T) {
+		req := makeRequest(1)
+		var skipped []string
+		require.Equal(t, 0, convertClassicHistogramsToNHCB(req, 0, func(reason string) { skipped = append(skipped, reason) }))
+		assert.Equal(t, []string{nhcbSkipReasonInvalid}, skipped)
+		assert.Len(t, req.Timeseries, 4)
+	})
+}
+
+func TestConvertClassicHistogramsToNHCB_Exemplars(t *testing.T) {
+	req := &mimirpb.WriteRequest{Timeseries: []mimirpb.PreallocTimeseries{
+		makeTimeseries([]string{model.MetricNameLabel, "latency_bucket", "le", "1"}, makeSamples(10, 1), makeExemplars([]string{"trace_id", "a"}, 10, 0.5)),
+		makeTimeseries([]string{model.MetricNameLabel, "latency_bucket", "le", "+Inf"}, makeSamples(10, 2), makeExemplars([]string{"trace_id", "b"}, 10, 5)),
+		makeTimeseries([]string{model.MetricNameLabel, "latency_sum"}, makeSamples(10, 5.5), nil),
+		makeTimeseries([]string{model.MetricNameLabel, "latency_count"}, makeSamples(10, 2), nil),
+	}}
+
+	require.Equal(t, 1, convertClassicHistogramsToNHCB(req, 0, func(string) { t.Fatal("unexpected skipped histogram") }))
+	require.Len(t, req.Timeseries, 1)
+	assert.Equal(t, []mimirpb.LabelAdapter{{Name: model.MetricNameLabel, Value: "latency"}}, req.Timeseries[0].Labels)
+	assert.Equal(t, append(makeExemplars([]string{"trace_id", "a"}, 10, 0.5), makeExemplars([]string{"trace_id", "b"}, 10, 5)...), req.Timeseries[0].Exemplars)
+}
+
+func cloneClassicHistogramSeries(series []mimirpb.PreallocTimeseries) []mimirpb.PreallocTimeseries {
+	out := make([]mimirpb.PreallocTimeseries, 0, len(series))
+	for _, ts := range series {
+		out = append(out, mimirpb.PreallocTimeseries{TimeSeries: &mimirpb.TimeSeries{
+			Labels:    append([]mimirpb.LabelAdapter(nil), ts.Labels...),
+			Samples:   append([]mimirpb.Sample(nil), ts.Samples...),
+			Exemplars: ts.Exemplars,
+		}})
+	}
+	return out
+}
@@ -246,7 +246,7 @@ func (d *Distributor) queryIngesterStream(ctx context.Context, replicationSets [
 
 		stream, err = client.(ingester_client.IngesterClient).QueryStream(ctx, req)
 		if err != nil {
-			return ingesterQueryResult{}, err
+			return ingesterQueryResult{}, wrapIngesterQueryError(err)
 		}
 
 		result := ingesterQueryResult{}
@@ -263,7 +263,7 @@ func (d *Distributor) queryIngesterStream(ctx context.Context, replicationSets [
 				// We will never get an EOF here from an ingester that is streaming chunks, so we don't need to do anything to set up streaming here.
 				return result, nil
 			} else if err != nil {
-				return ingesterQueryResult{}, err
+				return ingesterQueryResult{}, wrapIngesterQueryError(err)
 			}
 
 			if len(resp.Timeseries) > 0 {
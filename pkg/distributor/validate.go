@@ -15,6 +15,7 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/histogram"
 
 	"github.com/grafana/mimir/pkg/mimirpb"
 	"github.com/grafana/mimir/pkg/util/extract"
@@ -118,6 +119,7 @@ type sampleValidationConfig interface {
 	PastGracePeriod(userID string) time.Duration
 	MaxNativeHistogramBuckets(userID string) int
 	ReduceNativeHistogramOverMaxBuckets(userID string) bool
+	ConvertClassicHistogramsToNHCB(userID string) bool
 	OutOfOrderTimeWindow(userID string) time.Duration
 }
 
@@ -253,7 +255,13 @@ func validateSampleHistogram(m *sampleValidationMetrics, now model.Time, cfg sam
 		return false, fmt.Errorf(sampleTimestampTooOldMsgFormat, s.Timestamp, unsafeMetricName)
 	}
 
-	if s.Schema < mimirpb.MinimumHistogramSchema || s.Schema > mimirpb.MaximumHistogramSchema {
+	// Native histograms with custom buckets are accepted only from the tenants which opted in.
+	if histogram.IsCustomBucketsSchema(s.Schema) {
+		if !cfg.ConvertClassicHistogramsToNHCB(userID) {
+			m.invalidNativeHistogramSchema.WithLabelValues(userID, group).Inc()
+			return false, fmt.Errorf(invalidSchemaNativeHistogramMsgFormat, s.Schema)
+		}
+	} else if s.Schema < mimirpb.MinimumHistogramSchema || s.Schema > mimirpb.MaximumHistogramSchema {
 		m.invalidNativeHistogramSchema.WithLabelValues(userID, group).Inc()
 		return false, fmt.Errorf(invalidSchemaNativeHistogramMsgFormat, s.Schema)
 	}
@@ -429,6 +429,7 @@ func TestValidateLabelDuplication(t *testing.T) {
 type sampleValidationCfg struct {
 	maxNativeHistogramBuckets           int
 	reduceNativeHistogramOverMaxBuckets bool
+	convertClassicHistogramsToNHCB      bool
 }
 
 func (c sampleValidationCfg) CreationGracePeriod(_ string) time.Duration {
@@ -451,6 +452,10 @@ func (c sampleValidationCfg) ReduceNativeHistogramOverMaxBuckets(_ string) bool
 	return c.reduceNativeHistogramOverMaxBuckets
 }
 
+func (c sampleValidationCfg) ConvertClassicHistogramsToNHCB(_ string) bool {
+	return c.convertClassicHistogramsToNHCB
+}
+
 func TestMaxNativeHistorgramBuckets(t *testing.T) {
 	// All will have 2 buckets, one negative and one positive
 	testCases := map[string]mimirpb.Histogram{
@@ -593,6 +598,7 @@ func TestMaxNativeHistorgramBuckets(t *testing.T) {
 func TestInvalidNativeHistogramSchema(t *testing.T) {
 	testCases := map[string]struct {
 		schema        int32
+		nhcbEnabled   bool
 		expectedError error
 	}{
 		"a valid schema causes no error": {
@@ -607,15 +613,24 @@ func TestInvalidNativeHistogramSchema(t *testing.T) {
 			schema:        10,
 			expectedError: fmt.Errorf("received a native histogram sample with an invalid schema: 10 (err-mimir-invalid-native-histogram-schema)"),
 		},
+		"the custom buckets schema causes no error when enabled for the tenant": {
+			schema:        -53,
+			nhcbEnabled:   true,
+			expectedError: nil,
+		},
+		"the custom buckets schema causes an error when not enabled for the tenant": {
+			schema:        -53,
+			expectedError: fmt.Errorf("received a native histogram sample with an invalid schema: -53 (err-mimir-invalid-native-histogram-schema)"),
+		},
 	}
 
 	registry := prometheus.NewRegistry()
 	metrics := newSampleValidationMetrics(registry)
-	cfg := sampleValidationCfg{}
 	hist := &mimirpb.Histogram{}
 	labels := []mimirpb.LabelAdapter{{Name: model.MetricNameLabel, Value: "a"}, {Name: "a", Value: "a"}}
 	for testName, testCase := range testCases {
 		t.Run(testName, func(t *testing.T) {
+			cfg := sampleValidationCfg{convertClassicHistogramsToNHCB: testCase.nhcbEnabled}
 			hist.Schema = testCase.schema
 			_, err := validateSampleHistogram(metrics, model.Now(), cfg, "user-1", "group-1", labels, hist)
 			require.Equal(t, testCase.expectedError, err)
@@ -625,7 +640,7 @@ func TestInvalidNativeHistogramSchema(t *testing.T) {
 	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(`
 			# HELP cortex_discarded_samples_total The total number of samples that were discarded.
 			# TYPE cortex_discarded_samples_total counter
-			cortex_discarded_samples_total{group="group-1",reason="invalid_native_histogram_schema",user="user-1"} 2
+			cortex_discarded_samples_total{group="group-1",reason="invalid_native_histogram_schema",user="user-1"} 3
 	`), "cortex_discarded_samples_total"))
 }
 
@@ -68,8 +68,8 @@ type Limits interface {
 	// This method is copied from compactor.ConfigProvider.
 	CompactorSplitAndMergeShards(userID string) int
 
-	// CompactorBlocksRetentionPeriod returns the retention period for a given user.
-	CompactorBlocksRetentionPeriod(userID string) time.Duration
+	// CompactorMaxBlocksRetentionPeriod returns the longest retention period of the blocks of any resolution for a given user.
+	CompactorMaxBlocksRetentionPeriod(userID string) time.Duration
 
 	// OutOfOrderTimeWindow returns the out-of-order time window for the user.
 	OutOfOrderTimeWindow(userID string) time.Duration
@@ -139,7 +139,7 @@ func (l limitsMiddleware) Do(ctx context.Context, r MetricsQueryRequest) (Respon
 	}
 
 	// Clamp the time range based on the max query lookback and block retention period.
-	blocksRetentionPeriod := validation.SmallestPositiveNonZeroDurationPerTenant(tenantIDs, l.CompactorBlocksRetentionPeriod)
+	blocksRetentionPeriod := validation.SmallestPositiveNonZeroDurationPerTenant(tenantIDs, l.CompactorMaxBlocksRetentionPeriod)
 	maxQueryLookback := validation.SmallestPositiveNonZeroDurationPerTenant(tenantIDs, l.MaxQueryLookback)
 	maxLookback := smallestPositiveNonZeroDuration(blocksRetentionPeriod, maxQueryLookback)
 	if maxLookback > 0 {
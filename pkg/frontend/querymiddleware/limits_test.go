@@ -537,7 +537,7 @@ func (m multiTenantMockLimits) CompactorSplitAndMergeShards(userID string) int {
 	return m.byTenant[userID].compactorShards
 }
 
-func (m multiTenantMockLimits) CompactorBlocksRetentionPeriod(userID string) time.Duration {
+func (m multiTenantMockLimits) CompactorMaxBlocksRetentionPeriod(userID string) time.Duration {
 	return m.byTenant[userID].compactorBlocksRetentionPeriod
 }
 
@@ -672,7 +672,7 @@ func (m mockLimits) CompactorSplitAndMergeShards(string) int {
 	return m.compactorShards
 }
 
-func (m mockLimits) CompactorBlocksRetentionPeriod(string) time.Duration {
+func (m mockLimits) CompactorMaxBlocksRetentionPeriod(string) time.Duration {
 	return m.compactorBlocksRetentionPeriod
 }
 
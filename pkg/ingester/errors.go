@@ -44,10 +44,26 @@ func newErrorWithStatus(originalErr error, code codes.Code) globalerror.ErrorWit
 	)
 	if errors.As(originalErr, &ingesterErr) {
 		errorDetails = &mimirpb.ErrorDetails{Cause: ingesterErr.errorCause()}
+
+		var rejectedErr rejectedSamplesError
+		if errors.As(originalErr, &rejectedErr) {
+			errorDetails.RejectedSamples = rejectedErr.rejectedSamples
+		}
 	}
 	return globalerror.WrapErrorWithGRPCStatus(originalErr, code, errorDetails)
 }
 
+// rejectedSamplesError wraps the error returned for a push request having some samples rejected because too old
+// or out of order. It carries all those samples, so that they can be reported to the distributor in the error details.
+type rejectedSamplesError struct {
+	error
+	rejectedSamples []mimirpb.RejectedSeriesSamples
+}
+
+func (e rejectedSamplesError) Unwrap() error {
+	return e.error
+}
+
 // ingesterError is a marker interface for the errors returned by ingester, and that are safe to wrap.
 type ingesterError interface {
 	errorCause() mimirpb.ErrorCause
@@ -254,6 +270,37 @@ var _ ingesterError = perUserSeriesLimitReachedError{}
 // Ensure that perUserSeriesLimitReachedError is an softError.
 var _ softError = perUserSeriesLimitReachedError{}
 
+// perUserMemoryLimitReachedError is an ingesterError indicating that a per-user memory limit has been reached.
+type perUserMemoryLimitReachedError struct {
+	limit int64
+}
+
+// newPerUserMemoryLimitReachedError creates a new perUserMemoryLimitReachedError indicating that a per-user memory limit has been reached.
+func newPerUserMemoryLimitReachedError(limit int64) perUserMemoryLimitReachedError {
+	return perUserMemoryLimitReachedError{
+		limit: limit,
+	}
+}
+
+func (e perUserMemoryLimitReachedError) Error() string {
+	return globalerror.MaxMemoryPerUser.MessageWithPerTenantLimitConfig(
+		fmt.Sprintf("per-user memory limit of %d bytes exceeded", e.limit),
+		validation.MaxIngesterMemoryBytesPerTenantFlag,
+	)
+}
+
+func (e perUserMemoryLimitReachedError) errorCause() mimirpb.ErrorCause {
+	return mimirpb.TENANT_LIMIT
+}
+
+func (e perUserMemoryLimitReachedError) soft() {}
+
+// Ensure that perUserMemoryLimitReachedError is an ingesterError.
+var _ ingesterError = perUserMemoryLimitReachedError{}
+
+// Ensure that perUserMemoryLimitReachedError is an softError.
+var _ softError = perUserMemoryLimitReachedError{}
+
 // perUserMetadataLimitReachedError is an ingesterError indicating that a per-user metadata limit has been reached.
 type perUserMetadataLimitReachedError struct {
 	limit int
@@ -321,6 +368,47 @@ var _ ingesterError = perMetricSeriesLimitReachedError{}
 // Ensure that perMetricSeriesLimitReachedError is an softError.
 var _ softError = perMetricSeriesLimitReachedError{}
 
+// perLabelValueSeriesLimitReachedError is an ingesterError indicating that a per-label-value series limit has been reached.
+type perLabelValueSeriesLimitReachedError struct {
+	limit             *validation.LabelValueSeriesLimit
+	maxValuesExceeded bool
+	series            string
+}
+
+// newPerLabelValueSeriesLimitReachedError creates a new perLabelValueSeriesLimitReachedError indicating that a per-label-value series limit has been reached.
+func newPerLabelValueSeriesLimitReachedError(limit *validation.LabelValueSeriesLimit, maxValuesExceeded bool, labels []mimirpb.LabelAdapter) perLabelValueSeriesLimitReachedError {
+	return perLabelValueSeriesLimitReachedError{
+		limit:             limit,
+		maxValuesExceeded: maxValuesExceeded,
+		series:            mimirpb.FromLabelAdaptersToString(labels),
+	}
+}
+
+func (e perLabelValueSeriesLimitReachedError) Error() string {
+	var msg string
+	if e.maxValuesExceeded {
+		msg = fmt.Sprintf("per-label-value series limit %q of %d distinct values of label %s exceeded", e.limit.Name, e.limit.MaxValues, e.limit.LabelName)
+	} else {
+		msg = fmt.Sprintf("per-label-value series limit %q of %d series for each value of label %s exceeded", e.limit.Name, e.limit.MaxSeriesPerValue, e.limit.LabelName)
+	}
+	return fmt.Sprintf("%s. To adjust the related per-tenant limit, configure label_value_series_limits, or contact your service administrator. This is for series %s",
+		globalerror.MaxSeriesPerLabelValue.Message(msg),
+		e.series,
+	)
+}
+
+func (e perLabelValueSeriesLimitReachedError) errorCause() mimirpb.ErrorCause {
+	return mimirpb.TENANT_LIMIT
+}
+
+func (e perLabelValueSeriesLimitReachedError) soft() {}
+
+// Ensure that perLabelValueSeriesLimitReachedError is an ingesterError.
+var _ ingesterError = perLabelValueSeriesLimitReachedError{}
+
+// Ensure that perLabelValueSeriesLimitReachedError is an softError.
+var _ softError = perLabelValueSeriesLimitReachedError{}
+
 // perMetricMetadataLimitReachedError is an ingesterError indicating that a per-metric metadata limit has been reached.
 type perMetricMetadataLimitReachedError struct {
 	limit  int
@@ -466,6 +554,74 @@ func (e ingesterTooBusyError) errorCause() mimirpb.ErrorCause {
 // Ensure that ingesterTooBusyError is an ingesterError.
 var _ ingesterError = ingesterTooBusyError{}
 
+// perUserQueryLimitReachedError is an ingesterError indicating that a query of a user was not admitted
+// because a per-user read path admission limit has been reached.
+type perUserQueryLimitReachedError struct {
+	id    globalerror.ID
+	msg   string
+	limit int
+	flag  string
+}
+
+// newPerUserConcurrentQueriesLimitReachedError creates a new perUserQueryLimitReachedError indicating that the
+// per-user concurrent queries limit has been reached.
+func newPerUserConcurrentQueriesLimitReachedError(limit int) perUserQueryLimitReachedError {
+	return perUserQueryLimitReachedError{
+		id:    globalerror.MaxIngesterConcurrentQueries,
+		msg:   "per-user concurrent queries limit of %d reached, try again later",
+		limit: limit,
+		flag:  validation.MaxIngesterConcurrentQueriesFlag,
+	}
+}
+
+// newPerUserInflightQuerySeriesLimitReachedError creates a new perUserQueryLimitReachedError indicating that the
+// per-user in-flight query series limit has been reached.
+func newPerUserInflightQuerySeriesLimitReachedError(limit int) perUserQueryLimitReachedError {
+	return perUserQueryLimitReachedError{
+		id:    globalerror.MaxIngesterInflightQuerySeries,
+		msg:   "per-user in-flight query series limit of %d reached, try again later",
+		limit: limit,
+		flag:  validation.MaxIngesterInflightQuerySeriesFlag,
+	}
+}
+
+func (e perUserQueryLimitReachedError) Error() string {
+	return e.id.MessageWithPerTenantLimitConfig(fmt.Sprintf(e.msg, e.limit), e.flag)
+}
+
+// errorCause is TOO_BUSY, so that the querier retries the query.
+func (e perUserQueryLimitReachedError) errorCause() mimirpb.ErrorCause {
+	return mimirpb.TOO_BUSY
+}
+
+// Ensure that perUserQueryLimitReachedError is an ingesterError.
+var _ ingesterError = perUserQueryLimitReachedError{}
+
+// perUserQuerySeriesLimitExceededError is an ingesterError indicating that a streaming query alone exceeds
+// the per-user in-flight query series limit, so it can never be admitted.
+type perUserQuerySeriesLimitExceededError struct {
+	limit int
+}
+
+func newPerUserQuerySeriesLimitExceededError(limit int) perUserQuerySeriesLimitExceededError {
+	return perUserQuerySeriesLimitExceededError{limit: limit}
+}
+
+func (e perUserQuerySeriesLimitExceededError) Error() string {
+	return globalerror.MaxIngesterInflightQuerySeries.MessageWithPerTenantLimitConfig(
+		fmt.Sprintf("the query exceeds the per-user in-flight query series limit of %d", e.limit),
+		validation.MaxIngesterInflightQuerySeriesFlag,
+	)
+}
+
+// errorCause is TENANT_LIMIT, so that the query is not retried.
+func (e perUserQuerySeriesLimitExceededError) errorCause() mimirpb.ErrorCause {
+	return mimirpb.TENANT_LIMIT
+}
+
+// Ensure that perUserQuerySeriesLimitExceededError is an ingesterError.
+var _ ingesterError = perUserQuerySeriesLimitExceededError{}
+
 type ingesterPushGrpcDisabledError struct{}
 
 func (e ingesterPushGrpcDisabledError) Error() string {
@@ -499,16 +655,18 @@ func (e circuitBreakerOpenError) errorCause() mimirpb.ErrorCause {
 var _ ingesterError = circuitBreakerOpenError{}
 
 type ingesterErrSamplers struct {
-	sampleTimestampTooOld             *log.Sampler
-	sampleTimestampTooOldOOOEnabled   *log.Sampler
-	sampleTimestampTooFarInFuture     *log.Sampler
-	sampleOutOfOrder                  *log.Sampler
-	sampleDuplicateTimestamp          *log.Sampler
-	maxSeriesPerMetricLimitExceeded   *log.Sampler
-	maxMetadataPerMetricLimitExceeded *log.Sampler
-	maxSeriesPerUserLimitExceeded     *log.Sampler
-	maxMetadataPerUserLimitExceeded   *log.Sampler
-	nativeHistogramValidationError    *log.Sampler
+	sampleTimestampTooOld               *log.Sampler
+	sampleTimestampTooOldOOOEnabled     *log.Sampler
+	sampleTimestampTooFarInFuture       *log.Sampler
+	sampleOutOfOrder                    *log.Sampler
+	sampleDuplicateTimestamp            *log.Sampler
+	maxSeriesPerMetricLimitExceeded     *log.Sampler
+	maxSeriesPerLabelValueLimitExceeded *log.Sampler
+	maxMetadataPerMetricLimitExceeded   *log.Sampler
+	maxSeriesPerUserLimitExceeded       *log.Sampler
+	maxMemoryPerUserLimitExceeded       *log.Sampler
+	maxMetadataPerUserLimitExceeded     *log.Sampler
+	nativeHistogramValidationError      *log.Sampler
 }
 
 func newIngesterErrSamplers(freq int64) ingesterErrSamplers {
@@ -523,6 +681,8 @@ func newIngesterErrSamplers(freq int64) ingesterErrSamplers {
 		log.NewSampler(freq),
 		log.NewSampler(freq),
 		log.NewSampler(freq),
+		log.NewSampler(freq),
+		log.NewSampler(freq),
 	}
 }
 
@@ -565,6 +725,8 @@ func mapReadErrorToErrorWithStatus(err error) error {
 		switch ingesterErr.errorCause() {
 		case mimirpb.TOO_BUSY:
 			errCode = codes.ResourceExhausted
+		case mimirpb.TENANT_LIMIT:
+			errCode = codes.FailedPrecondition
 		case mimirpb.SERVICE_UNAVAILABLE:
 			errCode = codes.Unavailable
 		case mimirpb.METHOD_NOT_ALLOWED:
@@ -286,6 +286,18 @@ func TestNewErrorWithStatus(t *testing.T) {
 			expectedErrorMessage: errMsg,
 			expectedErrorDetails: &mimirpb.ErrorDetails{Cause: ingesterErr.errorCause()},
 		},
+		"new ErrorWithStatus backed by an ingesterError with rejected samples contains them in the ErrorDetails": {
+			originErr: fmt.Errorf("user=test: %w", rejectedSamplesError{
+				error:           ingesterErr,
+				rejectedSamples: []mimirpb.RejectedSeriesSamples{{SeriesIndex: 1, TimestampsMs: []int64{10, 20}}},
+			}),
+			statusCode:           codes.Unimplemented,
+			expectedErrorMessage: "user=test: " + errMsg,
+			expectedErrorDetails: &mimirpb.ErrorDetails{
+				Cause:           ingesterErr.errorCause(),
+				RejectedSamples: []mimirpb.RejectedSeriesSamples{{SeriesIndex: 1, TimestampsMs: []int64{10, 20}}},
+			},
+		},
 		"new ErrorWithStatus backed by a non-ingesterError doesn't contain ErrorDetails": {
 			originErr:            nonIngesterErr,
 			statusCode:           codes.Unimplemented,
@@ -593,6 +605,12 @@ func TestMapReadErrorToErrorWithStatus(t *testing.T) {
 			expectedMessage: newCircuitBreakerOpenError("foo", 1*time.Second).Error(),
 			expectedDetails: &mimirpb.ErrorDetails{Cause: mimirpb.CIRCUIT_BREAKER_OPEN},
 		},
+		"a perUserQuerySeriesLimitExceededError gets translated into an ErrorWithStatus FailedPrecondition error with details": {
+			err:             newPerUserQuerySeriesLimitExceededError(10),
+			expectedCode:    codes.FailedPrecondition,
+			expectedMessage: newPerUserQuerySeriesLimitExceededError(10).Error(),
+			expectedDetails: &mimirpb.ErrorDetails{Cause: mimirpb.TENANT_LIMIT},
+		},
 		"a wrapped circuitBreakerOpenError gets translated into an ErrorWithStatus Unavailable error with details": {
 			err:             fmt.Errorf("wrapped: %w", newCircuitBreakerOpenError("foo", 1*time.Second)),
 			expectedCode:    codes.Unavailable,
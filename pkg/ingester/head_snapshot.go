@@ -0,0 +1,737 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"bytes"
+	"context"
+	crypto_rand "crypto/rand"
+	"encoding/json"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/concurrency"
+	"github.com/grafana/dskit/runutil"
+	"github.com/grafana/dskit/services"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/prometheus/prometheus/tsdb/index"
+	"github.com/prometheus/prometheus/tsdb/record"
+	"github.com/prometheus/prometheus/tsdb/wlog"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
+	util_log "github.com/grafana/mimir/pkg/util/log"
+)
+
+const (
+	// headSnapshotsPrefix is the prefix of the head snapshots in the tenant's bucket. Each ingester uploads
+	// its snapshots in a directory named after the instance ID. The same prefix is used under the Mimir
+	// internals prefix to keep track of the tenants each ingester has uploaded a snapshot for, so that the
+	// bootstrap doesn't need to scan all the tenants in the bucket.
+	headSnapshotsPrefix      = mimir_tsdb.HeadSnapshotsPrefix
+	headSnapshotMetaFilename = "meta.json"
+	headSnapshotMetaVersion1 = 1
+
+	headSnapshotBootstrapConcurrency = 4
+
+	// Directories within the TSDB directory, as defined by Prometheus TSDB.
+	headChunksDirName = "chunks_head"
+	walDirName        = "wal"
+
+	// headSnapshotStagingDirName is the directory within the TSDB directory where the files of a snapshot
+	// are hard linked, and the head chunks written, while uploading it.
+	headSnapshotStagingDirName = "head_snapshot.tmp"
+)
+
+// Head snapshot bootstrap states.
+const (
+	headSnapshotBootstrapDownloading = "downloading"
+	headSnapshotBootstrapReplaying   = "replaying"
+	headSnapshotBootstrapCompleted   = "completed"
+	headSnapshotBootstrapFailed      = "failed"
+)
+
+// headSnapshotMeta describes the last head snapshot uploaded by an ingester for a tenant. It's uploaded
+// after all the snapshot files, so the snapshot it references is always complete.
+type headSnapshotMeta struct {
+	Version int `json:"version"`
+
+	// ID of the snapshot. The snapshot files are stored in a directory named after it.
+	ID string `json:"id"`
+
+	// CreatedAt is the time the snapshot has been taken.
+	CreatedAt time.Time `json:"created_at"`
+
+	Files []headSnapshotFile `json:"files"`
+}
+
+type headSnapshotFile struct {
+	// Path of the file, relative to the TSDB directory.
+	Path string `json:"path"`
+
+	// Object storing the file, relative to the instance prefix. The WAL segments and checkpoint uploaded by a
+	// previous snapshot are referenced by the next ones instead of being uploaded again.
+	Object    string `json:"object"`
+	SizeBytes int64  `json:"size_bytes"`
+}
+
+func (m *headSnapshotMeta) totalBytes() int64 {
+	total := int64(0)
+	for _, f := range m.Files {
+		total += f.SizeBytes
+	}
+	return total
+}
+
+// headSnapshotStatus holds the progress of the head snapshot uploads and bootstrap of a tenant.
+type headSnapshotStatus struct {
+	lastSnapshotName string
+	lastFiles        []headSnapshotFile
+
+	LastUploadID       string
+	LastUploadTime     time.Time
+	LastUploadBytes    int64
+	LastUploadNewBytes int64
+	LastUploadErr      string
+
+	BootstrapState           string
+	BootstrapSnapshotID      string
+	BootstrapSnapshotTime    time.Time
+	BootstrapDownloadedBytes int64
+	BootstrapTotalBytes      int64
+	BootstrapErr             string
+}
+
+// headSnapshotter periodically uploads a snapshot of each tenant's TSDB head to the object storage: the chunk
+// snapshot written in the same format used by the memory snapshot on shutdown, the m-mapped head chunks, the last
+// WAL checkpoint and the WAL segments up to the snapshot. When the ingester starts with an empty disk, the TSDB of
+// each tenant is bootstrapped from the last snapshot uploaded by the same instance, so that only the data ingested
+// after the snapshot has been taken is missing. The WAL allows the TSDB to be recovered even if the chunk
+// snapshot can't be loaded. Only the files which have changed since the previous snapshot are uploaded.
+type headSnapshotter struct {
+	services.Service
+
+	bkt          objstore.Bucket
+	cfgProvider  bucket.TenantConfigProvider
+	instanceID   string
+	tsdbDir      string
+	getTSDBUsers func() []string
+	getTSDB      func(string) *userTSDB
+	logger       log.Logger
+
+	uploads        prometheus.Counter
+	uploadFailures prometheus.Counter
+
+	// uploadMtx is held while uploading a snapshot and while deleting the snapshots of a tenant, so that
+	// a snapshot uploaded while the tenant's TSDB is closed is not left in the bucket.
+	uploadMtx sync.Mutex
+
+	statusMtx sync.Mutex
+	status    map[string]*headSnapshotStatus
+}
+
+func newHeadSnapshotter(bkt objstore.Bucket, cfgProvider bucket.TenantConfigProvider, instanceID, tsdbDir string, interval time.Duration, getTSDBUsers func() []string, getTSDB func(string) *userTSDB, logger log.Logger, reg prometheus.Registerer) *headSnapshotter {
+	s := &headSnapshotter{
+		bkt:          bkt,
+		cfgProvider:  cfgProvider,
+		instanceID:   instanceID,
+		tsdbDir:      tsdbDir,
+		getTSDBUsers: getTSDBUsers,
+		getTSDB:      getTSDB,
+		logger:       logger,
+		status:       map[string]*headSnapshotStatus{},
+
+		uploads: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_ingester_head_snapshot_uploads_total",
+			Help: "Total number of TSDB head snapshots uploaded to the object storage.",
+		}),
+		uploadFailures: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_ingester_head_snapshot_upload_failures_total",
+			Help: "Total number of TSDB head snapshots failed to be uploaded to the object storage.",
+		}),
+	}
+
+	s.Service = services.NewTimerService(interval, nil, s.iteration, nil)
+	return s
+}
+
+func (s *headSnapshotter) iteration(ctx context.Context) error {
+	for _, userID := range s.getTSDBUsers() {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		if err := s.uploadTenant(ctx, userID); err != nil {
+			s.uploadFailures.Inc()
+			level.Warn(s.logger).Log("msg", "failed to upload TSDB head snapshot", "user", userID, "err", err)
+			s.updateStatus(userID, func(st *headSnapshotStatus) { st.LastUploadErr = err.Error() })
+		}
+	}
+	return nil
+}
+
+// uploadTenant takes a snapshot of the tenant's TSDB head and uploads it, unless nothing has been written
+// to the head since the last uploaded snapshot.
+func (s *headSnapshotter) uploadTenant(ctx context.Context, userID string) error {
+	db := s.getTSDB(userID)
+	if db == nil {
+		return nil
+	}
+
+	s.uploadMtx.Lock()
+	defer s.uploadMtx.Unlock()
+
+	prev, _ := s.getStatus(userID)
+	stagingDir := filepath.Join(db.db.Dir(), headSnapshotStagingDirName)
+	defer func() {
+		if err := os.RemoveAll(stagingDir); err != nil {
+			level.Warn(s.logger).Log("msg", "failed to remove head snapshot staging directory", "user", userID, "err", err)
+		}
+	}()
+
+	snapshotName, files, err := s.stageSnapshot(ctx, db, stagingDir, prev)
+	if err != nil || files == nil {
+		return err
+	}
+
+	createdAt := time.Now()
+	meta := &headSnapshotMeta{
+		Version:   headSnapshotMetaVersion1,
+		ID:        ulid.MustNew(ulid.Timestamp(createdAt), crypto_rand.Reader).String(),
+		CreatedAt: createdAt,
+	}
+
+	userBkt := bucket.NewUserBucketClient(userID, s.bkt, s.cfgProvider)
+	instancePrefix := path.Join(headSnapshotsPrefix, s.instanceID)
+
+	newBytes := int64(0)
+	for _, f := range files {
+		if f.Object != "" {
+			// Already uploaded by a previous snapshot.
+			meta.Files = append(meta.Files, f.headSnapshotFile)
+			continue
+		}
+
+		object := path.Join(meta.ID, f.Path)
+		size, err := uploadHeadSnapshotFile(ctx, userBkt, f.src, path.Join(instancePrefix, object), f.SizeBytes)
+		if err != nil {
+			return err
+		}
+		newBytes += size
+		meta.Files = append(meta.Files, headSnapshotFile{Path: f.Path, Object: object, SizeBytes: size})
+	}
+
+	if err := writeHeadSnapshotMeta(ctx, userBkt, instancePrefix, meta); err != nil {
+		return err
+	}
+	if err := s.bkt.Upload(ctx, s.tenantMarkerPath(userID), strings.NewReader(meta.ID)); err != nil {
+		return errors.Wrap(err, "upload head snapshot tenant marker")
+	}
+
+	// Delete the objects of the previous snapshots which are no longer referenced.
+	referenced := map[string]struct{}{path.Join(instancePrefix, headSnapshotMetaFilename): {}}
+	for _, f := range meta.Files {
+		referenced[path.Join(instancePrefix, f.Object)] = struct{}{}
+	}
+	err = userBkt.Iter(ctx, instancePrefix+objstore.DirDelim, func(name string) error {
+		if _, ok := referenced[name]; ok {
+			return nil
+		}
+		if err := userBkt.Delete(ctx, name); err != nil && !userBkt.IsObjNotFoundErr(err) {
+			return err
+		}
+		return nil
+	}, objstore.WithRecursiveIter())
+	if err != nil {
+		level.Warn(s.logger).Log("msg", "failed to delete previous TSDB head snapshots", "user", userID, "err", err)
+	}
+
+	s.uploads.Inc()
+	s.updateStatus(userID, func(st *headSnapshotStatus) {
+		st.lastSnapshotName = snapshotName
+		st.lastFiles = meta.Files
+		st.LastUploadID = meta.ID
+		st.LastUploadTime = createdAt
+		st.LastUploadBytes = meta.totalBytes()
+		st.LastUploadNewBytes = newBytes
+		st.LastUploadErr = ""
+	})
+
+	level.Debug(s.logger).Log("msg", "uploaded TSDB head snapshot", "user", userID, "id", meta.ID, "files", len(meta.Files), "bytes", meta.totalBytes(), "new_bytes", newBytes)
+	return nil
+}
+
+// stageSnapshot takes a chunk snapshot of the tenant's TSDB head, writes the head chunks and hard links the
+// other files of the snapshot to stagingDir, so that they can be uploaded while the TSDB keeps truncating the
+// WAL and the blocks are shipped. The files which have been uploaded by the previous snapshot and haven't
+// changed since then reference the uploaded objects, and they're not staged. It returns no files if nothing
+// has been written to the head since the previous snapshot.
+func (s *headSnapshotter) stageSnapshot(ctx context.Context, db *userTSDB, stagingDir string, prev headSnapshotStatus) (string, []localHeadSnapshotFile, error) {
+	// Make sure the TSDB state is active, in order to avoid any race condition with closing idle TSDBs.
+	if ok, _ := db.changeState(active, activeShipping); !ok {
+		return "", nil, nil
+	}
+	defer db.changeState(activeShipping, active)
+
+	// The chunk snapshot records the WAL offset the head is replayed from when it's loaded, so appends
+	// are blocked while it's taken, otherwise the samples appended meanwhile may be replayed twice.
+	head := db.db.Head()
+	if err := db.withAppendsBlocked(func() error {
+		_, err := head.ChunkSnapshot()
+		return err
+	}); err != nil {
+		return "", nil, errors.Wrap(err, "take chunk snapshot")
+	}
+
+	dir := db.db.Dir()
+	snapshotDir, walIdx, walOffset, err := tsdb.LastChunkSnapshot(dir)
+	if errors.Is(err, record.ErrNotFound) {
+		return "", nil, nil
+	}
+	if err != nil {
+		return "", nil, errors.Wrap(err, "find last chunk snapshot")
+	}
+	snapshotName := filepath.Base(snapshotDir)
+
+	if prev.lastSnapshotName == snapshotName {
+		// Nothing has been written to the head since the last uploaded snapshot.
+		return "", nil, nil
+	}
+
+	// The chunk snapshot only includes the last chunk of each series, while the previous chunks may
+	// still be buffered in memory before being written to the m-mapped head chunks files. For this
+	// reason we write them again to the staging directory, once the snapshot has been taken.
+	if err := os.RemoveAll(stagingDir); err != nil {
+		return "", nil, errors.Wrap(err, "remove head snapshot staging directory")
+	}
+	chunksDir := filepath.Join(stagingDir, headChunksDirName)
+	if err := writeHeadChunks(ctx, head, chunksDir); err != nil {
+		return "", nil, err
+	}
+
+	files, err := listHeadSnapshotFiles(dir, snapshotName, walIdx, int64(walOffset), chunksDir)
+	if err != nil {
+		return "", nil, err
+	}
+
+	uploaded := make(map[string]headSnapshotFile, len(prev.lastFiles))
+	for _, f := range prev.lastFiles {
+		uploaded[f.Path] = f
+	}
+	for i, f := range files {
+		if strings.HasPrefix(f.Path, headChunksDirName+"/") {
+			// Already written to the staging directory.
+			continue
+		}
+		if u, ok := uploaded[f.Path]; ok && f.immutable && u.SizeBytes == f.SizeBytes {
+			files[i].headSnapshotFile = u
+			continue
+		}
+
+		// The last WAL segment is still being written, but only the bytes up to the snapshot offset are uploaded.
+		dst := filepath.Join(stagingDir, filepath.FromSlash(f.Path))
+		if err := os.MkdirAll(filepath.Dir(dst), os.ModePerm); err != nil {
+			return "", nil, errors.Wrap(err, "create head snapshot staging directory")
+		}
+		if err := os.Link(f.src, dst); err != nil {
+			return "", nil, errors.Wrapf(err, "link %s", f.src)
+		}
+		files[i].src = dst
+	}
+
+	return snapshotName, files, nil
+}
+
+// writeHeadChunks writes all the in-order chunks of the head series, except the last chunk of each series,
+// to dir in the m-mapped head chunks format. If a chunk has been cut after the chunk snapshot has been taken,
+// the last chunk included in the snapshot is written too, and it takes precedence when the snapshot is loaded.
+func writeHeadChunks(ctx context.Context, head *tsdb.Head, dir string) (returnErr error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return errors.Wrap(err, "create head snapshot chunks directory")
+	}
+
+	cdm, err := chunks.NewChunkDiskMapper(nil, dir, chunkenc.NewPool(), chunks.DefaultWriteBufferSize, 0)
+	if err != nil {
+		return errors.Wrap(err, "open head snapshot chunks")
+	}
+	defer func() {
+		if err := cdm.Close(); err != nil && returnErr == nil {
+			returnErr = errors.Wrap(err, "close head snapshot chunks")
+		}
+	}()
+
+	ir, err := head.Index()
+	if err != nil {
+		return err
+	}
+	defer runutil.CloseWithErrCapture(&returnErr, ir, "close head index reader")
+
+	cr, err := head.Chunks()
+	if err != nil {
+		return err
+	}
+	defer runutil.CloseWithErrCapture(&returnErr, cr, "close head chunk reader")
+
+	name, value := index.AllPostingsKey()
+	postings, err := ir.Postings(ctx, name, value)
+	if err != nil {
+		return err
+	}
+
+	var (
+		builder  labels.ScratchBuilder
+		metas    []chunks.Meta
+		writeErr error
+	)
+	for postings.Next() && writeErr == nil {
+		ref := postings.At()
+		if err := ir.Series(ref, &builder, &metas); errors.Is(err, storage.ErrNotFound) {
+			// The series has been garbage collected.
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		for n := 0; n < len(metas)-1; n++ {
+			chk, _, err := cr.ChunkOrIterable(metas[n])
+			if errors.Is(err, storage.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			// The write queue is disabled, so the chunk is synchronously written and the callback is called before returning.
+			cdm.WriteChunk(chunks.HeadSeriesRef(ref), metas[n].MinTime, metas[n].MaxTime, chk, false, func(err error) {
+				if err != nil {
+					writeErr = errors.Wrap(err, "write head snapshot chunk")
+				}
+			})
+		}
+	}
+	if writeErr != nil {
+		return writeErr
+	}
+	return postings.Err()
+}
+
+type localHeadSnapshotFile struct {
+	headSnapshotFile
+
+	// src is the path of the file on the local disk.
+	src string
+
+	// immutable is true if the file is no longer written, so it doesn't need to be uploaded again.
+	immutable bool
+}
+
+// listHeadSnapshotFiles returns the files to upload for the chunk snapshot with the given name. The size of each
+// file is the number of bytes to upload, which may be less than the file size when the file is still being written.
+func listHeadSnapshotFiles(dir, snapshotName string, walIdx int, walOffset int64, chunksDir string) ([]localHeadSnapshotFile, error) {
+	// The chunk snapshot.
+	files, err := listHeadSnapshotDirFiles(dir, snapshotName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "list chunk snapshot %s", snapshotName)
+	}
+
+	// The last WAL checkpoint and the WAL segments after it, up to the snapshot offset. When the chunk snapshot
+	// is loaded, the replay starts from the snapshot offset, but the segment must exist. If the chunk snapshot
+	// can't be loaded, the head is recovered from the checkpoint and the segments.
+	walDir := filepath.Join(dir, walDirName)
+	firstSegment, _, err := wlog.Segments(walDir)
+	if err != nil {
+		return nil, errors.Wrap(err, "list WAL segments")
+	}
+
+	checkpointDir, checkpointIdx, err := wlog.LastCheckpoint(walDir)
+	switch {
+	case errors.Is(err, record.ErrNotFound):
+	case err != nil:
+		return nil, errors.Wrap(err, "find last WAL checkpoint")
+	case checkpointIdx >= walIdx:
+		// The WAL has been truncated after the chunk snapshot has been taken.
+		return nil, errors.Errorf("WAL checkpoint %d is not older than the chunk snapshot WAL segment %d", checkpointIdx, walIdx)
+	default:
+		checkpointFiles, err := listHeadSnapshotDirFiles(dir, path.Join(walDirName, filepath.Base(checkpointDir)))
+		if err != nil {
+			return nil, errors.Wrapf(err, "list WAL checkpoint %s", checkpointDir)
+		}
+		for i := range checkpointFiles {
+			checkpointFiles[i].immutable = true
+		}
+		files = append(files, checkpointFiles...)
+		firstSegment = checkpointIdx + 1
+	}
+
+	for idx := firstSegment; idx <= walIdx; idx++ {
+		segment := wlog.SegmentName(walDir, idx)
+		size := walOffset
+		if idx < walIdx {
+			info, err := os.Stat(segment)
+			if err != nil {
+				return nil, errors.Wrap(err, "stat WAL segment")
+			}
+			size = info.Size()
+		}
+		files = append(files, localHeadSnapshotFile{src: segment, immutable: idx < walIdx, headSnapshotFile: headSnapshotFile{Path: path.Join(walDirName, filepath.Base(segment)), SizeBytes: size}})
+	}
+
+	// The head chunks.
+	entries, err := os.ReadDir(chunksDir)
+	if err != nil {
+		return nil, errors.Wrap(err, "list head snapshot chunks")
+	}
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, localHeadSnapshotFile{src: filepath.Join(chunksDir, e.Name()), headSnapshotFile: headSnapshotFile{Path: path.Join(headChunksDirName, e.Name()), SizeBytes: info.Size()}})
+	}
+
+	return files, nil
+}
+
+// listHeadSnapshotDirFiles returns all the files in the directory at the given path, relative to dir.
+func listHeadSnapshotDirFiles(dir, relDir string) ([]localHeadSnapshotFile, error) {
+	var files []localHeadSnapshotFile
+	err := filepath.Walk(filepath.Join(dir, filepath.FromSlash(relDir)), func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		files = append(files, localHeadSnapshotFile{src: p, headSnapshotFile: headSnapshotFile{Path: filepath.ToSlash(rel), SizeBytes: info.Size()}})
+		return nil
+	})
+	return files, err
+}
+
+func uploadHeadSnapshotFile(ctx context.Context, bkt objstore.Bucket, src, dst string, size int64) (int64, error) {
+	f, err := os.Open(src)
+	if err != nil {
+		return 0, errors.Wrapf(err, "open %s", src)
+	}
+	defer f.Close()
+
+	r := &countingReader{r: io.LimitReader(f, size)}
+	if err := bkt.Upload(ctx, dst, r); err != nil {
+		return 0, errors.Wrapf(err, "upload %s", dst)
+	}
+	return r.n, nil
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func writeHeadSnapshotMeta(ctx context.Context, bkt objstore.Bucket, instancePrefix string, meta *headSnapshotMeta) error {
+	content, err := json.Marshal(meta)
+	if err != nil {
+		return errors.Wrap(err, "marshal head snapshot meta")
+	}
+	return errors.Wrap(bkt.Upload(ctx, path.Join(instancePrefix, headSnapshotMetaFilename), bytes.NewReader(content)), "upload head snapshot meta")
+}
+
+// readHeadSnapshotMeta reads the head snapshot meta in the given instance prefix. If it doesn't exist, nil is returned.
+func readHeadSnapshotMeta(ctx context.Context, bkt objstore.InstrumentedBucket, instancePrefix string, logger log.Logger) (*headSnapshotMeta, error) {
+	name := path.Join(instancePrefix, headSnapshotMetaFilename)
+	reader, err := bkt.WithExpectedErrs(bkt.IsObjNotFoundErr).Get(ctx, name)
+	if bkt.IsObjNotFoundErr(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "get head snapshot meta %s", name)
+	}
+	defer runutil.CloseWithLogOnErr(logger, reader, "close head snapshot meta reader")
+
+	meta := &headSnapshotMeta{}
+	if err := json.NewDecoder(reader).Decode(meta); err != nil {
+		return nil, errors.Wrapf(err, "decode head snapshot meta %s", name)
+	}
+	if meta.Version != headSnapshotMetaVersion1 {
+		return nil, errors.Errorf("unexpected head snapshot meta %s version %d", name, meta.Version)
+	}
+	return meta, nil
+}
+
+// tenantMarkersPrefix returns the prefix of the objects recording the tenants this instance has uploaded
+// a head snapshot for.
+func (s *headSnapshotter) tenantMarkersPrefix() string {
+	return path.Join(bucket.MimirInternalsPrefix, headSnapshotsPrefix, s.instanceID) + objstore.DirDelim
+}
+
+func (s *headSnapshotter) tenantMarkerPath(userID string) string {
+	return s.tenantMarkersPrefix() + userID
+}
+
+// bootstrap downloads the last head snapshot uploaded by this instance for each tenant. It must be called
+// before opening the TSDBs, and only when there's no TSDB on the local disk. This is a best effort: if the
+// snapshot of a tenant can't be downloaded, the TSDB of the tenant starts empty.
+func (s *headSnapshotter) bootstrap(ctx context.Context) error {
+	var userIDs []string
+	err := s.bkt.Iter(ctx, s.tenantMarkersPrefix(), func(name string) error {
+		if !strings.HasSuffix(name, objstore.DirDelim) {
+			userIDs = append(userIDs, path.Base(name))
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "list tenants with head snapshots")
+	}
+
+	return concurrency.ForEachUser(ctx, userIDs, headSnapshotBootstrapConcurrency, func(ctx context.Context, userID string) error {
+		logger := util_log.WithUserID(userID, s.logger)
+		userBkt := bucket.NewUserBucketClient(userID, s.bkt, s.cfgProvider)
+		instancePrefix := path.Join(headSnapshotsPrefix, s.instanceID)
+
+		meta, err := readHeadSnapshotMeta(ctx, userBkt, instancePrefix, logger)
+		if err != nil {
+			level.Warn(logger).Log("msg", "failed to read TSDB head snapshot meta", "err", err)
+			return nil
+		}
+		if meta == nil {
+			return nil
+		}
+
+		start := time.Now()
+		level.Info(logger).Log("msg", "bootstrapping TSDB from head snapshot", "id", meta.ID, "created_at", meta.CreatedAt, "bytes", meta.totalBytes())
+
+		if err := s.download(ctx, userBkt, instancePrefix, filepath.Join(s.tsdbDir, userID), userID, meta); err != nil {
+			level.Warn(logger).Log("msg", "failed to download TSDB head snapshot, the TSDB will start empty", "id", meta.ID, "err", err)
+			s.updateStatus(userID, func(st *headSnapshotStatus) {
+				st.BootstrapState = headSnapshotBootstrapFailed
+				st.BootstrapErr = err.Error()
+			})
+
+			if err := os.RemoveAll(filepath.Join(s.tsdbDir, userID)); err != nil {
+				level.Warn(logger).Log("msg", "failed to remove partially downloaded TSDB head snapshot", "err", err)
+			}
+			return nil
+		}
+
+		level.Info(logger).Log("msg", "downloaded TSDB head snapshot", "id", meta.ID, "duration", time.Since(start))
+		s.updateStatus(userID, func(st *headSnapshotStatus) { st.BootstrapState = headSnapshotBootstrapReplaying })
+		return nil
+	})
+}
+
+func (s *headSnapshotter) download(ctx context.Context, bkt objstore.Bucket, instancePrefix, dir, userID string, meta *headSnapshotMeta) error {
+	s.updateStatus(userID, func(st *headSnapshotStatus) {
+		st.BootstrapState = headSnapshotBootstrapDownloading
+		st.BootstrapSnapshotID = meta.ID
+		st.BootstrapSnapshotTime = meta.CreatedAt
+		st.BootstrapTotalBytes = meta.totalBytes()
+	})
+
+	for _, f := range meta.Files {
+		dst := filepath.Join(dir, filepath.FromSlash(f.Path))
+		if !strings.HasPrefix(dst, filepath.Clean(dir)+string(filepath.Separator)) {
+			return errors.Errorf("invalid head snapshot file path %s", f.Path)
+		}
+		if err := os.MkdirAll(filepath.Dir(dst), os.ModePerm); err != nil {
+			return err
+		}
+		if err := objstore.DownloadFile(ctx, s.logger, bkt, path.Join(instancePrefix, f.Object), dst); err != nil {
+			return err
+		}
+
+		s.updateStatus(userID, func(st *headSnapshotStatus) { st.BootstrapDownloadedBytes += f.SizeBytes })
+	}
+	return nil
+}
+
+// bootstrapped returns whether the tenant's TSDB has been bootstrapped from a head snapshot and not opened yet.
+func (s *headSnapshotter) bootstrapped(userID string) bool {
+	st, ok := s.getStatus(userID)
+	return ok && st.BootstrapState == headSnapshotBootstrapReplaying
+}
+
+// bootstrapCompleted records that the tenant's TSDB has been opened. It's a no-op if the TSDB
+// has not been bootstrapped from a head snapshot.
+func (s *headSnapshotter) bootstrapCompleted(userID string) {
+	if s == nil {
+		return
+	}
+
+	s.statusMtx.Lock()
+	defer s.statusMtx.Unlock()
+
+	if st, ok := s.status[userID]; ok && st.BootstrapState == headSnapshotBootstrapReplaying {
+		st.BootstrapState = headSnapshotBootstrapCompleted
+	}
+}
+
+// removeTenant deletes the head snapshots uploaded by this instance for the tenant, whose TSDB has been
+// closed and deleted from the local disk, so that it's not bootstrapped again.
+func (s *headSnapshotter) removeTenant(ctx context.Context, userID string) {
+	if s == nil {
+		return
+	}
+
+	s.uploadMtx.Lock()
+	defer s.uploadMtx.Unlock()
+
+	userBkt := bucket.NewUserBucketClient(userID, s.bkt, s.cfgProvider)
+	if _, err := bucket.DeletePrefix(ctx, userBkt, path.Join(headSnapshotsPrefix, s.instanceID), s.logger); err != nil {
+		level.Warn(s.logger).Log("msg", "failed to delete TSDB head snapshots", "user", userID, "err", err)
+	} else if err := s.bkt.Delete(ctx, s.tenantMarkerPath(userID)); err != nil && !s.bkt.IsObjNotFoundErr(err) {
+		level.Warn(s.logger).Log("msg", "failed to delete TSDB head snapshot tenant marker", "user", userID, "err", err)
+	}
+
+	s.statusMtx.Lock()
+	delete(s.status, userID)
+	s.statusMtx.Unlock()
+}
+
+func (s *headSnapshotter) getStatus(userID string) (headSnapshotStatus, bool) {
+	if s == nil {
+		return headSnapshotStatus{}, false
+	}
+
+	s.statusMtx.Lock()
+	defer s.statusMtx.Unlock()
+
+	st, ok := s.status[userID]
+	if !ok {
+		return headSnapshotStatus{}, false
+	}
+	return *st, true
+}
+
+func (s *headSnapshotter) updateStatus(userID string, update func(*headSnapshotStatus)) {
+	s.statusMtx.Lock()
+	defer s.statusMtx.Unlock()
+
+	st, ok := s.status[userID]
+	if !ok {
+		st = &headSnapshotStatus{}
+		s.status[userID] = st
+	}
+	update(st)
+}
@@ -0,0 +1,299 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/gorilla/mux"
+	"github.com/grafana/dskit/services"
+	"github.com/grafana/dskit/test"
+	"github.com/grafana/dskit/user"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/objstore/providers/filesystem"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestIngester_HeadSnapshotBootstrap(t *testing.T) {
+	cfg := defaultIngesterTestConfig(t)
+	cfg.BlocksStorageConfig.TSDB.HeadSnapshotUploadEnabled = true
+	cfg.BlocksStorageConfig.TSDB.HeadSnapshotUploadInterval = time.Hour
+
+	overrides, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
+	require.NoError(t, err)
+
+	bucketDir := t.TempDir()
+	ctx := user.InjectOrgID(context.Background(), userID)
+
+	startIngester := func(dataDir string) *Ingester {
+		i, err := prepareIngesterWithBlockStorageAndOverrides(t, cfg, overrides, nil, dataDir, bucketDir, nil)
+		require.NoError(t, err)
+		require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+
+		// Wait until it's healthy.
+		test.Poll(t, 1*time.Second, 1, func() interface{} {
+			return i.lifecycler.HealthyInstancesCount()
+		})
+		return i
+	}
+
+	// Push enough samples to cut and m-map some head chunks.
+	first := startIngester(t.TempDir())
+
+	const numSamples = 500
+	series := labels.FromStrings(model.MetricNameLabel, "test_metric")
+	startTime := time.Now().Add(-time.Hour).Truncate(time.Minute)
+	for n := 0; n < numSamples; n++ {
+		req, _, _, _ := mockWriteRequest(t, series, float64(n), startTime.Add(time.Duration(n)*time.Second).UnixMilli())
+		_, err = first.Push(ctx, req)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, first.headSnapshotter.uploadTenant(context.Background(), userID))
+
+	st, ok := first.headSnapshotter.getStatus(userID)
+	require.True(t, ok)
+	require.NotEmpty(t, st.LastUploadID)
+	require.Empty(t, st.LastUploadErr)
+
+	// Nothing has been written since the last snapshot, so it's not uploaded again.
+	require.NoError(t, first.headSnapshotter.uploadTenant(context.Background(), userID))
+	st2, _ := first.headSnapshotter.getStatus(userID)
+	require.Equal(t, st.LastUploadID, st2.LastUploadID)
+
+	// These samples are not included in the snapshot.
+	req, _, _, _ := mockWriteRequest(t, series, numSamples, startTime.Add(numSamples*time.Second).UnixMilli())
+	_, err = first.Push(ctx, req)
+	require.NoError(t, err)
+
+	require.NoError(t, services.StopAndAwaitTerminated(context.Background(), first))
+
+	// Only the last snapshot is kept in the bucket.
+	bkt, err := filesystem.NewBucket(bucketDir)
+	require.NoError(t, err)
+	userBkt := bucket.NewUserBucketClient(userID, bkt, nil)
+	meta, err := readHeadSnapshotMeta(context.Background(), userBkt, headSnapshotsPrefix+"/"+cfg.IngesterRing.InstanceID, log.NewNopLogger())
+	require.NoError(t, err)
+	require.NotNil(t, meta)
+	require.Equal(t, st.LastUploadID, meta.ID)
+
+	// The tenant is tracked under the instance prefix, in order to be found by the bootstrap.
+	exists, err := bkt.Exists(context.Background(), "__mimir_cluster/"+headSnapshotsPrefix+"/"+cfg.IngesterRing.InstanceID+"/"+userID)
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	// Start a replacement ingester with an empty disk.
+	second := startIngester(t.TempDir())
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(context.Background(), second))
+	})
+
+	res, _, err := runTestQuery(ctx, t, second, labels.MatchEqual, model.MetricNameLabel, "test_metric")
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.Len(t, res[0].Values, numSamples)
+	assert.Equal(t, model.Time(startTime.UnixMilli()), res[0].Values[0].Timestamp)
+	assert.Equal(t, model.SampleValue(numSamples-1), res[0].Values[numSamples-1].Value)
+
+	st, ok = second.headSnapshotter.getStatus(userID)
+	require.True(t, ok)
+	assert.Equal(t, headSnapshotBootstrapCompleted, st.BootstrapState)
+	assert.Equal(t, meta.ID, st.BootstrapSnapshotID)
+	assert.Equal(t, meta.totalBytes(), st.BootstrapDownloadedBytes)
+
+	// The bootstrap progress is exposed on the tenant TSDB page.
+	rec := httptest.NewRecorder()
+	httpReq := mux.SetURLVars(httptest.NewRequest("GET", "/ingester/tsdb/"+userID, nil), map[string]string{"tenant": userID})
+	second.TenantTSDBHandler(rec, httpReq)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "<li>Bootstrap state: completed</li>")
+
+	// The replacement ingester can ingest new samples.
+	req, _, _, _ = mockWriteRequest(t, series, numSamples, startTime.Add(numSamples*time.Second).UnixMilli())
+	_, err = second.Push(ctx, req)
+	require.NoError(t, err)
+}
+
+func TestIngester_HeadSnapshotUpload_ShouldOnlyUploadTheChangedFiles(t *testing.T) {
+	cfg := defaultIngesterTestConfig(t)
+	cfg.BlocksStorageConfig.TSDB.HeadSnapshotUploadEnabled = true
+	cfg.BlocksStorageConfig.TSDB.HeadSnapshotUploadInterval = time.Hour
+	cfg.BlocksStorageConfig.TSDB.WALSegmentSizeBytes = 32 * 1024
+
+	overrides, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
+	require.NoError(t, err)
+
+	bucketDir := t.TempDir()
+	ctx := user.InjectOrgID(context.Background(), userID)
+
+	i, err := prepareIngesterWithBlockStorageAndOverrides(t, cfg, overrides, nil, t.TempDir(), bucketDir, nil)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(context.Background(), i))
+	})
+
+	series := labels.FromStrings(model.MetricNameLabel, "test_metric")
+	startTime := time.Now().Add(-time.Hour).Truncate(time.Minute)
+	push := func(from, to int) {
+		for n := from; n < to; n++ {
+			req, _, _, _ := mockWriteRequest(t, series, float64(n), startTime.Add(time.Duration(n)*time.Second).UnixMilli())
+			_, err := i.Push(ctx, req)
+			require.NoError(t, err)
+		}
+	}
+
+	// Push enough samples to fill some WAL segments.
+	push(0, 3000)
+	require.NoError(t, i.headSnapshotter.uploadTenant(context.Background(), userID))
+	first, ok := i.headSnapshotter.getStatus(userID)
+	require.True(t, ok)
+	assert.Equal(t, first.LastUploadBytes, first.LastUploadNewBytes)
+
+	push(3000, 3010)
+	require.NoError(t, i.headSnapshotter.uploadTenant(context.Background(), userID))
+	second, ok := i.headSnapshotter.getStatus(userID)
+	require.True(t, ok)
+	require.NotEqual(t, first.LastUploadID, second.LastUploadID)
+
+	// The complete WAL segments have been uploaded with the first snapshot.
+	assert.Less(t, second.LastUploadNewBytes, second.LastUploadBytes)
+
+	bkt, err := filesystem.NewBucket(bucketDir)
+	require.NoError(t, err)
+	userBkt := bucket.NewUserBucketClient(userID, bkt, nil)
+	instancePrefix := headSnapshotsPrefix + "/" + cfg.IngesterRing.InstanceID
+	meta, err := readHeadSnapshotMeta(context.Background(), userBkt, instancePrefix, log.NewNopLogger())
+	require.NoError(t, err)
+	require.Equal(t, second.LastUploadID, meta.ID)
+
+	expected := []string{instancePrefix + "/" + headSnapshotMetaFilename}
+	reused := 0
+	for _, f := range meta.Files {
+		expected = append(expected, instancePrefix+"/"+f.Object)
+		if strings.HasPrefix(f.Object, first.LastUploadID+"/") {
+			require.True(t, strings.HasPrefix(f.Path, walDirName+"/"), f.Path)
+			reused++
+		}
+	}
+	assert.Positive(t, reused)
+
+	// Only the objects referenced by the last snapshot are kept.
+	var actual []string
+	require.NoError(t, userBkt.Iter(context.Background(), instancePrefix+"/", func(name string) error {
+		actual = append(actual, name)
+		return nil
+	}, objstore.WithRecursiveIter()))
+	assert.ElementsMatch(t, expected, actual)
+}
+
+func TestIngester_HeadSnapshotUpload_ShouldNotBlockBlocksShipping(t *testing.T) {
+	cfg := defaultIngesterTestConfig(t)
+	cfg.BlocksStorageConfig.TSDB.HeadSnapshotUploadEnabled = true
+	cfg.BlocksStorageConfig.TSDB.HeadSnapshotUploadInterval = time.Hour
+
+	overrides, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
+	require.NoError(t, err)
+
+	ctx := user.InjectOrgID(context.Background(), userID)
+	i, err := prepareIngesterWithBlockStorageAndOverrides(t, cfg, overrides, nil, t.TempDir(), t.TempDir(), nil)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(context.Background(), i))
+	})
+
+	req, _, _, _ := mockWriteRequest(t, labels.FromStrings(model.MetricNameLabel, "test_metric"), 1, time.Now().UnixMilli())
+	_, err = i.Push(ctx, req)
+	require.NoError(t, err)
+
+	// Block the upload of the snapshot files, once they've been staged.
+	uploading := make(chan struct{})
+	release := make(chan struct{})
+	i.headSnapshotter.bkt = &blockingUploadBucket{Bucket: i.headSnapshotter.bkt, uploading: uploading, release: release}
+
+	done := make(chan error)
+	go func() {
+		done <- i.headSnapshotter.uploadTenant(context.Background(), userID)
+	}()
+	<-uploading
+
+	// The TSDB is back in the active state while uploading, so the blocks can be shipped and the appends are allowed.
+	db := i.getTSDB(userID)
+	ok, _ := db.changeState(active, activeShipping)
+	require.True(t, ok)
+	db.changeState(activeShipping, active)
+
+	_, err = i.Push(ctx, req)
+	require.NoError(t, err)
+
+	close(release)
+	require.NoError(t, <-done)
+}
+
+type blockingUploadBucket struct {
+	objstore.Bucket
+
+	once      sync.Once
+	uploading chan struct{}
+	release   chan struct{}
+}
+
+func (b *blockingUploadBucket) Upload(ctx context.Context, name string, r io.Reader) error {
+	b.once.Do(func() { close(b.uploading) })
+	<-b.release
+	return b.Bucket.Upload(ctx, name, r)
+}
+
+func TestListHeadSnapshotFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name string, size int) {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), os.ModePerm))
+		require.NoError(t, os.WriteFile(p, make([]byte, size), 0o644))
+	}
+
+	writeFile("chunk_snapshot.000004.000050/000000", 10)
+	writeFile("wal/00000001", 100)
+	writeFile("wal/00000002", 100)
+	writeFile("wal/checkpoint.00000002/00000000", 20)
+	writeFile("wal/00000003", 100)
+	writeFile("wal/00000004", 100)
+	writeFile("chunks/000001", 30)
+
+	files, err := listHeadSnapshotFiles(dir, "chunk_snapshot.000004.000050", 4, 50, filepath.Join(dir, "chunks"))
+	require.NoError(t, err)
+
+	actual := map[string]int64{}
+	for _, f := range files {
+		actual[f.Path] = f.SizeBytes
+	}
+	assert.Equal(t, map[string]int64{
+		"chunk_snapshot.000004.000050/000000": 10,
+		"wal/checkpoint.00000002/00000000":    20,
+		"wal/00000003":                        100,
+		"wal/00000004":                        50,
+		"chunks_head/000001":                  30,
+	}, actual)
+
+	// The snapshot can't be uploaded if the WAL has been truncated after it has been taken.
+	writeFile("wal/checkpoint.00000004/00000000", 20)
+	_, err = listHeadSnapshotFiles(dir, "chunk_snapshot.000004.000050", 4, 50, filepath.Join(dir, "chunks"))
+	require.Error(t, err)
+}
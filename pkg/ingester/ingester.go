@@ -18,6 +18,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"sync"
 	"time"
@@ -36,6 +37,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/procfs"
 	promcfg "github.com/prometheus/prometheus/config"
 	"github.com/prometheus/prometheus/model/exemplar"
 	"github.com/prometheus/prometheus/model/histogram"
@@ -52,6 +54,7 @@ import (
 	"golang.org/x/exp/slices"
 	"golang.org/x/sync/errgroup"
 
+	"github.com/grafana/mimir/pkg/deadletter"
 	"github.com/grafana/mimir/pkg/ingester/activeseries"
 	asmodel "github.com/grafana/mimir/pkg/ingester/activeseries/model"
 	"github.com/grafana/mimir/pkg/ingester/client"
@@ -63,6 +66,7 @@ import (
 	"github.com/grafana/mimir/pkg/storage/sharding"
 	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
 	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storage/tsdb/metricsusage"
 	"github.com/grafana/mimir/pkg/usagestats"
 	"github.com/grafana/mimir/pkg/util"
 	"github.com/grafana/mimir/pkg/util/globalerror"
@@ -102,14 +106,16 @@ const (
 	instanceIngestionRateTickInterval = time.Second
 
 	// Reasons for discarding samples
-	reasonSampleOutOfOrder       = "sample-out-of-order"
-	reasonSampleTooOld           = "sample-too-old"
-	reasonSampleTooFarInFuture   = "sample-too-far-in-future"
-	reasonNewValueForTimestamp   = "new-value-for-timestamp"
-	reasonSampleTimestampTooOld  = "sample-timestamp-too-old"
-	reasonPerUserSeriesLimit     = "per_user_series_limit"
-	reasonPerMetricSeriesLimit   = "per_metric_series_limit"
-	reasonInvalidNativeHistogram = "invalid-native-histogram"
+	reasonSampleOutOfOrder         = "sample-out-of-order"
+	reasonSampleTooOld             = "sample-too-old"
+	reasonSampleTooFarInFuture     = "sample-too-far-in-future"
+	reasonNewValueForTimestamp     = "new-value-for-timestamp"
+	reasonSampleTimestampTooOld    = "sample-timestamp-too-old"
+	reasonPerUserSeriesLimit       = "per_user_series_limit"
+	reasonPerUserMemoryLimit       = "per_user_memory_limit"
+	reasonPerMetricSeriesLimit     = "per_metric_series_limit"
+	reasonPerLabelValueSeriesLimit = "per_label_value_series_limit"
+	reasonInvalidNativeHistogram   = "invalid-native-histogram"
 
 	replicationFactorStatsName             = "ingester_replication_factor"
 	ringStoreStatsName                     = "ingester_ring_store"
@@ -202,6 +208,9 @@ type Config struct {
 	ReadPathMemoryUtilizationLimit       uint64  `yaml:"read_path_memory_utilization_limit" category:"experimental"`
 	LogUtilizationBasedLimiterCPUSamples bool    `yaml:"log_utilization_based_limiter_cpu_samples" category:"experimental"`
 
+	ReadPathMaxConcurrentQueries  int           `yaml:"read_path_max_concurrent_queries" category:"experimental"`
+	ReadPathAdmissionQueueTimeout time.Duration `yaml:"read_path_admission_queue_timeout" category:"experimental"`
+
 	ErrorSampleRate int64 `yaml:"error_sample_rate" json:"error_sample_rate" category:"advanced"`
 
 	// UseIngesterOwnedSeriesForLimits was added in 2.12, but we keep it experimental until we decide, what is the correct behaviour
@@ -213,6 +222,8 @@ type Config struct {
 	PushCircuitBreaker CircuitBreakerConfig `yaml:"push_circuit_breaker"`
 	ReadCircuitBreaker CircuitBreakerConfig `yaml:"read_circuit_breaker"`
 
+	DeadLetterConfig deadletter.Config `yaml:"dead_letter"`
+
 	PushGrpcMethodEnabled bool `yaml:"push_grpc_method_enabled" category:"experimental" doc:"hidden"`
 
 	// This config is dynamically injected because defined outside the ingester config.
@@ -230,6 +241,7 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 	cfg.ActiveSeriesMetrics.RegisterFlags(f)
 	cfg.PushCircuitBreaker.RegisterFlagsWithPrefix("ingester.push-circuit-breaker.", f, circuitBreakerDefaultPushTimeout)
 	cfg.ReadCircuitBreaker.RegisterFlagsWithPrefix("ingester.read-circuit-breaker.", f, circuitBreakerDefaultReadTimeout)
+	cfg.DeadLetterConfig.RegisterFlagsWithPrefix("ingester.", f)
 
 	f.DurationVar(&cfg.MetadataRetainPeriod, "ingester.metadata-retain-period", 10*time.Minute, "Period at which metadata we have not seen will remain in memory before being deleted.")
 	f.DurationVar(&cfg.RateUpdatePeriod, "ingester.rate-update-period", 15*time.Second, "Period with which to update the per-tenant ingestion rates.")
@@ -239,6 +251,8 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 	f.Float64Var(&cfg.ReadPathCPUUtilizationLimit, "ingester.read-path-cpu-utilization-limit", 0, "CPU utilization limit, as CPU cores, for CPU/memory utilization based read request limiting. Use 0 to disable it.")
 	f.Uint64Var(&cfg.ReadPathMemoryUtilizationLimit, "ingester.read-path-memory-utilization-limit", 0, "Memory limit, in bytes, for CPU/memory utilization based read request limiting. Use 0 to disable it.")
 	f.BoolVar(&cfg.LogUtilizationBasedLimiterCPUSamples, "ingester.log-utilization-based-limiter-cpu-samples", false, "Enable logging of utilization based limiter CPU samples.")
+	f.IntVar(&cfg.ReadPathMaxConcurrentQueries, "ingester.read-path-max-concurrent-queries", 0, "The maximum number of queries the ingester runs concurrently across all tenants. Queued queries are admitted in round-robin order across tenants. Use 0 to disable it.")
+	f.DurationVar(&cfg.ReadPathAdmissionQueueTimeout, "ingester.read-path-admission-queue-timeout", time.Second, "How long a query waits to be admitted when the -ingester.read-path-max-concurrent-queries, -ingester.max-concurrent-queries-per-tenant or -ingester.max-inflight-query-series-per-tenant limits are reached, before being rejected with a retryable error.")
 	f.Int64Var(&cfg.ErrorSampleRate, "ingester.error-sample-rate", 10, "Each error will be logged once in this many times. Use 0 to log all of them.")
 	f.BoolVar(&cfg.UseIngesterOwnedSeriesForLimits, "ingester.use-ingester-owned-series-for-limits", false, "When enabled, only series currently owned by ingester according to the ring are used when checking user per-tenant series limit.")
 	f.BoolVar(&cfg.UpdateIngesterOwnedSeries, "ingester.track-ingester-owned-series", false, "This option enables tracking of ingester-owned series based on ring state, even if -ingester.use-ingester-owned-series-for-limits is disabled.")
@@ -254,6 +268,10 @@ func (cfg *Config) Validate(log.Logger) error {
 		return fmt.Errorf("error sample rate cannot be a negative number")
 	}
 
+	if err := cfg.DeadLetterConfig.Validate(); err != nil {
+		return err
+	}
+
 	return cfg.IngesterRing.Validate()
 }
 
@@ -293,6 +311,7 @@ type Ingester struct {
 	limiter               *Limiter
 	subservicesWatcher    *services.FailureWatcher
 	ownedSeriesService    *ownedSeriesService
+	ownedSeriesStrategy   ownedSeriesRingStrategy
 	compactionService     services.Service
 	metricsUpdaterService services.Service
 	metadataPurgerService services.Service
@@ -338,6 +357,11 @@ type Ingester struct {
 	inflightPushRequestsBytes atomic.Int64
 
 	utilizationBasedLimiter utilizationBasedLimiter
+	readAdmission           *readAdmissionController
+
+	// Return the Go heap in use and the resident memory of the process. Overridden in tests.
+	heapInuse      func() uint64
+	residentMemory func() (uint64, error)
 
 	errorSamplers ingesterErrSamplers
 
@@ -347,6 +371,15 @@ type Ingester struct {
 	ingestPartitionLifecycler *ring.PartitionInstanceLifecycler
 
 	circuitBreaker ingesterCircuitBreaker
+
+	// Captures the rejected series. Nil if the dead letter is disabled.
+	deadLetter *deadletter.Recorder
+
+	// Tracks the last time each metric has been queried. Nil if the metrics usage tracking is disabled.
+	metricsUsage *metricsusage.Tracker
+
+	// Uploads the TSDB head snapshots and bootstraps the TSDBs from them. Nil if the head snapshot upload is disabled.
+	headSnapshotter *headSnapshotter
 }
 
 func newIngester(cfg Config, limits *validation.Overrides, registerer prometheus.Registerer, logger log.Logger) (*Ingester, error) {
@@ -377,7 +410,9 @@ func newIngester(cfg Config, limits *validation.Overrides, registerer prometheus
 		shipTrigger:         make(chan requestWithUsersAndCallback),
 		seriesHashCache:     hashcache.NewSeriesHashCache(cfg.BlocksStorageConfig.TSDB.SeriesHashCacheMaxBytes),
 
-		errorSamplers: newIngesterErrSamplers(cfg.ErrorSampleRate),
+		errorSamplers:  newIngesterErrSamplers(cfg.ErrorSampleRate),
+		heapInuse:      readHeapInuse,
+		residentMemory: readResidentMemory,
 	}, nil
 }
 
@@ -425,6 +460,8 @@ func New(cfg Config, limits *validation.Overrides, ingestersRing ring.ReadRing,
 			prometheus.WrapRegistererWithPrefix("cortex_ingester_", registerer))
 	}
 
+	i.readAdmission = newReadAdmissionController(limits, cfg.ReadPathMaxConcurrentQueries, cfg.ReadPathAdmissionQueueTimeout, registerer)
+
 	i.shipperIngesterID = i.lifecycler.ID
 
 	// Apply positive jitter only to ensure that the minimum timeout is adhered to.
@@ -475,6 +512,7 @@ func New(cfg Config, limits *validation.Overrides, ingestersRing ring.ReadRing,
 	}
 
 	i.limiter = NewLimiter(limits, limiterStrategy)
+	i.ownedSeriesStrategy = ownedSeriesStrategy
 
 	if cfg.UseIngesterOwnedSeriesForLimits || cfg.UpdateIngesterOwnedSeries {
 		i.ownedSeriesService = newOwnedSeriesService(i.cfg.OwnedSeriesUpdateInterval, ownedSeriesStrategy, log.With(i.logger, "component", "owned series"), registerer, i.limiter.maxSeriesPerUser, i.getTSDBUsers, i.getTSDB)
@@ -498,6 +536,24 @@ func New(cfg Config, limits *validation.Overrides, ingestersRing ring.ReadRing,
 	}, nil)
 	i.subservicesWatcher.WatchService(i.metadataPurgerService)
 
+	if cfg.DeadLetterConfig.Enabled {
+		i.deadLetter, err = deadletter.NewRecorder(cfg.DeadLetterConfig, "ingester", cfg.IngesterRing.InstanceID, limits, i.bucket, cfg.IngestStorageConfig, logger, registerer)
+		if err != nil {
+			return nil, err
+		}
+		i.subservicesWatcher.WatchService(i.deadLetter)
+	}
+
+	if cfg.BlocksStorageConfig.MetricsUsageTrackingEnabled {
+		i.metricsUsage = metricsusage.NewTracker(i.bucket, limits, "ingester", cfg.IngesterRing.InstanceID, cfg.BlocksStorageConfig.MetricsUsageFlushInterval, cfg.BlocksStorageConfig.MetricsUsageRetentionPeriod, logger)
+		i.subservicesWatcher.WatchService(i.metricsUsage)
+	}
+
+	if cfg.BlocksStorageConfig.TSDB.HeadSnapshotUploadEnabled {
+		i.headSnapshotter = newHeadSnapshotter(i.bucket, limits, cfg.IngesterRing.InstanceID, cfg.BlocksStorageConfig.TSDB.Dir, cfg.BlocksStorageConfig.TSDB.HeadSnapshotUploadInterval, i.getTSDBUsers, i.getTSDB, logger, registerer)
+		i.subservicesWatcher.WatchService(i.headSnapshotter)
+	}
+
 	i.BasicService = services.NewBasicService(i.starting, i.ingesterRunning, i.stopping)
 	return i, nil
 }
@@ -551,6 +607,12 @@ func (i *Ingester) starting(ctx context.Context) (err error) {
 		i.setPrepareShutdown()
 	}
 
+	if i.headSnapshotter != nil {
+		if err := i.bootstrapFromHeadSnapshots(ctx); err != nil {
+			return err
+		}
+	}
+
 	if err := i.openExistingTSDB(ctx); err != nil {
 		// Try to rollback and close opened TSDBs before halting the ingester.
 		i.closeAllTSDB()
@@ -577,7 +639,17 @@ func (i *Ingester) starting(ctx context.Context) (err error) {
 
 	// Start the following services before starting the ingest storage reader, in order to have them
 	// running while replaying the partition (if ingest storage is enabled).
-	i.subservicesForPartitionReplay, err = createManagerThenStartAndAwaitHealthy(ctx, i.compactionService, i.metricsUpdaterService, i.metadataPurgerService)
+	replayServices := []services.Service{i.compactionService, i.metricsUpdaterService, i.metadataPurgerService}
+	if i.deadLetter != nil {
+		replayServices = append(replayServices, i.deadLetter)
+	}
+	if i.metricsUsage != nil {
+		replayServices = append(replayServices, i.metricsUsage)
+	}
+	if i.headSnapshotter != nil {
+		replayServices = append(replayServices, i.headSnapshotter)
+	}
+	i.subservicesForPartitionReplay, err = createManagerThenStartAndAwaitHealthy(ctx, replayServices...)
 	if err != nil {
 		return errors.Wrap(err, "failed to start ingester subservices before partition reader")
 	}
@@ -698,10 +770,19 @@ func (i *Ingester) ingesterRunning(ctx context.Context) error {
 	tsdbUpdateTicker := time.NewTicker(i.cfg.TSDBConfigUpdatePeriod)
 	defer tsdbUpdateTicker.Stop()
 
+	var seriesDeletionTickerChan <-chan time.Time
+	if i.cfg.BlocksStorageConfig.SeriesDeletionEnabled {
+		t := time.NewTicker(i.cfg.BlocksStorageConfig.SeriesDeletionSyncInterval)
+		seriesDeletionTickerChan = t.C
+		defer t.Stop()
+	}
+
 	for {
 		select {
 		case <-tsdbUpdateTicker.C:
 			i.applyTSDBSettings()
+		case <-seriesDeletionTickerChan:
+			i.applySeriesDeletionRequests(ctx)
 		case <-ctx.Done():
 			return nil
 		case err := <-i.subservicesWatcher.Chan():
@@ -792,6 +873,7 @@ func (i *Ingester) updateActiveSeries(now time.Time) {
 			i.metrics.activeSeriesLoading.WithLabelValues(userID).Set(1)
 		} else {
 			allActive, activeMatching, allActiveHistograms, activeMatchingHistograms, allActiveBuckets, activeMatchingBuckets := userDB.activeSeries.ActiveWithMatchers()
+			userDB.updateNativeHistogramsMemoryBytes(allActiveHistograms, allActiveBuckets)
 			i.metrics.activeSeriesLoading.DeleteLabelValues(userID)
 			if allActive > 0 {
 				i.metrics.activeSeriesPerUser.WithLabelValues(userID).Set(float64(allActive))
@@ -965,8 +1047,33 @@ type pushStats struct {
 	sampleTooFarInFutureCount   int
 	newValueForTimestampCount   int
 	perUserSeriesLimitCount     int
+	perUserMemoryLimitCount     int
 	perMetricSeriesLimitCount   int
 	invalidNativeHistogramCount int
+
+	// Number of native histogram series, and their buckets, created by the request.
+	// Counted only when the active series are tracked.
+	newNativeHistogramSeriesCount  int
+	newNativeHistogramBucketsCount int
+
+	// Number of samples discarded by label value series limits, by limit name.
+	// Allocated only when a label value series limit is hit.
+	perLabelValueSeriesLimitCount map[string]int
+
+	// Samples rejected because too old or out of order, reported back to the distributor in the error details.
+	// seriesIndex is the index, in the push request, of the series being appended.
+	seriesIndex     int
+	rejectedSamples []mimirpb.RejectedSeriesSamples
+}
+
+// recordRejectedSample records the timestamp of a sample of the series being appended, rejected because too old
+// or out of order.
+func (s *pushStats) recordRejectedSample(timestamp int64) {
+	if n := len(s.rejectedSamples); n == 0 || s.rejectedSamples[n-1].SeriesIndex != int32(s.seriesIndex) {
+		s.rejectedSamples = append(s.rejectedSamples, mimirpb.RejectedSeriesSamples{SeriesIndex: int32(s.seriesIndex)})
+	}
+	last := &s.rejectedSamples[len(s.rejectedSamples)-1]
+	last.TimestampsMs = append(last.TimestampsMs, timestamp)
 }
 
 type ctxKey int
@@ -1184,36 +1291,49 @@ func (i *Ingester) PushWithCleanup(ctx context.Context, req *mimirpb.WriteReques
 
 		outOfOrderWindow = i.limits.OutOfOrderTimeWindow(userID)
 
+		// recordRejected captures the rejected series in the dead letter, if enabled.
+		recordRejected = func(reason string, timestamp int64, labels []mimirpb.LabelAdapter) {
+			i.deadLetter.Record(userID, reason, labels, timestamp, "")
+		}
+
 		errProcessor = mimir_storage.NewSoftAppendErrorProcessor(
 			func() {
 				stats.failedSamplesCount++
 			},
 			func(timestamp int64, labels []mimirpb.LabelAdapter) {
 				stats.sampleTimestampTooOldCount++
+				stats.recordRejectedSample(timestamp)
+				recordRejected(reasonSampleTimestampTooOld, timestamp, labels)
 				updateFirstPartial(i.errorSamplers.sampleTimestampTooOld, func() softError {
 					return newSampleTimestampTooOldError(model.Time(timestamp), labels)
 				})
 			},
 			func(timestamp int64, labels []mimirpb.LabelAdapter) {
 				stats.sampleOutOfOrderCount++
+				stats.recordRejectedSample(timestamp)
+				recordRejected(reasonSampleOutOfOrder, timestamp, labels)
 				updateFirstPartial(i.errorSamplers.sampleOutOfOrder, func() softError {
 					return newSampleOutOfOrderError(model.Time(timestamp), labels)
 				})
 			},
 			func(timestamp int64, labels []mimirpb.LabelAdapter) {
 				stats.sampleTooOldCount++
+				stats.recordRejectedSample(timestamp)
+				recordRejected(reasonSampleTooOld, timestamp, labels)
 				updateFirstPartial(i.errorSamplers.sampleTimestampTooOldOOOEnabled, func() softError {
 					return newSampleTimestampTooOldOOOEnabledError(model.Time(timestamp), labels, outOfOrderWindow)
 				})
 			},
 			func(timestamp int64, labels []mimirpb.LabelAdapter) {
 				stats.sampleTooFarInFutureCount++
+				recordRejected(reasonSampleTooFarInFuture, timestamp, labels)
 				updateFirstPartial(i.errorSamplers.sampleTimestampTooFarInFuture, func() softError {
 					return newSampleTimestampTooFarInFutureError(model.Time(timestamp), labels)
 				})
 			},
 			func(timestamp int64, labels []mimirpb.LabelAdapter) {
 				stats.newValueForTimestampCount++
+				recordRejected(reasonNewValueForTimestamp, timestamp, labels)
 				updateFirstPartial(i.errorSamplers.sampleDuplicateTimestamp, func() softError {
 					return newSampleDuplicateTimestampError(model.Time(timestamp), labels)
 				})
@@ -1224,14 +1344,37 @@ func (i *Ingester) PushWithCleanup(ctx context.Context, req *mimirpb.WriteReques
 					return newPerUserSeriesLimitReachedError(i.limiter.limits.MaxGlobalSeriesPerUser(userID))
 				})
 			},
+			func() {
+				stats.perUserMemoryLimitCount++
+				updateFirstPartial(i.errorSamplers.maxMemoryPerUserLimitExceeded, func() softError {
+					return newPerUserMemoryLimitReachedError(i.limiter.limits.MaxIngesterMemoryBytesPerTenant(userID))
+				})
+			},
 			func(labels []mimirpb.LabelAdapter) {
 				stats.perMetricSeriesLimitCount++
+				recordRejected(reasonPerMetricSeriesLimit, 0, labels)
 				updateFirstPartial(i.errorSamplers.maxSeriesPerMetricLimitExceeded, func() softError {
 					return newPerMetricSeriesLimitReachedError(i.limiter.limits.MaxGlobalSeriesPerMetric(userID), labels)
 				})
 			},
+			func(err error, labels []mimirpb.LabelAdapter) {
+				var limitErr labelValueSeriesLimitExceededError
+				if !errors.As(err, &limitErr) {
+					return
+				}
+				if stats.perLabelValueSeriesLimitCount == nil {
+					stats.perLabelValueSeriesLimitCount = map[string]int{}
+				}
+				stats.perLabelValueSeriesLimitCount[limitErr.limit.Name]++
+				recordRejected(reasonPerLabelValueSeriesLimit, 0, labels)
+				updateFirstPartial(i.errorSamplers.maxSeriesPerLabelValueLimitExceeded, func() softError {
+					return newPerLabelValueSeriesLimitReachedError(limitErr.limit, limitErr.maxValuesExceeded, labels)
+				})
+			},
 			func(err error, timestamp int64, labels []mimirpb.LabelAdapter) {
 				stats.sampleOutOfOrderCount++
+				stats.recordRejectedSample(timestamp)
+				recordRejected(reasonSampleOutOfOrder, timestamp, labels)
 				updateFirstPartial(i.errorSamplers.nativeHistogramValidationError, func() softError {
 					e := newNativeHistogramValidationError(globalerror.NativeHistogramOOODisabled, err, model.Time(timestamp), labels)
 					return e
@@ -1239,30 +1382,35 @@ func (i *Ingester) PushWithCleanup(ctx context.Context, req *mimirpb.WriteReques
 			},
 			func(err error, timestamp int64, labels []mimirpb.LabelAdapter) {
 				stats.invalidNativeHistogramCount++
+				recordRejected(reasonInvalidNativeHistogram, timestamp, labels)
 				updateFirstPartial(i.errorSamplers.nativeHistogramValidationError, func() softError {
 					return newNativeHistogramValidationError(globalerror.NativeHistogramCountMismatch, err, model.Time(timestamp), labels)
 				})
 			},
 			func(err error, timestamp int64, labels []mimirpb.LabelAdapter) {
 				stats.invalidNativeHistogramCount++
+				recordRejected(reasonInvalidNativeHistogram, timestamp, labels)
 				updateFirstPartial(i.errorSamplers.nativeHistogramValidationError, func() softError {
 					return newNativeHistogramValidationError(globalerror.NativeHistogramCountNotBigEnough, err, model.Time(timestamp), labels)
 				})
 			},
 			func(err error, timestamp int64, labels []mimirpb.LabelAdapter) {
 				stats.invalidNativeHistogramCount++
+				recordRejected(reasonInvalidNativeHistogram, timestamp, labels)
 				updateFirstPartial(i.errorSamplers.nativeHistogramValidationError, func() softError {
 					return newNativeHistogramValidationError(globalerror.NativeHistogramNegativeBucketCount, err, model.Time(timestamp), labels)
 				})
 			},
 			func(err error, timestamp int64, labels []mimirpb.LabelAdapter) {
 				stats.invalidNativeHistogramCount++
+				recordRejected(reasonInvalidNativeHistogram, timestamp, labels)
 				updateFirstPartial(i.errorSamplers.nativeHistogramValidationError, func() softError {
 					return newNativeHistogramValidationError(globalerror.NativeHistogramSpanNegativeOffset, err, model.Time(timestamp), labels)
 				})
 			},
 			func(err error, timestamp int64, labels []mimirpb.LabelAdapter) {
 				stats.invalidNativeHistogramCount++
+				recordRejected(reasonInvalidNativeHistogram, timestamp, labels)
 				updateFirstPartial(i.errorSamplers.nativeHistogramValidationError, func() softError {
 					return newNativeHistogramValidationError(globalerror.NativeHistogramSpansBucketsMismatch, err, model.Time(timestamp), labels)
 				})
@@ -1314,6 +1462,11 @@ func (i *Ingester) PushWithCleanup(ctx context.Context, req *mimirpb.WriteReques
 		db.setLastUpdate(time.Now())
 	}
 
+	// Account the memory of the new native histogram series until the next active series update.
+	if stats.newNativeHistogramSeriesCount > 0 {
+		db.addNativeHistogramsMemoryBytes(stats.newNativeHistogramSeriesCount, stats.newNativeHistogramBucketsCount)
+	}
+
 	// Increment metrics only if the samples have been successfully committed.
 	// If the code didn't reach this point, it means that we returned an error
 	// which will be converted into an HTTP 5xx and the client should/will retry.
@@ -1329,6 +1482,9 @@ func (i *Ingester) PushWithCleanup(ctx context.Context, req *mimirpb.WriteReques
 	i.updateMetricsFromPushStats(userID, group, &stats, req.Source, db, i.metrics.discarded)
 
 	if firstPartialErr != nil {
+		if len(stats.rejectedSamples) > 0 {
+			firstPartialErr = rejectedSamplesError{error: firstPartialErr, rejectedSamples: stats.rejectedSamples}
+		}
 		return wrapOrAnnotateWithUser(firstPartialErr, userID)
 	}
 
@@ -1354,9 +1510,20 @@ func (i *Ingester) updateMetricsFromPushStats(userID string, group string, stats
 	if stats.perUserSeriesLimitCount > 0 {
 		discarded.perUserSeriesLimit.WithLabelValues(userID, group).Add(float64(stats.perUserSeriesLimitCount))
 	}
+	if stats.perUserMemoryLimitCount > 0 {
+		discarded.perUserMemoryLimit.WithLabelValues(userID, group).Add(float64(stats.perUserMemoryLimitCount))
+	}
 	if stats.perMetricSeriesLimitCount > 0 {
 		discarded.perMetricSeriesLimit.WithLabelValues(userID, group).Add(float64(stats.perMetricSeriesLimitCount))
 	}
+	if len(stats.perLabelValueSeriesLimitCount) > 0 {
+		total := 0
+		for limitName, count := range stats.perLabelValueSeriesLimitCount {
+			total += count
+			i.metrics.discardedSamplesPerLabelValueSeriesLimit.WithLabelValues(userID, limitName).Add(float64(count))
+		}
+		discarded.perLabelValueSeriesLimit.WithLabelValues(userID, group).Add(float64(total))
+	}
 	if stats.invalidNativeHistogramCount > 0 {
 		discarded.invalidNativeHistogram.WithLabelValues(userID, group).Add(float64(stats.invalidNativeHistogramCount))
 	}
@@ -1390,7 +1557,9 @@ func (i *Ingester) pushSamplesToAppender(userID string, timeseries []mimirpb.Pre
 
 	var builder labels.ScratchBuilder
 	var nonCopiedLabels labels.Labels
-	for _, ts := range timeseries {
+	for seriesIdx, ts := range timeseries {
+		stats.seriesIndex = seriesIdx
+
 		// The labels must be sorted (in our case, it's guaranteed a write request
 		// has sorted labels once hit the ingester).
 
@@ -1406,6 +1575,12 @@ func (i *Ingester) pushSamplesToAppender(userID string, timeseries []mimirpb.Pre
 
 				stats.failedSamplesCount += len(ts.Samples) + len(ts.Histograms)
 				stats.sampleTimestampTooOldCount += len(ts.Samples) + len(ts.Histograms)
+				for _, s := range ts.Samples {
+					stats.recordRejectedSample(s.TimestampMs)
+				}
+				for _, h := range ts.Histograms {
+					stats.recordRejectedSample(h.Timestamp)
+				}
 
 				var firstTimestamp int64
 				if len(ts.Samples) > 0 {
@@ -1415,6 +1590,7 @@ func (i *Ingester) pushSamplesToAppender(userID string, timeseries []mimirpb.Pre
 					firstTimestamp = ts.Histograms[0].Timestamp
 				}
 
+				i.deadLetter.Record(userID, reasonSampleTimestampTooOld, ts.Labels, firstTimestamp, "")
 				updateFirstPartial(i.errorSamplers.sampleTimestampTooOld, func() softError {
 					return newSampleTimestampTooOldError(model.Time(firstTimestamp), ts.Labels)
 				})
@@ -1427,9 +1603,13 @@ func (i *Ingester) pushSamplesToAppender(userID string, timeseries []mimirpb.Pre
 
 				stats.failedSamplesCount += len(ts.Samples)
 				stats.sampleTimestampTooOldCount += len(ts.Samples)
+				for _, s := range ts.Samples {
+					stats.recordRejectedSample(s.TimestampMs)
+				}
 
 				firstTimestamp := ts.Samples[0].TimestampMs
 
+				i.deadLetter.Record(userID, reasonSampleTimestampTooOld, ts.Labels, firstTimestamp, "")
 				updateFirstPartial(i.errorSamplers.sampleTimestampTooOld, func() softError {
 					return newSampleTimestampTooOldError(model.Time(firstTimestamp), ts.Labels)
 				})
@@ -1443,6 +1623,7 @@ func (i *Ingester) pushSamplesToAppender(userID string, timeseries []mimirpb.Pre
 		// Look up a reference for this series. The hash passed should be the output of Labels.Hash()
 		// and NOT the stable hashing because we use the stable hashing in ingesters only for query sharding.
 		ref, copiedLabels := app.GetRef(nonCopiedLabels, hash)
+		newSeries := ref == 0
 
 		// To find out if any sample was added to this series, we keep old value.
 		oldSucceededSamplesCount := stats.succeededSamplesCount
@@ -1549,6 +1730,11 @@ func (i *Ingester) pushSamplesToAppender(userID string, timeseries []mimirpb.Pre
 
 		if activeSeries != nil && stats.succeededSamplesCount > oldSucceededSamplesCount {
 			activeSeries.UpdateSeries(nonCopiedLabels, ref, startAppend, numNativeHistogramBuckets)
+
+			if newSeries && numNativeHistogramBuckets >= 0 {
+				stats.newNativeHistogramSeriesCount++
+				stats.newNativeHistogramBucketsCount += numNativeHistogramBuckets
+			}
 		}
 
 		if len(ts.Exemplars) > 0 && i.limits.MaxGlobalExemplarsPerUser(userID) > 0 {
@@ -2108,9 +2294,20 @@ func (i *Ingester) QueryStream(req *client.QueryRequest, stream client.Ingester_
 		return nil
 	}
 
+	if err := i.readAdmission.acquireQuery(ctx, userID); err != nil {
+		return err
+	}
+	defer i.readAdmission.releaseQuery(userID)
+
 	numSamples := 0
 	numSeries := 0
 
+	if i.metricsUsage != nil {
+		recorder := i.metricsUsage.NewRecorder()
+		defer func() { i.metricsUsage.Record(userID, recorder, time.Now()) }()
+		stream = &metricsUsageQueryStreamServer{Ingester_QueryStreamServer: stream, recorder: recorder}
+	}
+
 	streamType := QueryStreamSamples
 	if i.cfg.StreamChunksWhenUsingBlocks {
 		streamType = QueryStreamChunks
@@ -2355,7 +2552,11 @@ func (i *Ingester) executeStreamingQuery(ctx context.Context, db *userTSDB, from
 	// The querier must remain open until we've finished streaming chunks.
 	defer q.Close()
 
-	allSeries, numSeries, err := i.sendStreamingQuerySeries(ctx, q, from, through, matchers, shard, stream)
+	// The series are held in memory until their chunks have been streamed.
+	seriesBudget := i.readAdmission.newSeriesBudget(ctx, db.userID)
+	defer seriesBudget.release()
+
+	allSeries, numSeries, err := i.sendStreamingQuerySeries(ctx, q, from, through, matchers, shard, stream, seriesBudget)
 	if err != nil {
 		return 0, 0, err
 	}
@@ -2405,7 +2606,7 @@ func putChunkSeriesNode(sn *chunkSeriesNode) {
 	chunkSeriesNodePool.Put(sn)
 }
 
-func (i *Ingester) sendStreamingQuerySeries(ctx context.Context, q storage.ChunkQuerier, from, through int64, matchers []*labels.Matcher, shard *sharding.ShardSelector, stream client.Ingester_QueryStreamServer) (*chunkSeriesNode, int, error) {
+func (i *Ingester) sendStreamingQuerySeries(ctx context.Context, q storage.ChunkQuerier, from, through int64, matchers []*labels.Matcher, shard *sharding.ShardSelector, stream client.Ingester_QueryStreamServer, seriesBudget *querySeriesBudget) (*chunkSeriesNode, int, error) {
 	// Disable chunks trimming, so that we don't have to rewrite chunks which have samples outside
 	// the requested from/through range. PromQL engine can handle it.
 	hints := initSelectHints(from, through)
@@ -2453,6 +2654,10 @@ func (i *Ingester) sendStreamingQuerySeries(ctx context.Context, q storage.Chunk
 		})
 
 		if len(seriesInBatch) >= queryStreamBatchSize {
+			if err := seriesBudget.acquire(len(seriesInBatch)); err != nil {
+				return nil, 0, err
+			}
+
 			err := client.SendQueryStream(stream, &client.QueryStreamResponse{
 				StreamingSeries: seriesInBatch,
 			})
@@ -2464,6 +2669,10 @@ func (i *Ingester) sendStreamingQuerySeries(ctx context.Context, q storage.Chunk
 		}
 	}
 
+	if err := seriesBudget.acquire(len(seriesInBatch)); err != nil {
+		return nil, 0, err
+	}
+
 	// Send any remaining series, and signal that there are no more.
 	err := client.SendQueryStream(stream, &client.QueryStreamResponse{
 		StreamingSeries:     seriesInBatch,
@@ -2625,10 +2834,18 @@ func (i *Ingester) getOrCreateTSDB(userID string) (*userTSDB, error) {
 // createTSDB creates a TSDB for a given userID, and returns the created db.
 func (i *Ingester) createTSDB(userID string, walReplayConcurrency int) (*userTSDB, error) {
 	tsdbPromReg := prometheus.NewRegistry()
+	headChunksReg := &headChunksCapturingRegisterer{Registerer: tsdbPromReg}
 	udir := i.cfg.BlocksStorageConfig.TSDB.BlocksDir(userID)
 	userLogger := util_log.WithUserID(userID, i.logger)
 
 	blockRanges := i.cfg.BlocksStorageConfig.TSDB.BlockRanges.ToMilliseconds()
+	if blockRange := i.limits.IngesterTSDBBlockRangePeriod(userID); blockRange > 0 {
+		blockRanges = []int64{blockRange.Milliseconds()}
+	}
+	retention := i.cfg.BlocksStorageConfig.TSDB.Retention
+	if r := i.limits.IngesterTSDBRetentionPeriod(userID); r > 0 {
+		retention = r
+	}
 	matchersConfig := i.limits.ActiveSeriesCustomTrackersConfig(userID)
 
 	// flusher doesn't actually start the ingester services
@@ -2645,12 +2862,14 @@ func (i *Ingester) createTSDB(userID string, walReplayConcurrency int) (*userTSD
 		userID:                  userID,
 		activeSeries:            activeseries.NewActiveSeries(asmodel.NewMatchers(matchersConfig), i.cfg.ActiveSeriesMetrics.IdleTimeout),
 		seriesInMetric:          newMetricCounter(i.limiter, i.cfg.getIgnoreSeriesLimitForMetricNamesMap()),
+		seriesInLabelValue:      newLabelValueCounter(i.limiter),
 		ingestedAPISamples:      util_math.NewEWMARate(0.2, i.cfg.RateUpdatePeriod),
 		ingestedRuleSamples:     util_math.NewEWMARate(0.2, i.cfg.RateUpdatePeriod),
 		instanceLimitsFn:        i.getInstanceLimits,
 		instanceSeriesCount:     &i.seriesCount,
 		instanceErrors:          i.metrics.rejected,
-		blockMinRetention:       i.cfg.BlocksStorageConfig.TSDB.Retention,
+		blockRange:              time.Duration(blockRanges[0]) * time.Millisecond,
+		blockMinRetention:       retention,
 		useOwnedSeriesForLimits: i.cfg.UseIngesterOwnedSeriesForLimits,
 
 		ownedState: ownedSeriesState{
@@ -2670,8 +2889,8 @@ func (i *Ingester) createTSDB(userID string, walReplayConcurrency int) (*userTSD
 
 	oooTW := i.limits.OutOfOrderTimeWindow(userID)
 	// Create a new user database
-	db, err := tsdb.Open(udir, userLogger, tsdbPromReg, &tsdb.Options{
-		RetentionDuration:                     i.cfg.BlocksStorageConfig.TSDB.Retention.Milliseconds(),
+	db, err := tsdb.Open(udir, userLogger, headChunksReg, &tsdb.Options{
+		RetentionDuration:                     retention.Milliseconds(),
 		MinBlockDuration:                      blockRanges[0],
 		MaxBlockDuration:                      blockRanges[len(blockRanges)-1],
 		NoLockfile:                            true,
@@ -2686,7 +2905,7 @@ func (i *Ingester) createTSDB(userID string, walReplayConcurrency int) (*userTSD
 		EnableExemplarStorage:                 true, // enable for everyone so we can raise the limit later
 		MaxExemplars:                          int64(i.limiter.maxExemplarsPerUser(userID)),
 		SeriesHashCache:                       i.seriesHashCache,
-		EnableMemorySnapshotOnShutdown:        i.cfg.BlocksStorageConfig.TSDB.MemorySnapshotOnShutdown,
+		EnableMemorySnapshotOnShutdown:        i.cfg.BlocksStorageConfig.TSDB.MemorySnapshotOnShutdown || i.headSnapshotter.bootstrapped(userID), // The chunk snapshot is loaded only if enabled.
 		IsolationDisabled:                     true,
 		HeadChunksWriteQueueSize:              i.cfg.BlocksStorageConfig.TSDB.HeadChunksWriteQueueSize,
 		EnableOverlappingCompaction:           false,                // always false since Mimir only uploads lvl 1 compacted blocks
@@ -2723,6 +2942,7 @@ func (i *Ingester) createTSDB(userID string, walReplayConcurrency int) (*userTSD
 	}
 
 	userDB.db = db
+	userDB.headChunksGauge = headChunksReg.gauge
 	userDBHasDB.Store(true)
 	// We set the limiter here because we don't want to limit
 	// series during WAL replay.
@@ -2745,6 +2965,22 @@ func (i *Ingester) createTSDB(userID string, walReplayConcurrency int) (*userTSD
 
 	// Create a new shipper for this database
 	if i.cfg.BlocksStorageConfig.TSDB.IsBlocksShippingEnabled() {
+		var exemplars storage.ExemplarQueryable
+		if i.cfg.BlocksStorageConfig.LongTermExemplarsEnabled {
+			exemplars = db
+		}
+
+		var metadata func() []*mimirpb.MetricMetadata
+		if i.cfg.BlocksStorageConfig.DurableMetricsMetadataEnabled {
+			metadata = func() []*mimirpb.MetricMetadata {
+				userMetadata := i.getUserMetadata(userID)
+				if userMetadata == nil {
+					return nil
+				}
+				return userMetadata.toClientMetadata(&client.MetricsMetadataRequest{Limit: -1, LimitPerMetric: -1})
+			}
+		}
+
 		userDB.shipper = newShipper(
 			userLogger,
 			i.limits,
@@ -2753,6 +2989,8 @@ func (i *Ingester) createTSDB(userID string, walReplayConcurrency int) (*userTSD
 			udir,
 			bucket.NewUserBucketClient(userID, i.bucket, i.limits),
 			block.ReceiveSource,
+			exemplars,
+			metadata,
 		)
 
 		// Initialise the shipper blocks cache.
@@ -2835,6 +3073,7 @@ func (i *Ingester) openExistingTSDB(ctx context.Context) error {
 				i.tsdbs[userID] = db
 				i.tsdbsMtx.Unlock()
 				i.metrics.memUsers.Inc()
+				i.headSnapshotter.bootstrapCompleted(userID)
 			}
 
 			return nil
@@ -2873,6 +3112,25 @@ func (i *Ingester) openExistingTSDB(ctx context.Context) error {
 	return nil
 }
 
+// bootstrapFromHeadSnapshots downloads the TSDB head snapshots previously uploaded by this ingester, if
+// there's no TSDB on the local disk, e.g. because the ingester has been replaced.
+func (i *Ingester) bootstrapFromHeadSnapshots(ctx context.Context) error {
+	userIDs, err := i.findUserIDsWithTSDBOnFilesystem()
+	if err != nil {
+		return errors.Wrap(err, "finding existing TSDBs")
+	}
+	if len(userIDs) > 0 {
+		return nil
+	}
+
+	level.Info(i.logger).Log("msg", "no TSDB found on the local disk, bootstrapping TSDBs from head snapshots")
+	if err := i.headSnapshotter.bootstrap(ctx); err != nil {
+		// The bootstrap is a best effort, so the TSDBs start empty if it fails.
+		level.Warn(i.logger).Log("msg", "failed to bootstrap TSDBs from head snapshots", "err", err)
+	}
+	return nil
+}
+
 func getOpenTSDBsConcurrencyConfig(tsdbConfig mimir_tsdb.TSDBConfig, userCount int) (tsdbOpenConcurrency, tsdbWALReplayConcurrency int) {
 	tsdbOpenConcurrency = mimir_tsdb.DefaultMaxTSDBOpeningConcurrencyOnStartup
 	tsdbWALReplayConcurrency = 0
@@ -3101,6 +3359,9 @@ func (i *Ingester) compactionServiceRunning(ctx context.Context) error {
 			// Check if any TSDB Head should be compacted to reduce the number of in-memory series.
 			i.compactBlocksToReduceInMemorySeries(ctx, time.Now())
 
+			// Check if any TSDB Head should be compacted to reduce the memory used by the process.
+			i.compactBlocksToReduceMemory(ctx, time.Now())
+
 			// Check if the desired interval has changed. We only compare the standard interval
 			// before the first interval may be random due to jittering.
 			if newFirstInterval, newStandardInterval := i.compactionServiceInterval(); standardInterval != newStandardInterval {
@@ -3144,6 +3405,20 @@ func (i *Ingester) compactionServiceInterval() (firstInterval, standardInterval
 	return
 }
 
+// compactionIdleTimeoutForUser returns the TSDB head compaction idle timeout of the user, including jitter.
+func (i *Ingester) compactionIdleTimeoutForUser(userID string) time.Duration {
+	timeout := i.limits.IngesterTSDBHeadCompactionIdleTimeout(userID)
+	if timeout <= 0 {
+		return i.compactionIdleTimeout
+	}
+
+	// Apply the same jitter computed for the global timeout, so that the timeout is stable across calls.
+	if global := i.cfg.BlocksStorageConfig.TSDB.HeadCompactionIdleTimeout; global > 0 {
+		return time.Duration(float64(timeout) * float64(i.compactionIdleTimeout) / float64(global))
+	}
+	return timeout
+}
+
 // Compacts all compactable blocks. Force flag will force compaction even if head is not compactable yet.
 func (i *Ingester) compactBlocks(ctx context.Context, force bool, forcedCompactionMaxTime int64, allowed *util.AllowedTenants) {
 	_ = concurrency.ForEachUser(ctx, i.getTSDBUsers(), i.cfg.BlocksStorageConfig.TSDB.HeadCompactionConcurrency, func(_ context.Context, userID string) error {
@@ -3167,19 +3442,20 @@ func (i *Ingester) compactBlocks(ctx context.Context, force bool, forcedCompacti
 		i.metrics.compactionsTriggered.Inc()
 
 		minTimeBefore := userDB.Head().MinTime()
+		idleTimeout := i.compactionIdleTimeoutForUser(userID)
 
 		reason := ""
 		switch {
 		case force:
 			reason = "forced"
-			err = userDB.compactHead(i.cfg.BlocksStorageConfig.TSDB.BlockRanges[0].Milliseconds(), forcedCompactionMaxTime)
+			err = userDB.compactHead(userDB.blockRange.Milliseconds(), forcedCompactionMaxTime)
 
-		case i.compactionIdleTimeout > 0 && userDB.isIdle(time.Now(), i.compactionIdleTimeout):
+		case idleTimeout > 0 && userDB.isIdle(time.Now(), idleTimeout):
 			reason = "idle"
 			level.Info(i.logger).Log("msg", "TSDB is idle, forcing compaction", "user", userID)
 
 			// Always pass math.MaxInt64 as forcedCompactionMaxTime because we want to compact the whole TSDB head.
-			err = userDB.compactHead(i.cfg.BlocksStorageConfig.TSDB.BlockRanges[0].Milliseconds(), math.MaxInt64)
+			err = userDB.compactHead(userDB.blockRange.Milliseconds(), math.MaxInt64)
 
 		default:
 			reason = "regular"
@@ -3262,11 +3538,180 @@ func (i *Ingester) compactBlocksToReduceInMemorySeries(ctx context.Context, now
 	}
 
 	level.Info(i.logger).Log("msg", "running TSDB head compaction to reduce the number of in-memory series", "users", strings.Join(usersToCompact, " "))
+	i.recordEarlyCompactions(usersToCompact, earlyCompactionReasonInMemorySeries, now)
 	forcedCompactionMaxTime := now.Add(-i.cfg.ActiveSeriesMetrics.IdleTimeout).UnixMilli()
 	i.compactBlocks(ctx, true, forcedCompactionMaxTime, util.NewAllowedTenants(usersToCompact, nil))
 	level.Info(i.logger).Log("msg", "run TSDB head compaction to reduce the number of in-memory series", "before_in_memory_series", totalMemorySeries, "after_in_memory_series", i.seriesCount.Load())
 }
 
+// compactBlocksToReduceMemory compacts the TSDB Head of the tenants using the most memory, when the memory used by
+// the process is higher than the configured target.
+func (i *Ingester) compactBlocksToReduceMemory(ctx context.Context, now time.Time) {
+	// Skip if disabled.
+	target := i.cfg.BlocksStorageConfig.TSDB.EarlyHeadCompactionMemoryTargetBytes
+	if target == 0 || !i.cfg.ActiveSeriesMetrics.Enabled {
+		return
+	}
+
+	// Both the Go heap in use and the resident memory must be above the target: the resident memory may stay
+	// above it for a long time after the TSDB Heads have been compacted, because the Go runtime releases
+	// the freed memory to the OS lazily, while the Go heap doesn't include the memory mapped head chunks
+	// which have been paged in.
+	heapInuse := i.heapInuse()
+	i.metrics.heapInuseBytes.Set(float64(heapInuse))
+	memory := heapInuse
+	if rss, err := i.residentMemory(); err != nil {
+		level.Warn(i.logger).Log("msg", "failed to read the resident memory, the early compaction is based on the Go heap in use only", "err", err)
+	} else {
+		i.metrics.residentMemoryBytes.Set(float64(rss))
+		memory = min(memory, rss)
+	}
+	if memory < target {
+		return
+	}
+
+	level.Info(i.logger).Log("msg", "the Go heap in use and the resident memory are higher than the configured early compaction target", "heap_inuse_bytes", heapInuse, "memory_bytes", memory, "early_compaction_target_bytes", target)
+
+	forcedCompactionMaxTime := now.Add(-i.cfg.ActiveSeriesMetrics.IdleTimeout).UnixMilli()
+	var estimations []headMemoryEstimation
+	for _, userID := range i.getTSDBUsers() {
+		db := i.getTSDB(userID)
+		if db == nil {
+			continue
+		}
+
+		userMemorySeries := db.Head().NumSeries()
+		if userMemorySeries == 0 {
+			continue
+		}
+
+		// Purge the active series so that the next call to Active() will return the up-to-date count.
+		db.activeSeries.Purge(now)
+
+		// Estimate the memory that would be released if we would compact the head up until "now - active
+		// series idle timeout": the memory of the series which would be dropped, assuming it's proportional
+		// to their number, and the memory of the chunks of all series, active or not, which would be compacted,
+		// assuming it's proportional to the compacted time range.
+		totalActiveSeries, _, _ := db.activeSeries.Active()
+		estimatedSeriesReduction := util_math.Max(0, int64(userMemorySeries)-int64(totalActiveSeries))
+		seriesBytes, chunksBytes := db.estimatedHeadMemoryBytes()
+		estimations = append(estimations, headMemoryEstimation{
+			userID:                  userID,
+			estimatedBytes:          seriesBytes + chunksBytes,
+			estimatedReductionBytes: seriesBytes*estimatedSeriesReduction/int64(userMemorySeries) + int64(float64(chunksBytes)*compactedHeadRangeRatio(db.Head(), forcedCompactionMaxTime)),
+		})
+	}
+
+	usersToCompact := filterUsersToCompactToReduceMemory(int64(memory-target), i.cfg.BlocksStorageConfig.TSDB.EarlyHeadCompactionMinEstimatedMemoryReductionPercentage, estimations)
+	if len(usersToCompact) == 0 {
+		level.Info(i.logger).Log("msg", "no viable per-tenant TSDB found to early compact in order to reduce memory")
+		return
+	}
+
+	level.Info(i.logger).Log("msg", "running TSDB head compaction to reduce memory", "users", strings.Join(usersToCompact, " "))
+	i.recordEarlyCompactions(usersToCompact, earlyCompactionReasonMemoryPressure, now)
+	i.compactBlocks(ctx, true, forcedCompactionMaxTime, util.NewAllowedTenants(usersToCompact, nil))
+	level.Info(i.logger).Log("msg", "run TSDB head compaction to reduce memory", "users", strings.Join(usersToCompact, " "))
+}
+
+// compactedHeadRangeRatio returns the ratio of the TSDB Head time range which would be compacted by a forced
+// compaction up until forcedCompactionMaxTime, between 0 and 1.
+func compactedHeadRangeRatio(h *tsdb.Head, forcedCompactionMaxTime int64) float64 {
+	minTime, maxTime := h.MinTime(), h.MaxTime()
+	if forcedCompactionMaxTime < minTime || maxTime < minTime {
+		return 0
+	}
+	if forcedCompactionMaxTime >= maxTime {
+		return 1
+	}
+	return float64(forcedCompactionMaxTime-minTime+1) / float64(maxTime-minTime+1)
+}
+
+type headMemoryEstimation struct {
+	userID                  string
+	estimatedBytes          int64
+	estimatedReductionBytes int64
+}
+
+// filterUsersToCompactToReduceMemory returns the tenants with the largest estimated TSDB Head memory reduction, until
+// their cumulative reduction reaches the given reduction target. At least one tenant is returned if any, unless the
+// total estimated reduction is lower than the given percentage of the total estimated TSDB Head memory.
+func filterUsersToCompactToReduceMemory(reductionTarget int64, minReductionPercentage int, estimations []headMemoryEstimation) []string {
+	// Skip if the estimated memory reduction is too low (there would be no big benefit).
+	var totalEstimatedBytes, totalEstimatedReductionBytes int64
+	for _, entry := range estimations {
+		totalEstimatedBytes += entry.estimatedBytes
+		totalEstimatedReductionBytes += entry.estimatedReductionBytes
+	}
+
+	if totalEstimatedReductionBytes == 0 || (totalEstimatedReductionBytes*100)/totalEstimatedBytes < int64(minReductionPercentage) {
+		return nil
+	}
+
+	slices.SortFunc(estimations, func(a, b headMemoryEstimation) int {
+		switch {
+		case b.estimatedReductionBytes < a.estimatedReductionBytes:
+			return -1
+		case b.estimatedReductionBytes > a.estimatedReductionBytes:
+			return 1
+		default:
+			return strings.Compare(a.userID, b.userID)
+		}
+	})
+
+	var (
+		usersToCompact []string
+		reductionSum   int64
+	)
+	for _, entry := range estimations {
+		if entry.estimatedReductionBytes == 0 || (len(usersToCompact) > 0 && reductionSum >= reductionTarget) {
+			break
+		}
+		usersToCompact = append(usersToCompact, entry.userID)
+		reductionSum += entry.estimatedReductionBytes
+	}
+
+	return usersToCompact
+}
+
+// recordEarlyCompactions tracks the early compaction of the TSDB Head of the users, to expose it in the metrics
+// and in the tenants page.
+func (i *Ingester) recordEarlyCompactions(userIDs []string, reason string, now time.Time) {
+	for _, userID := range userIDs {
+		db := i.getTSDB(userID)
+		if db == nil {
+			continue
+		}
+		seriesBytes, chunksBytes := db.estimatedHeadMemoryBytes()
+		db.lastEarlyCompaction.Store(&earlyCompactionDecision{
+			time:            now,
+			reason:          reason,
+			headMemoryBytes: seriesBytes + chunksBytes,
+		})
+		i.metrics.earlyCompactions.WithLabelValues(reason).Inc()
+	}
+}
+
+// readHeapInuse returns the Go heap in use.
+func readHeapInuse() uint64 {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return m.HeapInuse
+}
+
+// readResidentMemory returns the resident memory of the process.
+func readResidentMemory() (uint64, error) {
+	p, err := procfs.Self()
+	if err != nil {
+		return 0, err
+	}
+	stat, err := p.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return uint64(stat.ResidentMemory()), nil
+}
+
 type seriesReductionEstimation struct {
 	userID              string
 	estimatedCount      int64
@@ -3391,7 +3836,10 @@ func (i *Ingester) closeAndDeleteUserTSDBIfIdle(userID string) tsdbCloseCheckRes
 
 	i.deleteUserMetadata(userID)
 	i.metrics.deletePerUserMetrics(userID)
+	i.readAdmission.removeTenantMetrics(userID)
 	i.metrics.deletePerUserCustomTrackerMetrics(userID, userDB.activeSeries.CurrentMatcherNames())
+	i.deadLetter.RemoveTenant(userID)
+	i.headSnapshotter.removeTenant(context.Background(), userID)
 
 	// And delete local data.
 	if err := os.RemoveAll(dir); err != nil {
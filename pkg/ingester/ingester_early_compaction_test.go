@@ -6,8 +6,11 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -18,6 +21,8 @@ import (
 	"github.com/grafana/dskit/test"
 	"github.com/grafana/dskit/user"
 	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/tsdb"
@@ -666,6 +671,201 @@ func TestIngester_compactBlocksToReduceInMemorySeries_Concurrency(t *testing.T)
 	}
 }
 
+func TestIngester_filterUsersToCompactToReduceMemory(t *testing.T) {
+	estimations := func() []headMemoryEstimation {
+		return []headMemoryEstimation{
+			{userID: "1", estimatedBytes: 100, estimatedReductionBytes: 10},
+			{userID: "2", estimatedBytes: 50, estimatedReductionBytes: 40},
+			{userID: "3", estimatedBytes: 30, estimatedReductionBytes: 30},
+			{userID: "4", estimatedBytes: 20, estimatedReductionBytes: 20},
+			{userID: "5", estimatedBytes: 100, estimatedReductionBytes: 0},
+		}
+	}
+
+	tests := map[string]struct {
+		reductionTarget        int64
+		minReductionPercentage int
+		expected               []string
+	}{
+		"should return the tenant with the largest head memory reduction if it's enough to reach the target": {
+			reductionTarget: 40,
+			expected:        []string{"2"},
+		},
+		"should return the tenants with the largest head memory reduction until the target is reached": {
+			reductionTarget: 50,
+			expected:        []string{"2", "3"},
+		},
+		"should return all tenants with a head memory reduction if the target can't be reached": {
+			reductionTarget: 1000,
+			expected:        []string{"2", "3", "4", "1"},
+		},
+		"should return at least one tenant": {
+			reductionTarget: 0,
+			expected:        []string{"2"},
+		},
+		"should return the tenants if the total head memory reduction is equal to the minimum percentage": {
+			reductionTarget:        40,
+			minReductionPercentage: 33,
+			expected:               []string{"2"},
+		},
+		"should return no tenant if the total head memory reduction is lower than the minimum percentage": {
+			reductionTarget:        40,
+			minReductionPercentage: 34,
+			expected:               nil,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			assert.Equal(t, testData.expected, filterUsersToCompactToReduceMemory(testData.reductionTarget, testData.minReductionPercentage, estimations()))
+		})
+	}
+
+	assert.Empty(t, filterUsersToCompactToReduceMemory(10, 0, nil))
+	assert.Empty(t, filterUsersToCompactToReduceMemory(10, 0, []headMemoryEstimation{{userID: "1", estimatedBytes: 100}}))
+}
+
+func TestIngester_compactBlocksToReduceMemory_ShouldCompactTenantsWithLargestHeadMemory(t *testing.T) {
+	var (
+		ctx             = context.Background()
+		now             = time.Now()
+		sampleTimestamp = now.UnixMilli()
+		reg             = prometheus.NewPedanticRegistry()
+	)
+
+	cfg := defaultIngesterTestConfig(t)
+	cfg.ActiveSeriesMetrics.Enabled = true
+	cfg.ActiveSeriesMetrics.IdleTimeout = 20 * time.Minute
+	cfg.BlocksStorageConfig.TSDB.HeadCompactionInterval = time.Hour // Do not trigger it during the test, so that we trigger it manually.
+	cfg.BlocksStorageConfig.TSDB.EarlyHeadCompactionMemoryTargetBytes = 1000
+
+	ingester, err := prepareIngesterWithBlocksStorage(t, cfg, nil, reg)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(ctx, ingester))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(ctx, ingester))
+	})
+
+	// Wait until it's ACTIVE.
+	test.Poll(t, time.Second, ring.ACTIVE, func() interface{} {
+		return ingester.lifecycler.GetState()
+	})
+
+	var heapInuse, residentMemory uint64
+	ingester.heapInuse = func() uint64 {
+		return heapInuse
+	}
+	ingester.residentMemory = func() (uint64, error) {
+		return residentMemory, nil
+	}
+
+	// Push more series to user-1 than user-2.
+	for userID, numSeries := range map[string]int{"user-1": 10, "user-2": 2} {
+		for seriesID := 0; seriesID < numSeries; seriesID++ {
+			require.NoError(t, pushSeriesToIngester(user.InjectOrgID(ctx, userID), t, ingester, []series{
+				{labels.FromStrings(labels.MetricName, fmt.Sprintf("metric_%d", seriesID)), 0, sampleTimestamp},
+			}))
+		}
+	}
+	user1SeriesBytes, user1ChunksBytes := ingester.getTSDB("user-1").estimatedHeadMemoryBytes()
+	user2SeriesBytes, user2ChunksBytes := ingester.getTSDB("user-2").estimatedHeadMemoryBytes()
+	require.Greater(t, user1SeriesBytes+user1ChunksBytes, user2SeriesBytes+user2ChunksBytes)
+
+	user1BlocksDir := filepath.Join(ingester.cfg.BlocksStorageConfig.TSDB.Dir, "user-1")
+	user2BlocksDir := filepath.Join(ingester.cfg.BlocksStorageConfig.TSDB.Dir, "user-2")
+
+	// TSDB head early compaction should not trigger while the memory is below the target.
+	heapInuse, residentMemory = 500, 500
+	ingester.compactBlocksToReduceMemory(ctx, now.Add(30*time.Minute))
+	require.Len(t, listBlocksInDir(t, user1BlocksDir), 0)
+	require.Len(t, listBlocksInDir(t, user2BlocksDir), 0)
+
+	// TSDB head early compaction should not trigger while either the Go heap in use or the resident memory is below the target.
+	heapInuse, residentMemory = 1001, 500
+	ingester.compactBlocksToReduceMemory(ctx, now.Add(30*time.Minute))
+	require.Len(t, listBlocksInDir(t, user1BlocksDir), 0)
+
+	heapInuse, residentMemory = 500, 1001
+	ingester.compactBlocksToReduceMemory(ctx, now.Add(30*time.Minute))
+	require.Len(t, listBlocksInDir(t, user1BlocksDir), 0)
+
+	// Once the memory is above the target, only the tenant using the most memory is compacted,
+	// because it's enough to reach the target.
+	heapInuse, residentMemory = 1001, 1001
+	ingester.compactBlocksToReduceMemory(ctx, now.Add(30*time.Minute))
+	require.Len(t, listBlocksInDir(t, user1BlocksDir), 1)
+	require.Len(t, listBlocksInDir(t, user2BlocksDir), 0)
+	require.Equal(t, uint64(0), ingester.getTSDB("user-1").Head().NumSeries())
+	require.Equal(t, uint64(2), ingester.getTSDB("user-2").Head().NumSeries())
+
+	decision := ingester.getTSDB("user-1").lastEarlyCompaction.Load()
+	require.NotNil(t, decision)
+	assert.Equal(t, earlyCompactionReasonMemoryPressure, decision.reason)
+	assert.Nil(t, ingester.getTSDB("user-2").lastEarlyCompaction.Load())
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_ingester_tsdb_early_head_compactions_total Total number of per-tenant TSDB Head compactions triggered before the end of the block range, by reason.
+		# TYPE cortex_ingester_tsdb_early_head_compactions_total counter
+		cortex_ingester_tsdb_early_head_compactions_total{reason="memory_pressure"} 1
+
+		# HELP cortex_ingester_tsdb_early_head_compaction_heap_inuse_bytes The Go heap in use, last observed when checking if the TSDB Heads should be early compacted.
+		# TYPE cortex_ingester_tsdb_early_head_compaction_heap_inuse_bytes gauge
+		cortex_ingester_tsdb_early_head_compaction_heap_inuse_bytes 1001
+
+		# HELP cortex_ingester_tsdb_early_head_compaction_resident_memory_bytes The resident memory of the process, last observed when checking if the TSDB Heads should be early compacted.
+		# TYPE cortex_ingester_tsdb_early_head_compaction_resident_memory_bytes gauge
+		cortex_ingester_tsdb_early_head_compaction_resident_memory_bytes 1001
+	`), "cortex_ingester_tsdb_early_head_compactions_total", "cortex_ingester_tsdb_early_head_compaction_heap_inuse_bytes", "cortex_ingester_tsdb_early_head_compaction_resident_memory_bytes"))
+
+	// The decision is shown in the tenants page.
+	rec := httptest.NewRecorder()
+	ingester.TenantsHandler(rec, httptest.NewRequest("GET", "/tenants", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), fmt.Sprintf("<td>%s</td>", formatEarlyCompactionDecision(decision)))
+	assert.Contains(t, rec.Body.String(), fmt.Sprintf("<td>%s series, 0 B chunks</td>", formatMemoryUsage(user2SeriesBytes, 0)))
+}
+
+func TestIngester_compactBlocksToReduceMemory_ShouldEstimateTheChunksReductionOfActiveSeries(t *testing.T) {
+	var (
+		ctx = context.Background()
+		now = time.Now()
+	)
+
+	cfg := defaultIngesterTestConfig(t)
+	cfg.ActiveSeriesMetrics.Enabled = true
+	cfg.ActiveSeriesMetrics.IdleTimeout = 20 * time.Minute
+	cfg.BlocksStorageConfig.TSDB.HeadCompactionInterval = time.Hour // Do not trigger it during the test, so that we trigger it manually.
+	cfg.BlocksStorageConfig.TSDB.EarlyHeadCompactionMemoryTargetBytes = 1000
+
+	ingester, err := prepareIngesterWithBlocksStorage(t, cfg, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(ctx, ingester))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(ctx, ingester))
+	})
+
+	test.Poll(t, time.Second, ring.ACTIVE, func() interface{} {
+		return ingester.lifecycler.GetState()
+	})
+	ingester.heapInuse = func() uint64 { return 1001 }
+	ingester.residentMemory = func() (uint64, error) { return 1001, nil }
+
+	// A single series, active until now, with enough samples over the last hour to cut several head chunks.
+	userCtx := user.InjectOrgID(ctx, "user-1")
+	for ts := now.Add(-time.Hour); !ts.After(now); ts = ts.Add(15 * time.Second) {
+		require.NoError(t, pushSeriesToIngester(userCtx, t, ingester, []series{
+			{labels.FromStrings(labels.MetricName, "metric"), 0, ts.UnixMilli()},
+		}))
+	}
+	_, chunksBytes := ingester.getTSDB("user-1").estimatedHeadMemoryBytes()
+	require.Positive(t, chunksBytes)
+
+	// No series would be dropped, but the chunks older than the active series idle timeout would be compacted.
+	ingester.compactBlocksToReduceMemory(ctx, now)
+	require.Len(t, listBlocksInDir(t, filepath.Join(ingester.cfg.BlocksStorageConfig.TSDB.Dir, "user-1")), 1)
+	require.Equal(t, uint64(1), ingester.getTSDB("user-1").Head().NumSeries())
+}
+
 func listBlocksInDir(t *testing.T, dir string) (ids []ulid.ULID) {
 	entries, err := os.ReadDir(dir)
 	require.NoError(t, err)
@@ -48,6 +48,8 @@ import (
 	"github.com/prometheus/prometheus/storage"
 	"github.com/prometheus/prometheus/tsdb"
 	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/prometheus/prometheus/tsdb/index"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -3510,6 +3512,94 @@ func TestIngester_Push_ShouldCorrectlyTrackMetricsInMultiTenantScenario(t *testi
 	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expectedMetrics), metricNames...))
 }
 
+func TestIngester_Push_ShouldRecordRejectedSeriesInDeadLetter(t *testing.T) {
+	cfg := defaultIngesterTestConfig(t)
+	cfg.DeadLetterConfig.Enabled = true
+
+	limits := defaultLimitsTestConfig()
+	limits.DeadLetterEnabled = true
+
+	i, err := prepareIngesterWithBlocksStorageAndLimits(t, cfg, limits, nil, "", nil)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+
+	// Wait until it's healthy
+	test.Poll(t, 1*time.Second, 1, func() interface{} {
+		return i.lifecycler.HealthyInstancesCount()
+	})
+
+	ctx := user.InjectOrgID(context.Background(), "test")
+	lbls := labels.FromStrings(labels.MetricName, "metric", "job", "test")
+
+	req, _, _, _ := mockWriteRequest(t, lbls, 1, 2000)
+	_, err = i.Push(ctx, req)
+	require.NoError(t, err)
+
+	// Push an out-of-order sample, which is rejected.
+	req, _, _, _ = mockWriteRequest(t, lbls, 1, 1000)
+	_, err = i.Push(ctx, req)
+	require.Error(t, err)
+
+	// Records are written to the object storage on flush, when the ingester stops.
+	require.NoError(t, services.StopAndAwaitTerminated(context.Background(), i))
+
+	records, err := i.deadLetter.RecentRecords(context.Background(), "test", 10)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "ingester", records[0].Component)
+	assert.Equal(t, reasonSampleOutOfOrder, records[0].Reason)
+	assert.Equal(t, `metric{job="test"}`, records[0].Series)
+	assert.Equal(t, int64(1000), records[0].TimestampMs)
+}
+
+func TestIngester_Push_ShouldReportRejectedSamplesInErrorDetails(t *testing.T) {
+	i, err := prepareIngesterWithBlocksStorage(t, defaultIngesterTestConfig(t), nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(context.Background(), i))
+	})
+
+	// Wait until it's healthy
+	test.Poll(t, 1*time.Second, 1, func() interface{} {
+		return i.lifecycler.HealthyInstancesCount()
+	})
+
+	ctx := user.InjectOrgID(context.Background(), "test")
+	series1 := mimirpb.FromLabelsToLabelAdapters(labels.FromStrings(labels.MetricName, "metric", "job", "1"))
+	series2 := mimirpb.FromLabelsToLabelAdapters(labels.FromStrings(labels.MetricName, "metric", "job", "2"))
+	series3 := mimirpb.FromLabelsToLabelAdapters(labels.FromStrings(labels.MetricName, "metric", "job", "3"))
+
+	req := mimirpb.ToWriteRequest(
+		[][]mimirpb.LabelAdapter{series1, series2},
+		[]mimirpb.Sample{{TimestampMs: 2000, Value: 1}, {TimestampMs: 2000, Value: 1}},
+		nil, nil, mimirpb.API,
+	)
+	_, err = i.Push(ctx, req)
+	require.NoError(t, err)
+
+	// Push out-of-order samples for the first two series, and an in-order sample for a new series.
+	req = &mimirpb.WriteRequest{
+		Timeseries: []mimirpb.PreallocTimeseries{
+			{TimeSeries: &mimirpb.TimeSeries{Labels: series3, Samples: []mimirpb.Sample{{TimestampMs: 1000, Value: 1}}}},
+			{TimeSeries: &mimirpb.TimeSeries{Labels: series1, Samples: []mimirpb.Sample{{TimestampMs: 1000, Value: 1}, {TimestampMs: 1500, Value: 1}, {TimestampMs: 3000, Value: 1}}}},
+			{TimeSeries: &mimirpb.TimeSeries{Labels: series2, Samples: []mimirpb.Sample{{TimestampMs: 1000, Value: 1}}}},
+		},
+	}
+	_, err = i.Push(ctx, req)
+	require.Error(t, err)
+
+	stat, ok := grpcutil.ErrorToStatus(err)
+	require.True(t, ok)
+	checkErrorWithStatusDetails(t, stat.Details(), &mimirpb.ErrorDetails{
+		Cause: mimirpb.BAD_DATA,
+		RejectedSamples: []mimirpb.RejectedSeriesSamples{
+			{SeriesIndex: 1, TimestampsMs: []int64{1000, 1500}},
+			{SeriesIndex: 2, TimestampsMs: []int64{1000}},
+		},
+	})
+}
+
 func TestIngester_Push_DecreaseInactiveSeries(t *testing.T) {
 	metricLabelAdapters := [][]mimirpb.LabelAdapter{{{Name: labels.MetricName, Value: "test"}}}
 	metricLabelAdaptersHist := [][]mimirpb.LabelAdapter{{{Name: labels.MetricName, Value: "test_histogram"}}}
@@ -8839,6 +8929,216 @@ func TestIngesterMetricLimitExceeded(t *testing.T) {
 	testLimits()
 }
 
+func TestIngesterLabelValueSeriesLimitExceeded(t *testing.T) {
+	limits := defaultLimitsTestConfig()
+	limits.LabelValueSeriesLimits = []*validation.LabelValueSeriesLimit{
+		{Name: "path", MetricName: "http_requests_total", LabelName: "path", MaxValues: 2},
+		{Name: "pod", LabelName: "pod", MaxSeriesPerValue: 1},
+	}
+
+	cfg := defaultIngesterTestConfig(t)
+	// Set RF=1 here to ensure the series limits are actually set to 1 instead of 3.
+	cfg.IngesterRing.ReplicationFactor = 1
+
+	registry := prometheus.NewRegistry()
+	ing, err := prepareIngesterWithBlocksStorageAndLimits(t, cfg, limits, nil, "", registry)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), ing))
+	defer services.StopAndAwaitTerminated(context.Background(), ing) //nolint:errcheck
+
+	// Wait until it's healthy
+	test.Poll(t, time.Second, 1, func() interface{} {
+		return ing.lifecycler.HealthyInstancesCount()
+	})
+
+	userID := "1"
+	ctx := user.InjectOrgID(context.Background(), userID)
+	sample := mimirpb.Sample{TimestampMs: 1, Value: 1}
+
+	series := func(metricName, labelName, labelValue string) []mimirpb.LabelAdapter {
+		return []mimirpb.LabelAdapter{{Name: labels.MetricName, Value: metricName}, {Name: labelName, Value: labelValue}}
+	}
+
+	// Two distinct values of "path" are allowed for http_requests_total, the third one is rejected.
+	_, err = ing.Push(ctx, mimirpb.ToWriteRequest([][]mimirpb.LabelAdapter{
+		series("http_requests_total", "path", "/a"),
+		series("http_requests_total", "path", "/b"),
+		series("other_metric", "path", "/c"),
+	}, []mimirpb.Sample{sample, sample, sample}, nil, nil, mimirpb.API))
+	require.NoError(t, err)
+
+	rejected := series("http_requests_total", "path", "/d")
+	_, err = ing.Push(ctx, mimirpb.ToWriteRequest([][]mimirpb.LabelAdapter{rejected}, []mimirpb.Sample{sample}, nil, nil, mimirpb.API))
+	expectedErr := newErrorWithStatus(wrapOrAnnotateWithUser(newPerLabelValueSeriesLimitReachedError(limits.LabelValueSeriesLimits[0], true, rejected), userID), codes.FailedPrecondition)
+	checkErrorWithStatus(t, err, expectedErr)
+
+	// Only one series is allowed for each value of "pod", across all metrics.
+	_, err = ing.Push(ctx, mimirpb.ToWriteRequest([][]mimirpb.LabelAdapter{series("metric_a", "pod", "p1")}, []mimirpb.Sample{sample}, nil, nil, mimirpb.API))
+	require.NoError(t, err)
+
+	rejected = series("metric_b", "pod", "p1")
+	_, err = ing.Push(ctx, mimirpb.ToWriteRequest([][]mimirpb.LabelAdapter{rejected, series("metric_b", "pod", "p2")}, []mimirpb.Sample{sample, sample}, nil, nil, mimirpb.API))
+	expectedErr = newErrorWithStatus(wrapOrAnnotateWithUser(newPerLabelValueSeriesLimitReachedError(limits.LabelValueSeriesLimits[1], false, rejected), userID), codes.FailedPrecondition)
+	checkErrorWithStatus(t, err, expectedErr)
+
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(`
+		# HELP cortex_discarded_samples_total The total number of samples that were discarded.
+		# TYPE cortex_discarded_samples_total counter
+		cortex_discarded_samples_total{group="",reason="per_label_value_series_limit",user="1"} 2
+		# HELP cortex_ingester_label_value_series_limit_discarded_samples_total The total number of samples discarded because creating their series would exceed a label value series limit.
+		# TYPE cortex_ingester_label_value_series_limit_discarded_samples_total counter
+		cortex_ingester_label_value_series_limit_discarded_samples_total{limit="path",user="1"} 1
+		cortex_ingester_label_value_series_limit_discarded_samples_total{limit="pod",user="1"} 1
+	`), "cortex_discarded_samples_total", "cortex_ingester_label_value_series_limit_discarded_samples_total"))
+}
+
+func TestIngesterMemoryLimitExceeded(t *testing.T) {
+	series := func(metricName string) []mimirpb.LabelAdapter {
+		return []mimirpb.LabelAdapter{{Name: labels.MetricName, Value: metricName}, {Name: "pod", Value: "p1"}}
+	}
+	seriesMemory := estimatedSeriesMemoryBytes(mimirpb.FromLabelAdaptersToLabels(series("metric_a")))
+
+	// Allow exactly two series.
+	limits := defaultLimitsTestConfig()
+	limits.MaxIngesterMemoryBytesPerTenant = 2 * seriesMemory
+
+	registry := prometheus.NewRegistry()
+	ing, err := prepareIngesterWithBlocksStorageAndLimits(t, defaultIngesterTestConfig(t), limits, nil, "", registry)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), ing))
+	defer services.StopAndAwaitTerminated(context.Background(), ing) //nolint:errcheck
+
+	// Wait until it's healthy
+	test.Poll(t, time.Second, 1, func() interface{} {
+		return ing.lifecycler.HealthyInstancesCount()
+	})
+
+	userID := "1"
+	ctx := user.InjectOrgID(context.Background(), userID)
+	sample := mimirpb.Sample{TimestampMs: 1, Value: 1}
+
+	_, err = ing.Push(ctx, mimirpb.ToWriteRequest([][]mimirpb.LabelAdapter{series("metric_a"), series("metric_b")}, []mimirpb.Sample{sample, sample}, nil, nil, mimirpb.API))
+	require.NoError(t, err)
+
+	db := ing.getTSDB(userID)
+	require.Equal(t, 2*seriesMemory, db.estimatedMemoryBytes())
+
+	// Appending to existing series is still allowed, while new series are rejected.
+	_, err = ing.Push(ctx, mimirpb.ToWriteRequest([][]mimirpb.LabelAdapter{series("metric_a"), series("metric_c")}, []mimirpb.Sample{{TimestampMs: 2, Value: 2}, sample}, nil, nil, mimirpb.API))
+	expectedErr := newErrorWithStatus(wrapOrAnnotateWithUser(newPerUserMemoryLimitReachedError(limits.MaxIngesterMemoryBytesPerTenant), userID), codes.FailedPrecondition)
+	checkErrorWithStatus(t, err, expectedErr)
+
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(`
+		# HELP cortex_discarded_samples_total The total number of samples that were discarded.
+		# TYPE cortex_discarded_samples_total counter
+		cortex_discarded_samples_total{group="",reason="per_user_memory_limit",user="1"} 1
+	`), "cortex_discarded_samples_total"))
+
+	// Native histograms are accounted for on top of the series.
+	db.updateNativeHistogramsMemoryBytes(1, 10)
+	require.Equal(t, 2*seriesMemory+estimatedNativeHistogramSeriesBytes+10*estimatedNativeHistogramBucketBytes, db.estimatedMemoryBytes())
+	db.updateNativeHistogramsMemoryBytes(0, 0)
+
+	// Once the series are removed from the head, new series are allowed again.
+	ing.compactBlocks(context.Background(), true, math.MaxInt64, nil)
+	require.Equal(t, int64(0), db.estimatedMemoryBytes())
+
+	_, err = ing.Push(ctx, mimirpb.ToWriteRequest([][]mimirpb.LabelAdapter{series("metric_c")}, []mimirpb.Sample{{TimestampMs: 3, Value: 3}}, nil, nil, mimirpb.API))
+	require.NoError(t, err)
+}
+
+func TestIngesterEstimatedMemoryBytes(t *testing.T) {
+	// The labels are created for each request, because the ingester may reuse the memory of the pushed requests.
+	floatSeries := func() labels.Labels {
+		return labels.FromStrings(labels.MetricName, "metric_float", "pod", "p1")
+	}
+	histogramSeries := func() labels.Labels {
+		return labels.FromStrings(labels.MetricName, "metric_histogram", "pod", "p1")
+	}
+
+	limits := defaultLimitsTestConfig()
+	limits.NativeHistogramsIngestionEnabled = true
+
+	ing, err := prepareIngesterWithBlocksStorageAndLimits(t, defaultIngesterTestConfig(t), limits, nil, "", nil)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), ing))
+	defer services.StopAndAwaitTerminated(context.Background(), ing) //nolint:errcheck
+
+	// Wait until it's healthy
+	test.Poll(t, time.Second, 1, func() interface{} {
+		return ing.lifecycler.HealthyInstancesCount()
+	})
+
+	userID := "1"
+	ctx := user.InjectOrgID(context.Background(), userID)
+
+	_, err = ing.Push(ctx, writeRequestSingleSeries(floatSeries(), []mimirpb.Sample{{TimestampMs: 1, Value: 1}}))
+	require.NoError(t, err)
+
+	db := ing.getTSDB(userID)
+	expectedSeriesBytes := estimatedSeriesMemoryBytes(floatSeries())
+	require.Equal(t, expectedSeriesBytes, db.estimatedMemoryBytes())
+
+	// The new native histogram series are accounted on push, without waiting for the active series update.
+	histogram := util_test.GenerateTestHistogram(1)
+	numBuckets := 0
+	for _, span := range append(histogram.PositiveSpans, histogram.NegativeSpans...) {
+		numBuckets += int(span.Length)
+	}
+	_, err = ing.Push(ctx, mockHistogramWriteRequest(histogramSeries(), 1, 1, false))
+	require.NoError(t, err)
+
+	expectedSeriesBytes += estimatedSeriesMemoryBytes(histogramSeries()) + estimatedNativeHistogramSeriesBytes + int64(numBuckets)*estimatedNativeHistogramBucketBytes
+	require.Equal(t, expectedSeriesBytes, db.estimatedMemoryBytes())
+
+	// Appending to an existing native histogram series doesn't account it again.
+	_, err = ing.Push(ctx, mockHistogramWriteRequest(histogramSeries(), 2, 1, false))
+	require.NoError(t, err)
+	require.Equal(t, expectedSeriesBytes, db.estimatedMemoryBytes())
+
+	// The active series update gives the same estimate.
+	ing.updateActiveSeries(time.Now())
+	require.Equal(t, expectedSeriesBytes, db.estimatedMemoryBytes())
+
+	// Cut several chunks of the float series: each chunk besides the open head chunk of the series is accounted.
+	samples := make([]mimirpb.Sample, 0, 1000)
+	for ts := int64(2); ts < 1000; ts++ {
+		samples = append(samples, mimirpb.Sample{TimestampMs: ts, Value: float64(ts)})
+	}
+	_, err = ing.Push(ctx, writeRequestSingleSeries(floatSeries(), samples))
+	require.NoError(t, err)
+
+	numChunks := countHeadChunks(t, db)
+	require.Greater(t, numChunks, 2)
+
+	expectedChunksBytes := int64(numChunks-2) * estimatedHeadChunkBytes
+	require.Equal(t, expectedChunksBytes, db.headChunksMemoryBytes())
+	require.Equal(t, expectedSeriesBytes+expectedChunksBytes, db.estimatedMemoryBytes())
+}
+
+// countHeadChunks returns the number of in-order chunks of all the series in the TSDB Head.
+func countHeadChunks(t *testing.T, db *userTSDB) int {
+	idx, err := db.Head().Index()
+	require.NoError(t, err)
+	defer idx.Close()
+
+	name, value := index.AllPostingsKey()
+	postings, err := idx.Postings(context.Background(), name, value)
+	require.NoError(t, err)
+
+	var (
+		builder labels.ScratchBuilder
+		chks    []chunks.Meta
+		count   int
+	)
+	for postings.Next() {
+		require.NoError(t, idx.Series(postings.At(), &builder, &chks))
+		count += len(chks)
+	}
+	require.NoError(t, postings.Err())
+	return count
+}
+
 // Construct a set of realistic-looking samples, all with slightly different label sets
 func benchmarkData(nSeries int) (allLabels [][]mimirpb.LabelAdapter, allSamples []mimirpb.Sample) {
 	// Real example from Kubernetes' embedded cAdvisor metrics, lightly obfuscated.
@@ -11667,3 +11967,70 @@ var ingesterSampleTypeScenarios = map[string]struct {
 		},
 	},
 }
+
+func TestIngester_PerTenantTSDBBlockRangeAndRetention(t *testing.T) {
+	const otherUserID = "other"
+
+	cfg := defaultIngesterTestConfig(t)
+	cfg.BlocksStorageConfig.TSDB.HeadCompactionIdleTimeout = 0
+
+	limits := map[string]*validation.Limits{
+		userID: func() *validation.Limits {
+			l := defaultLimitsTestConfig()
+			l.IngesterTSDBBlockRangePeriod = model.Duration(time.Hour)
+			l.IngesterTSDBHeadCompactionIdleTimeout = model.Duration(10 * time.Minute)
+			l.IngesterTSDBRetentionPeriod = model.Duration(3 * time.Hour)
+			return &l
+		}(),
+	}
+	overrides, err := validation.NewOverrides(defaultLimitsTestConfig(), validation.NewMockTenantLimits(limits))
+	require.NoError(t, err)
+
+	i, err := prepareIngesterWithBlockStorageAndOverrides(t, cfg, overrides, nil, "", "", nil)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(context.Background(), i))
+	})
+
+	// Push samples spanning 4 hours for both tenants, aligned to the default 2h block range.
+	startTime := time.Now().Add(-6 * time.Hour).Truncate(2 * time.Hour)
+	series := labels.FromStrings(model.MetricNameLabel, "test_metric")
+	for _, u := range []string{userID, otherUserID} {
+		ctx := user.InjectOrgID(context.Background(), u)
+		for ts := startTime; ts.Before(startTime.Add(4 * time.Hour)); ts = ts.Add(10 * time.Minute) {
+			req, _, _, _ := mockWriteRequest(t, series, 1, ts.UnixMilli())
+			_, err := i.Push(ctx, req)
+			require.NoError(t, err)
+		}
+	}
+
+	db := i.getTSDB(userID)
+	require.NotNil(t, db)
+	assert.Equal(t, time.Hour, db.blockRange)
+	assert.Equal(t, 3*time.Hour, db.blockMinRetention)
+	assert.Equal(t, 10*time.Minute, i.compactionIdleTimeoutForUser(userID))
+
+	otherDB := i.getTSDB(otherUserID)
+	require.NotNil(t, otherDB)
+	assert.Equal(t, cfg.BlocksStorageConfig.TSDB.BlockRanges[0], otherDB.blockRange)
+	assert.Equal(t, cfg.BlocksStorageConfig.TSDB.Retention, otherDB.blockMinRetention)
+	assert.Equal(t, time.Duration(0), i.compactionIdleTimeoutForUser(otherUserID))
+
+	// Forced compaction cuts blocks with the tenant's block range.
+	i.compactBlocks(context.Background(), true, math.MaxInt64, nil)
+
+	for u, expected := range map[string]struct {
+		numBlocks  int
+		blockRange time.Duration
+	}{
+		userID:      {numBlocks: 4, blockRange: time.Hour},
+		otherUserID: {numBlocks: 2, blockRange: 2 * time.Hour},
+	} {
+		blocks := i.getTSDB(u).Blocks()
+		require.Len(t, blocks, expected.numBlocks, "user: %s", u)
+		for _, b := range blocks {
+			assert.LessOrEqual(t, b.MaxTime()-b.MinTime(), expected.blockRange.Milliseconds(), "user: %s", u)
+		}
+	}
+}
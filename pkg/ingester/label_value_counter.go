@@ -0,0 +1,174 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/segmentio/fasthash/fnv1a"
+
+	"github.com/grafana/mimir/pkg/util/globalerror"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+const numLabelValueCounterShards = 16
+
+// labelValueCounterKey identifies the series tracked by a label value series limit. Limits with the
+// same metric name and label name share the same counts.
+type labelValueCounterKey struct {
+	metricName string
+	labelName  string
+}
+
+type labelValueCounterShard struct {
+	mtx sync.Mutex
+	m   map[labelValueCounterKey]map[string]int
+}
+
+// labelValueCounter tracks the number of in-memory series for each value of the labels referenced
+// by the tenant's label value series limits.
+//
+// Series are only tracked for the limits configured when they're created, so a newly added limit
+// doesn't account for the series created before it.
+type labelValueCounter struct {
+	limiter *Limiter
+	shards  []labelValueCounterShard
+}
+
+func newLabelValueCounter(limiter *Limiter) *labelValueCounter {
+	shards := make([]labelValueCounterShard, 0, numLabelValueCounterShards)
+	for i := 0; i < numLabelValueCounterShards; i++ {
+		shards = append(shards, labelValueCounterShard{
+			m: map[labelValueCounterKey]map[string]int{},
+		})
+	}
+	return &labelValueCounter{
+		limiter: limiter,
+		shards:  shards,
+	}
+}
+
+func (c *labelValueCounter) getShard(key labelValueCounterKey) *labelValueCounterShard {
+	h := fnv1a.AddString64(fnv1a.HashString64(key.metricName), key.labelName)
+	return &c.shards[hashFP(model.Fingerprint(h))%numLabelValueCounterShards]
+}
+
+func (c *labelValueCounter) limitsFor(userID string) []*validation.LabelValueSeriesLimit {
+	if c.limiter == nil {
+		return nil
+	}
+	return c.limiter.limits.LabelValueSeriesLimits(userID)
+}
+
+// canAddSeriesFor returns the first label value series limit which would be exceeded by creating the
+// input series, or nil if none would be exceeded. The returned bool is true if the limit on the
+// number of distinct values has been reached, false if it's the limit on series per value.
+func (c *labelValueCounter) canAddSeriesFor(userID, metricName string, series labels.Labels) (*validation.LabelValueSeriesLimit, bool) {
+	for _, limit := range c.limitsFor(userID) {
+		if limit.MetricName != "" && limit.MetricName != metricName {
+			continue
+		}
+		value := series.Get(limit.LabelName)
+		if value == "" {
+			continue
+		}
+
+		key := labelValueCounterKey{metricName: limit.MetricName, labelName: limit.LabelName}
+		shard := c.getShard(key)
+		shard.mtx.Lock()
+		values := shard.m[key]
+		seriesForValue := values[value]
+		shard.mtx.Unlock()
+
+		if seriesForValue == 0 && !c.limiter.IsWithinMaxValuesPerLabel(limit, len(values)) {
+			return limit, true
+		}
+		if !c.limiter.IsWithinMaxSeriesPerLabelValue(userID, limit, seriesForValue) {
+			return limit, false
+		}
+	}
+	return nil, false
+}
+
+func (c *labelValueCounter) increaseSeriesFor(userID, metricName string, series labels.Labels) {
+	c.updateSeriesFor(userID, metricName, series, func(values map[string]int, value string) {
+		values[value]++
+	})
+}
+
+func (c *labelValueCounter) decreaseSeriesFor(userID, metricName string, series labels.Labels) {
+	c.updateSeriesFor(userID, metricName, series, func(values map[string]int, value string) {
+		// The series may have been created before the limit was configured.
+		if values[value] <= 1 {
+			delete(values, value)
+			return
+		}
+		values[value]--
+	})
+}
+
+func (c *labelValueCounter) updateSeriesFor(userID, metricName string, series labels.Labels, update func(values map[string]int, value string)) {
+	limits := c.limitsFor(userID)
+	if len(limits) == 0 {
+		return
+	}
+
+	// Multiple limits can share the same key, but the series must be counted once per key.
+	updated := make([]labelValueCounterKey, 0, len(limits))
+
+	for _, limit := range limits {
+		if limit.MetricName != "" && limit.MetricName != metricName {
+			continue
+		}
+		value := series.Get(limit.LabelName)
+		if value == "" {
+			continue
+		}
+
+		key := labelValueCounterKey{metricName: limit.MetricName, labelName: limit.LabelName}
+		if containsLabelValueCounterKey(updated, key) {
+			continue
+		}
+		updated = append(updated, key)
+
+		shard := c.getShard(key)
+		shard.mtx.Lock()
+		values, ok := shard.m[key]
+		if !ok {
+			values = map[string]int{}
+			shard.m[key] = values
+		}
+		update(values, value)
+		if len(values) == 0 {
+			delete(shard.m, key)
+		}
+		shard.mtx.Unlock()
+	}
+}
+
+func containsLabelValueCounterKey(keys []labelValueCounterKey, key labelValueCounterKey) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// labelValueSeriesLimitExceededError is returned by userTSDB.PreCreation when creating a series would
+// exceed one of the tenant's label value series limits.
+type labelValueSeriesLimitExceededError struct {
+	limit             *validation.LabelValueSeriesLimit
+	maxValuesExceeded bool
+}
+
+func (e labelValueSeriesLimitExceededError) Error() string {
+	return fmt.Sprintf("%s: %s", globalerror.MaxSeriesPerLabelValue, e.limit.Name)
+}
+
+func (e labelValueSeriesLimitExceededError) Unwrap() error {
+	return globalerror.MaxSeriesPerLabelValue
+}
@@ -0,0 +1,95 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestLabelValueCounter(t *testing.T) {
+	const userID = "test"
+
+	pathLimit := &validation.LabelValueSeriesLimit{Name: "path", MetricName: "http_requests_total", LabelName: "path", MaxValues: 2}
+	podLimit := &validation.LabelValueSeriesLimit{Name: "pod", LabelName: "pod", MaxSeriesPerValue: 2}
+
+	overrides, err := validation.NewOverrides(validation.Limits{
+		LabelValueSeriesLimits: []*validation.LabelValueSeriesLimit{pathLimit, podLimit},
+	}, nil)
+	require.NoError(t, err)
+
+	ring := &ringCountMock{instancesCount: 1, zonesCount: 1}
+	limiter := NewLimiter(overrides, newIngesterRingLimiterStrategy(ring, 1, false, "", overrides.IngestionTenantShardSize))
+	counter := newLabelValueCounter(limiter)
+
+	add := func(series labels.Labels) (*validation.LabelValueSeriesLimit, bool) {
+		metricName := series.Get(labels.MetricName)
+		limit, maxValuesExceeded := counter.canAddSeriesFor(userID, metricName, series)
+		if limit == nil {
+			counter.increaseSeriesFor(userID, metricName, series)
+		}
+		return limit, maxValuesExceeded
+	}
+
+	t.Run("distinct values limit", func(t *testing.T) {
+		limit, _ := add(labels.FromStrings(labels.MetricName, "http_requests_total", "path", "/a"))
+		assert.Nil(t, limit)
+		limit, _ = add(labels.FromStrings(labels.MetricName, "http_requests_total", "path", "/b"))
+		assert.Nil(t, limit)
+
+		// An existing value can still get new series.
+		limit, _ = add(labels.FromStrings(labels.MetricName, "http_requests_total", "path", "/b", "method", "POST"))
+		assert.Nil(t, limit)
+
+		// Other metrics are not affected.
+		limit, _ = add(labels.FromStrings(labels.MetricName, "other", "path", "/c"))
+		assert.Nil(t, limit)
+
+		limit, maxValuesExceeded := add(labels.FromStrings(labels.MetricName, "http_requests_total", "path", "/c"))
+		assert.Equal(t, pathLimit, limit)
+		assert.True(t, maxValuesExceeded)
+
+		// Deleting all series of a value makes room for a new one.
+		counter.decreaseSeriesFor(userID, "http_requests_total", labels.FromStrings(labels.MetricName, "http_requests_total", "path", "/a"))
+		limit, _ = add(labels.FromStrings(labels.MetricName, "http_requests_total", "path", "/c"))
+		assert.Nil(t, limit)
+	})
+
+	t.Run("series per value limit", func(t *testing.T) {
+		limit, _ := add(labels.FromStrings(labels.MetricName, "a", "pod", "p1"))
+		assert.Nil(t, limit)
+		limit, _ = add(labels.FromStrings(labels.MetricName, "b", "pod", "p1"))
+		assert.Nil(t, limit)
+
+		limit, maxValuesExceeded := add(labels.FromStrings(labels.MetricName, "c", "pod", "p1"))
+		assert.Equal(t, podLimit, limit)
+		assert.False(t, maxValuesExceeded)
+
+		// Series without the label are not limited.
+		limit, _ = add(labels.FromStrings(labels.MetricName, "c"))
+		assert.Nil(t, limit)
+
+		limit, _ = add(labels.FromStrings(labels.MetricName, "c", "pod", "p2"))
+		assert.Nil(t, limit)
+	})
+
+	t.Run("decreasing series not tracked is a no-op", func(t *testing.T) {
+		counter.decreaseSeriesFor(userID, "a", labels.FromStrings(labels.MetricName, "a", "pod", "unknown"))
+
+		limit, _ := add(labels.FromStrings(labels.MetricName, "c", "pod", "unknown"))
+		assert.Nil(t, limit)
+	})
+}
+
+func TestLabelValueCounter_NilLimiter(t *testing.T) {
+	counter := newLabelValueCounter(nil)
+	series := labels.FromStrings(labels.MetricName, "a", "pod", "p1")
+
+	counter.increaseSeriesFor("test", "a", series)
+	counter.decreaseSeriesFor("test", "a", series)
+}
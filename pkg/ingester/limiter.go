@@ -12,12 +12,15 @@ import (
 
 	"github.com/grafana/mimir/pkg/util"
 	util_math "github.com/grafana/mimir/pkg/util/math"
+	"github.com/grafana/mimir/pkg/util/validation"
 )
 
 // limiterTenantLimits provides access to limits used by Limiter.
 type limiterTenantLimits interface {
 	MaxGlobalSeriesPerUser(userID string) int
 	MaxGlobalSeriesPerMetric(userID string) int
+	LabelValueSeriesLimits(userID string) []*validation.LabelValueSeriesLimit
+	MaxIngesterMemoryBytesPerTenant(userID string) int64
 	MaxGlobalMetadataPerMetric(userID string) int
 	MaxGlobalMetricsWithMetadataPerUser(userID string) int
 	MaxGlobalExemplarsPerUser(userID string) int
@@ -45,6 +48,23 @@ func (l *Limiter) IsWithinMaxSeriesPerMetric(userID string, series int) bool {
 	return series < actualLimit
 }
 
+// IsWithinMaxSeriesPerLabelValue returns true if the series limit of the given label value limit has not been
+// reached compared to the current number of series with the same label value in input; otherwise returns false.
+func (l *Limiter) IsWithinMaxSeriesPerLabelValue(userID string, limit *validation.LabelValueSeriesLimit, series int) bool {
+	actualLimit := l.maxSeriesPerLabelValue(userID, limit)
+	return series < actualLimit
+}
+
+// IsWithinMaxValuesPerLabel returns true if the distinct values limit of the given label value limit has not been
+// reached compared to the current number of distinct label values in input; otherwise returns false.
+func (l *Limiter) IsWithinMaxValuesPerLabel(limit *validation.LabelValueSeriesLimit, values int) bool {
+	// A label value can have series on any ingester, so the limit is not divided between ingesters.
+	if limit.MaxValues <= 0 {
+		return true
+	}
+	return values < limit.MaxValues
+}
+
 // IsWithinMaxMetadataPerMetric returns true if limit has not been reached compared to the current
 // number of metadata per metric in input; otherwise returns false.
 func (l *Limiter) IsWithinMaxMetadataPerMetric(userID string, metadata int) bool {
@@ -59,6 +79,14 @@ func (l *Limiter) IsWithinMaxSeriesPerUser(userID string, series int, minLocalLi
 	return series < actualLimit
 }
 
+// IsWithinMaxMemoryPerUser returns true if limit has not been reached compared to the current
+// estimated memory in input; otherwise returns false.
+func (l *Limiter) IsWithinMaxMemoryPerUser(userID string, memoryBytes int64) bool {
+	// The estimated memory is tracked by each ingester, so the limit is not divided between ingesters.
+	actualLimit := l.limits.MaxIngesterMemoryBytesPerTenant(userID)
+	return actualLimit <= 0 || memoryBytes < actualLimit
+}
+
 // IsWithinMaxMetricsWithMetadataPerUser returns true if limit has not been reached compared to the current
 // number of metrics with metadata in input; otherwise returns false.
 func (l *Limiter) IsWithinMaxMetricsWithMetadataPerUser(userID string, metrics int) bool {
@@ -70,6 +98,10 @@ func (l *Limiter) maxSeriesPerMetric(userID string) int {
 	return l.convertGlobalToLocalLimitOrUnlimited(userID, l.limits.MaxGlobalSeriesPerMetric, 0)
 }
 
+func (l *Limiter) maxSeriesPerLabelValue(userID string, limit *validation.LabelValueSeriesLimit) int {
+	return l.convertGlobalToLocalLimitOrUnlimited(userID, func(string) int { return limit.MaxSeriesPerValue }, 0)
+}
+
 func (l *Limiter) maxMetadataPerMetric(userID string) int {
 	return l.convertGlobalToLocalLimitOrUnlimited(userID, l.limits.MaxGlobalMetadataPerMetric, 0)
 }
@@ -510,6 +510,84 @@ func TestLimiter_IsWithinMaxSeriesPerMetric(t *testing.T) {
 	}
 }
 
+func TestLimiter_IsWithinMaxSeriesPerLabelValue(t *testing.T) {
+	tests := map[string]struct {
+		maxSeriesPerValue     int
+		ringReplicationFactor int
+		ringIngesterCount     int
+		series                int
+		expected              bool
+	}{
+		"limit is disabled": {
+			maxSeriesPerValue:     0,
+			ringReplicationFactor: 1,
+			ringIngesterCount:     1,
+			series:                100,
+			expected:              true,
+		},
+		"current number of series is below the limit": {
+			maxSeriesPerValue:     1000,
+			ringReplicationFactor: 3,
+			ringIngesterCount:     10,
+			series:                299,
+			expected:              true,
+		},
+		"current number of series is above the limit": {
+			maxSeriesPerValue:     1000,
+			ringReplicationFactor: 3,
+			ringIngesterCount:     10,
+			series:                300,
+			expected:              false,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			// Mock the ring
+			ring := &ringCountMock{instancesCount: testData.ringIngesterCount, zonesCount: 1}
+
+			// Mock limits
+			limit := &validation.LabelValueSeriesLimit{Name: "test", LabelName: "path", MaxSeriesPerValue: testData.maxSeriesPerValue}
+			limits, err := validation.NewOverrides(validation.Limits{
+				LabelValueSeriesLimits: []*validation.LabelValueSeriesLimit{limit},
+			}, nil)
+			require.NoError(t, err)
+
+			strategy := newIngesterRingLimiterStrategy(ring, testData.ringReplicationFactor, false, "", limits.IngestionTenantShardSize)
+			limiter := NewLimiter(limits, strategy)
+			actual := limiter.IsWithinMaxSeriesPerLabelValue("test", limit, testData.series)
+
+			assert.Equal(t, testData.expected, actual)
+		})
+	}
+}
+
+func TestLimiter_IsWithinMaxValuesPerLabel(t *testing.T) {
+	limiter := NewLimiter(nil, nil)
+
+	// The limit is not divided between ingesters.
+	assert.True(t, limiter.IsWithinMaxValuesPerLabel(&validation.LabelValueSeriesLimit{MaxValues: 0}, 1000))
+	assert.True(t, limiter.IsWithinMaxValuesPerLabel(&validation.LabelValueSeriesLimit{MaxValues: 10}, 9))
+	assert.False(t, limiter.IsWithinMaxValuesPerLabel(&validation.LabelValueSeriesLimit{MaxValues: 10}, 10))
+}
+
+func TestLimiter_IsWithinMaxMemoryPerUser(t *testing.T) {
+	limits, err := validation.NewOverrides(validation.Limits{MaxIngesterMemoryBytesPerTenant: 1000}, nil)
+	require.NoError(t, err)
+
+	// The limit is not divided between ingesters.
+	limiter := NewLimiter(limits, nil)
+	assert.True(t, limiter.IsWithinMaxMemoryPerUser("test", 999))
+	assert.False(t, limiter.IsWithinMaxMemoryPerUser("test", 1000))
+
+	// The limit is disabled.
+	limits, err = validation.NewOverrides(validation.Limits{MaxIngesterMemoryBytesPerTenant: 0}, nil)
+	require.NoError(t, err)
+
+	limiter = NewLimiter(limits, nil)
+	assert.True(t, limiter.IsWithinMaxMemoryPerUser("test", math.MaxInt64))
+}
+
 func TestLimiter_IsWithinMaxSeriesPerMetric_WithPartitionsRing(t *testing.T) {
 	tests := map[string]struct {
 		maxGlobalSeriesPerMetric int
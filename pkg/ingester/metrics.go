@@ -64,6 +64,9 @@ type ingesterMetrics struct {
 	// Head compactions metrics.
 	compactionsTriggered   prometheus.Counter
 	compactionsFailed      prometheus.Counter
+	earlyCompactions       *prometheus.CounterVec
+	heapInuseBytes         prometheus.Gauge
+	residentMemoryBytes    prometheus.Gauge
 	appenderAddDuration    prometheus.Histogram
 	appenderCommitDuration prometheus.Histogram
 	idleTsdbChecks         *prometheus.CounterVec
@@ -74,6 +77,9 @@ type ingesterMetrics struct {
 	discarded *discardedMetrics
 	rejected  *prometheus.CounterVec
 
+	// Samples discarded by label value series limits, by limit name.
+	discardedSamplesPerLabelValueSeriesLimit *prometheus.CounterVec
+
 	// Discarded metadata
 	discardedMetadataPerUserMetadataLimit   *prometheus.CounterVec
 	discardedMetadataPerMetricMetadataLimit *prometheus.CounterVec
@@ -344,6 +350,18 @@ func newIngesterMetrics(
 			Name: "cortex_ingester_tsdb_compactions_failed_total",
 			Help: "Total number of compactions that failed.",
 		}),
+		earlyCompactions: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_ingester_tsdb_early_head_compactions_total",
+			Help: "Total number of per-tenant TSDB Head compactions triggered before the end of the block range, by reason.",
+		}, []string{"reason"}),
+		heapInuseBytes: promauto.With(r).NewGauge(prometheus.GaugeOpts{
+			Name: "cortex_ingester_tsdb_early_head_compaction_heap_inuse_bytes",
+			Help: "The Go heap in use, last observed when checking if the TSDB Heads should be early compacted.",
+		}),
+		residentMemoryBytes: promauto.With(r).NewGauge(prometheus.GaugeOpts{
+			Name: "cortex_ingester_tsdb_early_head_compaction_resident_memory_bytes",
+			Help: "The resident memory of the process, last observed when checking if the TSDB Heads should be early compacted.",
+		}),
 		appenderAddDuration: promauto.With(r).NewHistogram(prometheus.HistogramOpts{
 			Name:    "cortex_ingester_tsdb_appender_add_duration_seconds",
 			Help:    "The total time it takes for a push request to add samples to the TSDB appender.",
@@ -368,6 +386,11 @@ func newIngesterMetrics(
 			Help: "Requests rejected for hitting per-instance limits",
 		}, []string{"reason"}),
 
+		discardedSamplesPerLabelValueSeriesLimit: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_ingester_label_value_series_limit_discarded_samples_total",
+			Help: "The total number of samples discarded because creating their series would exceed a label value series limit.",
+		}, []string{"user", "limit"}),
+
 		discardedMetadataPerUserMetadataLimit:   validation.DiscardedMetadataCounter(r, perUserMetadataLimit),
 		discardedMetadataPerMetricMetadataLimit: validation.DiscardedMetadataCounter(r, perMetricMetadataLimit),
 
@@ -396,6 +419,7 @@ func (m *ingesterMetrics) deletePerUserMetrics(userID string) {
 	filter := prometheus.Labels{"user": userID}
 	m.discarded.DeletePartialMatch(filter)
 
+	m.discardedSamplesPerLabelValueSeriesLimit.DeletePartialMatch(filter)
 	m.discardedMetadataPerUserMetadataLimit.DeleteLabelValues(userID)
 	m.discardedMetadataPerMetricMetadataLimit.DeleteLabelValues(userID)
 
@@ -420,26 +444,30 @@ func (m *ingesterMetrics) deletePerUserCustomTrackerMetrics(userID string, custo
 }
 
 type discardedMetrics struct {
-	sampleTimestampTooOld  *prometheus.CounterVec
-	sampleOutOfOrder       *prometheus.CounterVec
-	sampleTooOld           *prometheus.CounterVec
-	sampleTooFarInFuture   *prometheus.CounterVec
-	newValueForTimestamp   *prometheus.CounterVec
-	perUserSeriesLimit     *prometheus.CounterVec
-	perMetricSeriesLimit   *prometheus.CounterVec
-	invalidNativeHistogram *prometheus.CounterVec
+	sampleTimestampTooOld    *prometheus.CounterVec
+	sampleOutOfOrder         *prometheus.CounterVec
+	sampleTooOld             *prometheus.CounterVec
+	sampleTooFarInFuture     *prometheus.CounterVec
+	newValueForTimestamp     *prometheus.CounterVec
+	perUserSeriesLimit       *prometheus.CounterVec
+	perUserMemoryLimit       *prometheus.CounterVec
+	perMetricSeriesLimit     *prometheus.CounterVec
+	perLabelValueSeriesLimit *prometheus.CounterVec
+	invalidNativeHistogram   *prometheus.CounterVec
 }
 
 func newDiscardedMetrics(r prometheus.Registerer) *discardedMetrics {
 	return &discardedMetrics{
-		sampleTimestampTooOld:  validation.DiscardedSamplesCounter(r, reasonSampleTimestampTooOld),
-		sampleOutOfOrder:       validation.DiscardedSamplesCounter(r, reasonSampleOutOfOrder),
-		sampleTooOld:           validation.DiscardedSamplesCounter(r, reasonSampleTooOld),
-		sampleTooFarInFuture:   validation.DiscardedSamplesCounter(r, reasonSampleTooFarInFuture),
-		newValueForTimestamp:   validation.DiscardedSamplesCounter(r, reasonNewValueForTimestamp),
-		perUserSeriesLimit:     validation.DiscardedSamplesCounter(r, reasonPerUserSeriesLimit),
-		perMetricSeriesLimit:   validation.DiscardedSamplesCounter(r, reasonPerMetricSeriesLimit),
-		invalidNativeHistogram: validation.DiscardedSamplesCounter(r, reasonInvalidNativeHistogram),
+		sampleTimestampTooOld:    validation.DiscardedSamplesCounter(r, reasonSampleTimestampTooOld),
+		sampleOutOfOrder:         validation.DiscardedSamplesCounter(r, reasonSampleOutOfOrder),
+		sampleTooOld:             validation.DiscardedSamplesCounter(r, reasonSampleTooOld),
+		sampleTooFarInFuture:     validation.DiscardedSamplesCounter(r, reasonSampleTooFarInFuture),
+		newValueForTimestamp:     validation.DiscardedSamplesCounter(r, reasonNewValueForTimestamp),
+		perUserSeriesLimit:       validation.DiscardedSamplesCounter(r, reasonPerUserSeriesLimit),
+		perUserMemoryLimit:       validation.DiscardedSamplesCounter(r, reasonPerUserMemoryLimit),
+		perMetricSeriesLimit:     validation.DiscardedSamplesCounter(r, reasonPerMetricSeriesLimit),
+		perLabelValueSeriesLimit: validation.DiscardedSamplesCounter(r, reasonPerLabelValueSeriesLimit),
+		invalidNativeHistogram:   validation.DiscardedSamplesCounter(r, reasonInvalidNativeHistogram),
 	}
 }
 
@@ -450,7 +478,9 @@ func (m *discardedMetrics) DeletePartialMatch(filter prometheus.Labels) {
 	m.sampleTooFarInFuture.DeletePartialMatch(filter)
 	m.newValueForTimestamp.DeletePartialMatch(filter)
 	m.perUserSeriesLimit.DeletePartialMatch(filter)
+	m.perUserMemoryLimit.DeletePartialMatch(filter)
 	m.perMetricSeriesLimit.DeletePartialMatch(filter)
+	m.perLabelValueSeriesLimit.DeletePartialMatch(filter)
 	m.invalidNativeHistogram.DeletePartialMatch(filter)
 }
 
@@ -461,7 +491,9 @@ func (m *discardedMetrics) DeleteLabelValues(userID string, group string) {
 	m.sampleTooFarInFuture.DeleteLabelValues(userID, group)
 	m.newValueForTimestamp.DeleteLabelValues(userID, group)
 	m.perUserSeriesLimit.DeleteLabelValues(userID, group)
+	m.perUserMemoryLimit.DeleteLabelValues(userID, group)
 	m.perMetricSeriesLimit.DeleteLabelValues(userID, group)
+	m.perLabelValueSeriesLimit.DeleteLabelValues(userID, group)
 	m.invalidNativeHistogram.DeleteLabelValues(userID, group)
 }
 
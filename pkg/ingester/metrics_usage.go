@@ -0,0 +1,29 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"github.com/grafana/mimir/pkg/ingester/client"
+	"github.com/grafana/mimir/pkg/storage/tsdb/metricsusage"
+)
+
+// metricsUsageQueryStreamServer records the metric names of the series sent to the querier.
+type metricsUsageQueryStreamServer struct {
+	client.Ingester_QueryStreamServer
+
+	recorder *metricsusage.Recorder
+}
+
+func (s *metricsUsageQueryStreamServer) Send(resp *client.QueryStreamResponse) error {
+	for _, series := range resp.Timeseries {
+		s.recorder.Add(series.Labels)
+	}
+	for _, series := range resp.Chunkseries {
+		s.recorder.Add(series.Labels)
+	}
+	for _, series := range resp.StreamingSeries {
+		s.recorder.Add(series.Labels)
+	}
+
+	return s.Ingester_QueryStreamServer.Send(resp)
+}
@@ -0,0 +1,69 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/services"
+	"github.com/grafana/dskit/test"
+	"github.com/grafana/dskit/user"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore/providers/filesystem"
+
+	"github.com/grafana/mimir/pkg/ingester/client"
+	"github.com/grafana/mimir/pkg/storage/tsdb/metricsusage"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestIngester_QueryStream_ShouldTrackMetricsUsage(t *testing.T) {
+	cfg := defaultIngesterTestConfig(t)
+	cfg.BlocksStorageConfig.MetricsUsageTrackingEnabled = true
+	cfg.BlocksStorageConfig.MetricsUsageFlushInterval = time.Hour
+
+	overrides, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
+	require.NoError(t, err)
+
+	bucketDir := t.TempDir()
+	i, err := prepareIngesterWithBlockStorageAndOverrides(t, cfg, overrides, nil, "", bucketDir, nil)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+
+	// Wait until it's healthy.
+	test.Poll(t, 1*time.Second, 1, func() interface{} {
+		return i.lifecycler.HealthyInstancesCount()
+	})
+
+	ctx := user.InjectOrgID(context.Background(), userID)
+	for _, metricName := range []string{"metric_1", "metric_2"} {
+		req, _, _, _ := mockWriteRequest(t, labels.FromStrings(model.MetricNameLabel, metricName), 1, 100)
+		_, err = i.Push(ctx, req)
+		require.NoError(t, err)
+	}
+
+	before := time.Now()
+	err = i.QueryStream(&client.QueryRequest{
+		StartTimestampMs: math.MinInt64,
+		EndTimestampMs:   math.MaxInt64,
+		Matchers:         []*client.LabelMatcher{{Type: client.EQUAL, Name: model.MetricNameLabel, Value: "metric_1"}},
+	}, &mockQueryStreamServer{ctx: ctx})
+	require.NoError(t, err)
+
+	// The metrics usage is stored when the ingester stops.
+	require.NoError(t, services.StopAndAwaitTerminated(context.Background(), i))
+
+	bkt, err := filesystem.NewBucket(bucketDir)
+	require.NoError(t, err)
+
+	usage, err := metricsusage.ReadUsage(context.Background(), bkt, userID, nil, log.NewNopLogger())
+	require.NoError(t, err)
+	require.Len(t, usage.Metrics, 1)
+	assert.GreaterOrEqual(t, usage.Metrics["metric_1"], before.UnixMilli())
+}
@@ -0,0 +1,375 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	readAdmissionReasonTenantQueries  = "tenant_concurrent_queries"
+	readAdmissionReasonTenantSeries   = "tenant_inflight_series"
+	readAdmissionReasonQuerySeries    = "query_series"
+	readAdmissionReasonIngesterQuery  = "ingester_concurrent_queries"
+	readAdmissionReasonContextExpired = "context_expired"
+)
+
+type readAdmissionLimits interface {
+	MaxIngesterConcurrentQueries(userID string) int
+	MaxIngesterInflightQuerySeries(userID string) int
+}
+
+// readAdmissionController limits the number of queries each tenant runs concurrently and the number of
+// series their streaming queries hold in memory, as well as the total number of concurrent queries.
+// Requests which can't be admitted immediately wait in a per-tenant FIFO queue, and the queues of the
+// tenants are served in round-robin order, so that a tenant with many queued queries can't starve
+// the others. Requests not admitted within the queue timeout are rejected.
+type readAdmissionController struct {
+	limits       readAdmissionLimits
+	maxQueries   int
+	queueTimeout time.Duration
+
+	mtx     sync.Mutex
+	running int
+	tenants map[string]*readAdmissionTenant
+	// waiting holds the tenants with queued requests, in round-robin order.
+	waiting []string
+	next    int
+
+	queued       prometheus.Gauge
+	waitDuration prometheus.Histogram
+	rejected     *prometheus.CounterVec
+}
+
+type readAdmissionTenant struct {
+	queries int
+	series  int
+	queue   []*readAdmissionRequest
+	// queuedHeldSeries is the number of series held by the queries whose requests are queued.
+	queuedHeldSeries int
+}
+
+type readAdmissionRequest struct {
+	queries  int
+	series   int
+	admitted chan struct{}
+	// heldSeries is the number of series already held by the query waiting for the request.
+	heldSeries int
+}
+
+func newReadAdmissionController(limits readAdmissionLimits, maxQueries int, queueTimeout time.Duration, reg prometheus.Registerer) *readAdmissionController {
+	return &readAdmissionController{
+		limits:       limits,
+		maxQueries:   maxQueries,
+		queueTimeout: queueTimeout,
+		tenants:      map[string]*readAdmissionTenant{},
+		queued: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name: "cortex_ingester_read_admission_queued_requests",
+			Help: "Number of read requests waiting to be admitted.",
+		}),
+		waitDuration: promauto.With(reg).NewHistogram(prometheus.HistogramOpts{
+			Name:                            "cortex_ingester_read_admission_wait_duration_seconds",
+			Help:                            "Time read requests waited to be admitted.",
+			NativeHistogramBucketFactor:     1.1,
+			NativeHistogramMaxBucketNumber:  100,
+			NativeHistogramMinResetDuration: 1 * time.Hour,
+			Buckets:                         prometheus.DefBuckets,
+		}),
+		rejected: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_ingester_read_admission_rejected_requests_total",
+			Help: "Total number of read requests rejected because they were not admitted within the queue timeout.",
+		}, []string{"user", "reason"}),
+	}
+}
+
+// acquireQuery waits until a query of the user can run. If it returns no error, releaseQuery must be called
+// once the query is completed.
+func (c *readAdmissionController) acquireQuery(ctx context.Context, userID string) error {
+	return c.acquire(ctx, userID, 1, 0, 0)
+}
+
+func (c *readAdmissionController) releaseQuery(userID string) {
+	c.release(userID, 1, 0)
+}
+
+// newSeriesBudget returns the budget tracking the series held in memory by a streaming query of the user,
+// or nil if the number of in-flight series of the user is not limited.
+func (c *readAdmissionController) newSeriesBudget(ctx context.Context, userID string) *querySeriesBudget {
+	if c.limits.MaxIngesterInflightQuerySeries(userID) <= 0 {
+		return nil
+	}
+	return &querySeriesBudget{ctx: ctx, c: c, userID: userID}
+}
+
+// tryAcquire admits the request only if it can run right away, and returns whether it has been admitted.
+func (c *readAdmissionController) tryAcquire(userID string, queries, series int) bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	t := c.tenantLocked(userID)
+	if c.tryAdmitLocked(userID, t, queries, series) {
+		return true
+	}
+	c.cleanupLocked(userID, t)
+	return false
+}
+
+// acquire waits until the request can run. heldSeries is the number of series already held by the query, which
+// keeps holding them while waiting.
+func (c *readAdmissionController) acquire(ctx context.Context, userID string, queries, series, heldSeries int) error {
+	c.mtx.Lock()
+	t := c.tenantLocked(userID)
+	if c.tryAdmitLocked(userID, t, queries, series) {
+		c.mtx.Unlock()
+		return nil
+	}
+
+	req := &readAdmissionRequest{queries: queries, series: series, admitted: make(chan struct{}), heldSeries: heldSeries}
+	t.queue = append(t.queue, req)
+	t.queuedHeldSeries += heldSeries
+	if len(t.queue) == 1 {
+		c.waiting = append(c.waiting, userID)
+	}
+	c.queued.Inc()
+	c.dispatchLocked()
+
+	// When all the series of the tenant are held by queued queries, no series will be released, so a request
+	// which doesn't fit in the series limit would wait for the other queued queries until the queue timeout.
+	// It's rejected right away instead, and the series released by its query may admit the other ones.
+	if heldSeries > 0 && t.series == t.queuedHeldSeries {
+		select {
+		case <-req.admitted:
+		default:
+			if reason, err := c.limitingReasonLocked(userID, t, 0, series); err != nil {
+				c.removeQueuedLocked(userID, t, req)
+				c.rejected.WithLabelValues(userID, reason).Inc()
+				c.mtx.Unlock()
+				return err
+			}
+		}
+	}
+	c.mtx.Unlock()
+
+	start := time.Now()
+	timer := time.NewTimer(c.queueTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-req.admitted:
+		c.waitDuration.Observe(time.Since(start).Seconds())
+		return nil
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	select {
+	case <-req.admitted:
+		// The request has been admitted in the meantime.
+		c.waitDuration.Observe(time.Since(start).Seconds())
+		return nil
+	default:
+	}
+
+	c.removeQueuedLocked(userID, t, req)
+
+	if err := ctx.Err(); err != nil {
+		c.cleanupLocked(userID, t)
+		c.rejected.WithLabelValues(userID, readAdmissionReasonContextExpired).Inc()
+		return err
+	}
+
+	reason, err := c.limitingReasonLocked(userID, t, queries, series)
+	if err == nil {
+		// The request was only waiting for the requests queued before it.
+		c.admitLocked(t, queries, series)
+		return nil
+	}
+	c.cleanupLocked(userID, t)
+	c.rejected.WithLabelValues(userID, reason).Inc()
+	return err
+}
+
+func (c *readAdmissionController) release(userID string, queries, series int) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	t := c.tenants[userID]
+	if t == nil {
+		return
+	}
+	t.queries -= queries
+	t.series -= series
+	c.running -= queries
+
+	c.dispatchLocked()
+	c.cleanupLocked(userID, t)
+}
+
+// dispatchLocked admits the queued requests which fit in the limits. It takes the first request of each tenant
+// in turn, starting from the tenant after the last one served.
+func (c *readAdmissionController) dispatchLocked() {
+	for admitted := true; admitted && len(c.waiting) > 0; {
+		admitted = false
+
+		for n := 0; n < len(c.waiting); n++ {
+			idx := (c.next + n) % len(c.waiting)
+			userID := c.waiting[idx]
+			t := c.tenants[userID]
+			req := t.queue[0]
+			if !c.fitsLocked(userID, t, req.queries, req.series) {
+				continue
+			}
+
+			c.admitLocked(t, req.queries, req.series)
+			close(req.admitted)
+			t.queue = t.queue[1:]
+			t.queuedHeldSeries -= req.heldSeries
+			c.queued.Dec()
+			admitted = true
+
+			if len(t.queue) == 0 {
+				c.waiting = slices.Delete(c.waiting, idx, idx+1)
+				c.next = idx
+			} else {
+				c.next = idx + 1
+			}
+			break
+		}
+	}
+}
+
+func (c *readAdmissionController) tenantLocked(userID string) *readAdmissionTenant {
+	t := c.tenants[userID]
+	if t == nil {
+		t = &readAdmissionTenant{}
+		c.tenants[userID] = t
+	}
+	return t
+}
+
+// tryAdmitLocked admits the request if it fits in the limits and no other request is waiting, otherwise
+// the request must be queued to keep the order of the requests of each tenant and the fairness between tenants.
+func (c *readAdmissionController) tryAdmitLocked(userID string, t *readAdmissionTenant, queries, series int) bool {
+	if len(c.waiting) > 0 || !c.fitsLocked(userID, t, queries, series) {
+		return false
+	}
+	c.admitLocked(t, queries, series)
+	return true
+}
+
+func (c *readAdmissionController) fitsLocked(userID string, t *readAdmissionTenant, queries, series int) bool {
+	_, err := c.limitingReasonLocked(userID, t, queries, series)
+	return err == nil
+}
+
+// limitingReasonLocked returns the reason and the error why the request doesn't fit in the limits, if any.
+func (c *readAdmissionController) limitingReasonLocked(userID string, t *readAdmissionTenant, queries, series int) (string, error) {
+	if queries > 0 {
+		if limit := c.limits.MaxIngesterConcurrentQueries(userID); limit > 0 && t.queries+queries > limit {
+			return readAdmissionReasonTenantQueries, newPerUserConcurrentQueriesLimitReachedError(limit)
+		}
+		if c.maxQueries > 0 && c.running+queries > c.maxQueries {
+			return readAdmissionReasonIngesterQuery, errTooBusy
+		}
+	}
+	if series > 0 {
+		if limit := c.limits.MaxIngesterInflightQuerySeries(userID); limit > 0 && t.series+series > limit {
+			return readAdmissionReasonTenantSeries, newPerUserInflightQuerySeriesLimitReachedError(limit)
+		}
+	}
+	return "", nil
+}
+
+func (c *readAdmissionController) admitLocked(t *readAdmissionTenant, queries, series int) {
+	t.queries += queries
+	t.series += series
+	c.running += queries
+}
+
+// removeQueuedLocked removes a request which hasn't been admitted from the queue of the tenant.
+func (c *readAdmissionController) removeQueuedLocked(userID string, t *readAdmissionTenant, req *readAdmissionRequest) {
+	t.queue = slices.DeleteFunc(t.queue, func(r *readAdmissionRequest) bool { return r == req })
+	t.queuedHeldSeries -= req.heldSeries
+	c.queued.Dec()
+	if len(t.queue) == 0 {
+		c.removeWaitingLocked(userID)
+	}
+	// The requests queued behind the removed one may now be admitted.
+	c.dispatchLocked()
+}
+
+func (c *readAdmissionController) removeWaitingLocked(userID string) {
+	idx := slices.Index(c.waiting, userID)
+	if idx < 0 {
+		return
+	}
+	c.waiting = slices.Delete(c.waiting, idx, idx+1)
+	if c.next > idx {
+		c.next--
+	}
+}
+
+func (c *readAdmissionController) cleanupLocked(userID string, t *readAdmissionTenant) {
+	if t.queries == 0 && t.series == 0 && len(t.queue) == 0 {
+		delete(c.tenants, userID)
+	}
+}
+
+func (c *readAdmissionController) removeTenantMetrics(userID string) {
+	if c == nil {
+		return
+	}
+	c.rejected.DeletePartialMatch(prometheus.Labels{"user": userID})
+}
+
+// querySeriesBudget tracks the series held in memory by a streaming query. A nil budget is unlimited.
+type querySeriesBudget struct {
+	ctx      context.Context
+	c        *readAdmissionController
+	userID   string
+	acquired int
+}
+
+// acquire waits until n more series can be held in memory by the query. A query which alone exceeds the
+// per-tenant limit fails right away, because it could never be admitted.
+func (b *querySeriesBudget) acquire(n int) error {
+	if b == nil || n == 0 {
+		return nil
+	}
+
+	total := b.acquired + n
+	if limit := b.c.limits.MaxIngesterInflightQuerySeries(b.userID); limit > 0 && total > limit {
+		b.c.rejected.WithLabelValues(b.userID, readAdmissionReasonQuerySeries).Inc()
+		return newPerUserQuerySeriesLimitExceededError(limit)
+	}
+
+	if b.c.tryAcquire(b.userID, 0, n) {
+		b.acquired = total
+		return nil
+	}
+
+	// The series acquired so far are kept while waiting, because the query still holds them in memory.
+	if err := b.c.acquire(b.ctx, b.userID, 0, n, b.acquired); err != nil {
+		return err
+	}
+	b.acquired = total
+	return nil
+}
+
+// release releases all the series acquired by the query.
+func (b *querySeriesBudget) release() {
+	if b == nil || b.acquired == 0 {
+		return
+	}
+	b.c.release(b.userID, 0, b.acquired)
+	b.acquired = 0
+}
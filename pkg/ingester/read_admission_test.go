@@ -0,0 +1,234 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/grpcutil"
+	"github.com/grafana/dskit/services"
+	"github.com/grafana/dskit/test"
+	"github.com/grafana/dskit/user"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+
+	"github.com/grafana/mimir/pkg/ingester/client"
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+type readAdmissionLimitsMock struct {
+	maxQueries int
+	maxSeries  int
+}
+
+func (m readAdmissionLimitsMock) MaxIngesterConcurrentQueries(string) int   { return m.maxQueries }
+func (m readAdmissionLimitsMock) MaxIngesterInflightQuerySeries(string) int { return m.maxSeries }
+
+func TestReadAdmissionController_PerTenantConcurrentQueries(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	c := newReadAdmissionController(readAdmissionLimitsMock{maxQueries: 1}, 0, 50*time.Millisecond, reg)
+	ctx := context.Background()
+
+	require.NoError(t, c.acquireQuery(ctx, "user-1"))
+
+	// Other tenants are not affected.
+	require.NoError(t, c.acquireQuery(ctx, "user-2"))
+	c.releaseQuery("user-2")
+
+	// The second query of the tenant is rejected after waiting for the queue timeout.
+	err := c.acquireQuery(ctx, "user-1")
+	require.ErrorAs(t, err, &perUserQueryLimitReachedError{})
+	assert.ErrorContains(t, err, "per-user concurrent queries limit of 1 reached")
+
+	// A queued query is admitted once the running one is completed.
+	admitted := make(chan error)
+	go func() { admitted <- c.acquireQuery(ctx, "user-1") }()
+	test.Poll(t, time.Second, 1.0, func() interface{} { return testutil.ToFloat64(c.queued) })
+	c.releaseQuery("user-1")
+	require.NoError(t, <-admitted)
+	c.releaseQuery("user-1")
+
+	// A query is not queued beyond the expiration of its context.
+	require.NoError(t, c.acquireQuery(ctx, "user-1"))
+	canceledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	require.ErrorIs(t, c.acquireQuery(canceledCtx, "user-1"), context.Canceled)
+	c.releaseQuery("user-1")
+
+	assert.Empty(t, c.tenants)
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_ingester_read_admission_rejected_requests_total Total number of read requests rejected because they were not admitted within the queue timeout.
+		# TYPE cortex_ingester_read_admission_rejected_requests_total counter
+		cortex_ingester_read_admission_rejected_requests_total{reason="context_expired",user="user-1"} 1
+		cortex_ingester_read_admission_rejected_requests_total{reason="tenant_concurrent_queries",user="user-1"} 1
+	`), "cortex_ingester_read_admission_rejected_requests_total"))
+}
+
+func TestReadAdmissionController_FairQueueing(t *testing.T) {
+	c := newReadAdmissionController(readAdmissionLimitsMock{}, 1, time.Minute, prometheus.NewPedanticRegistry())
+	ctx := context.Background()
+
+	require.NoError(t, c.acquireQuery(ctx, "user-1"))
+
+	// Queue several queries of user-1 before a single query of user-2.
+	admitted := make(chan string, 4)
+	queue := func(userID string, expectedQueued int) {
+		go func() {
+			if err := c.acquireQuery(ctx, userID); err == nil {
+				admitted <- userID
+			}
+		}()
+		test.Poll(t, time.Second, float64(expectedQueued), func() interface{} { return testutil.ToFloat64(c.queued) })
+	}
+	queue("user-1", 1)
+	queue("user-1", 2)
+	queue("user-1", 3)
+	queue("user-2", 4)
+
+	// The tenants are served in turn, so user-2 doesn't wait for all the queries of user-1.
+	var order []string
+	c.releaseQuery("user-1")
+	for len(order) < 4 {
+		userID := <-admitted
+		order = append(order, userID)
+		c.releaseQuery(userID)
+	}
+	assert.Equal(t, []string{"user-1", "user-2", "user-1", "user-1"}, order)
+	assert.Empty(t, c.tenants)
+}
+
+func TestReadAdmissionController_InflightSeries(t *testing.T) {
+	c := newReadAdmissionController(readAdmissionLimitsMock{maxSeries: 10}, 0, 50*time.Millisecond, prometheus.NewPedanticRegistry())
+	ctx := context.Background()
+
+	first := c.newSeriesBudget(ctx, "user-1")
+	require.NoError(t, first.acquire(6))
+	require.NoError(t, first.acquire(2))
+
+	// The series held by the other queries of the tenant count towards the limit.
+	second := c.newSeriesBudget(ctx, "user-1")
+	err := second.acquire(5)
+	require.ErrorAs(t, err, &perUserQueryLimitReachedError{})
+	assert.ErrorContains(t, err, "per-user in-flight query series limit of 10 reached")
+
+	// A query needing more series than the limit is rejected without waiting, with a non-retryable error.
+	start := time.Now()
+	err = second.acquire(11)
+	require.ErrorAs(t, err, &perUserQuerySeriesLimitExceededError{})
+	assert.Less(t, time.Since(start), 50*time.Millisecond)
+
+	first.release()
+	require.NoError(t, second.acquire(5))
+
+	// The series acquired so far by the query count towards the series it needs.
+	err = second.acquire(6)
+	require.ErrorAs(t, err, &perUserQuerySeriesLimitExceededError{})
+	assert.ErrorContains(t, err, "the query exceeds the per-user in-flight query series limit of 10")
+
+	second.release()
+	assert.Empty(t, c.tenants)
+
+	// The series are not tracked when the limit is disabled.
+	assert.Nil(t, newReadAdmissionController(readAdmissionLimitsMock{}, 0, 0, prometheus.NewPedanticRegistry()).newSeriesBudget(ctx, "user-1"))
+}
+
+func TestReadAdmissionController_InflightSeriesWaitingQueriesKeepTheirSeries(t *testing.T) {
+	c := newReadAdmissionController(readAdmissionLimitsMock{maxSeries: 10}, 0, time.Minute, prometheus.NewPedanticRegistry())
+	ctx := context.Background()
+
+	first := c.newSeriesBudget(ctx, "user-1")
+	second := c.newSeriesBudget(ctx, "user-1")
+	require.NoError(t, first.acquire(6))
+	require.NoError(t, second.acquire(4))
+
+	tenantSeries := func() int {
+		c.mtx.Lock()
+		defer c.mtx.Unlock()
+		return c.tenants["user-1"].series
+	}
+
+	// The first query waits for more series, while still holding the ones it has acquired.
+	firstDone := make(chan error, 1)
+	go func() { firstDone <- first.acquire(2) }()
+	test.Poll(t, time.Second, 1.0, func() interface{} { return testutil.ToFloat64(c.queued) })
+	assert.Equal(t, 10, tenantSeries())
+
+	// The second query needs more series too, but all the series are held by waiting queries,
+	// so it's rejected right away instead of waiting for the first one until the queue timeout.
+	start := time.Now()
+	err := second.acquire(2)
+	require.ErrorAs(t, err, &perUserQueryLimitReachedError{})
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, 10, tenantSeries())
+
+	// The series released by the second query admit the first one.
+	second.release()
+	require.NoError(t, <-firstDone)
+	assert.Equal(t, 8, tenantSeries())
+
+	first.release()
+	assert.Empty(t, c.tenants)
+	assert.Equal(t, 0.0, testutil.ToFloat64(c.queued))
+}
+
+func TestIngester_QueryStream_ReadAdmission(t *testing.T) {
+	cfg := defaultIngesterTestConfig(t)
+	cfg.ReadPathAdmissionQueueTimeout = 10 * time.Millisecond
+
+	limits := defaultLimitsTestConfig()
+	limits.MaxIngesterConcurrentQueries = 1
+	limits.MaxIngesterInflightQuerySeries = 200
+
+	i, err := prepareIngesterWithBlocksStorageAndLimits(t, cfg, limits, nil, "", nil)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(context.Background(), i))
+	})
+
+	// Wait until it's healthy.
+	test.Poll(t, 1*time.Second, 1, func() interface{} {
+		return i.lifecycler.HealthyInstancesCount()
+	})
+
+	ctx := user.InjectOrgID(context.Background(), userID)
+	pushSeries := func(numSeries int) {
+		for n := 0; n < numSeries; n++ {
+			series := labels.FromStrings(model.MetricNameLabel, "test_metric", "n", fmt.Sprint(n))
+			_, err := i.Push(ctx, writeRequestSingleSeries(series, []mimirpb.Sample{{TimestampMs: 1000, Value: 1}}))
+			require.NoError(t, err)
+		}
+	}
+	query := func() error {
+		req, err := client.ToQueryRequest(0, 2000, []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, model.MetricNameLabel, "test_metric")})
+		require.NoError(t, err)
+		req.StreamingChunksBatchSize = 64
+		return i.QueryStream(req, &stream{ctx: ctx})
+	}
+
+	pushSeries(150)
+	require.NoError(t, query())
+
+	// The query is rejected while another query of the tenant is running.
+	require.NoError(t, i.readAdmission.acquireQuery(ctx, userID))
+	err = query()
+	require.Error(t, err)
+	assert.Equal(t, codes.ResourceExhausted, grpcutil.ErrorToStatusCode(err))
+	i.readAdmission.releaseQuery(userID)
+
+	// The query is rejected with a non-retryable error when it holds too many series.
+	pushSeries(250)
+	err = query()
+	require.Error(t, err)
+	assert.Equal(t, codes.FailedPrecondition, grpcutil.ErrorToStatusCode(err))
+	assert.Contains(t, err.Error(), "the query exceeds the per-user in-flight query series limit of 200")
+}
@@ -0,0 +1,56 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"context"
+
+	"github.com/go-kit/log/level"
+
+	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
+)
+
+// applySeriesDeletionRequests loads the series deletion requests of the tenants owned by the ingester from the bucket,
+// and applies the ones not applied yet as tombstones to the TSDB heads. The TSDBs of the tenants not owned anymore
+// only receive queries until they're closed, and the deleted series are filtered out by the queriers anyway.
+func (i *Ingester) applySeriesDeletionRequests(ctx context.Context) {
+	for _, userID := range i.getTSDBUsers() {
+		if ctx.Err() != nil {
+			return
+		}
+
+		if !i.ownsTenant(userID) {
+			continue
+		}
+
+		db := i.getTSDB(userID)
+		if db == nil {
+			continue
+		}
+
+		reqs, err := mimir_tsdb.ReadSeriesDeletionRequests(ctx, i.bucket, userID, i.logger)
+		if err != nil {
+			level.Warn(i.logger).Log("msg", "failed to read series deletion requests", "user", userID, "err", err)
+			continue
+		}
+
+		if err := db.applySeriesDeletionRequests(ctx, reqs); err != nil {
+			level.Warn(i.logger).Log("msg", "failed to apply series deletion requests", "user", userID, "err", err)
+		}
+	}
+}
+
+// ownsTenant returns whether the tenant's shard includes the ingester, or its partition when ingest storage is enabled.
+// It returns true if the ownership can't be determined.
+func (i *Ingester) ownsTenant(userID string) bool {
+	if i.ownedSeriesStrategy == nil {
+		return true
+	}
+
+	ranges, err := i.ownedSeriesStrategy.tokenRangesForUser(userID, i.ownedSeriesStrategy.shardSizeForUser(userID))
+	if err != nil {
+		level.Warn(i.logger).Log("msg", "failed to check whether the tenant is owned by the ingester", "user", userID, "err", err)
+		return true
+	}
+	return len(ranges) > 0
+}
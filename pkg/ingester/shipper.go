@@ -12,6 +12,7 @@ import (
 	"path"
 	"path/filepath"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/go-kit/log"
@@ -22,9 +23,13 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
 	"github.com/prometheus/prometheus/tsdb/fileutil"
+	"github.com/prometheus/prometheus/tsdb/index"
 	"github.com/thanos-io/objstore"
 
+	"github.com/grafana/mimir/pkg/mimirpb"
 	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
 	"github.com/grafana/mimir/pkg/storage/tsdb/block"
 	"github.com/grafana/mimir/pkg/util/spanlogger"
@@ -70,11 +75,20 @@ type shipper struct {
 	metrics     *shipperMetrics
 	bucket      objstore.Bucket
 	source      block.SourceType
+
+	// exemplars is used to store the exemplars in the shipped blocks. Nil if long-term exemplars storage is disabled.
+	exemplars storage.ExemplarQueryable
+
+	// metadata returns the metric metadata of the tenant, which is stored in the shipped blocks. Nil if durable
+	// metric metadata is disabled.
+	metadata func() []*mimirpb.MetricMetadata
 }
 
 // newShipper creates a new uploader that detects new TSDB blocks in dir and uploads them to
 // remote if necessary. It attaches the Thanos metadata section in each meta JSON file.
 // If uploadCompacted is enabled, it also uploads compacted blocks which are already in filesystem.
+// If exemplars is not nil, the exemplars in the time range of each block are stored alongside the block.
+// If metadata is not nil, the metric metadata returned by it at upload time is stored alongside each block.
 func newShipper(
 	logger log.Logger,
 	cfgProvider ShipperConfigProvider,
@@ -83,6 +97,8 @@ func newShipper(
 	dir string,
 	bucket objstore.Bucket,
 	source block.SourceType,
+	exemplars storage.ExemplarQueryable,
+	metadata func() []*mimirpb.MetricMetadata,
 ) *shipper {
 	if logger == nil {
 		logger = log.NewNopLogger()
@@ -96,6 +112,8 @@ func newShipper(
 		bucket:      bucket,
 		metrics:     metrics,
 		source:      source,
+		exemplars:   exemplars,
+		metadata:    metadata,
 	}
 }
 
@@ -200,10 +218,124 @@ func (s *shipper) upload(ctx context.Context, logger log.Logger, meta *block.Met
 		meta.Thanos.Labels[mimir_tsdb.OutOfOrderExternalLabel] = mimir_tsdb.OutOfOrderExternalLabelValue
 	}
 
+	if s.exemplars != nil {
+		if err := s.writeExemplars(ctx, blockDir, meta); err != nil {
+			// Exemplars are stored on a best-effort basis, so we don't fail the upload.
+			level.Warn(logger).Log("msg", "failed to store exemplars of the block", "block", meta.ULID, "err", err)
+		}
+	}
+
+	if s.metadata != nil {
+		if err := s.writeMetricsMetadata(ctx, blockDir); err != nil {
+			// Metric metadata is stored on a best-effort basis, so we don't fail the upload.
+			level.Warn(logger).Log("msg", "failed to store metric metadata of the block", "block", meta.ULID, "err", err)
+		}
+	}
+
 	// Upload block with custom metadata.
 	return block.Upload(ctx, logger, s.bucket, blockDir, meta)
 }
 
+// writeExemplars writes the exemplars in the block time range to the block directory, so that they're uploaded
+// together with the block. Exemplars are read from the in-memory circular buffer, so the oldest exemplars may
+// have already been evicted.
+func (s *shipper) writeExemplars(ctx context.Context, blockDir string, meta *block.Meta) error {
+	q, err := s.exemplars.ExemplarQuerier(ctx)
+	if err != nil {
+		return err
+	}
+
+	// The block max time is exclusive.
+	results, err := q.Select(meta.MinTime, meta.MaxTime-1, []*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, model.MetricNameLabel, ".+")})
+	if err != nil {
+		return err
+	}
+
+	exemplars := &block.Exemplars{}
+	for _, res := range results {
+		series := block.ExemplarSeries{Labels: res.SeriesLabels, Exemplars: make([]block.Exemplar, 0, len(res.Exemplars))}
+		for _, e := range res.Exemplars {
+			series.Exemplars = append(series.Exemplars, block.Exemplar{Labels: e.Labels, Value: e.Value, Timestamp: e.Ts})
+		}
+		exemplars.Series = append(exemplars.Series, series)
+	}
+	if len(exemplars.Series) == 0 {
+		return nil
+	}
+
+	return block.WriteExemplarsFile(blockDir, block.MergeExemplars(exemplars))
+}
+
+// writeMetricsMetadata writes the metric metadata currently held in memory of the metrics having series in the
+// block to the block directory, so that it's uploaded together with the block. The in-memory metadata isn't tracked
+// by time, so it's a snapshot taken at upload time rather than the exact metadata ingested during the block time range.
+func (s *shipper) writeMetricsMetadata(ctx context.Context, blockDir string) error {
+	metadata := s.metadata()
+	if len(metadata) == 0 {
+		return nil
+	}
+
+	names, err := readBlockMetricNames(ctx, blockDir)
+	if err != nil {
+		return err
+	}
+
+	m := &block.MetricsMetadata{}
+	for _, md := range metadata {
+		if !hasMetricFamilySeries(names, md.MetricFamilyName) {
+			continue
+		}
+		m.Metadata = append(m.Metadata, block.MetricMetadata{
+			Metric: md.MetricFamilyName,
+			Type:   string(mimirpb.MetricMetadataMetricTypeToMetricType(md.GetType())),
+			Help:   md.Help,
+			Unit:   md.Unit,
+		})
+	}
+
+	if len(m.Metadata) == 0 {
+		return nil
+	}
+	return block.WriteMetricsMetadataFile(blockDir, m)
+}
+
+// metricFamilySuffixes are the suffixes of the series names of a metric family, in addition to the family name itself.
+var metricFamilySuffixes = []string{"_total", "_created", "_bucket", "_count", "_sum", "_gcount", "_gsum", "_info"}
+
+// hasMetricFamilySeries returns whether the metric names include a series of the metric family.
+func hasMetricFamilySeries(names map[string]struct{}, family string) bool {
+	if _, ok := names[family]; ok {
+		return true
+	}
+	for _, suffix := range metricFamilySuffixes {
+		if _, ok := names[family+suffix]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// readBlockMetricNames returns the set of metric names in the block index.
+func readBlockMetricNames(ctx context.Context, blockDir string) (_ map[string]struct{}, err error) {
+	r, err := index.NewFileReader(filepath.Join(blockDir, block.IndexFilename))
+	if err != nil {
+		return nil, errors.Wrap(err, "open index file")
+	}
+	defer runutil.CloseWithErrCapture(&err, r, "close index reader")
+
+	values, err := r.LabelValues(ctx, model.MetricNameLabel)
+	if err != nil {
+		return nil, errors.Wrap(err, "read metric names")
+	}
+
+	// The values are backed by the index file, which is unmapped when the reader is closed.
+	names := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		names[strings.Clone(v)] = struct{}{}
+	}
+	return names, nil
+}
+
 // blockMetasFromOldest returns the block meta of each block found in dir
 // sorted by minTime asc.
 func (s *shipper) blockMetasFromOldest() (metas []*block.Meta, _ error) {
@@ -12,6 +12,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"slices"
 	"sort"
 	"testing"
 	"time"
@@ -20,10 +21,15 @@ import (
 	"github.com/grafana/dskit/concurrency"
 	"github.com/oklog/ulid"
 	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/exemplar"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
 	"github.com/prometheus/prometheus/tsdb"
 	"github.com/stretchr/testify/require"
 	"github.com/thanos-io/objstore"
 
+	"github.com/grafana/mimir/pkg/mimirpb"
 	"github.com/grafana/mimir/pkg/storage/bucket/filesystem"
 	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
 	"github.com/grafana/mimir/pkg/storage/tsdb/block"
@@ -51,7 +57,7 @@ func TestShipper(t *testing.T) {
 	logger := log.NewLogfmtLogger(logs)
 	overrides, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
 	require.NoError(t, err)
-	s := newShipper(logger, overrides, "", newShipperMetrics(nil), blocksDir, bkt, block.TestSource)
+	s := newShipper(logger, overrides, "", newShipperMetrics(nil), blocksDir, bkt, block.TestSource, nil, nil)
 
 	t.Run("no shipper file yet", func(t *testing.T) {
 		// No shipper file = nothing is reported as shipped.
@@ -193,7 +199,7 @@ func TestShipper_DeceivingUploadErrors(t *testing.T) {
 	logger := log.NewLogfmtLogger(os.Stderr)
 	overrides, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
 	require.NoError(t, err)
-	s := newShipper(logger, overrides, "", newShipperMetrics(nil), blocksDir, bkt, block.TestSource)
+	s := newShipper(logger, overrides, "", newShipperMetrics(nil), blocksDir, bkt, block.TestSource, nil, nil)
 
 	// Create and upload a block
 	id1 := ulid.MustNew(1, nil)
@@ -259,7 +265,7 @@ func TestIterBlockMetas(t *testing.T) {
 	}.WriteToDir(log.NewNopLogger(), path.Join(dir, id3.String())))
 	overrides, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
 	require.NoError(t, err)
-	shipper := newShipper(nil, overrides, "", newShipperMetrics(nil), dir, nil, block.TestSource)
+	shipper := newShipper(nil, overrides, "", newShipperMetrics(nil), dir, nil, block.TestSource, nil, nil)
 	metas, err := shipper.blockMetasFromOldest()
 	require.NoError(t, err)
 	require.Equal(t, sort.SliceIsSorted(metas, func(i, j int) bool {
@@ -273,7 +279,7 @@ func TestShipperAddsSegmentFiles(t *testing.T) {
 	inmemory := objstore.NewInMemBucket()
 	overrides, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
 	require.NoError(t, err)
-	s := newShipper(nil, overrides, "", newShipperMetrics(nil), dir, inmemory, block.TestSource)
+	s := newShipper(nil, overrides, "", newShipperMetrics(nil), dir, inmemory, block.TestSource, nil, nil)
 
 	id := ulid.MustNew(1, nil)
 	blockDir := path.Join(dir, id.String())
@@ -306,6 +312,120 @@ func TestShipperAddsSegmentFiles(t *testing.T) {
 	require.Equal(t, []string{segmentFile}, meta.Thanos.SegmentFiles)
 }
 
+func TestShipper_StoresExemplars(t *testing.T) {
+	dir := t.TempDir()
+
+	inmemory := objstore.NewInMemBucket()
+	overrides, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
+	require.NoError(t, err)
+
+	series := labels.FromStrings(model.MetricNameLabel, "series_1")
+	exemplars := &mockExemplarQueryable{results: []exemplar.QueryResult{{
+		SeriesLabels: series,
+		Exemplars: []exemplar.Exemplar{
+			{Labels: labels.FromStrings("trace_id", "1"), Value: 1, Ts: 1000, HasTs: true},
+			{Labels: labels.FromStrings("trace_id", "2"), Value: 2, Ts: 1500, HasTs: true},
+		},
+	}}}
+	s := newShipper(nil, overrides, "", newShipperMetrics(nil), dir, inmemory, block.TestSource, exemplars, nil)
+
+	id := ulid.MustNew(1, nil)
+	createBlock(t, dir, id, block.Meta{
+		BlockMeta: tsdb.BlockMeta{
+			ULID:    id,
+			MinTime: 1000,
+			MaxTime: 2000,
+			Version: 1,
+			Stats: tsdb.BlockStats{
+				NumSamples: 100, // Shipper checks if number of samples is greater than 0.
+			},
+		},
+	})
+
+	uploaded, err := s.Sync(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, uploaded)
+
+	// The exemplars in the block time range have been requested. The block max time is exclusive.
+	require.Equal(t, int64(1000), exemplars.start)
+	require.Equal(t, int64(1999), exemplars.end)
+
+	stored, err := block.DownloadExemplars(context.Background(), inmemory, id)
+	require.NoError(t, err)
+	require.Equal(t, []block.ExemplarSeries{{
+		Labels: series,
+		Exemplars: []block.Exemplar{
+			{Labels: labels.FromStrings("trace_id", "1"), Value: 1, Timestamp: 1000},
+			{Labels: labels.FromStrings("trace_id", "2"), Value: 2, Timestamp: 1500},
+		},
+	}}, stored.Series)
+
+	meta, err := block.DownloadMeta(context.Background(), log.NewNopLogger(), inmemory, id)
+	require.NoError(t, err)
+
+	var files []string
+	for _, f := range meta.Thanos.Files {
+		files = append(files, f.RelPath)
+	}
+	require.Contains(t, files, block.ExemplarsFilename)
+}
+
+func TestShipper_StoresMetricsMetadata(t *testing.T) {
+	dir := t.TempDir()
+
+	inmemory := objstore.NewInMemBucket()
+	overrides, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
+	require.NoError(t, err)
+
+	metadata := func() []*mimirpb.MetricMetadata {
+		return []*mimirpb.MetricMetadata{
+			{MetricFamilyName: "metric_3", Type: mimirpb.HISTOGRAM, Help: "Third metric."},
+			{MetricFamilyName: "metric_2", Type: mimirpb.GAUGE, Help: "Second metric."},
+			{MetricFamilyName: "metric_1", Type: mimirpb.COUNTER, Help: "First metric.", Unit: "seconds"},
+		}
+	}
+	s := newShipper(nil, overrides, "", newShipperMetrics(nil), dir, inmemory, block.TestSource, nil, metadata)
+
+	// Only the metadata of the metrics having series in the block is stored.
+	id, err := block.CreateBlock(context.Background(), dir, []labels.Labels{
+		labels.FromStrings(model.MetricNameLabel, "metric_1_total", "series", "1"),
+		labels.FromStrings(model.MetricNameLabel, "metric_1_total", "series", "2"),
+		labels.FromStrings(model.MetricNameLabel, "metric_3_bucket", "le", "+Inf"),
+	}, 10, 1000, 2000, labels.EmptyLabels())
+	require.NoError(t, err)
+
+	uploaded, err := s.Sync(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, uploaded)
+
+	stored, err := block.DownloadMetricsMetadata(context.Background(), inmemory, id)
+	require.NoError(t, err)
+	require.Equal(t, []block.MetricMetadata{
+		{Metric: "metric_1", Type: "counter", Help: "First metric.", Unit: "seconds"},
+		{Metric: "metric_3", Type: "histogram", Help: "Third metric."},
+	}, stored.Metadata)
+
+	meta, err := block.DownloadMeta(context.Background(), log.NewNopLogger(), inmemory, id)
+	require.NoError(t, err)
+	require.True(t, slices.ContainsFunc(meta.Thanos.Files, func(f block.File) bool {
+		return f.RelPath == block.MetricsMetadataFilename && f.SizeBytes > 0
+	}))
+}
+
+type mockExemplarQueryable struct {
+	results    []exemplar.QueryResult
+	start, end int64
+}
+
+func (m *mockExemplarQueryable) ExemplarQuerier(context.Context) (storage.ExemplarQuerier, error) {
+	return m, nil
+}
+
+func (m *mockExemplarQueryable) Select(start, end int64, _ ...[]*labels.Matcher) ([]exemplar.QueryResult, error) {
+	m.start, m.end = start, end
+	return m.results, nil
+}
+
 func TestShipper_AddOOOLabel(t *testing.T) {
 	for _, tc := range []struct {
 		name                      string
@@ -417,7 +537,7 @@ func TestShipper_AddOOOLabel(t *testing.T) {
 			}
 			overrides, err := validation.NewOverrides(defaultLimitsTestConfig(), validation.NewMockTenantLimits(tenantLimits))
 			require.NoError(t, err)
-			s := newShipper(logger, overrides, "", newShipperMetrics(nil), blocksDir, bkt, block.TestSource)
+			s := newShipper(logger, overrides, "", newShipperMetrics(nil), blocksDir, bkt, block.TestSource, nil, nil)
 
 			createBlock(t, blocksDir, tc.meta.ULID, tc.meta)
 
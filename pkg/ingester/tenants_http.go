@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/dustin/go-humanize"
 	"github.com/gorilla/mux"
 	"github.com/prometheus/prometheus/tsdb"
 	"golang.org/x/exp/slices"
@@ -27,6 +28,10 @@ type tenantStats struct {
 	Blocks  int
 	MinTime string
 	MaxTime string
+	Memory  string
+
+	HeadMemory          string
+	LastEarlyCompaction string
 
 	Warning string
 }
@@ -39,8 +44,12 @@ type tenantTSDBPageContent struct {
 	Now    time.Time
 	Tenant string
 
-	Head   tenantTSDBHeadPageContent
-	Blocks []tenantTSDBBlockPageContent
+	// Bootstrapping is true while the TSDB is being bootstrapped from a head snapshot, so it's not open yet.
+	Bootstrapping bool
+
+	Head         tenantTSDBHeadPageContent
+	HeadSnapshot *tenantTSDBHeadSnapshotPageContent
+	Blocks       []tenantTSDBBlockPageContent
 }
 
 type tenantTSDBHeadPageContent struct {
@@ -52,6 +61,20 @@ type tenantTSDBHeadPageContent struct {
 	MaxOOOTime             string
 }
 
+type tenantTSDBHeadSnapshotPageContent struct {
+	LastUploadID   string
+	LastUploadTime string
+	LastUploadSize string
+	LastUploadNew  string
+	LastUploadErr  string
+
+	BootstrapState        string
+	BootstrapSnapshotID   string
+	BootstrapSnapshotTime string
+	BootstrapProgress     string
+	BootstrapErr          string
+}
+
 type tenantTSDBBlockPageContent struct {
 	ID         string
 	MinTime    string
@@ -86,6 +109,12 @@ func (i *Ingester) TenantsHandler(w http.ResponseWriter, req *http.Request) {
 		s.MinTime = formatMillisTime(db.Head().MinTime())
 		maxMillis := db.Head().MaxTime()
 		s.MaxTime = formatMillisTime(maxMillis)
+		s.Memory = formatMemoryUsage(db.estimatedMemoryBytes(), i.limits.MaxIngesterMemoryBytesPerTenant(t))
+		seriesBytes, chunksBytes := db.estimatedHeadMemoryBytes()
+		s.HeadMemory = fmt.Sprintf("%s series, %s chunks", formatMemoryUsage(seriesBytes, 0), formatMemoryUsage(chunksBytes, 0))
+		if d := db.lastEarlyCompaction.Load(); d != nil {
+			s.LastEarlyCompaction = formatEarlyCompactionDecision(d)
+		}
 
 		if maxMillis-nowMillis > i.limits.CreationGracePeriod(t).Milliseconds() {
 			s.Warning = "TSDB Head max timestamp too far in the future"
@@ -94,6 +123,10 @@ func (i *Ingester) TenantsHandler(w http.ResponseWriter, req *http.Request) {
 			s.Warning = "TSDB Head min timestamp too far in the past"
 		}
 
+		if !i.limiter.IsWithinMaxMemoryPerUser(t, db.estimatedMemoryBytes()) {
+			s.Warning = "Estimated memory usage reached the per-tenant limit"
+		}
+
 		tss = append(tss, s)
 	}
 
@@ -111,8 +144,20 @@ func (i *Ingester) TenantTSDBHandler(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	snapshot, snapshotFound := i.headSnapshotter.getStatus(tenant)
+
 	db := i.getTSDB(tenant)
 	if db == nil {
+		if snapshotFound && snapshot.BootstrapState != "" {
+			util.RenderHTTPResponse(w, tenantTSDBPageContent{
+				Now:           time.Now(),
+				Tenant:        tenant,
+				Bootstrapping: true,
+				HeadSnapshot:  newTenantTSDBHeadSnapshotPageContent(snapshot),
+			}, tenantTSDBTemplate, req)
+			return
+		}
+
 		w.WriteHeader(http.StatusNotFound)
 		util.WriteTextResponse(w, "TSDB not found for tenant "+tenant)
 		return
@@ -137,6 +182,10 @@ func (i *Ingester) TenantTSDBHandler(w http.ResponseWriter, req *http.Request) {
 		c.Head.AppendableMinValidTime = formatMillisTime(m)
 	}
 
+	if snapshotFound {
+		c.HeadSnapshot = newTenantTSDBHeadSnapshotPageContent(snapshot)
+	}
+
 	shipped := db.getCachedShippedBlocks()
 
 	blocks := db.db.Blocks()
@@ -162,6 +211,26 @@ func (i *Ingester) TenantTSDBHandler(w http.ResponseWriter, req *http.Request) {
 	util.RenderHTTPResponse(w, c, tenantTSDBTemplate, req)
 }
 
+func newTenantTSDBHeadSnapshotPageContent(s headSnapshotStatus) *tenantTSDBHeadSnapshotPageContent {
+	c := &tenantTSDBHeadSnapshotPageContent{
+		LastUploadID:        s.LastUploadID,
+		LastUploadErr:       s.LastUploadErr,
+		BootstrapState:      s.BootstrapState,
+		BootstrapSnapshotID: s.BootstrapSnapshotID,
+		BootstrapErr:        s.BootstrapErr,
+	}
+	if !s.LastUploadTime.IsZero() {
+		c.LastUploadTime = formatTime(s.LastUploadTime)
+		c.LastUploadSize = humanize.IBytes(uint64(s.LastUploadBytes))
+		c.LastUploadNew = humanize.IBytes(uint64(s.LastUploadNewBytes))
+	}
+	if s.BootstrapState != "" {
+		c.BootstrapSnapshotTime = formatTime(s.BootstrapSnapshotTime)
+		c.BootstrapProgress = fmt.Sprintf("%s / %s", humanize.IBytes(uint64(s.BootstrapDownloadedBytes)), humanize.IBytes(uint64(s.BootstrapTotalBytes)))
+	}
+	return c
+}
+
 func formatMillisTime(t int64) string {
 	switch t {
 	case 0:
@@ -177,3 +246,20 @@ func formatMillisTime(t int64) string {
 func formatTime(t time.Time) string {
 	return t.UTC().Format(time.RFC3339)
 }
+
+// formatEarlyCompactionDecision formats the time and the reason of an early compaction, along with the estimated
+// TSDB Head memory when it was decided.
+func formatEarlyCompactionDecision(d *earlyCompactionDecision) string {
+	return fmt.Sprintf("%s (%s, head memory %s)", formatTime(d.time), d.reason, formatMemoryUsage(d.headMemoryBytes, 0))
+}
+
+// formatMemoryUsage formats the estimated memory usage of a tenant, along with its limit if enabled.
+func formatMemoryUsage(bytes, limit int64) string {
+	if bytes < 0 {
+		bytes = 0
+	}
+	if limit <= 0 {
+		return humanize.IBytes(uint64(bytes))
+	}
+	return fmt.Sprintf("%s / %s", humanize.IBytes(uint64(bytes)), humanize.IBytes(uint64(limit)))
+}
@@ -44,6 +44,8 @@ func TestIngester_TenantsHandlers(t *testing.T) {
 		require.Equal(t, http.StatusOK, rec.Code)
 		// Check if link to user's TSDB was generated
 		require.Contains(t, rec.Body.String(), fmt.Sprintf(`<a href="tsdb/%s">%s</a>`, userID, userID))
+		// Check if the estimated memory usage of the tenant is shown
+		require.Contains(t, rec.Body.String(), fmt.Sprintf(`<td>%s</td>`, formatMemoryUsage(i.getTSDB(userID).estimatedMemoryBytes(), 0)))
 	})
 
 	t.Run("tenant TSDB for valid tenant", func(t *testing.T) {
@@ -70,3 +72,9 @@ func TestIngester_TenantsHandlers(t *testing.T) {
 		require.Contains(t, rec.Body.String(), "TSDB not found for tenant unknown")
 	})
 }
+
+func TestFormatMemoryUsage(t *testing.T) {
+	require.Equal(t, "1.0 KiB", formatMemoryUsage(1024, 0))
+	require.Equal(t, "1.0 KiB / 2.0 KiB", formatMemoryUsage(1024, 2048))
+	require.Equal(t, "0 B", formatMemoryUsage(-1, 0))
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/oklog/ulid"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/storage"
 	"github.com/prometheus/prometheus/tsdb"
@@ -25,6 +26,7 @@ import (
 	"go.uber.org/atomic"
 
 	"github.com/grafana/mimir/pkg/ingester/activeseries"
+	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
 	"github.com/grafana/mimir/pkg/util/extract"
 	"github.com/grafana/mimir/pkg/util/globalerror"
 	util_math "github.com/grafana/mimir/pkg/util/math"
@@ -34,7 +36,7 @@ type tsdbState int
 
 const (
 	active          tsdbState = iota // Pushes are allowed.
-	activeShipping                   // Pushes are allowed. Blocks shipping is in progress.
+	activeShipping                   // Pushes are allowed. Blocks shipping or head snapshot staging is in progress.
 	forceCompacting                  // TSDB is being force-compacted.
 	closing                          // Used while closing idle TSDB.
 	closed                           // Used to avoid setting closing back to active in closeAndDeleteIdleUsers method.
@@ -78,11 +80,31 @@ func (r tsdbCloseCheckResult) shouldClose() bool {
 	return r == tsdbIdle || r == tsdbTenantMarkedForDeletion
 }
 
+const (
+	// estimatedSeriesOverheadBytes is the approximate memory used by each in-memory series besides its labels:
+	// the TSDB head series, its open head chunk of float samples and its entries in the head series maps.
+	estimatedSeriesOverheadBytes = 320
+	// estimatedLabelOverheadBytes is the approximate memory used by each label of an in-memory series besides
+	// its name and value: the labels encoding and the series reference in the label postings.
+	estimatedLabelOverheadBytes = 16
+	// estimatedNativeHistogramSeriesBytes is the approximate additional memory used by each native histogram
+	// series, for its larger head chunk and last sample.
+	estimatedNativeHistogramSeriesBytes = 256
+	// estimatedNativeHistogramBucketBytes is the approximate additional memory used by each native histogram bucket.
+	estimatedNativeHistogramBucketBytes = 16
+	// estimatedHeadChunkBytes is the approximate memory used by each TSDB Head chunk, in-order or out-of-order,
+	// besides the open head chunk of the series: its metadata and its data, once paged in from the memory mapped file.
+	estimatedHeadChunkBytes = 200
+)
+
 var (
 	errTSDBForcedCompaction = newTSDBUnavailableError("TSDB Head forced compaction in progress and no write request is currently allowed")
 	errTSDBEarlyCompaction  = newTSDBUnavailableError("TSDB Head early compaction in progress and the write request contains samples overlapping with it")
 	errTSDBClosing          = newTSDBUnavailableError("TSDB is closing")
 	errTSDBNotActive        = newTSDBUnavailableError("TSDB is not active")
+
+	// headChunksDesc is the descriptor of the TSDB Head gauge of the number of chunks.
+	headChunksDesc = prometheus.NewDesc("prometheus_tsdb_head_chunks", "Total number of chunks in the head block.", nil, nil)
 )
 
 type ownedSeriesState struct {
@@ -92,15 +114,28 @@ type ownedSeriesState struct {
 }
 
 type userTSDB struct {
-	db             *tsdb.DB
-	userID         string
-	activeSeries   *activeseries.ActiveSeries
-	seriesInMetric *metricCounter
-	limiter        *Limiter
+	db                 *tsdb.DB
+	userID             string
+	activeSeries       *activeseries.ActiveSeries
+	seriesInMetric     *metricCounter
+	seriesInLabelValue *labelValueCounter
+	limiter            *Limiter
 
 	instanceSeriesCount *atomic.Int64 // Shared across all userTSDB instances created by ingester.
-	instanceLimitsFn    func() *InstanceLimits
-	instanceErrors      *prometheus.CounterVec
+
+	// Approximate memory used by the in-memory series, updated when series are created and deleted.
+	seriesMemoryBytes atomic.Int64
+	// Approximate additional memory used by the native histogram series, updated with the active series and
+	// on every push with native histograms.
+	nativeHistogramsMemoryBytes atomic.Int64
+	instanceLimitsFn            func() *InstanceLimits
+	instanceErrors              *prometheus.CounterVec
+
+	// Gauge of the number of TSDB Head chunks, nil if unknown.
+	headChunksGauge prometheus.Gauge
+
+	// Last early compaction of the TSDB Head, nil if none has been run.
+	lastEarlyCompaction atomic.Pointer[earlyCompactionDecision]
 
 	stateMtx                                     sync.RWMutex
 	state                                        tsdbState
@@ -108,6 +143,9 @@ type userTSDB struct {
 	inFlightAppendsStartedBeforeForcedCompaction sync.WaitGroup // Increased with stateMtx read lock held.
 	forcedCompactionMaxTime                      int64          // Max timestamp of samples that will be compacted from the TSDB head during a forced o early compaction.
 
+	// appendsMtx is read locked while appending, and locked to block the appends while taking a head snapshot.
+	appendsMtx sync.RWMutex
+
 	// Used to detect idle TSDBs.
 	lastUpdate atomic.Int64
 
@@ -125,9 +163,16 @@ type userTSDB struct {
 	ingestedAPISamples  *util_math.EwmaRate
 	ingestedRuleSamples *util_math.EwmaRate
 
+	// Range of the blocks cut from the head.
+	blockRange time.Duration
+
 	// Block min retention
 	blockMinRetention time.Duration
 
+	// IDs of the series deletion requests already applied to the head. Only accessed by the
+	// ingester goroutine applying the requests, so it doesn't need to be synchronized.
+	appliedSeriesDeletionRequests map[string]struct{}
+
 	// Cached shipped blocks.
 	shippedBlocksMtx sync.Mutex
 	shippedBlocks    map[ulid.ULID]time.Time
@@ -299,6 +344,11 @@ func (u *userTSDB) PreCreation(metric labels.Labels) error {
 		return globalerror.MaxSeriesPerUser
 	}
 
+	// Memory limit.
+	if !u.limiter.IsWithinMaxMemoryPerUser(u.userID, u.estimatedMemoryBytes()) {
+		return globalerror.MaxMemoryPerUser
+	}
+
 	// Series per metric name limit.
 	metricName, err := extract.MetricNameFromLabels(metric)
 	if err != nil {
@@ -308,6 +358,11 @@ func (u *userTSDB) PreCreation(metric labels.Labels) error {
 		return globalerror.MaxSeriesPerMetric
 	}
 
+	// Series per label value limits.
+	if limit, maxValuesExceeded := u.seriesInLabelValue.canAddSeriesFor(u.userID, metricName, metric); limit != nil {
+		return labelValueSeriesLimitExceededError{limit: limit, maxValuesExceeded: maxValuesExceeded}
+	}
+
 	return nil
 }
 
@@ -326,6 +381,7 @@ func (u *userTSDB) getSeriesCountAndMinLocalLimit() (int, int) {
 
 func (u *userTSDB) PostCreation(metric labels.Labels) {
 	u.instanceSeriesCount.Inc()
+	u.seriesMemoryBytes.Add(estimatedSeriesMemoryBytes(metric))
 
 	// If series was just created, it must belong to this ingester. (Unless it was created while replaying WAL,
 	// but we will recompute owned series when ingester joins the ring.)
@@ -339,18 +395,22 @@ func (u *userTSDB) PostCreation(metric labels.Labels) {
 		return
 	}
 	u.seriesInMetric.increaseSeriesForMetric(metricName)
+	u.seriesInLabelValue.increaseSeriesFor(u.userID, metricName, metric)
 }
 
 func (u *userTSDB) PostDeletion(metrics map[chunks.HeadSeriesRef]labels.Labels) {
 	u.instanceSeriesCount.Sub(int64(len(metrics)))
 
 	for _, lbls := range metrics {
+		u.seriesMemoryBytes.Sub(estimatedSeriesMemoryBytes(lbls))
+
 		metricName, err := extract.MetricNameFromLabels(lbls)
 		if err != nil {
 			// This should never happen because it has already been checked in PreCreation().
 			continue
 		}
 		u.seriesInMetric.decreaseSeriesForMetric(metricName)
+		u.seriesInLabelValue.decreaseSeriesFor(u.userID, metricName, lbls)
 	}
 
 	// We cannot update ownedSeriesCount here, as we don't know whether deleted series were owned by this ingester or not.
@@ -359,6 +419,96 @@ func (u *userTSDB) PostDeletion(metrics map[chunks.HeadSeriesRef]labels.Labels)
 	u.activeSeries.PostDeletion(metrics)
 }
 
+// estimatedMemoryBytes returns the approximate memory used by the TSDB Head: the in-memory series, including
+// their labels, postings and open head chunk, and all the other in-order and out-of-order Head chunks.
+func (u *userTSDB) estimatedMemoryBytes() int64 {
+	return u.seriesMemoryBytes.Load() + u.nativeHistogramsMemoryBytes.Load() + u.headChunksMemoryBytes()
+}
+
+// estimatedHeadMemoryBytes returns the approximate memory used by the TSDB Head, split between the in-memory series,
+// including their open head chunk, and all the other in-order and out-of-order Head chunks.
+func (u *userTSDB) estimatedHeadMemoryBytes() (seriesBytes, chunksBytes int64) {
+	return u.seriesMemoryBytes.Load() + u.nativeHistogramsMemoryBytes.Load(), u.headChunksMemoryBytes()
+}
+
+// headChunksMemoryBytes returns the approximate memory used by the TSDB Head chunks besides the open head chunk
+// of each series, which is accounted in the series memory.
+func (u *userTSDB) headChunksMemoryBytes() int64 {
+	if u.headChunksGauge == nil || u.db == nil {
+		return 0
+	}
+
+	m := &dto.Metric{}
+	if err := u.headChunksGauge.Write(m); err != nil {
+		return 0
+	}
+	return max(int64(m.GetGauge().GetValue())-int64(u.Head().NumSeries()), 0) * estimatedHeadChunkBytes
+}
+
+// headChunksCapturingRegisterer is a prometheus.Registerer which keeps a reference to the gauge of the number
+// of TSDB Head chunks when it's registered, so that it can be read without gathering all the TSDB metrics.
+// The TSDB doesn't expose the number of Head chunks, so the gauge is matched on the whole descriptor, which
+// fails the tests if it changes upstream.
+type headChunksCapturingRegisterer struct {
+	prometheus.Registerer
+	gauge prometheus.Gauge
+}
+
+func (r *headChunksCapturingRegisterer) Register(c prometheus.Collector) error {
+	if err := r.Registerer.Register(c); err != nil {
+		return err
+	}
+	r.capture(c)
+	return nil
+}
+
+func (r *headChunksCapturingRegisterer) MustRegister(cs ...prometheus.Collector) {
+	r.Registerer.MustRegister(cs...)
+	for _, c := range cs {
+		r.capture(c)
+	}
+}
+
+func (r *headChunksCapturingRegisterer) capture(c prometheus.Collector) {
+	if g, ok := c.(prometheus.Gauge); ok && g.Desc().String() == headChunksDesc.String() {
+		r.gauge = g
+	}
+}
+
+const (
+	earlyCompactionReasonInMemorySeries = "in_memory_series"
+	earlyCompactionReasonMemoryPressure = "memory_pressure"
+)
+
+// earlyCompactionDecision records why and when the TSDB Head of a tenant has been early compacted.
+type earlyCompactionDecision struct {
+	time   time.Time
+	reason string
+	// Estimated memory used by the TSDB Head when the decision was taken.
+	headMemoryBytes int64
+}
+
+// updateNativeHistogramsMemoryBytes updates the approximate additional memory used by the native histogram series,
+// given the current number of active native histogram series and buckets.
+func (u *userTSDB) updateNativeHistogramsMemoryBytes(nativeHistograms, nativeHistogramBuckets int) {
+	u.nativeHistogramsMemoryBytes.Store(int64(nativeHistograms)*estimatedNativeHistogramSeriesBytes + int64(nativeHistogramBuckets)*estimatedNativeHistogramBucketBytes)
+}
+
+// addNativeHistogramsMemoryBytes adds the approximate additional memory used by the given number of new native
+// histogram series and buckets. It's overwritten by the next update from the active series.
+func (u *userTSDB) addNativeHistogramsMemoryBytes(nativeHistograms, nativeHistogramBuckets int) {
+	u.nativeHistogramsMemoryBytes.Add(int64(nativeHistograms)*estimatedNativeHistogramSeriesBytes + int64(nativeHistogramBuckets)*estimatedNativeHistogramBucketBytes)
+}
+
+// estimatedSeriesMemoryBytes returns the approximate memory used by an in-memory series with the given labels.
+func estimatedSeriesMemoryBytes(metric labels.Labels) int64 {
+	bytes := int64(estimatedSeriesOverheadBytes)
+	metric.Range(func(l labels.Label) {
+		bytes += int64(len(l.Name) + len(l.Value) + estimatedLabelOverheadBytes)
+	})
+	return bytes
+}
+
 // blocksToDelete filters the input blocks and returns the blocks which are safe to be deleted from the ingester.
 func (u *userTSDB) blocksToDelete(blocks []*tsdb.Block) map[ulid.ULID]struct{} {
 	if u.db == nil {
@@ -473,7 +623,14 @@ func (u *userTSDB) shouldCloseTSDB(idleTimeout time.Duration) tsdbCloseCheckResu
 // acquireAppendLock acquires a lock to append to the per-tenant TSDB. The minTimestamp
 // parameter must specify the lowest timestamp value that is going to be appended to
 // TSDB while the lock is held.
-func (u *userTSDB) acquireAppendLock(minTimestamp int64) (tsdbState, error) {
+func (u *userTSDB) acquireAppendLock(minTimestamp int64) (_ tsdbState, returnErr error) {
+	u.appendsMtx.RLock()
+	defer func() {
+		if returnErr != nil {
+			u.appendsMtx.RUnlock()
+		}
+	}()
+
 	u.stateMtx.RLock()
 	defer u.stateMtx.RUnlock()
 
@@ -509,6 +666,16 @@ func (u *userTSDB) releaseAppendLock(acquireState tsdbState) {
 	if acquireState != forceCompacting {
 		u.inFlightAppendsStartedBeforeForcedCompaction.Done()
 	}
+	u.appendsMtx.RUnlock()
+}
+
+// withAppendsBlocked waits for the in-flight appends to complete and runs f. The appends started
+// meanwhile wait for f to return.
+func (u *userTSDB) withAppendsBlocked(f func() error) error {
+	u.appendsMtx.Lock()
+	defer u.appendsMtx.Unlock()
+
+	return f()
 }
 
 // ownedSeriesState returns a copy of the current state
@@ -630,3 +797,24 @@ func (u *userTSDB) computeOwnedSeries() int {
 	})
 	return count
 }
+
+// applySeriesDeletionRequests deletes the series of the requests from the head, unless already done.
+func (u *userTSDB) applySeriesDeletionRequests(ctx context.Context, reqs mimir_tsdb.SeriesDeletionRequests) error {
+	if u.appliedSeriesDeletionRequests == nil {
+		u.appliedSeriesDeletionRequests = map[string]struct{}{}
+	}
+
+	for _, req := range reqs {
+		if _, ok := u.appliedSeriesDeletionRequests[req.ID]; ok {
+			continue
+		}
+
+		for _, matchers := range req.Matchers() {
+			if err := u.Head().Delete(ctx, req.MinTime, req.MaxTime, matchers...); err != nil {
+				return errors.Wrapf(err, "apply series deletion request %s", req.ID)
+			}
+		}
+		u.appliedSeriesDeletionRequests[req.ID] = struct{}{}
+	}
+	return nil
+}
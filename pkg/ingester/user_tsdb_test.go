@@ -11,9 +11,11 @@ import (
 	"github.com/go-kit/log"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
 	"github.com/grafana/mimir/pkg/util/validation"
 )
 
@@ -311,3 +313,55 @@ func TestRecomputeOwnedSeries(t *testing.T) {
 		require.Equal(t, math.MaxInt32, db.ownedState.localSeriesLimit)
 	})
 }
+
+func TestUserTSDB_applySeriesDeletionRequests(t *testing.T) {
+	ctx := context.Background()
+	tsdbDB, err := tsdb.Open(t.TempDir(), log.NewNopLogger(), nil, tsdb.DefaultOptions(), nil)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, tsdbDB.Close())
+	})
+
+	app := tsdbDB.Appender(ctx)
+	for _, ts := range []int64{10, 20, 30} {
+		_, err = app.Append(0, labels.FromStrings("series", "1"), ts, float64(ts))
+		require.NoError(t, err)
+		_, err = app.Append(0, labels.FromStrings("series", "2"), ts, float64(ts))
+		require.NoError(t, err)
+	}
+	require.NoError(t, app.Commit())
+
+	db := userTSDB{db: tsdbDB}
+
+	req, err := mimir_tsdb.NewSeriesDeletionRequest([]string{`{series="1"}`}, 15, 25, time.Now())
+	require.NoError(t, err)
+	require.NoError(t, db.applySeriesDeletionRequests(ctx, mimir_tsdb.SeriesDeletionRequests{req}))
+	require.Contains(t, db.appliedSeriesDeletionRequests, req.ID)
+
+	// Applying the same request again is a no-op.
+	require.NoError(t, db.applySeriesDeletionRequests(ctx, mimir_tsdb.SeriesDeletionRequests{req}))
+	require.Len(t, db.appliedSeriesDeletionRequests, 1)
+
+	q, err := tsdbDB.Querier(0, 100)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, q.Close())
+	})
+
+	actual := map[string][]int64{}
+	set := q.Select(ctx, true, nil, labels.MustNewMatcher(labels.MatchRegexp, "series", ".+"))
+	for set.Next() {
+		it := set.At().Iterator(nil)
+		for it.Next() != chunkenc.ValNone {
+			ts, _ := it.At()
+			actual[set.At().Labels().Get("series")] = append(actual[set.At().Labels().Get("series")], ts)
+		}
+		require.NoError(t, it.Err())
+	}
+	require.NoError(t, set.Err())
+
+	require.Equal(t, map[string][]int64{
+		"1": {10, 30},
+		"2": {10, 20, 30},
+	}, actual)
+}
@@ -255,6 +255,10 @@ func (c *Config) Validate(log log.Logger) error {
 		if !c.IngestStorage.Enabled && !c.Ingester.PushGrpcMethodEnabled {
 			return errors.New("cannot disable Push gRPC method in ingester, while ingest storage (-ingest-storage.enabled) is not enabled")
 		}
+		if c.IngestStorage.Enabled && c.BlocksStorage.TSDB.HeadSnapshotUploadEnabled {
+			// The partition is consumed from the last committed offset, so the data ingested between the last head snapshot and that offset would be missing.
+			return errors.New("the TSDB head snapshots upload (-blocks-storage.tsdb.head-snapshot-upload-enabled) is not supported when ingest storage (-ingest-storage.enabled) is enabled")
+		}
 	}
 	if err := c.BlocksStorage.Validate(c.Ingester.ActiveSeriesMetrics); err != nil {
 		return errors.Wrap(err, "invalid TSDB config")
@@ -721,6 +725,7 @@ type Mimir struct {
 	QuerierQueryable                prom_storage.SampleAndChunkQueryable
 	ExemplarQueryable               prom_storage.ExemplarQueryable
 	AdditionalStorageQueryables     []querier.TimeRangeQueryable
+	SeriesDeletionRequestsLoader    *tsdb.SeriesDeletionRequestsLoader
 	MetadataSupplier                querier.MetadataSupplier
 	QuerierEngine                   promql.QueryEngine
 	QueryFrontendTripperware        querymiddleware.Tripperware
@@ -730,6 +735,7 @@ type Mimir struct {
 	RulerStorage                    rulestore.RuleStore
 	Alertmanager                    *alertmanager.MultitenantAlertmanager
 	Compactor                       *compactor.MultitenantCompactor
+	CompactorScheduler              *compactor.CompactorScheduler
 	StoreGateway                    *storegateway.StoreGateway
 	MemberlistKV                    *memberlist.KVInitService
 	ActivityTracker                 *activitytracker.ActivityTracker
@@ -768,6 +774,9 @@ func New(cfg Config, reg prometheus.Registerer) (*Mimir, error) {
 			"/frontend.Frontend/Process",
 			"/frontend.Frontend/NotifyClientShutdown",
 			"/ruler.Ruler/SyncRules",
+			"/compactorschedulerpb.CompactorScheduler/AssignJob",
+			"/compactorschedulerpb.CompactorScheduler/UpdateJob",
+			"/compactorschedulerpb.CompactorScheduler/ListJobs",
 			"/schedulerpb.SchedulerForFrontend/FrontendLoop",
 			"/schedulerpb.SchedulerForQuerier/QuerierLoop",
 			"/schedulerpb.SchedulerForQuerier/NotifyQuerierShutdown",
@@ -436,6 +436,20 @@ func TestConfigValidation(t *testing.T) {
 			},
 			expectAnyError: true,
 		},
+		{
+			name: "should fail if TSDB head snapshots upload is enabled in ingester running with ingest storage",
+			getTestConfig: func() *Config {
+				cfg := newDefaultConfig()
+				_ = cfg.Target.Set("ingester")
+				cfg.IngestStorage.Enabled = true
+				cfg.IngestStorage.KafkaConfig.Address = "localhost:9092"
+				cfg.IngestStorage.KafkaConfig.Topic = "test"
+				cfg.BlocksStorage.TSDB.HeadSnapshotUploadEnabled = true
+
+				return cfg
+			},
+			expectAnyError: true,
+		},
 		{
 			name: "should fail if ingester ring is misconfigured with spread-minimizing token generation strategy when target is ingester",
 			getTestConfig: func() *Config {
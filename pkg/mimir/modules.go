@@ -34,6 +34,7 @@ import (
 	"github.com/prometheus/prometheus/rules"
 	prom_storage "github.com/prometheus/prometheus/storage"
 	prom_remote "github.com/prometheus/prometheus/storage/remote"
+	"github.com/thanos-io/objstore"
 
 	"github.com/grafana/mimir/pkg/alertmanager"
 	"github.com/grafana/mimir/pkg/alertmanager/alertstore"
@@ -57,6 +58,7 @@ import (
 	"github.com/grafana/mimir/pkg/scheduler"
 	"github.com/grafana/mimir/pkg/storage/bucket"
 	"github.com/grafana/mimir/pkg/storage/ingest"
+	"github.com/grafana/mimir/pkg/storage/tsdb"
 	"github.com/grafana/mimir/pkg/storegateway"
 	"github.com/grafana/mimir/pkg/usagestats"
 	"github.com/grafana/mimir/pkg/util"
@@ -96,6 +98,7 @@ const (
 	Ruler                           string = "ruler"
 	AlertManager                    string = "alertmanager"
 	Compactor                       string = "compactor"
+	CompactorScheduler              string = "compactor-scheduler"
 	StoreGateway                    string = "store-gateway"
 	MemberlistKV                    string = "memberlist-kv"
 	QueryScheduler                  string = "query-scheduler"
@@ -461,6 +464,7 @@ func (t *Mimir) initDistributorService() (serv services.Service, err error) {
 	t.Cfg.Distributor.MinimiseIngesterRequestsHedgingDelay = t.Cfg.Querier.MinimiseIngesterRequestsHedgingDelay
 	t.Cfg.Distributor.PreferAvailabilityZone = t.Cfg.Querier.PreferAvailabilityZone
 	t.Cfg.Distributor.IngestStorageConfig = t.Cfg.IngestStorage
+	t.Cfg.Distributor.BucketConfig = t.Cfg.BlocksStorage.Bucket
 
 	t.Distributor, err = distributor.New(t.Cfg.Distributor, t.Cfg.IngesterClient, t.Overrides, t.ActiveGroupsCleanup, t.IngesterRing, t.IngesterPartitionInstanceRing, canJoinDistributorsRing, t.Registerer, util_log.Logger)
 	if err != nil {
@@ -492,10 +496,24 @@ func (t *Mimir) initQueryable() (serv services.Service, err error) {
 	if err != nil {
 		return nil, fmt.Errorf("could not create queryable: %w", err)
 	}
+	if t.QuerierQueryable, err = t.wrapSeriesDeletionQueryable(t.QuerierQueryable); err != nil {
+		return nil, err
+	}
+	t.QuerierQueryable = querier.NewSeriesRetentionQueryable(t.QuerierQueryable, t.Overrides)
 
 	// Use the distributor to return metric metadata by default
 	t.MetadataSupplier = t.Distributor
 
+	// Merge the metric metadata held by the ingesters with the metadata index, if durable metric metadata is enabled.
+	if t.Cfg.BlocksStorage.DurableMetricsMetadataEnabled {
+		bucketClient, err := bucket.NewClient(context.Background(), t.Cfg.BlocksStorage.Bucket, "metadata-index", util_log.Logger, t.Registerer)
+		if err != nil {
+			return nil, fmt.Errorf("could not create bucket client for the metadata index: %w", err)
+		}
+		// The metadata index is cached for as long as the bucket index is considered up to date by the queriers.
+		t.MetadataSupplier = querier.NewDurableMetadataSupplier(t.MetadataSupplier, bucketClient, t.Overrides, t.Cfg.BlocksStorage.BucketStore.SyncInterval, util_log.Logger)
+	}
+
 	// Register the default endpoints that are always enabled for the querier module
 	t.API.RegisterQueryable(t.Distributor)
 
@@ -575,6 +593,15 @@ func (t *Mimir) initQuerier() (serv services.Service, err error) {
 	t.Cfg.Worker.MaxConcurrentRequests = t.Cfg.Querier.EngineConfig.MaxConcurrent
 	t.Cfg.Worker.QuerySchedulerDiscovery = t.Cfg.QueryScheduler.ServiceDiscovery
 
+	// The metrics usage tracked by the ingesters and store-gateways is read from the bucket, if enabled.
+	var metricsUsageBucket objstore.Bucket
+	if t.Cfg.BlocksStorage.MetricsUsageTrackingEnabled {
+		metricsUsageBucket, err = bucket.NewClient(context.Background(), t.Cfg.BlocksStorage.Bucket, "metrics-usage", util_log.Logger, t.Registerer)
+		if err != nil {
+			return nil, fmt.Errorf("could not create bucket client for the metrics usage: %w", err)
+		}
+	}
+
 	// Create an internal HTTP handler that is configured with the Prometheus API routes and points
 	// to a Prometheus API struct instantiated with the Mimir Queryable.
 	internalQuerierRouter := api.NewQuerierHandler(
@@ -587,6 +614,7 @@ func (t *Mimir) initQuerier() (serv services.Service, err error) {
 		t.Registerer,
 		util_log.Logger,
 		t.Overrides,
+		metricsUsageBucket,
 	)
 
 	// If the querier is running standalone without the query-frontend or query-scheduler, we must register it's internal
@@ -635,10 +663,32 @@ func (t *Mimir) initStoreQueryable() (services.Service, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize block store queryable: %v", err)
 	}
-	t.AdditionalStorageQueryables = append(t.AdditionalStorageQueryables, querier.NewStoreGatewayTimeRangeQueryable(q, t.Cfg.Querier))
+	storeQueryable := querier.NewStoreGatewayTimeRangeQueryable(q, t.Cfg.Querier)
+	if t.Cfg.BlocksStorage.LongTermExemplarsEnabled {
+		storeQueryable.ExemplarQueryable = q
+	}
+	t.AdditionalStorageQueryables = append(t.AdditionalStorageQueryables, storeQueryable)
 	return q, nil
 }
 
+// wrapSeriesDeletionQueryable wraps the queryable to filter out the samples deleted by the series deletion requests,
+// if series deletion is enabled.
+func (t *Mimir) wrapSeriesDeletionQueryable(q prom_storage.SampleAndChunkQueryable) (prom_storage.SampleAndChunkQueryable, error) {
+	if !t.Cfg.BlocksStorage.SeriesDeletionEnabled {
+		return q, nil
+	}
+
+	if t.SeriesDeletionRequestsLoader == nil {
+		bucketClient, err := bucket.NewClient(context.Background(), t.Cfg.BlocksStorage.Bucket, "series-deletion", util_log.Logger, t.Registerer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create series deletion bucket client: %w", err)
+		}
+		t.SeriesDeletionRequestsLoader = tsdb.NewSeriesDeletionRequestsLoader(bucketClient, t.Cfg.BlocksStorage.SeriesDeletionSyncInterval, util_log.Logger)
+	}
+
+	return querier.NewSeriesDeletionQueryable(q, t.SeriesDeletionRequestsLoader), nil
+}
+
 func (t *Mimir) initActiveGroupsCleanupService() (services.Service, error) {
 	t.ActiveGroupsCleanup = util.NewActiveGroupsCleanupService(3*time.Minute, t.Cfg.Ingester.ActiveSeriesMetrics.IdleTimeout, t.Cfg.MaxSeparateMetricsGroupsPerUser)
 	return t.ActiveGroupsCleanup, nil
@@ -868,12 +918,17 @@ func (t *Mimir) initRuler() (serv services.Service, err error) {
 		// TODO: Consider wrapping logger to differentiate from querier module logger
 		rulerRegisterer := prometheus.WrapRegistererWith(rulerEngine, t.Registerer)
 
-		queryable, _, eng, err := querier.New(t.Cfg.Querier, t.Overrides, t.Distributor, t.AdditionalStorageQueryables, rulerRegisterer, util_log.Logger, t.ActivityTracker)
+		rulerQueryable, _, eng, err := querier.New(t.Cfg.Querier, t.Overrides, t.Distributor, t.AdditionalStorageQueryables, rulerRegisterer, util_log.Logger, t.ActivityTracker)
 		if err != nil {
 			return nil, fmt.Errorf("could not create queryable for ruler: %w", err)
 		}
+		rulerQueryable, err = t.wrapSeriesDeletionQueryable(rulerQueryable)
+		if err != nil {
+			return nil, err
+		}
+		rulerQueryable = querier.NewSeriesRetentionQueryable(rulerQueryable, t.Overrides)
 
-		queryable = querier.NewErrorTranslateQueryableWithFn(queryable, ruler.WrapQueryableErrors)
+		queryable = querier.NewErrorTranslateQueryableWithFn(rulerQueryable, ruler.WrapQueryableErrors)
 
 		if t.Cfg.Ruler.TenantFederation.Enabled {
 			if !t.Cfg.TenantFederation.Enabled {
@@ -1001,11 +1056,39 @@ func (t *Mimir) initCompactor() (serv services.Service, err error) {
 		return
 	}
 
+	if t.Cfg.Compactor.TenantOperationsEnabled {
+		// The stores metrics are not registered, because they would conflict with the ones of the ruler and the
+		// alertmanager when running in the same process.
+		ruleStore, err := ruler.NewRuleStore(context.Background(), t.Cfg.RulerStorage, t.Overrides, rules.FileLoader{}, 0, util_log.Logger, nil)
+		if err != nil {
+			return nil, err
+		}
+		alertStore, err := alertstore.NewAlertStore(context.Background(), t.Cfg.AlertmanagerStorage, t.Overrides, bucketclient.BucketAlertStoreConfig{}, util_log.Logger, nil)
+		if err != nil {
+			return nil, err
+		}
+		t.Compactor.SetTenantOperationsStores(ruleStore, alertStore)
+	}
+
 	// Expose HTTP endpoints.
 	t.API.RegisterCompactor(t.Compactor)
 	return t.Compactor, nil
 }
 
+func (t *Mimir) initCompactorScheduler() (serv services.Service, err error) {
+	// Distinguish the metrics shared with the compactor, in case both run in the same process.
+	reg := prometheus.WrapRegistererWith(prometheus.Labels{"component": CompactorScheduler}, t.Registerer)
+
+	t.CompactorScheduler, err = compactor.NewCompactorScheduler(t.Cfg.Compactor, t.Cfg.BlocksStorage, t.Overrides, util_log.Logger, reg)
+	if err != nil {
+		return
+	}
+
+	// Expose HTTP endpoints.
+	t.API.RegisterCompactorScheduler(t.CompactorScheduler)
+	return t.CompactorScheduler, nil
+}
+
 func (t *Mimir) initStoreGateway() (serv services.Service, err error) {
 	t.Cfg.StoreGateway.ShardingRing.ListenPort = t.Cfg.Server.GRPCListenPort
 	t.StoreGateway, err = storegateway.NewStoreGateway(t.Cfg.StoreGateway, t.Cfg.BlocksStorage, t.Overrides, util_log.Logger, t.Registerer, t.ActivityTracker)
@@ -1152,6 +1235,7 @@ func (t *Mimir) setupModuleManager() error {
 	mm.RegisterModule(Ruler, t.initRuler)
 	mm.RegisterModule(AlertManager, t.initAlertManager)
 	mm.RegisterModule(Compactor, t.initCompactor)
+	mm.RegisterModule(CompactorScheduler, t.initCompactorScheduler)
 	mm.RegisterModule(StoreGateway, t.initStoreGateway)
 	mm.RegisterModule(QueryScheduler, t.initQueryScheduler)
 	mm.RegisterModule(TenantFederation, t.initTenantFederation, modules.UserInvisibleModule)
@@ -1191,6 +1275,7 @@ func (t *Mimir) setupModuleManager() error {
 		RulerStorage:                    {Overrides},
 		AlertManager:                    {API, MemberlistKV, Overrides, Vault},
 		Compactor:                       {API, MemberlistKV, Overrides, Vault},
+		CompactorScheduler:              {API, Overrides},
 		StoreGateway:                    {API, Overrides, MemberlistKV, Vault},
 		TenantFederation:                {Queryable},
 		BlockBuilder:                    {API, Overrides},
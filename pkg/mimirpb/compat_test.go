@@ -8,6 +8,7 @@ package mimirpb
 import (
 	stdlibjson "encoding/json"
 	"math"
+	"reflect"
 	"strconv"
 	"testing"
 	"unsafe"
@@ -740,7 +741,20 @@ func TestCompareLabelAdapters(t *testing.T) {
 }
 
 func TestRemoteWriteV1HistogramEquivalence(t *testing.T) {
-	test.RequireSameShape(t, prompb.Histogram{}, Histogram{}, false, true)
+	// The custom_values field of Histogram is not part of the vendored remote write 1.0 Histogram yet,
+	// so we compare against the remote write 1.0 Histogram extended with it.
+	promType := reflect.TypeOf(prompb.Histogram{})
+	customValues, _ := reflect.TypeOf(Histogram{}).FieldByName("CustomValues")
+
+	var fields []reflect.StructField
+	for i := 0; i < promType.NumField(); i++ {
+		if f := promType.Field(i); f.Name == "XXX_NoUnkeyedLiteral" {
+			fields = append(fields, customValues)
+		}
+		fields = append(fields, promType.Field(i))
+	}
+
+	test.RequireSameShape(t, reflect.New(reflect.StructOf(fields)).Elem().Interface(), Histogram{}, false, true)
 }
 
 // The main usecase for `LabelsToKeyString` is to generate hashKeys
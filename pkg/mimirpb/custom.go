@@ -80,6 +80,9 @@ func (h Histogram) IsGauge() bool {
 // Returns the resulting bucket count and an error if the histogram is not
 // possible to reduce further.
 func (h *Histogram) ReduceResolution() (int, error) {
+	if histogram.IsCustomBucketsSchema(h.Schema) {
+		return 0, fmt.Errorf("cannot reduce resolution of histogram with custom buckets")
+	}
 	if h.IsFloatHistogram() {
 		return h.reduceFloatResolution()
 	}
@@ -159,3 +159,28 @@ func TestIsFloatHistogram(t *testing.T) {
 		})
 	}
 }
+
+func TestHistogram_CustomBuckets(t *testing.T) {
+	h := &histogram.Histogram{
+		Schema:          histogram.CustomBucketsSchema,
+		Count:           5,
+		Sum:             12.5,
+		PositiveSpans:   []histogram.Span{{Offset: 0, Length: 3}},
+		PositiveBuckets: []int64{1, 1, 0},
+		CustomValues:    []float64{0.5, 1, 2.5},
+	}
+
+	// The custom bucket boundaries survive a protobuf round trip.
+	orig := FromHistogramToHistogramProto(1234, h)
+	data, err := orig.Marshal()
+	require.NoError(t, err)
+
+	var decoded Histogram
+	require.NoError(t, decoded.Unmarshal(data))
+	assert.Equal(t, orig, decoded)
+	assert.Equal(t, h, FromHistogramProtoToHistogram(&decoded))
+
+	// The resolution of custom buckets can't be reduced.
+	_, err = decoded.ReduceResolution()
+	require.Error(t, err)
+}
@@ -140,7 +140,7 @@ var MetricMetadata_MetricType_value = map[string]int32{
 }
 
 func (MetricMetadata_MetricType) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{6, 0}
+	return fileDescriptor_86d4d7485f544059, []int{7, 0}
 }
 
 type Histogram_ResetHint int32
@@ -167,7 +167,7 @@ var Histogram_ResetHint_value = map[string]int32{
 }
 
 func (Histogram_ResetHint) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{9, 0}
+	return fileDescriptor_86d4d7485f544059, []int{10, 0}
 }
 
 // These values correspond to the possible status values defined in https://github.com/prometheus/prometheus/blob/main/web/api/v1/api.go.
@@ -189,7 +189,7 @@ var QueryResponse_Status_value = map[string]int32{
 }
 
 func (QueryResponse_Status) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{16, 0}
+	return fileDescriptor_86d4d7485f544059, []int{17, 0}
 }
 
 // These values correspond to the possible error type values defined in https://github.com/prometheus/prometheus/blob/main/web/api/v1/api.go.
@@ -232,7 +232,7 @@ var QueryResponse_ErrorType_value = map[string]int32{
 }
 
 func (QueryResponse_ErrorType) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{16, 1}
+	return fileDescriptor_86d4d7485f544059, []int{17, 1}
 }
 
 type WriteRequest struct {
@@ -345,6 +345,8 @@ var xxx_messageInfo_WriteResponse proto.InternalMessageInfo
 
 type ErrorDetails struct {
 	Cause ErrorCause `protobuf:"varint,1,opt,name=Cause,proto3,enum=cortexpb.ErrorCause" json:"Cause,omitempty"`
+	// The samples of the push request rejected by the ingester because too old or out of order.
+	RejectedSamples []RejectedSeriesSamples `protobuf:"bytes,2,rep,name=RejectedSamples,proto3" json:"RejectedSamples"`
 }
 
 func (m *ErrorDetails) Reset()      { *m = ErrorDetails{} }
@@ -386,6 +388,65 @@ func (m *ErrorDetails) GetCause() ErrorCause {
 	return UNKNOWN_CAUSE
 }
 
+func (m *ErrorDetails) GetRejectedSamples() []RejectedSeriesSamples {
+	if m != nil {
+		return m.RejectedSamples
+	}
+	return nil
+}
+
+type RejectedSeriesSamples struct {
+	// Index of the series in the push request.
+	SeriesIndex  int32   `protobuf:"varint,1,opt,name=SeriesIndex,proto3" json:"SeriesIndex,omitempty"`
+	TimestampsMs []int64 `protobuf:"varint,2,rep,packed,name=TimestampsMs,proto3" json:"TimestampsMs,omitempty"`
+}
+
+func (m *RejectedSeriesSamples) Reset()      { *m = RejectedSeriesSamples{} }
+func (*RejectedSeriesSamples) ProtoMessage() {}
+func (*RejectedSeriesSamples) Descriptor() ([]byte, []int) {
+	return fileDescriptor_86d4d7485f544059, []int{3}
+}
+func (m *RejectedSeriesSamples) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *RejectedSeriesSamples) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_RejectedSeriesSamples.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *RejectedSeriesSamples) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RejectedSeriesSamples.Merge(m, src)
+}
+func (m *RejectedSeriesSamples) XXX_Size() int {
+	return m.Size()
+}
+func (m *RejectedSeriesSamples) XXX_DiscardUnknown() {
+	xxx_messageInfo_RejectedSeriesSamples.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RejectedSeriesSamples proto.InternalMessageInfo
+
+func (m *RejectedSeriesSamples) GetSeriesIndex() int32 {
+	if m != nil {
+		return m.SeriesIndex
+	}
+	return 0
+}
+
+func (m *RejectedSeriesSamples) GetTimestampsMs() []int64 {
+	if m != nil {
+		return m.TimestampsMs
+	}
+	return nil
+}
+
 type TimeSeries struct {
 	Labels []LabelAdapter `protobuf:"bytes,1,rep,name=labels,proto3,customtype=LabelAdapter" json:"labels"`
 	// Sorted by time, oldest sample first.
@@ -400,7 +461,7 @@ type TimeSeries struct {
 func (m *TimeSeries) Reset()      { *m = TimeSeries{} }
 func (*TimeSeries) ProtoMessage() {}
 func (*TimeSeries) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{3}
+	return fileDescriptor_86d4d7485f544059, []int{4}
 }
 func (m *TimeSeries) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -458,7 +519,7 @@ type LabelPair struct {
 func (m *LabelPair) Reset()      { *m = LabelPair{} }
 func (*LabelPair) ProtoMessage() {}
 func (*LabelPair) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{4}
+	return fileDescriptor_86d4d7485f544059, []int{5}
 }
 func (m *LabelPair) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -510,7 +571,7 @@ type Sample struct {
 func (m *Sample) Reset()      { *m = Sample{} }
 func (*Sample) ProtoMessage() {}
 func (*Sample) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{5}
+	return fileDescriptor_86d4d7485f544059, []int{6}
 }
 func (m *Sample) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -563,7 +624,7 @@ type MetricMetadata struct {
 func (m *MetricMetadata) Reset()      { *m = MetricMetadata{} }
 func (*MetricMetadata) ProtoMessage() {}
 func (*MetricMetadata) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{6}
+	return fileDescriptor_86d4d7485f544059, []int{7}
 }
 func (m *MetricMetadata) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -627,7 +688,7 @@ type Metric struct {
 func (m *Metric) Reset()      { *m = Metric{} }
 func (*Metric) ProtoMessage() {}
 func (*Metric) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{7}
+	return fileDescriptor_86d4d7485f544059, []int{8}
 }
 func (m *Metric) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -666,7 +727,7 @@ type Exemplar struct {
 func (m *Exemplar) Reset()      { *m = Exemplar{} }
 func (*Exemplar) ProtoMessage() {}
 func (*Exemplar) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{8}
+	return fileDescriptor_86d4d7485f544059, []int{9}
 }
 func (m *Exemplar) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -748,12 +809,15 @@ type Histogram struct {
 	ResetHint      Histogram_ResetHint `protobuf:"varint,14,opt,name=reset_hint,json=resetHint,proto3,enum=cortexpb.Histogram_ResetHint" json:"reset_hint,omitempty"`
 	// timestamp is in ms format
 	Timestamp int64 `protobuf:"varint,15,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	// custom_values is an additional field used by non-exponential bucketing layouts.
+	// For custom buckets (-53 schema value) custom_values specify the boundaries of the buckets.
+	CustomValues []float64 `protobuf:"fixed64,16,rep,packed,name=custom_values,json=customValues,proto3" json:"custom_values,omitempty"`
 }
 
 func (m *Histogram) Reset()      { *m = Histogram{} }
 func (*Histogram) ProtoMessage() {}
 func (*Histogram) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{9}
+	return fileDescriptor_86d4d7485f544059, []int{10}
 }
 func (m *Histogram) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -931,6 +995,13 @@ func (m *Histogram) GetTimestamp() int64 {
 	return 0
 }
 
+func (m *Histogram) GetCustomValues() []float64 {
+	if m != nil {
+		return m.CustomValues
+	}
+	return nil
+}
+
 // XXX_OneofWrappers is for the internal use of the proto package.
 func (*Histogram) XXX_OneofWrappers() []interface{} {
 	return []interface{}{
@@ -961,7 +1032,7 @@ type FloatHistogram struct {
 func (m *FloatHistogram) Reset()      { *m = FloatHistogram{} }
 func (*FloatHistogram) ProtoMessage() {}
 func (*FloatHistogram) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{10}
+	return fileDescriptor_86d4d7485f544059, []int{11}
 }
 func (m *FloatHistogram) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1084,7 +1155,7 @@ type BucketSpan struct {
 func (m *BucketSpan) Reset()      { *m = BucketSpan{} }
 func (*BucketSpan) ProtoMessage() {}
 func (*BucketSpan) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{11}
+	return fileDescriptor_86d4d7485f544059, []int{12}
 }
 func (m *BucketSpan) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1136,7 +1207,7 @@ type FloatHistogramPair struct {
 func (m *FloatHistogramPair) Reset()      { *m = FloatHistogramPair{} }
 func (*FloatHistogramPair) ProtoMessage() {}
 func (*FloatHistogramPair) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{12}
+	return fileDescriptor_86d4d7485f544059, []int{13}
 }
 func (m *FloatHistogramPair) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1191,7 +1262,7 @@ type SampleHistogram struct {
 func (m *SampleHistogram) Reset()      { *m = SampleHistogram{} }
 func (*SampleHistogram) ProtoMessage() {}
 func (*SampleHistogram) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{13}
+	return fileDescriptor_86d4d7485f544059, []int{14}
 }
 func (m *SampleHistogram) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1252,7 +1323,7 @@ type HistogramBucket struct {
 func (m *HistogramBucket) Reset()      { *m = HistogramBucket{} }
 func (*HistogramBucket) ProtoMessage() {}
 func (*HistogramBucket) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{14}
+	return fileDescriptor_86d4d7485f544059, []int{15}
 }
 func (m *HistogramBucket) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1318,7 +1389,7 @@ type SampleHistogramPair struct {
 func (m *SampleHistogramPair) Reset()      { *m = SampleHistogramPair{} }
 func (*SampleHistogramPair) ProtoMessage() {}
 func (*SampleHistogramPair) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{15}
+	return fileDescriptor_86d4d7485f544059, []int{16}
 }
 func (m *SampleHistogramPair) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1379,7 +1450,7 @@ type QueryResponse struct {
 func (m *QueryResponse) Reset()      { *m = QueryResponse{} }
 func (*QueryResponse) ProtoMessage() {}
 func (*QueryResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{16}
+	return fileDescriptor_86d4d7485f544059, []int{17}
 }
 func (m *QueryResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1521,7 +1592,7 @@ type StringData struct {
 func (m *StringData) Reset()      { *m = StringData{} }
 func (*StringData) ProtoMessage() {}
 func (*StringData) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{17}
+	return fileDescriptor_86d4d7485f544059, []int{18}
 }
 func (m *StringData) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1572,7 +1643,7 @@ type VectorData struct {
 func (m *VectorData) Reset()      { *m = VectorData{} }
 func (*VectorData) ProtoMessage() {}
 func (*VectorData) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{18}
+	return fileDescriptor_86d4d7485f544059, []int{19}
 }
 func (m *VectorData) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1625,7 +1696,7 @@ type VectorSample struct {
 func (m *VectorSample) Reset()      { *m = VectorSample{} }
 func (*VectorSample) ProtoMessage() {}
 func (*VectorSample) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{19}
+	return fileDescriptor_86d4d7485f544059, []int{20}
 }
 func (m *VectorSample) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1685,7 +1756,7 @@ type VectorHistogram struct {
 func (m *VectorHistogram) Reset()      { *m = VectorHistogram{} }
 func (*VectorHistogram) ProtoMessage() {}
 func (*VectorHistogram) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{20}
+	return fileDescriptor_86d4d7485f544059, []int{21}
 }
 func (m *VectorHistogram) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1743,7 +1814,7 @@ type ScalarData struct {
 func (m *ScalarData) Reset()      { *m = ScalarData{} }
 func (*ScalarData) ProtoMessage() {}
 func (*ScalarData) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{21}
+	return fileDescriptor_86d4d7485f544059, []int{22}
 }
 func (m *ScalarData) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1793,7 +1864,7 @@ type MatrixData struct {
 func (m *MatrixData) Reset()      { *m = MatrixData{} }
 func (*MatrixData) ProtoMessage() {}
 func (*MatrixData) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{22}
+	return fileDescriptor_86d4d7485f544059, []int{23}
 }
 func (m *MatrixData) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1839,7 +1910,7 @@ type MatrixSeries struct {
 func (m *MatrixSeries) Reset()      { *m = MatrixSeries{} }
 func (*MatrixSeries) ProtoMessage() {}
 func (*MatrixSeries) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{23}
+	return fileDescriptor_86d4d7485f544059, []int{24}
 }
 func (m *MatrixSeries) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1899,6 +1970,7 @@ func init() {
 	proto.RegisterType((*WriteRequest)(nil), "cortexpb.WriteRequest")
 	proto.RegisterType((*WriteResponse)(nil), "cortexpb.WriteResponse")
 	proto.RegisterType((*ErrorDetails)(nil), "cortexpb.ErrorDetails")
+	proto.RegisterType((*RejectedSeriesSamples)(nil), "cortexpb.RejectedSeriesSamples")
 	proto.RegisterType((*TimeSeries)(nil), "cortexpb.TimeSeries")
 	proto.RegisterType((*LabelPair)(nil), "cortexpb.LabelPair")
 	proto.RegisterType((*Sample)(nil), "cortexpb.Sample")
@@ -1925,134 +1997,138 @@ func init() {
 func init() { proto.RegisterFile("mimir.proto", fileDescriptor_86d4d7485f544059) }
 
 var fileDescriptor_86d4d7485f544059 = []byte{
-	// 2029 bytes of a gzipped FileDescriptorProto
+	// 2088 bytes of a gzipped FileDescriptorProto
 	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x58, 0xcf, 0x93, 0xdb, 0x48,
-	0xf5, 0xb7, 0x6c, 0xf9, 0x87, 0xde, 0xd8, 0x33, 0x9d, 0x4e, 0x36, 0x5f, 0x6f, 0xbe, 0x1b, 0x27,
-	0xd1, 0x16, 0xcb, 0x90, 0x82, 0x09, 0xb5, 0x81, 0x6c, 0x6d, 0x2a, 0xfc, 0x90, 0x6d, 0x25, 0xe3,
-	0xc4, 0x96, 0x67, 0x5b, 0xf2, 0x84, 0x70, 0x51, 0x69, 0x3c, 0x3d, 0x33, 0xaa, 0xb5, 0x2c, 0x23,
-	0xc9, 0xd9, 0x0c, 0x27, 0x2e, 0x50, 0x14, 0x27, 0x2e, 0x5c, 0x28, 0x6e, 0x5c, 0xa8, 0xe2, 0x1f,
-	0x49, 0x15, 0x97, 0x1c, 0x17, 0x0e, 0x29, 0x32, 0xb9, 0x2c, 0x07, 0xaa, 0xb6, 0x28, 0x4e, 0x9c,
-	0xa8, 0xee, 0xd6, 0x4f, 0xcf, 0x0c, 0x04, 0xc8, 0x4d, 0xef, 0xbd, 0xcf, 0x7b, 0xfd, 0xba, 0xdf,
-	0x8f, 0x7e, 0x2d, 0x58, 0xf3, 0x5c, 0xcf, 0x0d, 0xb6, 0x16, 0x81, 0x1f, 0xf9, 0xb8, 0x31, 0xf5,
-	0x83, 0x88, 0x3e, 0x5b, 0xec, 0x5d, 0xf9, 0xc6, 0xa1, 0x1b, 0x1d, 0x2d, 0xf7, 0xb6, 0xa6, 0xbe,
-	0x77, 0xeb, 0xd0, 0x3f, 0xf4, 0x6f, 0x71, 0xc0, 0xde, 0xf2, 0x80, 0x53, 0x9c, 0xe0, 0x5f, 0x42,
-	0x51, 0xfd, 0x6b, 0x19, 0x9a, 0x8f, 0x03, 0x37, 0xa2, 0x84, 0xfe, 0x68, 0x49, 0xc3, 0x08, 0xef,
-	0x00, 0x44, 0xae, 0x47, 0x43, 0x1a, 0xb8, 0x34, 0x6c, 0x4b, 0xd7, 0x2b, 0x9b, 0x6b, 0x1f, 0x5e,
-	0xda, 0x4a, 0xcc, 0x6f, 0x59, 0xae, 0x47, 0x4d, 0x2e, 0xeb, 0x5e, 0x79, 0xfe, 0xf2, 0x5a, 0xe9,
-	0x4f, 0x2f, 0xaf, 0xe1, 0x9d, 0x80, 0x3a, 0xb3, 0x99, 0x3f, 0xb5, 0x52, 0x3d, 0x92, 0xb3, 0x81,
-	0x3f, 0x86, 0x9a, 0xe9, 0x2f, 0x83, 0x29, 0x6d, 0x97, 0xaf, 0x4b, 0x9b, 0xeb, 0x1f, 0xde, 0xc8,
-	0xac, 0xe5, 0x57, 0xde, 0x12, 0x20, 0x7d, 0xbe, 0xf4, 0x48, 0xac, 0x80, 0xef, 0x42, 0xc3, 0xa3,
-	0x91, 0xb3, 0xef, 0x44, 0x4e, 0xbb, 0xc2, 0x5d, 0x69, 0x67, 0xca, 0x23, 0x1a, 0x05, 0xee, 0x74,
-	0x14, 0xcb, 0xbb, 0xf2, 0xf3, 0x97, 0xd7, 0x24, 0x92, 0xe2, 0xf1, 0x6d, 0x78, 0x27, 0xfc, 0xd4,
-	0x5d, 0xd8, 0x33, 0x67, 0x8f, 0xce, 0xec, 0xa7, 0xce, 0xcc, 0xdd, 0x77, 0x22, 0xd7, 0x9f, 0xb7,
-	0xbf, 0xa8, 0x5f, 0x97, 0x36, 0x1b, 0xe4, 0x22, 0x93, 0x0e, 0x99, 0x70, 0x37, 0x95, 0xe1, 0xef,
-	0xc2, 0xff, 0xe7, 0x94, 0xa6, 0xfe, 0x72, 0x1e, 0xe5, 0x55, 0xff, 0x22, 0x54, 0xdb, 0xa9, 0x6a,
-	0x8f, 0x21, 0x32, 0x7d, 0xf5, 0x1a, 0x40, 0xb6, 0x0d, 0x5c, 0x87, 0x8a, 0xb6, 0x33, 0x40, 0x25,
-	0xdc, 0x00, 0x99, 0x4c, 0x86, 0x3a, 0x92, 0xd4, 0x0d, 0x68, 0xc5, 0x9b, 0x0e, 0x17, 0xfe, 0x3c,
-	0xa4, 0xea, 0x5d, 0x68, 0xea, 0x41, 0xe0, 0x07, 0x7d, 0x1a, 0x39, 0xee, 0x2c, 0xc4, 0x37, 0xa1,
-	0xda, 0x73, 0x96, 0x21, 0x6d, 0x4b, 0xfc, 0xb0, 0x72, 0x47, 0xcf, 0x61, 0x5c, 0x46, 0x04, 0x44,
-	0xfd, 0xbb, 0x04, 0x90, 0x05, 0x04, 0x6b, 0x50, 0xe3, 0x7e, 0x27, 0x61, 0xbb, 0x98, 0xe9, 0x72,
-	0x67, 0x77, 0x1c, 0x37, 0xe8, 0x5e, 0x8a, 0xa3, 0xd6, 0xe4, 0x2c, 0x6d, 0xdf, 0x59, 0x44, 0x34,
-	0x20, 0xb1, 0x22, 0xfe, 0x26, 0xd4, 0x43, 0xc7, 0x5b, 0xcc, 0x68, 0xd8, 0x2e, 0x73, 0x1b, 0x28,
-	0xb3, 0x61, 0x72, 0x01, 0x3f, 0xe7, 0x12, 0x49, 0x60, 0xf8, 0x0e, 0x28, 0xf4, 0x19, 0xf5, 0x16,
-	0x33, 0x27, 0x08, 0xe3, 0x18, 0xe1, 0x9c, 0xcf, 0xb1, 0x28, 0xd6, 0xca, 0xa0, 0xf8, 0x63, 0x80,
-	0x23, 0x37, 0x8c, 0xfc, 0xc3, 0xc0, 0xf1, 0xc2, 0xb6, 0xbc, 0xea, 0xf0, 0x76, 0x22, 0x8b, 0x35,
-	0x73, 0x60, 0xf5, 0xdb, 0xa0, 0xa4, 0xfb, 0xc1, 0x18, 0xe4, 0xb9, 0xe3, 0x89, 0xe3, 0x6a, 0x12,
-	0xfe, 0x8d, 0x2f, 0x41, 0xf5, 0xa9, 0x33, 0x5b, 0x8a, 0x84, 0x6b, 0x12, 0x41, 0xa8, 0x1a, 0xd4,
-	0xc4, 0x16, 0xf0, 0x0d, 0x68, 0xf2, 0xfc, 0x8c, 0x1c, 0x6f, 0x61, 0x7b, 0x21, 0x87, 0x55, 0xc8,
-	0x5a, 0xca, 0x1b, 0x85, 0x99, 0x09, 0x66, 0x57, 0x4a, 0x4c, 0xfc, 0xba, 0x0c, 0xeb, 0xc5, 0xb4,
-	0xc3, 0x1f, 0x81, 0x1c, 0x1d, 0x2f, 0x92, 0x70, 0xbd, 0x7f, 0x5e, 0x7a, 0xc6, 0xa4, 0x75, 0xbc,
-	0xa0, 0x84, 0x2b, 0xe0, 0xaf, 0x03, 0xf6, 0x38, 0xcf, 0x3e, 0x70, 0x3c, 0x77, 0x76, 0x6c, 0xf3,
-	0x6d, 0x30, 0x57, 0x14, 0x82, 0x84, 0xe4, 0x3e, 0x17, 0x18, 0x6c, 0x4b, 0x18, 0xe4, 0x23, 0x3a,
-	0x5b, 0xb4, 0x65, 0x2e, 0xe7, 0xdf, 0x8c, 0xb7, 0x9c, 0xbb, 0x51, 0xbb, 0x2a, 0x78, 0xec, 0x5b,
-	0x3d, 0x06, 0xc8, 0x56, 0xc2, 0x6b, 0x50, 0x9f, 0x18, 0x8f, 0x8c, 0xf1, 0x63, 0x03, 0x95, 0x18,
-	0xd1, 0x1b, 0x4f, 0x0c, 0x4b, 0x27, 0x48, 0xc2, 0x0a, 0x54, 0x1f, 0x68, 0x93, 0x07, 0x3a, 0x2a,
-	0xe3, 0x16, 0x28, 0xdb, 0x03, 0xd3, 0x1a, 0x3f, 0x20, 0xda, 0x08, 0x55, 0x30, 0x86, 0x75, 0x2e,
-	0xc9, 0x78, 0x32, 0x53, 0x35, 0x27, 0xa3, 0x91, 0x46, 0x9e, 0xa0, 0x2a, 0x4b, 0xe6, 0x81, 0x71,
-	0x7f, 0x8c, 0x6a, 0xb8, 0x09, 0x0d, 0xd3, 0xd2, 0x2c, 0xdd, 0xd4, 0x2d, 0x54, 0x57, 0x1f, 0x41,
-	0x4d, 0x2c, 0xfd, 0x16, 0x12, 0x51, 0xfd, 0x99, 0x04, 0x8d, 0x24, 0x79, 0xde, 0x46, 0x62, 0x17,
-	0x52, 0x22, 0x89, 0xe7, 0xa9, 0x44, 0xa8, 0x9c, 0x4a, 0x04, 0xf5, 0x0f, 0x55, 0x50, 0xd2, 0x64,
-	0xc4, 0x57, 0x41, 0x11, 0x4d, 0xc1, 0x9d, 0x47, 0x3c, 0xe4, 0xf2, 0x76, 0x89, 0x34, 0x38, 0x6b,
-	0x30, 0x8f, 0xf0, 0x0d, 0x58, 0x13, 0xe2, 0x83, 0x99, 0xef, 0x44, 0x62, 0xad, 0xed, 0x12, 0x01,
-	0xce, 0xbc, 0xcf, 0x78, 0x18, 0x41, 0x25, 0x5c, 0x7a, 0x7c, 0x25, 0x89, 0xb0, 0x4f, 0x7c, 0x19,
-	0x6a, 0xe1, 0xf4, 0x88, 0x7a, 0x0e, 0x0f, 0xee, 0x05, 0x12, 0x53, 0xf8, 0x2b, 0xb0, 0xfe, 0x63,
-	0x1a, 0xf8, 0x76, 0x74, 0x14, 0xd0, 0xf0, 0xc8, 0x9f, 0xed, 0xf3, 0x40, 0x4b, 0xa4, 0xc5, 0xb8,
-	0x56, 0xc2, 0xc4, 0x1f, 0xc4, 0xb0, 0xcc, 0xaf, 0x1a, 0xf7, 0x4b, 0x22, 0x4d, 0xc6, 0xef, 0x25,
-	0xbe, 0xdd, 0x04, 0x94, 0xc3, 0x09, 0x07, 0xeb, 0xdc, 0x41, 0x89, 0xac, 0xa7, 0x48, 0xe1, 0xa4,
-	0x06, 0xeb, 0x73, 0x7a, 0xe8, 0x44, 0xee, 0x53, 0x6a, 0x87, 0x0b, 0x67, 0x1e, 0xb6, 0x1b, 0xab,
-	0x17, 0x41, 0x77, 0x39, 0xfd, 0x94, 0x46, 0xe6, 0xc2, 0x99, 0xc7, 0x15, 0xda, 0x4a, 0x34, 0x18,
-	0x2f, 0xc4, 0x5f, 0x85, 0x8d, 0xd4, 0xc4, 0x3e, 0x9d, 0x45, 0x4e, 0xd8, 0x56, 0xae, 0x57, 0x36,
-	0x31, 0x49, 0x2d, 0xf7, 0x39, 0xb7, 0x00, 0xe4, 0xbe, 0x85, 0x6d, 0xb8, 0x5e, 0xd9, 0x94, 0x32,
-	0x20, 0x77, 0x8c, 0xb5, 0xb7, 0xf5, 0x85, 0x1f, 0xba, 0x39, 0xa7, 0xd6, 0xfe, 0xbd, 0x53, 0x89,
-	0x46, 0xea, 0x54, 0x6a, 0x22, 0x76, 0xaa, 0x29, 0x9c, 0x4a, 0xd8, 0x99, 0x53, 0x29, 0x30, 0x76,
-	0xaa, 0x25, 0x9c, 0x4a, 0xd8, 0xb1, 0x53, 0xf7, 0x00, 0x02, 0x1a, 0xd2, 0xc8, 0x3e, 0x62, 0x27,
-	0xbf, 0xce, 0x9b, 0xc0, 0xd5, 0x33, 0xda, 0xd8, 0x16, 0x61, 0xa8, 0x6d, 0x77, 0x1e, 0x11, 0x25,
-	0x48, 0x3e, 0xf1, 0x7b, 0xa0, 0xa4, 0xb9, 0xd6, 0xde, 0xe0, 0xc9, 0x97, 0x31, 0xd4, 0xbb, 0xa0,
-	0xa4, 0x5a, 0xc5, 0x52, 0xae, 0x43, 0xe5, 0x89, 0x6e, 0x22, 0x09, 0xd7, 0xa0, 0x6c, 0x8c, 0x51,
-	0x39, 0x2b, 0xe7, 0xca, 0x15, 0xf9, 0xe7, 0xbf, 0xed, 0x48, 0xdd, 0x3a, 0x54, 0xb9, 0xdf, 0xdd,
-	0x26, 0x40, 0x16, 0x76, 0xf5, 0x6f, 0x32, 0xac, 0xf3, 0x10, 0x67, 0x29, 0x1d, 0x02, 0xe6, 0x32,
-	0x1a, 0xd8, 0x2b, 0x3b, 0x69, 0x75, 0xf5, 0x7f, 0xbc, 0xbc, 0xa6, 0xe5, 0x06, 0x8a, 0x45, 0xe0,
-	0x7b, 0x34, 0x3a, 0xa2, 0xcb, 0x30, 0xff, 0xe9, 0xf9, 0xfb, 0x74, 0x76, 0x2b, 0x6d, 0xd0, 0x5b,
-	0x3d, 0x61, 0x2e, 0xdb, 0x31, 0x9a, 0xae, 0x70, 0xfe, 0xd7, 0x9c, 0xbf, 0x9a, 0xdf, 0x94, 0xc8,
-	0x62, 0xa2, 0xa4, 0x39, 0xcc, 0x8a, 0x5d, 0x48, 0xe2, 0x62, 0xe7, 0xc4, 0x19, 0x95, 0xf7, 0x16,
-	0x32, 0xea, 0x2d, 0x54, 0xca, 0xd7, 0x00, 0xa5, 0x5e, 0xec, 0x71, 0x6c, 0x92, 0x6c, 0x69, 0x0e,
-	0x0a, 0x13, 0x1c, 0x9a, 0xae, 0x96, 0x40, 0x45, 0xb1, 0xa4, 0x35, 0x94, 0x40, 0xdf, 0x87, 0xd6,
-	0x74, 0x19, 0x46, 0xbe, 0x67, 0xf3, 0x56, 0x17, 0xb6, 0x11, 0xc7, 0x35, 0x05, 0x73, 0x97, 0xf3,
-	0x1e, 0xca, 0x0d, 0x09, 0x95, 0x1f, 0xca, 0x8d, 0x1a, 0xaa, 0x3f, 0x94, 0x1b, 0x0a, 0x82, 0x87,
-	0x72, 0xa3, 0x89, 0x5a, 0x0f, 0xe5, 0xc6, 0x06, 0x42, 0x24, 0x6b, 0x75, 0x64, 0xa5, 0xc5, 0x90,
-	0xd5, 0xda, 0x26, 0xab, 0x75, 0x95, 0xcf, 0xe3, 0x7b, 0x00, 0xd9, 0x19, 0xb0, 0xd0, 0xfb, 0x07,
-	0x07, 0x21, 0x15, 0xfd, 0xf3, 0x02, 0x89, 0x29, 0xc6, 0x9f, 0xd1, 0xf9, 0x61, 0x74, 0xc4, 0xa3,
-	0xd6, 0x22, 0x31, 0xa5, 0x2e, 0x01, 0x17, 0x33, 0x96, 0x5f, 0xfb, 0x6f, 0x70, 0x85, 0xdf, 0x03,
-	0x25, 0xcd, 0x49, 0xbe, 0x56, 0x61, 0x7a, 0x2c, 0xda, 0x8c, 0xa7, 0xc7, 0x4c, 0x41, 0x9d, 0xc3,
-	0x86, 0x98, 0x16, 0xb2, 0x4a, 0x49, 0xd3, 0x4a, 0x3a, 0x23, 0xad, 0xca, 0x59, 0x5a, 0xdd, 0x86,
-	0x7a, 0x12, 0x1c, 0x31, 0x10, 0xbd, 0x7b, 0xd6, 0x5c, 0xc3, 0x11, 0x24, 0x41, 0xaa, 0x21, 0x6c,
-	0xac, 0xc8, 0x70, 0x07, 0x60, 0xcf, 0x5f, 0xce, 0xf7, 0x9d, 0x78, 0x14, 0x97, 0x36, 0xab, 0x24,
-	0xc7, 0x61, 0xfe, 0xcc, 0xfc, 0xcf, 0x68, 0x90, 0xa4, 0x39, 0x27, 0x18, 0x77, 0xb9, 0x58, 0xd0,
-	0x20, 0x4e, 0x74, 0x41, 0x64, 0xbe, 0xcb, 0x39, 0xdf, 0xd5, 0x19, 0x5c, 0x5c, 0xd9, 0x24, 0x3f,
-	0xdc, 0x42, 0x5b, 0x2a, 0xaf, 0xb4, 0x25, 0xfc, 0xd1, 0xe9, 0x73, 0x7d, 0x77, 0x75, 0x4a, 0x4c,
-	0xed, 0xe5, 0x8f, 0xf4, 0x8f, 0x32, 0xb4, 0x3e, 0x59, 0xd2, 0xe0, 0x38, 0x19, 0x7e, 0xf1, 0x1d,
-	0xa8, 0x85, 0x91, 0x13, 0x2d, 0xc3, 0x78, 0x7c, 0xea, 0x64, 0x76, 0x0a, 0xc0, 0x2d, 0x93, 0xa3,
-	0x48, 0x8c, 0xc6, 0xdf, 0x07, 0xa0, 0x6c, 0x1a, 0xb6, 0xf9, 0xe8, 0x75, 0xea, 0x59, 0x51, 0xd4,
-	0xe5, 0x73, 0x33, 0x1f, 0xbc, 0x14, 0x9a, 0x7c, 0xb2, 0xf3, 0xe0, 0x04, 0x3f, 0x25, 0x85, 0x08,
-	0x02, 0x6f, 0x31, 0x7f, 0x02, 0x77, 0x7e, 0xc8, 0x8f, 0xa9, 0x50, 0xc5, 0x26, 0xe7, 0xf7, 0x9d,
-	0xc8, 0xd9, 0x2e, 0x91, 0x18, 0xc5, 0xf0, 0x4f, 0xe9, 0x34, 0xf2, 0x03, 0xde, 0xa6, 0x0a, 0xf8,
-	0x5d, 0xce, 0x4f, 0xf0, 0x02, 0xc5, 0xed, 0x4f, 0x9d, 0x99, 0x13, 0xf0, 0x3b, 0xba, 0x68, 0x9f,
-	0xf3, 0x53, 0xfb, 0x9c, 0x62, 0x78, 0xcf, 0x89, 0x02, 0xf7, 0x19, 0xef, 0x71, 0x05, 0xfc, 0x88,
-	0xf3, 0x13, 0xbc, 0x40, 0xe1, 0x2b, 0xd0, 0xf8, 0xcc, 0x09, 0xe6, 0xee, 0xfc, 0x50, 0xf4, 0x21,
-	0x85, 0xa4, 0x34, 0xdb, 0xb1, 0x3b, 0x3f, 0xf0, 0xc5, 0x35, 0xac, 0x10, 0x41, 0xa8, 0x1f, 0x40,
-	0x4d, 0x9c, 0x2d, 0xbb, 0x42, 0x74, 0x42, 0xc6, 0x44, 0x4c, 0x8a, 0xe6, 0xa4, 0xd7, 0xd3, 0x4d,
-	0x13, 0x49, 0xe2, 0x3e, 0x51, 0x7f, 0x25, 0x81, 0x92, 0x1e, 0x24, 0x1b, 0x01, 0x8d, 0xb1, 0xa1,
-	0x0b, 0xa8, 0x35, 0x18, 0xe9, 0xe3, 0x89, 0x85, 0x24, 0x36, 0x0f, 0xf6, 0x34, 0xa3, 0xa7, 0x0f,
-	0xf5, 0xbe, 0x98, 0x2b, 0xf5, 0x1f, 0xe8, 0xbd, 0x89, 0x35, 0x18, 0x1b, 0xa8, 0xc2, 0x84, 0x5d,
-	0xad, 0x6f, 0xf7, 0x35, 0x4b, 0x43, 0x32, 0xa3, 0x06, 0x6c, 0x14, 0x35, 0xb4, 0x21, 0xaa, 0xe2,
-	0x0d, 0x58, 0x9b, 0x18, 0xda, 0xae, 0x36, 0x18, 0x6a, 0xdd, 0xa1, 0x8e, 0x6a, 0x4c, 0xd7, 0x18,
-	0x5b, 0xf6, 0xfd, 0xf1, 0xc4, 0xe8, 0xa3, 0x3a, 0x9b, 0x49, 0x19, 0xa9, 0xf5, 0x7a, 0xfa, 0x8e,
-	0xc5, 0x21, 0x8d, 0xf8, 0x9e, 0xab, 0x81, 0xcc, 0xc6, 0x6b, 0x55, 0x07, 0xc8, 0x22, 0x54, 0x9c,
-	0xde, 0x95, 0xf3, 0xa6, 0xbd, 0xd3, 0x3d, 0x43, 0xfd, 0xa9, 0x04, 0x90, 0x45, 0x0e, 0xdf, 0xc9,
-	0x9e, 0x43, 0x62, 0xf2, 0xbc, 0xbc, 0x1a, 0xe0, 0xb3, 0x1f, 0x45, 0xdf, 0x2b, 0x3c, 0x6e, 0xca,
-	0xab, 0x4d, 0x40, 0xa8, 0xfe, 0xab, 0x27, 0x8e, 0x0d, 0xcd, 0xbc, 0x7d, 0xd6, 0x1c, 0xc5, 0x93,
-	0x80, 0xfb, 0xa1, 0x90, 0x98, 0xfa, 0xef, 0xc7, 0xda, 0x5f, 0x48, 0xb0, 0xb1, 0xe2, 0xc6, 0xb9,
-	0x8b, 0x14, 0x1a, 0x69, 0xf9, 0x0d, 0x1a, 0x69, 0x29, 0x57, 0xf5, 0x6f, 0xe2, 0x0c, 0x0b, 0x5e,
-	0x9a, 0xfe, 0x67, 0x3f, 0xbd, 0xde, 0x24, 0x78, 0x5d, 0x80, 0xac, 0x2a, 0xf0, 0xb7, 0xa0, 0x56,
-	0xf8, 0x89, 0x71, 0x79, 0xb5, 0x76, 0xe2, 0xdf, 0x18, 0xc2, 0xe1, 0x18, 0xab, 0xfe, 0x46, 0x82,
-	0x66, 0x5e, 0x7c, 0xee, 0xa1, 0xfc, 0xe7, 0x2f, 0xe5, 0x6e, 0x21, 0x29, 0xc4, 0xcd, 0xf0, 0xde,
-	0x79, 0xe7, 0xc8, 0x9f, 0x34, 0xa7, 0xf2, 0xe2, 0xe6, 0xef, 0xcb, 0x00, 0xd9, 0x7f, 0x00, 0x7c,
-	0x01, 0x5a, 0xf1, 0x50, 0x68, 0xf7, 0xb4, 0x89, 0xc9, 0x0a, 0xf2, 0x0a, 0x5c, 0x26, 0xfa, 0xce,
-	0x70, 0xd0, 0xd3, 0x4c, 0xbb, 0x3f, 0xe8, 0xdb, 0xac, 0x6e, 0x46, 0x9a, 0xd5, 0xdb, 0x46, 0x12,
-	0x7e, 0x07, 0x2e, 0x58, 0xe3, 0xb1, 0x3d, 0xd2, 0x8c, 0x27, 0x76, 0x6f, 0x38, 0x31, 0x2d, 0x9d,
-	0x98, 0xa8, 0x5c, 0xa8, 0xcc, 0x0a, 0x33, 0x30, 0x30, 0x1e, 0xe8, 0x26, 0x2b, 0x5b, 0x9b, 0x68,
-	0x96, 0x6e, 0x0f, 0x07, 0xa3, 0x81, 0xa5, 0xf7, 0x91, 0x8c, 0xdb, 0x70, 0x89, 0xe8, 0x9f, 0x4c,
-	0x74, 0xd3, 0x2a, 0x4a, 0xaa, 0xac, 0x42, 0x07, 0x86, 0x69, 0xb1, 0xea, 0x17, 0x5c, 0x54, 0xc3,
-	0xff, 0x07, 0x17, 0x4d, 0x9d, 0xec, 0x0e, 0x7a, 0xba, 0x9d, 0xaf, 0xee, 0x3a, 0xbe, 0x04, 0xc8,
-	0x32, 0xfb, 0xdd, 0x02, 0xb7, 0xc1, 0xdc, 0x60, 0xde, 0x75, 0x27, 0xe6, 0x13, 0xa4, 0xb0, 0xa5,
-	0x7a, 0x03, 0xd2, 0x9b, 0x0c, 0x2c, 0xbb, 0x4b, 0x74, 0xed, 0x91, 0x4e, 0xec, 0xf1, 0x8e, 0x6e,
-	0x20, 0xc0, 0x97, 0x01, 0x8f, 0x74, 0x6b, 0x7b, 0x2c, 0xf6, 0xa6, 0x0d, 0x87, 0xe3, 0xc7, 0x7a,
-	0x1f, 0xad, 0x61, 0x04, 0x4d, 0x4b, 0x37, 0x34, 0xc3, 0x8a, 0x1d, 0x68, 0x76, 0xbf, 0xf3, 0xe2,
-	0x55, 0xa7, 0xf4, 0xf9, 0xab, 0x4e, 0xe9, 0xcb, 0x57, 0x1d, 0xe9, 0x27, 0x27, 0x1d, 0xe9, 0x77,
-	0x27, 0x1d, 0xe9, 0xf9, 0x49, 0x47, 0x7a, 0x71, 0xd2, 0x91, 0xfe, 0x7c, 0xd2, 0x91, 0xbe, 0x38,
-	0xe9, 0x94, 0xbe, 0x3c, 0xe9, 0x48, 0xbf, 0x7c, 0xdd, 0x29, 0xbd, 0x78, 0xdd, 0x29, 0x7d, 0xfe,
-	0xba, 0x53, 0xfa, 0x61, 0x9d, 0xff, 0x58, 0x5b, 0xec, 0xed, 0xd5, 0xf8, 0x2f, 0xb2, 0xdb, 0xff,
-	0x0c, 0x00, 0x00, 0xff, 0xff, 0xc3, 0xd5, 0x81, 0xca, 0x6a, 0x13, 0x00, 0x00,
+	0xf5, 0xb7, 0x6c, 0xf9, 0x87, 0xde, 0xd8, 0x33, 0x9d, 0xce, 0x8f, 0xaf, 0x37, 0xdf, 0x8d, 0x67,
+	0xa2, 0x2d, 0x96, 0x90, 0x82, 0x09, 0xb5, 0x81, 0x6c, 0xed, 0x56, 0xf8, 0x21, 0xdb, 0x4a, 0xc6,
+	0x89, 0x2d, 0xcf, 0xb6, 0xe4, 0x84, 0x50, 0x45, 0xa9, 0x34, 0x9e, 0x9e, 0x19, 0xb1, 0x96, 0x65,
+	0x24, 0x39, 0x9b, 0x70, 0xe2, 0x02, 0x45, 0xc1, 0x85, 0x0b, 0x17, 0x8a, 0x1b, 0x17, 0xaa, 0xf8,
+	0x47, 0x72, 0xcc, 0x71, 0xe1, 0x90, 0x22, 0x93, 0xcb, 0x72, 0xa0, 0x2a, 0x45, 0x71, 0xe2, 0x44,
+	0x75, 0xb7, 0x7e, 0x7a, 0x26, 0x30, 0x40, 0x6e, 0xea, 0xf7, 0x3e, 0xef, 0xe9, 0xd3, 0xaf, 0xdf,
+	0x7b, 0x7a, 0x2d, 0x58, 0xf3, 0x5c, 0xcf, 0x0d, 0xb6, 0x17, 0x81, 0x1f, 0xf9, 0xb8, 0x31, 0xf5,
+	0x83, 0x88, 0x3e, 0x59, 0xec, 0x5d, 0xfe, 0xda, 0xa1, 0x1b, 0x1d, 0x2d, 0xf7, 0xb6, 0xa7, 0xbe,
+	0x77, 0xe3, 0xd0, 0x3f, 0xf4, 0x6f, 0x70, 0xc0, 0xde, 0xf2, 0x80, 0xaf, 0xf8, 0x82, 0x3f, 0x09,
+	0x43, 0xf5, 0xaf, 0x65, 0x68, 0x3e, 0x0c, 0xdc, 0x88, 0x12, 0xfa, 0xa3, 0x25, 0x0d, 0x23, 0xbc,
+	0x0b, 0x10, 0xb9, 0x1e, 0x0d, 0x69, 0xe0, 0xd2, 0xb0, 0x2d, 0x6d, 0x55, 0xae, 0xad, 0x7d, 0x70,
+	0x61, 0x3b, 0x71, 0xbf, 0x6d, 0xb9, 0x1e, 0x35, 0xb9, 0xae, 0x7b, 0xf9, 0xd9, 0x8b, 0xcd, 0xd2,
+	0x9f, 0x5e, 0x6c, 0xe2, 0xdd, 0x80, 0x3a, 0xb3, 0x99, 0x3f, 0xb5, 0x52, 0x3b, 0x92, 0xf3, 0x81,
+	0x3f, 0x82, 0x9a, 0xe9, 0x2f, 0x83, 0x29, 0x6d, 0x97, 0xb7, 0xa4, 0x6b, 0xeb, 0x1f, 0x5c, 0xcd,
+	0xbc, 0xe5, 0xdf, 0xbc, 0x2d, 0x40, 0xfa, 0x7c, 0xe9, 0x91, 0xd8, 0x00, 0x7f, 0x0c, 0x0d, 0x8f,
+	0x46, 0xce, 0xbe, 0x13, 0x39, 0xed, 0x0a, 0xa7, 0xd2, 0xce, 0x8c, 0x47, 0x34, 0x0a, 0xdc, 0xe9,
+	0x28, 0xd6, 0x77, 0xe5, 0x67, 0x2f, 0x36, 0x25, 0x92, 0xe2, 0xf1, 0x4d, 0xb8, 0x18, 0x7e, 0xea,
+	0x2e, 0xec, 0x99, 0xb3, 0x47, 0x67, 0xf6, 0x63, 0x67, 0xe6, 0xee, 0x3b, 0x91, 0xeb, 0xcf, 0xdb,
+	0x5f, 0xd4, 0xb7, 0xa4, 0x6b, 0x0d, 0x72, 0x9e, 0x69, 0x87, 0x4c, 0xf9, 0x20, 0xd5, 0xe1, 0x6f,
+	0xc3, 0xff, 0xe7, 0x8c, 0xa6, 0xfe, 0x72, 0x1e, 0xe5, 0x4d, 0xff, 0x22, 0x4c, 0xdb, 0xa9, 0x69,
+	0x8f, 0x21, 0x32, 0x7b, 0x75, 0x13, 0x20, 0xdb, 0x06, 0xae, 0x43, 0x45, 0xdb, 0x1d, 0xa0, 0x12,
+	0x6e, 0x80, 0x4c, 0x26, 0x43, 0x1d, 0x49, 0xea, 0x06, 0xb4, 0xe2, 0x4d, 0x87, 0x0b, 0x7f, 0x1e,
+	0x52, 0xf5, 0x97, 0x12, 0x34, 0xf5, 0x20, 0xf0, 0x83, 0x3e, 0x8d, 0x1c, 0x77, 0x16, 0xe2, 0xeb,
+	0x50, 0xed, 0x39, 0xcb, 0x90, 0xb6, 0x25, 0x1e, 0xad, 0x5c, 0xec, 0x39, 0x8c, 0xeb, 0x88, 0x80,
+	0xe0, 0x31, 0x6c, 0x10, 0xfa, 0x43, 0x3a, 0x8d, 0xe8, 0xbe, 0xe9, 0x78, 0x8b, 0x19, 0x0d, 0xdb,
+	0x65, 0x1e, 0xa6, 0xcd, 0xcc, 0x2a, 0x05, 0xf0, 0xd3, 0x88, 0x61, 0x3c, 0x5a, 0x25, 0xb2, 0x6a,
+	0xad, 0xfe, 0x00, 0x2e, 0x9e, 0x8a, 0xc7, 0x5b, 0xb0, 0x26, 0x04, 0x83, 0xf9, 0x3e, 0x7d, 0xc2,
+	0xb9, 0x55, 0x49, 0x5e, 0x84, 0x55, 0x68, 0xf2, 0x04, 0x88, 0x1c, 0x6f, 0x11, 0x8e, 0x04, 0x91,
+	0x0a, 0x29, 0xc8, 0xd4, 0xbf, 0x4b, 0x00, 0x59, 0x06, 0x61, 0x0d, 0x6a, 0x3c, 0xd0, 0x49, 0x9e,
+	0x9d, 0xcf, 0x58, 0xf3, 0xe8, 0xee, 0x3a, 0x6e, 0xd0, 0xbd, 0x10, 0xa7, 0x59, 0x93, 0x8b, 0xb4,
+	0x7d, 0x67, 0x11, 0xd1, 0x80, 0xc4, 0x86, 0xf8, 0xeb, 0x50, 0x0f, 0x0b, 0x3b, 0x47, 0x99, 0x0f,
+	0xc1, 0x3d, 0xde, 0x6a, 0x02, 0xc3, 0xb7, 0x40, 0xa1, 0x4f, 0xa8, 0xb7, 0x98, 0x39, 0x41, 0x18,
+	0x27, 0x15, 0xce, 0xc5, 0x38, 0x56, 0xc5, 0x56, 0x19, 0x14, 0x7f, 0x04, 0x70, 0xe4, 0x86, 0x91,
+	0x7f, 0x18, 0x38, 0x5e, 0xd8, 0x96, 0x57, 0x09, 0xef, 0x24, 0xba, 0xd8, 0x32, 0x07, 0x56, 0xbf,
+	0x09, 0x4a, 0xba, 0x1f, 0x8c, 0x41, 0x9e, 0x3b, 0x9e, 0x38, 0xde, 0x26, 0xe1, 0xcf, 0xf8, 0x02,
+	0x54, 0x1f, 0x3b, 0xb3, 0xa5, 0xa8, 0x90, 0x26, 0x11, 0x0b, 0x55, 0x83, 0x9a, 0xd8, 0x02, 0xbe,
+	0x0a, 0xcd, 0x28, 0x89, 0xa3, 0xed, 0x85, 0x1c, 0x56, 0x21, 0x6b, 0xa9, 0x6c, 0x14, 0x66, 0x2e,
+	0x98, 0x5f, 0x29, 0x71, 0xf1, 0x9b, 0x32, 0xac, 0x17, 0xeb, 0x04, 0x7f, 0x08, 0x72, 0xf4, 0x74,
+	0x91, 0xa4, 0xd7, 0x7b, 0x6f, 0xaa, 0xa7, 0x78, 0x69, 0x3d, 0x5d, 0x50, 0xc2, 0x0d, 0xf0, 0x57,
+	0x01, 0x7b, 0x5c, 0x66, 0x1f, 0x38, 0x9e, 0x3b, 0x7b, 0x6a, 0xf3, 0x6d, 0x30, 0x2a, 0x0a, 0x41,
+	0x42, 0x73, 0x87, 0x2b, 0x0c, 0xb6, 0x25, 0x0c, 0xf2, 0x11, 0x9d, 0x2d, 0xda, 0x32, 0xd7, 0xf3,
+	0x67, 0x26, 0x5b, 0xce, 0xdd, 0xa8, 0x5d, 0x15, 0x32, 0xf6, 0xac, 0x3e, 0x05, 0xc8, 0xde, 0x84,
+	0xd7, 0xa0, 0x3e, 0x31, 0xee, 0x1b, 0xe3, 0x87, 0x06, 0x2a, 0xb1, 0x45, 0x6f, 0x3c, 0x31, 0x2c,
+	0x9d, 0x20, 0x09, 0x2b, 0x50, 0xbd, 0xab, 0x4d, 0xee, 0xea, 0xa8, 0x8c, 0x5b, 0xa0, 0xec, 0x0c,
+	0x4c, 0x6b, 0x7c, 0x97, 0x68, 0x23, 0x54, 0xc1, 0x18, 0xd6, 0xb9, 0x26, 0x93, 0xc9, 0xcc, 0xd4,
+	0x9c, 0x8c, 0x46, 0x1a, 0x79, 0x84, 0xaa, 0xac, 0xfa, 0x06, 0xc6, 0x9d, 0x31, 0xaa, 0xe1, 0x26,
+	0x34, 0x4c, 0x4b, 0xb3, 0x74, 0x53, 0xb7, 0x50, 0x5d, 0xbd, 0x0f, 0x35, 0xf1, 0xea, 0xb7, 0x90,
+	0x88, 0xea, 0xcf, 0x24, 0x68, 0x24, 0xc9, 0xf3, 0x36, 0x12, 0xbb, 0x90, 0x12, 0xc9, 0x79, 0x9e,
+	0x48, 0x84, 0xca, 0x89, 0x44, 0x50, 0x5f, 0x57, 0x41, 0x49, 0x93, 0x11, 0x5f, 0x01, 0x45, 0x74,
+	0x31, 0x77, 0x1e, 0xf1, 0x23, 0x97, 0x77, 0x4a, 0xa4, 0xc1, 0x45, 0x83, 0x79, 0x84, 0xaf, 0xc2,
+	0x9a, 0x50, 0x1f, 0xcc, 0x7c, 0x27, 0x12, 0xef, 0xda, 0x29, 0x11, 0xe0, 0xc2, 0x3b, 0x4c, 0x86,
+	0x11, 0x54, 0xc2, 0xa5, 0xc7, 0xdf, 0x24, 0x11, 0xf6, 0x88, 0x2f, 0x41, 0x2d, 0x9c, 0x1e, 0x51,
+	0xcf, 0xe1, 0x87, 0x7b, 0x8e, 0xc4, 0x2b, 0xfc, 0x25, 0x58, 0xff, 0x31, 0x0d, 0x7c, 0x3b, 0x3a,
+	0x0a, 0x68, 0x78, 0xe4, 0xcf, 0xf6, 0xf9, 0x41, 0x4b, 0xa4, 0xc5, 0xa4, 0x56, 0x22, 0xc4, 0xef,
+	0xc7, 0xb0, 0x8c, 0x57, 0x8d, 0xf3, 0x92, 0x48, 0x93, 0xc9, 0x7b, 0x09, 0xb7, 0xeb, 0x80, 0x72,
+	0x38, 0x41, 0xb0, 0xce, 0x09, 0x4a, 0x64, 0x3d, 0x45, 0x0a, 0x92, 0x1a, 0xac, 0xcf, 0xe9, 0xa1,
+	0x13, 0xb9, 0x8f, 0xa9, 0x1d, 0x2e, 0x9c, 0x79, 0xd8, 0x6e, 0xac, 0x7e, 0xb9, 0xba, 0xcb, 0xe9,
+	0xa7, 0x34, 0x32, 0x17, 0xce, 0x3c, 0xae, 0xd0, 0x56, 0x62, 0xc1, 0x64, 0x21, 0xfe, 0x32, 0x6c,
+	0xa4, 0x2e, 0xf6, 0xe9, 0x2c, 0x72, 0xc2, 0xb6, 0xb2, 0x55, 0xb9, 0x86, 0x49, 0xea, 0xb9, 0xcf,
+	0xa5, 0x05, 0x20, 0xe7, 0x16, 0xb6, 0x61, 0xab, 0x72, 0x4d, 0xca, 0x80, 0x9c, 0x18, 0x6b, 0x6f,
+	0xeb, 0x0b, 0x3f, 0x74, 0x73, 0xa4, 0xd6, 0xfe, 0x3d, 0xa9, 0xc4, 0x22, 0x25, 0x95, 0xba, 0x88,
+	0x49, 0x35, 0x05, 0xa9, 0x44, 0x9c, 0x91, 0x4a, 0x81, 0x31, 0xa9, 0x96, 0x20, 0x95, 0x88, 0x63,
+	0x52, 0xb7, 0x01, 0x02, 0x1a, 0xd2, 0xc8, 0x3e, 0x62, 0x91, 0x5f, 0xe7, 0x4d, 0xe0, 0xca, 0x29,
+	0x6d, 0x6c, 0x9b, 0x30, 0xd4, 0x8e, 0x3b, 0x8f, 0x88, 0x12, 0x24, 0x8f, 0xf8, 0x5d, 0x50, 0xd2,
+	0x5c, 0x6b, 0x6f, 0xf0, 0xe4, 0xcb, 0x04, 0xf8, 0x3d, 0x68, 0x4d, 0x97, 0x61, 0xe4, 0x7b, 0x36,
+	0xcf, 0xd6, 0xb0, 0x8d, 0x38, 0x85, 0xa6, 0x10, 0x3e, 0xe0, 0x32, 0xf5, 0x63, 0x50, 0x52, 0xd7,
+	0xc5, 0x7a, 0xaf, 0x43, 0xe5, 0x91, 0x6e, 0x22, 0x09, 0xd7, 0xa0, 0x6c, 0x8c, 0x51, 0x39, 0xab,
+	0xf9, 0xca, 0x65, 0xf9, 0xe7, 0xbf, 0xeb, 0x48, 0xdd, 0x3a, 0x54, 0xf9, 0xe6, 0xba, 0x4d, 0x80,
+	0x2c, 0x37, 0xd4, 0xbf, 0xc9, 0xb0, 0xce, 0xf3, 0x20, 0xcb, 0xfb, 0x10, 0x30, 0xd7, 0xd1, 0xc0,
+	0x5e, 0xd9, 0x6e, 0xab, 0xab, 0xff, 0xe3, 0xc5, 0xa6, 0x96, 0x1b, 0x93, 0x16, 0x81, 0xef, 0xd1,
+	0xe8, 0x88, 0x2e, 0xc3, 0xfc, 0xa3, 0xe7, 0xef, 0xd3, 0xd9, 0x8d, 0xb4, 0x8b, 0x6f, 0xf7, 0x84,
+	0xbb, 0x2c, 0x2c, 0x68, 0xba, 0x22, 0xf9, 0x5f, 0x0b, 0xe3, 0x4a, 0x7e, 0x53, 0x22, 0xd5, 0x89,
+	0x92, 0x26, 0x3a, 0xeb, 0x08, 0x42, 0x13, 0x77, 0x04, 0xbe, 0x38, 0xa5, 0x3c, 0xdf, 0x42, 0xda,
+	0xbd, 0x85, 0x72, 0xfa, 0x0a, 0xa0, 0x94, 0xc5, 0x1e, 0xc7, 0x26, 0x19, 0x99, 0x26, 0xaa, 0x70,
+	0xc1, 0xa1, 0xe9, 0xdb, 0x12, 0xa8, 0xa8, 0xa8, 0xb4, 0xd0, 0x12, 0xe8, 0x59, 0x32, 0xec, 0x9e,
+	0xdc, 0x90, 0x50, 0xf9, 0x9e, 0xdc, 0xa8, 0xa1, 0xfa, 0x3d, 0xb9, 0xa1, 0x20, 0xb8, 0x27, 0x37,
+	0x9a, 0xa8, 0x75, 0x4f, 0x6e, 0x6c, 0x20, 0x44, 0xb2, 0x7e, 0x48, 0x56, 0xfa, 0x10, 0x59, 0x6d,
+	0x00, 0x64, 0xb5, 0xf8, 0x72, 0xc9, 0xae, 0xde, 0x06, 0xc8, 0x62, 0xc0, 0x8e, 0xde, 0x3f, 0x38,
+	0x08, 0xa9, 0x68, 0xb2, 0xe7, 0x48, 0xbc, 0x62, 0xf2, 0x19, 0x9d, 0x1f, 0x46, 0x47, 0xfc, 0xd4,
+	0x5a, 0x24, 0x5e, 0xa9, 0x4b, 0xc0, 0xc5, 0x8c, 0xe5, 0xb3, 0xc1, 0x19, 0xbe, 0xf3, 0xb7, 0x41,
+	0x49, 0x73, 0x92, 0xbf, 0xab, 0x30, 0x13, 0x17, 0x7d, 0xc6, 0x33, 0x71, 0x66, 0xa0, 0xce, 0x61,
+	0x43, 0x8c, 0x14, 0x59, 0xa5, 0xa4, 0x69, 0x25, 0x9d, 0x92, 0x56, 0xe5, 0x2c, 0xad, 0x6e, 0x42,
+	0x3d, 0x39, 0x1c, 0x31, 0x35, 0xbd, 0x73, 0xda, 0xf0, 0xc3, 0x11, 0x24, 0x41, 0xaa, 0x21, 0x6c,
+	0xac, 0xe8, 0x70, 0x07, 0x60, 0xcf, 0x5f, 0xce, 0xf7, 0x9d, 0xf8, 0x82, 0xc1, 0x06, 0xc9, 0x9c,
+	0x84, 0xf1, 0x99, 0xf9, 0x9f, 0xd1, 0x20, 0x49, 0x73, 0xbe, 0x60, 0xd2, 0xe5, 0x62, 0x41, 0x83,
+	0x38, 0xd1, 0xc5, 0x22, 0xe3, 0x2e, 0xe7, 0xb8, 0xab, 0x33, 0x38, 0xbf, 0xb2, 0x49, 0x1e, 0xdc,
+	0x42, 0xef, 0x2a, 0xaf, 0xf6, 0xae, 0x0f, 0x4f, 0xc6, 0xf5, 0x9d, 0xd5, 0x51, 0x32, 0xf5, 0x97,
+	0x0f, 0xe9, 0x1f, 0x65, 0x68, 0x7d, 0xb2, 0xa4, 0xc1, 0xd3, 0x64, 0xa4, 0xc7, 0xb7, 0xa0, 0x16,
+	0x46, 0x4e, 0xb4, 0x0c, 0xe3, 0x19, 0xab, 0x93, 0xf9, 0x29, 0x00, 0xb7, 0x4d, 0x8e, 0x22, 0x31,
+	0x1a, 0x7f, 0x17, 0x80, 0xb2, 0x11, 0xdf, 0xe6, 0xf3, 0xd9, 0x89, 0xcb, 0x52, 0xd1, 0x96, 0x5f,
+	0x06, 0xf8, 0x74, 0xa6, 0xd0, 0xe4, 0x91, 0xc5, 0x83, 0x2f, 0x78, 0x94, 0x14, 0x22, 0x16, 0x78,
+	0x9b, 0xf1, 0x09, 0xdc, 0xf9, 0x21, 0x0f, 0x53, 0xa1, 0x8a, 0x4d, 0x2e, 0xef, 0x3b, 0x91, 0xb3,
+	0x53, 0x22, 0x31, 0x8a, 0xe1, 0x1f, 0xd3, 0x69, 0xe4, 0x07, 0xed, 0xea, 0x2a, 0xfe, 0x01, 0x97,
+	0x27, 0x78, 0x81, 0xe2, 0xfe, 0xa7, 0xce, 0xcc, 0x09, 0xda, 0xb5, 0x55, 0xbc, 0xc9, 0xe5, 0xa9,
+	0x7f, 0xbe, 0x62, 0x78, 0xcf, 0x89, 0x02, 0xf7, 0x49, 0xbb, 0xbe, 0x8a, 0x1f, 0x71, 0x79, 0x82,
+	0x17, 0x28, 0x7c, 0x19, 0x1a, 0x9f, 0x39, 0xc1, 0xdc, 0x9d, 0x1f, 0x8a, 0x3e, 0xa4, 0x90, 0x74,
+	0xcd, 0x76, 0xec, 0xce, 0x0f, 0x7c, 0xf1, 0xad, 0x56, 0x88, 0x58, 0xa8, 0xef, 0x43, 0x4d, 0xc4,
+	0x96, 0x7d, 0x42, 0x74, 0x42, 0xc6, 0x44, 0x8c, 0x93, 0xe6, 0xa4, 0xd7, 0xd3, 0x4d, 0x13, 0x49,
+	0xe2, 0x7b, 0xa2, 0xfe, 0x5a, 0x02, 0x25, 0x0d, 0x24, 0x9b, 0x13, 0x8d, 0xb1, 0xa1, 0x0b, 0xa8,
+	0x35, 0x18, 0xe9, 0xe3, 0x89, 0x85, 0x24, 0x36, 0x34, 0xf6, 0x34, 0xa3, 0xa7, 0x0f, 0xf5, 0xbe,
+	0x18, 0x3e, 0xf5, 0xef, 0xe9, 0xbd, 0x89, 0x35, 0x18, 0x1b, 0xa8, 0xc2, 0x94, 0x5d, 0xad, 0x6f,
+	0xf7, 0x35, 0x4b, 0x43, 0x32, 0x5b, 0x0d, 0xd8, 0xbc, 0x6a, 0x68, 0x43, 0x54, 0xc5, 0x1b, 0xb0,
+	0x36, 0x31, 0xb4, 0x07, 0xda, 0x60, 0xa8, 0x75, 0x87, 0x3a, 0xaa, 0x31, 0x5b, 0x63, 0x6c, 0xd9,
+	0x77, 0xc6, 0x13, 0xa3, 0x8f, 0xea, 0x6c, 0x70, 0x65, 0x4b, 0xad, 0xd7, 0xd3, 0x77, 0x2d, 0x0e,
+	0x69, 0xc4, 0xdf, 0xb9, 0x1a, 0xc8, 0x6c, 0x06, 0x57, 0x75, 0x80, 0xec, 0x84, 0x8a, 0x23, 0xbe,
+	0xf2, 0xa6, 0x91, 0xf0, 0x64, 0xcf, 0x50, 0x7f, 0x2a, 0x01, 0x64, 0x27, 0x87, 0x6f, 0x65, 0x77,
+	0x26, 0x31, 0x9e, 0x5e, 0x5a, 0x3d, 0xe0, 0xd3, 0x6f, 0x4e, 0xdf, 0x29, 0xdc, 0x80, 0xca, 0xab,
+	0x4d, 0x40, 0x98, 0xfe, 0xab, 0x7b, 0x90, 0x0d, 0xcd, 0xbc, 0x7f, 0xd6, 0x1c, 0xc5, 0xbd, 0x81,
+	0xf3, 0x50, 0x48, 0xbc, 0xfa, 0xef, 0x67, 0xdf, 0x5f, 0x48, 0xb0, 0xb1, 0x42, 0xe3, 0x8d, 0x2f,
+	0x29, 0x34, 0xd2, 0xf2, 0x19, 0x1a, 0x69, 0x29, 0x57, 0xf5, 0x67, 0x21, 0xc3, 0x0e, 0x2f, 0x4d,
+	0xff, 0xd3, 0xef, 0x67, 0x67, 0x39, 0xbc, 0x2e, 0x40, 0x56, 0x15, 0xf8, 0x1b, 0x50, 0x2b, 0xfc,
+	0x9a, 0xb9, 0xb4, 0x5a, 0x3b, 0xf1, 0xcf, 0x19, 0x41, 0x38, 0xc6, 0xaa, 0xbf, 0x95, 0xa0, 0x99,
+	0x57, 0xbf, 0x31, 0x28, 0xff, 0xf9, 0x75, 0xba, 0x5b, 0x48, 0x0a, 0xf1, 0x65, 0x78, 0xf7, 0x4d,
+	0x71, 0xe4, 0xf7, 0x9e, 0x13, 0x79, 0x71, 0xfd, 0x0f, 0x65, 0x80, 0xec, 0xe7, 0x06, 0x3e, 0x07,
+	0xad, 0x78, 0x28, 0xb4, 0x7b, 0xda, 0xc4, 0x64, 0x05, 0x79, 0x19, 0x2e, 0x11, 0x7d, 0x77, 0x38,
+	0xe8, 0x69, 0xa6, 0xdd, 0x1f, 0xf4, 0x6d, 0x56, 0x37, 0x23, 0xcd, 0xea, 0xed, 0x20, 0x09, 0x5f,
+	0x84, 0x73, 0xd6, 0x78, 0x6c, 0x8f, 0x34, 0xe3, 0x91, 0xdd, 0x1b, 0x4e, 0x4c, 0x4b, 0x27, 0x26,
+	0x2a, 0x17, 0x2a, 0xb3, 0xc2, 0x1c, 0x0c, 0x8c, 0xbb, 0xba, 0xc9, 0xca, 0xd6, 0x26, 0x9a, 0xa5,
+	0xdb, 0xc3, 0xc1, 0x68, 0x60, 0xe9, 0x7d, 0x24, 0xe3, 0x36, 0x5c, 0x20, 0xfa, 0x27, 0x13, 0xdd,
+	0xb4, 0x8a, 0x9a, 0x2a, 0xab, 0xd0, 0x81, 0x61, 0x5a, 0xac, 0xfa, 0x85, 0x14, 0xd5, 0xf0, 0xff,
+	0xc1, 0x79, 0x53, 0x27, 0x0f, 0x06, 0x3d, 0xdd, 0xce, 0x57, 0x77, 0x1d, 0x5f, 0x00, 0x64, 0x99,
+	0xfd, 0x6e, 0x41, 0xda, 0x60, 0x34, 0x18, 0xbb, 0xee, 0xc4, 0x7c, 0x84, 0x14, 0xf6, 0xaa, 0xde,
+	0x80, 0xf4, 0x26, 0x03, 0xcb, 0xee, 0x12, 0x5d, 0xbb, 0xaf, 0x13, 0x7b, 0xbc, 0xab, 0x1b, 0x08,
+	0xf0, 0x25, 0xc0, 0x23, 0xdd, 0xda, 0x19, 0x8b, 0xbd, 0x69, 0xc3, 0xe1, 0xf8, 0xa1, 0xde, 0x47,
+	0x6b, 0x18, 0x41, 0xd3, 0xd2, 0x0d, 0xcd, 0xb0, 0x62, 0x02, 0xcd, 0xee, 0xb7, 0x9e, 0xbf, 0xec,
+	0x94, 0x3e, 0x7f, 0xd9, 0x29, 0xbd, 0x7e, 0xd9, 0x91, 0x7e, 0x72, 0xdc, 0x91, 0x7e, 0x7f, 0xdc,
+	0x91, 0x9e, 0x1d, 0x77, 0xa4, 0xe7, 0xc7, 0x1d, 0xe9, 0xcf, 0xc7, 0x1d, 0xe9, 0x8b, 0xe3, 0x4e,
+	0xe9, 0xf5, 0x71, 0x47, 0xfa, 0xd5, 0xab, 0x4e, 0xe9, 0xf9, 0xab, 0x4e, 0xe9, 0xf3, 0x57, 0x9d,
+	0xd2, 0xf7, 0xeb, 0xfc, 0x77, 0xe1, 0x62, 0x6f, 0xaf, 0xc6, 0x7f, 0xfc, 0xdd, 0xfc, 0xe7, 0x00,
+	0x15, 0xca, 0xf4, 0xd3, 0x40, 0x14, 0x00, 0x00,
 }
 
 func (x ErrorCause) String() string {
@@ -2186,6 +2262,46 @@ func (this *ErrorDetails) Equal(that interface{}) bool {
 	if this.Cause != that1.Cause {
 		return false
 	}
+	if len(this.RejectedSamples) != len(that1.RejectedSamples) {
+		return false
+	}
+	for i := range this.RejectedSamples {
+		if !this.RejectedSamples[i].Equal(&that1.RejectedSamples[i]) {
+			return false
+		}
+	}
+	return true
+}
+func (this *RejectedSeriesSamples) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*RejectedSeriesSamples)
+	if !ok {
+		that2, ok := that.(RejectedSeriesSamples)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.SeriesIndex != that1.SeriesIndex {
+		return false
+	}
+	if len(this.TimestampsMs) != len(that1.TimestampsMs) {
+		return false
+	}
+	for i := range this.TimestampsMs {
+		if this.TimestampsMs[i] != that1.TimestampsMs[i] {
+			return false
+		}
+	}
 	return true
 }
 func (this *TimeSeries) Equal(that interface{}) bool {
@@ -2492,6 +2608,14 @@ func (this *Histogram) Equal(that interface{}) bool {
 	if this.Timestamp != that1.Timestamp {
 		return false
 	}
+	if len(this.CustomValues) != len(that1.CustomValues) {
+		return false
+	}
+	for i := range this.CustomValues {
+		if this.CustomValues[i] != that1.CustomValues[i] {
+			return false
+		}
+	}
 	return true
 }
 func (this *Histogram_CountInt) Equal(that interface{}) bool {
@@ -3233,9 +3357,27 @@ func (this *ErrorDetails) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 5)
+	s := make([]string, 0, 6)
 	s = append(s, "&mimirpb.ErrorDetails{")
 	s = append(s, "Cause: "+fmt.Sprintf("%#v", this.Cause)+",\n")
+	if this.RejectedSamples != nil {
+		vs := make([]RejectedSeriesSamples, len(this.RejectedSamples))
+		for i := range vs {
+			vs[i] = this.RejectedSamples[i]
+		}
+		s = append(s, "RejectedSamples: "+fmt.Sprintf("%#v", vs)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *RejectedSeriesSamples) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 6)
+	s = append(s, "&mimirpb.RejectedSeriesSamples{")
+	s = append(s, "SeriesIndex: "+fmt.Sprintf("%#v", this.SeriesIndex)+",\n")
+	s = append(s, "TimestampsMs: "+fmt.Sprintf("%#v", this.TimestampsMs)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -3362,6 +3504,7 @@ func (this *Histogram) GoString() string {
 	s = append(s, "PositiveCounts: "+fmt.Sprintf("%#v", this.PositiveCounts)+",\n")
 	s = append(s, "ResetHint: "+fmt.Sprintf("%#v", this.ResetHint)+",\n")
 	s = append(s, "Timestamp: "+fmt.Sprintf("%#v", this.Timestamp)+",\n")
+	s = append(s, "CustomValues: "+fmt.Sprintf("%#v", this.CustomValues)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -3782,6 +3925,20 @@ func (m *ErrorDetails) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if len(m.RejectedSamples) > 0 {
+		for iNdEx := len(m.RejectedSamples) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.RejectedSamples[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintMimir(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x12
+		}
+	}
 	if m.Cause != 0 {
 		i = encodeVarintMimir(dAtA, i, uint64(m.Cause))
 		i--
@@ -3790,6 +3947,53 @@ func (m *ErrorDetails) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *RejectedSeriesSamples) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *RejectedSeriesSamples) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *RejectedSeriesSamples) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.TimestampsMs) > 0 {
+		dAtA2 := make([]byte, len(m.TimestampsMs)*10)
+		var j1 int
+		for _, num1 := range m.TimestampsMs {
+			num := uint64(num1)
+			for num >= 1<<7 {
+				dAtA2[j1] = uint8(uint64(num)&0x7f | 0x80)
+				num >>= 7
+				j1++
+			}
+			dAtA2[j1] = uint8(num)
+			j1++
+		}
+		i -= j1
+		copy(dAtA[i:], dAtA2[:j1])
+		i = encodeVarintMimir(dAtA, i, uint64(j1))
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.SeriesIndex != 0 {
+		i = encodeVarintMimir(dAtA, i, uint64(m.SeriesIndex))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
 func (m *TimeSeries) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
@@ -4094,6 +4298,18 @@ func (m *Histogram) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if len(m.CustomValues) > 0 {
+		for iNdEx := len(m.CustomValues) - 1; iNdEx >= 0; iNdEx-- {
+			f3 := math.Float64bits(float64(m.CustomValues[iNdEx]))
+			i -= 8
+			encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(f3))
+		}
+		i = encodeVarintMimir(dAtA, i, uint64(len(m.CustomValues)*8))
+		i--
+		dAtA[i] = 0x1
+		i--
+		dAtA[i] = 0x82
+	}
 	if m.Timestamp != 0 {
 		i = encodeVarintMimir(dAtA, i, uint64(m.Timestamp))
 		i--
@@ -4106,30 +4322,30 @@ func (m *Histogram) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	}
 	if len(m.PositiveCounts) > 0 {
 		for iNdEx := len(m.PositiveCounts) - 1; iNdEx >= 0; iNdEx-- {
-			f1 := math.Float64bits(float64(m.PositiveCounts[iNdEx]))
+			f2 := math.Float64bits(float64(m.PositiveCounts[iNdEx]))
 			i -= 8
-			encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(f1))
+			encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(f2))
 		}
 		i = encodeVarintMimir(dAtA, i, uint64(len(m.PositiveCounts)*8))
 		i--
 		dAtA[i] = 0x6a
 	}
 	if len(m.PositiveDeltas) > 0 {
-		var j2 int
-		dAtA4 := make([]byte, len(m.PositiveDeltas)*10)
+		var j3 int
+		dAtA5 := make([]byte, len(m.PositiveDeltas)*10)
 		for _, num := range m.PositiveDeltas {
 			x3 := (uint64(num) << 1) ^ uint64((num >> 63))
 			for x3 >= 1<<7 {
-				dAtA4[j2] = uint8(uint64(x3)&0x7f | 0x80)
-				j2++
+				dAtA5[j3] = uint8(uint64(x3)&0x7f | 0x80)
+				j3++
 				x3 >>= 7
 			}
-			dAtA4[j2] = uint8(x3)
-			j2++
+			dAtA5[j3] = uint8(x3)
+			j3++
 		}
-		i -= j2
-		copy(dAtA[i:], dAtA4[:j2])
-		i = encodeVarintMimir(dAtA, i, uint64(j2))
+		i -= j3
+		copy(dAtA[i:], dAtA5[:j3])
+		i = encodeVarintMimir(dAtA, i, uint64(j3))
 		i--
 		dAtA[i] = 0x62
 	}
@@ -4149,30 +4365,30 @@ func (m *Histogram) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	}
 	if len(m.NegativeCounts) > 0 {
 		for iNdEx := len(m.NegativeCounts) - 1; iNdEx >= 0; iNdEx-- {
-			f5 := math.Float64bits(float64(m.NegativeCounts[iNdEx]))
+			f6 := math.Float64bits(float64(m.NegativeCounts[iNdEx]))
 			i -= 8
-			encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(f5))
+			encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(f6))
 		}
 		i = encodeVarintMimir(dAtA, i, uint64(len(m.NegativeCounts)*8))
 		i--
 		dAtA[i] = 0x52
 	}
 	if len(m.NegativeDeltas) > 0 {
-		var j6 int
-		dAtA8 := make([]byte, len(m.NegativeDeltas)*10)
+		var j7 int
+		dAtA9 := make([]byte, len(m.NegativeDeltas)*10)
 		for _, num := range m.NegativeDeltas {
 			x7 := (uint64(num) << 1) ^ uint64((num >> 63))
 			for x7 >= 1<<7 {
-				dAtA8[j6] = uint8(uint64(x7)&0x7f | 0x80)
-				j6++
+				dAtA9[j7] = uint8(uint64(x7)&0x7f | 0x80)
+				j7++
 				x7 >>= 7
 			}
-			dAtA8[j6] = uint8(x7)
-			j6++
+			dAtA9[j7] = uint8(x7)
+			j7++
 		}
-		i -= j6
-		copy(dAtA[i:], dAtA8[:j6])
-		i = encodeVarintMimir(dAtA, i, uint64(j6))
+		i -= j7
+		copy(dAtA[i:], dAtA9[:j7])
+		i = encodeVarintMimir(dAtA, i, uint64(j7))
 		i--
 		dAtA[i] = 0x4a
 	}
@@ -5112,6 +5328,31 @@ func (m *ErrorDetails) Size() (n int) {
 	if m.Cause != 0 {
 		n += 1 + sovMimir(uint64(m.Cause))
 	}
+	if len(m.RejectedSamples) > 0 {
+		for _, e := range m.RejectedSamples {
+			l = e.Size()
+			n += 1 + l + sovMimir(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *RejectedSeriesSamples) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.SeriesIndex != 0 {
+		n += 1 + sovMimir(uint64(m.SeriesIndex))
+	}
+	if len(m.TimestampsMs) > 0 {
+		l = 0
+		for _, e := range m.TimestampsMs {
+			l += sovMimir(uint64(e))
+		}
+		n += 1 + sovMimir(uint64(l)) + l
+	}
 	return n
 }
 
@@ -5299,6 +5540,9 @@ func (m *Histogram) Size() (n int) {
 	if m.Timestamp != 0 {
 		n += 1 + sovMimir(uint64(m.Timestamp))
 	}
+	if len(m.CustomValues) > 0 {
+		n += 2 + sovMimir(uint64(len(m.CustomValues)*8)) + len(m.CustomValues)*8
+	}
 	return n
 }
 
@@ -5730,8 +5974,25 @@ func (this *ErrorDetails) String() string {
 	if this == nil {
 		return "nil"
 	}
+	repeatedStringForRejectedSamples := "[]RejectedSeriesSamples{"
+	for _, f := range this.RejectedSamples {
+		repeatedStringForRejectedSamples += strings.Replace(strings.Replace(f.String(), "RejectedSeriesSamples", "RejectedSeriesSamples", 1), `&`, ``, 1) + ","
+	}
+	repeatedStringForRejectedSamples += "}"
 	s := strings.Join([]string{`&ErrorDetails{`,
 		`Cause:` + fmt.Sprintf("%v", this.Cause) + `,`,
+		`RejectedSamples:` + repeatedStringForRejectedSamples + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *RejectedSeriesSamples) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&RejectedSeriesSamples{`,
+		`SeriesIndex:` + fmt.Sprintf("%v", this.SeriesIndex) + `,`,
+		`TimestampsMs:` + fmt.Sprintf("%v", this.TimestampsMs) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -5849,6 +6110,7 @@ func (this *Histogram) String() string {
 		`PositiveCounts:` + fmt.Sprintf("%v", this.PositiveCounts) + `,`,
 		`ResetHint:` + fmt.Sprintf("%v", this.ResetHint) + `,`,
 		`Timestamp:` + fmt.Sprintf("%v", this.Timestamp) + `,`,
+		`CustomValues:` + fmt.Sprintf("%v", this.CustomValues) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -6435,6 +6697,185 @@ func (m *ErrorDetails) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RejectedSamples", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMimir
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthMimir
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthMimir
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.RejectedSamples = append(m.RejectedSamples, RejectedSeriesSamples{})
+			if err := m.RejectedSamples[len(m.RejectedSamples)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMimir(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthMimir
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *RejectedSeriesSamples) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMimir
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: RejectedSeriesSamples: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: RejectedSeriesSamples: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SeriesIndex", wireType)
+			}
+			m.SeriesIndex = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMimir
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.SeriesIndex |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType == 0 {
+				var v int64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowMimir
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					v |= int64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				m.TimestampsMs = append(m.TimestampsMs, v)
+			} else if wireType == 2 {
+				var packedLen int
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowMimir
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					packedLen |= int(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				if packedLen < 0 {
+					return ErrInvalidLengthMimir
+				}
+				postIndex := iNdEx + packedLen
+				if postIndex < 0 {
+					return ErrInvalidLengthMimir
+				}
+				if postIndex > l {
+					return io.ErrUnexpectedEOF
+				}
+				var elementCount int
+				var count int
+				for _, integer := range dAtA[iNdEx:postIndex] {
+					if integer < 128 {
+						count++
+					}
+				}
+				elementCount = count
+				if elementCount != 0 && len(m.TimestampsMs) == 0 {
+					m.TimestampsMs = make([]int64, 0, elementCount)
+				}
+				for iNdEx < postIndex {
+					var v int64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowMimir
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						v |= int64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					m.TimestampsMs = append(m.TimestampsMs, v)
+				}
+			} else {
+				return fmt.Errorf("proto: wrong wireType = %d for field TimestampsMs", wireType)
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipMimir(dAtA[iNdEx:])
@@ -7730,6 +8171,60 @@ func (m *Histogram) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 16:
+			if wireType == 1 {
+				var v uint64
+				if (iNdEx + 8) > l {
+					return io.ErrUnexpectedEOF
+				}
+				v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+				iNdEx += 8
+				v2 := float64(math.Float64frombits(v))
+				m.CustomValues = append(m.CustomValues, v2)
+			} else if wireType == 2 {
+				var packedLen int
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowMimir
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					packedLen |= int(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				if packedLen < 0 {
+					return ErrInvalidLengthMimir
+				}
+				postIndex := iNdEx + packedLen
+				if postIndex < 0 {
+					return ErrInvalidLengthMimir
+				}
+				if postIndex > l {
+					return io.ErrUnexpectedEOF
+				}
+				var elementCount int
+				elementCount = packedLen / 8
+				if elementCount != 0 && len(m.CustomValues) == 0 {
+					m.CustomValues = make([]float64, 0, elementCount)
+				}
+				for iNdEx < postIndex {
+					var v uint64
+					if (iNdEx + 8) > l {
+						return io.ErrUnexpectedEOF
+					}
+					v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+					iNdEx += 8
+					v2 := float64(math.Float64frombits(v))
+					m.CustomValues = append(m.CustomValues, v2)
+				}
+			} else {
+				return fmt.Errorf("proto: wrong wireType = %d for field CustomValues", wireType)
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipMimir(dAtA[iNdEx:])
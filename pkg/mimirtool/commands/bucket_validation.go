@@ -111,7 +111,7 @@ func (b *BucketValidationCommand) validate(_ *kingpin.ParseContext) error {
 		return nil
 	}
 
-	err := b.parseBucketConfig()
+	err := parseBucketConfig(&b.cfg, b.bucketConfig)
 	if err != nil {
 		return errors.Wrap(err, "error when parsing bucket config")
 	}
@@ -179,15 +179,16 @@ mimirtool bucket-validation --bucket-config='-backend=s3 -s3.endpoint=localhost:
 	fs.Usage()
 }
 
-func (b *BucketValidationCommand) parseBucketConfig() error {
+// parseBucketConfig parses the CLI args configuring a storage bucket into cfg.
+func parseBucketConfig(cfg *bucket.Config, args string) error {
 	fs := flag.NewFlagSet("bucket-config", flag.ContinueOnError)
-	b.cfg.RegisterFlags(fs)
-	err := fs.Parse(strings.Split(b.bucketConfig, " "))
+	cfg.RegisterFlags(fs)
+	err := fs.Parse(strings.Split(args, " "))
 	if err != nil {
 		return err
 	}
 
-	return b.cfg.Validate()
+	return cfg.Validate()
 }
 
 func (b *BucketValidationCommand) report(phase string, completed int) {
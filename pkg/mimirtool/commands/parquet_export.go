@@ -0,0 +1,632 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/concurrency"
+	"github.com/oklog/ulid"
+	"github.com/parquet-go/parquet-go"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+)
+
+const (
+	// parquetExportedMarkersDir is the directory, relative to the tenant output location, containing one marker for
+	// each exported block. The leading underscore makes the analytics engines ignore it when reading the dataset.
+	parquetExportedMarkersDir = "_exported"
+
+	// Number of rows buffered for each output file before writing them.
+	parquetRowsBatchSize = 1024
+
+	parquetMetaFetchConcurrency = 16
+)
+
+// ParquetExportCommand exports the compacted blocks of a tenant to Apache Parquet files.
+type ParquetExportCommand struct {
+	bucketConfig       string
+	outputBucketConfig string
+	bucketConfigHelp   bool
+	userID             string
+	minBlockDuration   time.Duration
+	blockConcurrency   int
+	maxRowsPerRowGroup int64
+	workDir            string
+	dryRun             bool
+	logger             log.Logger
+}
+
+// Register is used to register the command to a parent command.
+func (c *ParquetExportCommand) Register(app *kingpin.Application, _ EnvVarNames) {
+	cmd := app.Command("parquet-export", "Export the compacted blocks of a tenant to Apache Parquet files, partitioned by day.").Action(c.export)
+
+	cmd.Flag("user", "The user (tenant) whose blocks are exported.").StringVar(&c.userID)
+	cmd.Flag("bucket-config", "The CLI args to configure the blocks storage bucket.").StringVar(&c.bucketConfig)
+	cmd.Flag("output-bucket-config", "The CLI args to configure the bucket where the Parquet files are written. It can be a different bucket or backend than the blocks storage bucket.").StringVar(&c.outputBucketConfig)
+	cmd.Flag("bucket-config-help", "Help text explaining how to use the -bucket-config and -output-bucket-config parameters").BoolVar(&c.bucketConfigHelp)
+	cmd.Flag("min-block-duration", "Only blocks spanning at least this duration are exported, so that blocks which are still going to be compacted are skipped.").Default("24h").DurationVar(&c.minBlockDuration)
+	cmd.Flag("block-concurrency", "How many blocks can be exported concurrently.").Default("2").IntVar(&c.blockConcurrency)
+	cmd.Flag("max-rows-per-row-group", "The maximum number of rows of each row group of the Parquet files. Rows are buffered in memory until their row group is written.").Default("1000000").Int64Var(&c.maxRowsPerRowGroup)
+	cmd.Flag("work-dir", "The local directory where blocks are downloaded and Parquet files are written before being uploaded. If empty, the system temporary directory is used.").StringVar(&c.workDir)
+	cmd.Flag("dry-run", "If set blocks are not downloaded (except metadata) and not exported; only what would happen is logged.").BoolVar(&c.dryRun)
+}
+
+func (c *ParquetExportCommand) export(_ *kingpin.ParseContext) error {
+	c.logger = log.NewLogfmtLogger(log.NewSyncWriter(os.Stderr))
+	if c.bucketConfigHelp {
+		c.printBucketConfigHelp()
+		return nil
+	}
+
+	if c.userID == "" {
+		return errors.New("--user is required")
+	}
+	if c.blockConcurrency < 1 {
+		return errors.New("--block-concurrency must be positive")
+	}
+	if c.maxRowsPerRowGroup < 1 {
+		return errors.New("--max-rows-per-row-group must be positive")
+	}
+
+	var cfg, outputCfg bucket.Config
+	if err := parseBucketConfig(&cfg, c.bucketConfig); err != nil {
+		return errors.Wrap(err, "error when parsing bucket config")
+	}
+	if err := parseBucketConfig(&outputCfg, c.outputBucketConfig); err != nil {
+		return errors.Wrap(err, "error when parsing output bucket config")
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	bkt, err := bucket.NewClient(ctx, cfg, "bucket", c.logger, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to create the bucket client")
+	}
+	outputBkt, err := bucket.NewClient(ctx, outputCfg, "output-bucket", c.logger, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to create the output bucket client")
+	}
+
+	return c.exportBlocks(ctx, bkt, outputBkt)
+}
+
+func (c *ParquetExportCommand) printBucketConfigHelp() {
+	fs := flag.NewFlagSet("bucket-config", flag.ContinueOnError)
+	cfg := bucket.Config{}
+	cfg.RegisterFlags(fs)
+
+	fmt.Fprintf(fs.Output(), `
+The following help text describes the arguments
+which may be specified in the strings that get
+passed to "-bucket-config" and "-output-bucket-config".
+
+Example:
+mimirtool parquet-export --user=tenant --bucket-config='-backend=s3 -s3.endpoint=localhost:9000 -s3.bucket-name=blocks' --output-bucket-config='-backend=s3 -s3.endpoint=localhost:9000 -s3.bucket-name=parquet'
+
+`)
+	fs.Usage()
+}
+
+// parquetExportedMarker is uploaded once all the Parquet files of a block have been uploaded. Blocks with a marker
+// are skipped by the next runs.
+type parquetExportedMarker struct {
+	ID      ulid.ULID `json:"id"`
+	MinTime int64     `json:"min_time"`
+	MaxTime int64     `json:"max_time"`
+	// ShardID and Sources are the compactor shard and the source blocks of the exported block. They're used to find
+	// the exported blocks which have been compacted into another block.
+	ShardID      string      `json:"shard_id,omitempty"`
+	Sources      []ulid.ULID `json:"sources"`
+	Files        []string    `json:"files"`
+	Rows         int64       `json:"rows"`
+	ExportedTime int64       `json:"exported_time"`
+}
+
+func (c *ParquetExportCommand) exportBlocks(ctx context.Context, bkt, outputBkt objstore.Bucket) error {
+	userBkt := bucket.NewUserBucketClient(c.userID, bkt, nil)
+	outputBkt = bucket.NewPrefixedBucketClient(outputBkt, "tenant="+c.userID)
+
+	exported, err := listParquetExportedMarkers(ctx, outputBkt)
+	if err != nil {
+		return err
+	}
+
+	metas, err := c.targetBlocks(ctx, userBkt, exported)
+	if err != nil {
+		return err
+	}
+	level.Info(c.logger).Log("msg", "found blocks to export", "blocks", len(metas))
+
+	return concurrency.ForEachJob(ctx, len(metas), c.blockConcurrency, func(ctx context.Context, idx int) error {
+		meta := metas[idx]
+		superseded := supersededParquetExports(exported, meta)
+
+		logger := log.With(c.logger, "block", meta.ULID.String())
+		if c.dryRun {
+			level.Info(logger).Log("msg", "dry run: would export block", "min_time", meta.MinTime, "max_time", meta.MaxTime, "superseded_blocks", len(superseded))
+			return nil
+		}
+
+		if err := c.exportBlock(ctx, userBkt, outputBkt, meta, logger); err != nil {
+			level.Error(logger).Log("msg", "failed to export block", "err", err)
+			return err
+		}
+
+		// The samples of the superseded blocks have been exported again with the compacted block, so their files
+		// are deleted to not duplicate the samples.
+		for _, m := range superseded {
+			if err := deleteParquetExport(ctx, outputBkt, m); err != nil {
+				level.Error(logger).Log("msg", "failed to delete the export of a superseded block", "superseded_block", m.ID.String(), "err", err)
+				return err
+			}
+			level.Info(logger).Log("msg", "deleted the export of a superseded block", "superseded_block", m.ID.String())
+		}
+		return nil
+	})
+}
+
+// targetBlocks returns the blocks of the tenant to export, sorted by ID. Blocks marked for deletion, moved to the
+// cold storage, quarantined, downsampled, shorter than the minimum duration or already exported are skipped.
+// Blocks whose source blocks have all been exported, for example because they've been compacted from exported
+// blocks, are skipped too.
+func (c *ParquetExportCommand) targetBlocks(ctx context.Context, userBkt objstore.InstrumentedBucket, exported map[ulid.ULID]*parquetExportedMarker) ([]*block.Meta, error) {
+	cold, err := block.ListBlockColdStorageMarks(ctx, userBkt)
+	if err != nil {
+		return nil, err
+	}
+	quarantined, err := block.ListBlockQuarantineMarks(ctx, userBkt)
+	if err != nil {
+		return nil, err
+	}
+
+	fetcher, err := block.NewMetaFetcher(c.logger, parquetMetaFetchConcurrency, userBkt, "", nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	metas, _, err := fetcher.FetchWithoutMarkedForDeletion(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to fetch blocks metadata")
+	}
+
+	// Exported source blocks, by compactor shard.
+	exportedSources := map[string]map[ulid.ULID]struct{}{}
+	for _, m := range exported {
+		if exportedSources[m.ShardID] == nil {
+			exportedSources[m.ShardID] = map[ulid.ULID]struct{}{}
+		}
+		for _, id := range m.Sources {
+			exportedSources[m.ShardID][id] = struct{}{}
+		}
+	}
+
+	var result []*block.Meta
+	for id, meta := range metas {
+		_, isExported := exported[id]
+		_, isCold := cold[id]
+		_, isQuarantined := quarantined[id]
+
+		switch {
+		case isExported || isCold || isQuarantined:
+			continue
+		case meta.Thanos.Downsample.Resolution > 0:
+			continue
+		case time.Duration(meta.MaxTime-meta.MinTime)*time.Millisecond < c.minBlockDuration:
+			continue
+		case allSourcesExported(meta, exportedSources[parquetExportShardID(meta)]):
+			continue
+		}
+		result = append(result, meta)
+	}
+
+	slices.SortFunc(result, func(a, b *block.Meta) int {
+		return a.ULID.Compare(b.ULID)
+	})
+	return result, nil
+}
+
+func allSourcesExported(meta *block.Meta, exportedSources map[ulid.ULID]struct{}) bool {
+	for _, id := range meta.Compaction.Sources {
+		if _, ok := exportedSources[id]; !ok {
+			return false
+		}
+	}
+	return len(meta.Compaction.Sources) > 0
+}
+
+// supersededParquetExports returns the exported blocks, of the same compactor shard, which have been compacted into
+// the input block.
+func supersededParquetExports(exported map[ulid.ULID]*parquetExportedMarker, meta *block.Meta) []*parquetExportedMarker {
+	shardID := parquetExportShardID(meta)
+
+	var superseded []*parquetExportedMarker
+	for _, m := range exported {
+		if m.ShardID != shardID || m.ID == meta.ULID {
+			continue
+		}
+		if slices.ContainsFunc(m.Sources, func(id ulid.ULID) bool { return slices.Contains(meta.Compaction.Sources, id) }) {
+			superseded = append(superseded, m)
+		}
+	}
+
+	slices.SortFunc(superseded, func(a, b *parquetExportedMarker) int {
+		return a.ID.Compare(b.ID)
+	})
+	return superseded
+}
+
+// parquetExportShardID returns the compactor shard of the block, if any. The split compactor creates a block for
+// each shard from the same source blocks.
+func parquetExportShardID(meta *block.Meta) string {
+	return meta.Thanos.Labels[mimir_tsdb.CompactorShardIDExternalLabel]
+}
+
+func listParquetExportedMarkers(ctx context.Context, outputBkt objstore.Bucket) (map[ulid.ULID]*parquetExportedMarker, error) {
+	exported := map[ulid.ULID]*parquetExportedMarker{}
+
+	err := outputBkt.Iter(ctx, parquetExportedMarkersDir+"/", func(name string) error {
+		id, err := ulid.Parse(strings.TrimSuffix(path.Base(name), ".json"))
+		if err != nil {
+			return nil
+		}
+
+		r, err := outputBkt.Get(ctx, name)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read exported marker %s", name)
+		}
+		defer r.Close()
+
+		m := &parquetExportedMarker{}
+		if err := json.NewDecoder(r).Decode(m); err != nil {
+			return errors.Wrapf(err, "failed to decode exported marker %s", name)
+		}
+		exported[id] = m
+		return nil
+	})
+
+	return exported, errors.Wrap(err, "failed to list exported blocks")
+}
+
+// deleteParquetExport deletes the Parquet files of an exported block and then its marker.
+func deleteParquetExport(ctx context.Context, outputBkt objstore.Bucket, m *parquetExportedMarker) error {
+	for _, name := range m.Files {
+		if err := outputBkt.Delete(ctx, name); err != nil && !outputBkt.IsObjNotFoundErr(err) {
+			return errors.Wrapf(err, "failed to delete %s", name)
+		}
+	}
+
+	name := path.Join(parquetExportedMarkersDir, m.ID.String()+".json")
+	if err := outputBkt.Delete(ctx, name); err != nil && !outputBkt.IsObjNotFoundErr(err) {
+		return errors.Wrapf(err, "failed to delete %s", name)
+	}
+	return nil
+}
+
+// exportBlock downloads the block, converts its samples to one Parquet file per UTC day, uploads the files and
+// finally the exported marker. Exporting a block again overwrites the same files.
+func (c *ParquetExportCommand) exportBlock(ctx context.Context, userBkt, outputBkt objstore.Bucket, meta *block.Meta, logger log.Logger) error {
+	tmpDir, err := os.MkdirTemp(c.workDir, "parquet-export-")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			level.Warn(logger).Log("msg", "failed to remove temporary directory", "dir", tmpDir, "err", err)
+		}
+	}()
+
+	blockDir := filepath.Join(tmpDir, meta.ULID.String())
+	if err := block.Download(ctx, logger, userBkt, meta.ULID, blockDir); err != nil {
+		return errors.Wrap(err, "failed to download block")
+	}
+
+	files, rows, err := writeParquetFiles(ctx, blockDir, filepath.Join(tmpDir, "parquet"), meta, c.maxRowsPerRowGroup, logger)
+	if err != nil {
+		return err
+	}
+
+	marker := parquetExportedMarker{
+		ID:           meta.ULID,
+		MinTime:      meta.MinTime,
+		MaxTime:      meta.MaxTime,
+		ShardID:      parquetExportShardID(meta),
+		Sources:      meta.Compaction.Sources,
+		Rows:         rows,
+		ExportedTime: time.Now().Unix(),
+	}
+	for _, f := range files {
+		name := path.Join("date="+f.date, meta.ULID.String()+".parquet")
+		if err := objstore.UploadFile(ctx, logger, outputBkt, f.path, name); err != nil {
+			return errors.Wrapf(err, "failed to upload %s", name)
+		}
+		marker.Files = append(marker.Files, name)
+	}
+
+	data, err := json.Marshal(marker)
+	if err != nil {
+		return err
+	}
+	if err := outputBkt.Upload(ctx, path.Join(parquetExportedMarkersDir, meta.ULID.String()+".json"), bytes.NewReader(data)); err != nil {
+		return errors.Wrap(err, "failed to upload exported marker")
+	}
+
+	level.Info(logger).Log("msg", "exported block", "files", len(files), "rows", rows)
+	return nil
+}
+
+// parquetSampleRow is a row of the exported Parquet files. Exactly one of Value and Histogram is set.
+type parquetSampleRow struct {
+	MetricName string               `parquet:"metric_name,dict"`
+	Labels     map[string]string    `parquet:"labels" parquet-key:",dict" parquet-value:",dict"`
+	Timestamp  int64                `parquet:"timestamp,timestamp(millisecond)"`
+	Value      *float64             `parquet:"value,optional"`
+	Histogram  *parquetHistogramRow `parquet:"histogram,optional"`
+}
+
+// parquetHistogramRow is a native histogram sample. Integer histograms are converted to float histograms, so the
+// buckets are absolute counts and not deltas.
+type parquetHistogramRow struct {
+	CounterResetHint string           `parquet:"counter_reset_hint,enum"`
+	Schema           int32            `parquet:"schema"`
+	ZeroThreshold    float64          `parquet:"zero_threshold"`
+	ZeroCount        float64          `parquet:"zero_count"`
+	Count            float64          `parquet:"count"`
+	Sum              float64          `parquet:"sum"`
+	PositiveSpans    []parquetSpanRow `parquet:"positive_spans,list"`
+	PositiveBuckets  []float64        `parquet:"positive_buckets,list"`
+	NegativeSpans    []parquetSpanRow `parquet:"negative_spans,list"`
+	NegativeBuckets  []float64        `parquet:"negative_buckets,list"`
+	CustomValues     []float64        `parquet:"custom_values,list"`
+}
+
+type parquetSpanRow struct {
+	Offset int32  `parquet:"offset"`
+	Length uint32 `parquet:"length"`
+}
+
+type parquetFile struct {
+	date string
+	path string
+}
+
+type parquetPartitionWriter struct {
+	file   *os.File
+	writer *parquet.GenericWriter[parquetSampleRow]
+	rows   []parquetSampleRow
+
+	// Number of rows written to the current row group, which is buffered in memory until flushed.
+	rowGroupRows       int64
+	maxRowsPerRowGroup int64
+}
+
+func (w *parquetPartitionWriter) write(row parquetSampleRow) error {
+	w.rows = append(w.rows, row)
+	if len(w.rows) < parquetRowsBatchSize && w.rowGroupRows+int64(len(w.rows)) < w.maxRowsPerRowGroup {
+		return nil
+	}
+	return w.flush()
+}
+
+// flush writes the buffered rows, and flushes the row group once it's full.
+func (w *parquetPartitionWriter) flush() error {
+	if _, err := w.writer.Write(w.rows); err != nil {
+		return err
+	}
+	w.rowGroupRows += int64(len(w.rows))
+	w.rows = w.rows[:0]
+
+	if w.rowGroupRows < w.maxRowsPerRowGroup {
+		return nil
+	}
+	w.rowGroupRows = 0
+	return w.writer.Flush()
+}
+
+func (w *parquetPartitionWriter) close() error {
+	err := w.flush()
+	if closeErr := w.writer.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := w.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// writeParquetFiles writes the samples of the block in blockDir to one Parquet file per UTC day in outputDir.
+// It returns the written files sorted by date, and the total number of rows. Staleness markers are skipped.
+func writeParquetFiles(ctx context.Context, blockDir, outputDir string, meta *block.Meta, maxRowsPerRowGroup int64, logger log.Logger) (_ []parquetFile, _ int64, returnErr error) {
+	if err := os.MkdirAll(outputDir, 0o750); err != nil {
+		return nil, 0, err
+	}
+
+	b, err := tsdb.OpenBlock(logger, blockDir, nil)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "failed to open block")
+	}
+	defer func() {
+		if err := b.Close(); err != nil && returnErr == nil {
+			returnErr = errors.Wrap(err, "failed to close block")
+		}
+	}()
+
+	q, err := tsdb.NewBlockQuerier(b, meta.MinTime, meta.MaxTime)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "failed to create block querier")
+	}
+	defer func() {
+		if err := q.Close(); err != nil && returnErr == nil {
+			returnErr = errors.Wrap(err, "failed to close block querier")
+		}
+	}()
+
+	partitions := map[string]*parquetPartitionWriter{}
+	defer func() {
+		for _, w := range partitions {
+			if err := w.close(); err != nil && returnErr == nil {
+				returnErr = errors.Wrap(err, "failed to close Parquet file")
+			}
+		}
+	}()
+
+	partitionFor := func(ts int64) (*parquetPartitionWriter, error) {
+		date := time.UnixMilli(ts).UTC().Format(time.DateOnly)
+		if w, ok := partitions[date]; ok {
+			return w, nil
+		}
+
+		f, err := os.Create(filepath.Join(outputDir, date+".parquet"))
+		if err != nil {
+			return nil, err
+		}
+		w := &parquetPartitionWriter{
+			file:               f,
+			writer:             parquet.NewGenericWriter[parquetSampleRow](f, parquet.Compression(&parquet.Zstd), parquet.MaxRowsPerRowGroup(maxRowsPerRowGroup)),
+			rows:               make([]parquetSampleRow, 0, parquetRowsBatchSize),
+			maxRowsPerRowGroup: maxRowsPerRowGroup,
+		}
+		partitions[date] = w
+		return w, nil
+	}
+
+	var (
+		rows int64
+		it   chunkenc.Iterator
+	)
+	set := q.Select(ctx, false, nil, labels.MustNewMatcher(labels.MatchRegexp, labels.MetricName, ".*"))
+	for set.Next() {
+		if err := ctx.Err(); err != nil {
+			return nil, 0, err
+		}
+
+		series := set.At()
+		metricName, lbls := parquetSeriesLabels(series.Labels())
+
+		it = series.Iterator(it)
+		for vt := it.Next(); vt != chunkenc.ValNone; vt = it.Next() {
+			row := parquetSampleRow{MetricName: metricName, Labels: lbls}
+
+			switch vt {
+			case chunkenc.ValFloat:
+				var v float64
+				row.Timestamp, v = it.At()
+				if value.IsStaleNaN(v) {
+					continue
+				}
+				row.Value = &v
+			case chunkenc.ValHistogram, chunkenc.ValFloatHistogram:
+				var fh *histogram.FloatHistogram
+				row.Timestamp, fh = it.AtFloatHistogram(nil)
+				if value.IsStaleNaN(fh.Sum) {
+					continue
+				}
+				row.Histogram = newParquetHistogramRow(fh)
+			default:
+				return nil, 0, errors.Errorf("unsupported value type %v", vt)
+			}
+
+			w, err := partitionFor(row.Timestamp)
+			if err != nil {
+				return nil, 0, errors.Wrap(err, "failed to create Parquet file")
+			}
+			if err := w.write(row); err != nil {
+				return nil, 0, errors.Wrap(err, "failed to write Parquet rows")
+			}
+			rows++
+		}
+		if err := it.Err(); err != nil {
+			return nil, 0, errors.Wrapf(err, "failed to iterate samples of series %s", series.Labels())
+		}
+	}
+	if err := set.Err(); err != nil {
+		return nil, 0, errors.Wrap(err, "failed to iterate series")
+	}
+
+	files := make([]parquetFile, 0, len(partitions))
+	for date, w := range partitions {
+		files = append(files, parquetFile{date: date, path: w.file.Name()})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].date < files[j].date
+	})
+	return files, rows, nil
+}
+
+// parquetSeriesLabels returns the metric name and the other labels of the series.
+func parquetSeriesLabels(lset labels.Labels) (string, map[string]string) {
+	lbls := make(map[string]string, lset.Len())
+	lset.Range(func(l labels.Label) {
+		lbls[l.Name] = l.Value
+	})
+
+	metricName := lbls[labels.MetricName]
+	delete(lbls, labels.MetricName)
+	return metricName, lbls
+}
+
+func newParquetHistogramRow(fh *histogram.FloatHistogram) *parquetHistogramRow {
+	return &parquetHistogramRow{
+		CounterResetHint: counterResetHintString(fh.CounterResetHint),
+		Schema:           fh.Schema,
+		ZeroThreshold:    fh.ZeroThreshold,
+		ZeroCount:        fh.ZeroCount,
+		Count:            fh.Count,
+		Sum:              fh.Sum,
+		PositiveSpans:    newParquetSpanRows(fh.PositiveSpans),
+		PositiveBuckets:  fh.PositiveBuckets,
+		NegativeSpans:    newParquetSpanRows(fh.NegativeSpans),
+		NegativeBuckets:  fh.NegativeBuckets,
+		CustomValues:     fh.CustomValues,
+	}
+}
+
+func newParquetSpanRows(spans []histogram.Span) []parquetSpanRow {
+	if len(spans) == 0 {
+		return nil
+	}
+
+	rows := make([]parquetSpanRow, 0, len(spans))
+	for _, s := range spans {
+		rows = append(rows, parquetSpanRow{Offset: s.Offset, Length: s.Length})
+	}
+	return rows
+}
+
+func counterResetHintString(hint histogram.CounterResetHint) string {
+	switch hint {
+	case histogram.CounterReset:
+		return "reset"
+	case histogram.NotCounterReset:
+		return "not_reset"
+	case histogram.GaugeType:
+		return "gauge"
+	default:
+		return "unknown"
+	}
+}
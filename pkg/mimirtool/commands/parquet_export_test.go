@@ -0,0 +1,324 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package commands
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid"
+	"github.com/parquet-go/parquet-go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/util/test"
+)
+
+const parquetExportUserID = "user-1"
+
+func TestParquetExportCommand_exportBlocks(t *testing.T) {
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+	bkt := block.BucketWithGlobalMarkers(objstore.NewInMemBucket())
+	userBkt := bucket.NewUserBucketClient(parquetExportUserID, bkt, nil)
+	outputBkt := objstore.NewInMemBucket()
+
+	startOfDay := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	h := test.GenerateTestHistogram(1)
+
+	exported := uploadParquetExportBlock(t, userBkt, []*block.SeriesSpec{
+		{
+			Labels: labels.FromStrings("__name__", "series_1", "job", "a"),
+			Chunks: []chunks.Meta{
+				mustParquetExport(chunks.ChunkFromSamples([]chunks.Sample{
+					newParquetExportSample(startOfDay.Add(time.Hour).UnixMilli(), 1, nil),
+					newParquetExportSample(startOfDay.Add(2*time.Hour).UnixMilli(), math.Float64frombits(value.StaleNaN), nil),
+					newParquetExportSample(startOfDay.Add(25*time.Hour).UnixMilli(), 2, nil),
+				})),
+			},
+		},
+		{
+			Labels: labels.FromStrings("__name__", "series_2"),
+			Chunks: []chunks.Meta{
+				mustParquetExport(chunks.ChunkFromSamples([]chunks.Sample{
+					newParquetExportSample(startOfDay.Add(30*time.Hour).UnixMilli(), 0, h),
+				})),
+			},
+		},
+	}, nil)
+	short := uploadParquetExportBlock(t, userBkt, []*block.SeriesSpec{
+		{
+			Labels: labels.FromStrings("__name__", "series_1", "job", "a"),
+			Chunks: []chunks.Meta{
+				mustParquetExport(chunks.ChunkFromSamples([]chunks.Sample{
+					newParquetExportSample(startOfDay.Add(48*time.Hour).UnixMilli(), 3, nil),
+					newParquetExportSample(startOfDay.Add(49*time.Hour).UnixMilli(), 4, nil),
+				})),
+			},
+		},
+	}, nil)
+	deleted := uploadParquetExportBlock(t, userBkt, []*block.SeriesSpec{
+		{
+			Labels: labels.FromStrings("__name__", "series_1", "job", "a"),
+			Chunks: []chunks.Meta{
+				mustParquetExport(chunks.ChunkFromSamples([]chunks.Sample{
+					newParquetExportSample(startOfDay.UnixMilli(), 5, nil),
+					newParquetExportSample(startOfDay.Add(48*time.Hour).UnixMilli(), 6, nil),
+				})),
+			},
+		},
+	}, nil)
+	require.NoError(t, block.MarkForDeletion(ctx, logger, userBkt, deleted, "test", prometheus.NewCounter(prometheus.CounterOpts{Name: "test"})))
+
+	cmd := newTestParquetExportCommand(t)
+	cmd.minBlockDuration = 12 * time.Hour
+	cmd.dryRun = true
+
+	// A dry run doesn't export anything.
+	require.NoError(t, cmd.exportBlocks(ctx, bkt, outputBkt))
+	assert.Empty(t, outputBkt.Objects())
+
+	cmd.dryRun = false
+	require.NoError(t, cmd.exportBlocks(ctx, bkt, outputBkt))
+
+	tenantPrefix := "tenant=" + parquetExportUserID
+	firstDay := path.Join(tenantPrefix, "date=2024-05-01", exported.String()+".parquet")
+	secondDay := path.Join(tenantPrefix, "date=2024-05-02", exported.String()+".parquet")
+	marker := path.Join(tenantPrefix, parquetExportedMarkersDir, exported.String()+".json")
+	require.ElementsMatch(t, []string{firstDay, secondDay, marker}, parquetExportObjectNames(outputBkt))
+
+	// The staleness marker is skipped.
+	rows := readParquetRows(t, outputBkt, firstDay)
+	require.Len(t, rows, 1)
+	assert.Equal(t, "series_1", rows[0].MetricName)
+	assert.Equal(t, map[string]string{"job": "a"}, rows[0].Labels)
+	assert.Equal(t, startOfDay.Add(time.Hour).UnixMilli(), rows[0].Timestamp)
+	require.NotNil(t, rows[0].Value)
+	assert.Equal(t, 1.0, *rows[0].Value)
+	assert.Nil(t, rows[0].Histogram)
+
+	rows = readParquetRows(t, outputBkt, secondDay)
+	require.Len(t, rows, 2)
+	assert.Equal(t, "series_1", rows[0].MetricName)
+	assert.Equal(t, 2.0, *rows[0].Value)
+	assert.Equal(t, "series_2", rows[1].MetricName)
+	assert.Empty(t, rows[1].Labels)
+	assert.Equal(t, startOfDay.Add(30*time.Hour).UnixMilli(), rows[1].Timestamp)
+	assert.Nil(t, rows[1].Value)
+	expectedHistogram := newParquetHistogramRow(h.ToFloat(nil))
+	expectedHistogram.CustomValues = []float64{} // Empty lists are read back as empty slices.
+	assert.Equal(t, expectedHistogram, rows[1].Histogram)
+
+	// The exported blocks are skipped by the next runs, while the blocks reaching the minimum duration are exported.
+	require.NoError(t, outputBkt.Delete(ctx, firstDay))
+	cmd.minBlockDuration = time.Hour
+	require.NoError(t, cmd.exportBlocks(ctx, bkt, outputBkt))
+
+	require.ElementsMatch(t, []string{
+		secondDay,
+		marker,
+		path.Join(tenantPrefix, "date=2024-05-03", short.String()+".parquet"),
+		path.Join(tenantPrefix, parquetExportedMarkersDir, short.String()+".json"),
+	}, parquetExportObjectNames(outputBkt))
+}
+
+func TestCounterResetHintString(t *testing.T) {
+	for hint, expected := range map[histogram.CounterResetHint]string{
+		histogram.UnknownCounterReset: "unknown",
+		histogram.CounterReset:        "reset",
+		histogram.NotCounterReset:     "not_reset",
+		histogram.GaugeType:           "gauge",
+	} {
+		assert.Equal(t, expected, counterResetHintString(hint))
+	}
+}
+
+func TestParquetExportCommand_exportBlocks_ShouldReplaceTheExportOfCompactedBlocks(t *testing.T) {
+	ctx := context.Background()
+	bkt := block.BucketWithGlobalMarkers(objstore.NewInMemBucket())
+	userBkt := bucket.NewUserBucketClient(parquetExportUserID, bkt, nil)
+	outputBkt := objstore.NewInMemBucket()
+
+	startOfDay := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	specs := func(values ...float64) []*block.SeriesSpec {
+		var samples []chunks.Sample
+		for i, v := range values {
+			samples = append(samples, newParquetExportSample(startOfDay.Add(time.Duration(i+1)*time.Hour).UnixMilli(), v, nil))
+		}
+		return []*block.SeriesSpec{{
+			Labels: labels.FromStrings("__name__", "series_1"),
+			Chunks: []chunks.Meta{mustParquetExport(chunks.ChunkFromSamples(samples))},
+		}}
+	}
+
+	cmd := newTestParquetExportCommand(t)
+	cmd.minBlockDuration = time.Hour
+
+	first := uploadParquetExportBlock(t, userBkt, specs(1, 2), nil)
+	require.NoError(t, cmd.exportBlocks(ctx, bkt, outputBkt))
+
+	tenantPrefix := "tenant=" + parquetExportUserID
+	exportedFiles := func(id ulid.ULID) []string {
+		return []string{
+			path.Join(tenantPrefix, "date=2024-05-01", id.String()+".parquet"),
+			path.Join(tenantPrefix, parquetExportedMarkersDir, id.String()+".json"),
+		}
+	}
+	require.ElementsMatch(t, exportedFiles(first), parquetExportObjectNames(outputBkt))
+
+	// The first block and another one are compacted together: the compacted block is exported and the export of the
+	// first block is deleted, so that its samples aren't duplicated.
+	other := ulid.MustNew(1, nil)
+	compacted := uploadParquetExportBlock(t, userBkt, specs(1, 2, 3), func(meta *block.Meta) {
+		meta.Compaction.Sources = []ulid.ULID{first, other}
+	})
+	require.NoError(t, cmd.exportBlocks(ctx, bkt, outputBkt))
+	require.ElementsMatch(t, exportedFiles(compacted), parquetExportObjectNames(outputBkt))
+
+	rows := readParquetRows(t, outputBkt, exportedFiles(compacted)[0])
+	require.Len(t, rows, 3)
+
+	// The first block, not marked for deletion yet, isn't exported again because its samples have been exported with
+	// the compacted block.
+	require.NoError(t, cmd.exportBlocks(ctx, bkt, outputBkt))
+	require.ElementsMatch(t, exportedFiles(compacted), parquetExportObjectNames(outputBkt))
+
+	// A block of a compactor shard, compacted from the same blocks, is exported without deleting the other exports.
+	sharded := uploadParquetExportBlock(t, userBkt, specs(4, 5), func(meta *block.Meta) {
+		meta.Compaction.Sources = []ulid.ULID{first, other}
+		meta.Thanos.Labels = map[string]string{mimir_tsdb.CompactorShardIDExternalLabel: "1_of_2"}
+	})
+	require.NoError(t, cmd.exportBlocks(ctx, bkt, outputBkt))
+	require.ElementsMatch(t, append(exportedFiles(compacted), exportedFiles(sharded)...), parquetExportObjectNames(outputBkt))
+}
+
+func TestWriteParquetFiles_ShouldFlushTheRowGroups(t *testing.T) {
+	dir := t.TempDir()
+	startOfDay := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	var samples []chunks.Sample
+	for i := 0; i < 5; i++ {
+		samples = append(samples, newParquetExportSample(startOfDay.Add(time.Duration(i)*time.Minute).UnixMilli(), float64(i), nil))
+	}
+	meta, err := block.GenerateBlockFromSpec(dir, []*block.SeriesSpec{{
+		Labels: labels.FromStrings("__name__", "series_1"),
+		Chunks: []chunks.Meta{mustParquetExport(chunks.ChunkFromSamples(samples))},
+	}})
+	require.NoError(t, err)
+
+	files, rows, err := writeParquetFiles(context.Background(), filepath.Join(dir, meta.ULID.String()), filepath.Join(dir, "parquet"), meta, 2, log.NewNopLogger())
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.Equal(t, int64(5), rows)
+
+	f, err := os.Open(files[0].path)
+	require.NoError(t, err)
+	defer f.Close()
+	info, err := f.Stat()
+	require.NoError(t, err)
+
+	pf, err := parquet.OpenFile(f, info.Size())
+	require.NoError(t, err)
+	require.Equal(t, int64(5), pf.NumRows())
+
+	var rowGroupRows []int64
+	for _, rg := range pf.RowGroups() {
+		rowGroupRows = append(rowGroupRows, rg.NumRows())
+	}
+	require.Equal(t, []int64{2, 2, 1}, rowGroupRows)
+}
+
+func newTestParquetExportCommand(t *testing.T) *ParquetExportCommand {
+	return &ParquetExportCommand{
+		userID:             parquetExportUserID,
+		minBlockDuration:   24 * time.Hour,
+		blockConcurrency:   2,
+		maxRowsPerRowGroup: 1000,
+		workDir:            t.TempDir(),
+		logger:             log.NewNopLogger(),
+	}
+}
+
+// uploadParquetExportBlock generates a block from the specs, optionally modifies its meta, and uploads it.
+func uploadParquetExportBlock(t *testing.T, bkt objstore.Bucket, specs []*block.SeriesSpec, modifyMeta func(*block.Meta)) ulid.ULID {
+	dir := t.TempDir()
+	meta, err := block.GenerateBlockFromSpec(dir, specs)
+	require.NoError(t, err)
+	if modifyMeta != nil {
+		modifyMeta(meta)
+	}
+	require.NoError(t, block.Upload(context.Background(), log.NewNopLogger(), bkt, filepath.Join(dir, meta.ULID.String()), meta))
+	return meta.ULID
+}
+
+func parquetExportObjectNames(bkt *objstore.InMemBucket) []string {
+	var names []string
+	for name := range bkt.Objects() {
+		names = append(names, name)
+	}
+	return names
+}
+
+func readParquetRows(t *testing.T, bkt *objstore.InMemBucket, name string) []parquetSampleRow {
+	data := bkt.Objects()[name]
+
+	r := parquet.NewGenericReader[parquetSampleRow](bytes.NewReader(data))
+	defer func() { require.NoError(t, r.Close()) }()
+
+	rows := make([]parquetSampleRow, r.NumRows())
+	n, err := r.Read(rows)
+	if err != nil && err != io.EOF {
+		require.NoError(t, err)
+	}
+	return rows[:n]
+}
+
+func mustParquetExport[T any](v T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+type parquetExportSample struct {
+	t int64
+	v float64
+	h *histogram.Histogram
+}
+
+func newParquetExportSample(t int64, v float64, h *histogram.Histogram) chunks.Sample {
+	return parquetExportSample{t, v, h}
+}
+func (s parquetExportSample) T() int64                      { return s.t }
+func (s parquetExportSample) F() float64                    { return s.v }
+func (s parquetExportSample) H() *histogram.Histogram       { return s.h }
+func (s parquetExportSample) FH() *histogram.FloatHistogram { return nil }
+
+func (s parquetExportSample) Type() chunkenc.ValueType {
+	if s.h != nil {
+		return chunkenc.ValHistogram
+	}
+	return chunkenc.ValFloat
+}
+
+func (s parquetExportSample) Copy() chunks.Sample {
+	return s
+}
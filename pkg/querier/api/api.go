@@ -2,7 +2,11 @@
 
 package api
 
-import "github.com/prometheus/prometheus/model/labels"
+import (
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+)
 
 // ContentTypeRemoteReadStreamedChunks is taken from the prometheus protobuf definitions documentation.
 // See: https://github.com/prometheus/prometheus/blob/d9d51c565c622cdc7d626d3e7569652bc28abe15/prompb/remote.proto#L48
@@ -36,6 +40,21 @@ type LabelNamesCardinalityItem struct {
 	LabelValuesCount int    `json:"label_values_count"`
 }
 
+type UnusedMetricsResponse struct {
+	// TrackedSince is when the tracking of the metrics usage started, or nil if it hasn't started yet.
+	TrackedSince           *time.Time     `json:"tracked_since"`
+	SeriesCountTotal       uint64         `json:"series_count_total"`
+	UnusedSeriesCountTotal uint64         `json:"unused_series_count_total"`
+	Metrics                []UnusedMetric `json:"metrics"`
+}
+
+type UnusedMetric struct {
+	MetricName  string `json:"metric_name"`
+	SeriesCount uint64 `json:"series_count"`
+	// LastQueried is when the metric has been last queried, or nil if it has never been queried since the tracking started.
+	LastQueried *time.Time `json:"last_queried"`
+}
+
 type ActiveSeriesResponse struct {
 	Data []labels.Labels `json:"data"`
 }
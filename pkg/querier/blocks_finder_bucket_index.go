@@ -6,8 +6,10 @@
 package querier
 
 import (
+	"cmp"
 	"context"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/go-kit/log"
@@ -55,7 +57,7 @@ func NewBucketIndexBlocksFinder(cfg BucketIndexBlocksFinderConfig, bkt objstore.
 }
 
 // GetBlocks implements BlocksFinder.
-func (f *BucketIndexBlocksFinder) GetBlocks(ctx context.Context, userID string, minT, maxT int64) (bucketindex.Blocks, error) {
+func (f *BucketIndexBlocksFinder) GetBlocks(ctx context.Context, userID string, minT, maxT, maxResolution int64) (bucketindex.Blocks, error) {
 	if f.State() != services.Running {
 		return nil, errBucketIndexBlocksFinderNotRunning
 	}
@@ -87,6 +89,11 @@ func (f *BucketIndexBlocksFinder) GetBlocks(ctx context.Context, userID string,
 			continue
 		}
 
+		// Exclude blocks which failed the integrity verification.
+		if block.Quarantined {
+			continue
+		}
+
 		matchingBlocks[block.ID] = block
 	}
 
@@ -98,13 +105,97 @@ func (f *BucketIndexBlocksFinder) GetBlocks(ctx context.Context, userID string,
 		}
 	}
 
-	// Convert matching blocks into a list.
-	blocks := make(bucketindex.Blocks, 0, len(matchingBlocks))
+	return selectBlocksByResolution(matchingBlocks, minT, maxT, maxResolution), nil
+}
+
+// selectBlocksByResolution returns the blocks to query among the matching blocks of all resolutions. The
+// resolutions are picked from the coarsest one not greater than maxResolution to the finest one, and then
+// from the finest one greater than maxResolution to the coarsest one. A block is selected only if the
+// blocks of the already picked resolutions don't cover the queried part of its time range yet.
+func selectBlocksByResolution(matchingBlocks map[ulid.ULID]*bucketindex.Block, minT, maxT, maxResolution int64) bucketindex.Blocks {
+	byResolution := map[int64]bucketindex.Blocks{}
 	for _, b := range matchingBlocks {
-		blocks = append(blocks, b)
+		byResolution[b.Resolution] = append(byResolution[b.Resolution], b)
+	}
+
+	// Fast path: only raw blocks, which is the case when downsampling is disabled.
+	if len(byResolution) <= 1 {
+		blocks := make(bucketindex.Blocks, 0, len(matchingBlocks))
+		for _, b := range matchingBlocks {
+			blocks = append(blocks, b)
+		}
+		return blocks
+	}
+
+	resolutions := make([]int64, 0, len(byResolution))
+	for res := range byResolution {
+		resolutions = append(resolutions, res)
 	}
+	slices.SortFunc(resolutions, func(a, b int64) int {
+		// Resolutions not greater than maxResolution come first, from the coarsest one.
+		aWithin, bWithin := a <= maxResolution, b <= maxResolution
+		switch {
+		case aWithin && !bWithin:
+			return -1
+		case !aWithin && bWithin:
+			return 1
+		case aWithin:
+			return cmp.Compare(b, a)
+		}
+		return cmp.Compare(a, b)
+	})
+
+	var (
+		blocks = make(bucketindex.Blocks, 0, len(matchingBlocks))
+		// The time ranges covered by the selected blocks, by compactor shard ID. The blocks of a split
+		// compaction only contain a subset of the series, so they only cover the blocks of the same shard.
+		covered = map[string][]blocksTimeRange{}
+	)
+
+	for _, res := range resolutions {
+		var selected []*bucketindex.Block
+		for _, b := range byResolution[res] {
+			// Block MaxTime is exclusive, while maxT is inclusive.
+			r := blocksTimeRange{minT: max(b.MinTime, minT), maxT: min(b.MaxTime-1, maxT)}
+			if !r.coveredBy(covered[b.CompactorShardID]) {
+				selected = append(selected, b)
+			}
+		}
 
-	return blocks, nil
+		// Only update the covered ranges after the whole resolution has been checked, so that overlapping
+		// blocks of the same resolution are all selected.
+		for _, b := range selected {
+			covered[b.CompactorShardID] = append(covered[b.CompactorShardID], blocksTimeRange{minT: b.MinTime, maxT: b.MaxTime - 1})
+		}
+		blocks = append(blocks, selected...)
+	}
+
+	return blocks
+}
+
+// blocksTimeRange is a time range in milliseconds, with both ends included.
+type blocksTimeRange struct {
+	minT, maxT int64
+}
+
+// coveredBy returns whether the time range is fully covered by the union of the ranges.
+func (r blocksTimeRange) coveredBy(ranges []blocksTimeRange) bool {
+	sorted := slices.Clone(ranges)
+	slices.SortFunc(sorted, func(a, b blocksTimeRange) int { return cmp.Compare(a.minT, b.minT) })
+
+	next := r.minT
+	for _, c := range sorted {
+		if c.minT > next {
+			break
+		}
+		if c.maxT >= next {
+			if c.maxT >= r.maxT {
+				return true
+			}
+			next = c.maxT + 1
+		}
+	}
+	return false
 }
 
 func newBucketIndexTooOldError(updatedAt time.Time, maxStalePeriod time.Duration) error {
@@ -20,6 +20,7 @@ import (
 	"github.com/thanos-io/objstore"
 
 	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
+	"github.com/grafana/mimir/pkg/storage/tsdb/downsample"
 	mimir_testutil "github.com/grafana/mimir/pkg/storage/tsdb/testutil"
 )
 
@@ -35,12 +36,13 @@ func TestBucketIndexBlocksFinder_GetBlocks(t *testing.T) {
 	block3 := &bucketindex.Block{ID: ulid.MustNew(3, nil), MinTime: 20, MaxTime: 30}
 	block4 := &bucketindex.Block{ID: ulid.MustNew(4, nil), MinTime: 30, MaxTime: 40}
 	block5 := &bucketindex.Block{ID: ulid.MustNew(5, nil), MinTime: 30, MaxTime: 40} // Time range overlaps with block4, but this block deletion mark is above the threshold.
+	block6 := &bucketindex.Block{ID: ulid.MustNew(6, nil), MinTime: 40, MaxTime: 50, Quarantined: true}
 	mark3 := &bucketindex.BlockDeletionMark{ID: block3.ID, DeletionTime: time.Now().Unix()}
 	mark5 := &bucketindex.BlockDeletionMark{ID: block5.ID, DeletionTime: time.Now().Add(-2 * time.Hour).Unix()}
 
 	require.NoError(t, bucketindex.WriteIndex(ctx, bkt, userID, nil, &bucketindex.Index{
 		Version:            bucketindex.IndexVersion1,
-		Blocks:             bucketindex.Blocks{block1, block2, block3, block4, block5},
+		Blocks:             bucketindex.Blocks{block1, block2, block3, block4, block5, block6},
 		BlockDeletionMarks: bucketindex.BlockDeletionMarks{mark3, mark5},
 		UpdatedAt:          time.Now().Unix(),
 	}))
@@ -90,11 +92,95 @@ func TestBucketIndexBlocksFinder_GetBlocks(t *testing.T) {
 			maxT:           block3.MaxTime - 1,
 			expectedBlocks: bucketindex.Blocks{block3},
 		},
+		"query range matching only a quarantined block": {
+			minT: block6.MinTime + 1,
+			maxT: block6.MaxTime - 1,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			blocks, err := finder.GetBlocks(ctx, userID, testData.minT, testData.maxT, 0)
+			require.NoError(t, err)
+			require.ElementsMatch(t, testData.expectedBlocks, blocks)
+		})
+	}
+}
+
+func TestBucketIndexBlocksFinder_GetBlocks_Downsampled(t *testing.T) {
+	const userID = "user-1"
+
+	ctx := context.Background()
+	bkt, _ := mimir_testutil.PrepareFilesystemBucket(t)
+
+	// Raw blocks, with the older ones already downsampled to 5m and the oldest one to 1h.
+	raw1 := &bucketindex.Block{ID: ulid.MustNew(1, nil), MinTime: 0, MaxTime: 100}
+	raw2 := &bucketindex.Block{ID: ulid.MustNew(2, nil), MinTime: 100, MaxTime: 200}
+	raw3 := &bucketindex.Block{ID: ulid.MustNew(3, nil), MinTime: 200, MaxTime: 300}
+	res5m1 := &bucketindex.Block{ID: ulid.MustNew(4, nil), MinTime: 0, MaxTime: 100, Resolution: downsample.ResLevel1}
+	res5m2 := &bucketindex.Block{ID: ulid.MustNew(5, nil), MinTime: 100, MaxTime: 200, Resolution: downsample.ResLevel1}
+	res1h1 := &bucketindex.Block{ID: ulid.MustNew(6, nil), MinTime: 0, MaxTime: 100, Resolution: downsample.ResLevel2}
+
+	// Split compacted raw blocks: the 5m block of a shard doesn't cover the other shards.
+	rawShard1 := &bucketindex.Block{ID: ulid.MustNew(7, nil), MinTime: 300, MaxTime: 400, CompactorShardID: "1_of_2"}
+	rawShard2 := &bucketindex.Block{ID: ulid.MustNew(8, nil), MinTime: 300, MaxTime: 400, CompactorShardID: "2_of_2"}
+	res5mShard1 := &bucketindex.Block{ID: ulid.MustNew(9, nil), MinTime: 300, MaxTime: 400, CompactorShardID: "1_of_2", Resolution: downsample.ResLevel1}
+
+	require.NoError(t, bucketindex.WriteIndex(ctx, bkt, userID, nil, &bucketindex.Index{
+		Version:   bucketindex.IndexVersion1,
+		Blocks:    bucketindex.Blocks{raw1, raw2, raw3, res5m1, res5m2, res1h1, rawShard1, rawShard2, res5mShard1},
+		UpdatedAt: time.Now().Unix(),
+	}))
+
+	finder := prepareBucketIndexBlocksFinder(t, bkt)
+
+	tests := map[string]struct {
+		minT           int64
+		maxT           int64
+		maxResolution  int64
+		expectedBlocks bucketindex.Blocks
+	}{
+		"raw resolution": {
+			minT:           0,
+			maxT:           299,
+			maxResolution:  0,
+			expectedBlocks: bucketindex.Blocks{raw1, raw2, raw3},
+		},
+		"5m resolution falling back to raw": {
+			minT:           0,
+			maxT:           299,
+			maxResolution:  downsample.ResLevel1,
+			expectedBlocks: bucketindex.Blocks{res5m1, res5m2, raw3},
+		},
+		"1h resolution falling back to 5m and raw": {
+			minT:           0,
+			maxT:           299,
+			maxResolution:  downsample.ResLevel2,
+			expectedBlocks: bucketindex.Blocks{res1h1, res5m2, raw3},
+		},
+		"resolution between 5m and 1h": {
+			minT:           0,
+			maxT:           99,
+			maxResolution:  downsample.ResLevel2 - 1,
+			expectedBlocks: bucketindex.Blocks{res5m1},
+		},
+		"query range partially covered by a coarser block": {
+			minT:           50,
+			maxT:           150,
+			maxResolution:  downsample.ResLevel2,
+			expectedBlocks: bucketindex.Blocks{res1h1, res5m2},
+		},
+		"split compacted blocks": {
+			minT:           300,
+			maxT:           399,
+			maxResolution:  downsample.ResLevel1,
+			expectedBlocks: bucketindex.Blocks{res5mShard1, rawShard2},
+		},
 	}
 
 	for testName, testData := range tests {
 		t.Run(testName, func(t *testing.T) {
-			blocks, err := finder.GetBlocks(ctx, userID, testData.minT, testData.maxT)
+			blocks, err := finder.GetBlocks(ctx, userID, testData.minT, testData.maxT, testData.maxResolution)
 			require.NoError(t, err)
 			require.ElementsMatch(t, testData.expectedBlocks, blocks)
 		})
@@ -133,7 +219,7 @@ func BenchmarkBucketIndexBlocksFinder_GetBlocks(b *testing.B) {
 	b.ResetTimer()
 
 	for n := 0; n < b.N; n++ {
-		blocks, err := finder.GetBlocks(ctx, userID, 100, 200)
+		blocks, err := finder.GetBlocks(ctx, userID, 100, 200, 0)
 		if err != nil || len(blocks) != 11 {
 			b.Fail()
 		}
@@ -147,7 +233,7 @@ func TestBucketIndexBlocksFinder_GetBlocks_BucketIndexDoesNotExist(t *testing.T)
 	bkt, _ := mimir_testutil.PrepareFilesystemBucket(t)
 	finder := prepareBucketIndexBlocksFinder(t, bkt)
 
-	blocks, err := finder.GetBlocks(ctx, userID, 10, 20)
+	blocks, err := finder.GetBlocks(ctx, userID, 10, 20, 0)
 	require.NoError(t, err)
 	assert.Empty(t, blocks)
 }
@@ -162,7 +248,7 @@ func TestBucketIndexBlocksFinder_GetBlocks_BucketIndexIsCorrupted(t *testing.T)
 	// Upload a corrupted bucket index.
 	require.NoError(t, bkt.Upload(ctx, path.Join(userID, bucketindex.IndexCompressedFilename), strings.NewReader("invalid}!")))
 
-	_, err := finder.GetBlocks(ctx, userID, 10, 20)
+	_, err := finder.GetBlocks(ctx, userID, 10, 20, 0)
 	require.Equal(t, bucketindex.ErrIndexCorrupted, err)
 }
 
@@ -181,7 +267,7 @@ func TestBucketIndexBlocksFinder_GetBlocks_BucketIndexIsTooOld(t *testing.T) {
 	}
 	require.NoError(t, bucketindex.WriteIndex(ctx, bkt, userID, nil, idx))
 
-	_, err := finder.GetBlocks(ctx, userID, 10, 20)
+	_, err := finder.GetBlocks(ctx, userID, 10, 20, 0)
 	require.EqualError(t, err, newBucketIndexTooOldError(idx.GetUpdatedAt(), finder.cfg.MaxStalePeriod).Error())
 }
 
@@ -0,0 +1,232 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/gogo/protobuf/types"
+	"github.com/grafana/dskit/services"
+	"github.com/grafana/dskit/tenant"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/exemplar"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"golang.org/x/sync/errgroup"
+	grpc_metadata "google.golang.org/grpc/metadata"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storegateway"
+	"github.com/grafana/mimir/pkg/storegateway/hintspb"
+	"github.com/grafana/mimir/pkg/storegateway/storepb"
+	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/spanlogger"
+)
+
+// ExemplarQuerier implements storage.ExemplarQueryable. Exemplars are read from the exemplars file stored
+// alongside the blocks, so they're only available if long-term exemplars storage is enabled.
+func (q *BlocksStoreQueryable) ExemplarQuerier(ctx context.Context) (storage.ExemplarQuerier, error) {
+	return &blocksStoreExemplarQuerier{ctx: ctx, queryable: q}, nil
+}
+
+type blocksStoreExemplarQuerier struct {
+	ctx       context.Context
+	queryable *BlocksStoreQueryable
+}
+
+// Select implements storage.ExemplarQuerier.
+func (q *blocksStoreExemplarQuerier) Select(start, end int64, matchers ...[]*labels.Matcher) ([]exemplar.QueryResult, error) {
+	spanLog, ctx := spanlogger.NewWithLogger(q.ctx, q.queryable.logger, "blocksStoreExemplarQuerier.Select")
+	defer spanLog.Finish()
+
+	tenantID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Exemplars older than the retention period have been dropped by the compactor, but they may still
+	// be stored in blocks which haven't been compacted yet.
+	if retention := q.queryable.limits.CompactorExemplarsRetentionPeriod(tenantID); retention > 0 {
+		start = max(start, time.Now().Add(-retention).UnixMilli())
+	}
+
+	spanLog.DebugLog(
+		"start", util.TimeFromMillis(start).UTC().String(),
+		"end", util.TimeFromMillis(end).UTC().String(),
+		"matchers", util.MultiMatchersStringer(matchers),
+	)
+
+	if start > end {
+		return nil, nil
+	}
+
+	if s := q.queryable.State(); s != services.Running {
+		return nil, errors.Errorf("BlocksStoreQueryable is not running: %v", s)
+	}
+	bq := q.queryable.newQuerier(start, end)
+
+	var sets []*block.Exemplars
+	queryF := func(clients map[BlocksStoreClient][]ulid.ULID, minT, maxT int64) ([]ulid.ULID, error) {
+		exemplars, queriedBlocks, err := bq.fetchExemplarsFromStore(ctx, clients, minT, maxT, tenantID, matchers)
+		if err != nil {
+			return nil, err
+		}
+
+		sets = append(sets, exemplars...)
+		return queriedBlocks, nil
+	}
+
+	if err := bq.queryWithConsistencyCheck(ctx, spanLog, start, end, 0, tenantID, nil, "", queryF); err != nil {
+		return nil, err
+	}
+
+	return exemplarsToQueryResults(block.MergeExemplars(sets...)), nil
+}
+
+// fetchExemplarsFromStore fetches the exemplars from the store-gateways. The exemplars response doesn't
+// carry the queried blocks, so the blocks requested to a store-gateway are considered queried if it
+// successfully responds.
+func (q *blocksStoreQuerier) fetchExemplarsFromStore(
+	ctx context.Context,
+	clients map[BlocksStoreClient][]ulid.ULID,
+	minT int64,
+	maxT int64,
+	tenantID string,
+	matchers [][]*labels.Matcher,
+) ([]*block.Exemplars, []ulid.ULID, error) {
+	var (
+		reqCtx        = grpc_metadata.AppendToOutgoingContext(ctx, storegateway.GrpcContextMetadataTenantID, tenantID)
+		g, gCtx       = errgroup.WithContext(reqCtx)
+		mtx           = sync.Mutex{}
+		sets          []*block.Exemplars
+		queriedBlocks = []ulid.ULID(nil)
+		spanLog       = spanlogger.FromContext(ctx, q.logger)
+	)
+
+	// Concurrently fetch exemplars from all clients.
+	for c, blockIDs := range clients {
+		g.Go(func() error {
+			var myExemplars []*block.Exemplars
+			for _, ms := range matchers {
+				req, err := createExemplarsRequest(minT, maxT, blockIDs, ms)
+				if err != nil {
+					return errors.Wrapf(err, "failed to create exemplars request")
+				}
+
+				resp, err := c.Exemplars(gCtx, req)
+				if err != nil {
+					if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+						return err
+					}
+
+					level.Warn(spanLog).Log("msg", "failed to fetch exemplars", "remote", c.RemoteAddress(), "err", err)
+					return nil
+				}
+
+				myExemplars = append(myExemplars, exemplarsFromTimeSeries(resp.Timeseries))
+			}
+
+			spanLog.DebugLog("msg", "received exemplars from store-gateway",
+				"instance", c,
+				"requested blocks", strings.Join(convertULIDsToString(blockIDs), " "))
+
+			// Store the result.
+			mtx.Lock()
+			sets = append(sets, myExemplars...)
+			queriedBlocks = append(queriedBlocks, blockIDs...)
+			mtx.Unlock()
+
+			return nil
+		})
+	}
+
+	// Wait until all client requests complete.
+	if err := g.Wait(); err != nil {
+		return nil, nil, err
+	}
+
+	return sets, queriedBlocks, nil
+}
+
+func createExemplarsRequest(minT, maxT int64, blockIDs []ulid.ULID, matchers []*labels.Matcher) (*storepb.ExemplarsRequest, error) {
+	req := &storepb.ExemplarsRequest{
+		MinTime:  minT,
+		MaxTime:  maxT,
+		Matchers: convertMatchersToLabelMatcher(matchers),
+	}
+
+	// Selectively query only specific blocks.
+	hints := &hintspb.SeriesRequestHints{
+		BlockMatchers: []storepb.LabelMatcher{
+			{
+				Type:  storepb.LabelMatcher_RE,
+				Name:  block.BlockIDLabel,
+				Value: strings.Join(convertULIDsToString(blockIDs), "|"),
+			},
+		},
+	}
+
+	anyHints, err := types.MarshalAny(hints)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal exemplars request hints")
+	}
+
+	req.Hints = anyHints
+
+	return req, nil
+}
+
+func exemplarsFromTimeSeries(timeseries []mimirpb.TimeSeries) *block.Exemplars {
+	out := &block.Exemplars{Series: make([]block.ExemplarSeries, 0, len(timeseries))}
+	for _, ts := range timeseries {
+		series := block.ExemplarSeries{
+			Labels:    mimirpb.FromLabelAdaptersToLabels(ts.Labels),
+			Exemplars: make([]block.Exemplar, 0, len(ts.Exemplars)),
+		}
+		for _, e := range ts.Exemplars {
+			series.Exemplars = append(series.Exemplars, block.Exemplar{
+				Labels:    mimirpb.FromLabelAdaptersToLabels(e.Labels),
+				Value:     e.Value,
+				Timestamp: e.TimestampMs,
+			})
+		}
+		out.Series = append(out.Series, series)
+	}
+	return out
+}
+
+func exemplarsFromQueryResults(results []exemplar.QueryResult) *block.Exemplars {
+	out := &block.Exemplars{Series: make([]block.ExemplarSeries, 0, len(results))}
+	for _, res := range results {
+		series := block.ExemplarSeries{
+			Labels:    res.SeriesLabels,
+			Exemplars: make([]block.Exemplar, 0, len(res.Exemplars)),
+		}
+		for _, e := range res.Exemplars {
+			series.Exemplars = append(series.Exemplars, block.Exemplar{Labels: e.Labels, Value: e.Value, Timestamp: e.Ts})
+		}
+		out.Series = append(out.Series, series)
+	}
+	return out
+}
+
+func exemplarsToQueryResults(exemplars *block.Exemplars) []exemplar.QueryResult {
+	results := make([]exemplar.QueryResult, 0, len(exemplars.Series))
+	for _, s := range exemplars.Series {
+		res := exemplar.QueryResult{
+			SeriesLabels: s.Labels,
+			Exemplars:    make([]exemplar.Exemplar, 0, len(s.Exemplars)),
+		}
+		for _, e := range s.Exemplars {
+			res.Exemplars = append(res.Exemplars, exemplar.Exemplar{Labels: e.Labels, Value: e.Value, Ts: e.Timestamp, HasTs: true})
+		}
+		results = append(results, res)
+	}
+	return results
+}
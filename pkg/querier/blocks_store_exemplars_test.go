@@ -0,0 +1,109 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/services"
+	"github.com/grafana/dskit/user"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/exemplar"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
+	"github.com/grafana/mimir/pkg/storegateway/storepb"
+)
+
+func TestBlocksStoreExemplarQuerier_Select(t *testing.T) {
+	now := time.Now()
+	block1 := ulid.MustNew(1, nil)
+	block2 := ulid.MustNew(2, nil)
+
+	series := []mimirpb.LabelAdapter{{Name: model.MetricNameLabel, Value: "series_1"}}
+	trace1 := []mimirpb.LabelAdapter{{Name: "trace_id", Value: "1"}}
+	trace2 := []mimirpb.LabelAdapter{{Name: "trace_id", Value: "2"}}
+
+	tests := map[string]struct {
+		retention         time.Duration
+		expectedMinT      int64
+		expectedExemplars []exemplar.Exemplar
+	}{
+		"should merge and deduplicate the exemplars from all store-gateways": {
+			expectedMinT: 0,
+			expectedExemplars: []exemplar.Exemplar{
+				{Labels: mimirpb.FromLabelAdaptersToLabels(trace1), Value: 1, Ts: 10, HasTs: true},
+				{Labels: mimirpb.FromLabelAdaptersToLabels(trace2), Value: 2, Ts: 20, HasTs: true},
+			},
+		},
+		"should clamp the query start to the exemplars retention period": {
+			retention:    time.Hour,
+			expectedMinT: now.Add(-time.Hour).UnixMilli(),
+			// The mocked store-gateways don't filter the exemplars by time range.
+			expectedExemplars: []exemplar.Exemplar{
+				{Labels: mimirpb.FromLabelAdaptersToLabels(trace1), Value: 1, Ts: 10, HasTs: true},
+				{Labels: mimirpb.FromLabelAdaptersToLabels(trace2), Value: 2, Ts: 20, HasTs: true},
+			},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			ctx := user.InjectOrgID(context.Background(), "user-1")
+
+			finder := &blocksFinderMock{Service: services.NewIdleService(nil, nil)}
+			finder.On("GetBlocks", mock.Anything, "user-1", mock.Anything, mock.Anything, mock.Anything).Return(bucketindex.Blocks{
+				{ID: block1},
+				{ID: block2},
+			}, error(nil))
+
+			gateway1 := &storeGatewayClientMock{remoteAddr: "1.1.1.1", mockedExemplarsResponse: &storepb.ExemplarsResponse{
+				Timeseries: []mimirpb.TimeSeries{{Labels: series, Exemplars: []mimirpb.Exemplar{
+					{Labels: trace1, Value: 1, TimestampMs: 10},
+					{Labels: trace2, Value: 2, TimestampMs: 20},
+				}}},
+			}}
+			gateway2 := &storeGatewayClientMock{remoteAddr: "2.2.2.2", mockedExemplarsResponse: &storepb.ExemplarsResponse{
+				Timeseries: []mimirpb.TimeSeries{{Labels: series, Exemplars: []mimirpb.Exemplar{
+					{Labels: trace2, Value: 2, TimestampMs: 20},
+				}}},
+			}}
+			stores := &blocksStoreSetMock{
+				Service: services.NewIdleService(nil, nil),
+				mockedResponses: []interface{}{
+					map[BlocksStoreClient][]ulid.ULID{
+						gateway1: {block1},
+						gateway2: {block2},
+					},
+				},
+			}
+
+			queryable, err := NewBlocksStoreQueryable(stores, finder, NewBlocksConsistency(0, nil), &blocksStoreLimitsMock{exemplarsRetentionPeriod: testData.retention}, 0, 0, log.NewNopLogger(), nil)
+			require.NoError(t, err)
+			require.NoError(t, services.StartAndAwaitRunning(context.Background(), queryable))
+			t.Cleanup(func() { require.NoError(t, services.StopAndAwaitTerminated(context.Background(), queryable)) })
+
+			querier, err := queryable.ExemplarQuerier(ctx)
+			require.NoError(t, err)
+
+			results, err := querier.Select(0, now.UnixMilli(), []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, model.MetricNameLabel, "series_1")})
+			require.NoError(t, err)
+			assert.Equal(t, []exemplar.QueryResult{{
+				SeriesLabels: mimirpb.FromLabelAdaptersToLabels(series),
+				Exemplars:    testData.expectedExemplars,
+			}}, results)
+
+			// Assert on the time range of the blocks lookup (5s delta).
+			require.Len(t, finder.Calls, 1)
+			assert.InDelta(t, testData.expectedMinT, finder.Calls[0].Arguments.Get(2).(int64), 5000)
+		})
+	}
+}
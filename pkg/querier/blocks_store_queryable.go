@@ -9,6 +9,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"sort"
 	"strings"
@@ -80,7 +81,9 @@ type BlocksFinder interface {
 
 	// GetBlocks returns known blocks for userID containing samples within the range minT
 	// and maxT (milliseconds, both included). Returned blocks are sorted by MaxTime descending.
-	GetBlocks(ctx context.Context, userID string, minT, maxT int64) (bucketindex.Blocks, error)
+	// When downsampled blocks are available, the coarsest resolution not greater than maxResolution
+	// (milliseconds) is preferred, falling back to other resolutions for the time ranges it doesn't cover.
+	GetBlocks(ctx context.Context, userID string, minT, maxT, maxResolution int64) (bucketindex.Blocks, error)
 }
 
 // BlocksStoreClient is the interface that should be implemented by any client used
@@ -100,6 +103,7 @@ type BlocksStoreLimits interface {
 	MaxLabelsQueryLength(userID string) time.Duration
 	MaxChunksPerQuery(userID string) int
 	StoreGatewayTenantShardSize(userID string) int
+	CompactorExemplarsRetentionPeriod(userID string) time.Duration
 }
 
 type blocksStoreQueryableMetrics struct {
@@ -109,6 +113,7 @@ type blocksStoreQueryableMetrics struct {
 	blocksFound                                       prometheus.Counter
 	blocksQueried                                     prometheus.Counter
 	blocksWithCompactorShardButIncompatibleQueryShard prometheus.Counter
+	blocksSkippedByMetricName                         prometheus.Counter
 	// The total number of chunks received from store-gateways that were used to evaluate queries
 	chunksTotal prometheus.Counter
 }
@@ -140,6 +145,10 @@ func newBlocksStoreQueryableMetrics(reg prometheus.Registerer) *blocksStoreQuery
 			Name: "cortex_querier_blocks_with_compactor_shard_but_incompatible_query_shard_total",
 			Help: "Blocks that couldn't be checked for query and compactor sharding optimization due to incompatible shard counts.",
 		}),
+		blocksSkippedByMetricName: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_querier_blocks_skipped_by_metric_name_total",
+			Help: "Blocks not queried because their stats in the bucket index show they don't contain the queried metric name.",
+		}),
 		chunksTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
 			Name: "cortex_querier_query_storegateway_chunks_total",
 			Help: "Number of chunks received from store gateways at query time.",
@@ -293,6 +302,10 @@ func (q *BlocksStoreQueryable) Querier(mint, maxt int64) (storage.Querier, error
 		return nil, errors.Errorf("BlocksStoreQueryable is not running: %v", s)
 	}
 
+	return q.newQuerier(mint, maxt), nil
+}
+
+func (q *BlocksStoreQueryable) newQuerier(mint, maxt int64) *blocksStoreQuerier {
 	return &blocksStoreQuerier{
 		minT:                     mint,
 		maxT:                     maxt,
@@ -304,7 +317,7 @@ func (q *BlocksStoreQueryable) Querier(mint, maxt int64) (storage.Querier, error
 		consistency:              q.consistency,
 		logger:                   q.logger,
 		queryStoreAfter:          q.queryStoreAfter,
-	}, nil
+	}
 }
 
 type blocksStoreQuerier struct {
@@ -371,7 +384,7 @@ func (q *blocksStoreQuerier) LabelNames(ctx context.Context, _ *storage.LabelHin
 		return queriedBlocks, nil
 	}
 
-	if err := q.queryWithConsistencyCheck(ctx, spanLog, minT, maxT, tenantID, nil, queryF); err != nil {
+	if err := q.queryWithConsistencyCheck(ctx, spanLog, minT, maxT, math.MaxInt64, tenantID, nil, "", queryF); err != nil {
 		return nil, nil, err
 	}
 
@@ -415,7 +428,7 @@ func (q *blocksStoreQuerier) LabelValues(ctx context.Context, name string, _ *st
 		return queriedBlocks, nil
 	}
 
-	if err := q.queryWithConsistencyCheck(ctx, spanLog, minT, maxT, tenantID, nil, queryF); err != nil {
+	if err := q.queryWithConsistencyCheck(ctx, spanLog, minT, maxT, math.MaxInt64, tenantID, nil, "", queryF); err != nil {
 		return nil, nil, err
 	}
 
@@ -460,7 +473,7 @@ func (q *blocksStoreQuerier) selectSorted(ctx context.Context, sp *storage.Selec
 		return queriedBlocks, nil
 	}
 
-	err = q.queryWithConsistencyCheck(ctx, spanLog, minT, maxT, tenantID, shard, queryF)
+	err = q.queryWithConsistencyCheck(ctx, spanLog, minT, maxT, maxResolutionFromHints(sp), tenantID, shard, metricNameFromMatchers(matchers), queryF)
 	if err != nil {
 		return storage.ErrSeriesSet(err)
 	}
@@ -495,7 +508,7 @@ func (q *blocksStoreQuerier) selectSorted(ctx context.Context, sp *storage.Selec
 type queryFunc func(clients map[BlocksStoreClient][]ulid.ULID, minT, maxT int64) ([]ulid.ULID, error)
 
 func (q *blocksStoreQuerier) queryWithConsistencyCheck(
-	ctx context.Context, spanLog *spanlogger.SpanLogger, minT, maxT int64, tenantID string, shard *sharding.ShardSelector, queryF queryFunc,
+	ctx context.Context, spanLog *spanlogger.SpanLogger, minT, maxT, maxResolution int64, tenantID string, shard *sharding.ShardSelector, metricName string, queryF queryFunc,
 ) (returnErr error) {
 	now := time.Now()
 
@@ -508,7 +521,7 @@ func (q *blocksStoreQuerier) queryWithConsistencyCheck(
 	maxT = clampMaxTime(spanLog, maxT, now.UnixMilli(), -q.queryStoreAfter, "query store after")
 
 	// Find the list of blocks we need to query given the time range.
-	knownBlocks, err := q.finder.GetBlocks(ctx, tenantID, minT, maxT)
+	knownBlocks, err := q.finder.GetBlocks(ctx, tenantID, minT, maxT, maxResolution)
 	if err != nil {
 		return err
 	}
@@ -532,6 +545,15 @@ func (q *blocksStoreQuerier) queryWithConsistencyCheck(
 		knownBlocks = result
 	}
 
+	if metricName != "" {
+		result := filterBlocksByMetricName(knownBlocks, metricName)
+
+		spanLog.DebugLog("msg", "filtered blocks by metric name", "metric_name", metricName, "before", len(knownBlocks), "after", len(result))
+		q.metrics.blocksSkippedByMetricName.Add(float64(len(knownBlocks) - len(result)))
+
+		knownBlocks = result
+	}
+
 	q.metrics.blocksQueried.Add(float64(len(knownBlocks)))
 
 	spanLog.DebugLog("msg", "found blocks to query", "expected", knownBlocks.String())
@@ -673,6 +695,28 @@ func filterBlocksByShard(blocks bucketindex.Blocks, queryShardIndex, queryShardC
 	return blocks, incompatibleBlocks
 }
 
+// filterBlocksByMetricName removes the blocks which, according to their stats, can't contain
+// any series with the input metric name. Blocks without stats are always kept.
+func filterBlocksByMetricName(blocks bucketindex.Blocks, metricName string) bucketindex.Blocks {
+	result := make(bucketindex.Blocks, 0, len(blocks))
+	for _, b := range blocks {
+		if b.MayContainMetric(metricName) {
+			result = append(result, b)
+		}
+	}
+	return result
+}
+
+// metricNameFromMatchers returns the metric name selected by an equality matcher, if any.
+func metricNameFromMatchers(matchers []*labels.Matcher) string {
+	for _, m := range matchers {
+		if m.Name == labels.MetricName && m.Type == labels.MatchEqual {
+			return m.Value
+		}
+	}
+	return ""
+}
+
 // canBlockWithCompactorShardIndexContainQueryShard returns false if block with
 // given compactor shard ID can *definitely NOT* contain series for given query shard.
 // Returns true otherwise (we don't know if block *does* contain such series,
@@ -759,7 +803,12 @@ func (q *blocksStoreQuerier) fetchSeriesFromStores(ctx context.Context, sp *stor
 			// But this is an acceptable workaround for now.
 			skipChunks := sp != nil && sp.Func == "series"
 
-			req, err := createSeriesRequest(minT, maxT, convertedMatchers, skipChunks, blockIDs, q.streamingChunksBatchSize)
+			var aggregates []storepb.Aggr
+			if sp != nil {
+				aggregates = aggrsFromFunc(sp.Func)
+			}
+
+			req, err := createSeriesRequest(minT, maxT, convertedMatchers, skipChunks, aggregates, blockIDs, q.streamingChunksBatchSize)
 			if err != nil {
 				return errors.Wrapf(err, "failed to create series request")
 			}
@@ -1153,7 +1202,7 @@ func (q *blocksStoreQuerier) fetchLabelValuesFromStore(
 	return valueSets, warnings, queriedBlocks, nil
 }
 
-func createSeriesRequest(minT, maxT int64, matchers []storepb.LabelMatcher, skipChunks bool, blockIDs []ulid.ULID, streamingBatchSize uint64) (*storepb.SeriesRequest, error) {
+func createSeriesRequest(minT, maxT int64, matchers []storepb.LabelMatcher, skipChunks bool, aggregates []storepb.Aggr, blockIDs []ulid.ULID, streamingBatchSize uint64) (*storepb.SeriesRequest, error) {
 	// Selectively query only specific blocks.
 	hints := &hintspb.SeriesRequestHints{
 		BlockMatchers: []storepb.LabelMatcher{
@@ -1180,10 +1229,43 @@ func createSeriesRequest(minT, maxT int64, matchers []storepb.LabelMatcher, skip
 		Matchers:                 matchers,
 		Hints:                    anyHints,
 		SkipChunks:               skipChunks,
+		Aggregates:               aggregates,
 		StreamingChunksBatchSize: streamingBatchSize,
 	}, nil
 }
 
+// maxResolutionFromHints returns the maximum resolution of the downsampled blocks which can be queried
+// to satisfy the select hints. At least 5 samples are required for each step, and 2 samples for each
+// range selector. The count_over_time function counts the samples, so it requires the raw samples.
+func maxResolutionFromHints(sp *storage.SelectHints) int64 {
+	if sp == nil || sp.Step <= 0 || sp.Func == "count_over_time" {
+		return 0
+	}
+
+	maxResolution := sp.Step / 5
+	if sp.Range > 0 {
+		maxResolution = min(maxResolution, sp.Range/2)
+	}
+	return maxResolution
+}
+
+// aggrsFromFunc returns the aggregates of the downsampled blocks which best approximate the raw
+// samples for the PromQL function.
+func aggrsFromFunc(f string) []storepb.Aggr {
+	switch {
+	case f == "min" || strings.HasPrefix(f, "min_"):
+		return []storepb.Aggr{storepb.MIN}
+	case f == "max" || strings.HasPrefix(f, "max_"):
+		return []storepb.Aggr{storepb.MAX}
+	case strings.HasPrefix(f, "sum_"):
+		return []storepb.Aggr{storepb.SUM}
+	case f == "increase" || f == "rate" || f == "irate" || f == "resets":
+		return []storepb.Aggr{storepb.COUNTER}
+	}
+	// In the default case, we retrieve count and sum to compute an average.
+	return []storepb.Aggr{storepb.COUNT, storepb.SUM}
+}
+
 func createLabelNamesRequest(minT, maxT int64, blockIDs []ulid.ULID, matchers []storepb.LabelMatcher) (*storepb.LabelNamesRequest, error) {
 	req := &storepb.LabelNamesRequest{
 		Start:    minT,
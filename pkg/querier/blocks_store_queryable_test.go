@@ -1550,7 +1550,7 @@ func TestBlocksStoreQuerier_Select(t *testing.T) {
 
 					stores := &blocksStoreSetMock{mockedResponses: storeSetResponses}
 					finder := &blocksFinderMock{}
-					finder.On("GetBlocks", mock.Anything, "user-1", minT, maxT).Return(testData.finderResult, testData.finderErr)
+					finder.On("GetBlocks", mock.Anything, "user-1", minT, maxT, mock.Anything).Return(testData.finderResult, testData.finderErr)
 
 					ctx, cancel := context.WithCancel(context.Background())
 					t.Cleanup(cancel)
@@ -1677,7 +1677,7 @@ func TestBlocksStoreQuerier_ShouldReturnContextCanceledIfContextWasCanceledWhile
 
 		// Mock the blocks finder.
 		finder := &blocksFinderMock{}
-		finder.On("GetBlocks", mock.Anything, tenantID, minT, maxT).Return(bucketindex.Blocks{{ID: block1}}, nil)
+		finder.On("GetBlocks", mock.Anything, tenantID, minT, maxT, mock.Anything).Return(bucketindex.Blocks{{ID: block1}}, nil)
 
 		// Create a real gRPC client connecting to the gRPC server we control in this test.
 		clientCfg := grpcclient.Config{}
@@ -1923,7 +1923,7 @@ func TestBlocksStoreQuerier_Select_cancelledContext(t *testing.T) {
 			}}
 
 			finder := &blocksFinderMock{}
-			finder.On("GetBlocks", mock.Anything, "user-1", minT, maxT).Return(bucketindex.Blocks{
+			finder.On("GetBlocks", mock.Anything, "user-1", minT, maxT, mock.Anything).Return(bucketindex.Blocks{
 				{ID: block},
 			}, nil)
 
@@ -2428,7 +2428,7 @@ func TestBlocksStoreQuerier_Labels(t *testing.T) {
 				reg := prometheus.NewPedanticRegistry()
 				stores := &blocksStoreSetMock{mockedResponses: testData.storeSetResponses}
 				finder := &blocksFinderMock{}
-				finder.On("GetBlocks", mock.Anything, "user-1", minT, maxT).Return(testData.finderResult, testData.finderErr)
+				finder.On("GetBlocks", mock.Anything, "user-1", minT, maxT, mock.Anything).Return(testData.finderResult, testData.finderErr)
 
 				q := &blocksStoreQuerier{
 					minT:        minT,
@@ -2497,7 +2497,7 @@ func TestBlocksStoreQuerier_Labels(t *testing.T) {
 				}}
 
 				finder := &blocksFinderMock{}
-				finder.On("GetBlocks", mock.Anything, "user-1", minT, maxT).Return(bucketindex.Blocks{
+				finder.On("GetBlocks", mock.Anything, "user-1", minT, maxT, mock.Anything).Return(bucketindex.Blocks{
 					{ID: block1},
 				}, nil)
 
@@ -2571,7 +2571,7 @@ func TestBlocksStoreQuerier_SelectSortedShouldHonorQueryStoreAfter(t *testing.T)
 	for testName, testData := range tests {
 		t.Run(testName, func(t *testing.T) {
 			finder := &blocksFinderMock{}
-			finder.On("GetBlocks", mock.Anything, "user-1", mock.Anything, mock.Anything).Return(bucketindex.Blocks(nil), error(nil))
+			finder.On("GetBlocks", mock.Anything, "user-1", mock.Anything, mock.Anything, mock.Anything).Return(bucketindex.Blocks(nil), error(nil))
 
 			const tenantID = "user-1"
 			ctx = user.InjectOrgID(ctx, tenantID)
@@ -2675,7 +2675,7 @@ func TestBlocksStoreQuerier_MaxLabelsQueryRange(t *testing.T) {
 	for testName, testData := range tests {
 		t.Run(testName, func(t *testing.T) {
 			finder := &blocksFinderMock{}
-			finder.On("GetBlocks", mock.Anything, "user-1", mock.Anything, mock.Anything).Return(bucketindex.Blocks(nil), error(nil))
+			finder.On("GetBlocks", mock.Anything, "user-1", mock.Anything, mock.Anything, mock.Anything).Return(bucketindex.Blocks(nil), error(nil))
 
 			ctx := user.InjectOrgID(context.Background(), "user-1")
 			q := &blocksStoreQuerier{
@@ -2799,7 +2799,7 @@ func TestBlocksStoreQuerier_PromQLExecution(t *testing.T) {
 					finder := &blocksFinderMock{
 						Service: services.NewIdleService(nil, nil),
 					}
-					finder.On("GetBlocks", mock.Anything, "user-1", mock.Anything, mock.Anything).Return(bucketindex.Blocks{
+					finder.On("GetBlocks", mock.Anything, "user-1", mock.Anything, mock.Anything, mock.Anything).Return(bucketindex.Blocks{
 						{ID: block1},
 						{ID: block2},
 					}, error(nil))
@@ -2952,6 +2952,90 @@ func TestFilterBlocksByShard(t *testing.T) {
 	}
 }
 
+func TestFilterBlocksByMetricName(t *testing.T) {
+	metricNames := bucketindex.NewBloomFilter(2)
+	metricNames.Add("metric_1")
+	metricNames.Add("metric_2")
+
+	block1 := &bucketindex.Block{ID: ulid.MustNew(ulid.Now(), crand.Reader), MinTime: 0, MaxTime: 100}
+	block2 := &bucketindex.Block{ID: ulid.MustNew(ulid.Now(), crand.Reader), MinTime: 0, MaxTime: 100, Stats: &bucketindex.BlockStats{NumSeries: 10}}
+	block3 := &bucketindex.Block{ID: ulid.MustNew(ulid.Now(), crand.Reader), MinTime: 0, MaxTime: 100, Stats: &bucketindex.BlockStats{NumSeries: 10, MetricNames: metricNames}}
+	allBlocks := bucketindex.Blocks{block1, block2, block3}
+
+	// Blocks without stats can't be filtered out.
+	assert.Equal(t, allBlocks, filterBlocksByMetricName(allBlocks, "metric_1"))
+	assert.Equal(t, bucketindex.Blocks{block1, block2}, filterBlocksByMetricName(allBlocks, "metric_3"))
+}
+
+func TestMetricNameFromMatchers(t *testing.T) {
+	assert.Equal(t, "metric_1", metricNameFromMatchers([]*labels.Matcher{
+		labels.MustNewMatcher(labels.MatchEqual, "job", "test"),
+		labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "metric_1"),
+	}))
+	assert.Equal(t, "", metricNameFromMatchers([]*labels.Matcher{
+		labels.MustNewMatcher(labels.MatchRegexp, labels.MetricName, "metric_1"),
+	}))
+	assert.Equal(t, "", metricNameFromMatchers(nil))
+}
+
+func TestMaxResolutionFromHints(t *testing.T) {
+	tests := map[string]struct {
+		hints    *storage.SelectHints
+		expected int64
+	}{
+		"no hints": {
+			hints:    nil,
+			expected: 0,
+		},
+		"instant query": {
+			hints:    &storage.SelectHints{Start: 0, End: 1000},
+			expected: 0,
+		},
+		"range query": {
+			hints:    &storage.SelectHints{Step: time.Hour.Milliseconds()},
+			expected: (12 * time.Minute).Milliseconds(),
+		},
+		"range query with count_over_time": {
+			hints:    &storage.SelectHints{Step: time.Hour.Milliseconds(), Range: (10 * time.Minute).Milliseconds(), Func: "count_over_time"},
+			expected: 0,
+		},
+		"range query with a short range selector": {
+			hints:    &storage.SelectHints{Step: time.Hour.Milliseconds(), Range: (10 * time.Minute).Milliseconds()},
+			expected: (5 * time.Minute).Milliseconds(),
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			assert.Equal(t, testData.expected, maxResolutionFromHints(testData.hints))
+		})
+	}
+}
+
+func TestAggrsFromFunc(t *testing.T) {
+	tests := map[string][]storepb.Aggr{
+		"":                   {storepb.COUNT, storepb.SUM},
+		"avg_over_time":      {storepb.COUNT, storepb.SUM},
+		"sum":                {storepb.COUNT, storepb.SUM},
+		"sum_over_time":      {storepb.SUM},
+		"min":                {storepb.MIN},
+		"min_over_time":      {storepb.MIN},
+		"max_over_time":      {storepb.MAX},
+		"count":              {storepb.COUNT, storepb.SUM},
+		"count_values":       {storepb.COUNT, storepb.SUM},
+		"rate":               {storepb.COUNTER},
+		"increase":           {storepb.COUNTER},
+		"histogram_count":    {storepb.COUNT, storepb.SUM},
+		"quantile_over_time": {storepb.COUNT, storepb.SUM},
+	}
+
+	for fn, expected := range tests {
+		t.Run(fn, func(t *testing.T) {
+			assert.Equal(t, expected, aggrsFromFunc(fn))
+		})
+	}
+}
+
 type blocksStoreSetMock struct {
 	services.Service
 
@@ -2982,8 +3066,8 @@ type blocksFinderMock struct {
 	mock.Mock
 }
 
-func (m *blocksFinderMock) GetBlocks(ctx context.Context, userID string, minT, maxT int64) (bucketindex.Blocks, error) {
-	args := m.Called(ctx, userID, minT, maxT)
+func (m *blocksFinderMock) GetBlocks(ctx context.Context, userID string, minT, maxT, maxResolution int64) (bucketindex.Blocks, error) {
+	args := m.Called(ctx, userID, minT, maxT, maxResolution)
 	return args.Get(0).(bucketindex.Blocks), args.Error(1)
 }
 
@@ -2995,6 +3079,8 @@ type storeGatewayClientMock struct {
 	mockedLabelNamesErr       error
 	mockedLabelValuesResponse *storepb.LabelValuesResponse
 	mockedLabelValuesErr      error
+	mockedExemplarsResponse   *storepb.ExemplarsResponse
+	mockedExemplarsErr        error
 }
 
 func (m *storeGatewayClientMock) Series(ctx context.Context, _ *storepb.SeriesRequest, _ ...grpc.CallOption) (storegatewaypb.StoreGateway_SeriesClient, error) {
@@ -3014,6 +3100,10 @@ func (m *storeGatewayClientMock) LabelValues(context.Context, *storepb.LabelValu
 	return m.mockedLabelValuesResponse, m.mockedLabelValuesErr
 }
 
+func (m *storeGatewayClientMock) Exemplars(context.Context, *storepb.ExemplarsRequest, ...grpc.CallOption) (*storepb.ExemplarsResponse, error) {
+	return m.mockedExemplarsResponse, m.mockedExemplarsErr
+}
+
 func (m *storeGatewayClientMock) RemoteAddress() string {
 	return m.remoteAddr
 }
@@ -3090,6 +3180,11 @@ func (m *cancelerStoreGatewayClientMock) LabelValues(ctx context.Context, _ *sto
 	return nil, ctx.Err()
 }
 
+func (m *cancelerStoreGatewayClientMock) Exemplars(ctx context.Context, _ *storepb.ExemplarsRequest, _ ...grpc.CallOption) (*storepb.ExemplarsResponse, error) {
+	m.cancel()
+	return nil, ctx.Err()
+}
+
 func (m *cancelerStoreGatewayClientMock) RemoteAddress() string {
 	return m.remoteAddr
 }
@@ -3098,6 +3193,7 @@ type blocksStoreLimitsMock struct {
 	maxLabelsQueryLength        time.Duration
 	maxChunksPerQuery           int
 	storeGatewayTenantShardSize int
+	exemplarsRetentionPeriod    time.Duration
 }
 
 func (m *blocksStoreLimitsMock) MaxLabelsQueryLength(_ string) time.Duration {
@@ -3112,6 +3208,10 @@ func (m *blocksStoreLimitsMock) StoreGatewayTenantShardSize(_ string) int {
 	return m.storeGatewayTenantShardSize
 }
 
+func (m *blocksStoreLimitsMock) CompactorExemplarsRetentionPeriod(_ string) time.Duration {
+	return m.exemplarsRetentionPeriod
+}
+
 func (m *blocksStoreLimitsMock) S3SSEType(_ string) string {
 	return ""
 }
@@ -7,18 +7,24 @@ import (
 	"net/http"
 	"sort"
 	"strconv"
+	"time"
 
+	"github.com/go-kit/log"
 	"github.com/grafana/dskit/httpgrpc"
 	"github.com/grafana/dskit/tenant"
 	jsoniter "github.com/json-iterator/go"
 	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
+	"github.com/thanos-io/objstore"
 
 	"github.com/grafana/mimir/pkg/cardinality"
 	"github.com/grafana/mimir/pkg/distributor"
 	ingester_client "github.com/grafana/mimir/pkg/ingester/client"
 	"github.com/grafana/mimir/pkg/querier/api"
 	"github.com/grafana/mimir/pkg/querier/worker"
+	"github.com/grafana/mimir/pkg/storage/tsdb/metricsusage"
 	"github.com/grafana/mimir/pkg/util"
+	util_log "github.com/grafana/mimir/pkg/util/log"
 	util_math "github.com/grafana/mimir/pkg/util/math"
 	"github.com/grafana/mimir/pkg/util/validation"
 )
@@ -181,6 +187,45 @@ func ActiveNativeHistogramMetricsHandler(d Distributor, limits *validation.Overr
 	})
 }
 
+// UnusedMetricsCardinalityHandler creates handler for unused metrics cardinality endpoint. It lists the metrics
+// currently held by the ingesters along with their series count and the last time they have been queried, which
+// is tracked by the ingesters and store-gateways and stored in the bucket.
+func UnusedMetricsCardinalityHandler(d Distributor, bkt objstore.Bucket, limits *validation.Overrides, logger log.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		// Guarantee request's context is for a single tenant id
+		tenantID, err := tenant.TenantID(ctx)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if !limits.CardinalityAnalysisEnabled(tenantID) {
+			http.Error(w, fmt.Sprintf("cardinality analysis is disabled for the tenant: %v", tenantID), http.StatusBadRequest)
+			return
+		}
+
+		cardinalityRequest, err := cardinality.DecodeUnusedMetricsRequest(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		seriesCountTotal, cardinalityResponse, err := d.LabelValuesCardinality(ctx, []model.LabelName{model.MetricNameLabel}, cardinalityRequest.Matchers, cardinalityRequest.CountMethod)
+		if err != nil {
+			respondFromError(err, w)
+			return
+		}
+
+		usage, err := metricsusage.ReadUsage(ctx, bkt, tenantID, limits, util_log.WithContext(ctx, logger))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		util.WriteJSONResponse(w, toUnusedMetricsResponse(seriesCountTotal, cardinalityResponse, usage, time.Now().Add(-cardinalityRequest.UnusedFor), cardinalityRequest.Limit))
+	})
+}
+
 func respondFromError(err error, w http.ResponseWriter) {
 	httpResp, ok := httpgrpc.HTTPResponseFromError(err)
 	if !ok {
@@ -280,3 +325,49 @@ func limitLabelValuesCardinality(labelValuesCardinality []api.LabelValuesCardina
 	}
 	return labelValuesCardinality[:limit]
 }
+
+// toUnusedMetricsResponse converts the ingesters response and the metrics usage to api.UnusedMetricsResponse,
+// only keeping the metrics which haven't been queried since usedSince.
+func toUnusedMetricsResponse(seriesCountTotal uint64, cardinalityResponse *ingester_client.LabelValuesCardinalityResponse, usage *metricsusage.Usage, usedSince time.Time, limit int) *api.UnusedMetricsResponse {
+	res := &api.UnusedMetricsResponse{
+		SeriesCountTotal: seriesCountTotal,
+		Metrics:          []api.UnusedMetric{},
+	}
+	if usage.Since > 0 {
+		since := time.UnixMilli(usage.Since).UTC()
+		res.TrackedSince = &since
+	}
+
+	usedSinceMs := usedSince.UnixMilli()
+	for _, item := range cardinalityResponse.Items {
+		if item.LabelName != model.MetricNameLabel {
+			continue
+		}
+
+		for metricName, seriesCount := range item.LabelValueSeries {
+			metric := api.UnusedMetric{MetricName: metricName, SeriesCount: seriesCount}
+			if lastQueried, ok := usage.Metrics[metricName]; ok {
+				if lastQueried >= usedSinceMs {
+					continue
+				}
+				t := time.UnixMilli(lastQueried).UTC()
+				metric.LastQueried = &t
+			}
+
+			res.UnusedSeriesCountTotal += seriesCount
+			res.Metrics = append(res.Metrics, metric)
+		}
+	}
+
+	// Sort in DESC order by SeriesCount and ASC order by MetricName.
+	sort.Slice(res.Metrics, func(l, r int) bool {
+		left := res.Metrics[l]
+		right := res.Metrics[r]
+		return left.SeriesCount > right.SeriesCount || (left.SeriesCount == right.SeriesCount && left.MetricName < right.MetricName)
+	})
+	if len(res.Metrics) > limit {
+		res.Metrics = res.Metrics[:limit]
+	}
+
+	return res
+}
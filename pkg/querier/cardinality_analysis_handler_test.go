@@ -12,20 +12,26 @@ import (
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/go-kit/log"
 	"github.com/grafana/dskit/flagext"
 	"github.com/grafana/dskit/httpgrpc"
+	"github.com/grafana/dskit/services"
 	"github.com/grafana/dskit/user"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
 
 	"github.com/grafana/mimir/pkg/cardinality"
 	pkg_distributor "github.com/grafana/mimir/pkg/distributor"
 	"github.com/grafana/mimir/pkg/ingester/client"
+	"github.com/grafana/mimir/pkg/mimirpb"
 	"github.com/grafana/mimir/pkg/querier/api"
+	"github.com/grafana/mimir/pkg/storage/tsdb/metricsusage"
 	"github.com/grafana/mimir/pkg/util/validation"
 )
 
@@ -1082,3 +1088,123 @@ func mockDistributorLabelValuesCardinality(labelNames []model.LabelName, matcher
 	distributor.On("LabelValuesCardinality", mock.Anything, labelNames, matchers, countMethod).Return(seriesCount, cardinalityResponse, err)
 	return distributor
 }
+
+func TestUnusedMetricsCardinalityHandler(t *testing.T) {
+	now := time.Now()
+
+	tests := map[string]struct {
+		requestParams    string
+		usage            []metricUsage
+		expectedResponse api.UnusedMetricsResponse
+	}{
+		"should return all metrics as never queried if the usage hasn't been tracked yet": {
+			expectedResponse: api.UnusedMetricsResponse{
+				SeriesCountTotal:       100,
+				UnusedSeriesCountTotal: 100,
+				Metrics: []api.UnusedMetric{
+					{MetricName: "metric_1", SeriesCount: 50},
+					{MetricName: "metric_2", SeriesCount: 30},
+					{MetricName: "metric_3", SeriesCount: 20},
+				},
+			},
+		},
+		"should return the last queried time of each metric": {
+			usage: []metricUsage{
+				{metricName: "metric_3", lastQueried: now.Add(-48 * time.Hour)},
+				{metricName: "metric_1", lastQueried: now.Add(-time.Hour)},
+			},
+			expectedResponse: api.UnusedMetricsResponse{
+				TrackedSince:           timePtr(now.Add(-48 * time.Hour)),
+				SeriesCountTotal:       100,
+				UnusedSeriesCountTotal: 100,
+				Metrics: []api.UnusedMetric{
+					{MetricName: "metric_1", SeriesCount: 50, LastQueried: timePtr(now.Add(-time.Hour))},
+					{MetricName: "metric_2", SeriesCount: 30},
+					{MetricName: "metric_3", SeriesCount: 20, LastQueried: timePtr(now.Add(-48 * time.Hour))},
+				},
+			},
+		},
+		"should only return the metrics not queried within unused_for": {
+			requestParams: "?unused_for=1d",
+			usage: []metricUsage{
+				{metricName: "metric_3", lastQueried: now.Add(-48 * time.Hour)},
+				{metricName: "metric_1", lastQueried: now.Add(-time.Hour)},
+			},
+			expectedResponse: api.UnusedMetricsResponse{
+				TrackedSince:           timePtr(now.Add(-48 * time.Hour)),
+				SeriesCountTotal:       100,
+				UnusedSeriesCountTotal: 50,
+				Metrics: []api.UnusedMetric{
+					{MetricName: "metric_2", SeriesCount: 30},
+					{MetricName: "metric_3", SeriesCount: 20, LastQueried: timePtr(now.Add(-48 * time.Hour))},
+				},
+			},
+		},
+		"should apply the limit": {
+			requestParams: "?limit=1",
+			expectedResponse: api.UnusedMetricsResponse{
+				SeriesCountTotal:       100,
+				UnusedSeriesCountTotal: 100,
+				Metrics: []api.UnusedMetric{
+					{MetricName: "metric_1", SeriesCount: 50},
+				},
+			},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			ctx := user.InjectOrgID(context.Background(), "test")
+			bkt := objstore.NewInMemBucket()
+
+			if len(testData.usage) > 0 {
+				tracker := metricsusage.NewTracker(bkt, nil, "ingester", "ingester-1", time.Hour, 0, log.NewNopLogger())
+				require.NoError(t, services.StartAndAwaitRunning(ctx, tracker))
+				for _, u := range testData.usage {
+					r := tracker.NewRecorder()
+					r.Add([]mimirpb.LabelAdapter{{Name: labels.MetricName, Value: u.metricName}})
+					tracker.Record("test", r, u.lastQueried)
+				}
+				require.NoError(t, services.StopAndAwaitTerminated(ctx, tracker))
+			}
+
+			distributor := mockDistributorLabelValuesCardinality(
+				[]model.LabelName{labels.MetricName},
+				[]*labels.Matcher(nil),
+				cardinality.InMemoryMethod,
+				100,
+				&client.LabelValuesCardinalityResponse{
+					Items: []*client.LabelValueSeriesCount{{
+						LabelName:        labels.MetricName,
+						LabelValueSeries: map[string]uint64{"metric_1": 50, "metric_2": 30, "metric_3": 20},
+					}},
+				},
+				nil)
+
+			overrides, err := validation.NewOverrides(validation.Limits{CardinalityAnalysisEnabled: true}, nil)
+			require.NoError(t, err)
+			handler := UnusedMetricsCardinalityHandler(distributor, bkt, overrides, log.NewNopLogger())
+
+			request, err := http.NewRequestWithContext(ctx, "GET", "/unused_metrics"+testData.requestParams, http.NoBody)
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+
+			require.Equal(t, http.StatusOK, recorder.Result().StatusCode)
+
+			responseBody := api.UnusedMetricsResponse{}
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &responseBody))
+			require.Equal(t, testData.expectedResponse, responseBody)
+		})
+	}
+}
+
+type metricUsage struct {
+	metricName  string
+	lastQueried time.Time
+}
+
+func timePtr(t time.Time) *time.Time {
+	t = time.UnixMilli(t.UnixMilli()).UTC()
+	return &t
+}
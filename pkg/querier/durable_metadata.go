@@ -0,0 +1,141 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/tenant"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/scrape"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/ingester/client"
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	"github.com/grafana/mimir/pkg/storage/tsdb/metadataindex"
+	"github.com/grafana/mimir/pkg/util/spanlogger"
+)
+
+// NewDurableMetadataSupplier returns a MetadataSupplier that merges the metric metadata returned by next,
+// which is held in the ingesters memory, with the metric metadata stored in the tenant's metadata index,
+// which includes the metadata of metrics no longer ingested and the past metadata of metrics whose
+// metadata has changed. The metadata index of each tenant is cached for the ttl, like the bucket index.
+func NewDurableMetadataSupplier(next MetadataSupplier, bkt objstore.Bucket, cfgProvider bucket.TenantConfigProvider, ttl time.Duration, logger log.Logger) MetadataSupplier {
+	return &durableMetadataSupplier{
+		next:        next,
+		bkt:         bkt,
+		cfgProvider: cfgProvider,
+		ttl:         ttl,
+		logger:      logger,
+		indexes:     map[string]cachedMetadataIndex{},
+	}
+}
+
+type durableMetadataSupplier struct {
+	next        MetadataSupplier
+	bkt         objstore.Bucket
+	cfgProvider bucket.TenantConfigProvider
+	ttl         time.Duration
+	logger      log.Logger
+
+	indexesMtx sync.Mutex
+	indexes    map[string]cachedMetadataIndex
+}
+
+type cachedMetadataIndex struct {
+	idx      *metadataindex.Index // Nil if the tenant has no metadata index.
+	loadedAt time.Time
+}
+
+func (s *durableMetadataSupplier) MetricsMetadata(ctx context.Context, req *client.MetricsMetadataRequest) ([]scrape.MetricMetadata, error) {
+	spanLog, ctx := spanlogger.NewWithLogger(ctx, s.logger, "durableMetadataSupplier.MetricsMetadata")
+	defer spanLog.Finish()
+
+	tenantID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := s.next.MetricsMetadata(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	idx, err := s.readIndex(ctx, tenantID, spanLog)
+	if err != nil {
+		// The metric metadata is best effort, so we return the metadata held by the ingesters.
+		level.Warn(spanLog).Log("msg", "failed to read metadata index", "err", err)
+		return out, nil
+	}
+	if idx == nil {
+		return out, nil
+	}
+
+	// The metadata held by the ingesters comes first, so that it takes precedence when the limits are applied.
+	// The metadata from the index follows, newest first for each metric. We do not apply the requested limits
+	// here as we will do this later right before returning the API response.
+	unique := make(map[scrape.MetricMetadata]struct{}, len(out))
+	for _, m := range out {
+		unique[m] = struct{}{}
+	}
+
+	metrics := make([]string, 0, len(idx.Metrics))
+	for metric := range idx.Metrics {
+		if req.Metric == "" || req.Metric == metric {
+			metrics = append(metrics, metric)
+		}
+	}
+	slices.Sort(metrics)
+
+	for _, metric := range metrics {
+		for _, e := range idx.Metrics[metric] {
+			m := scrape.MetricMetadata{Metric: metric, Type: model.MetricType(e.Type), Help: e.Help, Unit: e.Unit}
+			if _, exists := unique[m]; !exists {
+				out = append(out, m)
+				unique[m] = struct{}{}
+			}
+		}
+	}
+
+	return out, nil
+}
+
+// readIndex returns the metadata index of the tenant, or nil if it doesn't exist. The index is read from the bucket
+// if it isn't cached or it has been cached for longer than the ttl. Failures are not cached.
+func (s *durableMetadataSupplier) readIndex(ctx context.Context, tenantID string, logger log.Logger) (*metadataindex.Index, error) {
+	now := time.Now()
+
+	s.indexesMtx.Lock()
+	cached, ok := s.indexes[tenantID]
+	s.indexesMtx.Unlock()
+	if ok && now.Sub(cached.loadedAt) < s.ttl {
+		return cached.idx, nil
+	}
+
+	idx, err := metadataindex.ReadIndex(ctx, s.bkt, tenantID, s.cfgProvider, logger)
+	if errors.Is(err, metadataindex.ErrIndexNotFound) {
+		idx, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.indexesMtx.Lock()
+	defer s.indexesMtx.Unlock()
+
+	// Remove the expired indexes, so that the ones of the tenants not queried anymore don't stay in memory.
+	for id, c := range s.indexes {
+		if now.Sub(c.loadedAt) >= s.ttl {
+			delete(s.indexes, id)
+		}
+	}
+	s.indexes[tenantID] = cachedMetadataIndex{idx: idx, loadedAt: now}
+
+	return idx, nil
+}
@@ -0,0 +1,117 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/user"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/scrape"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/ingester/client"
+	"github.com/grafana/mimir/pkg/storage/tsdb/metadataindex"
+)
+
+func TestDurableMetadataSupplier_MetricsMetadata(t *testing.T) {
+	ingestersMetadata := []scrape.MetricMetadata{
+		{Metric: "metric_1", Type: model.MetricTypeCounter, Help: "First metric."},
+	}
+
+	tests := map[string]struct {
+		index    *metadataindex.Index
+		req      *client.MetricsMetadataRequest
+		expected []scrape.MetricMetadata
+	}{
+		"should return the ingesters metadata if the metadata index doesn't exist": {
+			req:      &client.MetricsMetadataRequest{Limit: -1, LimitPerMetric: -1},
+			expected: ingestersMetadata,
+		},
+		"should merge the ingesters metadata with the metadata index, including historical metadata": {
+			index: &metadataindex.Index{Version: metadataindex.IndexVersion1, Metrics: map[string][]metadataindex.Entry{
+				"metric_1": {
+					{Type: "counter", Help: "First metric.", MinTime: 20, MaxTime: 30},
+					{Type: "gauge", Help: "First metric.", MinTime: 10, MaxTime: 20},
+				},
+				"metric_2": {
+					{Type: "histogram", Help: "Second metric.", Unit: "seconds", MinTime: 10, MaxTime: 20},
+				},
+			}},
+			req: &client.MetricsMetadataRequest{Limit: -1, LimitPerMetric: -1},
+			expected: []scrape.MetricMetadata{
+				{Metric: "metric_1", Type: model.MetricTypeCounter, Help: "First metric."},
+				{Metric: "metric_1", Type: model.MetricTypeGauge, Help: "First metric."},
+				{Metric: "metric_2", Type: model.MetricTypeHistogram, Help: "Second metric.", Unit: "seconds"},
+			},
+		},
+		"should only return the metadata index entries of the requested metric": {
+			index: &metadataindex.Index{Version: metadataindex.IndexVersion1, Metrics: map[string][]metadataindex.Entry{
+				"metric_2": {
+					{Type: "histogram", Help: "Second metric.", Unit: "seconds", MinTime: 10, MaxTime: 20},
+				},
+			}},
+			req:      &client.MetricsMetadataRequest{Limit: -1, LimitPerMetric: -1, Metric: "metric_1"},
+			expected: ingestersMetadata,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			ctx := user.InjectOrgID(context.Background(), "user-1")
+			bkt := objstore.NewInMemBucket()
+			if testData.index != nil {
+				require.NoError(t, metadataindex.WriteIndex(ctx, bkt, "user-1", nil, testData.index))
+			}
+
+			d := &mockDistributor{}
+			d.On("MetricsMetadata", mock.Anything, testData.req).Return(ingestersMetadata, nil)
+
+			supplier := NewDurableMetadataSupplier(d, bkt, nil, time.Minute, log.NewNopLogger())
+			actual, err := supplier.MetricsMetadata(ctx, testData.req)
+			require.NoError(t, err)
+			assert.Equal(t, testData.expected, actual)
+		})
+	}
+}
+
+func TestDurableMetadataSupplier_MetricsMetadata_CachesTheIndex(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "user-1")
+	req := &client.MetricsMetadataRequest{Limit: -1, LimitPerMetric: -1}
+	bkt := objstore.NewInMemBucket()
+
+	d := &mockDistributor{}
+	d.On("MetricsMetadata", mock.Anything, req).Return([]scrape.MetricMetadata(nil), nil)
+
+	writeIndex := func(metric string) {
+		require.NoError(t, metadataindex.WriteIndex(ctx, bkt, "user-1", nil, &metadataindex.Index{Version: metadataindex.IndexVersion1, Metrics: map[string][]metadataindex.Entry{
+			metric: {{Type: "counter", Help: "A metric.", MinTime: 10, MaxTime: 20}},
+		}}))
+	}
+
+	cached := NewDurableMetadataSupplier(d, bkt, nil, time.Hour, log.NewNopLogger())
+	uncached := NewDurableMetadataSupplier(d, bkt, nil, 0, log.NewNopLogger())
+
+	// The tenant has no metadata index yet.
+	for _, supplier := range []MetadataSupplier{cached, uncached} {
+		actual, err := supplier.MetricsMetadata(ctx, req)
+		require.NoError(t, err)
+		assert.Empty(t, actual)
+	}
+
+	writeIndex("metric_1")
+
+	actual, err := cached.MetricsMetadata(ctx, req)
+	require.NoError(t, err)
+	assert.Empty(t, actual)
+
+	actual, err = uncached.MetricsMetadata(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, []scrape.MetricMetadata{{Metric: "metric_1", Type: model.MetricTypeCounter, Help: "A metric."}}, actual)
+}
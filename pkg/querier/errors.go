@@ -13,8 +13,9 @@ import (
 )
 
 var (
-	errBadLookbackConfigs = fmt.Errorf("the -%s setting must be greater than -%s otherwise queries might return partial results", validation.QueryIngestersWithinFlag, queryStoreAfterFlag)
-	errEmptyTimeRange     = errors.New("empty time range")
+	errBadLookbackConfigs             = fmt.Errorf("the -%s setting must be greater than -%s otherwise queries might return partial results", validation.QueryIngestersWithinFlag, queryStoreAfterFlag)
+	errBadIngesterTSDBRetentionConfig = fmt.Errorf("the -%s setting must be greater than -%s otherwise queries might return partial results", validation.IngesterTSDBRetentionPeriodFlag, queryStoreAfterFlag)
+	errEmptyTimeRange                 = errors.New("empty time range")
 )
 
 func NewMaxQueryLengthError(actualQueryLen, maxQueryLength time.Duration) validation.LimitError {
@@ -18,6 +18,10 @@ func TestQuerier_Errors(t *testing.T) {
 			err:              errBadLookbackConfigs,
 			expectedErrorMsg: "the -querier.query-ingesters-within setting must be greater than -querier.query-store-after otherwise queries might return partial results",
 		},
+		"errBadIngesterTSDBRetentionConfig has a correct message": {
+			err:              errBadIngesterTSDBRetentionConfig,
+			expectedErrorMsg: "the -ingester.tsdb-retention-period setting must be greater than -querier.query-store-after otherwise queries might return partial results",
+		},
 		"errEmptyTimeRange has a correct message": {
 			err:              errEmptyTimeRange,
 			expectedErrorMsg: "empty time range",
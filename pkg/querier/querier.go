@@ -18,6 +18,7 @@ import (
 	"github.com/grafana/dskit/tenant"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/exemplar"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/promql"
 	"github.com/prometheus/prometheus/promql/parser"
@@ -30,6 +31,7 @@ import (
 	"github.com/grafana/mimir/pkg/querier/stats"
 	"github.com/grafana/mimir/pkg/storage/chunk"
 	"github.com/grafana/mimir/pkg/storage/lazyquery"
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
 	"github.com/grafana/mimir/pkg/streamingpromql"
 	"github.com/grafana/mimir/pkg/streamingpromql/compat"
 	"github.com/grafana/mimir/pkg/util"
@@ -107,6 +109,13 @@ func (cfg *Config) ValidateLimits(limits validation.Limits) error {
 		}
 	}
 
+	// The ingesters must keep the blocks of the tenant until the queriers query them from the store-gateways.
+	if limits.IngesterTSDBRetentionPeriod != 0 && cfg.QueryStoreAfter != 0 {
+		if cfg.QueryStoreAfter >= time.Duration(limits.IngesterTSDBRetentionPeriod) {
+			return errBadIngesterTSDBRetentionConfig
+		}
+	}
+
 	return nil
 }
 
@@ -145,7 +154,7 @@ func New(cfg Config, limits *validation.Overrides, distributor Distributor, quer
 	})
 
 	queryable := newQueryable(queryables, cfg, limits, queryMetrics, logger)
-	exemplarQueryable := newDistributorExemplarQueryable(distributor, logger)
+	exemplarQueryable := newExemplarQueryable(newDistributorExemplarQueryable(distributor, logger), queryables)
 
 	lazyQueryable := storage.QueryableFunc(func(minT int64, maxT int64) (storage.Querier, error) {
 		querier, err := queryable.Querier(minT, maxT)
@@ -237,6 +246,9 @@ type TimeRangeQueryable struct {
 	storage.Queryable
 	IsApplicable func(tenantID string, now time.Time, queryMinT, queryMaxT int64) bool
 	StorageName  string
+
+	// ExemplarQueryable is used to query the exemplars from the storage. Nil if the storage doesn't store exemplars.
+	ExemplarQueryable storage.ExemplarQueryable
 }
 
 func NewStoreGatewayTimeRangeQueryable(q storage.Queryable, querierConfig Config) TimeRangeQueryable {
@@ -249,6 +261,80 @@ func NewStoreGatewayTimeRangeQueryable(q storage.Queryable, querierConfig Config
 	}
 }
 
+// newExemplarQueryable returns an exemplar queryable querying the exemplars from the ingesters and from
+// the queryables storing exemplars, if any.
+func newExemplarQueryable(ingesters storage.ExemplarQueryable, queryables []TimeRangeQueryable) storage.ExemplarQueryable {
+	var stores []TimeRangeQueryable
+	for _, q := range queryables {
+		if q.ExemplarQueryable != nil {
+			stores = append(stores, q)
+		}
+	}
+	if len(stores) == 0 {
+		return ingesters
+	}
+
+	return &multiExemplarQueryable{ingesters: ingesters, stores: stores}
+}
+
+// multiExemplarQueryable implements storage.ExemplarQueryable, merging the exemplars from the ingesters
+// and from the queryables storing exemplars.
+type multiExemplarQueryable struct {
+	ingesters storage.ExemplarQueryable
+	stores    []TimeRangeQueryable
+}
+
+func (m *multiExemplarQueryable) ExemplarQuerier(ctx context.Context) (storage.ExemplarQuerier, error) {
+	return &multiExemplarQuerier{ctx: ctx, queryable: m}, nil
+}
+
+type multiExemplarQuerier struct {
+	ctx       context.Context
+	queryable *multiExemplarQueryable
+}
+
+// Select implements storage.ExemplarQuerier. The exemplars returned by multiple storages are deduplicated.
+func (m *multiExemplarQuerier) Select(start, end int64, matchers ...[]*labels.Matcher) ([]exemplar.QueryResult, error) {
+	tenantID, err := tenant.TenantID(m.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The ingesters are always queried, because exemplars are not stored in blocks if long-term storage
+	// was disabled when the blocks were shipped.
+	queryables := []storage.ExemplarQueryable{m.queryable.ingesters}
+	now := time.Now()
+	for _, q := range m.queryable.stores {
+		if q.IsApplicable(tenantID, now, start, end) {
+			queryables = append(queryables, q.ExemplarQueryable)
+		}
+	}
+
+	sets := make([]*block.Exemplars, len(queryables))
+	g, ctx := errgroup.WithContext(m.ctx)
+	for i, q := range queryables {
+		g.Go(func() error {
+			querier, err := q.ExemplarQuerier(ctx)
+			if err != nil {
+				return err
+			}
+
+			results, err := querier.Select(start, end, matchers...)
+			if err != nil {
+				return err
+			}
+
+			sets[i] = exemplarsFromQueryResults(results)
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return exemplarsToQueryResults(block.MergeExemplars(sets...)), nil
+}
+
 // multiQuerier implements storage.Querier, orchestrating requests across a set of queriers.
 type multiQuerier struct {
 	queryables   []TimeRangeQueryable
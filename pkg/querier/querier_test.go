@@ -16,6 +16,7 @@ import (
 	"github.com/grafana/dskit/user"
 	"github.com/pkg/errors"
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/exemplar"
 	"github.com/prometheus/prometheus/model/histogram"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/promql"
@@ -1367,6 +1368,19 @@ func TestConfig_ValidateLimits(t *testing.T) {
 			},
 			expected: errBadLookbackConfigs,
 		},
+		"should pass if both 'query store after' and 'ingester TSDB retention period' are set and 'query store after' < 'ingester TSDB retention period'": {
+			setup: func(cfg *Config, limits *validation.Limits) {
+				cfg.QueryStoreAfter = 12 * time.Hour
+				limits.IngesterTSDBRetentionPeriod = model.Duration(24 * time.Hour)
+			},
+		},
+		"should fail if both 'query store after' and 'ingester TSDB retention period' are set and 'query store after' > 'ingester TSDB retention period'": {
+			setup: func(cfg *Config, limits *validation.Limits) {
+				cfg.QueryStoreAfter = 12 * time.Hour
+				limits.IngesterTSDBRetentionPeriod = model.Duration(6 * time.Hour)
+			},
+			expected: errBadIngesterTSDBRetentionConfig,
+		},
 	}
 
 	for testName, testData := range tests {
@@ -1380,6 +1394,72 @@ func TestConfig_ValidateLimits(t *testing.T) {
 	}
 }
 
+func TestExemplarQueryable_ShouldMergeExemplarsFromIngestersAndStores(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "user-1")
+	series1 := labels.FromStrings(model.MetricNameLabel, "series_1")
+	series2 := labels.FromStrings(model.MetricNameLabel, "series_2")
+	trace1 := labels.FromStrings("trace_id", "1")
+	trace2 := labels.FromStrings("trace_id", "2")
+
+	ingesters := &exemplarQueryableMock{results: []exemplar.QueryResult{
+		{SeriesLabels: series1, Exemplars: []exemplar.Exemplar{{Labels: trace2, Value: 2, Ts: 20, HasTs: true}}},
+	}}
+	store := &exemplarQueryableMock{results: []exemplar.QueryResult{
+		{SeriesLabels: series1, Exemplars: []exemplar.Exemplar{
+			{Labels: trace1, Value: 1, Ts: 10, HasTs: true},
+			{Labels: trace2, Value: 2, Ts: 20, HasTs: true},
+		}},
+		{SeriesLabels: series2, Exemplars: []exemplar.Exemplar{{Labels: trace1, Value: 3, Ts: 10, HasTs: true}}},
+	}}
+	notApplicable := &exemplarQueryableMock{results: []exemplar.QueryResult{
+		{SeriesLabels: series2, Exemplars: []exemplar.Exemplar{{Labels: trace2, Value: 4, Ts: 20, HasTs: true}}},
+	}}
+
+	queryable := newExemplarQueryable(ingesters, []TimeRangeQueryable{
+		{
+			StorageName:       "store-gateway",
+			IsApplicable:      func(string, time.Time, int64, int64) bool { return true },
+			ExemplarQueryable: store,
+		}, {
+			StorageName:       "other",
+			IsApplicable:      func(string, time.Time, int64, int64) bool { return false },
+			ExemplarQueryable: notApplicable,
+		}, {
+			// Storage not storing exemplars.
+			StorageName:  "ingester",
+			IsApplicable: func(string, time.Time, int64, int64) bool { return true },
+		},
+	})
+
+	querier, err := queryable.ExemplarQuerier(ctx)
+	require.NoError(t, err)
+
+	results, err := querier.Select(0, 100, []*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, model.MetricNameLabel, "series_.*")})
+	require.NoError(t, err)
+	assert.Equal(t, []exemplar.QueryResult{
+		{SeriesLabels: series1, Exemplars: []exemplar.Exemplar{
+			{Labels: trace1, Value: 1, Ts: 10, HasTs: true},
+			{Labels: trace2, Value: 2, Ts: 20, HasTs: true},
+		}},
+		{SeriesLabels: series2, Exemplars: []exemplar.Exemplar{{Labels: trace1, Value: 3, Ts: 10, HasTs: true}}},
+	}, results)
+
+	// When no storage stores exemplars, only the ingesters are queried.
+	assert.Same(t, ingesters, newExemplarQueryable(ingesters, nil))
+}
+
+type exemplarQueryableMock struct {
+	results []exemplar.QueryResult
+}
+
+func (m *exemplarQueryableMock) ExemplarQuerier(context.Context) (storage.ExemplarQuerier, error) {
+	return m, nil
+}
+
+func (m *exemplarQueryableMock) Select(int64, int64, ...[]*labels.Matcher) ([]exemplar.QueryResult, error) {
+	return m.results, nil
+}
+
 func TestClampMaxTime(t *testing.T) {
 	logger := spanlogger.FromContext(context.Background(), log.NewNopLogger())
 
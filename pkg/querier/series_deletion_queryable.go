@@ -0,0 +1,119 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+
+	"github.com/grafana/dskit/tenant"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/tombstones"
+
+	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
+)
+
+// SeriesDeletionRequestsProvider returns the series deletion requests of a tenant.
+type SeriesDeletionRequestsProvider interface {
+	Get(ctx context.Context, userID string) (mimir_tsdb.SeriesDeletionRequests, error)
+}
+
+// NewSeriesDeletionQueryable returns a queryable filtering out the samples deleted by the series deletion requests
+// of the tenant, until they're permanently deleted from ingesters and blocks.
+func NewSeriesDeletionQueryable(next storage.SampleAndChunkQueryable, requests SeriesDeletionRequestsProvider) storage.SampleAndChunkQueryable {
+	return NewSampleAndChunkQueryable(storage.QueryableFunc(func(mint, maxt int64) (storage.Querier, error) {
+		q, err := next.Querier(mint, maxt)
+		if err != nil {
+			return nil, err
+		}
+
+		return &seriesDeletionQuerier{Querier: q, requests: requests, mint: mint, maxt: maxt}, nil
+	}))
+}
+
+type seriesDeletionQuerier struct {
+	storage.Querier
+
+	requests   SeriesDeletionRequestsProvider
+	mint, maxt int64
+}
+
+func (q *seriesDeletionQuerier) Select(ctx context.Context, sortSeries bool, hints *storage.SelectHints, matchers ...*labels.Matcher) storage.SeriesSet {
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return storage.ErrSeriesSet(err)
+	}
+
+	reqs, err := q.requests.Get(ctx, userID)
+	if err != nil {
+		return storage.ErrSeriesSet(err)
+	}
+
+	mint, maxt := q.mint, q.maxt
+	if hints != nil {
+		mint, maxt = hints.Start, hints.End
+	}
+
+	reqs = reqs.Overlapping(mint, maxt)
+	if len(reqs) == 0 {
+		return q.Querier.Select(ctx, sortSeries, hints, matchers...)
+	}
+
+	return &seriesDeletionSeriesSet{
+		SeriesSet: q.Querier.Select(ctx, sortSeries, hints, matchers...),
+		requests:  reqs,
+		mint:      mint,
+		maxt:      maxt,
+	}
+}
+
+// seriesDeletionSeriesSet removes the deleted samples from the series, and the series whose samples
+// have all been deleted in the queried time range.
+type seriesDeletionSeriesSet struct {
+	storage.SeriesSet
+
+	requests   mimir_tsdb.SeriesDeletionRequests
+	mint, maxt int64
+	curr       storage.Series
+}
+
+func (s *seriesDeletionSeriesSet) Next() bool {
+	for s.SeriesSet.Next() {
+		series := s.SeriesSet.At()
+
+		intervals := s.requests.DeletedIntervals(series.Labels())
+		if len(intervals) == 0 {
+			s.curr = series
+			return true
+		}
+		if mimir_tsdb.IntervalsCover(intervals, s.mint, s.maxt) {
+			continue
+		}
+
+		s.curr = &seriesWithDeletedIntervals{Series: series, intervals: intervals}
+		return true
+	}
+	return false
+}
+
+func (s *seriesDeletionSeriesSet) At() storage.Series {
+	return s.curr
+}
+
+type seriesWithDeletedIntervals struct {
+	storage.Series
+
+	intervals tombstones.Intervals
+}
+
+func (s *seriesWithDeletedIntervals) Iterator(it chunkenc.Iterator) chunkenc.Iterator {
+	if deleted, ok := it.(*tsdb.DeletedIterator); ok {
+		deleted.Iter = s.Series.Iterator(deleted.Iter)
+		deleted.Intervals = s.intervals
+		return deleted
+	}
+
+	return &tsdb.DeletedIterator{Iter: s.Series.Iterator(it), Intervals: s.intervals}
+}
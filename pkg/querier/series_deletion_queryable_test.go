@@ -0,0 +1,94 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/user"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/storage/series"
+	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
+)
+
+type mockSeriesDeletionRequestsProvider map[string]mimir_tsdb.SeriesDeletionRequests
+
+func (m mockSeriesDeletionRequestsProvider) Get(_ context.Context, userID string) (mimir_tsdb.SeriesDeletionRequests, error) {
+	return m[userID], nil
+}
+
+func TestSeriesDeletionQueryable(t *testing.T) {
+	samples := func(timestamps ...int64) []model.SamplePair {
+		out := make([]model.SamplePair, 0, len(timestamps))
+		for _, ts := range timestamps {
+			out = append(out, model.SamplePair{Timestamp: model.Time(ts), Value: model.SampleValue(ts)})
+		}
+		return out
+	}
+
+	next := mockSampleAndChunkQueryable{
+		queryableFn: func(_, _ int64) (storage.Querier, error) {
+			return mockQuerier{
+				selectFn: func(context.Context, bool, *storage.SelectHints, ...*labels.Matcher) storage.SeriesSet {
+					return series.NewConcreteSeriesSetFromUnsortedSeries([]storage.Series{
+						series.NewConcreteSeries(labels.FromStrings("job", "a"), samples(10, 20, 30, 40), nil),
+						series.NewConcreteSeries(labels.FromStrings("job", "b"), samples(10, 20, 30, 40), nil),
+						series.NewConcreteSeries(labels.FromStrings("job", "c"), samples(10, 20, 30, 40), nil),
+					})
+				},
+			}, nil
+		},
+	}
+
+	now := time.Now()
+	partial, err := mimir_tsdb.NewSeriesDeletionRequest([]string{`{job="a"}`}, 15, 30, now)
+	require.NoError(t, err)
+	full, err := mimir_tsdb.NewSeriesDeletionRequest([]string{`{job="b"}`}, 0, 100, now)
+	require.NoError(t, err)
+
+	queryable := NewSeriesDeletionQueryable(next, mockSeriesDeletionRequestsProvider{
+		"user": {partial, full},
+	})
+
+	query := func(userID string) map[string][]int64 {
+		q, err := queryable.Querier(0, 50)
+		require.NoError(t, err)
+
+		set := q.Select(user.InjectOrgID(context.Background(), userID), true, nil)
+		out := map[string][]int64{}
+		var it chunkenc.Iterator
+		for set.Next() {
+			s := set.At()
+			it = s.Iterator(it)
+
+			timestamps := []int64{}
+			for it.Next() != chunkenc.ValNone {
+				ts, _ := it.At()
+				timestamps = append(timestamps, ts)
+			}
+			require.NoError(t, it.Err())
+			out[s.Labels().Get("job")] = timestamps
+		}
+		require.NoError(t, set.Err())
+		return out
+	}
+
+	assert.Equal(t, map[string][]int64{
+		"a": {10, 40},
+		"c": {10, 20, 30, 40},
+	}, query("user"))
+
+	assert.Equal(t, map[string][]int64{
+		"a": {10, 20, 30, 40},
+		"b": {10, 20, 30, 40},
+		"c": {10, 20, 30, 40},
+	}, query("other"))
+}
@@ -0,0 +1,284 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/grafana/dskit/tenant"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/tombstones"
+	"github.com/prometheus/prometheus/util/annotations"
+
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+// SeriesRetentionPoliciesProvider returns the series retention policies of a tenant.
+type SeriesRetentionPoliciesProvider interface {
+	CompactorSeriesRetentionPolicies(userID string) validation.SeriesRetentionPolicies
+}
+
+// NewSeriesRetentionQueryable returns a queryable filtering out the samples expired by the series retention
+// policies of the tenant, until they're removed from the blocks by the compactor.
+func NewSeriesRetentionQueryable(next storage.SampleAndChunkQueryable, limits SeriesRetentionPoliciesProvider) storage.SampleAndChunkQueryable {
+	return NewSampleAndChunkQueryable(storage.QueryableFunc(func(mint, maxt int64) (storage.Querier, error) {
+		q, err := next.Querier(mint, maxt)
+		if err != nil {
+			return nil, err
+		}
+
+		return &seriesRetentionQuerier{Querier: q, limits: limits, mint: mint, maxt: maxt}, nil
+	}))
+}
+
+type seriesRetentionQuerier struct {
+	storage.Querier
+
+	limits     SeriesRetentionPoliciesProvider
+	mint, maxt int64
+}
+
+func (q *seriesRetentionQuerier) Select(ctx context.Context, sortSeries bool, hints *storage.SelectHints, matchers ...*labels.Matcher) storage.SeriesSet {
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return storage.ErrSeriesSet(err)
+	}
+
+	policies := q.limits.CompactorSeriesRetentionPolicies(userID)
+	if !policies.Enabled() {
+		return q.Querier.Select(ctx, sortSeries, hints, matchers...)
+	}
+
+	mint, maxt := q.mint, q.maxt
+	if hints != nil {
+		mint, maxt = hints.Start, hints.End
+	}
+
+	return &seriesRetentionSeriesSet{
+		SeriesSet: q.Querier.Select(ctx, sortSeries, hints, matchers...),
+		policies:  policies,
+		now:       time.Now(),
+		mint:      mint,
+		maxt:      maxt,
+	}
+}
+
+// LabelValues returns the values of the label name in the series not expired by the retention policies. To avoid
+// selecting all the series, only the series matching the policies which expire the whole queried time range are
+// selected, and then the series with the label values found only in expired series, if any. The default period is
+// the blocks retention period, after which the blocks are deleted, so it's not applied to the label queries.
+func (q *seriesRetentionQuerier) LabelValues(ctx context.Context, name string, hints *storage.LabelHints, matchers ...*labels.Matcher) ([]string, annotations.Annotations, error) {
+	values, annots, err := q.Querier.LabelValues(ctx, name, hints, matchers...)
+	if err != nil || len(values) == 0 {
+		return values, annots, err
+	}
+	filter, ok, err := q.labelsFilter(ctx)
+	if err != nil || !ok {
+		return values, annots, err
+	}
+
+	notEmpty := labels.MustNewMatcher(labels.MatchNotEqual, name, "")
+	expired := map[string]struct{}{}
+	kept := map[string]struct{}{}
+	for _, policy := range filter.expiring {
+		ws, err := filter.selectSeries(ctx, q.Querier, append(concatMatchers(matchers, policy.Matchers...), notEmpty), func(lset labels.Labels, isExpired bool) bool {
+			if isExpired {
+				expired[lset.Get(name)] = struct{}{}
+			} else {
+				kept[lset.Get(name)] = struct{}{}
+			}
+			return true
+		})
+		annots = annots.Merge(ws)
+		if err != nil {
+			return nil, annots, err
+		}
+	}
+	for value := range kept {
+		delete(expired, value)
+	}
+	if len(expired) == 0 {
+		return values, annots, nil
+	}
+
+	// The values found in expired series may be found in series which have not expired too.
+	quoted := make([]string, 0, len(expired))
+	for _, value := range sortedKeys(expired) {
+		quoted = append(quoted, regexp.QuoteMeta(value))
+	}
+	inExpired, err := labels.NewMatcher(labels.MatchRegexp, name, strings.Join(quoted, "|"))
+	if err != nil {
+		return nil, annots, err
+	}
+	ws, err := filter.selectSeries(ctx, q.Querier, concatMatchers(matchers, inExpired), func(lset labels.Labels, isExpired bool) bool {
+		if !isExpired {
+			delete(expired, lset.Get(name))
+		}
+		return len(expired) > 0
+	})
+	annots = annots.Merge(ws)
+	if err != nil {
+		return nil, annots, err
+	}
+
+	return slices.DeleteFunc(values, func(value string) bool {
+		_, ok := expired[value]
+		return ok
+	}), annots, nil
+}
+
+// LabelNames returns the label names of the series not expired by the retention policies, selecting the series
+// like LabelValues does.
+func (q *seriesRetentionQuerier) LabelNames(ctx context.Context, hints *storage.LabelHints, matchers ...*labels.Matcher) ([]string, annotations.Annotations, error) {
+	names, annots, err := q.Querier.LabelNames(ctx, hints, matchers...)
+	if err != nil || len(names) == 0 {
+		return names, annots, err
+	}
+	filter, ok, err := q.labelsFilter(ctx)
+	if err != nil || !ok {
+		return names, annots, err
+	}
+
+	expired := map[string]struct{}{}
+	kept := map[string]struct{}{}
+	for _, policy := range filter.expiring {
+		ws, err := filter.selectSeries(ctx, q.Querier, concatMatchers(matchers, policy.Matchers...), func(lset labels.Labels, isExpired bool) bool {
+			lset.Range(func(l labels.Label) {
+				if isExpired {
+					expired[l.Name] = struct{}{}
+				} else {
+					kept[l.Name] = struct{}{}
+				}
+			})
+			return true
+		})
+		annots = annots.Merge(ws)
+		if err != nil {
+			return nil, annots, err
+		}
+	}
+	for name := range kept {
+		delete(expired, name)
+	}
+
+	// The names found in expired series may be found in series which have not expired too.
+	for _, name := range sortedKeys(expired) {
+		ws, err := filter.selectSeries(ctx, q.Querier, concatMatchers(matchers, labels.MustNewMatcher(labels.MatchNotEqual, name, "")), func(_ labels.Labels, isExpired bool) bool {
+			if !isExpired {
+				delete(expired, name)
+			}
+			return isExpired
+		})
+		annots = annots.Merge(ws)
+		if err != nil {
+			return nil, annots, err
+		}
+	}
+
+	return slices.DeleteFunc(names, func(name string) bool {
+		_, ok := expired[name]
+		return ok
+	}), annots, nil
+}
+
+// labelsFilter returns the filter of the label queries of the tenant. It returns false if no series can be
+// expired in the whole queried time range, and the label queries don't need to be filtered.
+func (q *seriesRetentionQuerier) labelsFilter(ctx context.Context) (seriesRetentionLabelsFilter, bool, error) {
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return seriesRetentionLabelsFilter{}, false, err
+	}
+
+	filter := seriesRetentionLabelsFilter{
+		policies: q.limits.CompactorSeriesRetentionPolicies(userID),
+		now:      time.Now(),
+		mint:     q.mint,
+		maxt:     q.maxt,
+	}
+	for _, policy := range filter.policies.Policies {
+		if policy.Period > 0 && filter.now.Add(-policy.Period).UnixMilli() > q.maxt {
+			filter.expiring = append(filter.expiring, policy)
+		}
+	}
+	return filter, len(filter.expiring) > 0, nil
+}
+
+type seriesRetentionLabelsFilter struct {
+	policies   validation.SeriesRetentionPolicies
+	now        time.Time
+	mint, maxt int64
+
+	// expiring are the policies expiring the whole queried time range.
+	expiring []validation.SeriesRetentionPolicy
+}
+
+// selectSeries calls f with the labels of the series matching the matchers, and whether all their samples in the
+// queried time range have expired, until f returns false.
+func (f seriesRetentionLabelsFilter) selectSeries(ctx context.Context, q storage.Querier, matchers []*labels.Matcher, fn func(lset labels.Labels, isExpired bool) bool) (annotations.Annotations, error) {
+	hints := &storage.SelectHints{Start: f.mint, End: f.maxt, Func: "series"}
+	set := q.Select(ctx, false, hints, matchers...)
+	for set.Next() {
+		lset := set.At().Labels()
+		if !fn(lset, f.policies.ExpiredBefore(lset, f.now) > f.maxt) {
+			break
+		}
+	}
+	return set.Warnings(), set.Err()
+}
+
+// concatMatchers returns a new slice with the matchers followed by the others.
+func concatMatchers(matchers []*labels.Matcher, others ...*labels.Matcher) []*labels.Matcher {
+	return append(slices.Clone(matchers), others...)
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	slices.Sort(out)
+	return out
+}
+
+// seriesRetentionSeriesSet removes the expired samples from the series, and the series whose samples
+// have all expired in the queried time range.
+type seriesRetentionSeriesSet struct {
+	storage.SeriesSet
+
+	policies   validation.SeriesRetentionPolicies
+	now        time.Time
+	mint, maxt int64
+	curr       storage.Series
+}
+
+func (s *seriesRetentionSeriesSet) Next() bool {
+	for s.SeriesSet.Next() {
+		series := s.SeriesSet.At()
+
+		expiredBefore := s.policies.ExpiredBefore(series.Labels(), s.now)
+		if expiredBefore <= s.mint {
+			s.curr = series
+			return true
+		}
+		if expiredBefore > s.maxt {
+			continue
+		}
+
+		s.curr = &seriesWithDeletedIntervals{
+			Series:    series,
+			intervals: tombstones.Intervals{{Mint: math.MinInt64, Maxt: expiredBefore - 1}},
+		}
+		return true
+	}
+	return false
+}
+
+func (s *seriesRetentionSeriesSet) At() storage.Series {
+	return s.curr
+}
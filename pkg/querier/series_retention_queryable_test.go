@@ -0,0 +1,222 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/user"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/util/annotations"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/storage/series"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+type mockSeriesRetentionPoliciesProvider map[string]validation.SeriesRetentionPolicies
+
+func (m mockSeriesRetentionPoliciesProvider) CompactorSeriesRetentionPolicies(userID string) validation.SeriesRetentionPolicies {
+	return m[userID]
+}
+
+func TestSeriesRetentionQueryable(t *testing.T) {
+	now := time.Now()
+	hoursAgo := func(h int) int64 {
+		return now.Add(-time.Duration(h) * time.Hour).UnixMilli()
+	}
+	samples := func(timestamps ...int64) []model.SamplePair {
+		out := make([]model.SamplePair, 0, len(timestamps))
+		for _, ts := range timestamps {
+			out = append(out, model.SamplePair{Timestamp: model.Time(ts), Value: model.SampleValue(ts)})
+		}
+		return out
+	}
+
+	next := mockSampleAndChunkQueryable{
+		queryableFn: func(_, _ int64) (storage.Querier, error) {
+			return mockQuerier{
+				selectFn: func(context.Context, bool, *storage.SelectHints, ...*labels.Matcher) storage.SeriesSet {
+					return series.NewConcreteSeriesSetFromUnsortedSeries([]storage.Series{
+						series.NewConcreteSeries(labels.FromStrings("job", "debug"), samples(hoursAgo(30), hoursAgo(20), hoursAgo(1)), nil),
+						series.NewConcreteSeries(labels.FromStrings("job", "old"), samples(hoursAgo(30), hoursAgo(20)), nil),
+						series.NewConcreteSeries(labels.FromStrings("job", "prod"), samples(hoursAgo(30), hoursAgo(20), hoursAgo(1)), nil),
+						series.NewConcreteSeries(labels.FromStrings("job", "other"), samples(hoursAgo(30), hoursAgo(20), hoursAgo(1)), nil),
+					})
+				},
+			}, nil
+		},
+	}
+
+	queryable := NewSeriesRetentionQueryable(next, mockSeriesRetentionPoliciesProvider{
+		"user": {
+			Policies: []validation.SeriesRetentionPolicy{
+				{Matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, "job", "debug|old")}, Period: 10 * time.Hour},
+				{Matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "job", "prod")}, Period: 0},
+			},
+			DefaultPeriod: 24 * time.Hour,
+		},
+	})
+
+	query := func(userID string, maxT int64) map[string][]int64 {
+		q, err := queryable.Querier(hoursAgo(40), maxT)
+		require.NoError(t, err)
+
+		set := q.Select(user.InjectOrgID(context.Background(), userID), true, nil)
+		out := map[string][]int64{}
+		var it chunkenc.Iterator
+		for set.Next() {
+			s := set.At()
+			it = s.Iterator(it)
+
+			timestamps := []int64{}
+			for it.Next() != chunkenc.ValNone {
+				ts, _ := it.At()
+				timestamps = append(timestamps, ts)
+			}
+			require.NoError(t, it.Err())
+			out[s.Labels().Get("job")] = timestamps
+		}
+		require.NoError(t, set.Err())
+		return out
+	}
+
+	assert.Equal(t, map[string][]int64{
+		"debug": {hoursAgo(1)},
+		"old":   {},
+		"prod":  {hoursAgo(30), hoursAgo(20), hoursAgo(1)},
+		"other": {hoursAgo(20), hoursAgo(1)},
+	}, query("user", now.UnixMilli()))
+
+	// Series whose samples have all expired in the queried time range are removed.
+	assert.Equal(t, map[string][]int64{
+		"prod":  {hoursAgo(30), hoursAgo(20), hoursAgo(1)},
+		"other": {hoursAgo(20), hoursAgo(1)},
+	}, query("user", hoursAgo(15)))
+
+	// Tenants without retention policies are not filtered.
+	assert.Len(t, query("other", now.UnixMilli()), 4)
+
+	t.Run("label queries", func(t *testing.T) {
+		ctx := user.InjectOrgID(context.Background(), "user")
+
+		var selects [][]*labels.Matcher
+		labelsQueryable := NewSeriesRetentionQueryable(mockSampleAndChunkQueryable{
+			queryableFn: func(_, _ int64) (storage.Querier, error) {
+				return &seriesListQuerier{
+					series: []labels.Labels{
+						labels.FromStrings("job", "debug", "debug_only", "true"),
+						labels.FromStrings("job", "old", "instance", "1"),
+						labels.FromStrings("job", "prod", "instance", "1"),
+						labels.FromStrings("job", "other"),
+					},
+					selects: &selects,
+				}, nil
+			},
+		}, mockSeriesRetentionPoliciesProvider{
+			"user": {
+				Policies: []validation.SeriesRetentionPolicy{
+					{Matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, "job", "debug|old")}, Period: 10 * time.Hour},
+					{Matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "job", "prod")}, Period: 0},
+				},
+				DefaultPeriod: 24 * time.Hour,
+			},
+		})
+
+		// Series whose samples have all expired in the queried time range are removed.
+		q, err := labelsQueryable.Querier(hoursAgo(40), hoursAgo(15))
+		require.NoError(t, err)
+
+		values, _, err := q.LabelValues(ctx, "job", nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"other", "prod"}, values)
+
+		names, _, err := q.LabelNames(ctx, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"instance", "job"}, names)
+
+		// Only the series matching the expiring policies, and the ones with the names and values found in expired
+		// series, are selected.
+		var selected []string
+		for _, matchers := range selects {
+			selected = append(selected, fmt.Sprint(matchers))
+		}
+		assert.Equal(t, []string{
+			`[job=~"debug|old" job!=""]`,
+			`[job=~"debug|old"]`,
+			`[job=~"debug|old"]`,
+			`[debug_only!=""]`,
+			`[instance!=""]`,
+			`[job!=""]`,
+		}, selected)
+
+		// Label queries are not filtered when no series can be expired in the whole queried time range.
+		selects = nil
+		q, err = labelsQueryable.Querier(hoursAgo(40), now.UnixMilli())
+		require.NoError(t, err)
+
+		values, _, err = q.LabelValues(ctx, "job", nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"debug", "old", "other", "prod"}, values)
+		assert.Empty(t, selects)
+	})
+}
+
+// seriesListQuerier is a querier of a list of series without samples, which records the matchers of the selects.
+type seriesListQuerier struct {
+	storage.Querier
+
+	series  []labels.Labels
+	selects *[][]*labels.Matcher
+}
+
+func (q *seriesListQuerier) Select(_ context.Context, _ bool, _ *storage.SelectHints, matchers ...*labels.Matcher) storage.SeriesSet {
+	*q.selects = append(*q.selects, matchers)
+
+	var out []storage.Series
+	for _, lset := range q.matching(matchers) {
+		out = append(out, series.NewConcreteSeries(lset, nil, nil))
+	}
+	return series.NewConcreteSeriesSetFromUnsortedSeries(out)
+}
+
+func (q *seriesListQuerier) LabelValues(_ context.Context, name string, _ *storage.LabelHints, matchers ...*labels.Matcher) ([]string, annotations.Annotations, error) {
+	values := map[string]struct{}{}
+	for _, lset := range q.matching(matchers) {
+		if value := lset.Get(name); value != "" {
+			values[value] = struct{}{}
+		}
+	}
+	return sortedKeys(values), nil, nil
+}
+
+func (q *seriesListQuerier) LabelNames(_ context.Context, _ *storage.LabelHints, matchers ...*labels.Matcher) ([]string, annotations.Annotations, error) {
+	names := map[string]struct{}{}
+	for _, lset := range q.matching(matchers) {
+		lset.Range(func(l labels.Label) {
+			names[l.Name] = struct{}{}
+		})
+	}
+	return sortedKeys(names), nil, nil
+}
+
+func (q *seriesListQuerier) matching(matchers []*labels.Matcher) []labels.Labels {
+	var out []labels.Labels
+	for _, lset := range q.series {
+		matches := true
+		for _, m := range matchers {
+			matches = matches && m.Matches(lset.Get(m.Name))
+		}
+		if matches {
+			out = append(out, lset)
+		}
+	}
+	return out
+}
@@ -78,6 +78,7 @@ type mockStoreGatewayServer struct {
 	onSeries      func(req *storepb.SeriesRequest, srv storegatewaypb.StoreGateway_SeriesServer) error
 	onLabelNames  func(ctx context.Context, req *storepb.LabelNamesRequest) (*storepb.LabelNamesResponse, error)
 	onLabelValues func(ctx context.Context, req *storepb.LabelValuesRequest) (*storepb.LabelValuesResponse, error)
+	onExemplars   func(ctx context.Context, req *storepb.ExemplarsRequest) (*storepb.ExemplarsResponse, error)
 }
 
 func (m *mockStoreGatewayServer) Series(req *storepb.SeriesRequest, srv storegatewaypb.StoreGateway_SeriesServer) error {
@@ -103,3 +104,11 @@ func (m *mockStoreGatewayServer) LabelValues(ctx context.Context, req *storepb.L
 
 	return nil, nil
 }
+
+func (m *mockStoreGatewayServer) Exemplars(ctx context.Context, req *storepb.ExemplarsRequest) (*storepb.ExemplarsResponse, error) {
+	if m.onExemplars != nil {
+		return m.onExemplars(ctx, req)
+	}
+
+	return nil, nil
+}
@@ -23,7 +23,9 @@ type SoftAppendErrorProcessor struct {
 	sampleTooFarInFuture             func(int64, []mimirpb.LabelAdapter)
 	errDuplicateSampleForTimestamp   func(int64, []mimirpb.LabelAdapter)
 	maxSeriesPerUser                 func()
+	maxMemoryPerUser                 func()
 	maxSeriesPerMetric               func(labels []mimirpb.LabelAdapter)
+	maxSeriesPerLabelValue           func(error, []mimirpb.LabelAdapter)
 	errOOONativeHistogramsDisabled   func(error, int64, []mimirpb.LabelAdapter)
 	errHistogramCountMismatch        func(error, int64, []mimirpb.LabelAdapter)
 	errHistogramCountNotBigEnough    func(error, int64, []mimirpb.LabelAdapter)
@@ -40,7 +42,9 @@ func NewSoftAppendErrorProcessor(
 	sampleTooFarInFuture func(int64, []mimirpb.LabelAdapter),
 	errDuplicateSampleForTimestamp func(int64, []mimirpb.LabelAdapter),
 	maxSeriesPerUser func(),
+	maxMemoryPerUser func(),
 	maxSeriesPerMetric func(labels []mimirpb.LabelAdapter),
+	maxSeriesPerLabelValue func(error, []mimirpb.LabelAdapter),
 	errOOONativeHistogramsDisabled func(error, int64, []mimirpb.LabelAdapter),
 	errHistogramCountMismatch func(error, int64, []mimirpb.LabelAdapter),
 	errHistogramCountNotBigEnough func(error, int64, []mimirpb.LabelAdapter),
@@ -56,7 +60,9 @@ func NewSoftAppendErrorProcessor(
 		sampleTooFarInFuture:             sampleTooFarInFuture,
 		errDuplicateSampleForTimestamp:   errDuplicateSampleForTimestamp,
 		maxSeriesPerUser:                 maxSeriesPerUser,
+		maxMemoryPerUser:                 maxMemoryPerUser,
 		maxSeriesPerMetric:               maxSeriesPerMetric,
+		maxSeriesPerLabelValue:           maxSeriesPerLabelValue,
 		errOOONativeHistogramsDisabled:   errOOONativeHistogramsDisabled,
 		errHistogramCountMismatch:        errHistogramCountMismatch,
 		errHistogramCountNotBigEnough:    errHistogramCountNotBigEnough,
@@ -91,9 +97,15 @@ func (e *SoftAppendErrorProcessor) ProcessErr(err error, ts int64, labels []mimi
 	case errors.Is(err, globalerror.MaxSeriesPerUser):
 		e.maxSeriesPerUser()
 		return true
+	case errors.Is(err, globalerror.MaxMemoryPerUser):
+		e.maxMemoryPerUser()
+		return true
 	case errors.Is(err, globalerror.MaxSeriesPerMetric):
 		e.maxSeriesPerMetric(labels)
 		return true
+	case errors.Is(err, globalerror.MaxSeriesPerLabelValue):
+		e.maxSeriesPerLabelValue(err, labels)
+		return true
 
 	// Map TSDB native histogram validation errors to soft errors.
 	case errors.Is(err, storage.ErrOOONativeHistogramsDisabled):
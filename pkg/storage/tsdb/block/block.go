@@ -121,6 +121,18 @@ func Upload(ctx context.Context, logger log.Logger, bkt objstore.Bucket, blockDi
 		return cleanUp(logger, bkt, id, errors.Wrap(err, "upload index"))
 	}
 
+	if _, err := os.Stat(filepath.Join(blockDir, ExemplarsFilename)); err == nil {
+		if err := objstore.UploadFile(ctx, logger, bkt, filepath.Join(blockDir, ExemplarsFilename), path.Join(id.String(), ExemplarsFilename)); err != nil {
+			return cleanUp(logger, bkt, id, errors.Wrap(err, "upload exemplars"))
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(blockDir, MetricsMetadataFilename)); err == nil {
+		if err := objstore.UploadFile(ctx, logger, bkt, filepath.Join(blockDir, MetricsMetadataFilename), path.Join(id.String(), MetricsMetadataFilename)); err != nil {
+			return cleanUp(logger, bkt, id, errors.Wrap(err, "upload metric metadata"))
+		}
+	}
+
 	// Meta.json always need to be uploaded as a last item. This will allow to assume block directories without meta file to be pending uploads.
 	if err := bkt.Upload(ctx, path.Join(id.String(), MetaFilename), strings.NewReader(metaEncoded.String())); err != nil {
 		// Don't call cleanUp here. Despite getting error, meta.json may have been uploaded in certain cases,
@@ -172,6 +184,82 @@ func MarkForDeletion(ctx context.Context, logger log.Logger, bkt objstore.Bucket
 	return nil
 }
 
+// MarkForColdStorage creates a file which stores information about when the block has been moved to the cold storage.
+func MarkForColdStorage(ctx context.Context, logger log.Logger, bkt objstore.Bucket, id ulid.ULID, details string, markedForColdStorage prometheus.Counter) error {
+	coldStorageMarkFile := path.Join(id.String(), ColdStorageMarkFilename)
+	coldStorageMarkExists, err := bkt.Exists(ctx, coldStorageMarkFile)
+	if err != nil {
+		return errors.Wrapf(err, "check exists %s in bucket", coldStorageMarkFile)
+	}
+	if coldStorageMarkExists {
+		level.Warn(logger).Log("msg", "requested to mark for cold storage, but file already exists; this should not happen; investigate", "err", errors.Errorf("file %s already exists in bucket", coldStorageMarkFile))
+		return nil
+	}
+
+	coldStorageMark, err := json.Marshal(ColdStorageMark{
+		ID:              id,
+		ColdStorageTime: time.Now().Unix(),
+		Version:         ColdStorageMarkVersion1,
+		Details:         details,
+	})
+	if err != nil {
+		return errors.Wrap(err, "json encode cold storage mark")
+	}
+
+	if err := bkt.Upload(ctx, coldStorageMarkFile, bytes.NewBuffer(coldStorageMark)); err != nil {
+		return errors.Wrapf(err, "upload file %s to bucket", coldStorageMarkFile)
+	}
+	markedForColdStorage.Inc()
+	level.Info(logger).Log("msg", "block has been marked for cold storage", "block", id)
+	return nil
+}
+
+// MarkForQuarantine creates a file which stores information about why the block has been quarantined.
+func MarkForQuarantine(ctx context.Context, logger log.Logger, bkt objstore.Bucket, id ulid.ULID, details string, markedForQuarantine prometheus.Counter) error {
+	quarantineMarkFile := path.Join(id.String(), QuarantineMarkFilename)
+	quarantineMarkExists, err := bkt.Exists(ctx, quarantineMarkFile)
+	if err != nil {
+		return errors.Wrapf(err, "check exists %s in bucket", quarantineMarkFile)
+	}
+	if quarantineMarkExists {
+		level.Warn(logger).Log("msg", "requested to mark for quarantine, but file already exists; this should not happen; investigate", "err", errors.Errorf("file %s already exists in bucket", quarantineMarkFile))
+		return nil
+	}
+
+	quarantineMark, err := json.Marshal(QuarantineMark{
+		ID:             id,
+		QuarantineTime: time.Now().Unix(),
+		Version:        QuarantineMarkVersion1,
+		Details:        details,
+	})
+	if err != nil {
+		return errors.Wrap(err, "json encode quarantine mark")
+	}
+
+	if err := bkt.Upload(ctx, quarantineMarkFile, bytes.NewBuffer(quarantineMark)); err != nil {
+		return errors.Wrapf(err, "upload file %s to bucket", quarantineMarkFile)
+	}
+	markedForQuarantine.Inc()
+	level.Info(logger).Log("msg", "block has been marked for quarantine", "block", id)
+	return nil
+}
+
+// DeleteData removes the data files of the block, keeping its meta.json and markers, so that the block is still
+// listed in the bucket. It's used to remove the data of the blocks moved to the cold storage from the blocks storage.
+func DeleteData(ctx context.Context, logger log.Logger, bkt objstore.Bucket, id ulid.ULID) error {
+	return deleteDirRec(ctx, logger, bkt, id.String(), IsMetadataFile)
+}
+
+// IsMetadataFile returns whether the object name, relative to the block directory parent, is the meta.json or a marker of a block.
+func IsMetadataFile(name string) bool {
+	switch path.Base(name) {
+	case MetaFilename, DeletionMarkFilename, NoCompactMarkFilename, ColdStorageMarkFilename, QuarantineMarkFilename:
+		return true
+	default:
+		return false
+	}
+}
+
 // Delete removes directory that is meant to be block directory.
 // NOTE: Always prefer this method for deleting blocks.
 //   - We have to delete block's files in the certain order (meta.json first and deletion-mark.json last)
@@ -313,6 +401,26 @@ func GatherFileStats(blockDir string) (res []File, _ error) {
 	}
 	res = append(res, mf)
 
+	exemplarsFile, err := os.Stat(filepath.Join(blockDir, ExemplarsFilename))
+	if err == nil {
+		res = append(res, File{
+			RelPath:   exemplarsFile.Name(),
+			SizeBytes: exemplarsFile.Size(),
+		})
+	} else if !os.IsNotExist(err) {
+		return nil, errors.Wrapf(err, "stat %v", filepath.Join(blockDir, ExemplarsFilename))
+	}
+
+	metadataFile, err := os.Stat(filepath.Join(blockDir, MetricsMetadataFilename))
+	if err == nil {
+		res = append(res, File{
+			RelPath:   metadataFile.Name(),
+			SizeBytes: metadataFile.Size(),
+		})
+	} else if !os.IsNotExist(err) {
+		return nil, errors.Wrapf(err, "stat %v", filepath.Join(blockDir, MetricsMetadataFilename))
+	}
+
 	metaFile, err := os.Stat(filepath.Join(blockDir, MetaFilename))
 	if err != nil {
 		return nil, errors.Wrapf(err, "stat %v", filepath.Join(blockDir, MetaFilename))
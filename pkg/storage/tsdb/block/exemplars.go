@@ -0,0 +1,221 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package block
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+
+	"github.com/grafana/dskit/runutil"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/thanos-io/objstore"
+)
+
+const (
+	// ExemplarsFilename is the known gzipped JSON filename for the exemplars of the block.
+	ExemplarsFilename = "exemplars.json.gz"
+
+	// ExemplarsVersion1 is the current exemplars file version.
+	ExemplarsVersion1 = 1
+)
+
+// Exemplars holds the exemplars of the series in a block. The exemplars file is optional,
+// and it's stored alongside the index and chunks of the block.
+type Exemplars struct {
+	Version int `json:"version"`
+
+	// Series sorted by labels.
+	Series []ExemplarSeries `json:"series"`
+}
+
+// ExemplarSeries holds the exemplars of a series, sorted by timestamp.
+type ExemplarSeries struct {
+	Labels    labels.Labels `json:"labels"`
+	Exemplars []Exemplar    `json:"exemplars"`
+}
+
+// Exemplar is a single exemplar.
+type Exemplar struct {
+	Labels    labels.Labels `json:"labels"`
+	Value     float64       `json:"-"`
+	Timestamp int64         `json:"timestamp"`
+}
+
+type exemplarJSON struct {
+	Labels    labels.Labels `json:"labels"`
+	Value     string        `json:"value"`
+	Timestamp int64         `json:"timestamp"`
+}
+
+// MarshalJSON implements json.Marshaler. The value is encoded as a string, because JSON doesn't support NaN and Inf.
+func (e Exemplar) MarshalJSON() ([]byte, error) {
+	return json.Marshal(exemplarJSON{
+		Labels:    e.Labels,
+		Value:     strconv.FormatFloat(e.Value, 'f', -1, 64),
+		Timestamp: e.Timestamp,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (e *Exemplar) UnmarshalJSON(data []byte) error {
+	var v exemplarJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	value, err := strconv.ParseFloat(v.Value, 64)
+	if err != nil {
+		return errors.Wrap(err, "parse exemplar value")
+	}
+
+	*e = Exemplar{Labels: v.Labels, Value: value, Timestamp: v.Timestamp}
+	return nil
+}
+
+// Len returns the number of exemplars.
+func (e *Exemplars) Len() int {
+	n := 0
+	for _, s := range e.Series {
+		n += len(s.Exemplars)
+	}
+	return n
+}
+
+// Filter returns the exemplars of the series for which keepSeries returns true, with timestamp in the
+// [minT, maxT] range.
+func (e *Exemplars) Filter(minT, maxT int64, keepSeries func(lset labels.Labels) bool) *Exemplars {
+	out := &Exemplars{Version: ExemplarsVersion1}
+	for _, s := range e.Series {
+		if keepSeries != nil && !keepSeries(s.Labels) {
+			continue
+		}
+
+		var exemplars []Exemplar
+		for _, ex := range s.Exemplars {
+			if ex.Timestamp >= minT && ex.Timestamp <= maxT {
+				exemplars = append(exemplars, ex)
+			}
+		}
+		if len(exemplars) > 0 {
+			out.Series = append(out.Series, ExemplarSeries{Labels: s.Labels, Exemplars: exemplars})
+		}
+	}
+	return out
+}
+
+// MergeExemplars merges the exemplars of multiple blocks, removing the duplicated ones.
+func MergeExemplars(sets ...*Exemplars) *Exemplars {
+	bySeries := map[string]*ExemplarSeries{}
+	for _, set := range sets {
+		if set == nil {
+			continue
+		}
+		for _, s := range set.Series {
+			key := s.Labels.String()
+			merged, ok := bySeries[key]
+			if !ok {
+				merged = &ExemplarSeries{Labels: s.Labels}
+				bySeries[key] = merged
+			}
+			merged.Exemplars = append(merged.Exemplars, s.Exemplars...)
+		}
+	}
+
+	out := &Exemplars{Version: ExemplarsVersion1, Series: make([]ExemplarSeries, 0, len(bySeries))}
+	for _, s := range bySeries {
+		slices.SortStableFunc(s.Exemplars, func(a, b Exemplar) int {
+			switch {
+			case a.Timestamp < b.Timestamp:
+				return -1
+			case a.Timestamp > b.Timestamp:
+				return 1
+			default:
+				return labels.Compare(a.Labels, b.Labels)
+			}
+		})
+		s.Exemplars = slices.CompactFunc(s.Exemplars, func(a, b Exemplar) bool {
+			return a.Timestamp == b.Timestamp && labels.Equal(a.Labels, b.Labels)
+		})
+		out.Series = append(out.Series, *s)
+	}
+	slices.SortFunc(out.Series, func(a, b ExemplarSeries) int {
+		return labels.Compare(a.Labels, b.Labels)
+	})
+	return out
+}
+
+// WriteExemplarsFile writes the exemplars file in the block directory.
+func WriteExemplarsFile(dir string, e *Exemplars) (err error) {
+	e.Version = ExemplarsVersion1
+
+	f, err := os.Create(filepath.Join(dir, ExemplarsFilename))
+	if err != nil {
+		return errors.Wrap(err, "create exemplars file")
+	}
+	defer runutil.CloseWithErrCapture(&err, f, "close exemplars file")
+
+	gzw := gzip.NewWriter(f)
+	if err := json.NewEncoder(gzw).Encode(e); err != nil {
+		return errors.Wrap(err, "encode exemplars")
+	}
+	if err := gzw.Close(); err != nil {
+		return errors.Wrap(err, "close gzip writer")
+	}
+	return f.Sync()
+}
+
+// ReadExemplarsFromDir reads the exemplars file from the block directory. If the block has no exemplars file,
+// empty exemplars are returned.
+func ReadExemplarsFromDir(dir string) (_ *Exemplars, err error) {
+	f, err := os.Open(filepath.Join(dir, ExemplarsFilename))
+	if os.IsNotExist(err) {
+		return &Exemplars{Version: ExemplarsVersion1}, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "open exemplars file")
+	}
+	defer runutil.CloseWithErrCapture(&err, f, "close exemplars file")
+
+	return readExemplars(f)
+}
+
+// DownloadExemplars downloads the exemplars file of the block from the bucket. If the block has no exemplars file,
+// empty exemplars are returned.
+func DownloadExemplars(ctx context.Context, bkt objstore.BucketReader, id ulid.ULID) (_ *Exemplars, err error) {
+	r, err := bkt.Get(ctx, path.Join(id.String(), ExemplarsFilename))
+	if bkt.IsObjNotFoundErr(err) {
+		return &Exemplars{Version: ExemplarsVersion1}, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "get exemplars of block %s", id)
+	}
+	defer runutil.CloseWithErrCapture(&err, r, "close exemplars reader")
+
+	return readExemplars(r)
+}
+
+func readExemplars(r io.Reader) (*Exemplars, error) {
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "create gzip reader")
+	}
+	defer gzr.Close()
+
+	e := &Exemplars{}
+	if err := json.NewDecoder(gzr).Decode(e); err != nil {
+		return nil, errors.Wrap(err, "decode exemplars")
+	}
+	if e.Version != ExemplarsVersion1 {
+		return nil, errors.Errorf("unexpected exemplars file version %d", e.Version)
+	}
+	return e, nil
+}
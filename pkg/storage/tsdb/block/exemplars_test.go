@@ -0,0 +1,110 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package block
+
+import (
+	"context"
+	"math"
+	"path"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+)
+
+func TestExemplars_WriteAndRead(t *testing.T) {
+	dir := t.TempDir()
+
+	// No exemplars file.
+	e, err := ReadExemplarsFromDir(dir)
+	require.NoError(t, err)
+	require.Equal(t, 0, e.Len())
+
+	expected := &Exemplars{Series: []ExemplarSeries{{
+		Labels: labels.FromStrings("__name__", "series_1"),
+		Exemplars: []Exemplar{
+			{Labels: labels.FromStrings("trace_id", "1"), Value: 1.5, Timestamp: 10},
+			{Labels: labels.FromStrings("trace_id", "2"), Value: math.Inf(1), Timestamp: 20},
+		},
+	}}}
+	require.NoError(t, WriteExemplarsFile(dir, expected))
+
+	e, err = ReadExemplarsFromDir(dir)
+	require.NoError(t, err)
+	require.Equal(t, expected, e)
+
+	// Upload the exemplars file as part of a block, and download it.
+	bkt := objstore.NewInMemBucket()
+	id := ulid.MustNew(1, nil)
+	require.NoError(t, objstore.UploadFile(context.Background(), log.NewNopLogger(), bkt, filepath.Join(dir, ExemplarsFilename), path.Join(id.String(), ExemplarsFilename)))
+
+	e, err = DownloadExemplars(context.Background(), bkt, id)
+	require.NoError(t, err)
+	require.Equal(t, expected, e)
+
+	// Block without exemplars file.
+	e, err = DownloadExemplars(context.Background(), bkt, ulid.MustNew(2, nil))
+	require.NoError(t, err)
+	require.Equal(t, 0, e.Len())
+}
+
+func TestMergeExemplars(t *testing.T) {
+	series1 := labels.FromStrings("__name__", "series_1")
+	series2 := labels.FromStrings("__name__", "series_2")
+	trace1 := labels.FromStrings("trace_id", "1")
+	trace2 := labels.FromStrings("trace_id", "2")
+
+	merged := MergeExemplars(
+		&Exemplars{Series: []ExemplarSeries{
+			{Labels: series2, Exemplars: []Exemplar{{Labels: trace1, Value: 1, Timestamp: 10}}},
+			{Labels: series1, Exemplars: []Exemplar{{Labels: trace2, Value: 2, Timestamp: 20}}},
+		}},
+		nil,
+		&Exemplars{Series: []ExemplarSeries{
+			{Labels: series1, Exemplars: []Exemplar{
+				{Labels: trace1, Value: 1, Timestamp: 10},
+				{Labels: trace2, Value: 2, Timestamp: 20},
+			}},
+		}},
+	)
+
+	require.Equal(t, &Exemplars{Version: ExemplarsVersion1, Series: []ExemplarSeries{
+		{Labels: series1, Exemplars: []Exemplar{
+			{Labels: trace1, Value: 1, Timestamp: 10},
+			{Labels: trace2, Value: 2, Timestamp: 20},
+		}},
+		{Labels: series2, Exemplars: []Exemplar{{Labels: trace1, Value: 1, Timestamp: 10}}},
+	}}, merged)
+}
+
+func TestExemplars_Filter(t *testing.T) {
+	series1 := labels.FromStrings("__name__", "series_1")
+	series2 := labels.FromStrings("__name__", "series_2")
+	trace := labels.FromStrings("trace_id", "1")
+
+	e := &Exemplars{Series: []ExemplarSeries{
+		{Labels: series1, Exemplars: []Exemplar{
+			{Labels: trace, Value: 1, Timestamp: 10},
+			{Labels: trace, Value: 2, Timestamp: 20},
+			{Labels: trace, Value: 3, Timestamp: 30},
+		}},
+		{Labels: series2, Exemplars: []Exemplar{{Labels: trace, Value: 1, Timestamp: 20}}},
+	}}
+
+	filtered := e.Filter(15, 30, func(lset labels.Labels) bool {
+		return labels.Equal(lset, series1)
+	})
+	require.Equal(t, []ExemplarSeries{
+		{Labels: series1, Exemplars: []Exemplar{
+			{Labels: trace, Value: 2, Timestamp: 20},
+			{Labels: trace, Value: 3, Timestamp: 30},
+		}},
+	}, filtered.Series)
+
+	// Series without exemplars in the time range are removed.
+	require.Empty(t, e.Filter(40, 50, nil).Series)
+}
@@ -65,6 +65,30 @@ func IsNoCompactMarkFilename(name string) (ulid.ULID, bool) {
 	return isMarkFilename(name, NoCompactMarkFilename)
 }
 
+// ColdStorageMarkFilepath returns the path, relative to the tenant's bucket location,
+// of a cold storage block mark in the bucket markers location.
+func ColdStorageMarkFilepath(blockID ulid.ULID) string {
+	return markFilepath(blockID, ColdStorageMarkFilename)
+}
+
+// IsColdStorageMarkFilename returns true if input filename matches the expected
+// pattern of cold storage block marker stored in the markers location.
+func IsColdStorageMarkFilename(name string) (ulid.ULID, bool) {
+	return isMarkFilename(name, ColdStorageMarkFilename)
+}
+
+// QuarantineMarkFilepath returns the path, relative to the tenant's bucket location,
+// of a quarantine block mark in the bucket markers location.
+func QuarantineMarkFilepath(blockID ulid.ULID) string {
+	return markFilepath(blockID, QuarantineMarkFilename)
+}
+
+// IsQuarantineMarkFilename returns true if input filename matches the expected
+// pattern of quarantine block marker stored in the markers location.
+func IsQuarantineMarkFilename(name string) (ulid.ULID, bool) {
+	return isMarkFilename(name, QuarantineMarkFilename)
+}
+
 // ListBlockDeletionMarks looks for block deletion marks in the global markers location
 // and returns a map containing all blocks having a deletion mark and their location in the
 // bucket.
@@ -86,3 +110,45 @@ func ListBlockDeletionMarks(ctx context.Context, bkt objstore.BucketReader) (map
 
 	return discovered, errors.Wrap(err, "list block deletion marks")
 }
+
+// ListBlockColdStorageMarks looks for cold storage block marks in the global markers location
+// and returns a map containing all blocks moved to the cold storage.
+func ListBlockColdStorageMarks(ctx context.Context, bkt objstore.BucketReader) (map[ulid.ULID]struct{}, error) {
+	discovered := map[ulid.ULID]struct{}{}
+
+	// Find all markers in the storage.
+	err := bkt.Iter(ctx, MarkersPathname+"/", func(name string) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if blockID, ok := IsColdStorageMarkFilename(path.Base(name)); ok {
+			discovered[blockID] = struct{}{}
+		}
+
+		return nil
+	})
+
+	return discovered, errors.Wrap(err, "list block cold storage marks")
+}
+
+// ListBlockQuarantineMarks looks for quarantine block marks in the global markers location
+// and returns a map containing all quarantined blocks.
+func ListBlockQuarantineMarks(ctx context.Context, bkt objstore.BucketReader) (map[ulid.ULID]struct{}, error) {
+	discovered := map[ulid.ULID]struct{}{}
+
+	// Find all markers in the storage.
+	err := bkt.Iter(ctx, MarkersPathname+"/", func(name string) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if blockID, ok := IsQuarantineMarkFilename(path.Base(name)); ok {
+			discovered[blockID] = struct{}{}
+		}
+
+		return nil
+	})
+
+	return discovered, errors.Wrap(err, "list block quarantine marks")
+}
@@ -180,6 +180,14 @@ func getGlobalMarkPathFromBlockMark(name string) string {
 		return path.Clean(path.Join(path.Dir(name), "../", NoCompactMarkFilepath(blockID)))
 	}
 
+	if blockID, ok := isColdStorageMark(name); ok {
+		return path.Clean(path.Join(path.Dir(name), "../", ColdStorageMarkFilepath(blockID)))
+	}
+
+	if blockID, ok := isQuarantineMark(name); ok {
+		return path.Clean(path.Join(path.Dir(name), "../", QuarantineMarkFilepath(blockID)))
+	}
+
 	return ""
 }
 
@@ -202,3 +210,23 @@ func isNoCompactMark(name string) (ulid.ULID, bool) {
 	// no-compact mark.
 	return IsBlockDir(path.Dir(name))
 }
+
+func isColdStorageMark(name string) (ulid.ULID, bool) {
+	if path.Base(name) != ColdStorageMarkFilename {
+		return ulid.ULID{}, false
+	}
+
+	// Parse the block ID in the path. If there's no block ID, then it's not the per-block
+	// cold storage mark.
+	return IsBlockDir(path.Dir(name))
+}
+
+func isQuarantineMark(name string) (ulid.ULID, bool) {
+	if path.Base(name) != QuarantineMarkFilename {
+		return ulid.ULID{}, false
+	}
+
+	// Parse the block ID in the path. If there's no block ID, then it's not the per-block
+	// quarantine mark.
+	return IsBlockDir(path.Dir(name))
+}
@@ -26,11 +26,25 @@ const (
 	// NoCompactMarkFilename is the known json filename for optional file storing details about why block has to be excluded from compaction.
 	// If such file is present in block dir, it means the block has to excluded from compaction (both vertical and horizontal) or rewrite (e.g deletions).
 	NoCompactMarkFilename = "no-compact-mark.json"
+	// ColdStorageMarkFilename is the known json filename for optional file storing details about when the block has been moved
+	// to the cold storage. If such file is present in block dir, the block data must be read from the cold storage bucket.
+	ColdStorageMarkFilename = "cold-storage-mark.json"
+	// QuarantineMarkFilename is the known json filename for optional file storing details about why the block has been quarantined.
+	// If such file is present in block dir, the block failed the integrity verification and it's not queried, compacted or rewritten.
+	QuarantineMarkFilename = "quarantine-mark.json"
 
 	// DeletionMarkVersion1 is the version of deletion-mark file supported by Thanos.
 	DeletionMarkVersion1 = 1
 	// NoCompactMarkVersion1 is the version of no-compact-mark file supported by Thanos.
 	NoCompactMarkVersion1 = 1
+	// ColdStorageMarkVersion1 is the version of cold-storage-mark file supported by Mimir.
+	ColdStorageMarkVersion1 = 1
+	// QuarantineMarkVersion1 is the version of quarantine-mark file supported by Mimir.
+	QuarantineMarkVersion1 = 1
+
+	// ColdStorageTier is the storage tier of the blocks moved to the cold storage. The blocks in the blocks storage
+	// bucket have an empty storage tier.
+	ColdStorageTier = "cold"
 )
 
 var (
@@ -95,6 +109,38 @@ type NoCompactMark struct {
 func (n NoCompactMark) BlockULID() ulid.ULID   { return n.ID }
 func (n NoCompactMark) markerFilename() string { return NoCompactMarkFilename }
 
+// ColdStorageMark stores block id and when the block has been moved to the cold storage.
+type ColdStorageMark struct {
+	// ID of the tsdb block.
+	ID ulid.ULID `json:"id"`
+	// Version of the file.
+	Version int `json:"version"`
+	// Details is a human readable string giving details of reason.
+	Details string `json:"details,omitempty"`
+
+	// ColdStorageTime is a unix timestamp of when the block has been moved to the cold storage.
+	ColdStorageTime int64 `json:"cold_storage_time"`
+}
+
+func (c ColdStorageMark) BlockULID() ulid.ULID   { return c.ID }
+func (c ColdStorageMark) markerFilename() string { return ColdStorageMarkFilename }
+
+// QuarantineMark stores block id and why the block has been quarantined.
+type QuarantineMark struct {
+	// ID of the tsdb block.
+	ID ulid.ULID `json:"id"`
+	// Version of the file.
+	Version int `json:"version"`
+	// Details is a human readable string giving details of reason.
+	Details string `json:"details,omitempty"`
+
+	// QuarantineTime is a unix timestamp of when the block has been quarantined.
+	QuarantineTime int64 `json:"quarantine_time"`
+}
+
+func (q QuarantineMark) BlockULID() ulid.ULID   { return q.ID }
+func (q QuarantineMark) markerFilename() string { return QuarantineMarkFilename }
+
 // ReadMarker reads the given mark file from <dir>/<marker filename>.json in bucket.
 // ReadMarker has a one-minute timeout for completing the read against the bucket.
 // This protects against operations that can take unbounded time.
@@ -129,6 +175,14 @@ func ReadMarker(ctx context.Context, logger log.Logger, bkt objstore.Instrumente
 		if version := marker.(*DeletionMark).Version; version != DeletionMarkVersion1 {
 			return errors.Errorf("unexpected deletion-mark file version %d, expected %d", version, DeletionMarkVersion1)
 		}
+	case ColdStorageMarkFilename:
+		if version := marker.(*ColdStorageMark).Version; version != ColdStorageMarkVersion1 {
+			return errors.Errorf("unexpected cold-storage-mark file version %d, expected %d", version, ColdStorageMarkVersion1)
+		}
+	case QuarantineMarkFilename:
+		if version := marker.(*QuarantineMark).Version; version != QuarantineMarkVersion1 {
+			return errors.Errorf("unexpected quarantine-mark file version %d, expected %d", version, QuarantineMarkVersion1)
+		}
 	}
 	return nil
 }
@@ -11,11 +11,13 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"slices"
 
 	"github.com/go-kit/log"
 	"github.com/grafana/dskit/runutil"
 	"github.com/pkg/errors"
 	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
 	"github.com/prometheus/prometheus/promql/parser"
 	"github.com/prometheus/prometheus/tsdb"
 	"github.com/prometheus/prometheus/tsdb/fileutil"
@@ -47,6 +49,9 @@ type Meta struct {
 	tsdb.BlockMeta
 
 	Thanos ThanosMeta `json:"thanos"`
+
+	// Tier is the storage tier of the block, as tracked by the bucket index. It's not stored in meta.json.
+	Tier string `json:"-"`
 }
 
 func (m *Meta) String() string {
@@ -75,6 +80,64 @@ type ThanosMeta struct {
 	// Useful to avoid API call to get size of each file, as well as for debugging purposes.
 	// Optional, added in v0.17.0.
 	Files []File `json:"files,omitempty"`
+
+	// Rewrites are the rewrites applied to the series of the block, in order. The compacted blocks keep the
+	// rewrites applied to all their source blocks.
+	Rewrites []Rewrite `json:"rewrites,omitempty"`
+}
+
+// Rewrite describes a rewrite of the series of a block.
+type Rewrite struct {
+	// RelabelsApplied are the relabel configs applied to the series labels.
+	RelabelsApplied []RelabelConfig `json:"relabels_applied,omitempty"`
+}
+
+// RelabelConfig is the representation of a relabel config stored in the block meta.
+type RelabelConfig struct {
+	SourceLabels []string `json:"source_labels,omitempty"`
+	Separator    string   `json:"separator,omitempty"`
+	Regex        string   `json:"regex,omitempty"`
+	Modulus      uint64   `json:"modulus,omitempty"`
+	TargetLabel  string   `json:"target_label,omitempty"`
+	Replacement  string   `json:"replacement,omitempty"`
+	Action       string   `json:"action,omitempty"`
+}
+
+// NewRelabelConfigs returns the representation of the relabel configs stored in the block meta.
+func NewRelabelConfigs(cfgs []*relabel.Config) []RelabelConfig {
+	result := make([]RelabelConfig, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		var sourceLabels []string
+		for _, l := range cfg.SourceLabels {
+			sourceLabels = append(sourceLabels, string(l))
+		}
+		result = append(result, RelabelConfig{
+			SourceLabels: sourceLabels,
+			Separator:    cfg.Separator,
+			Regex:        cfg.Regex.String(),
+			Modulus:      cfg.Modulus,
+			TargetLabel:  cfg.TargetLabel,
+			Replacement:  cfg.Replacement,
+			Action:       string(cfg.Action),
+		})
+	}
+	return result
+}
+
+// Equal returns whether the rewrites applied the same relabel configs.
+func (r Rewrite) Equal(o Rewrite) bool {
+	return slices.EqualFunc(r.RelabelsApplied, o.RelabelsApplied, RelabelConfig.Equal)
+}
+
+// Equal returns whether the relabel configs are the same.
+func (c RelabelConfig) Equal(o RelabelConfig) bool {
+	return slices.Equal(c.SourceLabels, o.SourceLabels) && c.Separator == o.Separator && c.Regex == o.Regex &&
+		c.Modulus == o.Modulus && c.TargetLabel == o.TargetLabel && c.Replacement == o.Replacement && c.Action == o.Action
+}
+
+// RelabelsApplied returns whether the relabel configs are the last rewrite applied to the series of the block.
+func (m ThanosMeta) RelabelsApplied(cfgs []RelabelConfig) bool {
+	return len(m.Rewrites) > 0 && m.Rewrites[len(m.Rewrites)-1].Equal(Rewrite{RelabelsApplied: cfgs})
 }
 
 type Matchers []*labels.Matcher
@@ -0,0 +1,128 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package block
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/grafana/dskit/runutil"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/thanos-io/objstore"
+)
+
+const (
+	// MetricsMetadataFilename is the known gzipped JSON filename for the metric metadata of the block.
+	MetricsMetadataFilename = "metric_metadata.json.gz"
+
+	// MetricsMetadataVersion1 is the current metric metadata file version.
+	MetricsMetadataVersion1 = 1
+)
+
+// MetricsMetadata holds the metadata of the metrics ingested while the block was in the head. The metric metadata
+// file is optional, and it's stored alongside the index and chunks of the block.
+type MetricsMetadata struct {
+	Version int `json:"version"`
+
+	// Metadata sorted by metric name, type, help and unit.
+	Metadata []MetricMetadata `json:"metadata"`
+}
+
+// MetricMetadata is the metadata of a metric.
+type MetricMetadata struct {
+	Metric string `json:"metric"`
+	Type   string `json:"type"`
+	Help   string `json:"help"`
+	Unit   string `json:"unit"`
+}
+
+func compareMetricMetadata(a, b MetricMetadata) int {
+	if c := strings.Compare(a.Metric, b.Metric); c != 0 {
+		return c
+	}
+	if c := strings.Compare(a.Type, b.Type); c != 0 {
+		return c
+	}
+	if c := strings.Compare(a.Help, b.Help); c != 0 {
+		return c
+	}
+	return strings.Compare(a.Unit, b.Unit)
+}
+
+// WriteMetricsMetadataFile writes the metric metadata file in the block directory. The metadata is sorted and
+// deduplicated before being written.
+func WriteMetricsMetadataFile(dir string, m *MetricsMetadata) (err error) {
+	m.Version = MetricsMetadataVersion1
+	slices.SortFunc(m.Metadata, compareMetricMetadata)
+	m.Metadata = slices.Compact(m.Metadata)
+
+	f, err := os.Create(filepath.Join(dir, MetricsMetadataFilename))
+	if err != nil {
+		return errors.Wrap(err, "create metric metadata file")
+	}
+	defer runutil.CloseWithErrCapture(&err, f, "close metric metadata file")
+
+	gzw := gzip.NewWriter(f)
+	if err := json.NewEncoder(gzw).Encode(m); err != nil {
+		return errors.Wrap(err, "encode metric metadata")
+	}
+	if err := gzw.Close(); err != nil {
+		return errors.Wrap(err, "close gzip writer")
+	}
+	return f.Sync()
+}
+
+// ReadMetricsMetadataFromDir reads the metric metadata file from the block directory. If the block has no
+// metric metadata file, empty metadata is returned.
+func ReadMetricsMetadataFromDir(dir string) (_ *MetricsMetadata, err error) {
+	f, err := os.Open(filepath.Join(dir, MetricsMetadataFilename))
+	if os.IsNotExist(err) {
+		return &MetricsMetadata{Version: MetricsMetadataVersion1}, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "open metric metadata file")
+	}
+	defer runutil.CloseWithErrCapture(&err, f, "close metric metadata file")
+
+	return readMetricsMetadata(f)
+}
+
+// DownloadMetricsMetadata downloads the metric metadata file of the block from the bucket. If the block has no
+// metric metadata file, empty metadata is returned.
+func DownloadMetricsMetadata(ctx context.Context, bkt objstore.BucketReader, id ulid.ULID) (_ *MetricsMetadata, err error) {
+	r, err := bkt.Get(ctx, path.Join(id.String(), MetricsMetadataFilename))
+	if bkt.IsObjNotFoundErr(err) {
+		return &MetricsMetadata{Version: MetricsMetadataVersion1}, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "get metric metadata of block %s", id)
+	}
+	defer runutil.CloseWithErrCapture(&err, r, "close metric metadata reader")
+
+	return readMetricsMetadata(r)
+}
+
+func readMetricsMetadata(r io.Reader) (*MetricsMetadata, error) {
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "create gzip reader")
+	}
+	defer gzr.Close()
+
+	m := &MetricsMetadata{}
+	if err := json.NewDecoder(gzr).Decode(m); err != nil {
+		return nil, errors.Wrap(err, "decode metric metadata")
+	}
+	if m.Version != MetricsMetadataVersion1 {
+		return nil, errors.Errorf("unexpected metric metadata file version %d", m.Version)
+	}
+	return m, nil
+}
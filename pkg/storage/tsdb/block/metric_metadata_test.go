@@ -0,0 +1,53 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package block
+
+import (
+	"context"
+	"path"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+)
+
+func TestMetricsMetadata_WriteAndRead(t *testing.T) {
+	dir := t.TempDir()
+
+	// No metric metadata file.
+	m, err := ReadMetricsMetadataFromDir(dir)
+	require.NoError(t, err)
+	require.Empty(t, m.Metadata)
+
+	require.NoError(t, WriteMetricsMetadataFile(dir, &MetricsMetadata{Metadata: []MetricMetadata{
+		{Metric: "metric_2", Type: "gauge", Help: "Second metric."},
+		{Metric: "metric_1", Type: "counter", Help: "First metric.", Unit: "seconds"},
+		{Metric: "metric_2", Type: "gauge", Help: "Second metric."},
+	}}))
+
+	expected := &MetricsMetadata{Version: MetricsMetadataVersion1, Metadata: []MetricMetadata{
+		{Metric: "metric_1", Type: "counter", Help: "First metric.", Unit: "seconds"},
+		{Metric: "metric_2", Type: "gauge", Help: "Second metric."},
+	}}
+
+	m, err = ReadMetricsMetadataFromDir(dir)
+	require.NoError(t, err)
+	require.Equal(t, expected, m)
+
+	// Upload the metric metadata file as part of a block, and download it.
+	bkt := objstore.NewInMemBucket()
+	id := ulid.MustNew(1, nil)
+	require.NoError(t, objstore.UploadFile(context.Background(), log.NewNopLogger(), bkt, filepath.Join(dir, MetricsMetadataFilename), path.Join(id.String(), MetricsMetadataFilename)))
+
+	m, err = DownloadMetricsMetadata(context.Background(), bkt, id)
+	require.NoError(t, err)
+	require.Equal(t, expected, m)
+
+	// Block without metric metadata file.
+	m, err = DownloadMetricsMetadata(context.Background(), bkt, ulid.MustNew(2, nil))
+	require.NoError(t, err)
+	require.Empty(t, m.Metadata)
+}
@@ -101,6 +101,34 @@ type Block struct {
 
 	// Labels contains the external labels from the block's metadata.
 	Labels map[string]string `json:"labels,omitempty"`
+
+	// Resolution is the downsampling resolution of the block (millis precision), 0 for raw blocks.
+	Resolution int64 `json:"resolution,omitempty"`
+
+	// Tier is the storage tier of the block, empty for the blocks stored in the blocks storage bucket.
+	Tier string `json:"tier,omitempty"`
+
+	// ColdStorageTime is a unix timestamp (seconds precision) of when the block has been moved to the cold storage.
+	ColdStorageTime int64 `json:"cold_storage_time,omitempty"`
+
+	// HotDataDeleted is whether the data of a block moved to the cold storage has been deleted from the blocks storage bucket.
+	HotDataDeleted bool `json:"hot_data_deleted,omitempty"`
+
+	// Quarantined is whether the block failed the integrity verification and has been quarantined.
+	// Quarantined blocks are not queried, compacted or rewritten.
+	Quarantined bool `json:"quarantined,omitempty"`
+
+	// Stats holds the series statistics of the block, nil if they haven't been computed.
+	Stats *BlockStats `json:"stats,omitempty"`
+}
+
+// MayContainMetric returns false if the block definitely doesn't contain any series
+// of the input metric name, true otherwise.
+func (m *Block) MayContainMetric(name string) bool {
+	if m.Stats == nil || m.Stats.MetricNames == nil {
+		return true
+	}
+	return m.Stats.MetricNames.MayContain(name)
 }
 
 // Within returns whether the block contains samples within the provided range.
@@ -114,6 +142,10 @@ func (m *Block) GetUploadedAt() time.Time {
 	return time.Unix(m.UploadedAt, 0)
 }
 
+func (m *Block) GetColdStorageTime() time.Time {
+	return time.Unix(m.ColdStorageTime, 0)
+}
+
 // ThanosMeta returns a block meta based on the known information in the index.
 // The returned meta doesn't include all original meta.json data but only a subset
 // of it.
@@ -139,7 +171,9 @@ func (m *Block) ThanosMeta() *block.Meta {
 			SegmentFiles: m.thanosMetaSegmentFiles(),
 			Source:       block.SourceType(m.Source),
 			Labels:       maps.Clone(m.Labels),
+			Downsample:   block.ThanosDownsample{Resolution: m.Resolution},
 		},
+		Tier: m.Tier,
 	}
 }
 
@@ -162,6 +196,9 @@ func (m *Block) String() string {
 		shard = "none"
 	}
 
+	if m.Resolution > 0 {
+		return fmt.Sprintf("%s (min time: %s max time: %s, compactor shard: %s, resolution: %s)", m.ID, minT.String(), maxT.String(), shard, time.Duration(m.Resolution)*time.Millisecond)
+	}
 	return fmt.Sprintf("%s (min time: %s max time: %s, compactor shard: %s)", m.ID, minT.String(), maxT.String(), shard)
 }
 
@@ -179,6 +216,8 @@ func BlockFromThanosMeta(meta block.Meta) *Block {
 		CompactionLevel:  meta.Compaction.Level,
 		OutOfOrder:       meta.Compaction.FromOutOfOrder(),
 		Labels:           maps.Clone(meta.Thanos.Labels),
+		Resolution:       meta.Thanos.Downsample.Resolution,
+		Tier:             meta.Tier,
 	}
 }
 
@@ -0,0 +1,116 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package bucketindex
+
+import (
+	"encoding/json"
+	"math"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+const (
+	// metricNamesFilterBitsPerName and metricNamesFilterHashes give a ~1% false positive rate.
+	metricNamesFilterBitsPerName = 10
+	metricNamesFilterHashes      = 7
+
+	// metricNamesFilterMaxBytes limits the size of the metric names filter of a block, because the filter
+	// is stored in the bucket index, which is downloaded by every querier, store-gateway and ruler. The
+	// false positive rate of the filter grows for blocks with more than ~3.3K metric names.
+	metricNamesFilterMaxBytes = 4 * 1024
+)
+
+// BlockStats holds the series statistics of a block.
+type BlockStats struct {
+	// NumSeries is the number of series in the block.
+	NumSeries uint64 `json:"num_series"`
+
+	// NumMetricNames is the number of distinct metric names in the block.
+	NumMetricNames uint64 `json:"num_metric_names"`
+
+	// MetricNames is a bloom filter of the metric names in the block.
+	MetricNames *BloomFilter `json:"metric_names,omitempty"`
+
+	// Labels holds the stats of the label names with the most distinct values in the block,
+	// sorted by number of distinct values, highest first.
+	Labels []LabelStats `json:"labels,omitempty"`
+}
+
+// Label returns the stats of the input label name, or nil if the label name is not one of
+// the top label names of the block.
+func (s *BlockStats) Label(name string) *LabelStats {
+	for i := range s.Labels {
+		if s.Labels[i].Name == name {
+			return &s.Labels[i]
+		}
+	}
+	return nil
+}
+
+// LabelStats holds the stats of a label name in a block.
+type LabelStats struct {
+	Name string `json:"name"`
+
+	// NumValues is the number of distinct values of the label in the block.
+	NumValues uint64 `json:"num_values"`
+}
+
+// encodedSize returns the size of the stats once encoded in the bucket index, before compression.
+func (s *BlockStats) encodedSize() int {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return 0
+	}
+	return len(data)
+}
+
+// BloomFilter is a probabilistic set of strings: MayContain never returns false
+// for a string added to the filter, but may return true for a string which wasn't.
+type BloomFilter struct {
+	Bits   []byte `json:"bits"`
+	Hashes int    `json:"hashes"`
+}
+
+// NewBloomFilter returns a filter sized for the input number of entries.
+func NewBloomFilter(entries int) *BloomFilter {
+	size := min(max((entries*metricNamesFilterBitsPerName+7)/8, 1), metricNamesFilterMaxBytes)
+	return &BloomFilter{
+		Bits:   make([]byte, size),
+		Hashes: metricNamesFilterHashes,
+	}
+}
+
+// Add adds the value to the filter.
+func (f *BloomFilter) Add(value string) {
+	f.forEachBit(value, func(i uint64) bool {
+		f.Bits[i/8] |= 1 << (i % 8)
+		return true
+	})
+}
+
+// MayContain returns false if the value has definitely not been added to the filter.
+func (f *BloomFilter) MayContain(value string) bool {
+	if len(f.Bits) == 0 {
+		return true
+	}
+
+	found := true
+	f.forEachBit(value, func(i uint64) bool {
+		found = f.Bits[i/8]&(1<<(i%8)) != 0
+		return found
+	})
+	return found
+}
+
+// forEachBit calls fn for each bit of the value, until fn returns false.
+// The bits are computed by double hashing of a single 64-bit hash.
+func (f *BloomFilter) forEachBit(value string, fn func(i uint64) bool) {
+	h := xxhash.Sum64String(value)
+	h1, h2 := h&math.MaxUint32, h>>32
+	size := uint64(len(f.Bits)) * 8
+	for i := 0; i < f.Hashes; i++ {
+		if !fn((h1 + uint64(i)*h2) % size) {
+			return
+		}
+	}
+}
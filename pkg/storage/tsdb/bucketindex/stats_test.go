@@ -0,0 +1,52 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package bucketindex
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBloomFilter(t *testing.T) {
+	const entries = 1000
+
+	f := NewBloomFilter(entries)
+	for i := 0; i < entries; i++ {
+		f.Add(fmt.Sprintf("metric_%d", i))
+	}
+
+	// Round trip the filter through JSON, as it is stored in the bucket index.
+	data, err := json.Marshal(f)
+	require.NoError(t, err)
+	f = &BloomFilter{}
+	require.NoError(t, json.Unmarshal(data, f))
+
+	for i := 0; i < entries; i++ {
+		require.True(t, f.MayContain(fmt.Sprintf("metric_%d", i)))
+	}
+
+	falsePositives := 0
+	for i := 0; i < entries; i++ {
+		if f.MayContain(fmt.Sprintf("other_metric_%d", i)) {
+			falsePositives++
+		}
+	}
+	assert.Less(t, falsePositives, entries*5/100)
+
+	// An empty filter may contain anything.
+	assert.True(t, (&BloomFilter{}).MayContain("metric_0"))
+}
+
+func TestBlock_MayContainMetric(t *testing.T) {
+	f := NewBloomFilter(1)
+	f.Add("metric_1")
+
+	assert.True(t, (&Block{}).MayContainMetric("metric_2"))
+	assert.True(t, (&Block{Stats: &BlockStats{}}).MayContainMetric("metric_2"))
+	assert.True(t, (&Block{Stats: &BlockStats{MetricNames: f}}).MayContainMetric("metric_1"))
+	assert.False(t, (&Block{Stats: &BlockStats{MetricNames: f}}).MayContainMetric("metric_2"))
+}
@@ -6,10 +6,12 @@
 package bucketindex
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"io"
 	"path"
+	"slices"
 	"time"
 
 	"github.com/go-kit/log"
@@ -19,6 +21,7 @@ import (
 	"github.com/oklog/ulid"
 	"github.com/pkg/errors"
 	"github.com/thanos-io/objstore"
+	"go.uber.org/atomic"
 
 	"github.com/grafana/mimir/pkg/storage/bucket"
 	"github.com/grafana/mimir/pkg/storage/tsdb/block"
@@ -36,8 +39,16 @@ type Updater struct {
 	bkt                           objstore.InstrumentedBucket
 	logger                        log.Logger
 	getDeletionMarkersConcurrency int
+
+	// Computes the series statistics of a block, nil if the block stats are disabled.
+	blockStats BlockStatsFunc
+	// Maximum total size of the stats of all the blocks in the index, 0 for no limit.
+	blockStatsMaxTotalBytes int
 }
 
+// BlockStatsFunc computes the series statistics of the block from the tenant bucket.
+type BlockStatsFunc func(ctx context.Context, userBkt objstore.InstrumentedBucket, id ulid.ULID) (*BlockStats, error)
+
 func NewUpdater(bkt objstore.Bucket, userID string, cfgProvider bucket.TenantConfigProvider, getDeletionMarkersConcurrency int, logger log.Logger) *Updater {
 	return &Updater{
 		bkt:                           bucket.NewUserBucketClient(userID, bkt, cfgProvider),
@@ -46,6 +57,14 @@ func NewUpdater(bkt objstore.Bucket, userID string, cfgProvider bucket.TenantCon
 	}
 }
 
+// WithBlockStats enables the computation of the series statistics of the blocks missing them, as long as the
+// total size of the stats in the index is lower than maxTotalBytes (0 for no limit).
+func (w *Updater) WithBlockStats(fn BlockStatsFunc, maxTotalBytes int) *Updater {
+	w.blockStats = fn
+	w.blockStatsMaxTotalBytes = maxTotalBytes
+	return w
+}
+
 // UpdateIndex generates the bucket index and returns it, without storing it to the storage.
 // If the old index is not passed in input, then the bucket index will be generated from scratch.
 func (w *Updater) UpdateIndex(ctx context.Context, old *Index) (*Index, map[ulid.ULID]error, error) {
@@ -83,6 +102,20 @@ func (w *Updater) UpdateIndex(ctx context.Context, old *Index) (*Index, map[ulid
 		return nil, nil, err
 	}
 
+	if err := w.updateBlocksTier(ctx, blocks); err != nil {
+		return nil, nil, err
+	}
+
+	if err := w.updateBlocksQuarantine(ctx, blocks); err != nil {
+		return nil, nil, err
+	}
+
+	if w.blockStats != nil {
+		if err := w.updateBlocksStats(ctx, blocks, blockDeletionMarks); err != nil {
+			return nil, nil, err
+		}
+	}
+
 	return &Index{
 		Version:            IndexVersion2,
 		Blocks:             blocks,
@@ -192,6 +225,156 @@ func (w *Updater) updateBlockIndexEntry(ctx context.Context, id ulid.ULID) (*Blo
 	return block, nil
 }
 
+// updateBlocksTier updates the storage tier of the blocks, based on the cold storage marks in the storage.
+func (w *Updater) updateBlocksTier(ctx context.Context, blocks []*Block) error {
+	marked, err := block.ListBlockColdStorageMarks(ctx, w.bkt)
+	if err != nil {
+		return err
+	}
+
+	// The cold storage marks of the blocks already in the cold storage can be skipped, because their
+	// tier has been copied from the old index.
+	var discovered []int
+	for i, b := range blocks {
+		_, cold := marked[b.ID]
+		if cold && b.Tier != block.ColdStorageTier {
+			discovered = append(discovered, i)
+		} else if !cold && b.Tier != "" {
+			// This should never happen, because blocks are never moved back from the cold storage.
+			level.Warn(w.logger).Log("msg", "block has been moved back from the cold storage", "block", b.ID.String())
+			updated := *b
+			updated.Tier, updated.ColdStorageTime, updated.HotDataDeleted = "", 0, false
+			blocks[i] = &updated
+		}
+	}
+
+	err = concurrency.ForEachJob(ctx, len(discovered), w.getDeletionMarkersConcurrency, func(ctx context.Context, idx int) error {
+		i := discovered[idx]
+		b := blocks[i]
+
+		m := block.ColdStorageMark{}
+		if err := block.ReadMarker(ctx, w.logger, w.bkt, b.ID.String(), &m); err != nil {
+			if errors.Is(err, block.ErrorMarkerNotFound) || errors.Is(err, block.ErrorUnmarshalMarker) {
+				// The block is kept in the blocks storage tier until its cold storage mark can be read.
+				level.Warn(w.logger).Log("msg", "skipped missing or corrupted block cold storage mark when updating bucket index", "block", b.ID.String(), "err", err)
+				return nil
+			}
+			return err
+		}
+
+		// The blocks of the old index are copied, so they're never modified in place.
+		updated := *b
+		updated.Tier = block.ColdStorageTier
+		updated.ColdStorageTime = m.ColdStorageTime
+
+		blocks[i] = &updated
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	level.Info(w.logger).Log("msg", "updated blocks storage tier", "recently_moved_to_cold_storage", len(discovered), "total_cold_storage_markers", len(marked))
+	return nil
+}
+
+// updateBlocksQuarantine updates whether the blocks are quarantined, based on the quarantine marks in the storage.
+func (w *Updater) updateBlocksQuarantine(ctx context.Context, blocks []*Block) error {
+	marked, err := block.ListBlockQuarantineMarks(ctx, w.bkt)
+	if err != nil {
+		return err
+	}
+
+	for i, b := range blocks {
+		if _, quarantined := marked[b.ID]; quarantined != b.Quarantined {
+			// The blocks of the old index are copied, so they're never modified in place.
+			updated := *b
+			updated.Quarantined = quarantined
+			blocks[i] = &updated
+		}
+	}
+
+	if len(marked) > 0 {
+		level.Info(w.logger).Log("msg", "updated blocks quarantine", "total_quarantine_markers", len(marked))
+	}
+	return nil
+}
+
+// updateBlocksStats computes the series statistics of the blocks missing them. The stats of a block
+// are computed only once, because blocks are immutable. Once the total size of the stats reaches the
+// limit, the stats of the remaining blocks are not computed until older blocks are deleted.
+func (w *Updater) updateBlocksStats(ctx context.Context, blocks []*Block, deletionMarks []*BlockDeletionMark) error {
+	marked := make(map[ulid.ULID]struct{}, len(deletionMarks))
+	for _, m := range deletionMarks {
+		marked[m.ID] = struct{}{}
+	}
+
+	// Blocks marked for deletion are skipped because they will be deleted soon, and blocks in the cold storage
+	// or quarantined are skipped because their data may not be available in the blocks storage bucket.
+	var missing []int
+	totalBytes := atomic.NewInt64(0)
+	for i, b := range blocks {
+		if b.Stats != nil {
+			totalBytes.Add(int64(b.Stats.encodedSize()))
+		} else if _, deleted := marked[b.ID]; !deleted && b.Tier == "" && !b.Quarantined {
+			missing = append(missing, i)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	// The most recent blocks are the most queried ones, so their stats are computed first.
+	slices.SortFunc(missing, func(a, b int) int {
+		return cmp.Compare(blocks[b].MaxTime, blocks[a].MaxTime)
+	})
+
+	failed := atomic.NewInt64(0)
+	skipped := atomic.NewInt64(0)
+	maxTotalBytes := int64(w.blockStatsMaxTotalBytes)
+
+	err := concurrency.ForEachJob(ctx, len(missing), w.getDeletionMarkersConcurrency, func(ctx context.Context, idx int) error {
+		i := missing[idx]
+		b := blocks[i]
+
+		if maxTotalBytes > 0 && totalBytes.Load() >= maxTotalBytes {
+			skipped.Inc()
+			return nil
+		}
+
+		stats, err := w.blockStats(ctx, w.bkt, b.ID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			// The stats are best effort: they're computed again at the next update.
+			level.Warn(w.logger).Log("msg", "failed to compute block stats when updating bucket index", "block", b.ID.String(), "err", err)
+			failed.Inc()
+			return nil
+		}
+
+		if size := int64(stats.encodedSize()); maxTotalBytes > 0 && totalBytes.Add(size) > maxTotalBytes {
+			totalBytes.Sub(size)
+			skipped.Inc()
+			return nil
+		}
+
+		// The blocks of the old index are copied, so they're never modified in place.
+		updated := *b
+		updated.Stats = stats
+		blocks[i] = &updated
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	computed := len(missing) - int(failed.Load()) - int(skipped.Load())
+	level.Info(w.logger).Log("msg", "updated blocks stats", "computed", computed, "failed", failed.Load(), "skipped", skipped.Load(), "total_bytes", totalBytes.Load())
+	return nil
+}
+
 func (w *Updater) updateBlockDeletionMarks(ctx context.Context, old []*BlockDeletionMark) ([]*BlockDeletionMark, error) {
 	out := make([]*BlockDeletionMark, 0, len(old))
 
@@ -16,9 +16,12 @@ import (
 	"github.com/go-kit/log"
 	"github.com/oklog/ulid"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/thanos-io/objstore"
+	"go.uber.org/atomic"
 
 	"github.com/grafana/mimir/pkg/storage/bucket"
 	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
@@ -157,6 +160,204 @@ func TestUpdater_UpdateIndex_ShouldSkipCorruptedDeletionMarks(t *testing.T) {
 	assert.Empty(t, partials)
 }
 
+func TestUpdater_UpdateIndex_ShouldTrackBlocksTier(t *testing.T) {
+	const userID = "user-1"
+
+	bkt, _ := testutil.PrepareFilesystemBucket(t)
+
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+
+	// Mock some blocks in the storage.
+	bkt = block.BucketWithGlobalMarkers(bkt)
+	userBkt := bucket.NewUserBucketClient(userID, bkt, nil)
+	block1 := block.MockStorageBlockWithExtLabels(t, bkt, userID, 10, 20, nil)
+	block2 := block.MockStorageBlockWithExtLabels(t, bkt, userID, 20, 30, nil)
+	block3 := block.MockStorageBlockWithExtLabels(t, bkt, userID, 30, 40, nil)
+	require.NoError(t, block.MarkForColdStorage(ctx, logger, userBkt, block1.ULID, "", promauto.With(nil).NewCounter(prometheus.CounterOpts{})))
+
+	// Overwrite a block's cold storage mark with invalid data.
+	require.NoError(t, block.MarkForColdStorage(ctx, logger, userBkt, block3.ULID, "", promauto.With(nil).NewCounter(prometheus.CounterOpts{})))
+	require.NoError(t, bkt.Upload(ctx, path.Join(userID, block3.ULID.String(), block.ColdStorageMarkFilename), bytes.NewReader([]byte("invalid!}"))))
+
+	w := NewUpdater(bkt, userID, nil, 16, logger)
+	idx, _, err := w.UpdateIndex(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, idx.Blocks, 3)
+
+	tiers := map[ulid.ULID]string{}
+	for _, b := range idx.Blocks {
+		tiers[b.ID] = b.Tier
+		if b.Tier == block.ColdStorageTier {
+			assert.InDelta(t, time.Now().Unix(), b.ColdStorageTime, 2)
+		}
+	}
+	assert.Equal(t, map[ulid.ULID]string{block1.ULID: block.ColdStorageTier, block2.ULID: "", block3.ULID: ""}, tiers)
+
+	// The tier is preserved from the old index, and reset if the cold storage mark has been removed.
+	require.NoError(t, block.MarkForColdStorage(ctx, logger, userBkt, block2.ULID, "", promauto.With(nil).NewCounter(prometheus.CounterOpts{})))
+	require.NoError(t, userBkt.Delete(ctx, path.Join(block1.ULID.String(), block.ColdStorageMarkFilename)))
+
+	idx, _, err = w.UpdateIndex(ctx, idx)
+	require.NoError(t, err)
+
+	tiers = map[ulid.ULID]string{}
+	for _, b := range idx.Blocks {
+		tiers[b.ID] = b.Tier
+	}
+	assert.Equal(t, map[ulid.ULID]string{block1.ULID: "", block2.ULID: block.ColdStorageTier, block3.ULID: ""}, tiers)
+}
+
+func TestUpdater_UpdateIndex_ShouldTrackQuarantinedBlocks(t *testing.T) {
+	const userID = "user-1"
+
+	bkt, _ := testutil.PrepareFilesystemBucket(t)
+
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+
+	// Mock some blocks in the storage.
+	bkt = block.BucketWithGlobalMarkers(bkt)
+	userBkt := bucket.NewUserBucketClient(userID, bkt, nil)
+	block1 := block.MockStorageBlockWithExtLabels(t, bkt, userID, 10, 20, nil)
+	block2 := block.MockStorageBlockWithExtLabels(t, bkt, userID, 20, 30, nil)
+	require.NoError(t, block.MarkForQuarantine(ctx, logger, userBkt, block1.ULID, "", promauto.With(nil).NewCounter(prometheus.CounterOpts{})))
+
+	w := NewUpdater(bkt, userID, nil, 16, logger)
+	idx, _, err := w.UpdateIndex(ctx, nil)
+	require.NoError(t, err)
+
+	quarantined := map[ulid.ULID]bool{}
+	for _, b := range idx.Blocks {
+		quarantined[b.ID] = b.Quarantined
+	}
+	assert.Equal(t, map[ulid.ULID]bool{block1.ULID: true, block2.ULID: false}, quarantined)
+
+	// The old index blocks are not modified in place, and the quarantine is reset if the mark has been removed.
+	oldIdx := idx
+	require.NoError(t, block.MarkForQuarantine(ctx, logger, userBkt, block2.ULID, "", promauto.With(nil).NewCounter(prometheus.CounterOpts{})))
+	require.NoError(t, userBkt.Delete(ctx, path.Join(block1.ULID.String(), block.QuarantineMarkFilename)))
+
+	idx, _, err = w.UpdateIndex(ctx, oldIdx)
+	require.NoError(t, err)
+
+	quarantined = map[ulid.ULID]bool{}
+	for _, b := range idx.Blocks {
+		quarantined[b.ID] = b.Quarantined
+	}
+	assert.Equal(t, map[ulid.ULID]bool{block1.ULID: false, block2.ULID: true}, quarantined)
+
+	for _, b := range oldIdx.Blocks {
+		assert.Equal(t, b.ID == block1.ULID, b.Quarantined)
+	}
+}
+
+func TestUpdater_UpdateIndex_ShouldTrackBlocksStats(t *testing.T) {
+	const userID = "user-1"
+
+	bkt, _ := testutil.PrepareFilesystemBucket(t)
+
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+
+	bkt = block.BucketWithGlobalMarkers(bkt)
+	block1 := block.MockStorageBlockWithExtLabels(t, bkt, userID, 10, 20, nil)
+	block2 := block.MockStorageBlockWithExtLabels(t, bkt, userID, 20, 30, nil)
+	block3 := block.MockStorageBlockWithExtLabels(t, bkt, userID, 30, 40, nil)
+	block.MockStorageDeletionMark(t, bkt, userID, block3.BlockMeta)
+
+	// The stats are not computed if disabled.
+	idx, _, err := NewUpdater(bkt, userID, nil, 16, logger).UpdateIndex(ctx, nil)
+	require.NoError(t, err)
+	for _, b := range idx.Blocks {
+		assert.Nil(t, b.Stats)
+	}
+
+	computed := atomic.NewInt64(0)
+	w := NewUpdater(bkt, userID, nil, 16, logger).WithBlockStats(func(_ context.Context, _ objstore.InstrumentedBucket, id ulid.ULID) (*BlockStats, error) {
+		computed.Inc()
+		if id == block2.ULID {
+			return nil, errors.New("failed to read block")
+		}
+		return &BlockStats{NumSeries: 1}, nil
+	}, 0)
+	idx, _, err = w.UpdateIndex(ctx, idx)
+	require.NoError(t, err)
+
+	stats := map[ulid.ULID]*BlockStats{}
+	for _, b := range idx.Blocks {
+		stats[b.ID] = b.Stats
+	}
+	require.Len(t, stats, 3)
+
+	// The stats of the blocks marked for deletion are not computed, and the failures are skipped.
+	assert.Equal(t, int64(2), computed.Load())
+	assert.Equal(t, &BlockStats{NumSeries: 1}, stats[block1.ULID])
+	assert.Nil(t, stats[block2.ULID])
+	assert.Nil(t, stats[block3.ULID])
+
+	// The stats of the old index blocks are kept, and the missing ones are computed again.
+	idx, _, err = w.UpdateIndex(ctx, idx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), computed.Load())
+	for _, b := range idx.Blocks {
+		if b.ID == block1.ULID {
+			assert.Same(t, stats[block1.ULID], b.Stats)
+		}
+	}
+}
+
+func TestUpdater_UpdateIndex_ShouldLimitTheTotalSizeOfBlocksStats(t *testing.T) {
+	const userID = "user-1"
+
+	bkt, _ := testutil.PrepareFilesystemBucket(t)
+
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+
+	block1 := block.MockStorageBlockWithExtLabels(t, bkt, userID, 10, 20, nil)
+	block2 := block.MockStorageBlockWithExtLabels(t, bkt, userID, 20, 30, nil)
+	block3 := block.MockStorageBlockWithExtLabels(t, bkt, userID, 30, 40, nil)
+
+	stats := &BlockStats{NumSeries: 1}
+	computed := atomic.NewInt64(0)
+	blockStats := func(context.Context, objstore.InstrumentedBucket, ulid.ULID) (*BlockStats, error) {
+		computed.Inc()
+		return stats, nil
+	}
+
+	// The limit fits the stats of two blocks: the stats of the most recent blocks are computed first,
+	// and the stats of the remaining blocks aren't computed once the limit is reached.
+	w := NewUpdater(bkt, userID, nil, 1, logger).WithBlockStats(blockStats, 2*stats.encodedSize())
+	idx, _, err := w.UpdateIndex(ctx, nil)
+	require.NoError(t, err)
+
+	withStats := func(idx *Index) []ulid.ULID {
+		var ids []ulid.ULID
+		for _, b := range idx.Blocks {
+			if b.Stats != nil {
+				ids = append(ids, b.ID)
+			}
+		}
+		return ids
+	}
+	assert.ElementsMatch(t, []ulid.ULID{block2.ULID, block3.ULID}, withStats(idx))
+	assert.Equal(t, int64(2), computed.Load())
+
+	// The stats aren't computed while the limit is reached.
+	idx, _, err = w.UpdateIndex(ctx, idx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []ulid.ULID{block2.ULID, block3.ULID}, withStats(idx))
+	assert.Equal(t, int64(2), computed.Load())
+
+	// Once a block is deleted, the stats of the remaining block are computed.
+	require.NoError(t, block.Delete(ctx, logger, bucket.NewUserBucketClient(userID, bkt, nil), block3.ULID))
+	idx, _, err = w.UpdateIndex(ctx, idx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []ulid.ULID{block1.ULID, block2.ULID}, withStats(idx))
+	assert.Equal(t, int64(3), computed.Load())
+}
+
 func TestUpdater_UpdateIndex_NoTenantInTheBucket(t *testing.T) {
 	const userID = "user-1"
 
@@ -21,6 +21,7 @@ import (
 	"github.com/grafana/mimir/pkg/ingester/activeseries"
 	"github.com/grafana/mimir/pkg/storage/bucket"
 	"github.com/grafana/mimir/pkg/storegateway/indexheader"
+	"github.com/grafana/mimir/pkg/util/configdoc"
 )
 
 const (
@@ -51,6 +52,10 @@ const (
 	// OutOfOrderExternalLabelValue is the value to be used for the OutOfOrderExternalLabel label
 	OutOfOrderExternalLabelValue = "true"
 
+	// HeadSnapshotsPrefix is the prefix of the TSDB head snapshots uploaded by ingesters, both in the tenant's
+	// bucket and under the Mimir internals prefix.
+	HeadSnapshotsPrefix = "ingester-head-snapshots"
+
 	// DefaultCloseIdleTSDBInterval is how often are open TSDBs checked for being idle and closed.
 	DefaultCloseIdleTSDBInterval = 5 * time.Minute
 
@@ -128,11 +133,17 @@ var (
 	errInvalidCompactionConcurrency                 = errors.New("invalid TSDB compaction concurrency")
 	errInvalidWALSegmentSizeBytes                   = errors.New("invalid TSDB WAL segment size bytes")
 	errInvalidWALReplayConcurrency                  = errors.New("invalid TSDB WAL replay concurrency")
+	errInvalidHeadSnapshotUploadInterval            = errors.New("invalid TSDB head snapshot upload interval")
 	errInvalidStripeSize                            = errors.New("invalid TSDB stripe size")
 	errInvalidStreamingBatchSize                    = errors.New("invalid store-gateway streaming batch size")
 	errInvalidEarlyHeadCompactionMinSeriesReduction = errors.New("early compaction minimum series reduction percentage must be a value between 0 and 100 (included)")
+	errInvalidEarlyHeadCompactionMinMemoryReduction = errors.New("early compaction minimum memory reduction percentage must be a value between 0 and 100 (included)")
 	errEarlyCompactionRequiresActiveSeries          = fmt.Errorf("early compaction requires -%s to be enabled", activeseries.EnabledFlag)
 	errEmptyBlockranges                             = errors.New("empty block ranges for TSDB")
+	errInvalidSeriesDeletionSyncInterval            = errors.New("invalid series deletion sync interval, must be greater than 0")
+	errInvalidMetricsUsageFlushInterval             = errors.New("invalid metrics usage flush interval, must be greater than 0")
+	errInvalidBlockStatsMaxLabelNames               = errors.New("invalid bucket index block stats max label names, must be greater than 0")
+	errInvalidBlockStatsMaxTotalSizeBytes           = errors.New("invalid bucket index block stats max total size, must be greater than or equal to 0")
 	errInvalidIgnoreDeletionMarksDelayConfig        = fmt.Errorf("value for -%s must be less than -%s", ignoreDeletionMarksWhileQueryingDelayFlag, ignoreDeletionMarksInStoreGatewayDelayFlag)
 	errIgnoreDeletionMarksDelayTooShort             = fmt.Errorf("value for -%s must be greater than %v× -%s to ensure that newly compacted blocks are queried before old blocks are ignored", ignoreDeletionMarksWhileQueryingDelayFlag, NewBlockDiscoveryDelayMultiplier, syncIntervalFlag)
 )
@@ -142,6 +153,51 @@ type BlocksStorageConfig struct {
 	Bucket      bucket.Config     `yaml:",inline"`
 	BucketStore BucketStoreConfig `yaml:"bucket_store" doc:"description=This configures how the querier and store-gateway discover and synchronize blocks stored in the bucket."`
 	TSDB        TSDBConfig        `yaml:"tsdb"`
+
+	SeriesDeletionEnabled              bool          `yaml:"series_deletion_enabled" category:"experimental"`
+	SeriesDeletionSyncInterval         time.Duration `yaml:"series_deletion_sync_interval" category:"experimental"`
+	SeriesDeletionProcessedRequestsTTL time.Duration `yaml:"series_deletion_processed_requests_ttl" category:"experimental"`
+
+	LongTermExemplarsEnabled bool `yaml:"long_term_exemplars_enabled" category:"experimental"`
+
+	DurableMetricsMetadataEnabled bool `yaml:"durable_metrics_metadata_enabled" category:"experimental"`
+
+	MetricsUsageTrackingEnabled bool          `yaml:"metrics_usage_tracking_enabled" category:"experimental"`
+	MetricsUsageFlushInterval   time.Duration `yaml:"metrics_usage_flush_interval" category:"experimental"`
+	MetricsUsageRetentionPeriod time.Duration `yaml:"metrics_usage_retention_period" category:"experimental"`
+
+	ColdStorage ColdStorageConfig `yaml:"cold_storage" doc:"description=This configures the cold storage where the compactor moves the blocks older than the tenant's -compactor.cold-storage-after, and from which the store-gateway reads them."`
+}
+
+// ColdStorageConfig holds the config information for the cold storage of the blocks.
+type ColdStorageConfig struct {
+	Enabled        bool          `yaml:"enabled" category:"experimental"`
+	RewriteInPlace bool          `yaml:"rewrite_in_place" category:"experimental"`
+	Bucket         bucket.Config `yaml:",inline"`
+}
+
+// RegisterFlags registers the cold storage flags.
+func (cfg *ColdStorageConfig) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "blocks-storage.cold-storage.enabled", false, "True to enable the cold storage. The compactor moves the blocks older than the tenant's -compactor.cold-storage-after to the cold storage bucket, and the store-gateway lazy loads them from it. Blocks in the cold storage are not compacted, downsampled or rewritten anymore.")
+	f.BoolVar(&cfg.RewriteInPlace, "blocks-storage.cold-storage.rewrite-in-place", false, "True to rewrite the objects of the blocks in place, instead of moving them to the cold storage bucket. Enable it when the cold storage bucket is configured as the same location as the blocks storage bucket, with a cheaper storage class.")
+	cfg.Bucket.RegisterFlagsWithPrefixAndDefaultDirectory("blocks-storage.cold-storage.", "cold-blocks", f)
+
+	// The bucket config is shared with the other storages, so its fields can't be tagged as experimental.
+	overrides := map[string]configdoc.Category{}
+	f.VisitAll(func(fl *flag.Flag) {
+		if strings.HasPrefix(fl.Name, "blocks-storage.cold-storage.") {
+			overrides[fl.Name] = configdoc.Experimental
+		}
+	})
+	configdoc.AddCategoryOverrides(overrides)
+}
+
+// Validate the config.
+func (cfg *ColdStorageConfig) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	return cfg.Bucket.Validate()
 }
 
 // DurationList is the block ranges for a tsdb
@@ -186,6 +242,16 @@ func (cfg *BlocksStorageConfig) RegisterFlags(f *flag.FlagSet) {
 	cfg.Bucket.RegisterFlagsWithPrefixAndDefaultDirectory("blocks-storage.", "blocks", f)
 	cfg.BucketStore.RegisterFlags(f)
 	cfg.TSDB.RegisterFlags(f)
+
+	f.BoolVar(&cfg.SeriesDeletionEnabled, "blocks-storage.series-deletion-enabled", false, "True to enable the series deletion API. Series deletion requests are stored in the bucket, applied by ingesters as head tombstones, filtered out at query time by queriers and store-gateways, and permanently removed from blocks by the compactor.")
+	f.DurationVar(&cfg.SeriesDeletionSyncInterval, "blocks-storage.series-deletion-sync-interval", time.Minute, "How frequently ingesters, queriers and store-gateways load the series deletion requests from the bucket.")
+	f.DurationVar(&cfg.SeriesDeletionProcessedRequestsTTL, "blocks-storage.series-deletion-processed-requests-ttl", 7*24*time.Hour, "How long the series deletion requests are kept in the bucket after the compactor has finished rewriting the blocks, so that their status can still be checked. 0 to keep them forever.")
+	f.BoolVar(&cfg.LongTermExemplarsEnabled, "blocks-storage.long-term-exemplars-enabled", false, "True to store exemplars in the blocks shipped by ingesters, merge them in the compactor, and query them from store-gateways, so that exemplars are available for the whole blocks retention.")
+	f.BoolVar(&cfg.DurableMetricsMetadataEnabled, "blocks-storage.durable-metrics-metadata-enabled", false, "True to store the metric metadata in the blocks shipped by ingesters, merge it into a per-tenant metadata index in the compactor, and query it from queriers, so that the metadata of metrics which are no longer ingested is still available.")
+	f.BoolVar(&cfg.MetricsUsageTrackingEnabled, "blocks-storage.metrics-usage-tracking-enabled", false, "True to track the last time each metric name has been queried in ingesters and store-gateways, and store it in the bucket, so that unused metrics can be listed through the unused metrics cardinality API.")
+	f.DurationVar(&cfg.MetricsUsageFlushInterval, "blocks-storage.metrics-usage-flush-interval", 5*time.Minute, "How frequently ingesters and store-gateways store the tracked metrics usage in the bucket.")
+	f.DurationVar(&cfg.MetricsUsageRetentionPeriod, "blocks-storage.metrics-usage-retention-period", 90*24*time.Hour, "How long ingesters and store-gateways keep the last query time of metrics which are no longer queried. Metrics not queried for longer are listed as unused without a last query time. 0 to keep them forever.")
+	cfg.ColdStorage.RegisterFlags(f)
 }
 
 // Validate the config.
@@ -194,6 +260,18 @@ func (cfg *BlocksStorageConfig) Validate(activeSeriesCfg activeseries.Config) er
 		return err
 	}
 
+	if cfg.SeriesDeletionEnabled && cfg.SeriesDeletionSyncInterval <= 0 {
+		return errInvalidSeriesDeletionSyncInterval
+	}
+
+	if cfg.MetricsUsageTrackingEnabled && cfg.MetricsUsageFlushInterval <= 0 {
+		return errInvalidMetricsUsageFlushInterval
+	}
+
+	if err := cfg.ColdStorage.Validate(); err != nil {
+		return errors.Wrap(err, "invalid cold storage config")
+	}
+
 	if err := cfg.TSDB.Validate(activeSeriesCfg); err != nil {
 		return err
 	}
@@ -205,24 +283,26 @@ func (cfg *BlocksStorageConfig) Validate(activeSeriesCfg activeseries.Config) er
 //
 //nolint:revive
 type TSDBConfig struct {
-	Dir                       string        `yaml:"dir"`
-	BlockRanges               DurationList  `yaml:"block_ranges_period" category:"experimental" doc:"hidden"`
-	Retention                 time.Duration `yaml:"retention_period"`
-	ShipInterval              time.Duration `yaml:"ship_interval" category:"advanced"`
-	ShipConcurrency           int           `yaml:"ship_concurrency" category:"advanced"`
-	HeadCompactionInterval    time.Duration `yaml:"head_compaction_interval" category:"advanced"`
-	HeadCompactionConcurrency int           `yaml:"head_compaction_concurrency" category:"advanced"`
-	HeadCompactionIdleTimeout time.Duration `yaml:"head_compaction_idle_timeout" category:"advanced"`
-	HeadChunksWriteBufferSize int           `yaml:"head_chunks_write_buffer_size_bytes" category:"advanced"`
-	HeadChunksEndTimeVariance float64       `yaml:"head_chunks_end_time_variance" category:"experimental"`
-	StripeSize                int           `yaml:"stripe_size" category:"advanced"`
-	WALCompressionEnabled     bool          `yaml:"wal_compression_enabled" category:"advanced"`
-	WALSegmentSizeBytes       int           `yaml:"wal_segment_size_bytes" category:"advanced"`
-	WALReplayConcurrency      int           `yaml:"wal_replay_concurrency" category:"advanced"`
-	FlushBlocksOnShutdown     bool          `yaml:"flush_blocks_on_shutdown" category:"advanced"`
-	CloseIdleTSDBTimeout      time.Duration `yaml:"close_idle_tsdb_timeout" category:"advanced"`
-	MemorySnapshotOnShutdown  bool          `yaml:"memory_snapshot_on_shutdown" category:"experimental"`
-	HeadChunksWriteQueueSize  int           `yaml:"head_chunks_write_queue_size" category:"advanced"`
+	Dir                        string        `yaml:"dir"`
+	BlockRanges                DurationList  `yaml:"block_ranges_period" category:"experimental" doc:"hidden"`
+	Retention                  time.Duration `yaml:"retention_period"`
+	ShipInterval               time.Duration `yaml:"ship_interval" category:"advanced"`
+	ShipConcurrency            int           `yaml:"ship_concurrency" category:"advanced"`
+	HeadCompactionInterval     time.Duration `yaml:"head_compaction_interval" category:"advanced"`
+	HeadCompactionConcurrency  int           `yaml:"head_compaction_concurrency" category:"advanced"`
+	HeadCompactionIdleTimeout  time.Duration `yaml:"head_compaction_idle_timeout" category:"advanced"`
+	HeadChunksWriteBufferSize  int           `yaml:"head_chunks_write_buffer_size_bytes" category:"advanced"`
+	HeadChunksEndTimeVariance  float64       `yaml:"head_chunks_end_time_variance" category:"experimental"`
+	StripeSize                 int           `yaml:"stripe_size" category:"advanced"`
+	WALCompressionEnabled      bool          `yaml:"wal_compression_enabled" category:"advanced"`
+	WALSegmentSizeBytes        int           `yaml:"wal_segment_size_bytes" category:"advanced"`
+	WALReplayConcurrency       int           `yaml:"wal_replay_concurrency" category:"advanced"`
+	FlushBlocksOnShutdown      bool          `yaml:"flush_blocks_on_shutdown" category:"advanced"`
+	CloseIdleTSDBTimeout       time.Duration `yaml:"close_idle_tsdb_timeout" category:"advanced"`
+	MemorySnapshotOnShutdown   bool          `yaml:"memory_snapshot_on_shutdown" category:"experimental"`
+	HeadSnapshotUploadEnabled  bool          `yaml:"head_snapshot_upload_enabled" category:"experimental"`
+	HeadSnapshotUploadInterval time.Duration `yaml:"head_snapshot_upload_interval" category:"experimental"`
+	HeadChunksWriteQueueSize   int           `yaml:"head_chunks_write_queue_size" category:"advanced"`
 
 	// Series hash cache.
 	SeriesHashCacheMaxBytes uint64 `yaml:"series_hash_cache_max_size_bytes" category:"advanced"`
@@ -270,8 +350,10 @@ type TSDBConfig struct {
 	// regardless of the `concurrent` param.
 	BlockPostingsForMatchersCacheForce bool `yaml:"block_postings_for_matchers_cache_force" category:"experimental"`
 
-	EarlyHeadCompactionMinInMemorySeries                     int64 `yaml:"early_head_compaction_min_in_memory_series" category:"experimental"`
-	EarlyHeadCompactionMinEstimatedSeriesReductionPercentage int   `yaml:"early_head_compaction_min_estimated_series_reduction_percentage" category:"experimental"`
+	EarlyHeadCompactionMinInMemorySeries                     int64  `yaml:"early_head_compaction_min_in_memory_series" category:"experimental"`
+	EarlyHeadCompactionMinEstimatedSeriesReductionPercentage int    `yaml:"early_head_compaction_min_estimated_series_reduction_percentage" category:"experimental"`
+	EarlyHeadCompactionMemoryTargetBytes                     uint64 `yaml:"early_head_compaction_memory_target_bytes" category:"experimental"`
+	EarlyHeadCompactionMinEstimatedMemoryReductionPercentage int    `yaml:"early_head_compaction_min_estimated_memory_reduction_percentage" category:"experimental"`
 
 	// HeadCompactionIntervalJitterEnabled is enabled by default, but allows to disable it in tests.
 	HeadCompactionIntervalJitterEnabled bool `yaml:"-"`
@@ -314,6 +396,8 @@ func (cfg *TSDBConfig) RegisterFlags(f *flag.FlagSet) {
 	f.BoolVar(&cfg.FlushBlocksOnShutdown, "blocks-storage.tsdb.flush-blocks-on-shutdown", false, "True to flush blocks to storage on shutdown. If false, incomplete blocks will be reused after restart.")
 	f.DurationVar(&cfg.CloseIdleTSDBTimeout, "blocks-storage.tsdb.close-idle-tsdb-timeout", 13*time.Hour, "If TSDB has not received any data for this duration, and all blocks from TSDB have been shipped, TSDB is closed and deleted from local disk. If set to positive value, this value should be equal or higher than -querier.query-ingesters-within flag to make sure that TSDB is not closed prematurely, which could cause partial query results. 0 or negative value disables closing of idle TSDB.")
 	f.BoolVar(&cfg.MemorySnapshotOnShutdown, "blocks-storage.tsdb.memory-snapshot-on-shutdown", false, "True to enable snapshotting of in-memory TSDB data on disk when shutting down.")
+	f.BoolVar(&cfg.HeadSnapshotUploadEnabled, "blocks-storage.tsdb.head-snapshot-upload-enabled", false, "True to periodically upload a snapshot of the in-memory TSDB head of each tenant to the object storage. An ingester starting with an empty disk bootstraps the TSDB of each tenant from the last snapshot it uploaded, instead of starting empty. Not supported when the ingest storage is enabled.")
+	f.DurationVar(&cfg.HeadSnapshotUploadInterval, "blocks-storage.tsdb.head-snapshot-upload-interval", 15*time.Minute, "How frequently the TSDB head snapshots are uploaded to the object storage, when enabled.")
 	f.IntVar(&cfg.HeadChunksWriteQueueSize, "blocks-storage.tsdb.head-chunks-write-queue-size", 1000000, headChunksWriteQueueSizeHelp)
 	f.IntVar(&cfg.OutOfOrderCapacityMax, "blocks-storage.tsdb.out-of-order-capacity-max", 32, "Maximum capacity for out of order chunks, in samples between 1 and 255.")
 	f.DurationVar(&cfg.HeadPostingsForMatchersCacheTTL, "blocks-storage.tsdb.head-postings-for-matchers-cache-ttl", tsdb.DefaultPostingsForMatchersCacheTTL, "How long to cache postings for matchers in the Head and OOOHead. 0 disables the cache and just deduplicates the in-flight calls.")
@@ -326,6 +410,8 @@ func (cfg *TSDBConfig) RegisterFlags(f *flag.FlagSet) {
 	f.BoolVar(&cfg.BlockPostingsForMatchersCacheForce, "blocks-storage.tsdb.block-postings-for-matchers-cache-force", tsdb.DefaultPostingsForMatchersCacheForce, "Force the cache to be used for postings for matchers in compacted blocks, even if it's not a concurrent (query-sharding) call.")
 	f.Int64Var(&cfg.EarlyHeadCompactionMinInMemorySeries, "blocks-storage.tsdb.early-head-compaction-min-in-memory-series", 0, fmt.Sprintf("When the number of in-memory series in the ingester is equal to or greater than this setting, the ingester tries to compact the TSDB Head. The early compaction removes from the memory all samples and inactive series up until -%s time ago. After an early compaction, the ingester will not accept any sample with a timestamp older than -%s time ago (unless out of order ingestion is enabled). The ingester checks every -%s whether an early compaction is required. Use 0 to disable it.", activeseries.IdleTimeoutFlag, activeseries.IdleTimeoutFlag, headCompactionIntervalFlag))
 	f.IntVar(&cfg.EarlyHeadCompactionMinEstimatedSeriesReductionPercentage, "blocks-storage.tsdb.early-head-compaction-min-estimated-series-reduction-percentage", 15, "When the early compaction is enabled, the early compaction is triggered only if the estimated series reduction is at least the configured percentage (0-100).")
+	f.Uint64Var(&cfg.EarlyHeadCompactionMemoryTargetBytes, "blocks-storage.tsdb.early-head-compaction-memory-target-bytes", 0, fmt.Sprintf("When both the Go heap in use and the resident memory of the ingester are equal to or greater than this setting, in bytes, the ingester compacts the TSDB Head of the tenants with the largest estimated Head memory reduction, until the reduction is estimated to cover the excess. The early compaction removes from the memory all samples and inactive series up until -%s time ago. The ingester checks every -%s whether an early compaction is required. Use 0 to disable it.", activeseries.IdleTimeoutFlag, headCompactionIntervalFlag))
+	f.IntVar(&cfg.EarlyHeadCompactionMinEstimatedMemoryReductionPercentage, "blocks-storage.tsdb.early-head-compaction-min-estimated-memory-reduction-percentage", 10, "When the memory based early compaction is enabled, the early compaction is triggered only if the estimated TSDB Head memory reduction is at least the configured percentage (0-100) of the estimated TSDB Head memory.")
 	f.BoolVar(&cfg.TimelyHeadCompaction, "blocks-storage.tsdb.timely-head-compaction-enabled", false, "Allows head compaction to happen when the min block range can no longer be appended, without requiring 1.5x the chunk range worth of data in the head.")
 
 	cfg.HeadCompactionIntervalJitterEnabled = true
@@ -366,7 +452,11 @@ func (cfg *TSDBConfig) Validate(activeSeriesCfg activeseries.Config) error {
 		return errInvalidWALReplayConcurrency
 	}
 
-	if cfg.EarlyHeadCompactionMinInMemorySeries > 0 && !activeSeriesCfg.Enabled {
+	if cfg.HeadSnapshotUploadEnabled && cfg.HeadSnapshotUploadInterval <= 0 {
+		return errInvalidHeadSnapshotUploadInterval
+	}
+
+	if (cfg.EarlyHeadCompactionMinInMemorySeries > 0 || cfg.EarlyHeadCompactionMemoryTargetBytes > 0) && !activeSeriesCfg.Enabled {
 		return errEarlyCompactionRequiresActiveSeries
 	}
 
@@ -374,6 +464,10 @@ func (cfg *TSDBConfig) Validate(activeSeriesCfg activeseries.Config) error {
 		return errInvalidEarlyHeadCompactionMinSeriesReduction
 	}
 
+	if cfg.EarlyHeadCompactionMinEstimatedMemoryReductionPercentage < 0 || cfg.EarlyHeadCompactionMinEstimatedMemoryReductionPercentage > 100 {
+		return errInvalidEarlyHeadCompactionMinMemoryReduction
+	}
+
 	return nil
 }
 
@@ -416,6 +510,9 @@ type BucketStoreConfig struct {
 	// Series hash cache.
 	SeriesHashCacheMaxBytes uint64 `yaml:"series_hash_cache_max_size_bytes" category:"advanced"`
 
+	// Exemplars cache.
+	ExemplarsCacheMaxBytes uint64 `yaml:"exemplars_cache_max_size_bytes" category:"experimental"`
+
 	// Controls the partitioner, used to aggregate multiple GET object API requests.
 	PartitionerMaxGapBytes uint64 `yaml:"partitioner_max_gap_bytes" category:"advanced"`
 
@@ -444,6 +541,7 @@ func (cfg *BucketStoreConfig) RegisterFlags(f *flag.FlagSet) {
 	f.StringVar(&cfg.SyncDir, "blocks-storage.bucket-store.sync-dir", "./tsdb-sync/", "Directory to store synchronized TSDB index headers. This directory is not required to be persisted between restarts, but it's highly recommended in order to improve the store-gateway startup time.")
 	f.DurationVar(&cfg.SyncInterval, syncIntervalFlag, 15*time.Minute, "How frequently to scan the bucket, or to refresh the bucket index (if enabled), in order to look for changes (new blocks shipped by ingesters and blocks deleted by retention or compaction).")
 	f.Uint64Var(&cfg.SeriesHashCacheMaxBytes, "blocks-storage.bucket-store.series-hash-cache-max-size-bytes", uint64(1*units.Gibibyte), "Max size - in bytes - of the in-memory series hash cache. The cache is shared across all tenants and it's used only when query sharding is enabled.")
+	f.Uint64Var(&cfg.ExemplarsCacheMaxBytes, "blocks-storage.bucket-store.exemplars-cache-max-size-bytes", uint64(256*units.Mebibyte), "Max size - in bytes - of the in-memory cache of the exemplars stored in the blocks. The cache is shared across all tenants and it's used only when the long-term exemplars storage is enabled.")
 	f.IntVar(&cfg.MaxConcurrent, "blocks-storage.bucket-store.max-concurrent", 200, "Max number of concurrent queries to execute against the long-term storage. The limit is shared across all tenants.")
 	f.DurationVar(&cfg.MaxConcurrentQueueTimeout, "blocks-storage.bucket-store.max-concurrent-queue-timeout", 5*time.Second, "Timeout for the queue of queries waiting for execution. If the queue is full and the timeout is reached, the query will be retried on another store-gateway. 0 means no timeout and all queries will wait indefinitely for their turn.")
 	f.IntVar(&cfg.TenantSyncConcurrency, "blocks-storage.bucket-store.tenant-sync-concurrency", 1, "Maximum number of concurrent tenants synching blocks.")
@@ -500,15 +598,28 @@ type BucketIndexConfig struct {
 	UpdateOnErrorInterval time.Duration `yaml:"update_on_error_interval" category:"advanced"`
 	IdleTimeout           time.Duration `yaml:"idle_timeout" category:"advanced"`
 	MaxStalePeriod        time.Duration `yaml:"max_stale_period" category:"advanced"`
+
+	BlockStatsEnabled           bool `yaml:"block_stats_enabled" category:"experimental"`
+	BlockStatsMaxLabelNames     int  `yaml:"block_stats_max_label_names" category:"experimental"`
+	BlockStatsMaxTotalSizeBytes int  `yaml:"block_stats_max_total_size_bytes" category:"experimental"`
 }
 
 func (cfg *BucketIndexConfig) RegisterFlagsWithPrefix(f *flag.FlagSet, prefix string) {
 	f.DurationVar(&cfg.UpdateOnErrorInterval, prefix+"update-on-error-interval", time.Minute, "How frequently a bucket index, which previously failed to load, should be tried to load again. This option is used only by querier.")
 	f.DurationVar(&cfg.IdleTimeout, prefix+"idle-timeout", time.Hour, "How long a unused bucket index should be cached. Once this timeout expires, the unused bucket index is removed from the in-memory cache. This option is used only by querier.")
 	f.DurationVar(&cfg.MaxStalePeriod, prefix+"max-stale-period", time.Hour, "The maximum allowed age of a bucket index (last updated) before queries start failing because the bucket index is too old. The bucket index is periodically updated by the compactor, and this check is enforced in the querier (at query time).")
+	f.BoolVar(&cfg.BlockStatsEnabled, prefix+"block-stats-enabled", false, "True to record the series statistics of each block in the bucket index: the number of series and metric names, a filter of the metric names, and the number of distinct values of the top label names. The statistics are computed by the compactor, and used by the querier to skip the blocks which can't contain the queried metric. The statistics of each block increase the size of the bucket index by up to about 5.5KiB for the metric names filter, once encoded.")
+	f.IntVar(&cfg.BlockStatsMaxLabelNames, prefix+"block-stats-max-label-names", 10, "Maximum number of label names, with the most distinct values, whose statistics are recorded for each block in the bucket index.")
+	f.IntVar(&cfg.BlockStatsMaxTotalSizeBytes, prefix+"block-stats-max-total-size-bytes", 2*1024*1024, "Maximum total size, before compression, of the series statistics of all the blocks in the bucket index of a tenant. Once reached, the statistics of the new blocks are not recorded until older blocks are deleted. The statistics of the most recent blocks are recorded first. 0 to disable the limit.")
 }
 
 // Validate the config.
 func (cfg *BucketIndexConfig) Validate() error {
+	if cfg.BlockStatsEnabled && cfg.BlockStatsMaxLabelNames <= 0 {
+		return errInvalidBlockStatsMaxLabelNames
+	}
+	if cfg.BlockStatsMaxTotalSizeBytes < 0 {
+		return errInvalidBlockStatsMaxTotalSizeBytes
+	}
 	return nil
 }
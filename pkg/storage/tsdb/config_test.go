@@ -128,12 +128,25 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			expectedErr: errEarlyCompactionRequiresActiveSeries,
 		},
+		"should fail if memory based forced compaction is enabled but active series tracker is not": {
+			setup: func(cfg *BlocksStorageConfig, activeSeriesCfg *activeseries.Config) {
+				cfg.TSDB.EarlyHeadCompactionMemoryTargetBytes = 1 << 30
+				activeSeriesCfg.Enabled = false
+			},
+			expectedErr: errEarlyCompactionRequiresActiveSeries,
+		},
 		"should fail on invalid forced compaction min series reduction percentage": {
 			setup: func(cfg *BlocksStorageConfig, _ *activeseries.Config) {
 				cfg.TSDB.EarlyHeadCompactionMinEstimatedSeriesReductionPercentage = 101
 			},
 			expectedErr: errInvalidEarlyHeadCompactionMinSeriesReduction,
 		},
+		"should fail on invalid forced compaction min memory reduction percentage": {
+			setup: func(cfg *BlocksStorageConfig, _ *activeseries.Config) {
+				cfg.TSDB.EarlyHeadCompactionMinEstimatedMemoryReductionPercentage = -1
+			},
+			expectedErr: errInvalidEarlyHeadCompactionMinMemoryReduction,
+		},
 		"should not fail on 'ignore deletion marks while querying delay' less than 'ignore deletion marks in store-gateways delay'": {
 			setup: func(cfg *BlocksStorageConfig, _ *activeseries.Config) {
 				cfg.BucketStore.IgnoreDeletionMarksWhileQueryingDelay = 120 * time.Minute
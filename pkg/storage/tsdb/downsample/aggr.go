@@ -0,0 +1,240 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+// Provenance-includes-location: https://github.com/thanos-io/thanos/blob/main/pkg/compact/downsample/aggr.go
+// Provenance-includes-license: Apache-2.0
+// Provenance-includes-copyright: The Thanos Authors.
+
+package downsample
+
+import (
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+)
+
+// ChunkEncAggr is the encoding of the chunks of the downsampled blocks. It's outside the range of
+// the encodings used by Prometheus.
+const ChunkEncAggr = chunkenc.Encoding(0xff)
+
+// AggrType is the type of an aggregate stored in an AggrChunk.
+type AggrType uint8
+
+// Valid aggregates.
+const (
+	AggrCount AggrType = iota
+	AggrSum
+	AggrMin
+	AggrMax
+	AggrCounter
+)
+
+func (t AggrType) String() string {
+	switch t {
+	case AggrCount:
+		return "count"
+	case AggrSum:
+		return "sum"
+	case AggrMin:
+		return "min"
+	case AggrMax:
+		return "max"
+	case AggrCounter:
+		return "counter"
+	}
+	return "<unknown>"
+}
+
+// ErrAggrNotExist is returned when the requested aggregate is not stored in the chunk.
+var ErrAggrNotExist = errors.New("aggregate does not exist")
+
+// AggrChunk is a chunk storing a sub-chunk for each aggregate. The count is always stored as a float
+// chunk, while the other aggregates are stored as float histogram chunks for histogram series.
+// The min and max aggregates are not stored for histogram series.
+//
+// Each sub-chunk is encoded as the uvarint length of its data, followed by its encoding and its data.
+// Missing sub-chunks have a length of 0.
+type AggrChunk struct {
+	b []byte
+}
+
+// NewAggrChunk returns an AggrChunk reading the given data.
+func NewAggrChunk(b []byte) *AggrChunk {
+	return &AggrChunk{b: b}
+}
+
+// EncodeAggrChunk encodes the given sub-chunks, indexed by AggrType, into an AggrChunk.
+// Nil sub-chunks are encoded as missing.
+func EncodeAggrChunk(chks [5]chunkenc.Chunk) *AggrChunk {
+	var b []byte
+	buf := make([]byte, binary.MaxVarintLen64)
+
+	for _, c := range chks {
+		if c == nil {
+			b = append(b, 0)
+			continue
+		}
+		data := c.Bytes()
+		n := binary.PutUvarint(buf, uint64(len(data)))
+		b = append(b, buf[:n]...)
+		b = append(b, byte(c.Encoding()))
+		b = append(b, data...)
+	}
+	return &AggrChunk{b: b}
+}
+
+// Get returns the sub-chunk of the given aggregate, or ErrAggrNotExist if it's missing.
+func (c *AggrChunk) Get(t AggrType) (chunkenc.Chunk, error) {
+	b := c.b
+	for i := AggrCount; i <= t; i++ {
+		l, n := binary.Uvarint(b)
+		if n <= 0 {
+			return nil, errors.Errorf("invalid size of %s aggregate", i)
+		}
+		b = b[n:]
+		if l == 0 {
+			if i == t {
+				return nil, ErrAggrNotExist
+			}
+			continue
+		}
+		if len(b) < int(l)+1 {
+			return nil, errors.Errorf("%s aggregate is truncated", i)
+		}
+		if i == t {
+			return chunkenc.FromData(chunkenc.Encoding(b[0]), b[1:l+1])
+		}
+		b = b[l+1:]
+	}
+	return nil, ErrAggrNotExist
+}
+
+// Average returns a chunk with the average of the samples aggregated in each window, computed
+// from the count and sum aggregates.
+func (c *AggrChunk) Average() (chunkenc.Chunk, error) {
+	count, err := c.Get(AggrCount)
+	if err != nil {
+		return nil, err
+	}
+	sum, err := c.Get(AggrSum)
+	if err != nil {
+		return nil, err
+	}
+
+	if sum.Encoding() == chunkenc.EncXOR {
+		out := chunkenc.NewXORChunk()
+		app, err := out.Appender()
+		if err != nil {
+			return nil, err
+		}
+		countIt, sumIt := count.Iterator(nil), sum.Iterator(nil)
+		for countIt.Next() == chunkenc.ValFloat {
+			if sumIt.Next() != chunkenc.ValFloat {
+				return nil, errors.New("sum aggregate has less samples than count aggregate")
+			}
+			t, n := countIt.At()
+			_, s := sumIt.At()
+			app.Append(t, s/n)
+		}
+		return out, iteratorsErr(countIt, sumIt)
+	}
+
+	var out chunkenc.Chunk = chunkenc.NewFloatHistogramChunk()
+	app, err := out.Appender()
+	if err != nil {
+		return nil, err
+	}
+	countIt, sumIt := count.Iterator(nil), sum.Iterator(nil)
+	for countIt.Next() == chunkenc.ValFloat {
+		if sumIt.Next() != chunkenc.ValFloatHistogram {
+			return nil, errors.New("sum aggregate has less samples than count aggregate")
+		}
+		t, n := countIt.At()
+		_, h := sumIt.AtFloatHistogram(nil)
+		h.CounterResetHint = histogram.GaugeType
+		newChk, recoded, newApp, err := app.AppendFloatHistogram(nil, t, h.Div(n), false)
+		if err != nil {
+			return nil, err
+		}
+		if newChk != nil {
+			if !recoded {
+				return nil, errors.Errorf("average of sum aggregate at %d doesn't fit in a single chunk", t)
+			}
+			out = newChk
+		}
+		app = newApp
+	}
+	return out, iteratorsErr(countIt, sumIt)
+}
+
+func iteratorsErr(its ...chunkenc.Iterator) error {
+	for _, it := range its {
+		if err := it.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Encoding implements chunkenc.Chunk.
+func (c *AggrChunk) Encoding() chunkenc.Encoding { return ChunkEncAggr }
+
+// Bytes implements chunkenc.Chunk.
+func (c *AggrChunk) Bytes() []byte { return c.b }
+
+// Appender implements chunkenc.Chunk. Samples can't be appended to an AggrChunk.
+func (c *AggrChunk) Appender() (chunkenc.Appender, error) {
+	return nil, errors.New("appending to an aggregated chunk is not supported")
+}
+
+// Iterator implements chunkenc.Chunk. The samples of an AggrChunk can only be iterated through its sub-chunks.
+func (c *AggrChunk) Iterator(chunkenc.Iterator) chunkenc.Iterator {
+	return errIterator{Iterator: chunkenc.NewNopIterator(), err: errors.New("iterating an aggregated chunk is not supported")}
+}
+
+// NumSamples implements chunkenc.Chunk. It returns the number of aggregated windows.
+func (c *AggrChunk) NumSamples() int {
+	count, err := c.Get(AggrCount)
+	if err != nil {
+		return 0
+	}
+	return count.NumSamples()
+}
+
+// Compact implements chunkenc.Chunk.
+func (c *AggrChunk) Compact() {}
+
+// Reset implements chunkenc.Chunk.
+func (c *AggrChunk) Reset(stream []byte) { c.b = stream }
+
+// errIterator is an iterator with no samples which returns an error.
+type errIterator struct {
+	chunkenc.Iterator
+	err error
+}
+
+func (it errIterator) Err() error { return it.err }
+
+// pool is a chunkenc.Pool which also supports AggrChunk.
+type pool struct {
+	chunkenc.Pool
+}
+
+// NewPool returns a chunkenc.Pool which supports the chunks of both raw and downsampled blocks.
+func NewPool() chunkenc.Pool {
+	return &pool{Pool: chunkenc.NewPool()}
+}
+
+func (p *pool) Get(e chunkenc.Encoding, b []byte) (chunkenc.Chunk, error) {
+	if e == ChunkEncAggr {
+		return NewAggrChunk(b), nil
+	}
+	return p.Pool.Get(e, b)
+}
+
+func (p *pool) Put(c chunkenc.Chunk) error {
+	if c.Encoding() == ChunkEncAggr {
+		return nil
+	}
+	return p.Pool.Put(c)
+}
@@ -0,0 +1,598 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+// Provenance-includes-location: https://github.com/thanos-io/thanos/blob/main/pkg/compact/downsample/downsample.go
+// Provenance-includes-license: Apache-2.0
+// Provenance-includes-copyright: The Thanos Authors.
+
+package downsample
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/prometheus/prometheus/tsdb/index"
+	"github.com/prometheus/prometheus/tsdb/tombstones"
+
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+)
+
+// Resolutions of the blocks, in milliseconds.
+const (
+	ResLevel0 = int64(0)                                  // Raw data.
+	ResLevel1 = int64(5 * time.Minute / time.Millisecond) // 5 minutes.
+	ResLevel2 = int64(time.Hour / time.Millisecond)       // 1 hour.
+)
+
+// maxSamplesPerChunk is the maximum number of aggregated windows stored in a single AggrChunk.
+const maxSamplesPerChunk = 120
+
+// Downsample downsamples the block b, described by origMeta, to the given resolution and writes the resulting
+// block in dir. The block b must have been opened with a chunkenc.Pool returned by NewPool if it's already
+// downsampled. It returns the ID of the new block.
+func Downsample(ctx context.Context, logger log.Logger, origMeta *block.Meta, b tsdb.BlockReader, dir string, resolution int64) (id ulid.ULID, returnErr error) {
+	if origMeta.Thanos.Downsample.Resolution >= resolution {
+		return id, errors.Errorf("cannot downsample block %s with resolution %d to resolution %d", origMeta.ULID, origMeta.Thanos.Downsample.Resolution, resolution)
+	}
+
+	indexr, err := b.Index()
+	if err != nil {
+		return id, errors.Wrap(err, "open index reader")
+	}
+	defer func() {
+		if err := indexr.Close(); err != nil && returnErr == nil {
+			returnErr = errors.Wrap(err, "close index reader")
+		}
+	}()
+
+	chunkr, err := b.Chunks()
+	if err != nil {
+		return id, errors.Wrap(err, "open chunk reader")
+	}
+	defer func() {
+		if err := chunkr.Close(); err != nil && returnErr == nil {
+			returnErr = errors.Wrap(err, "close chunk reader")
+		}
+	}()
+
+	id = ulid.MustNew(ulid.Now(), rand.Reader)
+	blockDir := filepath.Join(dir, id.String())
+	defer func() {
+		if returnErr != nil {
+			_ = os.RemoveAll(blockDir)
+		}
+	}()
+
+	chunkw, err := chunks.NewWriter(filepath.Join(blockDir, block.ChunksDirname))
+	if err != nil {
+		return id, errors.Wrap(err, "create chunk writer")
+	}
+	indexw, err := index.NewWriter(ctx, filepath.Join(blockDir, block.IndexFilename))
+	if err != nil {
+		_ = chunkw.Close()
+		return id, errors.Wrap(err, "create index writer")
+	}
+
+	stats, err := downsampleSeries(ctx, indexr, chunkr, chunkw, indexw, origMeta.Thanos.Downsample.Resolution, resolution)
+	if closeErr := chunkw.Close(); closeErr != nil && err == nil {
+		err = errors.Wrap(closeErr, "close chunk writer")
+	}
+	if closeErr := indexw.Close(); closeErr != nil && err == nil {
+		err = errors.Wrap(closeErr, "close index writer")
+	}
+	if err != nil {
+		return id, err
+	}
+
+	meta := &block.Meta{
+		BlockMeta: tsdb.BlockMeta{
+			ULID:    id,
+			MinTime: origMeta.MinTime,
+			MaxTime: origMeta.MaxTime,
+			Version: block.TSDBVersion1,
+			Stats:   stats,
+			Compaction: tsdb.BlockMetaCompaction{
+				Level:   origMeta.Compaction.Level,
+				Sources: slices.Clone(origMeta.Compaction.Sources),
+				Parents: []tsdb.BlockDesc{{ULID: origMeta.ULID, MinTime: origMeta.MinTime, MaxTime: origMeta.MaxTime}},
+				Hints:   slices.Clone(origMeta.Compaction.Hints),
+			},
+		},
+		Thanos: block.ThanosMeta{
+			Version:    block.ThanosVersion1,
+			Labels:     origMeta.Thanos.Labels,
+			Downsample: block.ThanosDownsample{Resolution: resolution},
+			Source:     block.CompactorSource,
+			Rewrites:   origMeta.Thanos.Rewrites,
+		},
+	}
+	if err := meta.WriteToDir(logger, blockDir); err != nil {
+		return id, errors.Wrap(err, "write meta")
+	}
+	return id, nil
+}
+
+func downsampleSeries(ctx context.Context, indexr tsdb.IndexReader, chunkr tsdb.ChunkReader, chunkw *chunks.Writer, indexw *index.Writer, fromResolution, resolution int64) (tsdb.BlockStats, error) {
+	var stats tsdb.BlockStats
+
+	// The downsampled block has the same series, so all the symbols of the original block are added.
+	symbols := indexr.Symbols()
+	for symbols.Next() {
+		if err := indexw.AddSymbol(symbols.At()); err != nil {
+			return stats, errors.Wrap(err, "add symbol")
+		}
+	}
+	if err := symbols.Err(); err != nil {
+		return stats, errors.Wrap(err, "iterate symbols")
+	}
+
+	k, v := index.AllPostingsKey()
+	postings, err := indexr.Postings(ctx, k, v)
+	if err != nil {
+		return stats, errors.Wrap(err, "get all postings")
+	}
+	postings = indexr.SortedPostings(postings)
+
+	var (
+		ref     storage.SeriesRef
+		builder labels.ScratchBuilder
+		chks    []chunks.Meta
+	)
+	for postings.Next() {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+		if err := indexr.Series(postings.At(), &builder, &chks); err != nil {
+			return stats, errors.Wrapf(err, "get series %d", postings.At())
+		}
+
+		d := &seriesDownsampler{resolution: resolution}
+		if err := d.addChunks(chunkr, chks, fromResolution); err != nil {
+			return stats, errors.Wrapf(err, "downsample series %s", builder.Labels())
+		}
+		d.flush()
+		if len(d.samples) == 0 {
+			continue
+		}
+
+		out, err := encodeAggrChunks(d.samples)
+		if err != nil {
+			return stats, errors.Wrapf(err, "encode chunks of series %s", builder.Labels())
+		}
+		if err := chunkw.WriteChunks(out...); err != nil {
+			return stats, errors.Wrap(err, "write chunks")
+		}
+		if err := indexw.AddSeries(ref, builder.Labels(), out...); err != nil {
+			return stats, errors.Wrap(err, "add series")
+		}
+		ref++
+
+		stats.NumSeries++
+		stats.NumChunks += uint64(len(out))
+		stats.NumSamples += uint64(len(d.samples))
+	}
+	if err := postings.Err(); err != nil {
+		return stats, errors.Wrap(err, "iterate postings")
+	}
+	return stats, nil
+}
+
+// MergeAggrChunks merges the aggregated chunks of the downsampled series with the given resolution in new aggregated
+// chunks, without the windows overlapping the deleted intervals of their series. The chunks must have been read with
+// a chunkenc.Pool returned by NewPool. When several series have a window starting at the same time, the window of the
+// first series is kept, like storage.ChainedSeriesMerge does for raw samples. It returns no chunks if all the windows
+// have been deleted.
+func MergeAggrChunks(series [][]chunks.Meta, deleted []tombstones.Intervals, resolution int64) ([]chunks.Meta, error) {
+	var (
+		merged []aggrSample
+		seen   = map[int64]struct{}{}
+	)
+	for i, chks := range series {
+		// The windows are read as they are, without aggregating them again.
+		d := &seriesDownsampler{resolution: 1}
+		for _, meta := range sortedChunkMetas(chks) {
+			aggrChk, ok := meta.Chunk.(*AggrChunk)
+			if !ok {
+				return nil, errors.Errorf("chunk %d of downsampled series has encoding %s", meta.Ref, meta.Chunk.Encoding())
+			}
+			if err := d.addAggrChunk(aggrChk); err != nil {
+				return nil, errors.Wrapf(err, "read aggregated chunk %d", meta.Ref)
+			}
+		}
+		d.flush()
+
+		for _, s := range d.samples {
+			start := windowStart(s.t, resolution)
+			if _, ok := seen[start]; ok {
+				continue
+			}
+			if i < len(deleted) && overlapsIntervals(deleted[i], start, s.t) {
+				continue
+			}
+			seen[start] = struct{}{}
+			merged = append(merged, s)
+		}
+	}
+
+	slices.SortStableFunc(merged, func(a, b aggrSample) int {
+		return cmp.Compare(a.t, b.t)
+	})
+	return encodeAggrChunks(merged)
+}
+
+// aggrSample holds the aggregates of the samples within a time window. A raw sample is
+// aggregated over a window containing only itself.
+type aggrSample struct {
+	// t is the timestamp of the last sample in the window.
+	t                      int64
+	count                  float64
+	sum, min, max, counter float64
+	histSum, histCounter   *histogram.FloatHistogram
+}
+
+func (s *aggrSample) isHistogram() bool {
+	return s.histSum != nil
+}
+
+// seriesDownsampler aggregates the samples of a series over windows of the resolution.
+type seriesDownsampler struct {
+	resolution int64
+	samples    []aggrSample
+
+	window      aggrSample
+	windowStart int64
+	inWindow    bool
+
+	// lastT is the timestamp of the last sample added, if any.
+	lastT   int64
+	hasLast bool
+
+	// Counter reset adjustment of raw samples.
+	prev, offset         float64
+	hasPrev              bool
+	histPrev, histOffset *histogram.FloatHistogram
+}
+
+func (d *seriesDownsampler) addChunks(chunkr tsdb.ChunkReader, chks []chunks.Meta, fromResolution int64) error {
+	var it chunkenc.Iterator
+	for _, meta := range sortedChunkMetas(chks) {
+		chk, iterable, err := chunkr.ChunkOrIterable(meta)
+		if err != nil {
+			return errors.Wrapf(err, "get chunk %d", meta.Ref)
+		}
+
+		if fromResolution > ResLevel0 {
+			aggrChk, ok := chk.(*AggrChunk)
+			if !ok {
+				return errors.Errorf("chunk %d of downsampled block has encoding %s", meta.Ref, chk.Encoding())
+			}
+			if err := d.addAggrChunk(aggrChk); err != nil {
+				return errors.Wrapf(err, "read aggregated chunk %d", meta.Ref)
+			}
+			continue
+		}
+
+		if chk != nil {
+			it = chk.Iterator(it)
+		} else {
+			it = iterable.Iterator(it)
+		}
+		if err := d.addRaw(it); err != nil {
+			return errors.Wrapf(err, "read chunk %d", meta.Ref)
+		}
+	}
+	return nil
+}
+
+func (d *seriesDownsampler) addRaw(it chunkenc.Iterator) error {
+	for vt := it.Next(); vt != chunkenc.ValNone; vt = it.Next() {
+		switch vt {
+		case chunkenc.ValFloat:
+			t, v := it.At()
+			if value.IsStaleNaN(v) {
+				continue
+			}
+			d.add(aggrSample{t: t, count: 1, sum: v, min: v, max: v, counter: d.adjustCounter(v)})
+		case chunkenc.ValHistogram, chunkenc.ValFloatHistogram:
+			t, h := it.AtFloatHistogram(nil)
+			if value.IsStaleNaN(h.Sum) {
+				continue
+			}
+			// The sum is copied because it's modified while aggregating the window.
+			d.add(aggrSample{t: t, count: 1, histSum: h.Copy(), histCounter: d.adjustHistogramCounter(h)})
+		}
+	}
+	return it.Err()
+}
+
+// adjustCounter returns the value of the counter adjusted for the resets which occurred since the first sample.
+func (d *seriesDownsampler) adjustCounter(v float64) float64 {
+	if d.hasPrev && v < d.prev {
+		d.offset += d.prev
+	}
+	d.prev, d.hasPrev = v, true
+	return v + d.offset
+}
+
+// adjustHistogramCounter returns the value of the histogram counter adjusted for the resets which occurred
+// since the first sample.
+func (d *seriesDownsampler) adjustHistogramCounter(h *histogram.FloatHistogram) *histogram.FloatHistogram {
+	if d.histPrev != nil && h.DetectReset(d.histPrev) {
+		if d.histOffset == nil {
+			d.histOffset = d.histPrev.Copy()
+		} else if _, err := d.histOffset.Add(d.histPrev); err != nil {
+			// The histograms are incompatible (different custom buckets): start over.
+			d.histOffset = nil
+		}
+	}
+	d.histPrev = h
+
+	adjusted := h.Copy()
+	adjusted.CounterResetHint = histogram.UnknownCounterReset
+	if d.histOffset != nil {
+		if _, err := adjusted.Add(d.histOffset); err != nil {
+			d.histOffset = nil
+			adjusted = h.Copy()
+			adjusted.CounterResetHint = histogram.UnknownCounterReset
+		}
+	}
+	return adjusted
+}
+
+func (d *seriesDownsampler) addAggrChunk(c *AggrChunk) error {
+	var its [5]chunkenc.Iterator
+	for typ := AggrCount; typ <= AggrCounter; typ++ {
+		chk, err := c.Get(typ)
+		if errors.Is(err, ErrAggrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		its[typ] = chk.Iterator(nil)
+	}
+	if its[AggrCount] == nil || its[AggrSum] == nil || its[AggrCounter] == nil {
+		return errors.New("missing count, sum or counter aggregate")
+	}
+
+	for its[AggrCount].Next() == chunkenc.ValFloat {
+		t, count := its[AggrCount].At()
+		s := aggrSample{t: t, count: count}
+
+		switch its[AggrSum].Next() {
+		case chunkenc.ValFloat:
+			_, s.sum = its[AggrSum].At()
+			if its[AggrMin] == nil || its[AggrMax] == nil {
+				return errors.New("missing min or max aggregate")
+			}
+			if its[AggrMin].Next() != chunkenc.ValFloat || its[AggrMax].Next() != chunkenc.ValFloat || its[AggrCounter].Next() != chunkenc.ValFloat {
+				return errors.Errorf("missing aggregates at %d", t)
+			}
+			_, s.min = its[AggrMin].At()
+			_, s.max = its[AggrMax].At()
+			_, s.counter = its[AggrCounter].At()
+		case chunkenc.ValFloatHistogram:
+			_, s.histSum = its[AggrSum].AtFloatHistogram(nil)
+			if its[AggrCounter].Next() != chunkenc.ValFloatHistogram {
+				return errors.Errorf("missing counter aggregate at %d", t)
+			}
+			_, s.histCounter = its[AggrCounter].AtFloatHistogram(nil)
+		default:
+			return errors.Errorf("missing sum aggregate at %d", t)
+		}
+		d.add(s)
+	}
+
+	for _, it := range its {
+		if it == nil {
+			continue
+		}
+		if err := it.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// add adds the aggregated sample s to the current window. Samples must be added in timestamp order.
+func (d *seriesDownsampler) add(s aggrSample) {
+	if d.hasLast && s.t <= d.lastT {
+		// Skip samples of overlapping chunks.
+		return
+	}
+	d.lastT, d.hasLast = s.t, true
+
+	start := windowStart(s.t, d.resolution)
+	if d.inWindow && (start != d.windowStart || s.isHistogram() != d.window.isHistogram()) {
+		d.flush()
+	}
+	if !d.inWindow {
+		d.window, d.windowStart, d.inWindow = s, start, true
+		return
+	}
+
+	if s.isHistogram() {
+		if _, err := d.window.histSum.Add(s.histSum); err != nil {
+			// The histograms are incompatible (different custom buckets): start a new window.
+			d.flush()
+			d.window, d.windowStart, d.inWindow = s, start, true
+			return
+		}
+		d.window.histCounter = s.histCounter
+	} else {
+		d.window.sum += s.sum
+		d.window.min = min(d.window.min, s.min)
+		d.window.max = max(d.window.max, s.max)
+		d.window.counter = s.counter
+	}
+	d.window.t = s.t
+	d.window.count += s.count
+}
+
+// flush closes the current window, if any.
+func (d *seriesDownsampler) flush() {
+	if !d.inWindow {
+		return
+	}
+	if d.window.isHistogram() {
+		d.window.histSum.Compact(0)
+	}
+	d.samples = append(d.samples, d.window)
+	d.window, d.inWindow = aggrSample{}, false
+}
+
+// overlapsIntervals returns whether the time range [mint, maxt] overlaps any of the intervals.
+func overlapsIntervals(intervals tombstones.Intervals, mint, maxt int64) bool {
+	for _, iv := range intervals {
+		if iv.Mint <= maxt && mint <= iv.Maxt {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedChunkMetas sorts the chunks by min time.
+func sortedChunkMetas(chks []chunks.Meta) []chunks.Meta {
+	slices.SortFunc(chks, func(a, b chunks.Meta) int {
+		return cmp.Compare(a.MinTime, b.MinTime)
+	})
+	return chks
+}
+
+// windowStart returns the start of the window of the given resolution containing t.
+func windowStart(t, resolution int64) int64 {
+	if t < 0 {
+		return (t - resolution + 1) / resolution * resolution
+	}
+	return t / resolution * resolution
+}
+
+// encodeAggrChunks encodes the aggregated samples in AggrChunks.
+func encodeAggrChunks(samples []aggrSample) ([]chunks.Meta, error) {
+	var out []chunks.Meta
+	for len(samples) > 0 {
+		n := min(len(samples), maxSamplesPerChunk)
+		for i := 1; i < n; i++ {
+			if samples[i].isHistogram() != samples[0].isHistogram() {
+				n = i
+				break
+			}
+		}
+
+		chk, n, err := encodeAggrChunk(samples[:n])
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, chunks.Meta{MinTime: samples[0].t, MaxTime: samples[n-1].t, Chunk: chk})
+		samples = samples[n:]
+	}
+	return out, nil
+}
+
+// encodeAggrChunk encodes the given samples, all of the same kind, in an AggrChunk. Histogram samples may
+// not all fit in a single chunk, so it returns the number of samples encoded.
+func encodeAggrChunk(samples []aggrSample) (*AggrChunk, int, error) {
+	var chks [5]chunkenc.Chunk
+
+	if !samples[0].isHistogram() {
+		for _, typ := range []AggrType{AggrCount, AggrSum, AggrMin, AggrMax, AggrCounter} {
+			chk := chunkenc.NewXORChunk()
+			app, err := chk.Appender()
+			if err != nil {
+				return nil, 0, err
+			}
+			for _, s := range samples {
+				app.Append(s.t, s.floatAggr(typ))
+			}
+			chks[typ] = chk
+		}
+		return EncodeAggrChunk(chks), len(samples), nil
+	}
+
+	sum, n, err := encodeFloatHistograms(samples, func(s *aggrSample) *histogram.FloatHistogram {
+		s.histSum.CounterResetHint = histogram.GaugeType
+		return s.histSum
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	counter, counterN, err := encodeFloatHistograms(samples[:n], func(s *aggrSample) *histogram.FloatHistogram {
+		return s.histCounter
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	if counterN < n {
+		// The counter aggregate doesn't fit in the same chunk, so the sum aggregate must be cut as well.
+		n = counterN
+		if sum, _, err = encodeFloatHistograms(samples[:n], func(s *aggrSample) *histogram.FloatHistogram { return s.histSum }); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	count := chunkenc.NewXORChunk()
+	app, err := count.Appender()
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, s := range samples[:n] {
+		app.Append(s.t, s.count)
+	}
+
+	chks[AggrCount], chks[AggrSum], chks[AggrCounter] = count, sum, counter
+	return EncodeAggrChunk(chks), n, nil
+}
+
+// encodeFloatHistograms encodes the histograms returned by get for the given samples in a float histogram chunk,
+// and returns the number of samples which fit in it.
+func encodeFloatHistograms(samples []aggrSample, get func(*aggrSample) *histogram.FloatHistogram) (chunkenc.Chunk, int, error) {
+	var chk chunkenc.Chunk = chunkenc.NewFloatHistogramChunk()
+	app, err := chk.Appender()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for i := range samples {
+		newChk, recoded, newApp, err := app.AppendFloatHistogram(nil, samples[i].t, get(&samples[i]), false)
+		if err != nil {
+			return nil, 0, err
+		}
+		if newChk != nil {
+			if !recoded {
+				return chk, i, nil
+			}
+			chk = newChk
+		}
+		app = newApp
+	}
+	return chk, len(samples), nil
+}
+
+func (s *aggrSample) floatAggr(typ AggrType) float64 {
+	switch typ {
+	case AggrCount:
+		return s.count
+	case AggrSum:
+		return s.sum
+	case AggrMin:
+		return s.min
+	case AggrMax:
+		return s.max
+	case AggrCounter:
+		return s.counter
+	}
+	return 0
+}
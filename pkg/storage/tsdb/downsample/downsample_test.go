@@ -0,0 +1,349 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package downsample
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/prometheus/prometheus/tsdb/index"
+	"github.com/prometheus/prometheus/tsdb/tombstones"
+	"github.com/prometheus/prometheus/tsdb/tsdbutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+)
+
+const scrapeInterval = int64(15 * time.Second / time.Millisecond)
+
+// counterValue returns the value of a counter at the i-th scrape, with a reset at the 30th scrape.
+func counterValue(i int) float64 {
+	if i < 30 {
+		return float64(i)
+	}
+	return float64(i - 30)
+}
+
+func TestDownsample_Floats(t *testing.T) {
+	dir := t.TempDir()
+	series := labels.FromStrings("__name__", "requests_total")
+
+	// Two raw chunks of 2h of samples.
+	var first, second []chunks.Sample
+	for i := 0; i < 240; i++ {
+		s := sample{t: int64(i) * scrapeInterval, f: counterValue(i)}
+		if i < 120 {
+			first = append(first, s)
+		} else {
+			second = append(second, s)
+		}
+	}
+	meta := generateBlock(t, dir, series, first, second)
+
+	id5m := downsampleBlock(t, dir, meta, ResLevel1)
+	chks := readAggrChunks(t, dir, id5m, series)
+	count := readFloats(t, chks, AggrCount)
+	sum := readFloats(t, chks, AggrSum)
+	minimum := readFloats(t, chks, AggrMin)
+	maximum := readFloats(t, chks, AggrMax)
+	counter := readFloats(t, chks, AggrCounter)
+
+	require.Len(t, count, 12)
+	for _, aggr := range [][]floatSample{sum, minimum, maximum, counter} {
+		require.Len(t, aggr, 12)
+	}
+
+	// The first window holds the scrapes 0 to 19.
+	assert.Equal(t, floatSample{t: 19 * scrapeInterval, f: 20}, count[0])
+	assert.Equal(t, 190.0, sum[0].f)
+	assert.Equal(t, 0.0, minimum[0].f)
+	assert.Equal(t, 19.0, maximum[0].f)
+	assert.Equal(t, 19.0, counter[0].f)
+
+	// The second window holds the scrapes 20 to 39, and the counter reset.
+	assert.Equal(t, floatSample{t: 39 * scrapeInterval, f: 20}, count[1])
+	assert.Equal(t, 290.0, sum[1].f)
+	assert.Equal(t, 0.0, minimum[1].f)
+	assert.Equal(t, 29.0, maximum[1].f)
+	assert.Equal(t, 38.0, counter[1].f)
+
+	avg, err := chks[0].Average()
+	require.NoError(t, err)
+	it := avg.Iterator(nil)
+	require.Equal(t, chunkenc.ValFloat, it.Next())
+	_, v := it.At()
+	assert.Equal(t, 9.5, v)
+
+	// Downsample the 5m block to 1h.
+	meta5m, err := block.ReadMetaFromDir(filepath.Join(dir, id5m.String()))
+	require.NoError(t, err)
+	assert.Equal(t, ResLevel1, meta5m.Thanos.Downsample.Resolution)
+	assert.Equal(t, meta.MinTime, meta5m.MinTime)
+	assert.Equal(t, meta.MaxTime, meta5m.MaxTime)
+	assert.Equal(t, meta.Compaction.Sources, meta5m.Compaction.Sources)
+
+	id1h := downsampleBlock(t, dir, meta5m, ResLevel2)
+	chks = readAggrChunks(t, dir, id1h, series)
+	assert.Equal(t, []floatSample{{t: 239 * scrapeInterval, f: 240}}, readFloats(t, chks, AggrCount))
+	assert.Equal(t, []floatSample{{t: 239 * scrapeInterval, f: 22380}}, readFloats(t, chks, AggrSum))
+	assert.Equal(t, []floatSample{{t: 239 * scrapeInterval, f: 0}}, readFloats(t, chks, AggrMin))
+	assert.Equal(t, []floatSample{{t: 239 * scrapeInterval, f: 209}}, readFloats(t, chks, AggrMax))
+	assert.Equal(t, []floatSample{{t: 239 * scrapeInterval, f: 238}}, readFloats(t, chks, AggrCounter))
+
+	// A block can't be downsampled to its own or a lower resolution.
+	_, err = Downsample(context.Background(), log.NewNopLogger(), meta5m, openBlock(t, dir, id5m), dir, ResLevel1)
+	require.Error(t, err)
+}
+
+func TestDownsample_Histograms(t *testing.T) {
+	dir := t.TempDir()
+	series := labels.FromStrings("__name__", "request_duration_seconds")
+
+	var samples []chunks.Sample
+	for i := 0; i < 240; i++ {
+		samples = append(samples, sample{t: int64(i) * scrapeInterval, fh: tsdbutil.GenerateTestFloatHistogram(i)})
+	}
+	meta := generateBlock(t, dir, series, samples)
+
+	id5m := downsampleBlock(t, dir, meta, ResLevel1)
+	chks := readAggrChunks(t, dir, id5m, series)
+
+	count := readFloats(t, chks, AggrCount)
+	require.Len(t, count, 12)
+	assert.Equal(t, floatSample{t: 19 * scrapeInterval, f: 20}, count[0])
+
+	sum := readHistograms(t, chks, AggrSum)
+	require.Len(t, sum, 12)
+	assert.Equal(t, 1950.0, sum[0].Count)
+
+	counter := readHistograms(t, chks, AggrCounter)
+	require.Len(t, counter, 12)
+	assert.Equal(t, tsdbutil.GenerateTestFloatHistogram(19).Count, counter[0].Count)
+
+	// The min and max aggregates are not stored for histograms.
+	for _, typ := range []AggrType{AggrMin, AggrMax} {
+		_, err := chks[0].Get(typ)
+		assert.ErrorIs(t, err, ErrAggrNotExist)
+	}
+
+	avg, err := chks[0].Average()
+	require.NoError(t, err)
+	it := avg.Iterator(nil)
+	require.Equal(t, chunkenc.ValFloatHistogram, it.Next())
+	_, h := it.AtFloatHistogram(nil)
+	assert.Equal(t, 97.5, h.Count)
+
+	// Downsample the 5m block to 1h.
+	meta5m, err := block.ReadMetaFromDir(filepath.Join(dir, id5m.String()))
+	require.NoError(t, err)
+
+	id1h := downsampleBlock(t, dir, meta5m, ResLevel2)
+	chks = readAggrChunks(t, dir, id1h, series)
+	assert.Equal(t, []floatSample{{t: 239 * scrapeInterval, f: 240}}, readFloats(t, chks, AggrCount))
+	counter = readHistograms(t, chks, AggrCounter)
+	require.Len(t, counter, 1)
+	assert.Equal(t, tsdbutil.GenerateTestFloatHistogram(239).Count, counter[0].Count)
+}
+
+func TestMergeAggrChunks(t *testing.T) {
+	dir := t.TempDir()
+	series := labels.FromStrings("__name__", "requests_total")
+
+	aggrChunkMetas := func(offset float64) []chunks.Meta {
+		var samples []chunks.Sample
+		for i := 0; i < 240; i++ {
+			samples = append(samples, sample{t: int64(i) * scrapeInterval, f: offset + float64(i)})
+		}
+		meta := generateBlock(t, dir, series, samples)
+
+		var metas []chunks.Meta
+		for _, chk := range readAggrChunks(t, dir, downsampleBlock(t, dir, meta, ResLevel1), series) {
+			metas = append(metas, chunks.Meta{Chunk: chk})
+		}
+		return metas
+	}
+	first, second := aggrChunkMetas(0), aggrChunkMetas(1000)
+
+	toAggrChunks := func(metas []chunks.Meta) []*AggrChunk {
+		var out []*AggrChunk
+		for _, meta := range metas {
+			out = append(out, meta.Chunk.(*AggrChunk))
+		}
+		return out
+	}
+
+	// A single series without deleted intervals is unchanged.
+	merged, err := MergeAggrChunks([][]chunks.Meta{first}, nil, ResLevel1)
+	require.NoError(t, err)
+	assert.Equal(t, readFloats(t, toAggrChunks(first), AggrSum), readFloats(t, toAggrChunks(merged), AggrSum))
+
+	// The windows overlapping the deleted intervals are removed.
+	deleted := []tombstones.Intervals{{{Mint: 25 * scrapeInterval, Maxt: 25 * scrapeInterval}}}
+	merged, err = MergeAggrChunks([][]chunks.Meta{first}, deleted, ResLevel1)
+	require.NoError(t, err)
+	count := readFloats(t, toAggrChunks(merged), AggrCount)
+	require.Len(t, count, 11)
+	assert.Equal(t, 19*scrapeInterval, count[0].t)
+	assert.Equal(t, 59*scrapeInterval, count[1].t)
+
+	// The windows of the first series are kept, and the deleted ones are taken from the next series.
+	merged, err = MergeAggrChunks([][]chunks.Meta{first, second}, deleted, ResLevel1)
+	require.NoError(t, err)
+	sum := readFloats(t, toAggrChunks(merged), AggrSum)
+	require.Len(t, sum, 12)
+	assert.Equal(t, floatSample{t: 19 * scrapeInterval, f: 190}, sum[0])
+	assert.Equal(t, floatSample{t: 39 * scrapeInterval, f: 20*1000 + 590}, sum[1])
+
+	// No chunks are returned when all the windows are deleted.
+	merged, err = MergeAggrChunks([][]chunks.Meta{first}, []tombstones.Intervals{{{Mint: 0, Maxt: 240 * scrapeInterval}}}, ResLevel1)
+	require.NoError(t, err)
+	assert.Empty(t, merged)
+}
+
+func TestAggrChunk_Get(t *testing.T) {
+	count := chunkenc.NewXORChunk()
+	app, err := count.Appender()
+	require.NoError(t, err)
+	app.Append(1, 2)
+
+	chk := EncodeAggrChunk([5]chunkenc.Chunk{AggrCount: count})
+	assert.Equal(t, 1, chk.NumSamples())
+	assert.Equal(t, ChunkEncAggr, chk.Encoding())
+
+	got, err := chk.Get(AggrCount)
+	require.NoError(t, err)
+	assert.Equal(t, count.Bytes(), got.Bytes())
+
+	_, err = chk.Get(AggrCounter)
+	assert.ErrorIs(t, err, ErrAggrNotExist)
+
+	// The chunks are read back from the pool.
+	fromPool, err := NewPool().Get(ChunkEncAggr, chk.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, chk, fromPool)
+
+	assert.Error(t, chk.Iterator(nil).Err())
+}
+
+type sample struct {
+	t  int64
+	f  float64
+	fh *histogram.FloatHistogram
+}
+
+func (s sample) T() int64                      { return s.t }
+func (s sample) F() float64                    { return s.f }
+func (s sample) H() *histogram.Histogram       { return nil }
+func (s sample) FH() *histogram.FloatHistogram { return s.fh }
+func (s sample) Copy() chunks.Sample           { return s }
+
+func (s sample) Type() chunkenc.ValueType {
+	if s.fh != nil {
+		return chunkenc.ValFloatHistogram
+	}
+	return chunkenc.ValFloat
+}
+
+type floatSample struct {
+	t int64
+	f float64
+}
+
+func generateBlock(t *testing.T, dir string, series labels.Labels, chunksSamples ...[]chunks.Sample) *block.Meta {
+	spec := &block.SeriesSpec{Labels: series}
+	for _, samples := range chunksSamples {
+		chk, err := chunks.ChunkFromSamples(samples)
+		require.NoError(t, err)
+		spec.Chunks = append(spec.Chunks, chk)
+	}
+
+	meta, err := block.GenerateBlockFromSpec(dir, block.SeriesSpecs{spec})
+	require.NoError(t, err)
+	return meta
+}
+
+func openBlock(t *testing.T, dir string, id ulid.ULID) *tsdb.Block {
+	b, err := tsdb.OpenBlock(log.NewNopLogger(), filepath.Join(dir, id.String()), NewPool())
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, b.Close()) })
+	return b
+}
+
+func downsampleBlock(t *testing.T, dir string, meta *block.Meta, resolution int64) ulid.ULID {
+	id, err := Downsample(context.Background(), log.NewNopLogger(), meta, openBlock(t, dir, meta.ULID), dir, resolution)
+	require.NoError(t, err)
+	return id
+}
+
+func readAggrChunks(t *testing.T, dir string, id ulid.ULID, series labels.Labels) []*AggrChunk {
+	b := openBlock(t, dir, id)
+
+	indexr, err := b.Index()
+	require.NoError(t, err)
+	defer indexr.Close()
+	chunkr, err := b.Chunks()
+	require.NoError(t, err)
+	defer chunkr.Close()
+
+	k, v := index.AllPostingsKey()
+	postings, err := indexr.Postings(context.Background(), k, v)
+	require.NoError(t, err)
+	require.True(t, postings.Next())
+
+	var (
+		builder labels.ScratchBuilder
+		metas   []chunks.Meta
+		out     []*AggrChunk
+	)
+	require.NoError(t, indexr.Series(postings.At(), &builder, &metas))
+	assert.Equal(t, series, builder.Labels())
+	require.False(t, postings.Next())
+
+	for _, meta := range metas {
+		chk, _, err := chunkr.ChunkOrIterable(meta)
+		require.NoError(t, err)
+		require.IsType(t, &AggrChunk{}, chk)
+		out = append(out, chk.(*AggrChunk))
+	}
+	return out
+}
+
+func readFloats(t *testing.T, chks []*AggrChunk, typ AggrType) []floatSample {
+	var out []floatSample
+	for _, c := range chks {
+		chk, err := c.Get(typ)
+		require.NoError(t, err)
+		it := chk.Iterator(nil)
+		for it.Next() == chunkenc.ValFloat {
+			ts, v := it.At()
+			out = append(out, floatSample{t: ts, f: v})
+		}
+		require.NoError(t, it.Err())
+	}
+	return out
+}
+
+func readHistograms(t *testing.T, chks []*AggrChunk, typ AggrType) []*histogram.FloatHistogram {
+	var out []*histogram.FloatHistogram
+	for _, c := range chks {
+		chk, err := c.Get(typ)
+		require.NoError(t, err)
+		it := chk.Iterator(nil)
+		for it.Next() == chunkenc.ValFloatHistogram {
+			_, h := it.AtFloatHistogram(nil)
+			out = append(out, h)
+		}
+		require.NoError(t, it.Err())
+	}
+	return out
+}
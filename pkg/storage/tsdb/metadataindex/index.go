@@ -0,0 +1,124 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package metadataindex
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/oklog/ulid"
+
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+)
+
+const (
+	IndexFilename           = "metadata-index.json"
+	IndexCompressedFilename = IndexFilename + ".gz"
+	IndexVersion1           = 1
+
+	// MaxEntriesPerMetric is the maximum number of metadata entries kept for each metric. When the metadata of
+	// a metric changes more often, the entries last seen the longest time ago are removed.
+	MaxEntriesPerMetric = 10
+)
+
+// Index contains the metric metadata of all the blocks of a tenant, including the metadata of metrics
+// whose blocks have since been compacted or deleted.
+type Index struct {
+	// Version of the index format.
+	Version int `json:"version"`
+
+	// List of the blocks whose metric metadata has been merged into the index.
+	Blocks []ulid.ULID `json:"blocks"`
+
+	// Metrics holds the metadata of each metric, sorted by the time it was last seen, newest first.
+	// A metric has multiple entries if its metadata (e.g. its type) has changed over time.
+	Metrics map[string][]Entry `json:"metrics"`
+
+	// UpdatedAt is a unix timestamp (seconds precision) of when the index has been updated
+	// (written in the storage) the last time.
+	UpdatedAt int64 `json:"updated_at"`
+}
+
+// Entry is a metric metadata, along with the time range of the blocks where it has been seen.
+type Entry struct {
+	Type string `json:"type"`
+	Help string `json:"help"`
+	Unit string `json:"unit"`
+
+	// MinTime and MaxTime are the min and max time (milliseconds precision) of the blocks where
+	// the metadata has been seen.
+	MinTime int64 `json:"min_time"`
+	MaxTime int64 `json:"max_time"`
+}
+
+func (idx *Index) GetUpdatedAt() time.Time {
+	return time.Unix(idx.UpdatedAt, 0)
+}
+
+// AddBlock merges the metric metadata of the block into the index.
+func (idx *Index) AddBlock(meta *block.Meta, metadata *block.MetricsMetadata) {
+	if idx.Metrics == nil {
+		idx.Metrics = map[string][]Entry{}
+	}
+
+	for _, m := range metadata.Metadata {
+		entries := idx.Metrics[m.Metric]
+
+		i := slices.IndexFunc(entries, func(e Entry) bool {
+			return e.Type == m.Type && e.Help == m.Help && e.Unit == m.Unit
+		})
+		if i < 0 {
+			entries = append(entries, Entry{Type: m.Type, Help: m.Help, Unit: m.Unit, MinTime: meta.MinTime, MaxTime: meta.MaxTime})
+		} else {
+			entries[i].MinTime = min(entries[i].MinTime, meta.MinTime)
+			entries[i].MaxTime = max(entries[i].MaxTime, meta.MaxTime)
+		}
+
+		slices.SortStableFunc(entries, compareEntries)
+		if len(entries) > MaxEntriesPerMetric {
+			entries = entries[:MaxEntriesPerMetric]
+		}
+		idx.Metrics[m.Metric] = entries
+	}
+
+	if !slices.Contains(idx.Blocks, meta.ULID) {
+		idx.Blocks = append(idx.Blocks, meta.ULID)
+	}
+}
+
+// RemoveEntriesBefore removes the metric metadata last seen in blocks whose max time is before minTime, and
+// the metrics left without metadata.
+func (idx *Index) RemoveEntriesBefore(minTime int64) {
+	for metric, entries := range idx.Metrics {
+		entries = slices.DeleteFunc(entries, func(e Entry) bool {
+			return e.MaxTime < minTime
+		})
+		if len(entries) == 0 {
+			delete(idx.Metrics, metric)
+		} else {
+			idx.Metrics[metric] = entries
+		}
+	}
+}
+
+// compareEntries sorts the entries by the time they've been last seen, newest first.
+func compareEntries(a, b Entry) int {
+	switch {
+	case a.MaxTime > b.MaxTime:
+		return -1
+	case a.MaxTime < b.MaxTime:
+		return 1
+	case a.MinTime > b.MinTime:
+		return -1
+	case a.MinTime < b.MinTime:
+		return 1
+	}
+	if c := strings.Compare(a.Type, b.Type); c != 0 {
+		return c
+	}
+	if c := strings.Compare(a.Help, b.Help); c != 0 {
+		return c
+	}
+	return strings.Compare(a.Unit, b.Unit)
+}
@@ -0,0 +1,89 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package metadataindex
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/oklog/ulid"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+)
+
+func TestIndex_AddBlock(t *testing.T) {
+	block1 := ulid.MustNew(1, nil)
+	block2 := ulid.MustNew(2, nil)
+	block3 := ulid.MustNew(3, nil)
+
+	idx := &Index{Version: IndexVersion1}
+	idx.AddBlock(&block.Meta{BlockMeta: tsdb.BlockMeta{ULID: block1, MinTime: 10, MaxTime: 20}}, &block.MetricsMetadata{Metadata: []block.MetricMetadata{
+		{Metric: "metric_1", Type: "gauge", Help: "First metric."},
+		{Metric: "metric_2", Type: "counter", Help: "Second metric."},
+	}})
+	idx.AddBlock(&block.Meta{BlockMeta: tsdb.BlockMeta{ULID: block2, MinTime: 20, MaxTime: 30}}, &block.MetricsMetadata{Metadata: []block.MetricMetadata{
+		{Metric: "metric_1", Type: "counter", Help: "First metric."},
+		{Metric: "metric_2", Type: "counter", Help: "Second metric."},
+	}})
+	// Blocks can be merged out of order.
+	idx.AddBlock(&block.Meta{BlockMeta: tsdb.BlockMeta{ULID: block3, MinTime: 0, MaxTime: 10}}, &block.MetricsMetadata{Metadata: []block.MetricMetadata{
+		{Metric: "metric_2", Type: "counter", Help: "Second metric."},
+	}})
+
+	assert.Equal(t, []ulid.ULID{block1, block2, block3}, idx.Blocks)
+	assert.Equal(t, map[string][]Entry{
+		// The type change is retained, newest first.
+		"metric_1": {
+			{Type: "counter", Help: "First metric.", MinTime: 20, MaxTime: 30},
+			{Type: "gauge", Help: "First metric.", MinTime: 10, MaxTime: 20},
+		},
+		"metric_2": {
+			{Type: "counter", Help: "Second metric.", MinTime: 0, MaxTime: 30},
+		},
+	}, idx.Metrics)
+}
+
+func TestIndex_AddBlock_ShouldLimitTheEntriesPerMetric(t *testing.T) {
+	idx := &Index{Version: IndexVersion1}
+	for i := 0; i < MaxEntriesPerMetric+5; i++ {
+		idx.AddBlock(&block.Meta{BlockMeta: tsdb.BlockMeta{ULID: ulid.MustNew(uint64(i), nil), MinTime: int64(i * 10), MaxTime: int64(i*10 + 10)}}, &block.MetricsMetadata{Metadata: []block.MetricMetadata{
+			{Metric: "metric_1", Type: "gauge", Help: fmt.Sprintf("Help %d.", i)},
+		}})
+	}
+
+	// The entries last seen the longest time ago are removed.
+	entries := idx.Metrics["metric_1"]
+	require.Len(t, entries, MaxEntriesPerMetric)
+	assert.Equal(t, fmt.Sprintf("Help %d.", MaxEntriesPerMetric+4), entries[0].Help)
+	assert.Equal(t, "Help 5.", entries[MaxEntriesPerMetric-1].Help)
+}
+
+func TestIndex_RemoveEntriesBefore(t *testing.T) {
+	idx := &Index{Version: IndexVersion1, Metrics: map[string][]Entry{
+		"metric_1": {
+			{Type: "counter", Help: "First metric.", MinTime: 20, MaxTime: 30},
+			{Type: "gauge", Help: "First metric.", MinTime: 10, MaxTime: 20},
+		},
+		"metric_2": {
+			{Type: "counter", Help: "Second metric.", MinTime: 0, MaxTime: 10},
+		},
+	}}
+
+	idx.RemoveEntriesBefore(20)
+	assert.Equal(t, map[string][]Entry{
+		"metric_1": {
+			{Type: "counter", Help: "First metric.", MinTime: 20, MaxTime: 30},
+			{Type: "gauge", Help: "First metric.", MinTime: 10, MaxTime: 20},
+		},
+	}, idx.Metrics)
+
+	idx.RemoveEntriesBefore(25)
+	assert.Equal(t, map[string][]Entry{
+		"metric_1": {
+			{Type: "counter", Help: "First metric.", MinTime: 20, MaxTime: 30},
+		},
+	}, idx.Metrics)
+}
@@ -0,0 +1,94 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package metadataindex
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/runutil"
+	"github.com/pkg/errors"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+)
+
+var (
+	ErrIndexNotFound  = errors.New("metadata index not found")
+	ErrIndexCorrupted = errors.New("metadata index corrupted")
+)
+
+// ReadIndex reads, parses and returns a metadata index from the bucket.
+// ReadIndex has a one-minute timeout for completing the read against the bucket.
+func ReadIndex(ctx context.Context, bkt objstore.Bucket, userID string, cfgProvider bucket.TenantConfigProvider, logger log.Logger) (*Index, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	userBkt := bucket.NewUserBucketClient(userID, bkt, cfgProvider)
+
+	// Get the metadata index.
+	reader, err := userBkt.WithExpectedErrs(userBkt.IsObjNotFoundErr).Get(ctx, IndexCompressedFilename)
+	if err != nil {
+		if userBkt.IsObjNotFoundErr(err) {
+			return nil, ErrIndexNotFound
+		}
+		return nil, errors.Wrap(err, "read metadata index")
+	}
+	defer runutil.CloseWithLogOnErr(logger, reader, "close metadata index reader")
+
+	gzipReader, err := gzip.NewReader(reader)
+	if err != nil {
+		return nil, ErrIndexCorrupted
+	}
+	defer runutil.CloseWithLogOnErr(logger, gzipReader, "close metadata index gzip reader")
+
+	index := &Index{}
+	if err := json.NewDecoder(gzipReader).Decode(index); err != nil {
+		return nil, ErrIndexCorrupted
+	}
+
+	return index, nil
+}
+
+// WriteIndex uploads the provided index to the storage.
+func WriteIndex(ctx context.Context, bkt objstore.Bucket, userID string, cfgProvider bucket.TenantConfigProvider, idx *Index) error {
+	bkt = bucket.NewUserBucketClient(userID, bkt, cfgProvider)
+
+	content, err := json.Marshal(idx)
+	if err != nil {
+		return errors.Wrap(err, "marshal metadata index")
+	}
+
+	var gzipContent bytes.Buffer
+	gzip := gzip.NewWriter(&gzipContent)
+	gzip.Name = IndexFilename
+
+	if _, err := gzip.Write(content); err != nil {
+		return errors.Wrap(err, "gzip metadata index")
+	}
+	if err := gzip.Close(); err != nil {
+		return errors.Wrap(err, "close gzip metadata index")
+	}
+
+	if err := bkt.Upload(ctx, IndexCompressedFilename, &gzipContent); err != nil {
+		return errors.Wrap(err, "upload metadata index")
+	}
+
+	return nil
+}
+
+// DeleteIndex deletes the metadata index from the storage. No error is returned if the index
+// does not exist.
+func DeleteIndex(ctx context.Context, bkt objstore.Bucket, userID string, cfgProvider bucket.TenantConfigProvider) error {
+	bkt = bucket.NewUserBucketClient(userID, bkt, cfgProvider)
+
+	err := bkt.Delete(ctx, IndexCompressedFilename)
+	if err != nil && !bkt.IsObjNotFoundErr(err) {
+		return errors.Wrap(err, "delete metadata index")
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package metadataindex
+
+import (
+	"context"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+)
+
+func TestReadIndex_ShouldReturnErrorIfIndexDoesNotExist(t *testing.T) {
+	bkt := objstore.NewInMemBucket()
+
+	idx, err := ReadIndex(context.Background(), bkt, "user-1", nil, log.NewNopLogger())
+	require.Equal(t, ErrIndexNotFound, err)
+	require.Nil(t, idx)
+}
+
+func TestReadIndex_ShouldReturnErrorIfIndexIsCorrupted(t *testing.T) {
+	bkt := objstore.NewInMemBucket()
+	require.NoError(t, bkt.Upload(context.Background(), path.Join("user-1", IndexCompressedFilename), strings.NewReader("invalid!}")))
+
+	idx, err := ReadIndex(context.Background(), bkt, "user-1", nil, log.NewNopLogger())
+	require.Equal(t, ErrIndexCorrupted, err)
+	require.Nil(t, idx)
+}
+
+func TestWriteIndex_ShouldBeReadByReadIndex(t *testing.T) {
+	bkt := objstore.NewInMemBucket()
+
+	expected := &Index{
+		Version: IndexVersion1,
+		Blocks:  []ulid.ULID{ulid.MustNew(1, nil)},
+		Metrics: map[string][]Entry{
+			"metric_1": {{Type: "counter", Help: "First metric.", Unit: "seconds", MinTime: 10, MaxTime: 20}},
+		},
+		UpdatedAt: 100,
+	}
+	require.NoError(t, WriteIndex(context.Background(), bkt, "user-1", nil, expected))
+
+	actual, err := ReadIndex(context.Background(), bkt, "user-1", nil, log.NewNopLogger())
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual)
+}
+
+func TestDeleteIndex(t *testing.T) {
+	bkt := objstore.NewInMemBucket()
+
+	// No error is returned if the index doesn't exist.
+	require.NoError(t, DeleteIndex(context.Background(), bkt, "user-1", nil))
+
+	require.NoError(t, WriteIndex(context.Background(), bkt, "user-1", nil, &Index{Version: IndexVersion1}))
+	require.NoError(t, DeleteIndex(context.Background(), bkt, "user-1", nil))
+
+	_, err := ReadIndex(context.Background(), bkt, "user-1", nil, log.NewNopLogger())
+	require.Equal(t, ErrIndexNotFound, err)
+}
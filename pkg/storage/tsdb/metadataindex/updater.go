@@ -0,0 +1,98 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package metadataindex
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/concurrency"
+	"github.com/oklog/ulid"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
+)
+
+// Updater is responsible to generate an updated in-memory metadata index.
+type Updater struct {
+	bkt         objstore.InstrumentedBucket
+	logger      log.Logger
+	concurrency int
+
+	retentionPeriod time.Duration
+}
+
+func NewUpdater(bkt objstore.Bucket, userID string, cfgProvider bucket.TenantConfigProvider, concurrency int, logger log.Logger) *Updater {
+	return &Updater{
+		bkt:         bucket.NewUserBucketClient(userID, bkt, cfgProvider),
+		concurrency: concurrency,
+		logger:      logger,
+	}
+}
+
+// WithRetentionPeriod enables the removal of the metric metadata last seen in blocks whose samples are all
+// older than the retention period (0 to keep it forever).
+func (w *Updater) WithRetentionPeriod(period time.Duration) *Updater {
+	w.retentionPeriod = period
+	return w
+}
+
+// UpdateIndex merges the metric metadata of the blocks which haven't been merged yet into the old index, and
+// returns the updated index, without storing it to the storage. Blocks which are no longer in the input list
+// are removed from the list of merged blocks, but their metric metadata is retained until it falls out of the
+// retention period.
+func (w *Updater) UpdateIndex(ctx context.Context, old *Index, blocks bucketindex.Blocks) (*Index, error) {
+	idx := &Index{Version: IndexVersion1, Metrics: map[string][]Entry{}}
+	merged := map[ulid.ULID]struct{}{}
+
+	// Use the old index if provided, and it is using the latest version format.
+	if old != nil && old.Version == IndexVersion1 {
+		if old.Metrics != nil {
+			idx.Metrics = old.Metrics
+		}
+		for _, id := range old.Blocks {
+			merged[id] = struct{}{}
+		}
+	}
+
+	var toMerge []*bucketindex.Block
+	for _, b := range blocks {
+		if _, ok := merged[b.ID]; ok {
+			idx.Blocks = append(idx.Blocks, b.ID)
+			continue
+		}
+		toMerge = append(toMerge, b)
+	}
+
+	var mtx sync.Mutex
+	err := concurrency.ForEachJob(ctx, len(toMerge), w.concurrency, func(ctx context.Context, jobIdx int) error {
+		b := toMerge[jobIdx]
+
+		metadata, err := block.DownloadMetricsMetadata(ctx, w.bkt, b.ID)
+		if err != nil {
+			// The block will be retried at the next update.
+			level.Warn(w.logger).Log("msg", "failed to read the metric metadata of the block", "block", b.ID, "err", err)
+			return nil
+		}
+
+		mtx.Lock()
+		idx.AddBlock(b.ThanosMeta(), metadata)
+		mtx.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if w.retentionPeriod > 0 {
+		idx.RemoveEntriesBefore(time.Now().Add(-w.retentionPeriod).UnixMilli())
+	}
+
+	idx.UpdatedAt = time.Now().Unix()
+	return idx, nil
+}
@@ -0,0 +1,96 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package metadataindex
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
+)
+
+func TestUpdater_UpdateIndex(t *testing.T) {
+	const userID = "user-1"
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+	bkt := objstore.NewInMemBucket()
+
+	block1 := ulid.MustNew(1, nil)
+	block2 := ulid.MustNew(2, nil)
+	block3 := ulid.MustNew(3, nil)
+
+	uploadMetricsMetadata(t, bkt, userID, block1, block.MetricMetadata{Metric: "metric_1", Type: "gauge", Help: "First metric."})
+	uploadMetricsMetadata(t, bkt, userID, block2, block.MetricMetadata{Metric: "metric_1", Type: "counter", Help: "First metric."})
+
+	w := NewUpdater(bkt, userID, nil, 1, logger)
+
+	// Build the index from scratch. Blocks without metric metadata are considered merged.
+	idx, err := w.UpdateIndex(ctx, nil, bucketindex.Blocks{
+		{ID: block1, MinTime: 10, MaxTime: 20},
+		{ID: block3, MinTime: 10, MaxTime: 20},
+	})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []ulid.ULID{block1, block3}, idx.Blocks)
+	assert.Equal(t, map[string][]Entry{
+		"metric_1": {{Type: "gauge", Help: "First metric.", MinTime: 10, MaxTime: 20}},
+	}, idx.Metrics)
+
+	// Block 1 has been deleted and block 2 has been uploaded: the metadata of block 1 is retained.
+	idx, err = w.UpdateIndex(ctx, idx, bucketindex.Blocks{
+		{ID: block2, MinTime: 20, MaxTime: 30},
+		{ID: block3, MinTime: 10, MaxTime: 20},
+	})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []ulid.ULID{block2, block3}, idx.Blocks)
+	assert.Equal(t, map[string][]Entry{
+		"metric_1": {
+			{Type: "counter", Help: "First metric.", MinTime: 20, MaxTime: 30},
+			{Type: "gauge", Help: "First metric.", MinTime: 10, MaxTime: 20},
+		},
+	}, idx.Metrics)
+}
+
+func TestUpdater_UpdateIndex_ShouldRemoveTheMetadataOutOfTheRetentionPeriod(t *testing.T) {
+	const userID = "user-1"
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+
+	now := time.Now()
+	block1 := ulid.MustNew(1, nil)
+	uploadMetricsMetadata(t, bkt, userID, block1, block.MetricMetadata{Metric: "metric_1", Type: "gauge", Help: "First metric."})
+
+	old := &Index{Version: IndexVersion1, Metrics: map[string][]Entry{
+		"metric_1": {{Type: "counter", Help: "First metric.", MinTime: now.Add(-3 * time.Hour).UnixMilli(), MaxTime: now.Add(-2 * time.Hour).UnixMilli()}},
+		"metric_2": {{Type: "counter", Help: "Second metric.", MinTime: now.Add(-3 * time.Hour).UnixMilli(), MaxTime: now.Add(-2 * time.Hour).UnixMilli()}},
+	}}
+
+	w := NewUpdater(bkt, userID, nil, 1, log.NewNopLogger()).WithRetentionPeriod(time.Hour)
+	idx, err := w.UpdateIndex(ctx, old, bucketindex.Blocks{
+		{ID: block1, MinTime: now.Add(-30 * time.Minute).UnixMilli(), MaxTime: now.UnixMilli()},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]Entry{
+		"metric_1": {{Type: "gauge", Help: "First metric.", MinTime: now.Add(-30 * time.Minute).UnixMilli(), MaxTime: now.UnixMilli()}},
+	}, idx.Metrics)
+}
+
+func uploadMetricsMetadata(t *testing.T, bkt objstore.Bucket, userID string, id ulid.ULID, metadata ...block.MetricMetadata) {
+	dir := t.TempDir()
+	require.NoError(t, block.WriteMetricsMetadataFile(dir, &block.MetricsMetadata{Metadata: metadata}))
+
+	content, err := os.ReadFile(filepath.Join(dir, block.MetricsMetadataFilename))
+	require.NoError(t, err)
+	require.NoError(t, bkt.Upload(context.Background(), path.Join(userID, id.String(), block.MetricsMetadataFilename), bytes.NewReader(content)))
+}
@@ -0,0 +1,326 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package metricsusage
+
+import (
+	"context"
+	"maps"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/services"
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/util/zeropool"
+	"github.com/segmentio/fasthash/fnv1a"
+	"github.com/thanos-io/objstore"
+	"go.uber.org/atomic"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	util_log "github.com/grafana/mimir/pkg/util/log"
+)
+
+const (
+	numStripes = 64
+
+	// staleUsageFileFlushIntervals is the number of flush intervals after which the usage file of another instance
+	// is considered stale, e.g. because the instance has been scaled down.
+	staleUsageFileFlushIntervals = 10
+)
+
+var recordersPool = zeropool.New(func() *Recorder {
+	return &Recorder{metrics: map[string]struct{}{}}
+})
+
+// Tracker tracks the last time each metric name has been queried, for each tenant, and periodically
+// stores it in the tenant's bucket, so that it survives restarts and can be read by the queriers.
+// The tenants which haven't been queried for a flush interval are removed from memory once their
+// usage is stored, and the metrics which haven't been queried for the retention period are removed.
+type Tracker struct {
+	services.Service
+
+	bkt         objstore.Bucket
+	cfgProvider bucket.TenantConfigProvider
+	filename    string
+	logger      log.Logger
+
+	// staleTimeout is the time after which the usage files not updated by other instances are merged into
+	// the usage of this instance and deleted.
+	staleTimeout time.Duration
+
+	// retentionPeriod is the time after which the metrics which haven't been queried are removed. 0 to keep
+	// them forever.
+	retentionPeriod time.Duration
+
+	tenantsMtx sync.RWMutex
+	tenants    map[string]*tenantUsage
+}
+
+type tenantUsage struct {
+	since   int64
+	stripes [numStripes]usageStripe
+
+	// loaded is true once the usage previously stored by this instance has been merged.
+	// It's only accessed by the flushing goroutine.
+	loaded bool
+
+	// recorded is true if any metric has been recorded since the previous flush.
+	recorded atomic.Bool
+}
+
+type usageStripe struct {
+	mtx     sync.Mutex
+	metrics map[string]int64
+}
+
+// NewTracker makes a new Tracker for the instance of the component, which stores the metrics usage every
+// flushInterval and when stopped.
+func NewTracker(bkt objstore.Bucket, cfgProvider bucket.TenantConfigProvider, component, instanceID string, flushInterval, retentionPeriod time.Duration, logger log.Logger) *Tracker {
+	t := &Tracker{
+		bkt:             bkt,
+		cfgProvider:     cfgProvider,
+		filename:        usageFilename(component, instanceID),
+		logger:          logger,
+		staleTimeout:    staleUsageFileFlushIntervals * flushInterval,
+		retentionPeriod: retentionPeriod,
+		tenants:         map[string]*tenantUsage{},
+	}
+
+	t.Service = services.NewTimerService(flushInterval, nil, t.iteration, t.stopping)
+	return t
+}
+
+func (t *Tracker) iteration(ctx context.Context) error {
+	t.flush(ctx)
+	return nil
+}
+
+func (t *Tracker) stopping(_ error) error {
+	t.flush(context.Background())
+	return nil
+}
+
+// NewRecorder returns a Recorder to collect the metric names queried by a single request. The Recorder must be
+// passed to Record once the request completes.
+func (t *Tracker) NewRecorder() *Recorder {
+	return recordersPool.Get()
+}
+
+// Record records the metric names collected by the Recorder as queried at the given time, and releases the Recorder.
+func (t *Tracker) Record(userID string, r *Recorder, now time.Time) {
+	defer r.release()
+
+	if len(r.metrics) == 0 {
+		return
+	}
+
+	// The tenants lock is held while recording, so that the tenant is not removed in the meanwhile.
+	u := t.readLockTenant(userID, now)
+	defer t.tenantsMtx.RUnlock()
+
+	u.recorded.Store(true)
+	ts := now.UnixMilli()
+	for metric := range r.metrics {
+		s := &u.stripes[stripeFor(metric)]
+		s.mtx.Lock()
+		s.metrics[metric] = ts
+		s.mtx.Unlock()
+	}
+}
+
+// readLockTenant returns the usage of the tenant, creating it if it doesn't exist, with the tenants read lock held.
+func (t *Tracker) readLockTenant(userID string, now time.Time) *tenantUsage {
+	for {
+		t.tenantsMtx.RLock()
+		if u := t.tenants[userID]; u != nil {
+			return u
+		}
+		t.tenantsMtx.RUnlock()
+
+		t.tenantsMtx.Lock()
+		// Ensure it was not created between switching locks.
+		if t.tenants[userID] == nil {
+			u := &tenantUsage{since: now.UnixMilli()}
+			for i := range u.stripes {
+				u.stripes[i].metrics = map[string]int64{}
+			}
+			t.tenants[userID] = u
+		}
+		t.tenantsMtx.Unlock()
+	}
+}
+
+// flush stores the metrics usage of each tenant in the bucket. This is a best effort, so errors are only logged.
+func (t *Tracker) flush(ctx context.Context) {
+	t.tenantsMtx.RLock()
+	userIDs := make([]string, 0, len(t.tenants))
+	for userID := range t.tenants {
+		userIDs = append(userIDs, userID)
+	}
+	t.tenantsMtx.RUnlock()
+
+	for _, userID := range userIDs {
+		t.tenantsMtx.RLock()
+		u := t.tenants[userID]
+		t.tenantsMtx.RUnlock()
+
+		recorded := u.recorded.Swap(false)
+		if err := t.flushTenant(ctx, userID, u); err != nil {
+			level.Warn(t.logger).Log("msg", "failed to store metrics usage", "user", userID, "err", err)
+			continue
+		}
+
+		// The usage of the tenants which haven't been queried since the previous flush is stored, so they're
+		// removed from memory, and the stored usage is merged again once they're queried.
+		if !recorded {
+			t.tenantsMtx.Lock()
+			if !u.recorded.Load() {
+				delete(t.tenants, userID)
+			}
+			t.tenantsMtx.Unlock()
+		}
+	}
+}
+
+func (t *Tracker) flushTenant(ctx context.Context, userID string, u *tenantUsage) error {
+	userBkt := bucket.NewUserBucketClient(userID, t.bkt, t.cfgProvider)
+	logger := util_log.WithUserID(userID, t.logger)
+	name := t.filename
+
+	// Merge the usage previously stored by this instance, e.g. before a restart.
+	if !u.loaded {
+		prev, err := readUsageFile(ctx, userBkt, name, logger)
+		if err != nil {
+			return err
+		}
+		if prev != nil {
+			u.merge(prev)
+		}
+		u.loaded = true
+	}
+
+	// Merge the usage stored by the instances which don't run anymore, so that it's not lost once their files are deleted.
+	stale, err := t.staleUsageFiles(ctx, userBkt, name)
+	if err != nil {
+		// The stale files are deleted by a later flush.
+		level.Warn(logger).Log("msg", "failed to find the stale metrics usage files", "err", err)
+	}
+	for _, staleName := range stale {
+		prev, err := readUsageFile(ctx, userBkt, staleName, logger)
+		if err != nil {
+			return err
+		}
+		if prev != nil {
+			u.merge(prev)
+		}
+	}
+
+	if t.retentionPeriod > 0 {
+		u.removeQueriedBefore(time.Now().Add(-t.retentionPeriod).UnixMilli())
+	}
+	if err := writeUsageFile(ctx, userBkt, name, u.snapshot()); err != nil {
+		return err
+	}
+
+	for _, staleName := range stale {
+		if err := userBkt.Delete(ctx, staleName); err != nil && !userBkt.IsObjNotFoundErr(err) {
+			return errors.Wrapf(err, "delete stale metrics usage file %s", staleName)
+		}
+		level.Info(logger).Log("msg", "merged and deleted stale metrics usage file", "file", staleName)
+	}
+	return nil
+}
+
+// staleUsageFiles returns the usage files of the other instances which haven't been updated for the stale timeout.
+func (t *Tracker) staleUsageFiles(ctx context.Context, userBkt objstore.Bucket, ownName string) ([]string, error) {
+	var stale []string
+	err := userBkt.Iter(ctx, UsagePrefix+"/", func(name string) error {
+		if name == ownName || !strings.HasSuffix(name, usageFileSuffix) {
+			return nil
+		}
+
+		attrs, err := userBkt.Attributes(ctx, name)
+		if userBkt.IsObjNotFoundErr(err) {
+			// Deleted by another instance in the meanwhile.
+			return nil
+		}
+		if err != nil {
+			return errors.Wrapf(err, "get attributes of metrics usage file %s", name)
+		}
+		if time.Since(attrs.LastModified) >= t.staleTimeout {
+			stale = append(stale, name)
+		}
+		return nil
+	})
+	return stale, err
+}
+
+// merge merges the usage into u, keeping the latest queried time of each metric and the earliest tracking start.
+func (u *tenantUsage) merge(other *Usage) {
+	for metric, ts := range other.Metrics {
+		s := &u.stripes[stripeFor(metric)]
+		s.mtx.Lock()
+		s.metrics[metric] = max(s.metrics[metric], ts)
+		s.mtx.Unlock()
+	}
+	if other.Since != 0 {
+		u.since = min(u.since, other.Since)
+	}
+}
+
+// removeQueriedBefore removes the metrics which have been last queried before the timestamp.
+func (u *tenantUsage) removeQueriedBefore(ts int64) {
+	for i := range u.stripes {
+		s := &u.stripes[i]
+		s.mtx.Lock()
+		maps.DeleteFunc(s.metrics, func(_ string, queried int64) bool {
+			return queried < ts
+		})
+		s.mtx.Unlock()
+	}
+}
+
+func (u *tenantUsage) snapshot() *Usage {
+	out := &Usage{Version: UsageVersion1, Since: u.since, Metrics: map[string]int64{}}
+	for i := range u.stripes {
+		s := &u.stripes[i]
+		s.mtx.Lock()
+		for metric, ts := range s.metrics {
+			out.Metrics[metric] = ts
+		}
+		s.mtx.Unlock()
+	}
+	return out
+}
+
+func stripeFor(metric string) int {
+	return int(fnv1a.HashString32(metric) % numStripes)
+}
+
+// Recorder collects the metric names of the series queried by a single request. It's not concurrency safe.
+type Recorder struct {
+	metrics map[string]struct{}
+}
+
+// Add records the metric name of the series.
+func (r *Recorder) Add(series []mimirpb.LabelAdapter) {
+	for _, l := range series {
+		if l.Name != model.MetricNameLabel {
+			continue
+		}
+		if _, ok := r.metrics[l.Value]; !ok {
+			// The labels may reference memory which is reused while the request is still running.
+			r.metrics[strings.Clone(l.Value)] = struct{}{}
+		}
+		return
+	}
+}
+
+func (r *Recorder) release() {
+	clear(r.metrics)
+	recordersPool.Put(r)
+}
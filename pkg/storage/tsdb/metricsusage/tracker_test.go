@@ -0,0 +1,202 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package metricsusage
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/services"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+func TestTracker_RecordAndFlush(t *testing.T) {
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+	now := time.UnixMilli(10000)
+
+	tracker := NewTracker(bkt, nil, "ingester", "ingester-1", time.Hour, 0, log.NewNopLogger())
+	require.NoError(t, services.StartAndAwaitRunning(ctx, tracker))
+
+	r := tracker.NewRecorder()
+	r.Add(mimirpb.FromLabelsToLabelAdapters(labels.FromStrings("__name__", "metric_1", "job", "test")))
+	r.Add(mimirpb.FromLabelsToLabelAdapters(labels.FromStrings("__name__", "metric_1", "job", "other")))
+	r.Add(mimirpb.FromLabelsToLabelAdapters(labels.FromStrings("__name__", "metric_2")))
+	r.Add(mimirpb.FromLabelsToLabelAdapters(labels.FromStrings("job", "no-metric-name")))
+	tracker.Record("user-1", r, now)
+
+	r = tracker.NewRecorder()
+	r.Add(mimirpb.FromLabelsToLabelAdapters(labels.FromStrings("__name__", "metric_2")))
+	tracker.Record("user-1", r, now.Add(time.Second))
+
+	// Empty recorders don't create the tenant.
+	tracker.Record("user-2", tracker.NewRecorder(), now)
+
+	// The usage is stored when the tracker stops.
+	require.NoError(t, services.StopAndAwaitTerminated(ctx, tracker))
+
+	u, err := ReadUsage(ctx, bkt, "user-1", nil, log.NewNopLogger())
+	require.NoError(t, err)
+	assert.Equal(t, &Usage{Version: UsageVersion1, Since: 10000, Metrics: map[string]int64{"metric_1": 10000, "metric_2": 11000}}, u)
+
+	u, err = ReadUsage(ctx, bkt, "user-2", nil, log.NewNopLogger())
+	require.NoError(t, err)
+	assert.Empty(t, u.Metrics)
+
+	// A new tracker for the same instance, e.g. after a restart, merges the previously stored usage.
+	tracker = NewTracker(bkt, nil, "ingester", "ingester-1", time.Hour, 0, log.NewNopLogger())
+	require.NoError(t, services.StartAndAwaitRunning(ctx, tracker))
+
+	r = tracker.NewRecorder()
+	r.Add(mimirpb.FromLabelsToLabelAdapters(labels.FromStrings("__name__", "metric_3")))
+	tracker.Record("user-1", r, now.Add(time.Minute))
+	require.NoError(t, services.StopAndAwaitTerminated(ctx, tracker))
+
+	u, err = ReadUsage(ctx, bkt, "user-1", nil, log.NewNopLogger())
+	require.NoError(t, err)
+	assert.Equal(t, &Usage{Version: UsageVersion1, Since: 10000, Metrics: map[string]int64{"metric_1": 10000, "metric_2": 11000, "metric_3": 70000}}, u)
+}
+
+func TestReadUsage_ShouldMergeAllInstances(t *testing.T) {
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+
+	tracker1 := NewTracker(bkt, nil, "ingester", "ingester-1", time.Hour, 0, log.NewNopLogger())
+	tracker2 := NewTracker(bkt, nil, "store-gateway", "store-gateway-1", time.Hour, 0, log.NewNopLogger())
+	require.NoError(t, services.StartAndAwaitRunning(ctx, tracker1))
+	require.NoError(t, services.StartAndAwaitRunning(ctx, tracker2))
+
+	r := tracker1.NewRecorder()
+	r.Add(mimirpb.FromLabelsToLabelAdapters(labels.FromStrings("__name__", "metric_1")))
+	r.Add(mimirpb.FromLabelsToLabelAdapters(labels.FromStrings("__name__", "metric_2")))
+	tracker1.Record("user-1", r, time.UnixMilli(20000))
+
+	r = tracker2.NewRecorder()
+	r.Add(mimirpb.FromLabelsToLabelAdapters(labels.FromStrings("__name__", "metric_2")))
+	r.Add(mimirpb.FromLabelsToLabelAdapters(labels.FromStrings("__name__", "metric_3")))
+	tracker2.Record("user-1", r, time.UnixMilli(10000))
+
+	require.NoError(t, services.StopAndAwaitTerminated(ctx, tracker1))
+	require.NoError(t, services.StopAndAwaitTerminated(ctx, tracker2))
+
+	// Unrelated objects are ignored.
+	require.NoError(t, bkt.Upload(ctx, "user-1/"+UsagePrefix+"/README", strings.NewReader("")))
+
+	u, err := ReadUsage(ctx, bkt, "user-1", nil, log.NewNopLogger())
+	require.NoError(t, err)
+	assert.Equal(t, &Usage{Version: UsageVersion1, Since: 10000, Metrics: map[string]int64{"metric_1": 20000, "metric_2": 20000, "metric_3": 10000}}, u)
+}
+
+func TestTracker_ShouldMergeAndDeleteStaleUsageFiles(t *testing.T) {
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+
+	// The usage stored by an instance which has been scaled down.
+	scaledDown := NewTracker(bkt, nil, "ingester", "ingester-2", time.Hour, 0, log.NewNopLogger())
+	require.NoError(t, services.StartAndAwaitRunning(ctx, scaledDown))
+	r := scaledDown.NewRecorder()
+	r.Add(mimirpb.FromLabelsToLabelAdapters(labels.FromStrings("__name__", "metric_1")))
+	scaledDown.Record("user-1", r, time.UnixMilli(10000))
+	require.NoError(t, services.StopAndAwaitTerminated(ctx, scaledDown))
+
+	tracker := NewTracker(bkt, nil, "ingester", "ingester-1", time.Hour, 0, log.NewNopLogger())
+	r = tracker.NewRecorder()
+	r.Add(mimirpb.FromLabelsToLabelAdapters(labels.FromStrings("__name__", "metric_2")))
+	tracker.Record("user-1", r, time.UnixMilli(20000))
+
+	// The usage file of the other instance is not stale yet.
+	tracker.flush(ctx)
+	exists, err := bkt.Exists(ctx, "user-1/"+usageFilename("ingester", "ingester-2"))
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	tracker.staleTimeout = 0
+	tracker.flush(ctx)
+	exists, err = bkt.Exists(ctx, "user-1/"+usageFilename("ingester", "ingester-2"))
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	// The usage of the stale file has been merged into the usage of this instance.
+	u, err := ReadUsage(ctx, bkt, "user-1", nil, log.NewNopLogger())
+	require.NoError(t, err)
+	assert.Equal(t, &Usage{Version: UsageVersion1, Since: 10000, Metrics: map[string]int64{"metric_1": 10000, "metric_2": 20000}}, u)
+}
+
+func TestTracker_ShouldNotOverwriteTheUsageOfOtherComponentsWithTheSameInstanceID(t *testing.T) {
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+
+	// Both instance IDs default to the hostname.
+	ingester := NewTracker(bkt, nil, "ingester", "host-1", time.Hour, 0, log.NewNopLogger())
+	storeGateway := NewTracker(bkt, nil, "store-gateway", "host-1", time.Hour, 0, log.NewNopLogger())
+
+	r := ingester.NewRecorder()
+	r.Add(mimirpb.FromLabelsToLabelAdapters(labels.FromStrings("__name__", "metric_1")))
+	ingester.Record("user-1", r, time.UnixMilli(10000))
+	ingester.flush(ctx)
+
+	r = storeGateway.NewRecorder()
+	r.Add(mimirpb.FromLabelsToLabelAdapters(labels.FromStrings("__name__", "metric_2")))
+	storeGateway.Record("user-1", r, time.UnixMilli(20000))
+	storeGateway.flush(ctx)
+
+	u, err := ReadUsage(ctx, bkt, "user-1", nil, log.NewNopLogger())
+	require.NoError(t, err)
+	assert.Equal(t, &Usage{Version: UsageVersion1, Since: 10000, Metrics: map[string]int64{"metric_1": 10000, "metric_2": 20000}}, u)
+}
+
+func TestTracker_ShouldRemoveTheMetricsNotQueriedWithinTheRetentionPeriod(t *testing.T) {
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+	now := time.Now()
+
+	tracker := NewTracker(bkt, nil, "ingester", "ingester-1", time.Hour, 24*time.Hour, log.NewNopLogger())
+	r := tracker.NewRecorder()
+	r.Add(mimirpb.FromLabelsToLabelAdapters(labels.FromStrings("__name__", "metric_1")))
+	tracker.Record("user-1", r, now.Add(-48*time.Hour))
+
+	r = tracker.NewRecorder()
+	r.Add(mimirpb.FromLabelsToLabelAdapters(labels.FromStrings("__name__", "metric_2")))
+	tracker.Record("user-1", r, now.Add(-time.Hour))
+	tracker.flush(ctx)
+
+	u, err := ReadUsage(ctx, bkt, "user-1", nil, log.NewNopLogger())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{"metric_2": now.Add(-time.Hour).UnixMilli()}, u.Metrics)
+}
+
+func TestTracker_ShouldRemoveTheIdleTenantsOnceFlushed(t *testing.T) {
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+
+	tracker := NewTracker(bkt, nil, "ingester", "ingester-1", time.Hour, 0, log.NewNopLogger())
+	r := tracker.NewRecorder()
+	r.Add(mimirpb.FromLabelsToLabelAdapters(labels.FromStrings("__name__", "metric_1")))
+	tracker.Record("user-1", r, time.UnixMilli(10000))
+
+	// The tenant has been queried since the previous flush.
+	tracker.flush(ctx)
+	require.Contains(t, tracker.tenants, "user-1")
+
+	// The tenant hasn't been queried since the previous flush.
+	tracker.flush(ctx)
+	require.NotContains(t, tracker.tenants, "user-1")
+
+	// The stored usage is merged once the tenant is queried again.
+	r = tracker.NewRecorder()
+	r.Add(mimirpb.FromLabelsToLabelAdapters(labels.FromStrings("__name__", "metric_2")))
+	tracker.Record("user-1", r, time.UnixMilli(20000))
+	tracker.flush(ctx)
+
+	u, err := ReadUsage(ctx, bkt, "user-1", nil, log.NewNopLogger())
+	require.NoError(t, err)
+	assert.Equal(t, &Usage{Version: UsageVersion1, Since: 10000, Metrics: map[string]int64{"metric_1": 10000, "metric_2": 20000}}, u)
+}
@@ -0,0 +1,133 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package metricsusage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"path"
+	"strings"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/runutil"
+	"github.com/pkg/errors"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+)
+
+const (
+	// UsagePrefix is the prefix of the metrics usage files in the tenant's bucket. Each instance tracking
+	// the metrics usage stores a file named after its component and instance ID.
+	UsagePrefix = "metrics-usage"
+
+	usageFileSuffix = ".json.gz"
+
+	UsageVersion1 = 1
+)
+
+// Usage holds the last time each metric name has been queried.
+type Usage struct {
+	// Version of the usage file format.
+	Version int `json:"version"`
+
+	// Since is the unix timestamp (milliseconds precision) of when the tracking started.
+	Since int64 `json:"since"`
+
+	// Metrics maps each metric name to the unix timestamp (milliseconds precision) it has been last queried.
+	Metrics map[string]int64 `json:"metrics"`
+}
+
+// Merge merges other into u, keeping the latest queried time of each metric and the earliest tracking start.
+func (u *Usage) Merge(other *Usage) {
+	if u.Metrics == nil {
+		u.Metrics = map[string]int64{}
+	}
+	if u.Since == 0 || (other.Since != 0 && other.Since < u.Since) {
+		u.Since = other.Since
+	}
+	for metric, ts := range other.Metrics {
+		u.Metrics[metric] = max(u.Metrics[metric], ts)
+	}
+}
+
+// ReadUsage reads the metrics usage files of all the instances in the tenant's bucket and merges them.
+// If no file exists, an empty usage is returned.
+func ReadUsage(ctx context.Context, bkt objstore.Bucket, userID string, cfgProvider bucket.TenantConfigProvider, logger log.Logger) (*Usage, error) {
+	userBkt := bucket.NewUserBucketClient(userID, bkt, cfgProvider)
+
+	merged := &Usage{Version: UsageVersion1, Metrics: map[string]int64{}}
+	err := userBkt.Iter(ctx, UsagePrefix+"/", func(name string) error {
+		if !strings.HasSuffix(name, usageFileSuffix) {
+			return nil
+		}
+
+		u, err := readUsageFile(ctx, userBkt, name, logger)
+		if err != nil {
+			return err
+		}
+		if u != nil {
+			merged.Merge(u)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "read metrics usage")
+	}
+
+	return merged, nil
+}
+
+// readUsageFile reads the metrics usage file with the given name. If the file doesn't exist, nil is returned.
+func readUsageFile(ctx context.Context, bkt objstore.InstrumentedBucket, name string, logger log.Logger) (*Usage, error) {
+	reader, err := bkt.WithExpectedErrs(bkt.IsObjNotFoundErr).Get(ctx, name)
+	if bkt.IsObjNotFoundErr(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "get metrics usage file %s", name)
+	}
+	defer runutil.CloseWithLogOnErr(logger, reader, "close metrics usage reader")
+
+	gzipReader, err := gzip.NewReader(reader)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read metrics usage file %s", name)
+	}
+	defer runutil.CloseWithLogOnErr(logger, gzipReader, "close metrics usage gzip reader")
+
+	u := &Usage{}
+	if err := json.NewDecoder(gzipReader).Decode(u); err != nil {
+		return nil, errors.Wrapf(err, "decode metrics usage file %s", name)
+	}
+	if u.Version != UsageVersion1 {
+		return nil, errors.Errorf("unexpected metrics usage file %s version %d", name, u.Version)
+	}
+	return u, nil
+}
+
+// writeUsageFile uploads the metrics usage file with the given name.
+func writeUsageFile(ctx context.Context, bkt objstore.Bucket, name string, u *Usage) error {
+	content, err := json.Marshal(u)
+	if err != nil {
+		return errors.Wrap(err, "marshal metrics usage")
+	}
+
+	var gzipContent bytes.Buffer
+	gzip := gzip.NewWriter(&gzipContent)
+	if _, err := gzip.Write(content); err != nil {
+		return errors.Wrap(err, "gzip metrics usage")
+	}
+	if err := gzip.Close(); err != nil {
+		return errors.Wrap(err, "close gzip metrics usage")
+	}
+
+	return errors.Wrap(bkt.Upload(ctx, name, &gzipContent), "upload metrics usage")
+}
+
+// usageFilename returns the name of the usage file of the instance. The instance IDs of different components
+// default to the hostname, so the component name is part of the file name.
+func usageFilename(component, instanceID string) string {
+	return path.Join(UsagePrefix, component+"-"+instanceID+usageFileSuffix)
+}
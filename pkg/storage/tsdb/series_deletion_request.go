@@ -0,0 +1,295 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package tsdb
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/tsdb/tombstones"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	"github.com/grafana/mimir/pkg/util"
+)
+
+// Relative to user-specific prefix.
+const SeriesDeletionRequestsPath = "markers/series-deletion-requests"
+
+// SeriesDeletionRequest is a request to delete the samples of the series matching any of the selectors
+// in the [MinTime, MaxTime] time range. It's stored in the bucket, and applied by ingesters as head tombstones,
+// filtered out at read time by queriers and store-gateways, and permanently removed from the blocks by the compactor.
+type SeriesDeletionRequest struct {
+	ID        string   `json:"id"`
+	Selectors []string `json:"selectors"`
+
+	// Time range of the samples to delete, in milliseconds. Both ends are inclusive.
+	MinTime int64 `json:"min_time"`
+	MaxTime int64 `json:"max_time"`
+
+	// Unix timestamp when the request was created.
+	CreationTime util.UnixSeconds `json:"creation_time"`
+
+	// The following fields are updated by the compactor while it rewrites the blocks.
+
+	// Number of blocks rewritten so far because they contained samples to delete.
+	RewrittenBlocks int `json:"rewritten_blocks"`
+	// Number of blocks still to check, as of the last compactor run.
+	PendingBlocks int `json:"pending_blocks"`
+	// Unix timestamp of the last compactor run which checked the blocks.
+	LastCheckTime util.UnixSeconds `json:"last_check_time,omitempty"`
+	// Unix timestamp when the compactor has finished rewriting the blocks.
+	ProcessedTime util.UnixSeconds `json:"processed_time,omitempty"`
+
+	matchers [][]*labels.Matcher
+}
+
+// NewSeriesDeletionRequest validates the selectors and the time range, and returns a new request.
+func NewSeriesDeletionRequest(selectors []string, minTime, maxTime int64, now time.Time) (*SeriesDeletionRequest, error) {
+	if len(selectors) == 0 {
+		return nil, errors.New("at least one series selector is required")
+	}
+	// Samples received after the request has been created are not deleted.
+	maxTime = min(maxTime, now.UnixMilli())
+	if minTime > maxTime {
+		return nil, errors.New("the start time must be before or equal to the end time, and not in the future")
+	}
+
+	req := &SeriesDeletionRequest{
+		ID:           ulid.MustNew(ulid.Timestamp(now), rand.Reader).String(),
+		Selectors:    selectors,
+		MinTime:      minTime,
+		MaxTime:      maxTime,
+		CreationTime: util.UnixSecondsFromTime(now),
+	}
+	if err := req.parseSelectors(); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func (r *SeriesDeletionRequest) parseSelectors() error {
+	r.matchers = make([][]*labels.Matcher, 0, len(r.Selectors))
+	for _, s := range r.Selectors {
+		matchers, err := parser.ParseMetricSelector(s)
+		if err != nil {
+			return errors.Wrapf(err, "invalid series selector %q", s)
+		}
+		r.matchers = append(r.matchers, matchers)
+	}
+	return nil
+}
+
+// Matchers returns the parsed selectors of the request.
+func (r *SeriesDeletionRequest) Matchers() [][]*labels.Matcher {
+	return r.matchers
+}
+
+// Processed returns whether the compactor has finished rewriting the blocks.
+func (r *SeriesDeletionRequest) Processed() bool {
+	return r.ProcessedTime > 0
+}
+
+// Overlaps returns whether the time range of the request overlaps the [minTime, maxTime] time range.
+func (r *SeriesDeletionRequest) Overlaps(minTime, maxTime int64) bool {
+	return r.MinTime <= maxTime && minTime <= r.MaxTime
+}
+
+func (r *SeriesDeletionRequest) matches(lset labels.Labels) bool {
+	for _, matchers := range r.matchers {
+		if matchesAll(matchers, lset) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAll(matchers []*labels.Matcher, lset labels.Labels) bool {
+	for _, m := range matchers {
+		if !m.Matches(lset.Get(m.Name)) {
+			return false
+		}
+	}
+	return true
+}
+
+// SeriesDeletionRequests is a list of series deletion requests, sorted by ID.
+type SeriesDeletionRequests []*SeriesDeletionRequest
+
+// Overlapping returns the requests overlapping the [minTime, maxTime] time range.
+func (rs SeriesDeletionRequests) Overlapping(minTime, maxTime int64) SeriesDeletionRequests {
+	var out SeriesDeletionRequests
+	for _, r := range rs {
+		if r.Overlaps(minTime, maxTime) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// DeletedIntervals returns the time intervals deleted for the series with the input labels.
+func (rs SeriesDeletionRequests) DeletedIntervals(lset labels.Labels) tombstones.Intervals {
+	var intervals tombstones.Intervals
+	for _, r := range rs {
+		if r.matches(lset) {
+			intervals = intervals.Add(tombstones.Interval{Mint: r.MinTime, Maxt: r.MaxTime})
+		}
+	}
+	return intervals
+}
+
+// IntervalsCover returns whether the intervals fully cover the [minTime, maxTime] time range.
+func IntervalsCover(intervals tombstones.Intervals, minTime, maxTime int64) bool {
+	// Intervals are sorted and don't overlap, because they're built with tombstones.Intervals.Add().
+	for _, iv := range intervals {
+		if iv.InBounds(minTime) && iv.InBounds(maxTime) {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteSeriesDeletionRequest uploads the series deletion request to the tenant location in the bucket.
+func WriteSeriesDeletionRequest(ctx context.Context, bkt objstore.Bucket, userID string, cfgProvider bucket.TenantConfigProvider, req *SeriesDeletionRequest) error {
+	bkt = bucket.NewUserBucketClient(userID, bkt, cfgProvider)
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return errors.Wrap(err, "serialize series deletion request")
+	}
+
+	return errors.Wrap(bkt.Upload(ctx, path.Join(SeriesDeletionRequestsPath, req.ID+".json"), bytes.NewReader(data)), "upload series deletion request")
+}
+
+// DeleteSeriesDeletionRequest deletes the series deletion request from the tenant location in the bucket.
+func DeleteSeriesDeletionRequest(ctx context.Context, bkt objstore.Bucket, userID string, cfgProvider bucket.TenantConfigProvider, id string) error {
+	bkt = bucket.NewUserBucketClient(userID, bkt, cfgProvider)
+
+	err := bkt.Delete(ctx, path.Join(SeriesDeletionRequestsPath, id+".json"))
+	if err != nil && !bkt.IsObjNotFoundErr(err) {
+		return errors.Wrap(err, "delete series deletion request")
+	}
+	return nil
+}
+
+// ReadSeriesDeletionRequests returns all the series deletion requests of the tenant, sorted by ID.
+func ReadSeriesDeletionRequests(ctx context.Context, bkt objstore.BucketReader, userID string, logger log.Logger) (SeriesDeletionRequests, error) {
+	var reqs SeriesDeletionRequests
+
+	err := bkt.Iter(ctx, path.Join(userID, SeriesDeletionRequestsPath), func(name string) error {
+		if !strings.HasSuffix(name, ".json") {
+			return nil
+		}
+
+		req, err := readSeriesDeletionRequest(ctx, bkt, name, logger)
+		if err != nil {
+			return err
+		}
+		if req != nil {
+			reqs = append(reqs, req)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "list series deletion requests")
+	}
+
+	slices.SortFunc(reqs, func(a, b *SeriesDeletionRequest) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	return reqs, nil
+}
+
+func readSeriesDeletionRequest(ctx context.Context, bkt objstore.BucketReader, name string, logger log.Logger) (*SeriesDeletionRequest, error) {
+	r, err := bkt.Get(ctx, name)
+	if err != nil {
+		if bkt.IsObjNotFoundErr(err) {
+			// The request has been deleted in the meanwhile.
+			return nil, nil
+		}
+
+		return nil, errors.Wrapf(err, "failed to read series deletion request object: %s", name)
+	}
+
+	req := &SeriesDeletionRequest{}
+	err = json.NewDecoder(r).Decode(req)
+
+	// Close reader before dealing with decode error.
+	if closeErr := r.Close(); closeErr != nil {
+		level.Warn(logger).Log("msg", "failed to close bucket reader", "err", closeErr)
+	}
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decode series deletion request object: %s", name)
+	}
+	if err := req.parseSelectors(); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse series deletion request object: %s", name)
+	}
+
+	return req, nil
+}
+
+// SeriesDeletionRequestsLoader loads the series deletion requests of tenants from the bucket, and caches them
+// for the configured sync interval.
+type SeriesDeletionRequestsLoader struct {
+	bkt          objstore.BucketReader
+	syncInterval time.Duration
+	logger       log.Logger
+
+	mtx   sync.Mutex
+	cache map[string]cachedSeriesDeletionRequests
+}
+
+type cachedSeriesDeletionRequests struct {
+	requests  SeriesDeletionRequests
+	fetchedAt time.Time
+}
+
+func NewSeriesDeletionRequestsLoader(bkt objstore.BucketReader, syncInterval time.Duration, logger log.Logger) *SeriesDeletionRequestsLoader {
+	return &SeriesDeletionRequestsLoader{
+		bkt:          bkt,
+		syncInterval: syncInterval,
+		logger:       logger,
+		cache:        map[string]cachedSeriesDeletionRequests{},
+	}
+}
+
+// Get returns the series deletion requests of the tenant. If the requests can't be loaded from the bucket,
+// the previously loaded ones are returned, if any.
+func (l *SeriesDeletionRequestsLoader) Get(ctx context.Context, userID string) (SeriesDeletionRequests, error) {
+	l.mtx.Lock()
+	cached, ok := l.cache[userID]
+	l.mtx.Unlock()
+
+	if ok && time.Since(cached.fetchedAt) < l.syncInterval {
+		return cached.requests, nil
+	}
+
+	reqs, err := ReadSeriesDeletionRequests(ctx, l.bkt, userID, l.logger)
+	if err != nil {
+		if ok {
+			level.Warn(l.logger).Log("msg", "failed to load series deletion requests, using previously loaded ones", "user", userID, "err", err)
+			return cached.requests, nil
+		}
+		return nil, err
+	}
+
+	l.mtx.Lock()
+	l.cache[userID] = cachedSeriesDeletionRequests{requests: reqs, fetchedAt: time.Now()}
+	l.mtx.Unlock()
+
+	return reqs, nil
+}
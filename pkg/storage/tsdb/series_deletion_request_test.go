@@ -0,0 +1,185 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package tsdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/tsdb/tombstones"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+)
+
+func TestNewSeriesDeletionRequest(t *testing.T) {
+	now := time.UnixMilli(1000)
+
+	for name, tc := range map[string]struct {
+		selectors       []string
+		minTime         int64
+		maxTime         int64
+		expectedMaxTime int64
+		expectedErr     string
+	}{
+		"valid request": {
+			selectors:       []string{`{__name__="metric"}`, `other{job="test"}`},
+			minTime:         100,
+			maxTime:         200,
+			expectedMaxTime: 200,
+		},
+		"end time in the future is clamped to now": {
+			selectors:       []string{"metric"},
+			minTime:         100,
+			maxTime:         5000,
+			expectedMaxTime: 1000,
+		},
+		"no selectors": {
+			minTime:     100,
+			maxTime:     200,
+			expectedErr: "at least one series selector is required",
+		},
+		"invalid selector": {
+			selectors:   []string{"{"},
+			minTime:     100,
+			maxTime:     200,
+			expectedErr: "invalid series selector",
+		},
+		"start time after end time": {
+			selectors:   []string{"metric"},
+			minTime:     200,
+			maxTime:     100,
+			expectedErr: "the start time must be before or equal to the end time",
+		},
+		"start time in the future": {
+			selectors:   []string{"metric"},
+			minTime:     2000,
+			maxTime:     3000,
+			expectedErr: "the start time must be before or equal to the end time",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			req, err := NewSeriesDeletionRequest(tc.selectors, tc.minTime, tc.maxTime, now)
+			if tc.expectedErr != "" {
+				require.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.minTime, req.MinTime)
+			assert.Equal(t, tc.expectedMaxTime, req.MaxTime)
+			assert.Len(t, req.Matchers(), len(tc.selectors))
+			assert.False(t, req.Processed())
+		})
+	}
+}
+
+func TestSeriesDeletionRequests_DeletedIntervals(t *testing.T) {
+	now := time.Now()
+
+	req1, err := NewSeriesDeletionRequest([]string{`{job="a"}`}, 10, 20, now)
+	require.NoError(t, err)
+	req2, err := NewSeriesDeletionRequest([]string{`{job="a", instance="1"}`, `{job="b"}`}, 15, 30, now)
+	require.NoError(t, err)
+	req3, err := NewSeriesDeletionRequest([]string{`{job="a"}`}, 50, 60, now)
+	require.NoError(t, err)
+
+	reqs := SeriesDeletionRequests{req1, req2, req3}
+
+	assert.Equal(t, SeriesDeletionRequests{req1, req2}, reqs.Overlapping(0, 15))
+	assert.Equal(t, SeriesDeletionRequests{req3}, reqs.Overlapping(40, 100))
+	assert.Empty(t, reqs.Overlapping(31, 49))
+
+	assert.Equal(t, tombstones.Intervals{{Mint: 10, Maxt: 20}, {Mint: 50, Maxt: 60}}, reqs.DeletedIntervals(labels.FromStrings("job", "a", "instance", "2")))
+	assert.Equal(t, tombstones.Intervals{{Mint: 10, Maxt: 30}, {Mint: 50, Maxt: 60}}, reqs.DeletedIntervals(labels.FromStrings("job", "a", "instance", "1")))
+	assert.Equal(t, tombstones.Intervals{{Mint: 15, Maxt: 30}}, reqs.DeletedIntervals(labels.FromStrings("job", "b")))
+	assert.Empty(t, reqs.DeletedIntervals(labels.FromStrings("job", "c")))
+
+	intervals := tombstones.Intervals{{Mint: 10, Maxt: 30}, {Mint: 50, Maxt: 60}}
+	assert.True(t, IntervalsCover(intervals, 10, 30))
+	assert.True(t, IntervalsCover(intervals, 55, 55))
+	assert.False(t, IntervalsCover(intervals, 5, 30))
+	assert.False(t, IntervalsCover(intervals, 10, 60))
+	assert.False(t, IntervalsCover(nil, 10, 30))
+}
+
+func TestWriteAndReadSeriesDeletionRequests(t *testing.T) {
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+	now := time.Now()
+
+	reqs, err := ReadSeriesDeletionRequests(ctx, bkt, "user", log.NewNopLogger())
+	require.NoError(t, err)
+	assert.Empty(t, reqs)
+
+	req1, err := NewSeriesDeletionRequest([]string{`{job="a"}`}, 10, 20, now.Add(-time.Minute))
+	require.NoError(t, err)
+	req2, err := NewSeriesDeletionRequest([]string{`{job="b"}`}, 30, 40, now)
+	require.NoError(t, err)
+
+	// Write them in reverse order, to check they're returned sorted.
+	require.NoError(t, WriteSeriesDeletionRequest(ctx, bkt, "user", nil, req2))
+	require.NoError(t, WriteSeriesDeletionRequest(ctx, bkt, "user", nil, req1))
+	require.NoError(t, WriteSeriesDeletionRequest(ctx, bkt, "other", nil, req1))
+
+	reqs, err = ReadSeriesDeletionRequests(ctx, bkt, "user", log.NewNopLogger())
+	require.NoError(t, err)
+	require.Len(t, reqs, 2)
+	assert.Equal(t, req1.ID, reqs[0].ID)
+	assert.Equal(t, req1.Selectors, reqs[0].Selectors)
+	assert.Equal(t, req1.Matchers(), reqs[0].Matchers())
+	assert.Equal(t, req2.ID, reqs[1].ID)
+	assert.Equal(t, req2.MinTime, reqs[1].MinTime)
+	assert.Equal(t, req2.MaxTime, reqs[1].MaxTime)
+
+	// Updating a request overwrites it.
+	req1.ProcessedTime = req1.CreationTime
+	require.NoError(t, WriteSeriesDeletionRequest(ctx, bkt, "user", nil, req1))
+
+	reqs, err = ReadSeriesDeletionRequests(ctx, bkt, "user", log.NewNopLogger())
+	require.NoError(t, err)
+	require.Len(t, reqs, 2)
+	assert.True(t, reqs[0].Processed())
+
+	// Deleting a request removes it, and deleting a missing request is not an error.
+	require.NoError(t, DeleteSeriesDeletionRequest(ctx, bkt, "user", nil, req1.ID))
+	require.NoError(t, DeleteSeriesDeletionRequest(ctx, bkt, "user", nil, req1.ID))
+
+	reqs, err = ReadSeriesDeletionRequests(ctx, bkt, "user", log.NewNopLogger())
+	require.NoError(t, err)
+	require.Len(t, reqs, 1)
+	assert.Equal(t, req2.ID, reqs[0].ID)
+}
+
+func TestSeriesDeletionRequestsLoader(t *testing.T) {
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+
+	req1, err := NewSeriesDeletionRequest([]string{`{job="a"}`}, 10, 20, time.Now())
+	require.NoError(t, err)
+	require.NoError(t, WriteSeriesDeletionRequest(ctx, bkt, "user", nil, req1))
+
+	loader := NewSeriesDeletionRequestsLoader(bkt, time.Hour, log.NewNopLogger())
+
+	reqs, err := loader.Get(ctx, "user")
+	require.NoError(t, err)
+	require.Len(t, reqs, 1)
+
+	// New requests are not visible until the sync interval has elapsed.
+	req2, err := NewSeriesDeletionRequest([]string{`{job="b"}`}, 10, 20, time.Now())
+	require.NoError(t, err)
+	require.NoError(t, WriteSeriesDeletionRequest(ctx, bkt, "user", nil, req2))
+
+	reqs, err = loader.Get(ctx, "user")
+	require.NoError(t, err)
+	require.Len(t, reqs, 1)
+
+	loader.syncInterval = 0
+
+	reqs, err = loader.Get(ctx, "user")
+	require.NoError(t, err)
+	require.Len(t, reqs, 2)
+}
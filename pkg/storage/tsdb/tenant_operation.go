@@ -0,0 +1,195 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package tsdb
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	"github.com/grafana/mimir/pkg/util"
+)
+
+// Relative to user-specific prefix of the destination tenant.
+const TenantOperationsPath = "markers/tenant-operations"
+
+// Types of tenant operations.
+const (
+	// TenantOperationCopy copies the data of the source tenant to an empty destination tenant.
+	TenantOperationCopy = "copy"
+	// TenantOperationRename moves the data of the source tenant to an empty destination tenant, and deletes the
+	// source tenant.
+	TenantOperationRename = "rename"
+	// TenantOperationMerge moves the data of the source tenant to the destination tenant, keeping the existing
+	// data of the destination tenant, and deletes the source tenant.
+	TenantOperationMerge = "merge"
+)
+
+// TenantOperationTypes is the list of supported tenant operation types.
+var TenantOperationTypes = []string{TenantOperationCopy, TenantOperationRename, TenantOperationMerge}
+
+// States of tenant operations.
+const (
+	TenantOperationStatePending   = "pending"
+	TenantOperationStateRunning   = "running"
+	TenantOperationStateCompleted = "completed"
+)
+
+// TenantOperation is an operation copying the blocks, rules and alertmanager configuration of the source tenant
+// to the destination tenant, run by the compactor. It's stored in the bucket under the destination tenant, and
+// it tracks the progress of the operation, so that it can be resumed after a restart.
+type TenantOperation struct {
+	ID                string            `json:"id"`
+	Type              string            `json:"type"`
+	SourceTenant      string            `json:"source_tenant"`
+	DestinationTenant string            `json:"destination_tenant"`
+	InjectLabels      map[string]string `json:"inject_labels,omitempty"`
+
+	// Unix timestamp when the operation was created.
+	CreationTime util.UnixSeconds `json:"creation_time"`
+
+	// The following fields are updated by the compactor while it runs the operation.
+
+	State string `json:"state"`
+	// Number of blocks copied to the destination tenant so far.
+	CopiedBlocks int `json:"copied_blocks"`
+	// Number of blocks still to copy, as of the last update.
+	PendingBlocks int `json:"pending_blocks"`
+	// Whether the rule groups and the alertmanager configuration have been copied.
+	RulesCopied              bool `json:"rules_copied"`
+	AlertmanagerConfigCopied bool `json:"alertmanager_config_copied"`
+	// Last error which interrupted the operation, if any. The operation is retried at the next compactor run.
+	Error string `json:"error,omitempty"`
+	// Unix timestamp of the last update of the operation progress.
+	LastUpdateTime util.UnixSeconds `json:"last_update_time,omitempty"`
+	// Unix timestamp when the compactor has completed the operation.
+	CompletedTime util.UnixSeconds `json:"completed_time,omitempty"`
+}
+
+// NewTenantOperation validates the input and returns a new tenant operation.
+func NewTenantOperation(typ, sourceTenant, destinationTenant string, injectLabels map[string]string, now time.Time) (*TenantOperation, error) {
+	if !slices.Contains(TenantOperationTypes, typ) {
+		return nil, errors.Errorf("unsupported tenant operation type %q, supported types are: %s", typ, strings.Join(TenantOperationTypes, ", "))
+	}
+	if sourceTenant == destinationTenant {
+		return nil, errors.New("the source and destination tenants must be different")
+	}
+	for name, value := range injectLabels {
+		if !model.LabelName(name).IsValid() || strings.HasPrefix(name, model.ReservedLabelPrefix) {
+			return nil, errors.Errorf("invalid label name %q", name)
+		}
+		if value == "" {
+			return nil, errors.Errorf("empty value for label %q", name)
+		}
+	}
+
+	return &TenantOperation{
+		ID:                ulid.MustNew(ulid.Timestamp(now), rand.Reader).String(),
+		Type:              typ,
+		SourceTenant:      sourceTenant,
+		DestinationTenant: destinationTenant,
+		InjectLabels:      injectLabels,
+		CreationTime:      util.UnixSecondsFromTime(now),
+		State:             TenantOperationStatePending,
+	}, nil
+}
+
+// Completed returns whether the compactor has completed the operation.
+func (o *TenantOperation) Completed() bool {
+	return o.State == TenantOperationStateCompleted
+}
+
+// DeletesSource returns whether the source tenant is deleted once the operation is completed.
+func (o *TenantOperation) DeletesSource() bool {
+	return o.Type == TenantOperationRename || o.Type == TenantOperationMerge
+}
+
+// RequiresEmptyDestination returns whether the destination tenant must have no blocks when the operation is created.
+func (o *TenantOperation) RequiresEmptyDestination() bool {
+	return o.Type == TenantOperationCopy || o.Type == TenantOperationRename
+}
+
+// Labels returns the labels injected in the series copied to the destination tenant, sorted by name.
+func (o *TenantOperation) Labels() labels.Labels {
+	return labels.FromMap(o.InjectLabels)
+}
+
+// WriteTenantOperation uploads the tenant operation to the destination tenant location in the bucket.
+func WriteTenantOperation(ctx context.Context, bkt objstore.Bucket, cfgProvider bucket.TenantConfigProvider, op *TenantOperation) error {
+	bkt = bucket.NewUserBucketClient(op.DestinationTenant, bkt, cfgProvider)
+
+	data, err := json.Marshal(op)
+	if err != nil {
+		return errors.Wrap(err, "serialize tenant operation")
+	}
+
+	return errors.Wrap(bkt.Upload(ctx, path.Join(TenantOperationsPath, op.ID+".json"), bytes.NewReader(data)), "upload tenant operation")
+}
+
+// ReadTenantOperations returns all the operations having the tenant as destination, sorted by ID.
+func ReadTenantOperations(ctx context.Context, bkt objstore.BucketReader, userID string, logger log.Logger) ([]*TenantOperation, error) {
+	var ops []*TenantOperation
+
+	err := bkt.Iter(ctx, path.Join(userID, TenantOperationsPath), func(name string) error {
+		if !strings.HasSuffix(name, ".json") {
+			return nil
+		}
+
+		op, err := readTenantOperation(ctx, bkt, name, logger)
+		if err != nil {
+			return err
+		}
+		if op != nil {
+			ops = append(ops, op)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "list tenant operations")
+	}
+
+	slices.SortFunc(ops, func(a, b *TenantOperation) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	return ops, nil
+}
+
+func readTenantOperation(ctx context.Context, bkt objstore.BucketReader, name string, logger log.Logger) (*TenantOperation, error) {
+	r, err := bkt.Get(ctx, name)
+	if err != nil {
+		if bkt.IsObjNotFoundErr(err) {
+			// The operation has been deleted in the meanwhile.
+			return nil, nil
+		}
+
+		return nil, errors.Wrapf(err, "failed to read tenant operation object: %s", name)
+	}
+
+	op := &TenantOperation{}
+	err = json.NewDecoder(r).Decode(op)
+
+	// Close reader before dealing with decode error.
+	if closeErr := r.Close(); closeErr != nil {
+		level.Warn(logger).Log("msg", "failed to close bucket reader", "err", closeErr)
+	}
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decode tenant operation object: %s", name)
+	}
+
+	return op, nil
+}
@@ -0,0 +1,121 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package tsdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+)
+
+func TestNewTenantOperation(t *testing.T) {
+	now := time.Now()
+
+	for name, tc := range map[string]struct {
+		typ          string
+		source       string
+		destination  string
+		injectLabels map[string]string
+		expectedErr  string
+	}{
+		"valid copy": {
+			typ:         TenantOperationCopy,
+			source:      "a",
+			destination: "b",
+		},
+		"valid merge with injected labels": {
+			typ:          TenantOperationMerge,
+			source:       "a",
+			destination:  "b",
+			injectLabels: map[string]string{"source_tenant": "a"},
+		},
+		"unsupported type": {
+			typ:         "move",
+			source:      "a",
+			destination: "b",
+			expectedErr: `unsupported tenant operation type "move"`,
+		},
+		"same source and destination": {
+			typ:         TenantOperationRename,
+			source:      "a",
+			destination: "a",
+			expectedErr: "the source and destination tenants must be different",
+		},
+		"invalid label name": {
+			typ:          TenantOperationCopy,
+			source:       "a",
+			destination:  "b",
+			injectLabels: map[string]string{"0invalid": "a"},
+			expectedErr:  `invalid label name "0invalid"`,
+		},
+		"reserved label name": {
+			typ:          TenantOperationCopy,
+			source:       "a",
+			destination:  "b",
+			injectLabels: map[string]string{"__name__": "a"},
+			expectedErr:  `invalid label name "__name__"`,
+		},
+		"empty label value": {
+			typ:          TenantOperationCopy,
+			source:       "a",
+			destination:  "b",
+			injectLabels: map[string]string{"source_tenant": ""},
+			expectedErr:  `empty value for label "source_tenant"`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			op, err := NewTenantOperation(tc.typ, tc.source, tc.destination, tc.injectLabels, now)
+			if tc.expectedErr != "" {
+				require.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, TenantOperationStatePending, op.State)
+			assert.False(t, op.Completed())
+			assert.Equal(t, labels.FromMap(tc.injectLabels), op.Labels())
+		})
+	}
+}
+
+func TestWriteAndReadTenantOperations(t *testing.T) {
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+	now := time.Now()
+
+	ops, err := ReadTenantOperations(ctx, bkt, "dest", log.NewNopLogger())
+	require.NoError(t, err)
+	assert.Empty(t, ops)
+
+	op1, err := NewTenantOperation(TenantOperationCopy, "source-1", "dest", nil, now.Add(-time.Minute))
+	require.NoError(t, err)
+	op2, err := NewTenantOperation(TenantOperationMerge, "source-2", "dest", map[string]string{"cluster": "b"}, now)
+	require.NoError(t, err)
+	op3, err := NewTenantOperation(TenantOperationCopy, "source-1", "other", nil, now)
+	require.NoError(t, err)
+
+	// Write them in reverse order, to check they're returned sorted.
+	require.NoError(t, WriteTenantOperation(ctx, bkt, nil, op2))
+	require.NoError(t, WriteTenantOperation(ctx, bkt, nil, op1))
+	require.NoError(t, WriteTenantOperation(ctx, bkt, nil, op3))
+
+	ops, err = ReadTenantOperations(ctx, bkt, "dest", log.NewNopLogger())
+	require.NoError(t, err)
+	require.Equal(t, []*TenantOperation{op1, op2}, ops)
+
+	// Updating an operation overwrites it.
+	op1.State = TenantOperationStateCompleted
+	require.NoError(t, WriteTenantOperation(ctx, bkt, nil, op1))
+
+	ops, err = ReadTenantOperations(ctx, bkt, "dest", log.NewNopLogger())
+	require.NoError(t, err)
+	require.Len(t, ops, 2)
+	assert.True(t, ops[0].Completed())
+	assert.False(t, ops[1].Completed())
+}
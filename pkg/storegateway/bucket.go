@@ -69,6 +69,8 @@ const (
 	targetQueryStreamBatchMessageSize = 1 * 1024 * 1024
 )
 
+var errQueriedColdStorageBlocks = errors.New("the query touched blocks in the cold storage, which may be slower to query")
+
 type BucketStoreStats struct {
 	// BlocksLoadedTotal is the total number of blocks currently loaded in the bucket store.
 	BlocksLoadedTotal int
@@ -92,6 +94,7 @@ type BucketStore struct {
 	indexCache      indexcache.IndexCache
 	indexReaderPool *indexheader.ReaderPool
 	seriesHashCache *hashcache.SeriesHashCache
+	exemplarsCache  *exemplarsCache
 
 	snapshotter services.Service
 
@@ -112,6 +115,12 @@ type BucketStore struct {
 	// Gate used to limit concurrency on loading index-headers across all tenants.
 	lazyLoadingGate gate.Gate
 
+	// Bucket client of the cold storage, nil if the cold storage is disabled.
+	coldBkt objstore.InstrumentedBucketReader
+
+	// Gate used to limit concurrency on loading index-headers of the blocks in the cold storage across all tenants.
+	coldLoadingGate gate.Gate
+
 	// chunksLimiterFactory creates a new limiter used to limit the number of chunks fetched by each Series() call.
 	chunksLimiterFactory ChunksLimiterFactory
 	// seriesLimiterFactory creates a new limiter used to limit the number of touched series by each Series() call,
@@ -127,6 +136,9 @@ type BucketStore struct {
 
 	// postingsStrategy is a strategy shared among all tenants.
 	postingsStrategy postingsSelectionStrategy
+
+	// seriesDeletionRequests loads the series deletion requests filtered out from the query results. Nil if series deletion is disabled.
+	seriesDeletionRequests *tsdb.SeriesDeletionRequestsLoader
 }
 
 type noopCache struct{}
@@ -197,6 +209,29 @@ func WithLazyLoadingGate(lazyLoadingGate gate.Gate) BucketStoreOption {
 	}
 }
 
+// WithColdStorage sets the bucket client of the cold storage, and the coldLoadingGate to use
+// to load the index-headers of the blocks in the cold storage instead of a gate.NewNoop().
+func WithColdStorage(coldBkt objstore.InstrumentedBucketReader, coldLoadingGate gate.Gate) BucketStoreOption {
+	return func(s *BucketStore) {
+		s.coldBkt = coldBkt
+		s.coldLoadingGate = coldLoadingGate
+	}
+}
+
+// WithSeriesDeletionRequests sets the loader of the series deletion requests filtered out from the query results.
+func WithSeriesDeletionRequests(loader *tsdb.SeriesDeletionRequestsLoader) BucketStoreOption {
+	return func(s *BucketStore) {
+		s.seriesDeletionRequests = loader
+	}
+}
+
+// WithExemplarsCache sets the cache of the blocks exemplars. The exemplars aren't cached if it's not set.
+func WithExemplarsCache(cache *exemplarsCache) BucketStoreOption {
+	return func(s *BucketStore) {
+		s.exemplarsCache = cache
+	}
+}
+
 // NewBucketStore creates a new bucket backed store that implements the store API against
 // an object store bucket. It is optimized to work against high latency backends.
 func NewBucketStore(
@@ -223,6 +258,7 @@ func NewBucketStore(
 		blockSyncConcurrency:        bucketStoreConfig.BlockSyncConcurrency,
 		queryGate:                   gate.NewNoop(),
 		lazyLoadingGate:             gate.NewNoop(),
+		coldLoadingGate:             gate.NewNoop(),
 		chunksLimiterFactory:        chunksLimiterFactory,
 		seriesLimiterFactory:        seriesLimiterFactory,
 		partitioners:                partitioners,
@@ -239,7 +275,7 @@ func NewBucketStore(
 		option(s)
 	}
 
-	s.indexReaderPool = indexheader.NewReaderPool(s.logger, bucketStoreConfig.IndexHeader, s.lazyLoadingGate, metrics.indexHeaderReaderMetrics)
+	s.indexReaderPool = indexheader.NewReaderPool(s.logger, bucketStoreConfig.IndexHeader, s.lazyLoadingGate, s.coldLoadingGate, metrics.indexHeaderReaderMetrics)
 
 	if bucketStoreConfig.IndexHeader.EagerLoadingStartupEnabled {
 		snapConfig := indexheader.SnapshotterConfig{
@@ -325,8 +361,17 @@ func (s *BucketStore) syncBlocks(ctx context.Context) error {
 	}
 
 	for id, meta := range metas {
-		if s.blockSet.contains(id) {
-			continue
+		if b := s.blockSet.get(id); b != nil {
+			if b.meta.Tier == meta.Tier {
+				continue
+			}
+
+			// The block has been moved to another storage tier, so we reload it from there.
+			if err := s.removeBlock(id); err != nil {
+				level.Warn(s.logger).Log("msg", "drop of block moved to another storage tier failed", "block", id, "err", err)
+				continue
+			}
+			level.Info(s.logger).Log("msg", "dropped block moved to another storage tier", "block", id, "tier", meta.Tier)
 		}
 		select {
 		case <-ctx.Done():
@@ -454,17 +499,31 @@ func (s *BucketStore) addBlock(ctx context.Context, meta *block.Meta) (err error
 	}()
 	s.metrics.blockLoads.Inc()
 
-	indexHeaderReader, err := s.indexReaderPool.NewBinaryReader(
-		ctx,
-		s.logger,
-		s.bkt,
-		s.dir,
-		meta.ULID,
-		s.postingOffsetsInMemSampling,
-		s.indexHeaderCfg,
+	var (
+		bkt               = s.bkt
+		indexHeaderReader indexheader.Reader
 	)
-	if err != nil {
-		return errors.Wrap(err, "create index header reader")
+	if meta.Tier == block.ColdStorageTier {
+		if s.coldBkt == nil {
+			return errors.New("the block is in the cold storage, but the cold storage is disabled")
+		}
+		bkt = s.coldBkt
+
+		// The index-headers of the blocks in the cold storage are always lazy loaded.
+		indexHeaderReader = s.indexReaderPool.NewColdBinaryReader(ctx, s.logger, bkt, s.dir, meta.ULID, s.postingOffsetsInMemSampling, s.indexHeaderCfg)
+	} else {
+		indexHeaderReader, err = s.indexReaderPool.NewBinaryReader(
+			ctx,
+			s.logger,
+			bkt,
+			s.dir,
+			meta.ULID,
+			s.postingOffsetsInMemSampling,
+			s.indexHeaderCfg,
+		)
+		if err != nil {
+			return errors.Wrap(err, "create index header reader")
+		}
 	}
 
 	defer func() {
@@ -479,7 +538,7 @@ func (s *BucketStore) addBlock(ctx context.Context, meta *block.Meta) (err error
 		log.With(s.logger, "block", meta.ULID),
 		s.metrics,
 		meta,
-		s.bkt,
+		bkt,
 		dir,
 		s.indexCache,
 		indexHeaderReader,
@@ -516,6 +575,7 @@ func (s *BucketStore) removeBlock(id ulid.ULID) (returnErr error) {
 	// The block has already been removed from BucketStore, so we track it as removed
 	// even if releasing its resources could fail below.
 	s.metrics.blockDrops.Inc()
+	s.exemplarsCache.remove(id)
 
 	if err := b.Close(); err != nil {
 		return errors.Wrap(err, "close block")
@@ -595,7 +655,7 @@ func (s *BucketStore) Series(req *storepb.SeriesRequest, srv storegatewaypb.Stor
 
 	logSeriesRequestToSpan(srv.Context(), s.logger, req.MinTime, req.MaxTime, matchers, reqBlockMatchers, shardSelector, req.StreamingChunksBatchSize)
 
-	blocks, indexReaders, chunkReaders := s.openBlocksForReading(ctx, req.SkipChunks, req.MinTime, req.MaxTime, reqBlockMatchers, stats)
+	blocks, indexReaders, chunkReaders := s.openBlocksForReading(ctx, req.SkipChunks, req.Aggregates, req.MinTime, req.MaxTime, reqBlockMatchers, stats)
 	// We must keep the readers open until all their data has been sent.
 	for _, r := range indexReaders {
 		defer runutil.CloseWithLogOnErr(s.logger, r, "close block index reader")
@@ -620,16 +680,27 @@ func (s *BucketStore) Series(req *storepb.SeriesRequest, srv storegatewaypb.Stor
 	var (
 		streamingIterators *streamingSeriesIterators
 		resHints           = &hintspb.SeriesResponseHints{}
+		queriedColdBlocks  bool
 	)
 	for _, b := range blocks {
 		resHints.AddQueriedBlock(b.meta.ULID)
 
+		if b.meta.Tier == block.ColdStorageTier {
+			queriedColdBlocks = true
+		}
+
 		if b.meta.Compaction.Level == 1 && b.meta.Thanos.Source == block.ReceiveSource && !b.queried.Load() {
 			level.Debug(s.logger).Log("msg", "queried non-compacted block", "blockId", b.meta.ULID, "ooo", b.meta.Compaction.FromOutOfOrder())
 		}
 
 		b.queried.Store(true)
 	}
+	if queriedColdBlocks {
+		s.metrics.coldStorageQueries.Inc()
+		if err := srv.Send(storepb.NewWarnSeriesResponse(errQueriedColdStorageBlocks)); err != nil {
+			return status.Error(codes.Unknown, errors.Wrap(err, "send series response warning").Error())
+		}
+	}
 	if err := s.sendHints(srv, resHints); err != nil {
 		return err
 	}
@@ -1125,6 +1196,15 @@ func (s *BucketStore) getSeriesIteratorFromBlocks(
 		begin                    = time.Now()
 		blocksQueriedByBlockMeta = make(map[blockQueriedMeta]int)
 	)
+
+	var deletions tsdb.SeriesDeletionRequests
+	if s.seriesDeletionRequests != nil {
+		var err error
+		if deletions, err = s.seriesDeletionRequests.Get(ctx, s.userID); err != nil {
+			return nil, errors.Wrap(err, "load series deletion requests")
+		}
+	}
+
 	for _, b := range blocks {
 		// Keep track of queried blocks.
 		indexr := indexReaders[b.meta.ULID]
@@ -1148,6 +1228,7 @@ func (s *BucketStore) getSeriesIteratorFromBlocks(
 				cachedSeriesHasher{blockSeriesHashCache},
 				strategy,
 				req.MinTime, req.MaxTime,
+				deletions,
 				stats,
 				s.logger,
 				streamingIterators,
@@ -1300,7 +1381,7 @@ func (s *BucketStore) recordSeriesHashCacheStats(stats *queryStats) {
 	s.metrics.seriesHashCacheHits.Add(float64(stats.seriesHashCacheHits))
 }
 
-func (s *BucketStore) openBlocksForReading(ctx context.Context, skipChunks bool, minT, maxT int64, blockMatchers []*labels.Matcher, stats *safeQueryStats) ([]*bucketBlock, map[ulid.ULID]*bucketIndexReader, map[ulid.ULID]chunkReader) {
+func (s *BucketStore) openBlocksForReading(ctx context.Context, skipChunks bool, aggrs []storepb.Aggr, minT, maxT int64, blockMatchers []*labels.Matcher, stats *safeQueryStats) ([]*bucketBlock, map[ulid.ULID]*bucketIndexReader, map[ulid.ULID]chunkReader) {
 	span, spanCtx := opentracing.StartSpanFromContext(ctx, "bucket_store_open_blocks_for_reading")
 	defer span.Finish()
 
@@ -1330,7 +1411,7 @@ func (s *BucketStore) openBlocksForReading(ctx context.Context, skipChunks bool,
 			chunkReaders = make(map[ulid.ULID]chunkReader)
 		}
 		// Ignore the span context from this method; chunkReader() retains the context to add spans after openBlocksForReading() returns.
-		chunkReaders[b.meta.ULID] = b.chunkReader(ctx)
+		chunkReaders[b.meta.ULID] = b.chunkReader(ctx, aggrs)
 	})
 
 	return blocks, indexReaders, chunkReaders
@@ -1456,6 +1537,7 @@ func blockLabelNames(ctx context.Context, indexr *bucketIndexReader, matchers []
 		cachedSeriesHasher{nil},
 		noChunkRefs,
 		minTime, maxTime,
+		nil,
 		stats,
 		logger,
 		nil,
@@ -1769,6 +1851,93 @@ func storeCachedLabelValues(ctx context.Context, indexCache indexcache.IndexCach
 	indexCache.StoreLabelValues(userID, blockID, labelName, entry.MatchersKey, data)
 }
 
+// Exemplars implements the storegatewaypb.StoreGatewayServer interface.
+// Exemplars are read from the exemplars file stored alongside each block queried.
+func (s *BucketStore) Exemplars(ctx context.Context, req *storepb.ExemplarsRequest) (*storepb.ExemplarsResponse, error) {
+	reqSeriesMatchers, err := storepb.MatchersToPromMatchers(req.Matchers...)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, errors.Wrap(err, "translate request labels matchers").Error())
+	}
+
+	var reqBlockMatchers []*labels.Matcher
+	if req.Hints != nil {
+		reqHints := &hintspb.SeriesRequestHints{}
+		err := types.UnmarshalAny(req.Hints, reqHints)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, errors.Wrap(err, "unmarshal exemplars request hints").Error())
+		}
+
+		reqBlockMatchers, err = storepb.MatchersToPromMatchers(reqHints.BlockMatchers...)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, errors.Wrap(err, "translate request hints labels matchers").Error())
+		}
+	}
+
+	// Exemplars requests count towards the concurrent queries, like the series ones.
+	if err := s.queryGate.Start(ctx); err != nil {
+		return nil, status.Error(codes.Unavailable, errors.Wrap(err, "failed to wait for turn").Error())
+	}
+	defer s.queryGate.Done()
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(s.blockSyncConcurrency)
+
+	var setsMtx sync.Mutex
+	var sets []*block.Exemplars
+
+	s.blockSet.filter(req.MinTime, req.MaxTime, reqBlockMatchers, func(b *bucketBlock) {
+		g.Go(func() error {
+			exemplars, err := b.loadExemplars(gctx, s.exemplarsCache)
+			if err != nil {
+				return errors.Wrapf(err, "block %s", b.meta.ULID)
+			}
+
+			result := exemplars.Filter(req.MinTime, req.MaxTime, func(lset labels.Labels) bool {
+				for _, m := range reqSeriesMatchers {
+					if !m.Matches(lset.Get(m.Name)) {
+						return false
+					}
+				}
+				return true
+			})
+
+			if len(result.Series) > 0 {
+				setsMtx.Lock()
+				sets = append(sets, result)
+				setsMtx.Unlock()
+			}
+
+			return nil
+		})
+	})
+
+	if err := g.Wait(); err != nil {
+		if errors.Is(err, context.Canceled) {
+			return nil, status.Error(codes.Canceled, err.Error())
+		}
+
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	merged := block.MergeExemplars(sets...)
+	res := &storepb.ExemplarsResponse{Timeseries: make([]mimirpb.TimeSeries, 0, len(merged.Series))}
+	for _, series := range merged.Series {
+		ts := mimirpb.TimeSeries{
+			Labels:    mimirpb.FromLabelsToLabelAdapters(series.Labels),
+			Exemplars: make([]mimirpb.Exemplar, 0, len(series.Exemplars)),
+		}
+		for _, e := range series.Exemplars {
+			ts.Exemplars = append(ts.Exemplars, mimirpb.Exemplar{
+				Labels:      mimirpb.FromLabelsToLabelAdapters(e.Labels),
+				Value:       e.Value,
+				TimestampMs: e.Timestamp,
+			})
+		}
+		res.Timeseries = append(res.Timeseries, ts)
+	}
+	return res, nil
+}
+
 // bucketBlockSet holds all blocks.
 type bucketBlockSet struct {
 	// mtx protects the below data strcutures, helping to keep them in sync.
@@ -1829,6 +1998,15 @@ func (s *bucketBlockSet) remove(id ulid.ULID) *bucketBlock {
 	return val.(*bucketBlock)
 }
 
+// get returns the block identified by id, or nil if it's not in the set.
+func (s *bucketBlockSet) get(id ulid.ULID) *bucketBlock {
+	val, ok := s.blockSet.Load(id)
+	if !ok {
+		return nil
+	}
+	return val.(*bucketBlock)
+}
+
 func (s *bucketBlockSet) contains(id ulid.ULID) bool {
 	_, ok := s.blockSet.Load(id)
 	return ok
@@ -1981,6 +2159,10 @@ type bucketBlock struct {
 
 	// Indicates whether the block was queried.
 	queried atomic.Bool
+
+	// The mutex is held while the exemplars of the block are downloaded, so that concurrent requests download
+	// them only once.
+	exemplarsMtx sync.Mutex
 }
 
 func newBucketBlock(
@@ -2073,9 +2255,27 @@ func (b *bucketBlock) indexReader(postingsStrategy postingsSelectionStrategy) *b
 	return newBucketIndexReader(b, postingsStrategy)
 }
 
-func (b *bucketBlock) chunkReader(ctx context.Context) *bucketChunkReader {
+func (b *bucketBlock) chunkReader(ctx context.Context, aggrs []storepb.Aggr) *bucketChunkReader {
 	b.pendingReaders.Add(1)
-	return newBucketChunkReader(ctx, b)
+	return newBucketChunkReader(ctx, b, aggrs)
+}
+
+// loadExemplars returns the exemplars of the block, downloading them unless they're in the cache. Failures
+// are not cached, so that the download is retried by the next request.
+func (b *bucketBlock) loadExemplars(ctx context.Context, cache *exemplarsCache) (*block.Exemplars, error) {
+	b.exemplarsMtx.Lock()
+	defer b.exemplarsMtx.Unlock()
+
+	if exemplars, ok := cache.get(b.meta.ULID); ok {
+		return exemplars, nil
+	}
+
+	exemplars, err := block.DownloadExemplars(ctx, b.bkt, b.meta.ULID)
+	if err != nil {
+		return nil, err
+	}
+	cache.set(b.meta.ULID, exemplars)
+	return exemplars, nil
 }
 
 // matchLabels verifies whether the block matches the given matchers.
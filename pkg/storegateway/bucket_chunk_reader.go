@@ -12,6 +12,7 @@ import (
 	"fmt"
 	"hash/crc32"
 	"io"
+	"slices"
 	"sort"
 	"sync"
 
@@ -24,6 +25,7 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	"github.com/grafana/mimir/pkg/storage/tsdb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/downsample"
 	"github.com/grafana/mimir/pkg/storegateway/storepb"
 	util_math "github.com/grafana/mimir/pkg/util/math"
 	"github.com/grafana/mimir/pkg/util/pool"
@@ -32,14 +34,17 @@ import (
 type bucketChunkReader struct {
 	ctx   context.Context
 	block *bucketBlock
+	// aggrs are the aggregates to return for the chunks of downsampled blocks.
+	aggrs []storepb.Aggr
 
 	toLoad [][]loadIdx
 }
 
-func newBucketChunkReader(ctx context.Context, block *bucketBlock) *bucketChunkReader {
+func newBucketChunkReader(ctx context.Context, block *bucketBlock, aggrs []storepb.Aggr) *bucketChunkReader {
 	return &bucketChunkReader{
 		ctx:    ctx,
 		block:  block,
+		aggrs:  aggrs,
 		toLoad: make([][]loadIdx, len(block.chunkObjs)),
 	}
 }
@@ -164,7 +169,7 @@ func (r *bucketChunkReader) loadChunks(ctx context.Context, res []seriesChunks,
 			return errors.Wrap(err, "read chunk")
 		}
 
-		err = populateChunk(&(res[pIdx.seriesEntry].chks[pIdx.chunkEntry]), cb)
+		err = populateChunk(&(res[pIdx.seriesEntry].chks[pIdx.chunkEntry]), cb, r.aggrs)
 		if err != nil {
 			return errors.Wrap(err, "populate chunk")
 		}
@@ -195,10 +200,20 @@ func (r *bucketChunkReader) fetchChunkRemainder(ctx context.Context, seq int, of
 	return nil
 }
 
-// populateChunk retains in.Bytes() in out.Raw.
-func populateChunk(out *storepb.AggrChunk, in rawChunk) error {
+// populateChunk retains in.Bytes() in out.Raw. The chunks of downsampled blocks are replaced by
+// the sub-chunk of the requested aggregates.
+func populateChunk(out *storepb.AggrChunk, in rawChunk, aggrs []storepb.Aggr) error {
+	chkEnc, chkData := in.Encoding(), in.Bytes()
+	if chkEnc == downsample.ChunkEncAggr {
+		chk, err := aggrChunk(downsample.NewAggrChunk(chkData), aggrs)
+		if err != nil {
+			return err
+		}
+		chkEnc, chkData = chk.Encoding(), chk.Bytes()
+	}
+
 	var enc storepb.Chunk_Encoding
-	switch in.Encoding() {
+	switch chkEnc {
 	case chunkenc.EncXOR:
 		enc = storepb.Chunk_XOR
 	case chunkenc.EncHistogram:
@@ -206,13 +221,43 @@ func populateChunk(out *storepb.AggrChunk, in rawChunk) error {
 	case chunkenc.EncFloatHistogram:
 		enc = storepb.Chunk_FloatHistogram
 	default:
-		return errors.Errorf("unsupported chunk encoding %d", in.Encoding())
+		return errors.Errorf("unsupported chunk encoding %d", chkEnc)
 	}
 
-	out.Raw = storepb.Chunk{Type: enc, Data: in.Bytes()}
+	out.Raw = storepb.Chunk{Type: enc, Data: chkData}
 	return nil
 }
 
+// aggrChunk returns the sub-chunk of the downsampled chunk for the requested aggregates. When both
+// the count and sum aggregates are requested, the average is returned. The counter aggregate is
+// returned when no aggregate is requested, or when the requested one is not stored in the chunk,
+// like the min and max aggregates of histograms.
+func aggrChunk(in *downsample.AggrChunk, aggrs []storepb.Aggr) (chunkenc.Chunk, error) {
+	if slices.Contains(aggrs, storepb.COUNT) && slices.Contains(aggrs, storepb.SUM) {
+		return in.Average()
+	}
+
+	typ := downsample.AggrCounter
+	if len(aggrs) > 0 {
+		switch aggrs[0] {
+		case storepb.COUNT:
+			typ = downsample.AggrCount
+		case storepb.SUM:
+			typ = downsample.AggrSum
+		case storepb.MIN:
+			typ = downsample.AggrMin
+		case storepb.MAX:
+			typ = downsample.AggrMax
+		}
+	}
+
+	chk, err := in.Get(typ)
+	if errors.Is(err, downsample.ErrAggrNotExist) && typ != downsample.AggrCounter {
+		return in.Get(downsample.AggrCounter)
+	}
+	return chk, err
+}
+
 type loadIdx struct {
 	offset uint32
 	// length is the estimated length of the chunk in the segment file.
@@ -19,6 +19,7 @@ import (
 	"github.com/thanos-io/objstore"
 
 	"github.com/grafana/mimir/pkg/storage/tsdb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/downsample"
 	"github.com/grafana/mimir/pkg/storegateway/storepb"
 	"github.com/grafana/mimir/pkg/util/pool"
 	"github.com/grafana/mimir/pkg/util/test"
@@ -43,6 +44,7 @@ func TestBucketChunkReader_refetchChunks(t *testing.T) {
 		defaultStrategy,
 		block.meta.MinTime,
 		block.meta.MaxTime,
+		nil,
 		newSafeQueryStats(),
 		log.NewNopLogger(),
 		nil,
@@ -67,10 +69,10 @@ func TestBucketChunkReader_refetchChunks(t *testing.T) {
 			// Start loading chunks in two readers - one with the estimations from the index and one with modified estimations.
 			// We expect that the estimations from the index lead to no refetches and that the skewed lengths trigger refetches.
 			loadedChunksCorrectLen := make([]seriesChunks, len(seriesRefs))
-			chunkrCorrectLen := block.chunkReader(ctx)
+			chunkrCorrectLen := block.chunkReader(ctx, nil)
 
 			loadedChunksModifiedLen := make([]seriesChunks, len(seriesRefs))
-			chunkrModifiedLen := block.chunkReader(ctx)
+			chunkrModifiedLen := block.chunkReader(ctx, nil)
 
 			for sIdx, seriesRef := range seriesRefs {
 				loadedChunksCorrectLen[sIdx].chks = append(loadedChunksCorrectLen[sIdx].chks, make([]storepb.AggrChunk, len(seriesRef.refs))...)
@@ -89,6 +91,95 @@ func TestBucketChunkReader_refetchChunks(t *testing.T) {
 	}
 }
 
+func TestPopulateChunk_Downsampled(t *testing.T) {
+	newFloatChunk := func(values ...float64) chunkenc.Chunk {
+		chk := chunkenc.NewXORChunk()
+		app, err := chk.Appender()
+		require.NoError(t, err)
+		for i, v := range values {
+			app.Append(int64(i), v)
+		}
+		return chk
+	}
+
+	aggr := downsample.EncodeAggrChunk([5]chunkenc.Chunk{
+		downsample.AggrCount:   newFloatChunk(2, 4),
+		downsample.AggrSum:     newFloatChunk(10, 20),
+		downsample.AggrMin:     newFloatChunk(1, 2),
+		downsample.AggrMax:     newFloatChunk(9, 8),
+		downsample.AggrCounter: newFloatChunk(100, 200),
+	})
+	// The chunks are prefixed by their encoding in the segment files.
+	in := rawChunk(append([]byte{byte(downsample.ChunkEncAggr)}, aggr.Bytes()...))
+
+	tests := map[string]struct {
+		aggrs    []storepb.Aggr
+		expected []float64
+	}{
+		"no aggregates": {
+			expected: []float64{100, 200},
+		},
+		"average": {
+			aggrs:    []storepb.Aggr{storepb.COUNT, storepb.SUM},
+			expected: []float64{5, 5},
+		},
+		"count": {
+			aggrs:    []storepb.Aggr{storepb.COUNT},
+			expected: []float64{2, 4},
+		},
+		"sum": {
+			aggrs:    []storepb.Aggr{storepb.SUM},
+			expected: []float64{10, 20},
+		},
+		"min": {
+			aggrs:    []storepb.Aggr{storepb.MIN},
+			expected: []float64{1, 2},
+		},
+		"max": {
+			aggrs:    []storepb.Aggr{storepb.MAX},
+			expected: []float64{9, 8},
+		},
+		"counter": {
+			aggrs:    []storepb.Aggr{storepb.COUNTER},
+			expected: []float64{100, 200},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			var out storepb.AggrChunk
+			require.NoError(t, populateChunk(&out, in, testData.aggrs))
+			require.Equal(t, storepb.Chunk_XOR, out.Raw.Type)
+
+			chk, err := chunkenc.FromData(chunkenc.EncXOR, out.Raw.Data)
+			require.NoError(t, err)
+
+			var actual []float64
+			it := chk.Iterator(nil)
+			for it.Next() == chunkenc.ValFloat {
+				_, v := it.At()
+				actual = append(actual, v)
+			}
+			require.NoError(t, it.Err())
+			assert.Equal(t, testData.expected, actual)
+		})
+	}
+
+	t.Run("missing aggregate falls back to counter", func(t *testing.T) {
+		in := rawChunk(append([]byte{byte(downsample.ChunkEncAggr)}, downsample.EncodeAggrChunk([5]chunkenc.Chunk{
+			downsample.AggrCount:   newFloatChunk(2),
+			downsample.AggrCounter: newFloatChunk(100),
+		}).Bytes()...))
+
+		var out storepb.AggrChunk
+		require.NoError(t, populateChunk(&out, in, []storepb.Aggr{storepb.MIN}))
+
+		expected, err := downsample.NewAggrChunk(in.Bytes()).Get(downsample.AggrCounter)
+		require.NoError(t, err)
+		assert.Equal(t, expected.Bytes(), []byte(out.Raw.Data))
+	})
+}
+
 func BenchmarkBucketChunkReader_loadChunks(b *testing.B) {
 	const chunkSize = 256
 
@@ -112,7 +203,7 @@ func BenchmarkBucketChunkReader_loadChunks(b *testing.B) {
 				chunkObjs:    chunkObjs,
 			}
 
-			reader := newBucketChunkReader(ctx, block)
+			reader := newBucketChunkReader(ctx, block, nil)
 
 			// Prepare mock data for testing.
 			loadIdxs := make([]loadIdx, numChunks)
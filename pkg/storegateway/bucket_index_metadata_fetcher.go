@@ -99,6 +99,10 @@ func (f *BucketIndexMetadataFetcher) Fetch(ctx context.Context) (metas map[ulid.
 	// Build block metas out of the index.
 	metas = make(map[ulid.ULID]*block.Meta, len(idx.Blocks))
 	for _, b := range idx.Blocks {
+		// Blocks which failed the integrity verification are never loaded.
+		if b.Quarantined {
+			continue
+		}
 		metas[b.ID] = b.ThanosMeta()
 	}
 
@@ -43,13 +43,14 @@ func TestBucketIndexMetadataFetcher_Fetch(t *testing.T) {
 	block2 := &bucketindex.Block{ID: ulid.MustNew(2, nil)}
 	block3 := &bucketindex.Block{ID: ulid.MustNew(3, nil)}
 	block4 := &bucketindex.Block{ID: ulid.MustNew(4, nil), MinTime: timestamp.FromTime(now.Add(-30 * time.Minute))} // Has most-recent data, to be ignored by minTimeMetaFilter.
+	block5 := &bucketindex.Block{ID: ulid.MustNew(5, nil), Quarantined: true}
 
 	mark1 := &bucketindex.BlockDeletionMark{ID: block1.ID, DeletionTime: now.Add(-time.Hour).Unix()}     // Below the ignore delay threshold.
 	mark2 := &bucketindex.BlockDeletionMark{ID: block2.ID, DeletionTime: now.Add(-3 * time.Hour).Unix()} // Above the ignore delay threshold.
 
 	require.NoError(t, bucketindex.WriteIndex(ctx, bkt, userID, nil, &bucketindex.Index{
 		Version:            bucketindex.IndexVersion1,
-		Blocks:             bucketindex.Blocks{block1, block2, block3, block4},
+		Blocks:             bucketindex.Blocks{block1, block2, block3, block4, block5},
 		BlockDeletionMarks: bucketindex.BlockDeletionMarks{mark1, mark2},
 		UpdatedAt:          now.Unix(),
 	}))
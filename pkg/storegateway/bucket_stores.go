@@ -32,6 +32,7 @@ import (
 	"github.com/grafana/mimir/pkg/storage/bucket"
 	"github.com/grafana/mimir/pkg/storage/tsdb"
 	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storage/tsdb/metricsusage"
 	"github.com/grafana/mimir/pkg/storegateway/indexcache"
 	"github.com/grafana/mimir/pkg/storegateway/storegatewaypb"
 	"github.com/grafana/mimir/pkg/storegateway/storepb"
@@ -64,6 +65,9 @@ type BucketStores struct {
 	// Series hash cache shared across all tenants.
 	seriesHashCache *hashcache.SeriesHashCache
 
+	// Exemplars cache shared across all tenants.
+	exemplarsCache *exemplarsCache
+
 	// partitioners shared across all tenants.
 	partitioners blockPartitioners
 
@@ -73,6 +77,18 @@ type BucketStores struct {
 	// Gate used to limit concurrency on loading index-headers across all tenants.
 	lazyLoadingGate gate.Gate
 
+	// Bucket client of the cold storage. Nil if the cold storage is disabled.
+	coldBucket objstore.Bucket
+
+	// Gate used to limit concurrency on loading index-headers of the blocks in the cold storage across all tenants.
+	coldLoadingGate gate.Gate
+
+	// Loader of the series deletion requests shared across all tenants. Nil if series deletion is disabled.
+	seriesDeletionRequests *tsdb.SeriesDeletionRequestsLoader
+
+	// Tracks the last time each metric has been queried. Nil if the metrics usage tracking is disabled.
+	metricsUsage *metricsusage.Tracker
+
 	// Keeps a bucket store for each tenant.
 	storesMu sync.RWMutex
 	stores   map[string]*BucketStore
@@ -118,6 +134,16 @@ func NewBucketStores(cfg tsdb.BlocksStorageConfig, shardingStrategy ShardingStra
 		lazyLoadingGate = timeoutGate{delegate: lazyLoadingGate, timeout: cfg.BucketStore.IndexHeader.LazyLoadingConcurrencyQueueTimeout}
 	}
 
+	// The number of concurrent index header loads of the blocks in the cold storage are limited separately.
+	coldLoadingGateReg := prometheus.WrapRegistererWith(prometheus.Labels{"gate": "index_header_cold_storage"}, gateReg)
+	coldLoadingGate := gate.NewNoop()
+	coldLoadingMax := cfg.BucketStore.IndexHeader.ColdStorageLazyLoadingConcurrency
+	if cfg.ColdStorage.Enabled && coldLoadingMax != 0 {
+		coldLoadingGate = gate.NewBlocking(coldLoadingMax)
+		coldLoadingGate = gate.NewInstrumented(coldLoadingGateReg, coldLoadingMax, coldLoadingGate)
+		coldLoadingGate = timeoutGate{delegate: coldLoadingGate, timeout: cfg.BucketStore.IndexHeader.LazyLoadingConcurrencyQueueTimeout}
+	}
+
 	u := &BucketStores{
 		logger:             logger,
 		cfg:                cfg,
@@ -130,8 +156,10 @@ func NewBucketStores(cfg tsdb.BlocksStorageConfig, shardingStrategy ShardingStra
 		metaFetcherMetrics: NewMetadataFetcherMetrics(logger),
 		queryGate:          queryGate,
 		lazyLoadingGate:    lazyLoadingGate,
+		coldLoadingGate:    coldLoadingGate,
 		partitioners:       newGapBasedPartitioners(cfg.BucketStore.PartitionerMaxGapBytes, reg),
 		seriesHashCache:    hashcache.NewSeriesHashCache(cfg.BucketStore.SeriesHashCacheMaxBytes),
+		exemplarsCache:     newExemplarsCache(cfg.BucketStore.ExemplarsCacheMaxBytes, reg),
 		syncBackoffConfig: backoff.Config{
 			MinBackoff: 1 * time.Second,
 			MaxBackoff: 10 * time.Second,
@@ -139,6 +167,10 @@ func NewBucketStores(cfg tsdb.BlocksStorageConfig, shardingStrategy ShardingStra
 		},
 	}
 
+	if cfg.SeriesDeletionEnabled {
+		u.seriesDeletionRequests = tsdb.NewSeriesDeletionRequestsLoader(bucketClient, cfg.SeriesDeletionSyncInterval, logger)
+	}
+
 	// Register metrics.
 	u.syncTimes = promauto.With(reg).NewHistogram(prometheus.HistogramOpts{
 		Name:    "cortex_bucket_stores_blocks_sync_seconds",
@@ -340,10 +372,17 @@ func (u *BucketStores) Series(req *storepb.SeriesRequest, srv storegatewaypb.Sto
 		return nil
 	}
 
-	return store.Series(req, spanSeriesServer{
+	var seriesSrv storegatewaypb.StoreGateway_SeriesServer = spanSeriesServer{
 		StoreGateway_SeriesServer: srv,
 		ctx:                       spanCtx,
-	})
+	}
+	if u.metricsUsage != nil {
+		recorder := u.metricsUsage.NewRecorder()
+		defer func() { u.metricsUsage.Record(userID, recorder, time.Now()) }()
+		seriesSrv = metricsUsageSeriesServer{StoreGateway_SeriesServer: seriesSrv, recorder: recorder}
+	}
+
+	return store.Series(req, seriesSrv)
 }
 
 // LabelNames implements the storegatewaypb.StoreGatewayServer interface.
@@ -382,6 +421,24 @@ func (u *BucketStores) LabelValues(ctx context.Context, req *storepb.LabelValues
 	return store.LabelValues(ctx, req)
 }
 
+// Exemplars implements the storegatewaypb.StoreGatewayServer interface.
+func (u *BucketStores) Exemplars(ctx context.Context, req *storepb.ExemplarsRequest) (*storepb.ExemplarsResponse, error) {
+	spanLog, spanCtx := spanlogger.NewWithLogger(ctx, u.logger, "BucketStores.Exemplars")
+	defer spanLog.Span.Finish()
+
+	userID := getUserIDFromGRPCContext(spanCtx)
+	if userID == "" {
+		return nil, fmt.Errorf("no userID")
+	}
+
+	store := u.getStore(userID)
+	if store == nil {
+		return &storepb.ExemplarsResponse{}, nil
+	}
+
+	return store.Exemplars(ctx, req)
+}
+
 // scanUsers in the bucket and return the list of found users, respecting any specifically
 // enabled or disabled users.
 func (u *BucketStores) scanUsers(ctx context.Context) ([]string, error) {
@@ -524,6 +581,13 @@ func (u *BucketStores) getOrCreateStore(ctx context.Context, userID string) (*Bu
 		WithIndexCache(u.indexCache),
 		WithQueryGate(u.queryGate),
 		WithLazyLoadingGate(u.lazyLoadingGate),
+		WithExemplarsCache(u.exemplarsCache),
+	}
+	if u.seriesDeletionRequests != nil {
+		bucketStoreOpts = append(bucketStoreOpts, WithSeriesDeletionRequests(u.seriesDeletionRequests))
+	}
+	if u.coldBucket != nil {
+		bucketStoreOpts = append(bucketStoreOpts, WithColdStorage(bucket.NewUserBucketClient(userID, u.coldBucket, u.limits), u.coldLoadingGate))
 	}
 
 	bs, err := NewBucketStore(
@@ -639,3 +703,23 @@ type spanSeriesServer struct {
 func (s spanSeriesServer) Context() context.Context {
 	return s.ctx
 }
+
+// metricsUsageSeriesServer records the metric names of the series sent to the querier.
+type metricsUsageSeriesServer struct {
+	storegatewaypb.StoreGateway_SeriesServer
+
+	recorder *metricsusage.Recorder
+}
+
+func (s metricsUsageSeriesServer) Send(resp *storepb.SeriesResponse) error {
+	if series := resp.GetSeries(); series != nil {
+		s.recorder.Add(series.Labels)
+	}
+	if batch := resp.GetStreamingSeries(); batch != nil {
+		for _, series := range batch.Series {
+			s.recorder.Add(series.Labels)
+		}
+	}
+
+	return s.StoreGateway_SeriesServer.Send(resp)
+}
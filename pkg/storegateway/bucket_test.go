@@ -44,6 +44,7 @@ import (
 	"github.com/prometheus/prometheus/tsdb/chunks"
 	"github.com/prometheus/prometheus/tsdb/encoding"
 	"github.com/prometheus/prometheus/tsdb/hashcache"
+	"github.com/prometheus/prometheus/tsdb/tsdbutil"
 	"github.com/prometheus/prometheus/tsdb/wlog"
 	"github.com/prometheus/prometheus/util/testutil"
 	"github.com/stretchr/testify/assert"
@@ -1841,7 +1842,7 @@ func TestBucketStore_Series_OneBlock_InMemIndexCacheSegfault(t *testing.T) {
 		indexReaderPool: indexheader.NewReaderPool(log.NewNopLogger(), indexheader.Config{
 			LazyLoadingEnabled:     false,
 			LazyLoadingIdleTimeout: 0,
-		}, gate.NewNoop(), indexheader.NewReaderPoolMetrics(nil)),
+		}, gate.NewNoop(), gate.NewNoop(), indexheader.NewReaderPoolMetrics(nil)),
 		blockSet:             newBucketBlockSet(),
 		metrics:              NewBucketStoreMetrics(nil),
 		postingsStrategy:     selectAllStrategy{},
@@ -2274,6 +2275,14 @@ func TestBucketStore_Series_BlockWithMultipleFloatHistogramChunks(t *testing.T)
 	testBucketStoreSeriesBlockWithMultipleChunks(t, appendF, chunkenc.EncFloatHistogram)
 }
 
+func TestBucketStore_Series_BlockWithMultipleCustomBucketsHistogramChunks(t *testing.T) {
+	appendF := func(app storage.Appender, lset labels.Labels, ts int64) error {
+		_, err := app.AppendHistogram(0, lset, ts, tsdbutil.GenerateTestCustomBucketsHistogram(int(ts)), nil)
+		return err
+	}
+	testBucketStoreSeriesBlockWithMultipleChunks(t, appendF, chunkenc.EncHistogram)
+}
+
 func testBucketStoreSeriesBlockWithMultipleChunks(
 	t *testing.T,
 	appendF func(storage.Appender, labels.Labels, int64) error,
@@ -0,0 +1,145 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"math"
+	"sync"
+
+	lru "github.com/hashicorp/golang-lru/v2/simplelru"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+)
+
+const (
+	// exemplarSizeBytes is the size of the value and timestamp of an exemplar, and of the header of its labels.
+	exemplarSizeBytes = 8 + 8 + 24
+
+	// exemplarSeriesSizeBytes is the size of the headers of the labels and exemplars of an exemplars series.
+	exemplarSeriesSizeBytes = 24 + 24
+)
+
+// exemplarsCache is an in-memory LRU cache of the exemplars of the blocks, shared across all tenants.
+// The estimated size of the cached exemplars doesn't exceed maxSizeBytes.
+type exemplarsCache struct {
+	mtx          sync.Mutex
+	lru          *lru.LRU[ulid.ULID, exemplarsCacheEntry]
+	maxSizeBytes uint64
+	curSizeBytes uint64
+
+	requests prometheus.Counter
+	hits     prometheus.Counter
+}
+
+type exemplarsCacheEntry struct {
+	exemplars *block.Exemplars
+	sizeBytes uint64
+}
+
+func newExemplarsCache(maxSizeBytes uint64, reg prometheus.Registerer) *exemplarsCache {
+	c := &exemplarsCache{
+		maxSizeBytes: maxSizeBytes,
+		requests: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_bucket_store_exemplars_cache_requests_total",
+			Help: "Total number of requests to the in-memory cache of the blocks exemplars.",
+		}),
+		hits: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_bucket_store_exemplars_cache_hits_total",
+			Help: "Total number of requests to the in-memory cache of the blocks exemplars that were a hit.",
+		}),
+	}
+	promauto.With(reg).NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "cortex_bucket_store_exemplars_cache_size_bytes",
+		Help: "Estimated size of the exemplars in the in-memory cache of the blocks exemplars.",
+	}, func() float64 {
+		c.mtx.Lock()
+		defer c.mtx.Unlock()
+		return float64(c.curSizeBytes)
+	})
+
+	// The number of entries is unbounded, because the evictions are based on the size of the entries.
+	c.lru, _ = lru.NewLRU[ulid.ULID, exemplarsCacheEntry](math.MaxInt, func(_ ulid.ULID, entry exemplarsCacheEntry) {
+		c.curSizeBytes -= entry.sizeBytes
+	})
+	return c
+}
+
+// get returns the cached exemplars of the block. It's safe to call on a nil exemplarsCache.
+func (c *exemplarsCache) get(id ulid.ULID) (*block.Exemplars, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.requests.Inc()
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	entry, ok := c.lru.Get(id)
+	if !ok {
+		return nil, false
+	}
+	c.hits.Inc()
+	return entry.exemplars, true
+}
+
+// set caches the exemplars of the block, evicting the least recently used ones until they fit. The exemplars
+// aren't cached if they're bigger than the cache. It's safe to call on a nil exemplarsCache.
+func (c *exemplarsCache) set(id ulid.ULID, exemplars *block.Exemplars) {
+	if c == nil {
+		return
+	}
+
+	size := exemplarsSize(exemplars)
+	if size > c.maxSizeBytes {
+		return
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.lru.Remove(id)
+	for c.curSizeBytes+size > c.maxSizeBytes {
+		if _, _, ok := c.lru.RemoveOldest(); !ok {
+			break
+		}
+	}
+	c.lru.Add(id, exemplarsCacheEntry{exemplars: exemplars, sizeBytes: size})
+	c.curSizeBytes += size
+}
+
+// remove removes the exemplars of the block from the cache. It's safe to call on a nil exemplarsCache.
+func (c *exemplarsCache) remove(id ulid.ULID) {
+	if c == nil {
+		return
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.lru.Remove(id)
+}
+
+// exemplarsSize returns the estimated size, in bytes, of the exemplars in memory.
+func exemplarsSize(e *block.Exemplars) uint64 {
+	size := uint64(len(e.Series)) * exemplarSeriesSizeBytes
+	for _, s := range e.Series {
+		size += labelsSize(s.Labels) + uint64(len(s.Exemplars))*exemplarSizeBytes
+		for _, ex := range s.Exemplars {
+			size += labelsSize(ex.Labels)
+		}
+	}
+	return size
+}
+
+func labelsSize(lset labels.Labels) uint64 {
+	var size uint64
+	lset.Range(func(l labels.Label) {
+		// Each label has a name and a value string header.
+		size += uint64(len(l.Name)+len(l.Value)) + 2*16
+	})
+	return size
+}
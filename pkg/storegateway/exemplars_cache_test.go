@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"testing"
+
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+)
+
+func TestExemplarsCache(t *testing.T) {
+	exemplars := func(seriesID string) *block.Exemplars {
+		return &block.Exemplars{Version: block.ExemplarsVersion1, Series: []block.ExemplarSeries{{
+			Labels:    labels.FromStrings("series_id", seriesID),
+			Exemplars: []block.Exemplar{{Labels: labels.FromStrings("trace_id", "1"), Value: 1, Timestamp: 10}},
+		}}}
+	}
+	size := exemplarsSize(exemplars("1"))
+	require.Greater(t, size, uint64(0))
+
+	block1, block2, block3 := ulid.MustNew(1, nil), ulid.MustNew(2, nil), ulid.MustNew(3, nil)
+
+	t.Run("least recently used exemplars are evicted once the max size is reached", func(t *testing.T) {
+		c := newExemplarsCache(2*size, prometheus.NewPedanticRegistry())
+		c.set(block1, exemplars("1"))
+		c.set(block2, exemplars("2"))
+
+		// Use the first block, so that the second one is the least recently used.
+		_, ok := c.get(block1)
+		require.True(t, ok)
+
+		c.set(block3, exemplars("3"))
+
+		_, ok = c.get(block2)
+		assert.False(t, ok)
+		for _, id := range []ulid.ULID{block1, block3} {
+			actual, ok := c.get(id)
+			require.True(t, ok)
+			assert.Len(t, actual.Series, 1)
+		}
+		assert.Equal(t, 2*size, c.curSizeBytes)
+	})
+
+	t.Run("exemplars bigger than the max size are not cached", func(t *testing.T) {
+		c := newExemplarsCache(size-1, prometheus.NewPedanticRegistry())
+		c.set(block1, exemplars("1"))
+
+		_, ok := c.get(block1)
+		assert.False(t, ok)
+		assert.Equal(t, uint64(0), c.curSizeBytes)
+	})
+
+	t.Run("removed exemplars release their size", func(t *testing.T) {
+		c := newExemplarsCache(2*size, prometheus.NewPedanticRegistry())
+		c.set(block1, exemplars("1"))
+		c.remove(block1)
+
+		_, ok := c.get(block1)
+		assert.False(t, ok)
+		assert.Equal(t, uint64(0), c.curSizeBytes)
+	})
+
+	t.Run("nil cache", func(t *testing.T) {
+		var c *exemplarsCache
+		c.set(block1, exemplars("1"))
+		_, ok := c.get(block1)
+		assert.False(t, ok)
+	})
+}
@@ -25,6 +25,7 @@ import (
 
 	"github.com/grafana/mimir/pkg/storage/bucket"
 	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/metricsusage"
 	"github.com/grafana/mimir/pkg/storegateway/storegatewaypb"
 	"github.com/grafana/mimir/pkg/storegateway/storepb"
 	"github.com/grafana/mimir/pkg/util"
@@ -92,7 +93,10 @@ type StoreGateway struct {
 	ringLifecycler *ring.BasicLifecycler
 	ring           *ring.Ring
 
-	// Subservices manager (ring, lifecycler)
+	// Tracks the last time each metric has been queried. Nil if the metrics usage tracking is disabled.
+	metricsUsage *metricsusage.Tracker
+
+	// Subservices manager (ring, lifecycler, metrics usage tracker)
 	subservices        *services.Manager
 	subservicesWatcher *services.FailureWatcher
 
@@ -188,6 +192,18 @@ func newStoreGateway(gatewayCfg Config, storageCfg mimir_tsdb.BlocksStorageConfi
 		return nil, errors.Wrap(err, "create bucket stores")
 	}
 
+	if storageCfg.MetricsUsageTrackingEnabled {
+		g.metricsUsage = metricsusage.NewTracker(bucketClient, limits, "store-gateway", gatewayCfg.ShardingRing.InstanceID, storageCfg.MetricsUsageFlushInterval, storageCfg.MetricsUsageRetentionPeriod, logger)
+		g.stores.metricsUsage = g.metricsUsage
+	}
+
+	if storageCfg.ColdStorage.Enabled {
+		g.stores.coldBucket, err = bucket.NewClient(context.Background(), storageCfg.ColdStorage.Bucket, "store-gateway-cold-storage", logger, reg)
+		if err != nil {
+			return nil, errors.Wrap(err, "create cold storage bucket client")
+		}
+	}
+
 	g.Service = services.NewBasicService(g.starting, g.running, g.stopping)
 
 	return g, nil
@@ -214,7 +230,11 @@ func (g *StoreGateway) starting(ctx context.Context) (err error) {
 
 	// First of all we register the instance in the ring and wait
 	// until the lifecycler successfully started.
-	if g.subservices, err = services.NewManager(g.ringLifecycler, g.ring); err != nil {
+	subservices := []services.Service{g.ringLifecycler, g.ring}
+	if g.metricsUsage != nil {
+		subservices = append(subservices, g.metricsUsage)
+	}
+	if g.subservices, err = services.NewManager(subservices...); err != nil {
 		return errors.Wrap(err, "unable to start store-gateway dependencies")
 	}
 
@@ -364,6 +384,16 @@ func (g *StoreGateway) LabelValues(ctx context.Context, req *storepb.LabelValues
 	return g.stores.LabelValues(ctx, req)
 }
 
+// Exemplars implements the storegatewaypb.StoreGatewayServer interface.
+func (g *StoreGateway) Exemplars(ctx context.Context, req *storepb.ExemplarsRequest) (*storepb.ExemplarsResponse, error) {
+	ix := g.tracker.Insert(func() string {
+		return requestActivity(ctx, "StoreGateway/Exemplars", req)
+	})
+	defer g.tracker.Delete(ix)
+
+	return g.stores.Exemplars(ctx, req)
+}
+
 func requestActivity(ctx context.Context, name string, req interface{}) string {
 	user := getUserIDFromGRPCContext(ctx)
 	traceID, _ := tracing.ExtractSampledTraceID(ctx)
@@ -41,6 +41,7 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"github.com/thanos-io/objstore"
+	grpc_metadata "google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 
 	"github.com/grafana/mimir/pkg/mimirpb"
@@ -1093,6 +1094,88 @@ func TestStoreGateway_SeriesQueryingShouldRemoveExternalLabels(t *testing.T) {
 	}
 }
 
+func TestStoreGateway_Exemplars(t *testing.T) {
+	test.VerifyNoLeak(t)
+
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+	userID := "user-1"
+
+	storageDir := t.TempDir()
+
+	now := time.Now()
+	minT := now.Add(-1*time.Hour).Unix() * 1000
+	maxT := now.Unix() * 1000
+	mockTSDB(t, path.Join(storageDir, userID), 2, 0, minT, maxT)
+
+	bucketClient, err := filesystem.NewBucketClient(filesystem.Config{Directory: storageDir})
+	require.NoError(t, err)
+
+	var blockID string
+	require.NoError(t, bucketClient.Iter(ctx, userID+"/", func(key string) error {
+		if _, ok := block.IsBlockDir(key); ok {
+			blockID = strings.TrimSuffix(strings.TrimPrefix(key, userID+"/"), "/")
+		}
+		return nil
+	}))
+	require.NotEmpty(t, blockID)
+
+	// Store the exemplars alongside the block.
+	trace := labels.FromStrings("trace_id", "1")
+	require.NoError(t, block.WriteExemplarsFile(filepath.Join(storageDir, userID, blockID), &block.Exemplars{Series: []block.ExemplarSeries{
+		{Labels: labels.FromStrings("series_id", "0"), Exemplars: []block.Exemplar{
+			{Labels: trace, Value: 1, Timestamp: minT},
+			{Labels: trace, Value: 2, Timestamp: maxT - 1},
+		}},
+		{Labels: labels.FromStrings("series_id", "1"), Exemplars: []block.Exemplar{
+			{Labels: trace, Value: 3, Timestamp: minT},
+		}},
+	}}))
+
+	createBucketIndex(t, bucketClient, userID)
+
+	gatewayCfg := mockGatewayConfig()
+	storageCfg := mockStorageConfig(t)
+
+	ringStore, closer := consul.NewInMemoryClient(ring.GetCodec(), log.NewNopLogger(), nil)
+	t.Cleanup(func() { assert.NoError(t, closer.Close()) })
+
+	g, err := newStoreGateway(gatewayCfg, storageCfg, bucketClient, ringStore, defaultLimitsOverrides(t), logger, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(ctx, g))
+	t.Cleanup(func() { assert.NoError(t, services.StopAndAwaitTerminated(ctx, g)) })
+
+	// Call the handler directly, so the tenant ID is read from the incoming context.
+	reqCtx := grpc_metadata.NewIncomingContext(ctx, grpc_metadata.Pairs(GrpcContextMetadataTenantID, userID))
+	res, err := g.Exemplars(reqCtx, &storepb.ExemplarsRequest{
+		MinTime: minT + 1,
+		MaxTime: maxT,
+		Matchers: []storepb.LabelMatcher{
+			{Type: storepb.LabelMatcher_EQ, Name: "series_id", Value: "0"},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []mimirpb.TimeSeries{{
+		Labels:    []mimirpb.LabelAdapter{{Name: "series_id", Value: "0"}},
+		Exemplars: []mimirpb.Exemplar{{Labels: []mimirpb.LabelAdapter{{Name: "trace_id", Value: "1"}}, Value: 2, TimestampMs: maxT - 1}},
+	}}, res.Timeseries)
+
+	// The exemplars are cached once loaded, so they're still returned after the file is removed from the bucket.
+	require.NoError(t, bucketClient.Delete(ctx, path.Join(userID, blockID, block.ExemplarsFilename)))
+	res, err = g.Exemplars(reqCtx, &storepb.ExemplarsRequest{
+		MinTime: minT,
+		MaxTime: maxT,
+		Matchers: []storepb.LabelMatcher{
+			{Type: storepb.LabelMatcher_EQ, Name: "series_id", Value: "1"},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []mimirpb.TimeSeries{{
+		Labels:    []mimirpb.LabelAdapter{{Name: "series_id", Value: "1"}},
+		Exemplars: []mimirpb.Exemplar{{Labels: []mimirpb.LabelAdapter{{Name: "trace_id", Value: "1"}}, Value: 3, TimestampMs: minT}},
+	}}, res.Timeseries)
+}
+
 func TestStoreGateway_Series_QuerySharding(t *testing.T) {
 	test.VerifyNoLeak(t)
 
@@ -148,6 +148,21 @@ func NewLazyBinaryReader(
 		level.Debug(logger).Log("msg", "built index-header file", "path", path, "elapsed", time.Since(start))
 	}
 
+	return newLazyBinaryReader(ctx, readerFactory, logger, path, id, metrics, onClosed, lazyLoadingGate), nil
+}
+
+// newLazyBinaryReader makes a new LazyBinaryReader for the index-header at path, without building it. The
+// readerFactory is expected to build the index-header, if it doesn't exist on the local disk, when called.
+func newLazyBinaryReader(
+	ctx context.Context,
+	readerFactory func() (Reader, error),
+	logger log.Logger,
+	path string,
+	id ulid.ULID,
+	metrics *LazyBinaryReaderMetrics,
+	onClosed func(*LazyBinaryReader),
+	lazyLoadingGate gate.Gate,
+) *LazyBinaryReader {
 	reader := &LazyBinaryReader{
 		logger:          logger,
 		filepath:        path,
@@ -165,7 +180,7 @@ func NewLazyBinaryReader(
 	}
 
 	go reader.controlLoop()
-	return reader, nil
+	return reader
 }
 
 // Close implements Reader.
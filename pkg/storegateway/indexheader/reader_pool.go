@@ -7,6 +7,7 @@ package indexheader
 
 import (
 	"context"
+	"path/filepath"
 	"sync"
 	"time"
 
@@ -18,6 +19,8 @@ import (
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
 )
 
 // ReaderPoolMetrics holds metrics tracked by ReaderPool.
@@ -37,7 +40,8 @@ func NewReaderPoolMetrics(reg prometheus.Registerer) *ReaderPoolMetrics {
 // ReaderPool is used to istantiate new index-header readers and keep track of them.
 // When the lazy reader is enabled, the pool keeps track of all instantiated readers
 // and automatically close them once the idle timeout is reached. A closed lazy reader
-// will be automatically re-opened upon next usage.
+// will be automatically re-opened upon next usage. The readers of the blocks in the
+// cold storage are always lazy.
 type ReaderPool struct {
 	services.Service
 
@@ -49,15 +53,19 @@ type ReaderPool struct {
 	// Gate used to limit the number of concurrent index-header loads.
 	lazyLoadingGate gate.Gate
 
+	// Gate used to limit the number of concurrent index-header loads of the blocks in the cold storage.
+	coldLoadingGate gate.Gate
+
 	// Keep track of all readers managed by the pool.
 	lazyReadersMx sync.Mutex
 	lazyReaders   map[*LazyBinaryReader]struct{}
 }
 
-// NewReaderPool makes a new ReaderPool. If lazy-loading is enabled, NewReaderPool also starts a background task for unloading idle Readers.
-func NewReaderPool(logger log.Logger, indexHeaderConfig Config, lazyLoadingGate gate.Gate, metrics *ReaderPoolMetrics) *ReaderPool {
-	p := newReaderPool(logger, indexHeaderConfig, lazyLoadingGate, metrics)
-	if !p.lazyReaderEnabled || p.lazyReaderIdleTimeout <= 0 {
+// NewReaderPool makes a new ReaderPool. If the idle timeout is enabled, NewReaderPool also starts a background task
+// for unloading idle Readers, which are the lazy readers and the readers of the blocks in the cold storage.
+func NewReaderPool(logger log.Logger, indexHeaderConfig Config, lazyLoadingGate, coldLoadingGate gate.Gate, metrics *ReaderPoolMetrics) *ReaderPool {
+	p := newReaderPool(logger, indexHeaderConfig, lazyLoadingGate, coldLoadingGate, metrics)
+	if p.lazyReaderIdleTimeout <= 0 {
 		p.Service = services.NewIdleService(nil, nil)
 	} else {
 		p.Service = services.NewTimerService(p.lazyReaderIdleTimeout/10, nil, p.unloadIdleReaders, nil)
@@ -66,7 +74,7 @@ func NewReaderPool(logger log.Logger, indexHeaderConfig Config, lazyLoadingGate
 }
 
 // newReaderPool makes a new ReaderPool.
-func newReaderPool(logger log.Logger, indexHeaderConfig Config, lazyLoadingGate gate.Gate, metrics *ReaderPoolMetrics) *ReaderPool {
+func newReaderPool(logger log.Logger, indexHeaderConfig Config, lazyLoadingGate, coldLoadingGate gate.Gate, metrics *ReaderPoolMetrics) *ReaderPool {
 	return &ReaderPool{
 		logger:                logger,
 		metrics:               metrics,
@@ -74,6 +82,7 @@ func newReaderPool(logger log.Logger, indexHeaderConfig Config, lazyLoadingGate
 		lazyReaderIdleTimeout: indexHeaderConfig.LazyLoadingIdleTimeout,
 		lazyReaders:           make(map[*LazyBinaryReader]struct{}),
 		lazyLoadingGate:       lazyLoadingGate,
+		coldLoadingGate:       coldLoadingGate,
 	}
 }
 
@@ -100,15 +109,38 @@ func (p *ReaderPool) NewBinaryReader(ctx context.Context, logger log.Logger, bkt
 	}
 
 	// Keep track of lazy readers only if required.
-	if p.lazyReaderEnabled && p.lazyReaderIdleTimeout > 0 {
-		p.lazyReadersMx.Lock()
-		p.lazyReaders[reader.(*LazyBinaryReader)] = struct{}{}
-		p.lazyReadersMx.Unlock()
+	if p.lazyReaderEnabled {
+		p.trackLazyReader(reader.(*LazyBinaryReader))
 	}
 
 	return reader, err
 }
 
+// NewColdBinaryReader creates and returns a new lazy reader for a block in the cold storage, regardless of
+// whether the lazy reader is enabled. The index-header isn't built until the reader is used for the first
+// time, and it's loaded through the cold storage loading gate, so that loading the index-headers of the blocks
+// in the cold storage, which is slower, doesn't delay the loading of the other blocks.
+func (p *ReaderPool) NewColdBinaryReader(ctx context.Context, logger log.Logger, bkt objstore.BucketReader, dir string, id ulid.ULID, postingOffsetsInMemSampling int, cfg Config) Reader {
+	readerFactory := func() (Reader, error) {
+		return NewStreamBinaryReader(ctx, logger, bkt, dir, id, postingOffsetsInMemSampling, p.metrics.streamReader, cfg)
+	}
+
+	reader := newLazyBinaryReader(ctx, readerFactory, logger, filepath.Join(dir, id.String(), block.IndexHeaderFilename), id, p.metrics.lazyReader, p.onLazyReaderClosed, p.coldLoadingGate)
+	p.trackLazyReader(reader)
+
+	return reader
+}
+
+func (p *ReaderPool) trackLazyReader(r *LazyBinaryReader) {
+	if p.lazyReaderIdleTimeout <= 0 {
+		return
+	}
+
+	p.lazyReadersMx.Lock()
+	p.lazyReaders[r] = struct{}{}
+	p.lazyReadersMx.Unlock()
+}
+
 func (p *ReaderPool) unloadIdleReaders(context.Context) error {
 	idleTimeoutAgo := time.Now().Add(-p.lazyReaderIdleTimeout).UnixNano()
 
@@ -59,7 +59,7 @@ func TestReaderPool_NewBinaryReader(t *testing.T) {
 				LazyLoadingEnabled:     testData.lazyReaderEnabled,
 				LazyLoadingIdleTimeout: testData.lazyReaderIdleTimeout,
 			}
-			pool := NewReaderPool(log.NewNopLogger(), indexHeaderConfig, gate.NewNoop(), metrics)
+			pool := NewReaderPool(log.NewNopLogger(), indexHeaderConfig, gate.NewNoop(), gate.NewNoop(), metrics)
 
 			r, err := pool.NewBinaryReader(ctx, log.NewNopLogger(), bkt, tmpDir, blockID, 3, indexHeaderConfig)
 			require.NoError(t, err)
@@ -89,7 +89,7 @@ func TestReaderPool_ShouldCloseIdleLazyReaders(t *testing.T) {
 		LazyLoadingEnabled:         true,
 		LazyLoadingIdleTimeout:     idleTimeout,
 		EagerLoadingStartupEnabled: false,
-	}, gate.NewNoop(), metrics)
+	}, gate.NewNoop(), gate.NewNoop(), metrics)
 
 	r, err := pool.NewBinaryReader(ctx, log.NewNopLogger(), bkt, tmpDir, blockID, 3, Config{})
 	require.NoError(t, err)
@@ -125,6 +125,33 @@ func TestReaderPool_ShouldCloseIdleLazyReaders(t *testing.T) {
 	require.Equal(t, float64(2), promtestutil.ToFloat64(metrics.lazyReader.unloadCount))
 }
 
+func TestReaderPool_NewColdBinaryReader(t *testing.T) {
+	ctx, tmpDir, bkt, blockID, metrics := prepareReaderPool(t)
+	indexHeaderPath := filepath.Join(tmpDir, blockID.String(), block.IndexHeaderFilename)
+
+	// The readers of the blocks in the cold storage are lazy even if the lazy reader is disabled.
+	pool := newReaderPool(log.NewNopLogger(), Config{
+		LazyLoadingEnabled:     false,
+		LazyLoadingIdleTimeout: time.Minute,
+	}, gate.NewNoop(), gate.NewNoop(), metrics)
+
+	r := pool.NewColdBinaryReader(ctx, log.NewNopLogger(), bkt, tmpDir, blockID, 3, Config{})
+	require.True(t, pool.isTracking(r.(*LazyBinaryReader)))
+
+	// The index-header is not built until the reader is used.
+	require.NoFileExists(t, indexHeaderPath)
+	require.Equal(t, float64(0), promtestutil.ToFloat64(metrics.lazyReader.loadCount))
+
+	labelNames, err := r.LabelNames(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a"}, labelNames)
+	require.FileExists(t, indexHeaderPath)
+	require.Equal(t, float64(1), promtestutil.ToFloat64(metrics.lazyReader.loadCount))
+
+	require.NoError(t, r.Close())
+	require.False(t, pool.isTracking(r.(*LazyBinaryReader)))
+}
+
 func TestReaderPool_LoadedBlocks(t *testing.T) {
 	usedAt := time.Now()
 	id, err := ulid.New(ulid.Now(), rand.Reader)
@@ -19,6 +19,7 @@ import (
 	"github.com/prometheus/prometheus/tsdb/chunks"
 	"github.com/prometheus/prometheus/tsdb/hashcache"
 	"github.com/prometheus/prometheus/tsdb/index"
+	"github.com/prometheus/prometheus/tsdb/tombstones"
 	"golang.org/x/exp/slices"
 	"golang.org/x/sync/errgroup"
 
@@ -706,6 +707,7 @@ func openBlockSeriesChunkRefsSetsIterator(
 	seriesHasher seriesHasher,
 	strategy seriesIteratorStrategy,
 	minTime, maxTime int64,
+	deletions tsdb.SeriesDeletionRequests,
 	stats *safeQueryStats,
 	logger log.Logger,
 	streamingIterators *streamingSeriesIterators,
@@ -720,7 +722,7 @@ func openBlockSeriesChunkRefsSetsIterator(
 	}
 
 	iteratorFactory := func(strategy seriesIteratorStrategy, psi *postingsSetsIterator) iterator[seriesChunkRefsSet] {
-		return openBlockSeriesChunkRefsSetsIteratorFromPostings(ctx, tenantID, indexr, indexCache, blockMeta, shard, seriesHasher, strategy, minTime, maxTime, stats, psi, pendingMatchers, logger)
+		return openBlockSeriesChunkRefsSetsIteratorFromPostings(ctx, tenantID, indexr, indexCache, blockMeta, shard, seriesHasher, strategy, minTime, maxTime, deletions, stats, psi, pendingMatchers, logger)
 	}
 
 	if streamingIterators == nil {
@@ -741,6 +743,7 @@ func openBlockSeriesChunkRefsSetsIteratorFromPostings(
 	seriesHasher seriesHasher,
 	strategy seriesIteratorStrategy,
 	minTime, maxTime int64,
+	deletions tsdb.SeriesDeletionRequests,
 	stats *safeQueryStats,
 	postingsSetsIterator *postingsSetsIterator,
 	pendingMatchers []*labels.Matcher,
@@ -767,6 +770,10 @@ func openBlockSeriesChunkRefsSetsIteratorFromPostings(
 		it = newFilteringSeriesChunkRefsSetIterator(pendingMatchers, it, stats)
 	}
 
+	if deletions = deletions.Overlapping(minTime, maxTime); len(deletions) > 0 {
+		it = newSeriesDeletionSeriesChunkRefsSetIterator(deletions, minTime, maxTime, it, stats)
+	}
+
 	return it
 }
 
@@ -1240,6 +1247,92 @@ func (m *filteringSeriesChunkRefsSetIterator) Err() error {
 	return m.from.Err()
 }
 
+// seriesDeletionSeriesChunkRefsSetIterator filters out the series whose samples have been deleted by series deletion
+// requests in the whole queried time range, and the chunks whose samples have all been deleted.
+// The samples of partially deleted chunks are filtered out by queriers.
+type seriesDeletionSeriesChunkRefsSetIterator struct {
+	stats            *safeQueryStats
+	from             iterator[seriesChunkRefsSet]
+	deletions        tsdb.SeriesDeletionRequests
+	minTime, maxTime int64
+
+	current seriesChunkRefsSet
+}
+
+func newSeriesDeletionSeriesChunkRefsSetIterator(deletions tsdb.SeriesDeletionRequests, minTime, maxTime int64, from iterator[seriesChunkRefsSet], stats *safeQueryStats) *seriesDeletionSeriesChunkRefsSetIterator {
+	return &seriesDeletionSeriesChunkRefsSetIterator{
+		stats:     stats,
+		from:      from,
+		deletions: deletions,
+		minTime:   minTime,
+		maxTime:   maxTime,
+	}
+}
+
+func (m *seriesDeletionSeriesChunkRefsSetIterator) Next() bool {
+	if !m.from.Next() {
+		return false
+	}
+
+	next := m.from.At()
+	writeIdx := 0
+
+	for _, series := range next.series {
+		intervals := m.deletions.DeletedIntervals(series.lset)
+		if len(intervals) > 0 {
+			if tsdb.IntervalsCover(intervals, m.minTime, m.maxTime) {
+				continue
+			}
+			series.refs = withoutDeletedChunks(series.refs, intervals)
+		}
+
+		next.series[writeIdx] = series
+		writeIdx++
+	}
+	m.stats.update(func(stats *queryStats) {
+		stats.seriesOmitted += next.len() - writeIdx
+	})
+	next.series = next.series[:writeIdx]
+
+	if next.len() == 0 {
+		next.release()
+		return m.Next()
+	}
+	m.current = next
+	return true
+}
+
+// withoutDeletedChunks removes the chunks whose samples have all been deleted. The series is selected in both
+// phases of chunks streaming, so it always keeps at least one chunk: the queriers filter out the deleted samples.
+func withoutDeletedChunks(refs []seriesChunkRef, intervals tombstones.Intervals) []seriesChunkRef {
+	deleted := 0
+	for _, r := range refs {
+		if tsdb.IntervalsCover(intervals, r.minTime, r.maxTime) {
+			deleted++
+		}
+	}
+	if deleted == 0 || deleted == len(refs) {
+		return refs
+	}
+
+	writeIdx := 0
+	for _, r := range refs {
+		if !tsdb.IntervalsCover(intervals, r.minTime, r.maxTime) {
+			refs[writeIdx] = r
+			writeIdx++
+		}
+	}
+	return refs[:writeIdx]
+}
+
+func (m *seriesDeletionSeriesChunkRefsSetIterator) At() seriesChunkRefsSet {
+	return m.current
+}
+
+func (m *seriesDeletionSeriesChunkRefsSetIterator) Err() error {
+	return m.from.Err()
+}
+
 // cachedSeriesForPostingsID contains enough information to be able to tell whether a cache entry
 // is the right cache entry that we are looking for. We store only the postingsKey in the
 // cache key because the encoded postings are too big. We store the encoded postings within
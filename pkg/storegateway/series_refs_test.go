@@ -966,6 +966,50 @@ func TestDeduplicatingSeriesChunkRefsSetIterator_PropagatesErrors(t *testing.T)
 	assert.ErrorContains(t, chainedSet.Err(), "something went wrong")
 }
 
+func TestSeriesDeletionSeriesChunkRefsSetIterator(t *testing.T) {
+	blockID := ulid.MustNew(1, nil)
+	ref := func(minTime, maxTime int64) seriesChunkRef {
+		return seriesChunkRef{blockID: blockID, minTime: minTime, maxTime: maxTime}
+	}
+
+	now := time.Now()
+	partial, err := tsdb.NewSeriesDeletionRequest([]string{`{l1="v1"}`, `{l1="v3"}`}, 0, 19, now)
+	require.NoError(t, err)
+	full, err := tsdb.NewSeriesDeletionRequest([]string{`{l1="v2"}`}, 0, 100, now)
+	require.NoError(t, err)
+
+	input := newSliceSeriesChunkRefsSetIterator(nil,
+		seriesChunkRefsSet{series: []seriesChunkRefs{
+			{lset: labels.FromStrings("l1", "v1"), refs: []seriesChunkRef{ref(0, 9), ref(10, 19), ref(20, 29)}},
+			{lset: labels.FromStrings("l1", "v2"), refs: []seriesChunkRef{ref(0, 29)}},
+		}},
+		seriesChunkRefsSet{series: []seriesChunkRefs{
+			{lset: labels.FromStrings("l1", "v2"), refs: []seriesChunkRef{ref(0, 29)}},
+		}},
+		seriesChunkRefsSet{series: []seriesChunkRefs{
+			// All the chunks are deleted, but the series isn't fully deleted in the queried time range.
+			{lset: labels.FromStrings("l1", "v3"), refs: []seriesChunkRef{ref(0, 9), ref(10, 19)}},
+			{lset: labels.FromStrings("l1", "v4"), refs: []seriesChunkRef{ref(0, 9), ref(10, 19)}},
+		}},
+	)
+
+	stats := newSafeQueryStats()
+	it := newSeriesDeletionSeriesChunkRefsSetIterator(tsdb.SeriesDeletionRequests{partial, full}, 0, 29, input, stats)
+	sets := readAllSeriesChunkRefsSet(it)
+	require.NoError(t, it.Err())
+
+	assert.Equal(t, []seriesChunkRefsSet{
+		{series: []seriesChunkRefs{
+			{lset: labels.FromStrings("l1", "v1"), refs: []seriesChunkRef{ref(20, 29)}},
+		}},
+		{series: []seriesChunkRefs{
+			{lset: labels.FromStrings("l1", "v3"), refs: []seriesChunkRef{ref(0, 9), ref(10, 19)}},
+			{lset: labels.FromStrings("l1", "v4"), refs: []seriesChunkRef{ref(0, 9), ref(10, 19)}},
+		}},
+	}, sets)
+	assert.Equal(t, 2, stats.export().seriesOmitted)
+}
+
 func TestLimitingSeriesChunkRefsSetIterator(t *testing.T) {
 	blockID := ulid.MustNew(1, nil)
 	testCases := map[string]struct {
@@ -1710,6 +1754,7 @@ func TestOpenBlockSeriesChunkRefsSetsIterator(t *testing.T) {
 				strategy,
 				minT,
 				maxT,
+				nil,
 				newSafeQueryStats(),
 				log.NewNopLogger(),
 				nil,
@@ -1811,6 +1856,7 @@ func TestOpenBlockSeriesChunkRefsSetsIterator_pendingMatchers(t *testing.T) {
 					noChunkRefs, // skip chunks since we are testing labels filtering
 					block.meta.MinTime,
 					block.meta.MaxTime,
+					nil,
 					newSafeQueryStats(),
 					log.NewNopLogger(),
 					nil,
@@ -1882,6 +1928,7 @@ func BenchmarkOpenBlockSeriesChunkRefsSetsIterator(b *testing.B) {
 							defaultStrategy, // we don't skip chunks, so we can measure impact in loading chunk refs too
 							block.meta.MinTime,
 							block.meta.MaxTime,
+							nil,
 							newSafeQueryStats(),
 							log.NewNopLogger(),
 							nil,
@@ -2209,6 +2256,7 @@ func TestOpenBlockSeriesChunkRefsSetsIterator_SeriesCaching(t *testing.T) {
 						noChunkRefs,
 						b.meta.MinTime,
 						b.meta.MaxTime,
+						nil,
 						statsColdCache,
 						log.NewNopLogger(),
 						nil,
@@ -2240,6 +2288,7 @@ func TestOpenBlockSeriesChunkRefsSetsIterator_SeriesCaching(t *testing.T) {
 						noChunkRefs,
 						b.meta.MinTime,
 						b.meta.MaxTime,
+						nil,
 						statsWarmCache,
 						log.NewNopLogger(),
 						nil,
@@ -45,6 +45,12 @@ func (c *customStoreGatewayClient) LabelValues(ctx context.Context, in *storepb.
 	return res, globalerror.WrapGRPCErrorWithContextError(ctx, err)
 }
 
+// Exemplars implements StoreGatewayClient.
+func (c *customStoreGatewayClient) Exemplars(ctx context.Context, in *storepb.ExemplarsRequest, opts ...grpc.CallOption) (*storepb.ExemplarsResponse, error) {
+	res, err := c.wrapped.Exemplars(ctx, in, opts...)
+	return res, globalerror.WrapGRPCErrorWithContextError(ctx, err)
+}
+
 // customStoreGatewayClient is a custom StoreGateway_SeriesClient which wraps well known gRPC errors into standard golang errors.
 type customSeriesClient struct {
 	*customClientStream
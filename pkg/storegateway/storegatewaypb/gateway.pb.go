@@ -28,24 +28,25 @@ const _ = proto.GoGoProtoPackageIsVersion3 // please upgrade the proto package
 func init() { proto.RegisterFile("gateway.proto", fileDescriptor_f1a937782ebbded5) }
 
 var fileDescriptor_f1a937782ebbded5 = []byte{
-	// 263 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x90, 0xbd, 0x4a, 0x04, 0x31,
-	0x14, 0x46, 0x93, 0x66, 0xc1, 0xf8, 0x53, 0x04, 0x14, 0x5c, 0xe1, 0x3e, 0xc2, 0x44, 0xb4, 0x12,
-	0x3b, 0x15, 0x6d, 0xc4, 0xc2, 0x05, 0x0b, 0xbb, 0x9b, 0xe5, 0x3a, 0x3b, 0xb8, 0x33, 0x89, 0x49,
-	0x06, 0xb1, 0xf3, 0x11, 0x7c, 0x0c, 0x1f, 0xc5, 0x72, 0x2a, 0xd9, 0xd2, 0xc9, 0x34, 0x96, 0xfb,
-	0x08, 0xe2, 0x66, 0x07, 0x7f, 0xd8, 0xf2, 0x3b, 0xf7, 0x70, 0x8a, 0x2b, 0x36, 0x73, 0x0c, 0xf4,
-	0x88, 0x4f, 0x99, 0x75, 0x26, 0x18, 0xb9, 0xb6, 0x9c, 0x56, 0x0f, 0x8f, 0xf3, 0x22, 0x4c, 0x6a,
-	0x9d, 0x8d, 0x4d, 0xa9, 0x72, 0x87, 0x77, 0x58, 0xa1, 0x2a, 0x8b, 0xb2, 0x70, 0xca, 0xde, 0xe7,
-	0xca, 0x07, 0xe3, 0x68, 0x29, 0xa7, 0x61, 0xb5, 0x72, 0x76, 0x9c, 0x3a, 0x07, 0xef, 0x5c, 0x6c,
-	0x8c, 0xbe, 0xe9, 0x45, 0x52, 0xe4, 0x91, 0x18, 0x8c, 0xc8, 0x15, 0xe4, 0xe5, 0x76, 0x16, 0x26,
-	0x58, 0x19, 0x9f, 0xa5, 0x7d, 0x4d, 0x0f, 0x35, 0xf9, 0x30, 0xdc, 0xf9, 0x8f, 0xbd, 0x35, 0x95,
-	0xa7, 0x7d, 0x2e, 0x4f, 0x85, 0xb8, 0x44, 0x4d, 0xd3, 0x2b, 0x2c, 0xc9, 0xcb, 0xdd, 0xde, 0xfb,
-	0x61, 0x7d, 0x62, 0xb8, 0xea, 0x94, 0x32, 0xf2, 0x5c, 0xac, 0x2f, 0xe8, 0x0d, 0x4e, 0x6b, 0xf2,
-	0xf2, 0xaf, 0x9a, 0x60, 0x9f, 0xd9, 0x5b, 0x79, 0x4b, 0x9d, 0x93, 0xb3, 0xa6, 0x05, 0x36, 0x6b,
-	0x81, 0xcd, 0x5b, 0xe0, 0xcf, 0x11, 0xf8, 0x6b, 0x04, 0xfe, 0x16, 0x81, 0x37, 0x11, 0xf8, 0x47,
-	0x04, 0xfe, 0x19, 0x81, 0xcd, 0x23, 0xf0, 0x97, 0x0e, 0x58, 0xd3, 0x01, 0x9b, 0x75, 0xc0, 0x6e,
-	0xb7, 0x7e, 0xbf, 0xcb, 0x6a, 0x3d, 0x58, 0x7c, 0xe9, 0xf0, 0x2b, 0x00, 0x00, 0xff, 0xff, 0xb9,
-	0xbe, 0x15, 0x37, 0x7e, 0x01, 0x00, 0x00,
+	// 282 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x90, 0xbb, 0x4e, 0xc3, 0x30,
+	0x14, 0x86, 0x6d, 0x86, 0x4a, 0x35, 0x97, 0xc1, 0x12, 0x88, 0x16, 0xe9, 0x3c, 0x42, 0x82, 0x60,
+	0x42, 0x2c, 0x88, 0xeb, 0x82, 0x18, 0xa8, 0xc4, 0xc0, 0x66, 0x57, 0x87, 0x34, 0xa2, 0x89, 0x8d,
+	0xed, 0x08, 0xd8, 0x78, 0x04, 0x46, 0x1e, 0x81, 0x47, 0x61, 0xcc, 0xd8, 0x91, 0x38, 0x0b, 0x63,
+	0x1f, 0x01, 0x51, 0x27, 0xdc, 0x94, 0xf1, 0x7c, 0xff, 0xa7, 0x6f, 0x38, 0x6c, 0x35, 0x11, 0x0e,
+	0xef, 0xc5, 0x63, 0xa4, 0x8d, 0x72, 0x8a, 0xf7, 0x9b, 0x53, 0xcb, 0xe1, 0x7e, 0x92, 0xba, 0x49,
+	0x21, 0xa3, 0xb1, 0xca, 0xe2, 0xc4, 0x88, 0x1b, 0x91, 0x8b, 0x38, 0x4b, 0xb3, 0xd4, 0xc4, 0xfa,
+	0x36, 0x89, 0xad, 0x53, 0x06, 0x1b, 0x39, 0x1c, 0x5a, 0xc6, 0x46, 0x8f, 0x43, 0x67, 0xe7, 0x65,
+	0x89, 0xad, 0x8c, 0xbe, 0xe8, 0x59, 0x50, 0xf8, 0x1e, 0xeb, 0x8d, 0xd0, 0xa4, 0x68, 0xf9, 0x7a,
+	0xe4, 0x26, 0x22, 0x57, 0x36, 0x0a, 0xf7, 0x25, 0xde, 0x15, 0x68, 0xdd, 0x70, 0xe3, 0x3f, 0xb6,
+	0x5a, 0xe5, 0x16, 0xb7, 0x29, 0x3f, 0x62, 0xec, 0x5c, 0x48, 0x9c, 0x5e, 0x88, 0x0c, 0x2d, 0x1f,
+	0xb4, 0xde, 0x0f, 0x6b, 0x13, 0xc3, 0xae, 0x29, 0x64, 0xf8, 0x29, 0x5b, 0x5e, 0xd0, 0x2b, 0x31,
+	0x2d, 0xd0, 0xf2, 0xbf, 0x6a, 0x80, 0x6d, 0x66, 0xab, 0x73, 0x6b, 0x3a, 0x07, 0xac, 0x7f, 0xf2,
+	0x80, 0x99, 0x9e, 0x0a, 0x63, 0xf9, 0x66, 0x6b, 0x7e, 0xa3, 0xb6, 0x31, 0xe8, 0x58, 0x42, 0xe1,
+	0xf0, 0xb8, 0xac, 0x80, 0xcc, 0x2a, 0x20, 0xf3, 0x0a, 0xe8, 0x93, 0x07, 0xfa, 0xea, 0x81, 0xbe,
+	0x79, 0xa0, 0xa5, 0x07, 0xfa, 0xee, 0x81, 0x7e, 0x78, 0x20, 0x73, 0x0f, 0xf4, 0xb9, 0x06, 0x52,
+	0xd6, 0x40, 0x66, 0x35, 0x90, 0xeb, 0xb5, 0xdf, 0x0f, 0xd7, 0x52, 0xf6, 0x16, 0x7f, 0xde, 0xfd,
+	0x1c, 0x00, 0x69, 0xad, 0x6f, 0x83, 0xc0, 0x01, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -72,6 +73,8 @@ type StoreGatewayClient interface {
 	LabelNames(ctx context.Context, in *storepb.LabelNamesRequest, opts ...grpc.CallOption) (*storepb.LabelNamesResponse, error)
 	// LabelValues returns all label values for given label name.
 	LabelValues(ctx context.Context, in *storepb.LabelValuesRequest, opts ...grpc.CallOption) (*storepb.LabelValuesResponse, error)
+	// Exemplars returns the exemplars stored in the blocks for given label matchers and time range.
+	Exemplars(ctx context.Context, in *storepb.ExemplarsRequest, opts ...grpc.CallOption) (*storepb.ExemplarsResponse, error)
 }
 
 type storeGatewayClient struct {
@@ -132,6 +135,15 @@ func (c *storeGatewayClient) LabelValues(ctx context.Context, in *storepb.LabelV
 	return out, nil
 }
 
+func (c *storeGatewayClient) Exemplars(ctx context.Context, in *storepb.ExemplarsRequest, opts ...grpc.CallOption) (*storepb.ExemplarsResponse, error) {
+	out := new(storepb.ExemplarsResponse)
+	err := c.cc.Invoke(ctx, "/gatewaypb.StoreGateway/Exemplars", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // StoreGatewayServer is the server API for StoreGateway service.
 type StoreGatewayServer interface {
 	// Series streams each Series for given label matchers and time range.
@@ -146,6 +158,8 @@ type StoreGatewayServer interface {
 	LabelNames(context.Context, *storepb.LabelNamesRequest) (*storepb.LabelNamesResponse, error)
 	// LabelValues returns all label values for given label name.
 	LabelValues(context.Context, *storepb.LabelValuesRequest) (*storepb.LabelValuesResponse, error)
+	// Exemplars returns the exemplars stored in the blocks for given label matchers and time range.
+	Exemplars(context.Context, *storepb.ExemplarsRequest) (*storepb.ExemplarsResponse, error)
 }
 
 // UnimplementedStoreGatewayServer can be embedded to have forward compatible implementations.
@@ -161,6 +175,9 @@ func (*UnimplementedStoreGatewayServer) LabelNames(ctx context.Context, req *sto
 func (*UnimplementedStoreGatewayServer) LabelValues(ctx context.Context, req *storepb.LabelValuesRequest) (*storepb.LabelValuesResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method LabelValues not implemented")
 }
+func (*UnimplementedStoreGatewayServer) Exemplars(ctx context.Context, req *storepb.ExemplarsRequest) (*storepb.ExemplarsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Exemplars not implemented")
+}
 
 func RegisterStoreGatewayServer(s *grpc.Server, srv StoreGatewayServer) {
 	s.RegisterService(&_StoreGateway_serviceDesc, srv)
@@ -223,6 +240,24 @@ func _StoreGateway_LabelValues_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _StoreGateway_Exemplars_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(storepb.ExemplarsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StoreGatewayServer).Exemplars(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gatewaypb.StoreGateway/Exemplars",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StoreGatewayServer).Exemplars(ctx, req.(*storepb.ExemplarsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _StoreGateway_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "gatewaypb.StoreGateway",
 	HandlerType: (*StoreGatewayServer)(nil),
@@ -235,6 +270,10 @@ var _StoreGateway_serviceDesc = grpc.ServiceDesc{
 			MethodName: "LabelValues",
 			Handler:    _StoreGateway_LabelValues_Handler,
 		},
+		{
+			MethodName: "Exemplars",
+			Handler:    _StoreGateway_Exemplars_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -29,6 +29,14 @@ func NewHintsSeriesResponse(hints *types.Any) *SeriesResponse {
 	}
 }
 
+func NewWarnSeriesResponse(err error) *SeriesResponse {
+	return &SeriesResponse{
+		Result: &SeriesResponse_Warning{
+			Warning: err.Error(),
+		},
+	}
+}
+
 func NewStatsResponse(indexBytesFetched int) *SeriesResponse {
 	return &SeriesResponse{
 		Result: &SeriesResponse_Stats{
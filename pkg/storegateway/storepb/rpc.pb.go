@@ -8,10 +8,12 @@ import (
 	_ "github.com/gogo/protobuf/gogoproto"
 	proto "github.com/gogo/protobuf/proto"
 	types "github.com/gogo/protobuf/types"
+	mimirpb "github.com/grafana/mimir/pkg/mimirpb"
 	io "io"
 	math "math"
 	math_bits "math/bits"
 	reflect "reflect"
+	strconv "strconv"
 	strings "strings"
 )
 
@@ -26,10 +28,46 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.GoGoProtoPackageIsVersion3 // please upgrade the proto package
 
+type Aggr int32
+
+const (
+	RAW     Aggr = 0
+	COUNT   Aggr = 1
+	SUM     Aggr = 2
+	MIN     Aggr = 3
+	MAX     Aggr = 4
+	COUNTER Aggr = 5
+)
+
+var Aggr_name = map[int32]string{
+	0: "RAW",
+	1: "COUNT",
+	2: "SUM",
+	3: "MIN",
+	4: "MAX",
+	5: "COUNTER",
+}
+
+var Aggr_value = map[string]int32{
+	"RAW":     0,
+	"COUNT":   1,
+	"SUM":     2,
+	"MIN":     3,
+	"MAX":     4,
+	"COUNTER": 5,
+}
+
+func (Aggr) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{0}
+}
+
 type SeriesRequest struct {
 	MinTime  int64          `protobuf:"varint,1,opt,name=min_time,json=minTime,proto3" json:"min_time,omitempty"`
 	MaxTime  int64          `protobuf:"varint,2,opt,name=max_time,json=maxTime,proto3" json:"max_time,omitempty"`
 	Matchers []LabelMatcher `protobuf:"bytes,3,rep,name=matchers,proto3" json:"matchers"`
+	// aggregates is the list of aggregates to return for the downsampled blocks. When both COUNT and SUM
+	// are requested, the average is returned.
+	Aggregates []Aggr `protobuf:"varint,5,rep,packed,name=aggregates,proto3,enum=thanos.Aggr" json:"aggregates,omitempty"`
 	// skip_chunks controls whether sending chunks or not in series responses.
 	SkipChunks bool `protobuf:"varint,8,opt,name=skip_chunks,json=skipChunks,proto3" json:"skip_chunks,omitempty"`
 	// hints is an opaque data structure that can be used to carry additional information.
@@ -436,7 +474,87 @@ func (m *LabelValuesResponse) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_LabelValuesResponse proto.InternalMessageInfo
 
+type ExemplarsRequest struct {
+	MinTime  int64          `protobuf:"varint,1,opt,name=min_time,json=minTime,proto3" json:"min_time,omitempty"`
+	MaxTime  int64          `protobuf:"varint,2,opt,name=max_time,json=maxTime,proto3" json:"max_time,omitempty"`
+	Matchers []LabelMatcher `protobuf:"bytes,3,rep,name=matchers,proto3" json:"matchers"`
+	// hints is an opaque data structure that can be used to carry additional information.
+	// The content of this field and whether it's supported depends on the
+	// implementation of a specific store.
+	Hints *types.Any `protobuf:"bytes,4,opt,name=hints,proto3" json:"hints,omitempty"`
+}
+
+func (m *ExemplarsRequest) Reset()      { *m = ExemplarsRequest{} }
+func (*ExemplarsRequest) ProtoMessage() {}
+func (*ExemplarsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{7}
+}
+func (m *ExemplarsRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ExemplarsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ExemplarsRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ExemplarsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExemplarsRequest.Merge(m, src)
+}
+func (m *ExemplarsRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *ExemplarsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExemplarsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExemplarsRequest proto.InternalMessageInfo
+
+type ExemplarsResponse struct {
+	// Series with exemplars only: samples and histograms are never set.
+	Timeseries []mimirpb.TimeSeries `protobuf:"bytes,1,rep,name=timeseries,proto3" json:"timeseries"`
+}
+
+func (m *ExemplarsResponse) Reset()      { *m = ExemplarsResponse{} }
+func (*ExemplarsResponse) ProtoMessage() {}
+func (*ExemplarsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{8}
+}
+func (m *ExemplarsResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ExemplarsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ExemplarsResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ExemplarsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExemplarsResponse.Merge(m, src)
+}
+func (m *ExemplarsResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *ExemplarsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExemplarsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExemplarsResponse proto.InternalMessageInfo
+
 func init() {
+	proto.RegisterEnum("thanos.Aggr", Aggr_name, Aggr_value)
 	proto.RegisterType((*SeriesRequest)(nil), "thanos.SeriesRequest")
 	proto.RegisterType((*Stats)(nil), "thanos.Stats")
 	proto.RegisterType((*SeriesResponse)(nil), "thanos.SeriesResponse")
@@ -444,61 +562,79 @@ func init() {
 	proto.RegisterType((*LabelNamesResponse)(nil), "thanos.LabelNamesResponse")
 	proto.RegisterType((*LabelValuesRequest)(nil), "thanos.LabelValuesRequest")
 	proto.RegisterType((*LabelValuesResponse)(nil), "thanos.LabelValuesResponse")
+	proto.RegisterType((*ExemplarsRequest)(nil), "thanos.ExemplarsRequest")
+	proto.RegisterType((*ExemplarsResponse)(nil), "thanos.ExemplarsResponse")
 }
 
 func init() { proto.RegisterFile("rpc.proto", fileDescriptor_77a6da22d6a3feb1) }
 
 var fileDescriptor_77a6da22d6a3feb1 = []byte{
-	// 740 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x93, 0xc1, 0x6e, 0x13, 0x3b,
-	0x14, 0x86, 0xc7, 0x19, 0xcf, 0xc4, 0x71, 0x9a, 0xde, 0xe9, 0xb4, 0xba, 0x77, 0x9a, 0x7b, 0x35,
-	0x8d, 0x22, 0x5d, 0x29, 0x42, 0x90, 0x4a, 0x45, 0x82, 0x15, 0x8b, 0x06, 0x21, 0xa5, 0x23, 0x60,
-	0xe1, 0x22, 0x16, 0x48, 0x28, 0x9a, 0x24, 0x6e, 0x62, 0x35, 0xe3, 0x09, 0x63, 0x07, 0x9a, 0xae,
-	0x78, 0x04, 0x1e, 0x03, 0xc1, 0x13, 0xb0, 0x65, 0xd5, 0x65, 0x97, 0x5d, 0x21, 0x92, 0x6e, 0x58,
-	0xf6, 0x11, 0xd0, 0x78, 0x9c, 0xa4, 0x11, 0xad, 0x4a, 0x25, 0x76, 0x3e, 0xff, 0x7f, 0x7c, 0x7c,
-	0xfc, 0xf9, 0x18, 0x17, 0x92, 0x61, 0xa7, 0x3e, 0x4c, 0x62, 0x19, 0xbb, 0xb6, 0xec, 0x87, 0x3c,
-	0x16, 0xe5, 0xa2, 0x1c, 0x0f, 0xa9, 0xc8, 0xc4, 0xf2, 0xbd, 0x1e, 0x93, 0xfd, 0x51, 0xbb, 0xde,
-	0x89, 0xa3, 0xed, 0x5e, 0xdc, 0x8b, 0xb7, 0x95, 0xdc, 0x1e, 0x1d, 0xa8, 0x48, 0x05, 0x6a, 0xa5,
-	0xd3, 0x37, 0x7b, 0x71, 0xdc, 0x1b, 0xd0, 0x45, 0x56, 0xc8, 0xc7, 0x99, 0x55, 0xfd, 0x92, 0xc3,
-	0xa5, 0x7d, 0x9a, 0x30, 0x2a, 0x08, 0x7d, 0x33, 0xa2, 0x42, 0xba, 0x9b, 0x18, 0x45, 0x8c, 0xb7,
-	0x24, 0x8b, 0xa8, 0x07, 0x2a, 0xa0, 0x66, 0x92, 0x7c, 0xc4, 0xf8, 0x0b, 0x16, 0x51, 0x65, 0x85,
-	0x47, 0x99, 0x95, 0xd3, 0x56, 0x78, 0xa4, 0xac, 0x07, 0xa9, 0x25, 0x3b, 0x7d, 0x9a, 0x08, 0xcf,
-	0xac, 0x98, 0xb5, 0xe2, 0xce, 0x46, 0x3d, 0xeb, 0xbc, 0xfe, 0x34, 0x6c, 0xd3, 0xc1, 0xb3, 0xcc,
-	0x6c, 0xc0, 0x93, 0x6f, 0x5b, 0x06, 0x99, 0xe7, 0xba, 0x5b, 0xb8, 0x28, 0x0e, 0xd9, 0xb0, 0xd5,
-	0xe9, 0x8f, 0xf8, 0xa1, 0xf0, 0x50, 0x05, 0xd4, 0x10, 0xc1, 0xa9, 0xf4, 0x58, 0x29, 0xee, 0x1d,
-	0x6c, 0xf5, 0x19, 0x97, 0xc2, 0x2b, 0x54, 0x80, 0xaa, 0x9a, 0xdd, 0xa5, 0x3e, 0xbb, 0x4b, 0x7d,
-	0x97, 0x8f, 0x49, 0x96, 0xe2, 0x3e, 0xc2, 0xff, 0x0a, 0x99, 0xd0, 0x30, 0x62, 0xbc, 0xa7, 0x2b,
-	0xb6, 0xda, 0xe9, 0x49, 0x2d, 0xc1, 0x8e, 0xa9, 0xd7, 0xad, 0x80, 0x1a, 0x24, 0xde, 0x3c, 0x25,
-	0x3b, 0xa1, 0x91, 0x26, 0xec, 0xb3, 0x63, 0x1a, 0x40, 0x04, 0x1d, 0x2b, 0x80, 0xc8, 0x72, 0xec,
-	0x00, 0x22, 0xdb, 0xc9, 0x07, 0x10, 0xe5, 0x1d, 0x14, 0x40, 0x84, 0x9d, 0x62, 0x00, 0x51, 0xd1,
-	0x59, 0x09, 0x20, 0x5a, 0x71, 0x4a, 0x01, 0x44, 0x25, 0x67, 0xb5, 0xfa, 0x10, 0x5b, 0xfb, 0x32,
-	0x94, 0xc2, 0xad, 0xe3, 0xf5, 0x03, 0x9a, 0x5e, 0xa8, 0xdb, 0x62, 0xbc, 0x4b, 0x8f, 0x5a, 0xed,
-	0xb1, 0xa4, 0x42, 0xd1, 0x83, 0x64, 0x4d, 0x5b, 0x7b, 0xa9, 0xd3, 0x48, 0x8d, 0xea, 0x27, 0x13,
-	0xaf, 0xce, 0xa0, 0x8b, 0x61, 0xcc, 0x05, 0x75, 0x6b, 0xd8, 0x16, 0x4a, 0x51, 0xbb, 0x8a, 0x3b,
-	0xab, 0x33, 0x7a, 0x59, 0x5e, 0xd3, 0x20, 0xda, 0x77, 0xcb, 0x38, 0xff, 0x2e, 0x4c, 0x38, 0xe3,
-	0x3d, 0xf5, 0x06, 0x85, 0xa6, 0x41, 0x66, 0x82, 0x7b, 0x77, 0x06, 0xcb, 0xbc, 0x1e, 0x56, 0xd3,
-	0x98, 0xe1, 0xfa, 0x1f, 0x5b, 0x22, 0xed, 0xdf, 0x83, 0x2a, 0xbb, 0x34, 0x3f, 0x32, 0x15, 0xd3,
-	0x34, 0xe5, 0xba, 0x7b, 0xd8, 0x59, 0x50, 0xd5, 0x4d, 0x5a, 0x6a, 0xc7, 0x7f, 0x8b, 0x1d, 0xda,
-	0xcf, 0xba, 0x55, 0x48, 0x9b, 0x06, 0xf9, 0x4b, 0x2c, 0xeb, 0xcb, 0xa5, 0xf4, 0x93, 0xdb, 0xd7,
-	0x94, 0xba, 0xf4, 0x3a, 0x4b, 0xa5, 0xf4, 0x5c, 0xbc, 0xc6, 0x9b, 0xbf, 0xbc, 0x35, 0x15, 0x92,
-	0x45, 0xa1, 0xa4, 0x5e, 0x5e, 0xd5, 0xdc, 0xba, 0xa6, 0xe6, 0x13, 0x9d, 0xd6, 0x34, 0xc8, 0x3f,
-	0xe2, 0x6a, 0xab, 0x81, 0xb0, 0x9d, 0x50, 0x31, 0x1a, 0xc8, 0xea, 0x67, 0x80, 0xd7, 0xd4, 0x08,
-	0x3f, 0x0f, 0xa3, 0xc5, 0x2f, 0xd9, 0x50, 0xec, 0x12, 0xa9, 0x48, 0x9b, 0x24, 0x0b, 0x5c, 0x07,
-	0x9b, 0x94, 0x77, 0x15, 0x4f, 0x93, 0xa4, 0xcb, 0xc5, 0xf8, 0x5a, 0x37, 0x8f, 0xef, 0xe5, 0x3f,
-	0x64, 0xff, 0xfe, 0x1f, 0x0a, 0x20, 0x02, 0x4e, 0x2e, 0x80, 0x28, 0xe7, 0x98, 0xd5, 0x04, 0xbb,
-	0x97, 0x9b, 0xd5, 0xd3, 0xb5, 0x81, 0x2d, 0x9e, 0x0a, 0x1e, 0xa8, 0x98, 0xb5, 0x02, 0xc9, 0x02,
-	0xb7, 0x8c, 0x91, 0x1e, 0x1c, 0xe1, 0xe5, 0x94, 0x31, 0x8f, 0x17, 0x7d, 0x9b, 0x37, 0xf6, 0x5d,
-	0xfd, 0x0a, 0xf4, 0xa1, 0x2f, 0xc3, 0xc1, 0x68, 0x09, 0xd1, 0x20, 0x55, 0xd5, 0x44, 0x17, 0x48,
-	0x16, 0x2c, 0xc0, 0xc1, 0x2b, 0xc0, 0x59, 0x57, 0x80, 0xb3, 0x6f, 0x07, 0x2e, 0x7f, 0x2b, 0x70,
-	0x39, 0xc7, 0x0c, 0x20, 0x32, 0x1d, 0x58, 0x1d, 0xe1, 0xf5, 0xa5, 0x3b, 0x68, 0x72, 0x7f, 0x63,
-	0xfb, 0xad, 0x52, 0x34, 0x3a, 0x1d, 0xfd, 0x29, 0x76, 0x8d, 0xdd, 0x93, 0x89, 0x6f, 0x9c, 0x4e,
-	0x7c, 0xe3, 0x6c, 0xe2, 0x1b, 0x17, 0x13, 0x1f, 0xbc, 0x9f, 0xfa, 0xe0, 0xe3, 0xd4, 0x07, 0x27,
-	0x53, 0x1f, 0x9c, 0x4e, 0x7d, 0xf0, 0x7d, 0xea, 0x83, 0x1f, 0x53, 0xdf, 0xb8, 0x98, 0xfa, 0xe0,
-	0xc3, 0xb9, 0x6f, 0x9c, 0x9e, 0xfb, 0xc6, 0xd9, 0xb9, 0x6f, 0xbc, 0xca, 0x0b, 0x19, 0x27, 0x74,
-	0xd8, 0x6e, 0xdb, 0xaa, 0xee, 0xfd, 0x9f, 0x01, 0x00, 0x00, 0xff, 0xff, 0xfa, 0xb6, 0x7d, 0x6f,
-	0x35, 0x06, 0x00, 0x00,
+	// 882 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xc4, 0x55, 0xdd, 0x6a, 0xe3, 0x46,
+	0x14, 0xd6, 0x58, 0x23, 0x4b, 0x3e, 0x4e, 0xd2, 0x89, 0x36, 0xb4, 0x4a, 0x5a, 0x14, 0x63, 0x28,
+	0x98, 0x65, 0xeb, 0x94, 0x14, 0x5a, 0x28, 0xf4, 0xc2, 0x5e, 0x02, 0x8e, 0x68, 0xb2, 0xa0, 0xec,
+	0xb6, 0xa5, 0x50, 0x8c, 0xe4, 0x4c, 0x64, 0x11, 0xeb, 0xa7, 0x9a, 0x71, 0xeb, 0xec, 0x55, 0x1f,
+	0xa1, 0x8f, 0x51, 0xda, 0x3e, 0x45, 0xaf, 0x72, 0x99, 0xcb, 0xbd, 0x2a, 0xb5, 0x73, 0xd3, 0xcb,
+	0x7d, 0x84, 0x32, 0xa3, 0xf1, 0x1f, 0x4d, 0x48, 0x17, 0x0a, 0x7b, 0xe5, 0x39, 0xe7, 0xfb, 0xe6,
+	0x9c, 0x6f, 0xbe, 0x39, 0x1a, 0x43, 0xad, 0xc8, 0x07, 0xed, 0xbc, 0xc8, 0x78, 0x66, 0x57, 0xf9,
+	0x30, 0x48, 0x33, 0xb6, 0x57, 0xe7, 0x57, 0x39, 0x65, 0x65, 0x72, 0xef, 0xa3, 0x28, 0xe6, 0xc3,
+	0x71, 0xd8, 0x1e, 0x64, 0xc9, 0x41, 0x94, 0x45, 0xd9, 0x81, 0x4c, 0x87, 0xe3, 0x0b, 0x19, 0xc9,
+	0x40, 0xae, 0x14, 0x7d, 0x37, 0xca, 0xb2, 0x68, 0x44, 0x97, 0xac, 0x20, 0xbd, 0x52, 0xd0, 0xc7,
+	0xab, 0x95, 0x8a, 0xe0, 0x22, 0x48, 0x83, 0x83, 0x24, 0x4e, 0xe2, 0xe2, 0x20, 0xbf, 0x8c, 0xca,
+	0x55, 0x1e, 0x96, 0xbf, 0xe5, 0x8e, 0xe6, 0xb4, 0x02, 0x9b, 0x67, 0xb4, 0x88, 0x29, 0xf3, 0xe9,
+	0xf7, 0x63, 0xca, 0xb8, 0xbd, 0x0b, 0x56, 0x12, 0xa7, 0x7d, 0x1e, 0x27, 0xd4, 0x41, 0x0d, 0xd4,
+	0xd2, 0x7d, 0x33, 0x89, 0xd3, 0xe7, 0x71, 0x42, 0x25, 0x14, 0x4c, 0x4a, 0xa8, 0xa2, 0xa0, 0x60,
+	0x22, 0xa1, 0x4f, 0x05, 0xc4, 0x07, 0x43, 0x5a, 0x30, 0x47, 0x6f, 0xe8, 0xad, 0xfa, 0xe1, 0x4e,
+	0xbb, 0x3c, 0x6b, 0xfb, 0xcb, 0x20, 0xa4, 0xa3, 0x93, 0x12, 0xec, 0xe2, 0xeb, 0x3f, 0xf7, 0x35,
+	0x7f, 0xc1, 0xb5, 0x9f, 0x00, 0x04, 0x51, 0x54, 0xd0, 0x28, 0xe0, 0x94, 0x39, 0x46, 0x43, 0x6f,
+	0x6d, 0x1d, 0x6e, 0xcc, 0x77, 0x76, 0xa2, 0xa8, 0xf0, 0x57, 0x70, 0x7b, 0x1f, 0xea, 0xec, 0x32,
+	0xce, 0xfb, 0x83, 0xe1, 0x38, 0xbd, 0x64, 0x8e, 0xd5, 0x40, 0x2d, 0xcb, 0x07, 0x91, 0x7a, 0x2a,
+	0x33, 0xf6, 0x63, 0x30, 0x86, 0x71, 0xca, 0x99, 0x53, 0x6b, 0x20, 0xa9, 0xa1, 0xf4, 0xaa, 0x3d,
+	0xf7, 0xaa, 0xdd, 0x49, 0xaf, 0xfc, 0x92, 0x62, 0x7f, 0x01, 0xef, 0x33, 0x5e, 0xd0, 0x20, 0x89,
+	0xd3, 0x48, 0x55, 0xec, 0x87, 0x42, 0x57, 0x9f, 0xc5, 0x2f, 0xa9, 0x73, 0xde, 0x40, 0x2d, 0xec,
+	0x3b, 0x0b, 0x4a, 0xd9, 0xa1, 0x2b, 0x08, 0x67, 0xf1, 0x4b, 0xea, 0x61, 0x0b, 0x13, 0xc3, 0xc3,
+	0x56, 0x95, 0x98, 0x1e, 0xb6, 0x4c, 0x62, 0x79, 0xd8, 0x02, 0x52, 0xf7, 0xb0, 0x55, 0x27, 0x1b,
+	0x1e, 0xb6, 0x36, 0xc8, 0xa6, 0x87, 0xad, 0x4d, 0xb2, 0xd5, 0xfc, 0x0c, 0x8c, 0x33, 0x1e, 0x70,
+	0x66, 0xb7, 0xe1, 0xd1, 0x05, 0x15, 0x07, 0x3f, 0xef, 0xc7, 0xe9, 0x39, 0x9d, 0xf4, 0xc3, 0x2b,
+	0x71, 0x6a, 0x24, 0x3b, 0x6d, 0x2b, 0xe8, 0x58, 0x20, 0x5d, 0x01, 0x34, 0x7f, 0xd5, 0x61, 0x6b,
+	0x7e, 0x39, 0x2c, 0xcf, 0x52, 0x46, 0xed, 0x16, 0x54, 0x99, 0xcc, 0xc8, 0x5d, 0xf5, 0xc3, 0xad,
+	0xb9, 0x57, 0x25, 0xaf, 0xa7, 0xf9, 0x0a, 0xb7, 0xf7, 0xc0, 0xfc, 0x31, 0x28, 0xd2, 0x38, 0x8d,
+	0xe4, 0x5d, 0xd5, 0x7a, 0x9a, 0x3f, 0x4f, 0xd8, 0x4f, 0xe6, 0x36, 0xe9, 0xf7, 0xdb, 0xd4, 0xd3,
+	0xe6, 0x46, 0x7d, 0x08, 0x06, 0x13, 0xfa, 0x1d, 0x2c, 0xd9, 0x9b, 0x8b, 0x96, 0x22, 0x29, 0x68,
+	0x12, 0xb5, 0x8f, 0x81, 0x2c, 0xfd, 0x54, 0x22, 0x0d, 0xb9, 0xe3, 0x83, 0xe5, 0x0e, 0x85, 0x97,
+	0x6a, 0xa5, 0x99, 0x3d, 0xcd, 0x7f, 0x87, 0xad, 0xe7, 0xd7, 0x4b, 0xa9, 0xcb, 0xae, 0xde, 0x53,
+	0x6a, 0xe5, 0x5e, 0xd6, 0x4a, 0xa9, 0x89, 0xf8, 0x0e, 0x76, 0xff, 0x75, 0xcb, 0x94, 0xf1, 0x38,
+	0x09, 0x38, 0x75, 0x4c, 0x59, 0x73, 0xff, 0x9e, 0x9a, 0x47, 0x8a, 0xd6, 0xd3, 0xfc, 0xf7, 0xd8,
+	0xdd, 0x50, 0xd7, 0x82, 0x6a, 0x41, 0xd9, 0x78, 0xc4, 0x9b, 0xbf, 0x21, 0xd8, 0x96, 0xa3, 0x7e,
+	0x1a, 0x24, 0xcb, 0xaf, 0x69, 0x47, 0x7a, 0x57, 0x70, 0xe9, 0xb4, 0xee, 0x97, 0x81, 0x4d, 0x40,
+	0xa7, 0xe9, 0xb9, 0xf4, 0x53, 0xf7, 0xc5, 0x72, 0x39, 0xb8, 0xc6, 0xc3, 0x83, 0xbb, 0xfa, 0xad,
+	0x55, 0xff, 0xfb, 0xb7, 0xe6, 0x61, 0x0b, 0x91, 0x8a, 0x87, 0xad, 0x0a, 0xd1, 0x9b, 0x05, 0xd8,
+	0xab, 0x62, 0xd5, 0x74, 0xed, 0x80, 0x91, 0x8a, 0x84, 0x83, 0x1a, 0x7a, 0xab, 0xe6, 0x97, 0x81,
+	0xbd, 0x07, 0x96, 0x1a, 0x1c, 0xe6, 0x54, 0x24, 0xb0, 0x88, 0x97, 0xba, 0xf5, 0x07, 0x75, 0x37,
+	0xff, 0x40, 0xaa, 0xe9, 0x57, 0xc1, 0x68, 0xbc, 0x66, 0xd1, 0x48, 0x64, 0xe5, 0x44, 0xd7, 0xfc,
+	0x32, 0x58, 0x1a, 0x87, 0xef, 0x30, 0xce, 0xb8, 0xc3, 0xb8, 0xea, 0x9b, 0x19, 0x67, 0xbe, 0x91,
+	0x71, 0x15, 0xa2, 0x7b, 0xd8, 0xd2, 0x09, 0x6e, 0x8e, 0xe1, 0xd1, 0xda, 0x19, 0x94, 0x73, 0xef,
+	0x42, 0xf5, 0x07, 0x99, 0x51, 0xd6, 0xa9, 0xe8, 0x7f, 0xf3, 0xee, 0x77, 0x04, 0xe4, 0x68, 0x42,
+	0x93, 0x7c, 0x14, 0x14, 0x6f, 0xe9, 0xa9, 0x5e, 0xc8, 0xc5, 0x0f, 0xcb, 0x7d, 0x06, 0xdb, 0x2b,
+	0x6a, 0x95, 0x47, 0x9f, 0x03, 0x08, 0x3d, 0x8b, 0xf7, 0xab, 0x6c, 0x3d, 0xc8, 0x0a, 0x4e, 0x27,
+	0x79, 0xd8, 0x16, 0xe2, 0xd4, 0xbb, 0x50, 0xb6, 0x5e, 0x61, 0x3f, 0xee, 0x02, 0x16, 0xff, 0x06,
+	0xb6, 0x09, 0xba, 0xdf, 0xf9, 0x9a, 0x68, 0x76, 0x0d, 0x8c, 0xa7, 0xcf, 0x5e, 0x9c, 0x3e, 0x27,
+	0x48, 0xe4, 0xce, 0x5e, 0x9c, 0x90, 0x8a, 0x58, 0x9c, 0x1c, 0x9f, 0x12, 0x5d, 0x2e, 0x3a, 0xdf,
+	0x10, 0x6c, 0xd7, 0xc1, 0x94, 0xac, 0x23, 0x9f, 0x18, 0xdd, 0xce, 0xf5, 0xd4, 0xd5, 0x6e, 0xa6,
+	0xae, 0xf6, 0x6a, 0xea, 0x6a, 0xaf, 0xa7, 0x2e, 0xfa, 0x69, 0xe6, 0xa2, 0x5f, 0x66, 0x2e, 0xba,
+	0x9e, 0xb9, 0xe8, 0x66, 0xe6, 0xa2, 0xbf, 0x66, 0x2e, 0xfa, 0x7b, 0xe6, 0x6a, 0xaf, 0x67, 0x2e,
+	0xfa, 0xf9, 0xd6, 0xd5, 0x6e, 0x6e, 0x5d, 0xed, 0xd5, 0xad, 0xab, 0x7d, 0x6b, 0x32, 0x9e, 0x15,
+	0x34, 0x0f, 0xc3, 0xaa, 0x3c, 0xec, 0x27, 0xff, 0x0c, 0x00, 0x14, 0x65, 0xe8, 0xac, 0xd3, 0x07,
+	0x00, 0x00,
 }
 
+func (x Aggr) String() string {
+	s, ok := Aggr_name[int32(x)]
+	if ok {
+		return s
+	}
+	return strconv.Itoa(int(x))
+}
 func (this *SeriesRequest) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
@@ -532,6 +668,14 @@ func (this *SeriesRequest) Equal(that interface{}) bool {
 			return false
 		}
 	}
+	if len(this.Aggregates) != len(that1.Aggregates) {
+		return false
+	}
+	for i := range this.Aggregates {
+		if this.Aggregates[i] != that1.Aggregates[i] {
+			return false
+		}
+	}
 	if this.SkipChunks != that1.SkipChunks {
 		return false
 	}
@@ -924,11 +1068,78 @@ func (this *LabelValuesResponse) Equal(that interface{}) bool {
 	}
 	return true
 }
+func (this *ExemplarsRequest) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*ExemplarsRequest)
+	if !ok {
+		that2, ok := that.(ExemplarsRequest)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.MinTime != that1.MinTime {
+		return false
+	}
+	if this.MaxTime != that1.MaxTime {
+		return false
+	}
+	if len(this.Matchers) != len(that1.Matchers) {
+		return false
+	}
+	for i := range this.Matchers {
+		if !this.Matchers[i].Equal(&that1.Matchers[i]) {
+			return false
+		}
+	}
+	if !this.Hints.Equal(that1.Hints) {
+		return false
+	}
+	return true
+}
+func (this *ExemplarsResponse) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*ExemplarsResponse)
+	if !ok {
+		that2, ok := that.(ExemplarsResponse)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if len(this.Timeseries) != len(that1.Timeseries) {
+		return false
+	}
+	for i := range this.Timeseries {
+		if !this.Timeseries[i].Equal(&that1.Timeseries[i]) {
+			return false
+		}
+	}
+	return true
+}
 func (this *SeriesRequest) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 10)
+	s := make([]string, 0, 11)
 	s = append(s, "&storepb.SeriesRequest{")
 	s = append(s, "MinTime: "+fmt.Sprintf("%#v", this.MinTime)+",\n")
 	s = append(s, "MaxTime: "+fmt.Sprintf("%#v", this.MaxTime)+",\n")
@@ -939,6 +1150,7 @@ func (this *SeriesRequest) GoString() string {
 		}
 		s = append(s, "Matchers: "+fmt.Sprintf("%#v", vs)+",\n")
 	}
+	s = append(s, "Aggregates: "+fmt.Sprintf("%#v", this.Aggregates)+",\n")
 	s = append(s, "SkipChunks: "+fmt.Sprintf("%#v", this.SkipChunks)+",\n")
 	if this.Hints != nil {
 		s = append(s, "Hints: "+fmt.Sprintf("%#v", this.Hints)+",\n")
@@ -1096,6 +1308,43 @@ func (this *LabelValuesResponse) GoString() string {
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
+func (this *ExemplarsRequest) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 8)
+	s = append(s, "&storepb.ExemplarsRequest{")
+	s = append(s, "MinTime: "+fmt.Sprintf("%#v", this.MinTime)+",\n")
+	s = append(s, "MaxTime: "+fmt.Sprintf("%#v", this.MaxTime)+",\n")
+	if this.Matchers != nil {
+		vs := make([]LabelMatcher, len(this.Matchers))
+		for i := range vs {
+			vs[i] = this.Matchers[i]
+		}
+		s = append(s, "Matchers: "+fmt.Sprintf("%#v", vs)+",\n")
+	}
+	if this.Hints != nil {
+		s = append(s, "Hints: "+fmt.Sprintf("%#v", this.Hints)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *ExemplarsResponse) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 5)
+	s = append(s, "&storepb.ExemplarsResponse{")
+	if this.Timeseries != nil {
+		vs := make([]mimirpb.TimeSeries, len(this.Timeseries))
+		for i := range vs {
+			vs[i] = this.Timeseries[i]
+		}
+		s = append(s, "Timeseries: "+fmt.Sprintf("%#v", vs)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
 func valueToGoStringRpc(v interface{}, typ string) string {
 	rv := reflect.ValueOf(v)
 	if rv.IsNil() {
@@ -1153,6 +1402,24 @@ func (m *SeriesRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i--
 		dAtA[i] = 0x40
 	}
+	if len(m.Aggregates) > 0 {
+		dAtA3 := make([]byte, len(m.Aggregates)*10)
+		var j2 int
+		for _, num := range m.Aggregates {
+			for num >= 1<<7 {
+				dAtA3[j2] = uint8(uint64(num)&0x7f | 0x80)
+				num >>= 7
+				j2++
+			}
+			dAtA3[j2] = uint8(num)
+			j2++
+		}
+		i -= j2
+		copy(dAtA[i:], dAtA3[:j2])
+		i = encodeVarintRpc(dAtA, i, uint64(j2))
+		i--
+		dAtA[i] = 0x2a
+	}
 	if len(m.Matchers) > 0 {
 		for iNdEx := len(m.Matchers) - 1; iNdEx >= 0; iNdEx-- {
 			{
@@ -1604,6 +1871,102 @@ func (m *LabelValuesResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *ExemplarsRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ExemplarsRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ExemplarsRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Hints != nil {
+		{
+			size, err := m.Hints.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintRpc(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x22
+	}
+	if len(m.Matchers) > 0 {
+		for iNdEx := len(m.Matchers) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Matchers[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintRpc(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x1a
+		}
+	}
+	if m.MaxTime != 0 {
+		i = encodeVarintRpc(dAtA, i, uint64(m.MaxTime))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.MinTime != 0 {
+		i = encodeVarintRpc(dAtA, i, uint64(m.MinTime))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ExemplarsResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ExemplarsResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ExemplarsResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Timeseries) > 0 {
+		for iNdEx := len(m.Timeseries) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Timeseries[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintRpc(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
 func encodeVarintRpc(dAtA []byte, offset int, v uint64) int {
 	offset -= sovRpc(v)
 	base := offset
@@ -1633,6 +1996,13 @@ func (m *SeriesRequest) Size() (n int) {
 			n += 1 + l + sovRpc(uint64(l))
 		}
 	}
+	if len(m.Aggregates) > 0 {
+		l = 0
+		for _, e := range m.Aggregates {
+			l += sovRpc(uint64(e))
+		}
+		n += 1 + sovRpc(uint64(l)) + l
+	}
 	if m.SkipChunks {
 		n += 2
 	}
@@ -1856,17 +2226,57 @@ func (m *LabelValuesResponse) Size() (n int) {
 	return n
 }
 
-func sovRpc(x uint64) (n int) {
-	return (math_bits.Len64(x|1) + 6) / 7
-}
-func sozRpc(x uint64) (n int) {
-	return sovRpc(uint64((x << 1) ^ uint64((int64(x) >> 63))))
-}
-func (this *SeriesRequest) String() string {
-	if this == nil {
-		return "nil"
+func (m *ExemplarsRequest) Size() (n int) {
+	if m == nil {
+		return 0
 	}
-	repeatedStringForMatchers := "[]LabelMatcher{"
+	var l int
+	_ = l
+	if m.MinTime != 0 {
+		n += 1 + sovRpc(uint64(m.MinTime))
+	}
+	if m.MaxTime != 0 {
+		n += 1 + sovRpc(uint64(m.MaxTime))
+	}
+	if len(m.Matchers) > 0 {
+		for _, e := range m.Matchers {
+			l = e.Size()
+			n += 1 + l + sovRpc(uint64(l))
+		}
+	}
+	if m.Hints != nil {
+		l = m.Hints.Size()
+		n += 1 + l + sovRpc(uint64(l))
+	}
+	return n
+}
+
+func (m *ExemplarsResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Timeseries) > 0 {
+		for _, e := range m.Timeseries {
+			l = e.Size()
+			n += 1 + l + sovRpc(uint64(l))
+		}
+	}
+	return n
+}
+
+func sovRpc(x uint64) (n int) {
+	return (math_bits.Len64(x|1) + 6) / 7
+}
+func sozRpc(x uint64) (n int) {
+	return sovRpc(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (this *SeriesRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForMatchers := "[]LabelMatcher{"
 	for _, f := range this.Matchers {
 		repeatedStringForMatchers += fmt.Sprintf("%v", f) + ","
 	}
@@ -1875,6 +2285,7 @@ func (this *SeriesRequest) String() string {
 		`MinTime:` + fmt.Sprintf("%v", this.MinTime) + `,`,
 		`MaxTime:` + fmt.Sprintf("%v", this.MaxTime) + `,`,
 		`Matchers:` + repeatedStringForMatchers + `,`,
+		`Aggregates:` + fmt.Sprintf("%v", this.Aggregates) + `,`,
 		`SkipChunks:` + fmt.Sprintf("%v", this.SkipChunks) + `,`,
 		`Hints:` + strings.Replace(fmt.Sprintf("%v", this.Hints), "Any", "types.Any", 1) + `,`,
 		`StreamingChunksBatchSize:` + fmt.Sprintf("%v", this.StreamingChunksBatchSize) + `,`,
@@ -2033,6 +2444,39 @@ func (this *LabelValuesResponse) String() string {
 	}, "")
 	return s
 }
+func (this *ExemplarsRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForMatchers := "[]LabelMatcher{"
+	for _, f := range this.Matchers {
+		repeatedStringForMatchers += fmt.Sprintf("%v", f) + ","
+	}
+	repeatedStringForMatchers += "}"
+	s := strings.Join([]string{`&ExemplarsRequest{`,
+		`MinTime:` + fmt.Sprintf("%v", this.MinTime) + `,`,
+		`MaxTime:` + fmt.Sprintf("%v", this.MaxTime) + `,`,
+		`Matchers:` + repeatedStringForMatchers + `,`,
+		`Hints:` + strings.Replace(fmt.Sprintf("%v", this.Hints), "Any", "types.Any", 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *ExemplarsResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForTimeseries := "[]TimeSeries{"
+	for _, f := range this.Timeseries {
+		repeatedStringForTimeseries += fmt.Sprintf("%v", f) + ","
+	}
+	repeatedStringForTimeseries += "}"
+	s := strings.Join([]string{`&ExemplarsResponse{`,
+		`Timeseries:` + repeatedStringForTimeseries + `,`,
+		`}`,
+	}, "")
+	return s
+}
 func valueToStringRpc(v interface{}) string {
 	rv := reflect.ValueOf(v)
 	if rv.IsNil() {
@@ -2142,6 +2586,75 @@ func (m *SeriesRequest) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 5:
+			if wireType == 0 {
+				var v Aggr
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowRpc
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					v |= Aggr(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				m.Aggregates = append(m.Aggregates, v)
+			} else if wireType == 2 {
+				var packedLen int
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowRpc
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					packedLen |= int(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				if packedLen < 0 {
+					return ErrInvalidLengthRpc
+				}
+				postIndex := iNdEx + packedLen
+				if postIndex < 0 {
+					return ErrInvalidLengthRpc
+				}
+				if postIndex > l {
+					return io.ErrUnexpectedEOF
+				}
+				var elementCount int
+				if elementCount != 0 && len(m.Aggregates) == 0 {
+					m.Aggregates = make([]Aggr, 0, elementCount)
+				}
+				for iNdEx < postIndex {
+					var v Aggr
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowRpc
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						v |= Aggr(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					m.Aggregates = append(m.Aggregates, v)
+				}
+			} else {
+				return fmt.Errorf("proto: wrong wireType = %d for field Aggregates", wireType)
+			}
 		case 8:
 			if wireType != 0 {
 				return fmt.Errorf("proto: wrong wireType = %d for field SkipChunks", wireType)
@@ -3244,6 +3757,248 @@ func (m *LabelValuesResponse) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipRpc(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ExemplarsRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowRpc
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ExemplarsRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ExemplarsRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MinTime", wireType)
+			}
+			m.MinTime = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MinTime |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxTime", wireType)
+			}
+			m.MaxTime = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxTime |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Matchers", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Matchers = append(m.Matchers, LabelMatcher{})
+			if err := m.Matchers[len(m.Matchers)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Hints", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Hints == nil {
+				m.Hints = &types.Any{}
+			}
+			if err := m.Hints.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipRpc(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ExemplarsResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowRpc
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ExemplarsResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ExemplarsResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Timeseries", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Timeseries = append(m.Timeseries, mimirpb.TimeSeries{})
+			if err := m.Timeseries[len(m.Timeseries)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipRpc(dAtA[iNdEx:])
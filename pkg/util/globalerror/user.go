@@ -29,8 +29,12 @@ const (
 	SampleTooFarInFuture                  ID = "too-far-in-future"
 	SampleTooFarInPast                    ID = "too-far-in-past"
 	MaxSeriesPerMetric                    ID = "max-series-per-metric"
+	MaxSeriesPerLabelValue                ID = "max-series-per-label-value"
 	MaxMetadataPerMetric                  ID = "max-metadata-per-metric"
 	MaxSeriesPerUser                      ID = "max-series-per-user"
+	MaxMemoryPerUser                      ID = "max-memory-per-user"
+	MaxIngesterConcurrentQueries          ID = "max-ingester-concurrent-queries"
+	MaxIngesterInflightQuerySeries        ID = "max-ingester-inflight-query-series"
 	MaxMetadataPerUser                    ID = "max-metadata-per-user"
 	MaxChunksPerQuery                     ID = "max-chunks-per-query"
 	MaxSeriesPerQuery                     ID = "max-series-per-query"
@@ -121,6 +125,23 @@ func (id ID) MessageWithStrategyAndPerTenantLimitConfig(msg, strategy, flag stri
 		msg, errPrefix, id, strategy, plural, flagsList)
 }
 
+// IDFromMessage returns the error ID of a message built by ID.Message or its variants.
+// It returns false if the message doesn't include an error ID.
+func IDFromMessage(msg string) (ID, bool) {
+	// The ID is appended after the message, which may include user data, so we look for the last occurrence.
+	start := strings.LastIndex(msg, "("+errPrefix)
+	if start < 0 {
+		return "", false
+	}
+	start += len(errPrefix) + 1
+
+	end := strings.IndexByte(msg[start:], ')')
+	if end <= 0 {
+		return "", false
+	}
+	return ID(msg[start : start+end]), true
+}
+
 // LabelValue returns the error ID converted to a form suitable for use as a Prometheus label value.
 func (id ID) LabelValue() string {
 	return strings.ReplaceAll(string(id), "-", "_")
@@ -58,3 +58,34 @@ func TestID_MessageWithPerTenantLimitConfig(t *testing.T) {
 		assert.Equal(t, tc.expected, tc.actual)
 	}
 }
+
+func TestIDFromMessage(t *testing.T) {
+	for _, tc := range []struct {
+		msg        string
+		expectedID ID
+		expectedOK bool
+	}{
+		{
+			msg:        MissingMetricName.Message("an error"),
+			expectedID: MissingMetricName,
+			expectedOK: true,
+		},
+		{
+			msg:        SeriesLabelValueTooLong.MessageWithPerTenantLimitConfig("an error with (err-mimir-fake) user data", "my-flag1"),
+			expectedID: SeriesLabelValueTooLong,
+			expectedOK: true,
+		},
+		{
+			msg:        "an error",
+			expectedOK: false,
+		},
+		{
+			msg:        "an error (err-mimir-",
+			expectedOK: false,
+		},
+	} {
+		id, ok := IDFromMessage(tc.msg)
+		assert.Equal(t, tc.expectedID, id)
+		assert.Equal(t, tc.expectedOK, ok)
+	}
+}
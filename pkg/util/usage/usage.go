@@ -223,7 +223,7 @@ func getFlagName(fl *flag.Flag) string {
 			return "string"
 		case "*model.Duration":
 			return "duration"
-		case "*tsdb.DurationList":
+		case "*tsdb.DurationList", "*validation.DurationList":
 			return "comma-separated list of durations"
 		}
 	}
@@ -0,0 +1,40 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package validation
+
+import (
+	"strings"
+	"time"
+)
+
+// DurationList is a list of durations, set from a comma-separated flag value.
+type DurationList []time.Duration
+
+// String implements the flag.Value interface
+func (d *DurationList) String() string {
+	values := make([]string, 0, len(*d))
+	for _, v := range *d {
+		values = append(values, v.String())
+	}
+
+	return strings.Join(values, ",")
+}
+
+// Set implements the flag.Value interface
+func (d *DurationList) Set(s string) error {
+	if s == "" {
+		*d = nil
+		return nil
+	}
+
+	values := strings.Split(s, ",")
+	*d = make([]time.Duration, 0, len(values)) // flag.Parse may be called twice, so overwrite instead of append
+	for _, v := range values {
+		t, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		*d = append(*d, t)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package validation
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/prometheus/common/model"
+)
+
+// LabelTransformationAction is the action applied by a LabelTransformation.
+type LabelTransformationAction string
+
+const (
+	// LabelTransformationLowercase converts label values to lowercase.
+	LabelTransformationLowercase LabelTransformationAction = "lowercase"
+	// LabelTransformationTrim removes leading and trailing white space from label values.
+	LabelTransformationTrim LabelTransformationAction = "trim"
+	// LabelTransformationTruncate truncates label values longer than the max length, appending a hash
+	// of the original value to keep truncated values distinct.
+	LabelTransformationTruncate LabelTransformationAction = "truncate"
+	// LabelTransformationMapOTelNames maps OpenTelemetry semantic convention names, like "http.server.duration",
+	// to Prometheus compliant metric and label names, like "http_server_duration".
+	LabelTransformationMapOTelNames LabelTransformationAction = "map_otel_names"
+	// LabelTransformationDropUUIDValues removes labels whose value is a UUID.
+	LabelTransformationDropUUIDValues LabelTransformationAction = "drop_uuid_values"
+)
+
+// LabelTransformationTruncateHashSuffixLength is the length of the hash suffix appended to truncated label values.
+const LabelTransformationTruncateHashSuffixLength = 17
+
+var (
+	labelTransformationActions = []LabelTransformationAction{
+		LabelTransformationLowercase,
+		LabelTransformationTrim,
+		LabelTransformationTruncate,
+		LabelTransformationMapOTelNames,
+		LabelTransformationDropUUIDValues,
+	}
+
+	errLabelTransformationMissingName = errors.New("label transformation must have a name")
+)
+
+// LabelTransformation is a structured normalisation of series labels applied by the distributor.
+type LabelTransformation struct {
+	// Name identifies the transformation in metrics.
+	Name string `yaml:"name" json:"name"`
+	// Action is the transformation to apply.
+	Action LabelTransformationAction `yaml:"action" json:"action"`
+	// LabelNames restricts the transformation to these labels. Empty means all labels except the metric name.
+	// It's ignored by the map_otel_names action, which always applies to the metric name and all label names.
+	LabelNames []string `yaml:"label_names,omitempty" json:"label_names,omitempty"`
+	// MaxLength is the maximum length of label values for the truncate action. 0 means the tenant's max label value length.
+	MaxLength int `yaml:"max_length,omitempty" json:"max_length,omitempty"`
+}
+
+func (t *LabelTransformation) validate() error {
+	if t.Name == "" {
+		return errLabelTransformationMissingName
+	}
+
+	validAction := false
+	for _, a := range labelTransformationActions {
+		if t.Action == a {
+			validAction = true
+			break
+		}
+	}
+	if !validAction {
+		return fmt.Errorf("label transformation %q has an invalid action %q", t.Name, t.Action)
+	}
+
+	for _, n := range t.LabelNames {
+		if !model.LabelName(n).IsValid() {
+			return fmt.Errorf("label transformation %q has an invalid label name %q", t.Name, n)
+		}
+	}
+
+	if t.MaxLength < 0 || (t.MaxLength > 0 && t.MaxLength <= LabelTransformationTruncateHashSuffixLength) {
+		return fmt.Errorf("label transformation %q must have a max length greater than %d", t.Name, LabelTransformationTruncateHashSuffixLength)
+	}
+	return nil
+}
+
+func validateLabelTransformations(transformations []*LabelTransformation) error {
+	names := make(map[string]struct{}, len(transformations))
+	for _, t := range transformations {
+		if t == nil {
+			return errors.New("invalid label_transformations")
+		}
+		if err := t.validate(); err != nil {
+			return err
+		}
+		if _, ok := names[t.Name]; ok {
+			return fmt.Errorf("duplicate label transformation name %q", t.Name)
+		}
+		names[t.Name] = struct{}{}
+	}
+	return nil
+}
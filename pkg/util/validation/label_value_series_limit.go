@@ -0,0 +1,68 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package validation
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/prometheus/common/model"
+)
+
+var (
+	errLabelValueSeriesLimitMissingName      = errors.New("label value series limit must have a name")
+	errLabelValueSeriesLimitMissingLabelName = errors.New("label value series limit must have a label name")
+)
+
+// LabelValueSeriesLimit limits the number of in-memory series of a tenant based on the values of a label,
+// optionally restricted to a single metric name.
+type LabelValueSeriesLimit struct {
+	// Name identifies the limit in metrics and error messages.
+	Name string `yaml:"name" json:"name"`
+	// MetricName restricts the limit to series with this metric name. Empty means all metrics.
+	MetricName string `yaml:"metric_name" json:"metric_name"`
+	// LabelName is the label whose values are limited.
+	LabelName string `yaml:"label_name" json:"label_name"`
+	// MaxSeriesPerValue is the maximum number of series, across the cluster before replication, for each
+	// distinct value of the label. 0 to disable.
+	MaxSeriesPerValue int `yaml:"max_series_per_value" json:"max_series_per_value"`
+	// MaxValues is the maximum number of distinct values of the label. It's enforced by each ingester
+	// on the series it holds in memory. 0 to disable.
+	MaxValues int `yaml:"max_values" json:"max_values"`
+}
+
+func (l *LabelValueSeriesLimit) validate() error {
+	if l.Name == "" {
+		return errLabelValueSeriesLimitMissingName
+	}
+	if l.LabelName == "" {
+		return errLabelValueSeriesLimitMissingLabelName
+	}
+	if !model.LabelName(l.LabelName).IsValid() {
+		return fmt.Errorf("label value series limit %q has an invalid label name %q", l.Name, l.LabelName)
+	}
+	if l.LabelName == model.MetricNameLabel {
+		return fmt.Errorf("label value series limit %q cannot limit the metric name label, use %s instead", l.Name, MaxSeriesPerMetricFlag)
+	}
+	if l.MaxSeriesPerValue < 0 || l.MaxValues < 0 {
+		return fmt.Errorf("label value series limit %q must not have negative limits", l.Name)
+	}
+	return nil
+}
+
+func validateLabelValueSeriesLimits(limits []*LabelValueSeriesLimit) error {
+	names := make(map[string]struct{}, len(limits))
+	for _, l := range limits {
+		if l == nil {
+			return errors.New("invalid label_value_series_limits")
+		}
+		if err := l.validate(); err != nil {
+			return err
+		}
+		if _, ok := names[l.Name]; ok {
+			return fmt.Errorf("duplicate label value series limit name %q", l.Name)
+		}
+		names[l.Name] = struct{}{}
+	}
+	return nil
+}
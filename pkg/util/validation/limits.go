@@ -33,6 +33,9 @@ const (
 	MaxMetadataPerMetricFlag                  = "ingester.max-global-metadata-per-metric"
 	MaxSeriesPerUserFlag                      = "ingester.max-global-series-per-user"
 	MaxMetadataPerUserFlag                    = "ingester.max-global-metadata-per-user"
+	MaxIngesterMemoryBytesPerTenantFlag       = "ingester.max-memory-bytes-per-tenant"
+	MaxIngesterConcurrentQueriesFlag          = "ingester.max-concurrent-queries-per-tenant"
+	MaxIngesterInflightQuerySeriesFlag        = "ingester.max-inflight-query-series-per-tenant"
 	MaxChunksPerQueryFlag                     = "querier.max-fetched-chunks-per-query"
 	MaxChunkBytesPerQueryFlag                 = "querier.max-fetched-chunk-bytes-per-query"
 	MaxSeriesPerQueryFlag                     = "querier.max-fetched-series-per-query"
@@ -59,16 +62,27 @@ const (
 	resultsCacheTTLForOutOfOrderWindowFlag    = "query-frontend.results-cache-ttl-for-out-of-order-time-window"
 	alignQueriesWithStepFlag                  = "query-frontend.align-queries-with-step"
 	QueryIngestersWithinFlag                  = "querier.query-ingesters-within"
+	IngesterTSDBRetentionPeriodFlag           = "ingester.tsdb-retention-period"
 	AlertmanagerMaxGrafanaConfigSizeFlag      = "alertmanager.max-grafana-config-size-bytes"
 	AlertmanagerMaxGrafanaStateSizeFlag       = "alertmanager.max-grafana-state-size-bytes"
 
 	// MinCompactorPartialBlockDeletionDelay is the minimum partial blocks deletion delay that can be configured in Mimir.
 	MinCompactorPartialBlockDeletionDelay = 4 * time.Hour
+
+	errInvalidCompactorBlockRanges            = "invalid compactor_block_ranges: each range period should be divisible by the previous one, but %s is not divisible by %s"
+	errNonPositiveCompactorBlockRange         = "invalid compactor_block_ranges: each range period should be greater than 0, but got %s"
+	errIngesterBlockRangeNotAligned           = "invalid compactor_block_ranges: the first range period should be divisible by ingester_tsdb_block_range_period, but %s is not divisible by %s"
+	errRetentionShorterThan5mAfter            = "invalid compactor_blocks_retention_period: the raw blocks retention period %s, which is the longest period of compactor_blocks_retention_period and compactor_series_retention_policies, should not be shorter than compactor_downsampling_5m_after %s"
+	errRetention5mShorterThan1hAfter          = "invalid compactor_blocks_retention_period_5m: the 5m resolution blocks retention period %s should not be shorter than compactor_downsampling_1h_after %s"
+	errIngesterBlockRangeNotQueried           = "invalid ingester_tsdb_block_range_period: the ingesters keep up to %s (1.5 times the block range period) of samples in the TSDB head before shipping them, so query_ingesters_within %s should not be shorter than that, otherwise queries might return partial results"
+	errIngesterRetentionShorterThanBlockRange = "invalid ingester_tsdb_retention_period: the retention period %s should be greater than ingester_tsdb_block_range_period %s"
 )
 
 var (
 	errInvalidIngestStorageReadConsistency         = fmt.Errorf("invalid ingest storage read consistency (supported values: %s)", strings.Join(api.ReadConsistencies, ", "))
 	errInvalidMaxEstimatedChunksPerQueryMultiplier = errors.New("invalid value for -" + MaxEstimatedChunksPerQueryMultiplierFlag + ": must be 0 or greater than or equal to 1")
+	errInvalidCompactorSchedulingWeight            = errors.New("invalid value for -compactor.scheduling-weight: must be greater than 0")
+	errInvalidIngesterTSDBBlockRangePeriod         = errors.New("invalid value for -ingester.tsdb-block-range-period: must not be negative")
 )
 
 // LimitError is a marker interface for the errors that do not comply with the specified limits.
@@ -117,6 +131,7 @@ type Limits struct {
 	MaxNativeHistogramBuckets                   int                 `yaml:"max_native_histogram_buckets" json:"max_native_histogram_buckets"`
 	MaxExemplarsPerSeriesPerRequest             int                 `yaml:"max_exemplars_per_series_per_request" json:"max_exemplars_per_series_per_request" category:"experimental"`
 	ReduceNativeHistogramOverMaxBuckets         bool                `yaml:"reduce_native_histogram_over_max_buckets" json:"reduce_native_histogram_over_max_buckets"`
+	ConvertClassicHistogramsToNHCB              bool                `yaml:"convert_classic_histograms_to_nhcb" json:"convert_classic_histograms_to_nhcb" category:"experimental"`
 	CreationGracePeriod                         model.Duration      `yaml:"creation_grace_period" json:"creation_grace_period" category:"advanced"`
 	PastGracePeriod                             model.Duration      `yaml:"past_grace_period" json:"past_grace_period" category:"advanced"`
 	EnforceMetadataMetricName                   bool                `yaml:"enforce_metadata_metric_name" json:"enforce_metadata_metric_name" category:"advanced"`
@@ -124,10 +139,17 @@ type Limits struct {
 	MetricRelabelConfigs                        []*relabel.Config   `yaml:"metric_relabel_configs,omitempty" json:"metric_relabel_configs,omitempty" doc:"nocli|description=List of metric relabel configurations. Note that in most situations, it is more effective to use metrics relabeling directly in the Prometheus server, e.g. remote_write.write_relabel_configs. Labels available during the relabeling phase and cleaned afterwards: __meta_tenant_id" category:"experimental"`
 	MetricRelabelingEnabled                     bool                `yaml:"metric_relabeling_enabled" json:"metric_relabeling_enabled" category:"experimental"`
 	ServiceOverloadStatusCodeOnRateLimitEnabled bool                `yaml:"service_overload_status_code_on_rate_limit_enabled" json:"service_overload_status_code_on_rate_limit_enabled" category:"experimental"`
+	// Label transformations.
+	LabelTransformations []*LabelTransformation `yaml:"label_transformations,omitempty" json:"label_transformations,omitempty" doc:"nocli|description=List of label transformations applied by the distributor, in order, after metric relabeling. Each transformation has a unique name and an action: lowercase and trim normalise label values, truncate shortens label values longer than max_length (defaults to max_label_value_length) appending a hash of the original value instead of rejecting the series, map_otel_names maps OpenTelemetry semantic convention names to Prometheus compliant metric and label names, drop_uuid_values removes labels whose value is a UUID. Value transformations apply to the labels listed in label_names, or all labels except the metric name if empty." category:"experimental"`
+	// Dead letter of rejected series.
+	DeadLetterEnabled            bool `yaml:"dead_letter_enabled" json:"dead_letter_enabled" category:"experimental"`
+	DeadLetterMaxSeriesPerReason int  `yaml:"dead_letter_max_series_per_reason" json:"dead_letter_max_series_per_reason" category:"experimental"`
 	// Ingester enforced limits.
 	// Series
-	MaxGlobalSeriesPerUser   int `yaml:"max_global_series_per_user" json:"max_global_series_per_user"`
-	MaxGlobalSeriesPerMetric int `yaml:"max_global_series_per_metric" json:"max_global_series_per_metric"`
+	MaxGlobalSeriesPerUser          int                      `yaml:"max_global_series_per_user" json:"max_global_series_per_user"`
+	MaxGlobalSeriesPerMetric        int                      `yaml:"max_global_series_per_metric" json:"max_global_series_per_metric"`
+	LabelValueSeriesLimits          []*LabelValueSeriesLimit `yaml:"label_value_series_limits,omitempty" json:"label_value_series_limits,omitempty" doc:"nocli|description=List of per-label-value series limits. Each limit has a unique name, a label_name, an optional metric_name to restrict it to a single metric, and max_series_per_value (the maximum number of in-memory series per value of the label, across the cluster before replication) and/or max_values (the maximum number of distinct values of the label held in memory by each ingester). 0 disables each of them." category:"experimental"`
+	MaxIngesterMemoryBytesPerTenant int64                    `yaml:"max_ingester_memory_bytes_per_tenant" json:"max_ingester_memory_bytes_per_tenant" category:"experimental"`
 	// Metadata
 	MaxGlobalMetricsWithMetadataPerUser int `yaml:"max_global_metadata_per_user" json:"max_global_metadata_per_user"`
 	MaxGlobalMetadataPerMetric          int `yaml:"max_global_metadata_per_metric" json:"max_global_metadata_per_metric"`
@@ -143,6 +165,13 @@ type Limits struct {
 	// Max allowed time window for out-of-order samples.
 	OutOfOrderTimeWindow                 model.Duration `yaml:"out_of_order_time_window" json:"out_of_order_time_window" category:"experimental"`
 	OutOfOrderBlocksExternalLabelEnabled bool           `yaml:"out_of_order_blocks_external_label_enabled" json:"out_of_order_blocks_external_label_enabled" category:"experimental"`
+	// TSDB
+	IngesterTSDBBlockRangePeriod          model.Duration `yaml:"ingester_tsdb_block_range_period" json:"ingester_tsdb_block_range_period" category:"experimental"`
+	IngesterTSDBHeadCompactionIdleTimeout model.Duration `yaml:"ingester_tsdb_head_compaction_idle_timeout" json:"ingester_tsdb_head_compaction_idle_timeout" category:"experimental"`
+	IngesterTSDBRetentionPeriod           model.Duration `yaml:"ingester_tsdb_retention_period" json:"ingester_tsdb_retention_period" category:"experimental"`
+	// Read path admission control
+	MaxIngesterConcurrentQueries   int `yaml:"max_ingester_concurrent_queries_per_tenant" json:"max_ingester_concurrent_queries_per_tenant" category:"experimental"`
+	MaxIngesterInflightQuerySeries int `yaml:"max_ingester_inflight_query_series_per_tenant" json:"max_ingester_inflight_query_series_per_tenant" category:"experimental"`
 
 	// User defined label to give the option of subdividing specific metrics by another label
 	SeparateMetricsGroupLabel string `yaml:"separate_metrics_group_label" json:"separate_metrics_group_label" category:"experimental"`
@@ -201,16 +230,27 @@ type Limits struct {
 	StoreGatewayTenantShardSize int `yaml:"store_gateway_tenant_shard_size" json:"store_gateway_tenant_shard_size"`
 
 	// Compactor.
-	CompactorBlocksRetentionPeriod        model.Duration `yaml:"compactor_blocks_retention_period" json:"compactor_blocks_retention_period"`
-	CompactorSplitAndMergeShards          int            `yaml:"compactor_split_and_merge_shards" json:"compactor_split_and_merge_shards"`
-	CompactorSplitGroups                  int            `yaml:"compactor_split_groups" json:"compactor_split_groups"`
-	CompactorTenantShardSize              int            `yaml:"compactor_tenant_shard_size" json:"compactor_tenant_shard_size"`
-	CompactorPartialBlockDeletionDelay    model.Duration `yaml:"compactor_partial_block_deletion_delay" json:"compactor_partial_block_deletion_delay"`
-	CompactorBlockUploadEnabled           bool           `yaml:"compactor_block_upload_enabled" json:"compactor_block_upload_enabled"`
-	CompactorBlockUploadValidationEnabled bool           `yaml:"compactor_block_upload_validation_enabled" json:"compactor_block_upload_validation_enabled"`
-	CompactorBlockUploadVerifyChunks      bool           `yaml:"compactor_block_upload_verify_chunks" json:"compactor_block_upload_verify_chunks"`
-	CompactorBlockUploadMaxBlockSizeBytes int64          `yaml:"compactor_block_upload_max_block_size_bytes" json:"compactor_block_upload_max_block_size_bytes" category:"advanced"`
-	CompactorInMemoryTenantMetaCacheSize  int            `yaml:"compactor_in_memory_tenant_meta_cache_size" json:"compactor_in_memory_tenant_meta_cache_size" category:"experimental" doc:"hidden"`
+	CompactorBlocksRetentionPeriod        model.Duration                 `yaml:"compactor_blocks_retention_period" json:"compactor_blocks_retention_period"`
+	CompactorSplitAndMergeShards          int                            `yaml:"compactor_split_and_merge_shards" json:"compactor_split_and_merge_shards"`
+	CompactorSplitGroups                  int                            `yaml:"compactor_split_groups" json:"compactor_split_groups"`
+	CompactorTenantShardSize              int                            `yaml:"compactor_tenant_shard_size" json:"compactor_tenant_shard_size"`
+	CompactorPartialBlockDeletionDelay    model.Duration                 `yaml:"compactor_partial_block_deletion_delay" json:"compactor_partial_block_deletion_delay"`
+	CompactorBlockUploadEnabled           bool                           `yaml:"compactor_block_upload_enabled" json:"compactor_block_upload_enabled"`
+	CompactorBlockUploadValidationEnabled bool                           `yaml:"compactor_block_upload_validation_enabled" json:"compactor_block_upload_validation_enabled"`
+	CompactorBlockUploadVerifyChunks      bool                           `yaml:"compactor_block_upload_verify_chunks" json:"compactor_block_upload_verify_chunks"`
+	CompactorBlockUploadMaxBlockSizeBytes int64                          `yaml:"compactor_block_upload_max_block_size_bytes" json:"compactor_block_upload_max_block_size_bytes" category:"advanced"`
+	CompactorInMemoryTenantMetaCacheSize  int                            `yaml:"compactor_in_memory_tenant_meta_cache_size" json:"compactor_in_memory_tenant_meta_cache_size" category:"experimental" doc:"hidden"`
+	CompactorExemplarsRetentionPeriod     model.Duration                 `yaml:"compactor_exemplars_retention_period" json:"compactor_exemplars_retention_period" category:"experimental"`
+	CompactorBlockRanges                  DurationList                   `yaml:"compactor_block_ranges" json:"compactor_block_ranges" category:"experimental"`
+	CompactorDownsampling5mAfter          model.Duration                 `yaml:"compactor_downsampling_5m_after" json:"compactor_downsampling_5m_after" category:"experimental"`
+	CompactorDownsampling1hAfter          model.Duration                 `yaml:"compactor_downsampling_1h_after" json:"compactor_downsampling_1h_after" category:"experimental"`
+	CompactorBlocksRetentionPeriod5m      model.Duration                 `yaml:"compactor_blocks_retention_period_5m" json:"compactor_blocks_retention_period_5m" category:"experimental"`
+	CompactorBlocksRetentionPeriod1h      model.Duration                 `yaml:"compactor_blocks_retention_period_1h" json:"compactor_blocks_retention_period_1h" category:"experimental"`
+	CompactorColdStorageAfter             model.Duration                 `yaml:"compactor_cold_storage_after" json:"compactor_cold_storage_after" category:"experimental"`
+	CompactorMaxThroughputBytesPerSecond  int64                          `yaml:"compactor_max_throughput_bytes_per_second" json:"compactor_max_throughput_bytes_per_second" category:"experimental"`
+	CompactorSchedulingWeight             float64                        `yaml:"compactor_scheduling_weight" json:"compactor_scheduling_weight" category:"experimental"`
+	CompactorSeriesRetentionPolicies      []*SeriesRetentionPolicyConfig `yaml:"compactor_series_retention_policies,omitempty" json:"compactor_series_retention_policies,omitempty" doc:"nocli|description=List of series retention policies, each with a selector and a period. The first policy whose selector matches a series sets its retention period, and the series not matching any policy are retained for compactor_blocks_retention_period. A period of 0 keeps the matching series forever. Raw blocks are kept for the longest retention period, and the compactor rewrites them to remove the expired series once a block is older than a shorter period. Queriers don't return the expired samples." category:"experimental"`
+	CompactorRelabelConfigs               []*relabel.Config              `yaml:"compactor_relabel_configs,omitempty" json:"compactor_relabel_configs,omitempty" doc:"nocli|description=List of relabel configurations applied by the compactor to the series labels of the tenant blocks, to rewrite or drop labels retroactively. The compaction jobs relabel the series of their source blocks, merging the series which have the same labels after relabeling, and the compactor rewrites the blocks which are not compacted anymore, including the downsampled blocks. Relabeling can change the compactor shard of a series, so the blocks of a compactor shard are relabeled only once they're not compacted anymore, and split again by shard. Series whose labels are all dropped are removed. The applied configurations are recorded in the block meta.json, so blocks are relabeled only once with the same configurations." category:"experimental"`
 
 	// This config doesn't have a CLI flag registered here because they're registered in
 	// their own original config struct.
@@ -267,8 +307,11 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.IntVar(&l.MaxNativeHistogramBuckets, maxNativeHistogramBucketsFlag, 0, "Maximum number of buckets per native histogram sample. 0 to disable the limit.")
 	f.IntVar(&l.MaxExemplarsPerSeriesPerRequest, "distributor.max-exemplars-per-series-per-request", 0, "Maximum number of exemplars per series per request. 0 to disable limit in request. The exceeding exemplars are dropped.")
 	f.BoolVar(&l.ReduceNativeHistogramOverMaxBuckets, ReduceNativeHistogramOverMaxBucketsFlag, true, "Whether to reduce or reject native histogram samples with more buckets than the configured limit.")
+	f.BoolVar(&l.ConvertClassicHistogramsToNHCB, "distributor.convert-classic-histograms-to-nhcb", false, "Whether the distributor converts the bucket, sum and count series of each classic histogram received in the same request into a single native histogram with custom buckets (NHCB) series. Classic histograms whose series are not all in the request, or with more buckets than the native histograms are allowed to have, are not converted. Native histograms with custom buckets sent by the clients are accepted only when enabled. Requires -ingester.native-histograms-ingestion-enabled.")
 	_ = l.CreationGracePeriod.Set("10m")
 	f.Var(&l.CreationGracePeriod, CreationGracePeriodFlag, "Controls how far into the future incoming samples and exemplars are accepted compared to the wall clock. Any sample or exemplar will be rejected if its timestamp is greater than '(now + creation_grace_period)'. This configuration is enforced in the distributor and ingester.")
+	f.BoolVar(&l.DeadLetterEnabled, "validation.dead-letter-enabled", false, "Whether to capture a sample of the series rejected by the distributors and ingesters in the dead letter. The dead letter must be enabled in the distributors and ingesters too.")
+	f.IntVar(&l.DeadLetterMaxSeriesPerReason, "validation.dead-letter-max-series-per-reason", 10, "Maximum number of rejected series captured in the dead letter for each rejection reason, by each distributor and ingester, between two flushes of the dead letter. 0 to disable the limit.")
 	f.Var(&l.PastGracePeriod, PastGracePeriodFlag, "Controls how far into the past incoming samples and exemplars are accepted compared to the wall clock. Any sample or exemplar will be rejected if its timestamp is lower than '(now - OOO window - past_grace_period)'. This configuration is enforced in the distributor and ingester. 0 to disable.")
 	f.BoolVar(&l.EnforceMetadataMetricName, "validation.enforce-metadata-metric-name", true, "Enforce every metadata has a metric name.")
 	f.BoolVar(&l.MetricRelabelingEnabled, "distributor.metric-relabeling-enabled", true, "Enable metric relabeling for the tenant. This configuration option can be used to forcefully disable metric relabeling on a per-tenant basis.")
@@ -278,6 +321,7 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 
 	f.IntVar(&l.MaxGlobalSeriesPerUser, MaxSeriesPerUserFlag, 150000, "The maximum number of in-memory series per tenant, across the cluster before replication. 0 to disable.")
 	f.IntVar(&l.MaxGlobalSeriesPerMetric, MaxSeriesPerMetricFlag, 0, "The maximum number of in-memory series per metric name, across the cluster before replication. 0 to disable.")
+	f.Int64Var(&l.MaxIngesterMemoryBytesPerTenant, MaxIngesterMemoryBytesPerTenantFlag, 0, "The maximum estimated memory, in bytes, used by the in-memory series of a tenant in each ingester, including series labels, postings and head chunks. When reached, new series are rejected. 0 to disable.")
 
 	f.IntVar(&l.MaxGlobalMetricsWithMetadataPerUser, MaxMetadataPerUserFlag, 0, "The maximum number of in-memory metrics with metadata per tenant, across the cluster. 0 to disable.")
 	f.IntVar(&l.MaxGlobalMetadataPerMetric, MaxMetadataPerMetricFlag, 0, "The maximum number of metadata per metric, across the cluster. 0 to disable.")
@@ -287,6 +331,11 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.Var(&l.OutOfOrderTimeWindow, "ingester.out-of-order-time-window", fmt.Sprintf("Non-zero value enables out-of-order support for most recent samples that are within the time window in relation to the TSDB's maximum time, i.e., within [db.maxTime-timeWindow, db.maxTime]). The ingester will need more memory as a factor of rate of out-of-order samples being ingested and the number of series that are getting out-of-order samples. If query falls into this window, cached results will use value from -%s option to specify TTL for resulting cache entry.", resultsCacheTTLForOutOfOrderWindowFlag))
 	f.BoolVar(&l.NativeHistogramsIngestionEnabled, "ingester.native-histograms-ingestion-enabled", false, "Enable ingestion of native histogram samples. If false, native histogram samples are ignored without an error. To query native histograms with query-sharding enabled make sure to set -query-frontend.query-result-response-format to 'protobuf'.")
 	f.BoolVar(&l.OOONativeHistogramsIngestionEnabled, "ingester.ooo-native-histograms-ingestion-enabled", false, "Enable experimental out-of-order native histogram ingestion. This only takes effect if the `-ingester.out-of-order-time-window` value is greater than zero and if `-ingester.native-histograms-ingestion-enabled = true`")
+	f.Var(&l.IngesterTSDBBlockRangePeriod, "ingester.tsdb-block-range-period", "TSDB blocks range period of the tenant in the ingesters. 0 to use -blocks-storage.tsdb.block-ranges-period. It must not be longer than 2/3 of -"+QueryIngestersWithinFlag+", because the ingesters keep up to 1.5 times the block range period of samples in the TSDB head. The change applies when the tenant's TSDB is opened.")
+	f.Var(&l.IngesterTSDBHeadCompactionIdleTimeout, "ingester.tsdb-head-compaction-idle-timeout", "If the TSDB head of the tenant is idle for this duration, it is compacted. The same jitter as -blocks-storage.tsdb.head-compaction-idle-timeout is added to the value. 0 to use -blocks-storage.tsdb.head-compaction-idle-timeout.")
+	f.Var(&l.IngesterTSDBRetentionPeriod, IngesterTSDBRetentionPeriodFlag, "How long the ingesters keep the TSDB blocks of the tenant on the local disk after they've been shipped. 0 to use -blocks-storage.tsdb.retention-period. It must be greater than -querier.query-store-after and the tenant's -ingester.tsdb-block-range-period. The change applies when the tenant's TSDB is opened.")
+	f.IntVar(&l.MaxIngesterConcurrentQueries, MaxIngesterConcurrentQueriesFlag, 0, "The maximum number of queries of a tenant that each ingester runs concurrently. Additional queries wait up to -ingester.read-path-admission-queue-timeout and are then rejected with a retryable error. 0 to disable.")
+	f.IntVar(&l.MaxIngesterInflightQuerySeries, MaxIngesterInflightQuerySeriesFlag, 0, "The maximum number of series that the streaming queries of a tenant hold in memory at the same time in each ingester. Queries that need more series wait up to -ingester.read-path-admission-queue-timeout and are then rejected with a retryable error. 0 to disable.")
 	f.BoolVar(&l.OutOfOrderBlocksExternalLabelEnabled, "ingester.out-of-order-blocks-external-label-enabled", false, "Whether the shipper should label out-of-order blocks with an external label before uploading them. Setting this label will compact out-of-order blocks separately from non-out-of-order blocks")
 
 	f.StringVar(&l.SeparateMetricsGroupLabel, "validation.separate-metrics-group-label", "", "Label used to define the group label for metrics separation. For each write request, the group is obtained from the first non-empty group label from the first timeseries in the incoming list of timeseries. Specific distributor and ingester metrics will be further separated adding a 'group' label with group label's value. Currently applies to the following metrics: cortex_discarded_samples_total")
@@ -347,6 +396,15 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.BoolVar(&l.CompactorBlockUploadVerifyChunks, "compactor.block-upload-verify-chunks", true, "Verify chunks when uploading blocks via the upload API for the tenant.")
 	f.Int64Var(&l.CompactorBlockUploadMaxBlockSizeBytes, "compactor.block-upload-max-block-size-bytes", 0, "Maximum size in bytes of a block that is allowed to be uploaded or validated. 0 = no limit.")
 	f.IntVar(&l.CompactorInMemoryTenantMetaCacheSize, "compactor.in-memory-tenant-meta-cache-size", 0, "Size of per-tenant in-memory cache for parsed meta.json files. This is useful when meta.json files are big and parsing is expensive. Small meta.json files are not cached. 0 means this cache is disabled.")
+	f.Var(&l.CompactorBlockRanges, "compactor.tenant-block-ranges", "List of compaction time ranges of the tenant. If empty, the compactor uses -compactor.block-ranges, adapted to the tenant's -ingester.tsdb-block-range-period when it's set.")
+	f.Var(&l.CompactorDownsampling5mAfter, "compactor.downsampling-5m-after", "Downsample the blocks to 5m resolution once all their samples are older than this period. 0 to disable downsampling.")
+	f.Var(&l.CompactorDownsampling1hAfter, "compactor.downsampling-1h-after", "Downsample the 5m resolution blocks to 1h resolution once all their samples are older than this period. Requires -compactor.downsampling-5m-after. 0 to disable.")
+	f.Var(&l.CompactorBlocksRetentionPeriod5m, "compactor.blocks-retention-period-5m", "Delete 5m resolution blocks containing samples older than the specified retention period. 0 to use the retention period of the raw blocks.")
+	f.Var(&l.CompactorBlocksRetentionPeriod1h, "compactor.blocks-retention-period-1h", "Delete 1h resolution blocks containing samples older than the specified retention period. 0 to use the retention period of the raw blocks.")
+	f.Var(&l.CompactorColdStorageAfter, "compactor.cold-storage-after", "Move the blocks to the cold storage once all their samples are older than this period. Requires -blocks-storage.cold-storage.enabled. 0 to keep the blocks in the blocks storage bucket.")
+	f.Int64Var(&l.CompactorMaxThroughputBytesPerSecond, "compactor.max-throughput-bytes-per-second", 0, "Maximum number of bytes per second that the compaction jobs of the tenant can download from and upload to the object storage. The limit is evenly split between the compactors which can run the jobs of the tenant: the compactors of the tenant shard, or all the compactors when using the compactor-scheduler. 0 to disable the limit.")
+	f.Float64Var(&l.CompactorSchedulingWeight, "compactor.scheduling-weight", 1, "Weight of the tenant when the compactor-scheduler shares the compactors between the tenants having pending compaction jobs, or when the compactor compacts the tenants in rounds with -compactor.compaction-round-max-bytes. Tenants get a share of the compaction throughput proportional to their weight, based on the estimated cost of their jobs. Must be greater than 0.")
+	f.Var(&l.CompactorExemplarsRetentionPeriod, "compactor.exemplars-retention-period", "Delete exemplars older than the specified retention period from the blocks, and don't query them from the store-gateways. Applies only when long-term exemplars storage is enabled. 0 to keep exemplars as long as the blocks containing them.")
 
 	// Query-frontend.
 	f.Var(&l.MaxTotalQueryLength, MaxTotalQueryLengthFlag, "Limit the total query time range (end - start time). This limit is enforced in the query-frontend on the received instant, range or remote read query.")
@@ -459,10 +517,76 @@ func (l *Limits) validate() error {
 		}
 	}
 
+	for _, cfg := range l.CompactorRelabelConfigs {
+		if cfg == nil {
+			return errors.New("invalid compactor_relabel_configs")
+		}
+	}
+
+	if err := validateLabelTransformations(l.LabelTransformations); err != nil {
+		return err
+	}
+
+	if err := validateLabelValueSeriesLimits(l.LabelValueSeriesLimits); err != nil {
+		return err
+	}
+
+	if err := validateSeriesRetentionPolicies(l.CompactorSeriesRetentionPolicies); err != nil {
+		return err
+	}
+
 	if l.MaxEstimatedChunksPerQueryMultiplier < 1 && l.MaxEstimatedChunksPerQueryMultiplier != 0 {
 		return errInvalidMaxEstimatedChunksPerQueryMultiplier
 	}
 
+	if l.CompactorSchedulingWeight <= 0 {
+		return errInvalidCompactorSchedulingWeight
+	}
+
+	if l.IngesterTSDBBlockRangePeriod < 0 {
+		return errInvalidIngesterTSDBBlockRangePeriod
+	}
+
+	for _, r := range l.CompactorBlockRanges {
+		if r <= 0 {
+			return fmt.Errorf(errNonPositiveCompactorBlockRange, r)
+		}
+	}
+	if period := l.IngesterTSDBBlockRangePeriod; period > 0 {
+		if l.QueryIngestersWithin > 0 && l.QueryIngestersWithin < period*3/2 {
+			return fmt.Errorf(errIngesterBlockRangeNotQueried, period*3/2, l.QueryIngestersWithin)
+		}
+		if l.IngesterTSDBRetentionPeriod > 0 && l.IngesterTSDBRetentionPeriod <= period {
+			return fmt.Errorf(errIngesterRetentionShorterThanBlockRange, l.IngesterTSDBRetentionPeriod, period)
+		}
+	}
+
+	if period := time.Duration(l.IngesterTSDBBlockRangePeriod); period > 0 && len(l.CompactorBlockRanges) > 0 && l.CompactorBlockRanges[0]%period != 0 {
+		return fmt.Errorf(errIngesterBlockRangeNotAligned, l.CompactorBlockRanges[0], period)
+	}
+	for i := 1; i < len(l.CompactorBlockRanges); i++ {
+		if l.CompactorBlockRanges[i]%l.CompactorBlockRanges[i-1] != 0 {
+			return fmt.Errorf(errInvalidCompactorBlockRanges, l.CompactorBlockRanges[i], l.CompactorBlockRanges[i-1])
+		}
+	}
+
+	// The blocks must be downsampled before they're deleted. The raw blocks are kept for the longest retention
+	// period of any series.
+	if l.CompactorDownsampling5mAfter > 0 {
+		rawRetention := model.Duration(toSeriesRetentionPolicies(l.CompactorSeriesRetentionPolicies, time.Duration(l.CompactorBlocksRetentionPeriod)).MaxPeriod())
+		if rawRetention > 0 && rawRetention < l.CompactorDownsampling5mAfter {
+			return fmt.Errorf(errRetentionShorterThan5mAfter, rawRetention, l.CompactorDownsampling5mAfter)
+		}
+
+		retention5m := l.CompactorBlocksRetentionPeriod5m
+		if retention5m <= 0 {
+			retention5m = rawRetention
+		}
+		if l.CompactorDownsampling1hAfter > 0 && retention5m > 0 && retention5m < l.CompactorDownsampling1hAfter {
+			return fmt.Errorf(errRetention5mShorterThan1hAfter, retention5m, l.CompactorDownsampling1hAfter)
+		}
+	}
+
 	if !util.StringsContain(api.ReadConsistencies, l.IngestStorageReadConsistency) {
 		return errInvalidIngestStorageReadConsistency
 	}
@@ -563,6 +687,17 @@ func (o *Overrides) ServiceOverloadStatusCodeOnRateLimitEnabled(userID string) b
 	return o.getOverridesForUser(userID).ServiceOverloadStatusCodeOnRateLimitEnabled
 }
 
+// DeadLetterEnabled returns whether the rejected series of the tenant are captured in the dead letter.
+func (o *Overrides) DeadLetterEnabled(userID string) bool {
+	return o.getOverridesForUser(userID).DeadLetterEnabled
+}
+
+// DeadLetterMaxSeriesPerReason returns the maximum number of rejected series captured in the dead letter
+// for each reason between two flushes.
+func (o *Overrides) DeadLetterMaxSeriesPerReason(userID string) int {
+	return o.getOverridesForUser(userID).DeadLetterMaxSeriesPerReason
+}
+
 // HAClusterLabel returns the cluster label to look for when deciding whether to accept a sample from a Prometheus HA replica.
 func (o *Overrides) HAClusterLabel(userID string) string {
 	return o.getOverridesForUser(userID).HAClusterLabel
@@ -612,6 +747,12 @@ func (o *Overrides) ReduceNativeHistogramOverMaxBuckets(userID string) bool {
 	return o.getOverridesForUser(userID).ReduceNativeHistogramOverMaxBuckets
 }
 
+// ConvertClassicHistogramsToNHCB returns whether to convert the classic histograms
+// received by the distributor into native histograms with custom buckets.
+func (o *Overrides) ConvertClassicHistogramsToNHCB(userID string) bool {
+	return o.getOverridesForUser(userID).ConvertClassicHistogramsToNHCB
+}
+
 // CreationGracePeriod is misnamed, and actually returns how far into the future
 // we should accept samples.
 func (o *Overrides) CreationGracePeriod(userID string) time.Duration {
@@ -634,6 +775,41 @@ func (o *Overrides) MaxGlobalSeriesPerMetric(userID string) int {
 	return o.getOverridesForUser(userID).MaxGlobalSeriesPerMetric
 }
 
+// IngesterTSDBBlockRangePeriod returns the TSDB blocks range period of a given user in the ingesters. 0 if not overridden.
+func (o *Overrides) IngesterTSDBBlockRangePeriod(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).IngesterTSDBBlockRangePeriod)
+}
+
+// IngesterTSDBHeadCompactionIdleTimeout returns the TSDB head compaction idle timeout of a given user in the ingesters. 0 if not overridden.
+func (o *Overrides) IngesterTSDBHeadCompactionIdleTimeout(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).IngesterTSDBHeadCompactionIdleTimeout)
+}
+
+// IngesterTSDBRetentionPeriod returns the TSDB blocks retention of a given user in the ingesters. 0 if not overridden.
+func (o *Overrides) IngesterTSDBRetentionPeriod(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).IngesterTSDBRetentionPeriod)
+}
+
+// MaxIngesterConcurrentQueries returns the maximum number of queries of a user that each ingester runs concurrently.
+func (o *Overrides) MaxIngesterConcurrentQueries(userID string) int {
+	return o.getOverridesForUser(userID).MaxIngesterConcurrentQueries
+}
+
+// MaxIngesterInflightQuerySeries returns the maximum number of series the streaming queries of a user hold in memory in each ingester.
+func (o *Overrides) MaxIngesterInflightQuerySeries(userID string) int {
+	return o.getOverridesForUser(userID).MaxIngesterInflightQuerySeries
+}
+
+// MaxIngesterMemoryBytesPerTenant returns the maximum estimated memory used by the in-memory series of a user in each ingester.
+func (o *Overrides) MaxIngesterMemoryBytesPerTenant(userID string) int64 {
+	return o.getOverridesForUser(userID).MaxIngesterMemoryBytesPerTenant
+}
+
+// LabelValueSeriesLimits returns the per-label-value series limits for the user.
+func (o *Overrides) LabelValueSeriesLimits(userID string) []*LabelValueSeriesLimit {
+	return o.getOverridesForUser(userID).LabelValueSeriesLimits
+}
+
 func (o *Overrides) MaxChunksPerQuery(userID string) int {
 	return o.getOverridesForUser(userID).MaxChunksPerQuery
 }
@@ -802,11 +978,100 @@ func (o *Overrides) EvaluationDelay(userID string) time.Duration {
 	return time.Duration(o.getOverridesForUser(userID).RulerEvaluationDelay)
 }
 
+// CompactorBlockRanges returns the compaction time ranges for a given user. Empty if not overridden.
+func (o *Overrides) CompactorBlockRanges(userID string) []time.Duration {
+	return o.getOverridesForUser(userID).CompactorBlockRanges
+}
+
 // CompactorBlocksRetentionPeriod returns the retention period for a given user.
 func (o *Overrides) CompactorBlocksRetentionPeriod(userID string) time.Duration {
 	return time.Duration(o.getOverridesForUser(userID).CompactorBlocksRetentionPeriod)
 }
 
+// CompactorSeriesRetentionPolicies returns the series retention policies for a given user. The series not matching
+// any policy are retained for the blocks retention period.
+func (o *Overrides) CompactorSeriesRetentionPolicies(userID string) SeriesRetentionPolicies {
+	return toSeriesRetentionPolicies(o.getOverridesForUser(userID).CompactorSeriesRetentionPolicies, o.CompactorBlocksRetentionPeriod(userID))
+}
+
+// CompactorRelabelConfigs returns the relabel configs applied by the compactor to the series of a given user.
+func (o *Overrides) CompactorRelabelConfigs(userID string) []*relabel.Config {
+	return o.getOverridesForUser(userID).CompactorRelabelConfigs
+}
+
+// CompactorRawBlocksRetentionPeriod returns the retention period of the raw blocks for a given user, which is the
+// longest retention period of any series.
+func (o *Overrides) CompactorRawBlocksRetentionPeriod(userID string) time.Duration {
+	return o.CompactorSeriesRetentionPolicies(userID).MaxPeriod()
+}
+
+// CompactorDownsampling5mAfter returns the age after which the blocks are downsampled to 5m resolution for a given user.
+func (o *Overrides) CompactorDownsampling5mAfter(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).CompactorDownsampling5mAfter)
+}
+
+// CompactorDownsampling1hAfter returns the age after which the 5m resolution blocks are downsampled to 1h resolution
+// for a given user. 0 if downsampling to 5m resolution is disabled.
+func (o *Overrides) CompactorDownsampling1hAfter(userID string) time.Duration {
+	if o.CompactorDownsampling5mAfter(userID) <= 0 {
+		return 0
+	}
+	return time.Duration(o.getOverridesForUser(userID).CompactorDownsampling1hAfter)
+}
+
+// CompactorBlocksRetentionPeriod5m returns the retention period of the 5m resolution blocks for a given user.
+func (o *Overrides) CompactorBlocksRetentionPeriod5m(userID string) time.Duration {
+	if retention := o.getOverridesForUser(userID).CompactorBlocksRetentionPeriod5m; retention > 0 {
+		return time.Duration(retention)
+	}
+	return o.CompactorRawBlocksRetentionPeriod(userID)
+}
+
+// CompactorBlocksRetentionPeriod1h returns the retention period of the 1h resolution blocks for a given user.
+func (o *Overrides) CompactorBlocksRetentionPeriod1h(userID string) time.Duration {
+	if retention := o.getOverridesForUser(userID).CompactorBlocksRetentionPeriod1h; retention > 0 {
+		return time.Duration(retention)
+	}
+	return o.CompactorRawBlocksRetentionPeriod(userID)
+}
+
+// CompactorMaxBlocksRetentionPeriod returns the longest retention period of the blocks of any resolution for a given
+// user, or 0 if the blocks of any resolution are kept forever.
+func (o *Overrides) CompactorMaxBlocksRetentionPeriod(userID string) time.Duration {
+	retention := o.CompactorRawBlocksRetentionPeriod(userID)
+	if retention <= 0 {
+		return 0
+	}
+
+	if o.CompactorDownsampling5mAfter(userID) > 0 {
+		retention = max(retention, o.CompactorBlocksRetentionPeriod5m(userID))
+	}
+	if o.CompactorDownsampling1hAfter(userID) > 0 {
+		retention = max(retention, o.CompactorBlocksRetentionPeriod1h(userID))
+	}
+	return retention
+}
+
+// CompactorColdStorageAfter returns the age after which the blocks are moved to the cold storage for a given user.
+func (o *Overrides) CompactorColdStorageAfter(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).CompactorColdStorageAfter)
+}
+
+// CompactorMaxThroughputBytesPerSecond returns the maximum bytes per second downloaded and uploaded by the compaction jobs of a given user.
+func (o *Overrides) CompactorMaxThroughputBytesPerSecond(userID string) int64 {
+	return o.getOverridesForUser(userID).CompactorMaxThroughputBytesPerSecond
+}
+
+// CompactorSchedulingWeight returns the weight of a given user when the compactor-scheduler shares the compactors between tenants.
+func (o *Overrides) CompactorSchedulingWeight(userID string) float64 {
+	return o.getOverridesForUser(userID).CompactorSchedulingWeight
+}
+
+// CompactorExemplarsRetentionPeriod returns the exemplars retention period for a given user.
+func (o *Overrides) CompactorExemplarsRetentionPeriod(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).CompactorExemplarsRetentionPeriod)
+}
+
 // CompactorSplitAndMergeShards returns the number of shards to use when splitting blocks.
 func (o *Overrides) CompactorSplitAndMergeShards(userID string) int {
 	return o.getOverridesForUser(userID).CompactorSplitAndMergeShards
@@ -860,6 +1125,11 @@ func (o *Overrides) MetricRelabelingEnabled(userID string) bool {
 	return o.getOverridesForUser(userID).MetricRelabelingEnabled
 }
 
+// LabelTransformations returns the label transformations to apply to the series of the given user.
+func (o *Overrides) LabelTransformations(userID string) []*LabelTransformation {
+	return o.getOverridesForUser(userID).LabelTransformations
+}
+
 // NativeHistogramsIngestionEnabled returns whether to ingest native histograms in the ingester
 func (o *Overrides) NativeHistogramsIngestionEnabled(userID string) bool {
 	return o.getOverridesForUser(userID).NativeHistogramsIngestionEnabled
@@ -24,6 +24,7 @@ import (
 	"gopkg.in/yaml.v3"
 
 	asmodel "github.com/grafana/mimir/pkg/ingester/activeseries/model"
+	"github.com/grafana/mimir/pkg/util"
 )
 
 func TestMain(m *testing.M) {
@@ -1040,6 +1041,32 @@ func TestCustomTrackerConfigDeserialize(t *testing.T) {
 	assert.Equal(t, expectedConfig.String(), overrides["user"].ActiveSeriesCustomTrackersConfig.String())
 }
 
+func TestCompactorSeriesRetentionPoliciesDeserialize(t *testing.T) {
+	cfg := `
+    user:
+        compactor_blocks_retention_period: 90d
+        compactor_series_retention_policies:
+          - selector: '{__name__=~"debug_.*"}'
+            period: 7d
+          - selector: '{env="prod", keep="true"}'
+            period: 0
+    `
+
+	overrides := map[string]*Limits{}
+	require.NoError(t, yaml.Unmarshal([]byte(cfg), &overrides), "parsing overrides")
+
+	ov, err := NewOverrides(Limits{}, NewMockTenantLimits(overrides))
+	require.NoError(t, err)
+
+	policies := ov.CompactorSeriesRetentionPolicies("user")
+	assert.Equal(t, 90*24*time.Hour, policies.DefaultPeriod)
+	require.Len(t, policies.Policies, 2)
+	assert.Equal(t, `__name__=~"debug_.*"`, util.MatchersStringer(policies.Policies[0].Matchers).String())
+	assert.Equal(t, 7*24*time.Hour, policies.Policies[0].Period)
+	assert.Equal(t, `env="prod",keep="true"`, util.MatchersStringer(policies.Policies[1].Matchers).String())
+	assert.Equal(t, time.Duration(0), policies.Policies[1].Period)
+}
+
 func TestUnmarshalYAML_ShouldValidateConfig(t *testing.T) {
 	tests := map[string]struct {
 		cfg         string
@@ -1052,6 +1079,13 @@ metric_relabel_configs:
 `,
 			expectedErr: "invalid metric_relabel_configs",
 		},
+		"should fail on invalid compactor_relabel_configs": {
+			cfg: `
+compactor_relabel_configs:
+  -
+`,
+			expectedErr: "invalid compactor_relabel_configs",
+		},
 		"should fail on negative max_estimated_fetched_chunks_per_query_multiplier": {
 			cfg:         `max_estimated_fetched_chunks_per_query_multiplier: -0.1`,
 			expectedErr: errInvalidMaxEstimatedChunksPerQueryMultiplier.Error(),
@@ -1076,6 +1110,240 @@ metric_relabel_configs:
 			cfg:         `ingest_storage_read_consistency: xyz`,
 			expectedErr: errInvalidIngestStorageReadConsistency.Error(),
 		},
+		"should pass on valid label_transformations": {
+			cfg: `
+label_transformations:
+  - name: lowercase_env
+    action: lowercase
+    label_names: [env]
+  - name: truncate
+    action: truncate
+    max_length: 256
+`,
+			expectedErr: "",
+		},
+		"should fail on label_transformations without name": {
+			cfg: `
+label_transformations:
+  - action: trim
+`,
+			expectedErr: errLabelTransformationMissingName.Error(),
+		},
+		"should fail on label_transformations with an invalid action": {
+			cfg: `
+label_transformations:
+  - name: invalid
+    action: uppercase
+`,
+			expectedErr: `label transformation "invalid" has an invalid action "uppercase"`,
+		},
+		"should fail on label_transformations with a too short max length": {
+			cfg: `
+label_transformations:
+  - name: truncate
+    action: truncate
+    max_length: 10
+`,
+			expectedErr: `label transformation "truncate" must have a max length greater than 17`,
+		},
+		"should fail on label_transformations with duplicate names": {
+			cfg: `
+label_transformations:
+  - name: trim
+    action: trim
+  - name: trim
+    action: lowercase
+`,
+			expectedErr: `duplicate label transformation name "trim"`,
+		},
+		"should pass on valid compactor_series_retention_policies": {
+			cfg: `
+compactor_series_retention_policies:
+  - selector: '{__name__=~"debug_.*"}'
+    period: 7d
+  - selector: '{env="prod"}'
+    period: 400d
+`,
+			expectedErr: "",
+		},
+		"should fail on compactor_series_retention_policies without selector": {
+			cfg: `
+compactor_series_retention_policies:
+  - period: 7d
+`,
+			expectedErr: errSeriesRetentionPolicyMissingSelector.Error(),
+		},
+		"should fail on compactor_series_retention_policies with an invalid selector": {
+			cfg: `
+compactor_series_retention_policies:
+  - selector: '{env=}'
+    period: 7d
+`,
+			expectedErr: `series retention policy has an invalid selector "{env=}"`,
+		},
+		"should pass on valid label_value_series_limits": {
+			cfg: `
+label_value_series_limits:
+  - name: path
+    metric_name: http_requests_total
+    label_name: path
+    max_values: 100
+  - name: pod
+    label_name: pod
+    max_series_per_value: 1000
+`,
+			expectedErr: "",
+		},
+		"should fail on label_value_series_limits without name": {
+			cfg: `
+label_value_series_limits:
+  - label_name: path
+    max_values: 100
+`,
+			expectedErr: errLabelValueSeriesLimitMissingName.Error(),
+		},
+		"should fail on label_value_series_limits without label name": {
+			cfg: `
+label_value_series_limits:
+  - name: path
+    max_values: 100
+`,
+			expectedErr: errLabelValueSeriesLimitMissingLabelName.Error(),
+		},
+		"should fail on label_value_series_limits limiting the metric name": {
+			cfg: `
+label_value_series_limits:
+  - name: metric
+    label_name: __name__
+    max_values: 100
+`,
+			expectedErr: "cannot limit the metric name label",
+		},
+		"should fail on label_value_series_limits with duplicate names": {
+			cfg: `
+label_value_series_limits:
+  - name: path
+    label_name: path
+    max_values: 100
+  - name: path
+    label_name: handler
+    max_values: 100
+`,
+			expectedErr: `duplicate label value series limit name "path"`,
+		},
+		"should pass on valid compactor_block_ranges": {
+			cfg: `
+compactor_block_ranges: [1h, 4h, 24h]
+`,
+			expectedErr: "",
+		},
+		"should fail on compactor_block_ranges not divisible by the previous one": {
+			cfg: `
+compactor_block_ranges: [2h, 5h]
+`,
+			expectedErr: "invalid compactor_block_ranges: each range period should be divisible by the previous one, but 5h0m0s is not divisible by 2h0m0s",
+		},
+		"should fail on compactor_block_ranges with a zero range": {
+			cfg: `
+compactor_block_ranges: [0s, 2h]
+`,
+			expectedErr: "invalid compactor_block_ranges: each range period should be greater than 0, but got 0s",
+		},
+		"should pass on compactor_block_ranges aligned to ingester_tsdb_block_range_period": {
+			cfg: `
+ingester_tsdb_block_range_period: 1h
+compactor_block_ranges: [2h, 12h]
+`,
+			expectedErr: "",
+		},
+		"should fail on compactor_block_ranges not aligned to ingester_tsdb_block_range_period": {
+			cfg: `
+ingester_tsdb_block_range_period: 3h
+compactor_block_ranges: [2h, 12h]
+`,
+			expectedErr: "invalid compactor_block_ranges: the first range period should be divisible by ingester_tsdb_block_range_period, but 2h0m0s is not divisible by 3h0m0s",
+		},
+		"should pass on ingester_tsdb_block_range_period whose TSDB head is queried from the ingesters": {
+			cfg: `
+ingester_tsdb_block_range_period: 8h
+query_ingesters_within: 12h
+`,
+			expectedErr: "",
+		},
+		"should fail on ingester_tsdb_block_range_period whose TSDB head is not queried from the ingesters": {
+			cfg: `
+ingester_tsdb_block_range_period: 12h
+query_ingesters_within: 13h
+`,
+			expectedErr: "invalid ingester_tsdb_block_range_period: the ingesters keep up to 18h (1.5 times the block range period) of samples in the TSDB head before shipping them, so query_ingesters_within 13h should not be shorter than that, otherwise queries might return partial results",
+		},
+		"should pass on ingester_tsdb_block_range_period when the ingesters are always queried": {
+			cfg: `
+ingester_tsdb_block_range_period: 12h
+query_ingesters_within: 0s
+`,
+			expectedErr: "",
+		},
+		"should fail on ingester_tsdb_retention_period not greater than ingester_tsdb_block_range_period": {
+			cfg: `
+ingester_tsdb_block_range_period: 2h
+ingester_tsdb_retention_period: 2h
+`,
+			expectedErr: "invalid ingester_tsdb_retention_period: the retention period 2h should be greater than ingester_tsdb_block_range_period 2h",
+		},
+		"should pass on retention periods not shorter than the downsampling periods": {
+			cfg: `
+compactor_blocks_retention_period: 30d
+compactor_blocks_retention_period_5m: 90d
+compactor_downsampling_5m_after: 2d
+compactor_downsampling_1h_after: 10d
+`,
+			expectedErr: "",
+		},
+		"should fail on raw blocks retention period shorter than compactor_downsampling_5m_after": {
+			cfg: `
+compactor_blocks_retention_period: 1d
+compactor_downsampling_5m_after: 2d
+`,
+			expectedErr: "invalid compactor_blocks_retention_period: the raw blocks retention period 1d, which is the longest period of compactor_blocks_retention_period and compactor_series_retention_policies, should not be shorter than compactor_downsampling_5m_after 2d",
+		},
+		"should pass on blocks retention period shorter than compactor_downsampling_5m_after when a series retention policy is longer": {
+			cfg: `
+compactor_blocks_retention_period: 1d
+compactor_series_retention_policies:
+  - selector: '{__name__="important"}'
+    period: 30d
+compactor_downsampling_5m_after: 2d
+`,
+			expectedErr: "",
+		},
+		"should fail on raw blocks retention period shorter than compactor_downsampling_5m_after when all the series retention policies are shorter": {
+			cfg: `
+compactor_blocks_retention_period: 1d
+compactor_series_retention_policies:
+  - selector: '{__name__="debug"}'
+    period: 12h
+compactor_downsampling_5m_after: 2d
+`,
+			expectedErr: "the raw blocks retention period 1d, which is the longest period of compactor_blocks_retention_period and compactor_series_retention_policies, should not be shorter",
+		},
+		"should fail on 5m resolution blocks retention period shorter than compactor_downsampling_1h_after": {
+			cfg: `
+compactor_blocks_retention_period: 30d
+compactor_blocks_retention_period_5m: 5d
+compactor_downsampling_5m_after: 2d
+compactor_downsampling_1h_after: 10d
+`,
+			expectedErr: "invalid compactor_blocks_retention_period_5m: the 5m resolution blocks retention period 5d should not be shorter than compactor_downsampling_1h_after 10d",
+		},
+		"should fail on raw blocks retention period shorter than compactor_downsampling_1h_after when the 5m retention period is not set": {
+			cfg: `
+compactor_blocks_retention_period: 5d
+compactor_downsampling_5m_after: 2d
+compactor_downsampling_1h_after: 10d
+`,
+			expectedErr: "invalid compactor_blocks_retention_period_5m: the 5m resolution blocks retention period 5d should not be shorter than compactor_downsampling_1h_after 10d",
+		},
 	}
 
 	for testName, testData := range tests {
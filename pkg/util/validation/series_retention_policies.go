@@ -0,0 +1,112 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package validation
+
+import (
+	"math"
+	"slices"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+)
+
+// SeriesRetentionPolicy keeps the series matching all the matchers for the retention period.
+type SeriesRetentionPolicy struct {
+	Matchers []*labels.Matcher
+
+	// Period is the retention period of the matching series. 0 to keep them forever.
+	Period time.Duration
+}
+
+// SeriesRetentionPolicies are the retention policies of the series of a tenant. The first policy matching
+// a series applies to it, and the series not matching any policy are kept for the default period.
+type SeriesRetentionPolicies struct {
+	Policies []SeriesRetentionPolicy
+
+	// DefaultPeriod is the retention period of the series not matching any policy. 0 to keep them forever.
+	DefaultPeriod time.Duration
+}
+
+// Enabled returns whether there's any retention policy.
+func (p SeriesRetentionPolicies) Enabled() bool {
+	return len(p.Policies) > 0
+}
+
+// Period returns the retention period of the series with the input labels. 0 if the series is kept forever.
+func (p SeriesRetentionPolicies) Period(lset labels.Labels) time.Duration {
+	for _, policy := range p.Policies {
+		if matchesAll(policy.Matchers, lset) {
+			return policy.Period
+		}
+	}
+	return p.DefaultPeriod
+}
+
+// MaxPeriod returns the longest retention period of any series, which is the retention period of the blocks.
+// 0 if some series are kept forever.
+func (p SeriesRetentionPolicies) MaxPeriod() time.Duration {
+	maxPeriod := p.DefaultPeriod
+	for _, policy := range p.Policies {
+		if policy.Period <= 0 || maxPeriod <= 0 {
+			return 0
+		}
+		maxPeriod = max(maxPeriod, policy.Period)
+	}
+	return maxPeriod
+}
+
+// MinPeriod returns the shortest retention period of any series. 0 if all the series are kept forever.
+func (p SeriesRetentionPolicies) MinPeriod() time.Duration {
+	var minPeriod time.Duration
+	for _, period := range append([]time.Duration{p.DefaultPeriod}, p.policyPeriods()...) {
+		if period > 0 && (minPeriod <= 0 || period < minPeriod) {
+			minPeriod = period
+		}
+	}
+	return minPeriod
+}
+
+// ShorterPeriods returns the sorted distinct retention periods shorter than the retention period of the blocks,
+// after which some series are removed from the blocks.
+func (p SeriesRetentionPolicies) ShorterPeriods() []time.Duration {
+	maxPeriod := p.MaxPeriod()
+
+	var periods []time.Duration
+	for _, period := range append([]time.Duration{p.DefaultPeriod}, p.policyPeriods()...) {
+		if period > 0 && (maxPeriod <= 0 || period < maxPeriod) && !slices.Contains(periods, period) {
+			periods = append(periods, period)
+		}
+	}
+	slices.Sort(periods)
+	return periods
+}
+
+func (p SeriesRetentionPolicies) policyPeriods() []time.Duration {
+	periods := make([]time.Duration, 0, len(p.Policies))
+	for _, policy := range p.Policies {
+		periods = append(periods, policy.Period)
+	}
+	return periods
+}
+
+// ExpiredBefore returns the timestamp (milliseconds) before which the samples of the series with the input labels
+// have expired at the given time. It returns math.MinInt64 if the series is kept forever.
+func (p SeriesRetentionPolicies) ExpiredBefore(lset labels.Labels, now time.Time) int64 {
+	return expiredBefore(p.Period(lset), now)
+}
+
+func expiredBefore(period time.Duration, now time.Time) int64 {
+	if period <= 0 {
+		return math.MinInt64
+	}
+	return now.Add(-period).UnixMilli()
+}
+
+func matchesAll(matchers []*labels.Matcher, lset labels.Labels) bool {
+	for _, m := range matchers {
+		if !m.Matches(lset.Get(m.Name)) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,55 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package validation
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeriesRetentionPolicies(t *testing.T) {
+	debug := SeriesRetentionPolicy{Matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, labels.MetricName, "debug_.*")}, Period: 7 * 24 * time.Hour}
+	prod := SeriesRetentionPolicy{Matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "env", "prod")}, Period: 400 * 24 * time.Hour}
+	forever := SeriesRetentionPolicy{Matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "keep", "true")}, Period: 0}
+
+	policies := SeriesRetentionPolicies{Policies: []SeriesRetentionPolicy{debug, prod}, DefaultPeriod: 90 * 24 * time.Hour}
+
+	t.Run("period", func(t *testing.T) {
+		assert.Equal(t, debug.Period, policies.Period(labels.FromStrings(labels.MetricName, "debug_requests")))
+		// The first matching policy applies.
+		assert.Equal(t, debug.Period, policies.Period(labels.FromStrings(labels.MetricName, "debug_requests", "env", "prod")))
+		assert.Equal(t, prod.Period, policies.Period(labels.FromStrings(labels.MetricName, "requests", "env", "prod")))
+		assert.Equal(t, policies.DefaultPeriod, policies.Period(labels.FromStrings(labels.MetricName, "requests", "env", "dev")))
+	})
+
+	t.Run("max period", func(t *testing.T) {
+		assert.Equal(t, prod.Period, policies.MaxPeriod())
+		assert.Equal(t, time.Duration(0), SeriesRetentionPolicies{Policies: []SeriesRetentionPolicy{debug, forever}, DefaultPeriod: time.Hour}.MaxPeriod())
+		assert.Equal(t, time.Duration(0), SeriesRetentionPolicies{Policies: []SeriesRetentionPolicy{debug}}.MaxPeriod())
+		assert.Equal(t, time.Hour, SeriesRetentionPolicies{DefaultPeriod: time.Hour}.MaxPeriod())
+	})
+
+	t.Run("min period", func(t *testing.T) {
+		assert.Equal(t, debug.Period, policies.MinPeriod())
+		assert.Equal(t, time.Hour, SeriesRetentionPolicies{Policies: []SeriesRetentionPolicy{debug, forever}, DefaultPeriod: time.Hour}.MinPeriod())
+		assert.Equal(t, time.Duration(0), SeriesRetentionPolicies{Policies: []SeriesRetentionPolicy{forever}}.MinPeriod())
+	})
+
+	t.Run("shorter periods", func(t *testing.T) {
+		assert.Equal(t, []time.Duration{debug.Period, policies.DefaultPeriod}, policies.ShorterPeriods())
+		assert.Equal(t, []time.Duration{time.Hour, debug.Period}, SeriesRetentionPolicies{Policies: []SeriesRetentionPolicy{debug, forever, debug}, DefaultPeriod: time.Hour}.ShorterPeriods())
+		assert.Empty(t, SeriesRetentionPolicies{Policies: []SeriesRetentionPolicy{prod}, DefaultPeriod: prod.Period}.ShorterPeriods())
+	})
+
+	t.Run("expired before", func(t *testing.T) {
+		now := time.Now()
+		assert.Equal(t, now.Add(-debug.Period).UnixMilli(), policies.ExpiredBefore(labels.FromStrings(labels.MetricName, "debug_requests"), now))
+
+		withForever := SeriesRetentionPolicies{Policies: []SeriesRetentionPolicy{forever}, DefaultPeriod: time.Hour}
+		assert.Equal(t, int64(math.MinInt64), withForever.ExpiredBefore(labels.FromStrings("keep", "true"), now))
+	})
+}
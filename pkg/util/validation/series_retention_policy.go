@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+var errSeriesRetentionPolicyMissingSelector = errors.New("series retention policy must have a selector")
+
+// SeriesRetentionPolicyConfig keeps the series of a tenant matching a selector for a retention period, overriding
+// the tenant's blocks retention period.
+type SeriesRetentionPolicyConfig struct {
+	// Selector is the series selector, like {__name__=~"debug_.*"}.
+	Selector string `yaml:"selector" json:"selector"`
+	// Period is the retention period of the matching series. 0 to keep them forever.
+	Period model.Duration `yaml:"period" json:"period"`
+
+	// matchers are parsed from the selector when the policy is validated.
+	matchers []*labels.Matcher
+}
+
+func (p *SeriesRetentionPolicyConfig) validate() error {
+	if p.Selector == "" {
+		return errSeriesRetentionPolicyMissingSelector
+	}
+	matchers, err := parser.ParseMetricSelector(p.Selector)
+	if err != nil {
+		return fmt.Errorf("series retention policy has an invalid selector %q: %w", p.Selector, err)
+	}
+	if p.Period < 0 {
+		return fmt.Errorf("series retention policy %q must not have a negative period", p.Selector)
+	}
+	p.matchers = matchers
+	return nil
+}
+
+func validateSeriesRetentionPolicies(policies []*SeriesRetentionPolicyConfig) error {
+	for _, p := range policies {
+		if p == nil {
+			return errors.New("invalid compactor_series_retention_policies")
+		}
+		if err := p.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// toSeriesRetentionPolicies returns the validated policies with their parsed selectors.
+func toSeriesRetentionPolicies(policies []*SeriesRetentionPolicyConfig, defaultPeriod time.Duration) SeriesRetentionPolicies {
+	out := SeriesRetentionPolicies{
+		Policies:      make([]SeriesRetentionPolicy, 0, len(policies)),
+		DefaultPeriod: defaultPeriod,
+	}
+	for _, p := range policies {
+		if p.matchers == nil {
+			// Policies are validated when loaded, so this should never happen.
+			continue
+		}
+		out.Policies = append(out.Policies, SeriesRetentionPolicy{Matchers: p.matchers, Period: time.Duration(p.Period)})
+	}
+	return out
+}
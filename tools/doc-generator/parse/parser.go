@@ -365,6 +365,12 @@ func getFieldCustomType(t reflect.Type) (string, bool) {
 		return "relabel_config...", true
 	case reflect.TypeOf([]*validation.BlockedQuery{}).String():
 		return "blocked_queries_config...", true
+	case reflect.TypeOf([]*validation.LabelValueSeriesLimit{}).String():
+		return "label_value_series_limits_config...", true
+	case reflect.TypeOf([]*validation.LabelTransformation{}).String():
+		return "label_transformations_config...", true
+	case reflect.TypeOf([]*validation.SeriesRetentionPolicyConfig{}).String():
+		return "series_retention_policies_config...", true
 	case reflect.TypeOf(asmodel.CustomTrackersConfig{}).String():
 		return "map of tracker name (string) to matcher (string)", true
 	default:
@@ -451,6 +457,12 @@ func getCustomFieldType(t reflect.Type) (string, bool) {
 		return "relabel_config...", true
 	case reflect.TypeOf([]*validation.BlockedQuery{}).String():
 		return "blocked_queries_config...", true
+	case reflect.TypeOf([]*validation.LabelValueSeriesLimit{}).String():
+		return "label_value_series_limits_config...", true
+	case reflect.TypeOf([]*validation.LabelTransformation{}).String():
+		return "label_transformations_config...", true
+	case reflect.TypeOf([]*validation.SeriesRetentionPolicyConfig{}).String():
+		return "series_retention_policies_config...", true
 	case reflect.TypeOf(asmodel.CustomTrackersConfig{}).String():
 		return "map of tracker name (string) to matcher (string)", true
 	default:
@@ -484,6 +496,12 @@ func ReflectType(typ string) reflect.Type {
 		return reflect.TypeOf([]*relabel.Config{})
 	case "blocked_queries_config...":
 		return reflect.TypeOf([]*validation.BlockedQuery{})
+	case "label_value_series_limits_config...":
+		return reflect.TypeOf([]*validation.LabelValueSeriesLimit{})
+	case "label_transformations_config...":
+		return reflect.TypeOf([]*validation.LabelTransformation{})
+	case "series_retention_policies_config...":
+		return reflect.TypeOf([]*validation.SeriesRetentionPolicyConfig{})
 	case "map of string to float64":
 		return reflect.TypeOf(validation.LimitsMap[float64]{})
 	case "map of string to int":